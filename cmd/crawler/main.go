@@ -6,6 +6,7 @@ import (
 
 	"github.com/benvon/sitemap-crawler/internal/config"
 	"github.com/benvon/sitemap-crawler/internal/crawler"
+	"github.com/benvon/sitemap-crawler/internal/output"
 	"github.com/sirupsen/logrus"
 )
 
@@ -32,6 +33,41 @@ func main() {
 		logger.SetLevel(logrus.InfoLevel)
 	}
 
+	// Colors follow --color; in auto mode only terminals without NO_COLOR
+	// get them. Quoting is disabled so colored values are not escaped.
+	colors := output.ColorEnabled(logger.Out, cfg.Color)
+	logger.SetFormatter(&logrus.TextFormatter{
+		ForceColors:   colors,
+		DisableColors: !colors,
+		DisableQuote:  colors,
+	})
+
+	if cfg.Command == config.CommandReportDiff {
+		if err := reportDiff(cfg); err != nil {
+			logger.WithError(err).Fatal("Report failed")
+		}
+		return
+	}
+
+	if cfg.Command == config.CommandReportMerge {
+		if err := reportMerge(cfg); err != nil {
+			logger.WithError(err).Fatal("Report failed")
+		}
+		return
+	}
+
+	if cfg.Command == config.CommandValidate {
+		if err := printValidation(cfg); err != nil {
+			logger.WithError(err).Fatal("Validation failed")
+		}
+		if len(cfg.Violations) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg.Version = version
+
 	// Log version information
 	logger.WithFields(logrus.Fields{
 		"version": version,
@@ -41,8 +77,68 @@ func main() {
 	}).Info("Starting sitemap crawler")
 
 	// Create and run crawler
-	c := crawler.New(cfg, logger)
+	c, err := crawler.New(cfg, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create crawler")
+	}
 	if err := c.Run(); err != nil {
 		logger.WithError(err).Fatal("Crawler failed")
 	}
 }
+
+// reportDiff compares two results files and prints the comparison to stdout
+func reportDiff(cfg *config.Config) error {
+	oldResults, err := output.LoadResults(cfg.CommandArgs[0])
+	if err != nil {
+		return err
+	}
+	newResults, err := output.LoadResults(cfg.CommandArgs[1])
+	if err != nil {
+		return err
+	}
+
+	diff := output.DiffResults(oldResults, newResults, output.DiffOptions{
+		LatencyRatio:    cfg.LatencyRegressionRatio,
+		LatencyMinDelta: cfg.LatencyRegressionMin,
+	})
+	_, err = fmt.Fprintln(os.Stdout, output.New(cfg.ReportFormat).FormatResultDiff(diff))
+	return err
+}
+
+// reportMerge combines results files, prints the aggregate report to stdout
+// and writes the merged results if a results file was given
+func reportMerge(cfg *config.Config) error {
+	files := make([]*output.ResultsFile, len(cfg.CommandArgs))
+	for i, path := range cfg.CommandArgs {
+		file, err := output.LoadResults(path)
+		if err != nil {
+			return err
+		}
+		files[i] = file
+	}
+
+	merged := output.MergeResults(cfg.CommandArgs, files)
+	if cfg.ResultsFile != "" {
+		format := "json"
+		if cfg.OutputFormat == "xml" {
+			format = "xml"
+		}
+		formatter := output.New(format)
+		if err := formatter.WriteToFile(cfg.ResultsFile, formatter.FormatSampledResults(merged.Results, merged.Sampling)); err != nil {
+			return fmt.Errorf("failed to write merged results file: %w", err)
+		}
+	}
+
+	_, err := fmt.Fprintln(os.Stdout, output.New(cfg.ReportFormat).FormatMergedResults(merged))
+	return err
+}
+
+// printValidation prints the problems the validate command found to stdout
+func printValidation(cfg *config.Config) error {
+	violations := make([]output.ConfigViolation, len(cfg.Violations))
+	for i, violation := range cfg.Violations {
+		violations[i] = output.ConfigViolation{Message: violation.Message, Flags: violation.Flags}
+	}
+	_, err := fmt.Fprintln(os.Stdout, output.New(cfg.ReportFormat).FormatValidation(cfg.ValidatedCommand, violations))
+	return err
+}
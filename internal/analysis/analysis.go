@@ -0,0 +1,295 @@
+// Package analysis runs page checks over crawled responses. Each HTML body
+// is parsed once and the parsed page is shared by every analyzer, so a new
+// check is added by implementing Analyzer rather than by reading and
+// tokenizing the body again.
+package analysis
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Built-in analyzer names, as given to --analyzers
+const (
+	MixedContentAnalyzer   = "mixed-content"
+	StructuredDataAnalyzer = "structured-data"
+	BrokenLinksAnalyzer    = "broken-links"
+)
+
+// Names lists the built-in analyzers
+var Names = []string{MixedContentAnalyzer, StructuredDataAnalyzer, BrokenLinksAnalyzer}
+
+// Finding is one problem an analyzer found on a page
+type Finding struct {
+	URL      string `json:"url"`
+	Analyzer string `json:"analyzer"`
+	Issue    string `json:"issue"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Analyzer checks crawled pages. Analyze is called from concurrent workers
+// for every response, including failed requests and responses that are not
+// HTML, so implementations must be safe for concurrent use and check what
+// the page carries.
+type Analyzer interface {
+	Name() string
+	Analyze(page *Page) []Finding
+}
+
+// Finisher is an analyzer with findings that depend on the whole crawl,
+// such as links to pages that failed. Finish is called once every page has
+// been analyzed.
+type Finisher interface {
+	Finish() []Finding
+}
+
+// Element is an HTML start tag, with the text it encloses for the elements
+// whose content analyzers read: title, script and style
+type Element struct {
+	Tag   string
+	Attrs map[string]string
+	Text  string
+}
+
+// Document is an HTML body parsed into the parts analyzers read
+type Document struct {
+	Title string
+
+	// Heading is the text of the first h1
+	Heading string
+
+	// Text is the text a visitor sees, without that of scripts and styles
+	Text     string
+	Elements []Element
+}
+
+// Page is a crawled response as analyzers see it
+type Page struct {
+	URL string
+
+	// StatusCode is zero when the request failed without a response
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Document is the parsed body; nil when there is no body to parse
+	Document *Document
+}
+
+// NewPage creates a page from a response and its (possibly truncated)
+// body, parsing the body if there is one
+func NewPage(pageURL string, resp *http.Response, body []byte) *Page {
+	page := &Page{URL: pageURL, StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+	if body != nil {
+		page.Document = Parse(body)
+	}
+	return page
+}
+
+// FailedPage creates a page for a request that failed without a response
+func FailedPage(pageURL string) *Page {
+	return &Page{URL: pageURL, Header: http.Header{}}
+}
+
+// Failed reports whether the request failed without a response
+func (p *Page) Failed() bool {
+	return p.StatusCode == 0
+}
+
+// Elements returns the page's elements with the tag
+func (p *Page) Elements(tag string) []Element {
+	if p.Document == nil {
+		return nil
+	}
+	var elements []Element
+	for _, element := range p.Document.Elements {
+		if element.Tag == tag {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// Resolve resolves a possibly relative reference against the page URL
+func (p *Page) Resolve(ref string) (*url.URL, bool) {
+	base, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, false
+	}
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, false
+	}
+	return base.ResolveReference(parsed), true
+}
+
+// Links returns the same-host http(s) links of the page's anchors, without
+// fragments
+func (p *Page) Links() []string {
+	base, err := url.Parse(p.URL)
+	if err != nil {
+		return nil
+	}
+
+	var links []string
+	for _, anchor := range p.Elements("a") {
+		href, ok := anchor.Attrs["href"]
+		if !ok {
+			continue
+		}
+		resolved, ok := p.Resolve(href)
+		if !ok || resolved.Host != base.Host || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+			continue
+		}
+		resolved.Fragment = ""
+		resolved.RawFragment = ""
+		links = append(links, resolved.String())
+	}
+	return links
+}
+
+// Parse walks an HTML body once and collects its elements, title, first
+// heading and text
+func Parse(body []byte) *Document {
+	doc := &Document{}
+	var text, heading strings.Builder
+	open := -1
+
+	// headings is 0 before the first h1, 1 inside it and 2 after it
+	headings := 0
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			doc.Text = text.String()
+			doc.Heading = strings.TrimSpace(heading.String())
+			return doc
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			element := Element{Tag: token.Data, Attrs: make(map[string]string, len(token.Attr))}
+			for _, attr := range token.Attr {
+				if _, ok := element.Attrs[attr.Key]; !ok {
+					element.Attrs[attr.Key] = attr.Val
+				}
+			}
+			doc.Elements = append(doc.Elements, element)
+			open = -1
+			if token.Type == html.StartTagToken && enclosesText(token.Data) {
+				open = len(doc.Elements) - 1
+			}
+			if token.Type == html.StartTagToken && token.Data == "h1" && headings == 0 {
+				headings = 1
+			}
+		case html.EndTagToken:
+			open = -1
+			if name, _ := tokenizer.TagName(); string(name) == "h1" && headings == 1 {
+				headings = 2
+			}
+		case html.TextToken:
+			content := string(tokenizer.Text())
+			if open >= 0 {
+				doc.Elements[open].Text += content
+				switch doc.Elements[open].Tag {
+				case "title":
+					doc.Title += content
+				case "script", "style":
+					continue
+				}
+			}
+			if headings == 1 {
+				heading.WriteString(content)
+			}
+			text.WriteString(content)
+		}
+	}
+}
+
+// enclosesText reports whether Parse keeps the text of an element
+func enclosesText(tag string) bool {
+	return tag == "title" || tag == "script" || tag == "style"
+}
+
+// Pipeline runs analyzers over each page and collects their findings
+type Pipeline struct {
+	analyzers []Analyzer
+
+	mu       sync.Mutex
+	findings []Finding
+}
+
+// NewPipeline creates a pipeline of analyzers, run in the order given
+func NewPipeline(analyzers ...Analyzer) *Pipeline {
+	return &Pipeline{analyzers: analyzers}
+}
+
+// Analyze runs every analyzer over a page
+func (p *Pipeline) Analyze(page *Page) {
+	var findings []Finding
+	for _, analyzer := range p.analyzers {
+		findings = append(findings, analyzer.Analyze(page)...)
+	}
+	if len(findings) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.findings = append(p.findings, findings...)
+}
+
+// Findings returns the findings of every page analyzed so far, and of the
+// analyzers that look at the whole crawl, sorted by URL. A page analyzed
+// more than once, e.g. in cache verification mode, is reported once.
+func (p *Pipeline) Findings() []Finding {
+	p.mu.Lock()
+	findings := append([]Finding(nil), p.findings...)
+	p.mu.Unlock()
+	for _, analyzer := range p.analyzers {
+		if finisher, ok := analyzer.(Finisher); ok {
+			findings = append(findings, finisher.Finish()...)
+		}
+	}
+
+	seen := make(map[Finding]bool, len(findings))
+	unique := make([]Finding, 0, len(findings))
+	for _, finding := range findings {
+		if !seen[finding] {
+			seen[finding] = true
+			unique = append(unique, finding)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Analyzer != b.Analyzer {
+			return a.Analyzer < b.Analyzer
+		}
+		if a.Issue != b.Issue {
+			return a.Issue < b.Issue
+		}
+		return a.Detail < b.Detail
+	})
+	return unique
+}
+
+// New creates the built-in analyzer with the name
+func New(name string) (Analyzer, bool) {
+	switch name {
+	case MixedContentAnalyzer:
+		return MixedContent{}, true
+	case StructuredDataAnalyzer:
+		return StructuredData{}, true
+	case BrokenLinksAnalyzer:
+		return NewBrokenLinks(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func htmlPage(pageURL string, status int, body string) *Page {
+	resp := &http.Response{StatusCode: status, Header: http.Header{"Content-Type": []string{"text/html"}}}
+	return NewPage(pageURL, resp, []byte(body))
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	doc := Parse([]byte(`<html><head><title>Pricing</title><script type="application/ld+json">{"@type":"Product"}</script>` +
+		`<link rel="canonical" href="/pricing" rel="alternate"></head><body><h1>Our <em>plans</em></h1><p>Plans</p><img src="/a.png"/>` +
+		`<h1>Second</h1></body></html>`))
+
+	assert.Equal(t, "Pricing", doc.Title)
+	assert.Equal(t, "Our plans", doc.Heading)
+	assert.Contains(t, doc.Text, "Plans")
+	assert.NotContains(t, doc.Text, "Product", "script text is not page text")
+	tags := make([]string, len(doc.Elements))
+	for i, element := range doc.Elements {
+		tags[i] = element.Tag
+	}
+	assert.Equal(t, []string{"html", "head", "title", "script", "link", "body", "h1", "em", "p", "img", "h1"}, tags)
+	assert.Equal(t, `{"@type":"Product"}`, doc.Elements[3].Text)
+	assert.Equal(t, "canonical", doc.Elements[4].Attrs["rel"], "the first of a repeated attribute wins")
+	assert.Empty(t, doc.Elements[8].Text, "only title, script and style keep their text")
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	page := htmlPage("https://example.com/page", http.StatusOK,
+		`<a href="/about">About</a><a href="https://example.com/contact#form">Contact</a>`+
+			`<a href="https://other.com/">Other</a><a href="mailto:me@example.com">Mail</a><a name="top">Top</a>`)
+	assert.Equal(t, []string{"https://example.com/about", "https://example.com/contact"}, page.Links())
+	assert.Nil(t, FailedPage("https://example.com/").Links())
+}
+
+func TestMixedContent(t *testing.T) {
+	t.Parallel()
+
+	body := `<link rel="stylesheet" href="http://cdn.example.com/site.css"><link rel="alternate" href="http://example.com/feed">` +
+		`<script src="//cdn.example.com/app.js"></script><img src="http://example.com/logo.png"><img src="/local.png">` +
+		`<a href="http://example.com/plain">Plain</a><object data="http://example.com/movie.swf"></object>`
+
+	findings := MixedContent{}.Analyze(htmlPage("https://example.com/", http.StatusOK, body))
+	details := make([]string, len(findings))
+	for i, finding := range findings {
+		assert.Equal(t, IssueMixedContent, finding.Issue)
+		assert.Equal(t, MixedContentAnalyzer, finding.Analyzer)
+		details[i] = finding.Detail
+	}
+	assert.Equal(t, []string{
+		"link http://cdn.example.com/site.css",
+		"img http://example.com/logo.png",
+		"object http://example.com/movie.swf",
+	}, details)
+
+	// Plain HTTP pages and pages without a body have nothing to mix
+	assert.Empty(t, MixedContent{}.Analyze(htmlPage("http://example.com/", http.StatusOK, body)))
+	assert.Empty(t, MixedContent{}.Analyze(FailedPage("https://example.com/")))
+}
+
+func TestStructuredData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []Finding
+	}{
+		{
+			name: "valid",
+			body: `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Product"}</script>`,
+		},
+		{
+			name: "graph takes the block's context",
+			body: `<script type="application/ld+json">{"@context":"https://schema.org","@graph":[{"@type":"Product"},{"name":"x"}]}</script>`,
+			expected: []Finding{
+				{Issue: IssueMissingType, Detail: "block 1: item 2"},
+			},
+		},
+		{
+			name: "array items",
+			body: `<script type="application/ld+json">[{"@context":"https://schema.org","@type":"Product"},{"@type":"Offer"}]</script>`,
+			expected: []Finding{
+				{Issue: IssueMissingContext, Detail: "block 1: item 2"},
+			},
+		},
+		{
+			name: "invalid JSON in the second block",
+			body: `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Product"}</script>` +
+				`<script type="text/javascript">var x = {</script><script type="application/ld+json">{"@type":}</script>`,
+			expected: []Finding{
+				{Issue: IssueInvalidStructuredData, Detail: "block 2: invalid character '}' looking for beginning of value"},
+			},
+		},
+		{
+			name: "no structured data",
+			body: `<p>Plain page</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			findings := StructuredData{}.Analyze(htmlPage("https://example.com/", http.StatusOK, tt.body))
+			for i := range tt.expected {
+				tt.expected[i].URL = "https://example.com/"
+				tt.expected[i].Analyzer = StructuredDataAnalyzer
+			}
+			assert.Equal(t, tt.expected, findings)
+		})
+	}
+}
+
+func TestBrokenLinks(t *testing.T) {
+	t.Parallel()
+
+	links := NewBrokenLinks()
+	pipeline := NewPipeline(links)
+	pipeline.Analyze(htmlPage("https://example.com/", http.StatusOK,
+		`<a href="/gone">Gone</a><a href="/down#top">Down</a><a href="/moved">Moved</a><a href="/unlisted">Unlisted</a><a href="/">Home</a>`))
+	pipeline.Analyze(htmlPage("https://example.com/about", http.StatusOK, `<a href="/gone">Gone</a>`))
+	pipeline.Analyze(htmlPage("https://example.com/gone", http.StatusNotFound, `<a href="/">Home</a>`))
+	pipeline.Analyze(htmlPage("https://example.com/moved", http.StatusMovedPermanently, ""))
+	pipeline.Analyze(FailedPage("https://example.com/down"))
+
+	assert.Equal(t, []Finding{
+		{URL: "https://example.com/", Analyzer: BrokenLinksAnalyzer, Issue: IssueBrokenLink, Detail: "https://example.com/down (request failed)"},
+		{URL: "https://example.com/", Analyzer: BrokenLinksAnalyzer, Issue: IssueBrokenLink, Detail: "https://example.com/gone (HTTP 404)"},
+		{URL: "https://example.com/about", Analyzer: BrokenLinksAnalyzer, Issue: IssueBrokenLink, Detail: "https://example.com/gone (HTTP 404)"},
+	}, pipeline.Findings())
+}
+
+// countingAnalyzer counts the pages it sees and reports each HTML one
+type countingAnalyzer struct {
+	mu    sync.Mutex
+	pages int
+}
+
+func (a *countingAnalyzer) Name() string { return "counting" }
+
+func (a *countingAnalyzer) Analyze(page *Page) []Finding {
+	a.mu.Lock()
+	a.pages++
+	a.mu.Unlock()
+	if page.Document == nil {
+		return nil
+	}
+	return []Finding{{URL: page.URL, Analyzer: a.Name(), Issue: "seen", Detail: page.Document.Title}}
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	plugin := &countingAnalyzer{}
+	pipeline := NewPipeline(plugin, MixedContent{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			pipeline.Analyze(htmlPage("https://example.com/b", http.StatusOK, `<title>B</title><img src="http://example.com/b.png">`))
+		})
+	}
+	wg.Wait()
+	pipeline.Analyze(htmlPage("https://example.com/a", http.StatusOK, `<title>A</title>`))
+	pipeline.Analyze(FailedPage("https://example.com/c"))
+
+	// Pages analyzed more than once are reported once, sorted by URL
+	assert.Equal(t, 12, plugin.pages)
+	findings := pipeline.Findings()
+	require.Len(t, findings, 3)
+	assert.Equal(t, Finding{URL: "https://example.com/a", Analyzer: "counting", Issue: "seen", Detail: "A"}, findings[0])
+	assert.Equal(t, Finding{URL: "https://example.com/b", Analyzer: "counting", Issue: "seen", Detail: "B"}, findings[1])
+	assert.Equal(t, MixedContentAnalyzer, findings[2].Analyzer)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range Names {
+		analyzer, ok := New(name)
+		require.True(t, ok, name)
+		assert.Equal(t, name, analyzer.Name())
+	}
+	_, ok := New("spelling")
+	assert.False(t, ok)
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// IssueBrokenLink is reported for a page that links to a crawled URL that
+// failed
+const IssueBrokenLink = "broken_link"
+
+// BrokenLinks reports links between crawled pages whose target failed with
+// an error status or no response. Links to URLs the crawl did not request
+// are not checked.
+type BrokenLinks struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	links    map[string]map[string]bool
+}
+
+// NewBrokenLinks creates a broken links analyzer
+func NewBrokenLinks() *BrokenLinks {
+	return &BrokenLinks{
+		statuses: make(map[string]int),
+		links:    make(map[string]map[string]bool),
+	}
+}
+
+// Name returns the analyzer name
+func (b *BrokenLinks) Name() string {
+	return BrokenLinksAnalyzer
+}
+
+// Analyze records the page's status and links. The links are checked by
+// Finish, once the status of every target is known.
+func (b *BrokenLinks) Analyze(page *Page) []Finding {
+	links := page.Links()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	source := withoutFragment(page.URL)
+	b.statuses[source] = page.StatusCode
+	for _, link := range links {
+		if link == source {
+			continue
+		}
+		if b.links[link] == nil {
+			b.links[link] = make(map[string]bool)
+		}
+		b.links[link][source] = true
+	}
+	return nil
+}
+
+// Finish reports each link to a target that failed, on the linking page
+func (b *BrokenLinks) Finish() []Finding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var findings []Finding
+	for target, sources := range b.links {
+		status, crawled := b.statuses[target]
+		if !crawled || (status != 0 && status < 400) {
+			continue
+		}
+		detail := fmt.Sprintf("%s (HTTP %d)", target, status)
+		if status == 0 {
+			detail = target + " (request failed)"
+		}
+		for source := range sources {
+			findings = append(findings, Finding{
+				URL:      source,
+				Analyzer: BrokenLinksAnalyzer,
+				Issue:    IssueBrokenLink,
+				Detail:   detail,
+			})
+		}
+	}
+	return findings
+}
+
+// withoutFragment strips the fragment of a URL so that it matches the links
+// pointing at it
+func withoutFragment(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import "strings"
+
+// IssueMixedContent is reported for an HTTPS page that loads a resource
+// over plain HTTP
+const IssueMixedContent = "mixed_content"
+
+// subresourceAttrs maps the elements that load a resource to the attribute
+// holding its URL. Anchors are navigation, not subresources, and are left
+// out.
+var subresourceAttrs = map[string]string{
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"audio":  "src",
+	"video":  "src",
+	"source": "src",
+	"track":  "src",
+	"embed":  "src",
+	"object": "data",
+	"link":   "href",
+}
+
+// MixedContent reports HTTPS pages that load scripts, styles, images and
+// other subresources over HTTP, which browsers block or warn about
+type MixedContent struct{}
+
+// Name returns the analyzer name
+func (MixedContent) Name() string {
+	return MixedContentAnalyzer
+}
+
+// Analyze reports each HTTP subresource of an HTTPS page
+func (MixedContent) Analyze(page *Page) []Finding {
+	if page.Document == nil || !strings.HasPrefix(strings.ToLower(page.URL), "https://") {
+		return nil
+	}
+
+	var findings []Finding
+	for _, element := range page.Document.Elements {
+		attr, ok := subresourceAttrs[element.Tag]
+		if !ok || (element.Tag == "link" && !loadsResource(element.Attrs["rel"])) {
+			continue
+		}
+		ref, ok := element.Attrs[attr]
+		if !ok {
+			continue
+		}
+		resolved, ok := page.Resolve(ref)
+		if !ok || resolved.Scheme != "http" {
+			continue
+		}
+		findings = append(findings, Finding{
+			URL:      page.URL,
+			Analyzer: MixedContentAnalyzer,
+			Issue:    IssueMixedContent,
+			Detail:   element.Tag + " " + resolved.String(),
+		})
+	}
+	return findings
+}
+
+// loadsResource reports whether a link element's rel makes the browser
+// fetch its href with the page
+func loadsResource(rel string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "stylesheet", "icon", "preload", "modulepreload", "manifest":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Structured data issues
+const (
+	IssueInvalidStructuredData = "invalid_structured_data"
+	IssueMissingContext        = "structured_data_missing_context"
+	IssueMissingType           = "structured_data_missing_type"
+)
+
+// StructuredData checks the JSON-LD blocks of a page: each must be valid
+// JSON, and each item must name its @context and @type, without which
+// search engines ignore it
+type StructuredData struct{}
+
+// Name returns the analyzer name
+func (StructuredData) Name() string {
+	return StructuredDataAnalyzer
+}
+
+// Analyze reports the problems of each JSON-LD block, numbered from 1 in
+// page order
+func (StructuredData) Analyze(page *Page) []Finding {
+	var findings []Finding
+	block := 0
+	for _, script := range page.Elements("script") {
+		if !strings.EqualFold(strings.TrimSpace(script.Attrs["type"]), "application/ld+json") {
+			continue
+		}
+		block++
+		finding := func(issue, detail string) {
+			findings = append(findings, Finding{
+				URL:      page.URL,
+				Analyzer: StructuredDataAnalyzer,
+				Issue:    issue,
+				Detail:   fmt.Sprintf("block %d: %s", block, detail),
+			})
+		}
+
+		var data interface{}
+		if err := json.Unmarshal([]byte(script.Text), &data); err != nil {
+			finding(IssueInvalidStructuredData, err.Error())
+			continue
+		}
+		for i, item := range structuredItems(data) {
+			if _, ok := item["@context"]; !ok {
+				finding(IssueMissingContext, fmt.Sprintf("item %d", i+1))
+			}
+			if _, ok := item["@type"]; !ok {
+				finding(IssueMissingType, fmt.Sprintf("item %d", i+1))
+			}
+		}
+	}
+	return findings
+}
+
+// structuredItems returns the top-level items of a JSON-LD block: the
+// block itself, the elements of an array, or the nodes of an @graph, which
+// take the block's @context
+func structuredItems(data interface{}) []map[string]interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		graph, ok := value["@graph"].([]interface{})
+		if !ok {
+			return []map[string]interface{}{value}
+		}
+		var items []map[string]interface{}
+		for _, node := range structuredItems(graph) {
+			if _, ok := node["@context"]; !ok && value["@context"] != nil {
+				node["@context"] = value["@context"]
+			}
+			items = append(items, node)
+		}
+		return items
+	case []interface{}:
+		var items []map[string]interface{}
+		for _, element := range value {
+			if item, ok := element.(map[string]interface{}); ok {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return nil
+}
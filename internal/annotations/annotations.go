@@ -0,0 +1,168 @@
+package annotations
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/output"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// MaxFailureAnnotations caps the per-URL annotations. GitHub shows at most
+// ten error annotations per step, so the rest are summarized.
+const MaxFailureAnnotations = 10
+
+// Annotation levels understood by GitHub Actions
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Annotation is a GitHub Actions workflow command that surfaces a message on
+// the checks page
+type Annotation struct {
+	Level   string
+	Title   string
+	Message string
+}
+
+// String renders the annotation as a workflow command
+func (a Annotation) String() string {
+	return fmt.Sprintf("::%s title=%s::%s", a.Level, escapeProperty(a.Title), escapeData(a.Message))
+}
+
+// Summary holds the crawl outcome checked against the rate thresholds
+type Summary struct {
+	Final     *stats.FinalStats
+	Cache     *stats.CacheStats
+	Cancelled bool
+
+	// CancelReason names the rule that cancelled the crawl
+	CancelReason string
+}
+
+// Collector accumulates failed results from the crawl
+type Collector struct {
+	mu       sync.Mutex
+	failures []stats.Result
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Add records a result; successful results are ignored
+func (c *Collector) Add(result *stats.Result) {
+	if result.Success {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = append(c.failures, *result)
+}
+
+// Annotations returns error annotations for failed URLs followed by
+// annotations for a cancelled crawl and breached rate thresholds
+func (c *Collector) Annotations(summary Summary) []Annotation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var annotations []Annotation
+	for i, failure := range c.failures {
+		if i == MaxFailureAnnotations {
+			annotations = append(annotations, Annotation{
+				Level:   LevelWarning,
+				Title:   "More failed URLs",
+				Message: fmt.Sprintf("%d more failed URLs were not annotated", len(c.failures)-MaxFailureAnnotations),
+			})
+			break
+		}
+		annotations = append(annotations, failureAnnotation(failure))
+	}
+
+	if summary.Cancelled {
+		message := "The crawl was cancelled after too many 403 errors"
+		if summary.CancelReason != "" {
+			message = "The crawl was cancelled due to " + summary.CancelReason
+		}
+		annotations = append(annotations, Annotation{
+			Level:   LevelError,
+			Title:   "Crawl cancelled",
+			Message: message,
+		})
+	}
+
+	if summary.Final != nil && summary.Final.TotalProcessed > 0 {
+		if annotation, ok := rateAnnotation("Success rate", summary.Final.SuccessRate, output.SuccessRateGood, output.SuccessRateWarn); ok {
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	if summary.Cache != nil && summary.Cache.CacheHits+summary.Cache.CacheMisses > 0 {
+		if annotation, ok := rateAnnotation("Cache hit rate", summary.Cache.CacheHitRate, output.HitRateGood, output.HitRateWarn); ok {
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	return annotations
+}
+
+// Write writes annotations one per line
+func Write(w io.Writer, annotations []Annotation) error {
+	for _, annotation := range annotations {
+		if _, err := fmt.Fprintln(w, annotation.String()); err != nil {
+			return fmt.Errorf("failed to write annotation: %w", err)
+		}
+	}
+	return nil
+}
+
+// failureAnnotation describes a failed URL
+func failureAnnotation(result stats.Result) Annotation {
+	var message string
+	switch {
+	case result.Error != "" && result.ErrorCategory != "":
+		message = fmt.Sprintf("%s failed (%s): %s", result.URL, result.ErrorCategory, result.Error)
+	case result.Error != "":
+		message = fmt.Sprintf("%s failed: %s", result.URL, result.Error)
+	default:
+		message = fmt.Sprintf("%s returned status %d", result.URL, result.StatusCode)
+	}
+
+	return Annotation{Level: LevelError, Title: "Request failed", Message: message}
+}
+
+// rateAnnotation reports a rate below the good threshold: an error under the
+// warning threshold and a warning otherwise
+func rateAnnotation(name string, rate, good, warn float64) (Annotation, bool) {
+	switch {
+	case rate >= good:
+		return Annotation{}, false
+	case rate >= warn:
+		return Annotation{
+			Level:   LevelWarning,
+			Title:   name + " below target",
+			Message: fmt.Sprintf("%s %.1f%% is below the %.1f%% target", name, rate, good),
+		}, true
+	default:
+		return Annotation{
+			Level:   LevelError,
+			Title:   name + " below threshold",
+			Message: fmt.Sprintf("%s %.1f%% is below the %.1f%% threshold", name, rate, warn),
+		}, true
+	}
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,105 @@
+package annotations
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationString(t *testing.T) {
+	t.Parallel()
+
+	annotation := Annotation{Level: LevelError, Title: "a: b, c", Message: "100% down\nretry"}
+	assert.Equal(t, "::error title=a%3A b%2C c::100%25 down%0Aretry", annotation.String())
+}
+
+func TestFailureAnnotations(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector()
+	collector.Add(&stats.Result{URL: "https://example.com/ok", Success: true, StatusCode: 200})
+	collector.Add(&stats.Result{URL: "https://example.com/missing", StatusCode: 404, ErrorCategory: stats.ErrorHTTPStatus})
+	collector.Add(&stats.Result{URL: "https://example.com/slow", Error: "deadline exceeded", ErrorCategory: stats.ErrorTimeout})
+
+	annotations := collector.Annotations(Summary{})
+	require.Len(t, annotations, 2)
+	assert.Equal(t, "::error title=Request failed::https://example.com/missing returned status 404", annotations[0].String())
+	assert.Equal(t, "::error title=Request failed::https://example.com/slow failed (timeout): deadline exceeded", annotations[1].String())
+}
+
+func TestFailureAnnotationsCapped(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector()
+	for i := 0; i < MaxFailureAnnotations+5; i++ {
+		collector.Add(&stats.Result{URL: fmt.Sprintf("https://example.com/%d", i), StatusCode: 500})
+	}
+
+	annotations := collector.Annotations(Summary{})
+	require.Len(t, annotations, MaxFailureAnnotations+1)
+	last := annotations[MaxFailureAnnotations]
+	assert.Equal(t, LevelWarning, last.Level)
+	assert.Equal(t, "5 more failed URLs were not annotated", last.Message)
+}
+
+func TestThresholdAnnotations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		summary  Summary
+		expected []string
+	}{
+		{
+			name:    "healthy crawl",
+			summary: Summary{Final: &stats.FinalStats{TotalProcessed: 100, SuccessRate: 100}},
+		},
+		{
+			name:     "success rate below target",
+			summary:  Summary{Final: &stats.FinalStats{TotalProcessed: 100, SuccessRate: 97}},
+			expected: []string{"::warning title=Success rate below target::Success rate 97.0%25 is below the 99.0%25 target"},
+		},
+		{
+			name:     "success rate below threshold",
+			summary:  Summary{Final: &stats.FinalStats{TotalProcessed: 100, SuccessRate: 50}},
+			expected: []string{"::error title=Success rate below threshold::Success rate 50.0%25 is below the 95.0%25 threshold"},
+		},
+		{
+			name: "cache hit rate below threshold",
+			summary: Summary{
+				Final: &stats.FinalStats{TotalProcessed: 100, SuccessRate: 100},
+				Cache: &stats.CacheStats{CacheHits: 1, CacheMisses: 3, CacheHitRate: 25},
+			},
+			expected: []string{"::error title=Cache hit rate below threshold::Cache hit rate 25.0%25 is below the 70.0%25 threshold"},
+		},
+		{
+			name:     "cancelled crawl",
+			summary:  Summary{Cancelled: true},
+			expected: []string{"::error title=Crawl cancelled::The crawl was cancelled after too many 403 errors"},
+		},
+		{
+			name:     "cancelled by rule",
+			summary:  Summary{Cancelled: true, CancelReason: "cancel rule \"status=401,count=3\": 3 401 responses"},
+			expected: []string{"::error title=Crawl cancelled::The crawl was cancelled due to cancel rule \"status=401,count=3\": 3 401 responses"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buffer bytes.Buffer
+			require.NoError(t, Write(&buffer, NewCollector().Annotations(tt.summary)))
+
+			expected := ""
+			for _, line := range tt.expected {
+				expected += line + "\n"
+			}
+			assert.Equal(t, expected, buffer.String())
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+)
+
+// Issue names reported in page reports
+const (
+	IssueHTTPError         = "http_error"
+	IssueRedirect          = "redirect"
+	IssueCanonicalMismatch = "canonical_mismatch"
+	IssueNoindex           = "noindex"
+	IssueSoftNotFound      = "soft_404"
+)
+
+// minSoftNotFoundBodyBytes is the body size below which a 200 HTML response
+// is treated as a likely soft 404
+const minSoftNotFoundBodyBytes = 512
+
+var softNotFoundPhrases = []string{"page not found", "404 not found", "not be found", "no longer available"}
+
+// PageReport represents the consolidated SEO findings for a single URL
+type PageReport struct {
+	URL            string   `json:"url"`
+	StatusCode     int      `json:"status_code"`
+	RedirectTarget string   `json:"redirect_target,omitempty"`
+	Canonical      string   `json:"canonical,omitempty"`
+	Noindex        bool     `json:"noindex"`
+	SoftNotFound   bool     `json:"soft_404"`
+	Indexable      bool     `json:"indexable"`
+	Issues         []string `json:"issues"`
+
+	// Variants holds the probed trailing-slash and case variants of the URL
+	// when it was sampled for variant probing
+	Variants []VariantReport `json:"variants,omitempty"`
+}
+
+// InspectPage produces a page report from an analyzed page
+func InspectPage(page *analysis.Page) PageReport {
+	if page.Failed() {
+		return FailedPage(page.URL)
+	}
+
+	report := PageReport{
+		URL:        page.URL,
+		StatusCode: page.StatusCode,
+		Issues:     make([]string, 0),
+	}
+
+	if page.StatusCode >= 300 && page.StatusCode < 400 {
+		report.RedirectTarget = resolveReference(page.URL, page.Header.Get("Location"))
+		report.Issues = append(report.Issues, IssueRedirect)
+	} else if page.StatusCode >= 400 {
+		report.Issues = append(report.Issues, IssueHTTPError)
+	}
+
+	canonical, robotsMeta := tagSignals(page)
+	report.Noindex = hasNoindex(page.Header.Get("X-Robots-Tag")) || hasNoindex(robotsMeta)
+	if report.Noindex {
+		report.Issues = append(report.Issues, IssueNoindex)
+	}
+
+	if canonical != "" {
+		report.Canonical = resolveReference(page.URL, canonical)
+		if !sameURL(report.Canonical, page.URL) {
+			report.Issues = append(report.Issues, IssueCanonicalMismatch)
+		}
+	}
+
+	if page.StatusCode == http.StatusOK && isSoftNotFound(page.Document, len(page.Body)) {
+		report.SoftNotFound = true
+		report.Issues = append(report.Issues, IssueSoftNotFound)
+	}
+
+	report.Indexable = len(report.Issues) == 0
+	return report
+}
+
+// FailedPage produces a page report for a URL whose request failed without a response
+func FailedPage(pageURL string) PageReport {
+	return PageReport{
+		URL:    pageURL,
+		Issues: []string{IssueHTTPError},
+	}
+}
+
+// tagSignals returns the page's first canonical link and its robots meta
+// directives
+func tagSignals(page *analysis.Page) (canonical, robotsMeta string) {
+	for _, link := range page.Elements("link") {
+		if strings.EqualFold(link.Attrs["rel"], "canonical") {
+			canonical = strings.TrimSpace(link.Attrs["href"])
+			break
+		}
+	}
+	for _, meta := range page.Elements("meta") {
+		name := strings.ToLower(meta.Attrs["name"])
+		if name == "robots" || name == "googlebot" {
+			robotsMeta += "," + meta.Attrs["content"]
+		}
+	}
+	return canonical, robotsMeta
+}
+
+// hasNoindex reports whether a robots directive list contains noindex or none
+func hasNoindex(directives string) bool {
+	for _, directive := range strings.FieldsFunc(strings.ToLower(directives), func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		if directive == "noindex" || directive == "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// isSoftNotFound applies heuristics for error pages served with a 200 status.
+// Phrases are looked for only in the title and first heading, since a real
+// page may mention an item that is no longer available in its content.
+func isSoftNotFound(doc *analysis.Document, bodySize int) bool {
+	if doc == nil {
+		doc = &analysis.Document{}
+	}
+	if bodySize > 0 && bodySize < minSoftNotFoundBodyBytes && strings.TrimSpace(doc.Text) == "" {
+		return true
+	}
+
+	haystack := strings.ToLower(doc.Title + " " + doc.Heading)
+	for _, phrase := range softNotFoundPhrases {
+		if strings.Contains(haystack, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveReference resolves a possibly relative reference against the page URL
+func resolveReference(pageURL, ref string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+	parsedRef, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(parsedRef).String()
+}
+
+// sameURL compares two URLs ignoring fragments and a trailing slash on the path
+func sameURL(a, b string) bool {
+	normalize := func(raw string) string {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return raw
+		}
+		parsed.Fragment = ""
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+		return strings.ToLower(parsed.Scheme) + "://" + strings.ToLower(parsed.Host) + parsed.Path + "?" + parsed.RawQuery
+	}
+	return normalize(a) == normalize(b)
+}
+
+// Collector accumulates page reports from concurrent workers
+type Collector struct {
+	mu    sync.Mutex
+	pages map[string]PageReport
+}
+
+// NewCollector creates a new audit collector
+func NewCollector() *Collector {
+	return &Collector{
+		pages: make(map[string]PageReport),
+	}
+}
+
+// Add records a page report, replacing any earlier report for the same URL
+func (c *Collector) Add(report PageReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pages[report.URL] = report
+}
+
+// AddVariants records the probed variants of a URL on its page report
+func (c *Collector) AddVariants(pageURL string, variants []VariantReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if report, ok := c.pages[pageURL]; ok {
+		report.Variants = variants
+		c.pages[pageURL] = report
+	}
+}
+
+// Name returns the analyzer name of the audit
+func (c *Collector) Name() string {
+	return "audit"
+}
+
+// Analyze records the page's report and returns its issues as findings.
+// Indexable pages have none.
+func (c *Collector) Analyze(page *analysis.Page) []analysis.Finding {
+	report := InspectPage(page)
+	c.Add(report)
+
+	findings := make([]analysis.Finding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		finding := analysis.Finding{URL: report.URL, Analyzer: c.Name(), Issue: issue}
+		switch issue {
+		case IssueRedirect:
+			finding.Detail = report.RedirectTarget
+		case IssueCanonicalMismatch:
+			finding.Detail = report.Canonical
+		case IssueHTTPError:
+			if report.StatusCode != 0 {
+				finding.Detail = "HTTP " + strconv.Itoa(report.StatusCode)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// Reports returns all page reports sorted by URL
+func (c *Collector) Reports() []PageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reports := make([]PageReport, 0, len(c.pages))
+	for _, report := range c.pages {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].URL < reports[j].URL
+	})
+	return reports
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+const pageURL = "https://example.com/page"
+
+func newResponse(statusCode int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: statusCode, Header: make(http.Header)}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	longContent := "<p>Welcome to our product page with plenty of useful content for visitors. " +
+		"This paragraph exists to make the document large enough that it is not mistaken for an empty shell. " +
+		"It describes features, pricing, and support options in detail so the heuristics see real text. " +
+		"More words follow here to pad the body comfortably beyond the minimum size threshold for soft 404s. " +
+		"And a final sentence rounds out the content so the page reads like a genuine landing page.</p>"
+
+	tests := []struct {
+		name           string
+		statusCode     int
+		headers        map[string]string
+		body           string
+		expectedIssues []string
+		indexable      bool
+	}{
+		{
+			name:           "indexable page",
+			statusCode:     http.StatusOK,
+			body:           `<html><head><title>Product</title><link rel="canonical" href="/page/"></head><body>` + longContent + `</body></html>`,
+			expectedIssues: []string{},
+			indexable:      true,
+		},
+		{
+			name:           "redirect",
+			statusCode:     http.StatusMovedPermanently,
+			headers:        map[string]string{"Location": "/new-page"},
+			expectedIssues: []string{IssueRedirect},
+		},
+		{
+			name:           "http error",
+			statusCode:     http.StatusNotFound,
+			expectedIssues: []string{IssueHTTPError},
+		},
+		{
+			name:           "canonical mismatch",
+			statusCode:     http.StatusOK,
+			body:           `<html><head><link rel="canonical" href="https://example.com/other"></head><body>` + longContent + `</body></html>`,
+			expectedIssues: []string{IssueCanonicalMismatch},
+		},
+		{
+			name:           "noindex meta tag",
+			statusCode:     http.StatusOK,
+			body:           `<html><head><meta name="robots" content="noindex, follow"></head><body>` + longContent + `</body></html>`,
+			expectedIssues: []string{IssueNoindex},
+		},
+		{
+			name:           "noindex header",
+			statusCode:     http.StatusOK,
+			headers:        map[string]string{"X-Robots-Tag": "none"},
+			body:           `<html><body>` + longContent + `</body></html>`,
+			expectedIssues: []string{IssueNoindex},
+		},
+		{
+			name:           "soft 404 by title",
+			statusCode:     http.StatusOK,
+			body:           `<html><head><title>Page Not Found</title></head><body>` + longContent + `</body></html>`,
+			expectedIssues: []string{IssueSoftNotFound},
+		},
+		{
+			name:           "soft 404 by heading",
+			statusCode:     http.StatusOK,
+			body:           `<html><head><title>Example Store</title></head><body><h1>Sorry, this page could not be found</h1>` + longContent + `</body></html>`,
+			expectedIssues: []string{IssueSoftNotFound},
+		},
+		{
+			name:       "product page mentioning an unavailable item",
+			statusCode: http.StatusOK,
+			body: `<html><head><title>Blue Kettle</title></head><body><h1>Blue Kettle</h1>` + longContent +
+				`<p>The red kettle is no longer available. A past order could not be found? Contact support.</p></body></html>`,
+			expectedIssues: []string{},
+			indexable:      true,
+		},
+		{
+			name:       "phrase inside a script",
+			statusCode: http.StatusOK,
+			body: `<html><head><title>Blue Kettle</title><script>var messages = {missing: "Page not found"};</script></head><body>` +
+				longContent + `</body></html>`,
+			expectedIssues: []string{},
+			indexable:      true,
+		},
+		{
+			name:           "soft 404 by empty shell",
+			statusCode:     http.StatusOK,
+			body:           `<html><body><div id="app"></div></body></html>`,
+			expectedIssues: []string{IssueSoftNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			report := InspectPage(analysis.NewPage(pageURL, newResponse(tt.statusCode, tt.headers), []byte(tt.body)))
+			assert.Equal(t, tt.expectedIssues, report.Issues)
+			assert.Equal(t, tt.indexable, report.Indexable)
+		})
+	}
+}
+
+func TestInspectRedirectTarget(t *testing.T) {
+	t.Parallel()
+
+	report := InspectPage(analysis.NewPage(pageURL, newResponse(http.StatusFound, map[string]string{"Location": "/moved"}), nil))
+	assert.Equal(t, "https://example.com/moved", report.RedirectTarget)
+}
+
+func TestCollectorReports(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector()
+	collector.Add(FailedPage("https://example.com/b"))
+	collector.Add(PageReport{URL: "https://example.com/a", StatusCode: http.StatusOK, Indexable: true})
+	collector.Add(PageReport{URL: "https://example.com/a", StatusCode: http.StatusNotFound})
+
+	reports := collector.Reports()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, "https://example.com/a", reports[0].URL)
+	assert.Equal(t, http.StatusNotFound, reports[0].StatusCode)
+	assert.Equal(t, []string{IssueHTTPError}, reports[1].Issues)
+}
+
+func TestCollectorAnalyze(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector()
+	page := analysis.NewPage(pageURL, newResponse(http.StatusOK, map[string]string{"X-Robots-Tag": "noindex"}),
+		[]byte(`<html><head><title>Page</title><link rel="canonical" href="/other"></head><body>`+strings.Repeat("content ", 100)+`</body></html>`))
+
+	findings := collector.Analyze(page)
+	assert.Equal(t, []analysis.Finding{
+		{URL: pageURL, Analyzer: "audit", Issue: IssueNoindex},
+		{URL: pageURL, Analyzer: "audit", Issue: IssueCanonicalMismatch, Detail: "https://example.com/other"},
+	}, findings)
+
+	assert.Equal(t, []analysis.Finding{{URL: "https://example.com/down", Analyzer: "audit", Issue: IssueHTTPError}},
+		collector.Analyze(analysis.FailedPage("https://example.com/down")))
+	assert.Empty(t, collector.Analyze(analysis.NewPage("https://example.com/ok", newResponse(http.StatusOK, nil),
+		[]byte(`<html><body>`+strings.Repeat("content ", 100)+`</body></html>`))))
+
+	reports := collector.Reports()
+	assert.Len(t, reports, 3)
+	assert.True(t, reports[2].Noindex)
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Kinds of URL variants probed
+const (
+	VariantTrailingSlash = "trailing_slash"
+	VariantCase          = "case"
+)
+
+// Outcomes of probing a URL variant. A redirect or a 404 is what a well
+// canonicalized site returns; a duplicate serves the page's content at a
+// second URL.
+const (
+	OutcomeRedirect  = "redirect"
+	OutcomeNotFound  = "not_found"
+	OutcomeDuplicate = "duplicate"
+	OutcomeDistinct  = "distinct"
+	OutcomeError     = "error"
+)
+
+// maxVariantBodyBytes bounds the bytes of a body hashed to compare a
+// variant with its page
+const maxVariantBodyBytes = 10 * 1024 * 1024
+
+// VariantReport is the result of probing one variant of a page's URL
+type VariantReport struct {
+	Kind           string `json:"kind"`
+	URL            string `json:"url"`
+	StatusCode     int    `json:"status_code"`
+	RedirectTarget string `json:"redirect_target,omitempty"`
+	Outcome        string `json:"outcome"`
+}
+
+// Fetcher requests a URL without following redirects
+type Fetcher func(url string) (*http.Response, error)
+
+// Variants returns the trailing-slash and case variants of a URL: its path
+// with the trailing slash added or removed, and its path lowercased, or
+// uppercased when it is already lowercase. The root path has neither, and a
+// path without letters has no case variant.
+func Variants(pageURL string) []VariantReport {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Path == "" || parsed.Path == "/" {
+		return nil
+	}
+
+	variant := func(kind, path string) VariantReport {
+		copied := *parsed
+		copied.Path = path
+		copied.RawPath = ""
+		return VariantReport{Kind: kind, URL: copied.String()}
+	}
+
+	var variants []VariantReport
+	if strings.HasSuffix(parsed.Path, "/") {
+		variants = append(variants, variant(VariantTrailingSlash, strings.TrimSuffix(parsed.Path, "/")))
+	} else {
+		variants = append(variants, variant(VariantTrailingSlash, parsed.Path+"/"))
+	}
+
+	cased := strings.ToLower(parsed.Path)
+	if cased == parsed.Path {
+		cased = strings.ToUpper(parsed.Path)
+	}
+	if cased != parsed.Path {
+		variants = append(variants, variant(VariantCase, cased))
+	}
+	return variants
+}
+
+// ProbeVariants fetches a page and each of its variants and reports whether
+// each variant redirects, is not found, or serves the same content as the
+// page. It returns nil if the page itself does not return 200.
+func ProbeVariants(pageURL string, fetch Fetcher) []VariantReport {
+	status, pageDigest, err := fetchDigest(pageURL, fetch)
+	if err != nil || status != http.StatusOK {
+		return nil
+	}
+
+	variants := Variants(pageURL)
+	for i := range variants {
+		variants[i].probe(fetch, pageDigest)
+	}
+	return variants
+}
+
+// probe fetches the variant and classifies the response
+func (v *VariantReport) probe(fetch Fetcher, pageDigest [sha256.Size]byte) {
+	resp, err := fetch(v.URL)
+	if err != nil {
+		v.Outcome = OutcomeError
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	v.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		v.Outcome = OutcomeRedirect
+		v.RedirectTarget = resolveReference(v.URL, resp.Header.Get("Location"))
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		v.Outcome = OutcomeNotFound
+	case resp.StatusCode == http.StatusOK:
+		digest, err := bodyDigest(resp.Body)
+		switch {
+		case err != nil:
+			v.Outcome = OutcomeError
+		case digest == pageDigest:
+			v.Outcome = OutcomeDuplicate
+		default:
+			v.Outcome = OutcomeDistinct
+		}
+	default:
+		v.Outcome = OutcomeError
+	}
+}
+
+// fetchDigest fetches a URL and returns its status and the SHA-256 hash of
+// its body
+func fetchDigest(target string, fetch Fetcher) (int, [sha256.Size]byte, error) {
+	resp, err := fetch(target)
+	if err != nil {
+		return 0, [sha256.Size]byte{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	digest, err := bodyDigest(resp.Body)
+	return resp.StatusCode, digest, err
+}
+
+// bodyDigest returns the SHA-256 hash of up to maxVariantBodyBytes of a body
+func bodyDigest(body io.Reader) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(body, maxVariantBodyBytes)); err != nil {
+		return digest, err
+	}
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariants(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected []VariantReport
+	}{
+		{
+			name: "lowercase path",
+			url:  "https://example.com/blog/post?page=2",
+			expected: []VariantReport{
+				{Kind: VariantTrailingSlash, URL: "https://example.com/blog/post/?page=2"},
+				{Kind: VariantCase, URL: "https://example.com/BLOG/POST?page=2"},
+			},
+		},
+		{
+			name: "mixed case path with trailing slash",
+			url:  "https://example.com/Blog/",
+			expected: []VariantReport{
+				{Kind: VariantTrailingSlash, URL: "https://example.com/Blog"},
+				{Kind: VariantCase, URL: "https://example.com/blog/"},
+			},
+		},
+		{
+			name: "path without letters",
+			url:  "https://example.com/2024",
+			expected: []VariantReport{
+				{Kind: VariantTrailingSlash, URL: "https://example.com/2024/"},
+			},
+		},
+		{
+			name: "root",
+			url:  "https://example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Variants(tt.url))
+		})
+	}
+}
+
+func TestProbeVariants(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	page := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>page</html>"))
+	}
+	mux.HandleFunc("/page", page)
+	mux.HandleFunc("/PAGE", page)
+	mux.HandleFunc("/page/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>other</html>"))
+	})
+	mux.HandleFunc("/OTHER", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>soft error</html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	fetch := func(url string) (*http.Response, error) {
+		return client.Get(url)
+	}
+
+	variants := ProbeVariants(server.URL+"/page", fetch)
+	require.Len(t, variants, 2)
+	assert.Equal(t, OutcomeRedirect, variants[0].Outcome)
+	assert.Equal(t, http.StatusMovedPermanently, variants[0].StatusCode)
+	assert.Equal(t, server.URL+"/page", variants[0].RedirectTarget)
+	assert.Equal(t, OutcomeDuplicate, variants[1].Outcome)
+
+	variants = ProbeVariants(server.URL+"/other", fetch)
+	require.Len(t, variants, 2)
+	assert.Equal(t, OutcomeNotFound, variants[0].Outcome)
+	assert.Equal(t, OutcomeDistinct, variants[1].Outcome)
+
+	assert.Nil(t, ProbeVariants(server.URL+"/missing", fetch), "a page that is not found has no variants probed")
+}
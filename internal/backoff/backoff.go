@@ -9,6 +9,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Recovery modes control how an active backoff eases off once responses are
+// healthy again
+const (
+	// RecoveryReset drops back to the initial delay on the first healthy
+	// response
+	RecoveryReset = "reset"
+	// RecoveryDecay halves the delay once per decay interval of healthy
+	// responses, so a barely recovering origin that fails again is met
+	// with a delay close to the one that last held
+	RecoveryDecay = "decay"
+)
+
 // ErrorEvent represents an error event for tracking
 type ErrorEvent struct {
 	Timestamp  time.Time
@@ -25,12 +37,20 @@ type Config struct {
 	ResponseTimeDegradationThreshold float64
 	ForbiddenErrorThreshold          int
 	ForbiddenErrorWindow             time.Duration
+
+	// Recovery is RecoveryReset or RecoveryDecay; empty means reset
+	Recovery      string
+	DecayInterval time.Duration
+
+	// CancelRules cancel the crawl independently of the 403 rule, and
+	// apply even when backoff is disabled
+	CancelRules []CancelRule
 }
 
 // Manager handles backoff logic and error tracking
 type Manager struct {
 	mu     sync.RWMutex
-	logger *logrus.Logger
+	logger logrus.FieldLogger
 
 	// Configuration
 	enabled                          bool
@@ -40,20 +60,33 @@ type Manager struct {
 	responseTimeDegradationThreshold float64
 	forbiddenErrorThreshold          int
 	forbiddenErrorWindow             time.Duration
+	recovery                         string
+	decayInterval                    time.Duration
+	cancelRules                      []*cancelRuleState
 
 	// State
 	currentDelay         time.Duration
 	backoffActive        bool
+	lastAdjusted         time.Time
 	baselineResponseTime time.Duration
 	recentResponseTimes  []time.Duration
 	responseTimeWindow   int
 	forbiddenErrors      []time.Time
 	cancelled            bool
+	cancelReason         string
 	cancelFunc           context.CancelFunc
+
+	// gate pauses all workers while a backoff delay is in effect
+	gate *Gate
 }
 
 // NewManager creates a new backoff manager
-func NewManager(logger *logrus.Logger, config Config) *Manager {
+func NewManager(logger logrus.FieldLogger, config Config) *Manager {
+	cancelRules := make([]*cancelRuleState, 0, len(config.CancelRules))
+	for _, rule := range config.CancelRules {
+		cancelRules = append(cancelRules, &cancelRuleState{rule: rule})
+	}
+
 	return &Manager{
 		logger:                           logger,
 		enabled:                          config.Enabled,
@@ -63,12 +96,22 @@ func NewManager(logger *logrus.Logger, config Config) *Manager {
 		responseTimeDegradationThreshold: config.ResponseTimeDegradationThreshold,
 		forbiddenErrorThreshold:          config.ForbiddenErrorThreshold,
 		forbiddenErrorWindow:             config.ForbiddenErrorWindow,
+		recovery:                         config.Recovery,
+		decayInterval:                    config.DecayInterval,
+		cancelRules:                      cancelRules,
 		currentDelay:                     config.InitialDelay,
 		responseTimeWindow:               20, // Track last 20 response times for baseline
 		forbiddenErrors:                  make([]time.Time, 0),
+		gate:                             NewGate(),
 	}
 }
 
+// Wait blocks until any backoff delay in effect has passed, so that every
+// worker pauses together rather than only the one that saw the failure
+func (m *Manager) Wait(ctx context.Context) error {
+	return m.gate.Wait(ctx)
+}
+
 // SetCancelFunc sets the cancel function for the crawler context
 func (m *Manager) SetCancelFunc(cancelFunc context.CancelFunc) {
 	m.mu.Lock()
@@ -78,16 +121,20 @@ func (m *Manager) SetCancelFunc(cancelFunc context.CancelFunc) {
 
 // ShouldBackoff determines if a backoff is needed based on the response
 func (m *Manager) ShouldBackoff(statusCode int, duration time.Duration) (bool, time.Duration, error) {
-	if !m.enabled {
-		return false, 0, nil
-	}
-
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check for cancellation first
 	if m.cancelled {
-		return false, 0, fmt.Errorf("crawl cancelled due to too many 403 errors")
+		return false, 0, fmt.Errorf("crawl cancelled due to %s", m.cancelReason)
+	}
+
+	if err := m.checkCancelRules(statusCode); err != nil {
+		return false, 0, err
+	}
+
+	if !m.enabled {
+		return false, 0, nil
 	}
 
 	// Track 403 errors and check for cancellation threshold
@@ -101,6 +148,7 @@ func (m *Manager) ShouldBackoff(statusCode int, duration time.Duration) (bool, t
 		// Check if we've exceeded the threshold
 		if len(m.forbiddenErrors) >= m.forbiddenErrorThreshold {
 			m.cancelled = true
+			m.cancelReason = "too many 403 errors"
 			m.logger.WithFields(logrus.Fields{
 				"forbidden_errors": len(m.forbiddenErrors),
 				"threshold":        m.forbiddenErrorThreshold,
@@ -141,15 +189,41 @@ func (m *Manager) ShouldBackoff(statusCode int, duration time.Duration) (bool, t
 		return m.activateBackoff(), m.currentDelay, nil
 	}
 
-	// Reset backoff if we have a successful request and things seem normal
+	// Ease off backoff if we have a successful request and things seem normal
 	if statusCode >= 200 && statusCode < 400 && m.backoffActive {
-		m.resetBackoff()
+		m.recoverBackoff()
 	}
 
 	return false, 0, nil
 }
 
-// activateBackoff activates or increases the backoff delay
+// checkCancelRules feeds a response to every cancel rule and cancels the
+// crawl when the first one fires
+func (m *Manager) checkCancelRules(statusCode int) error {
+	now := time.Now()
+	for _, state := range m.cancelRules {
+		reason := state.observe(statusCode, now)
+		if reason == "" {
+			continue
+		}
+
+		m.cancelled = true
+		m.cancelReason = fmt.Sprintf("cancel rule %q: %s", state.rule.Spec, reason)
+		m.logger.WithFields(logrus.Fields{
+			"rule":   state.rule.Spec,
+			"reason": reason,
+		}).Error("Cancel rule fired, cancelling crawl")
+
+		if m.cancelFunc != nil {
+			m.cancelFunc()
+		}
+		return fmt.Errorf("crawl cancelled: %s", m.cancelReason)
+	}
+	return nil
+}
+
+// activateBackoff activates or increases the backoff delay and closes the
+// gate for that long
 func (m *Manager) activateBackoff() bool {
 	if !m.backoffActive {
 		m.backoffActive = true
@@ -163,9 +237,39 @@ func (m *Manager) activateBackoff() bool {
 			m.currentDelay = newDelay
 		}
 	}
+
+	m.lastAdjusted = time.Now()
+	m.gate.Hold(m.currentDelay)
 	return true
 }
 
+// recoverBackoff eases off an active backoff after a healthy response,
+// either at once or by halving the delay once per decay interval
+func (m *Manager) recoverBackoff() {
+	if m.recovery != RecoveryDecay {
+		m.resetBackoff()
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(m.lastAdjusted) < m.decayInterval {
+		return
+	}
+
+	decayed := m.currentDelay / 2
+	if decayed < m.initialDelay {
+		m.resetBackoff()
+		return
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"previous_delay": m.currentDelay,
+		"current_delay":  decayed,
+	}).Info("Decaying backoff delay, server appears to be recovering")
+	m.currentDelay = decayed
+	m.lastAdjusted = now
+}
+
 // resetBackoff resets the backoff state
 func (m *Manager) resetBackoff() {
 	if m.backoffActive {
@@ -250,9 +354,17 @@ func (m *Manager) GetStats() map[string]interface{} {
 		"current_avg_response":   m.getCurrentAverageResponseTime(),
 		"forbidden_errors_count": len(m.forbiddenErrors),
 		"cancelled":              m.cancelled,
+		"cancel_reason":          m.cancelReason,
+		"paused_until":           m.gate.Until(),
 	}
 }
 
+// Pause returns when the pause of every worker in effect ends, a past or
+// zero time if there is none, and how long workers have been paused so far
+func (m *Manager) Pause() (until time.Time, paused time.Duration) {
+	return m.gate.Until(), m.gate.Held()
+}
+
 // IsBackoffActive returns whether backoff is currently active
 func (m *Manager) IsBackoffActive() bool {
 	m.mu.RLock()
@@ -266,3 +378,11 @@ func (m *Manager) IsCancelled() bool {
 	defer m.mu.RUnlock()
 	return m.cancelled
 }
+
+// CancelReason describes what cancelled the crawl, or "" if it was not
+// cancelled
+func (m *Manager) CancelReason() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cancelReason
+}
@@ -156,6 +156,65 @@ func TestShouldBackoff_ResetOnSuccess(t *testing.T) {
 	assert.False(t, manager.IsBackoffActive())
 }
 
+func TestShouldBackoff_DecayOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	config := getTestConfig()
+	config.Recovery = RecoveryDecay
+	manager := NewManager(logger, config)
+
+	// Escalate to 1s, 2s, 4s, 8s
+	for range 4 {
+		_, _, err := manager.ShouldBackoff(500, 100*time.Millisecond)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 8*time.Second, manager.GetStats()["current_delay"])
+
+	// With no decay interval every healthy response halves the delay
+	for _, expected := range []time.Duration{4 * time.Second, 2 * time.Second, 1 * time.Second} {
+		_, _, err := manager.ShouldBackoff(200, 100*time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, manager.IsBackoffActive())
+		assert.Equal(t, expected, manager.GetStats()["current_delay"])
+	}
+
+	// Halving below the initial delay ends the backoff
+	_, _, err := manager.ShouldBackoff(200, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, manager.IsBackoffActive())
+}
+
+func TestShouldBackoff_DecayWaitsForInterval(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	config := getTestConfig()
+	config.Recovery = RecoveryDecay
+	config.DecayInterval = time.Hour
+	manager := NewManager(logger, config)
+
+	for range 3 {
+		_, _, err := manager.ShouldBackoff(500, 100*time.Millisecond)
+		assert.NoError(t, err)
+	}
+
+	// A healthy response inside the interval keeps the delay, so the next
+	// failure escalates from it instead of starting over
+	_, _, err := manager.ShouldBackoff(200, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, manager.IsBackoffActive())
+	assert.Equal(t, 4*time.Second, manager.GetStats()["current_delay"])
+
+	_, delay, err := manager.ShouldBackoff(503, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 8*time.Second, delay)
+}
+
 func TestShouldBackoff_ForbiddenErrors(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,211 @@
+package backoff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCancelMinRequests is how many responses an error-rate rule waits for
+// before it can fire, so that one early failure does not cancel the crawl
+const DefaultCancelMinRequests = 20
+
+// CancelRule cancels the crawl when responses match a condition. Exactly one
+// of Count, Consecutive or ErrorRate is set.
+type CancelRule struct {
+	// Spec is the rule as written, used to report which rule fired
+	Spec string
+
+	// Status matches a code ("401"), a class ("5xx") or "error" for
+	// requests that got no response
+	Status string
+
+	// Count fires after this many matching responses within Window, or
+	// over the whole crawl if Window is zero
+	Count  int
+	Window time.Duration
+
+	// Consecutive fires after this many matching responses in a row
+	Consecutive int
+
+	// ErrorRate fires when the share of failed requests reaches this
+	// fraction after at least MinRequests responses
+	ErrorRate   float64
+	MinRequests int
+}
+
+// ParseCancelRule parses a rule such as "status=401,count=3,window=1m",
+// "status=5xx,consecutive=10" or "error-rate=0.5,min-requests=100"
+func ParseCancelRule(spec string) (CancelRule, error) {
+	rule := CancelRule{Spec: spec}
+
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return CancelRule{}, fmt.Errorf("invalid cancel rule %q: expected key=value, got %q", spec, field)
+		}
+
+		var err error
+		switch key {
+		case "status":
+			rule.Status = strings.ToLower(value)
+			if !validStatusPattern(rule.Status) {
+				err = fmt.Errorf("status must be a code, a class such as 5xx, or error")
+			}
+		case "count":
+			rule.Count, err = strconv.Atoi(value)
+		case "window":
+			rule.Window, err = time.ParseDuration(value)
+		case "consecutive":
+			rule.Consecutive, err = strconv.Atoi(value)
+		case "error-rate":
+			rule.ErrorRate, err = strconv.ParseFloat(value, 64)
+		case "min-requests":
+			rule.MinRequests, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return CancelRule{}, fmt.Errorf("invalid cancel rule %q: %w", spec, err)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return CancelRule{}, fmt.Errorf("invalid cancel rule %q: %w", spec, err)
+	}
+	if rule.ErrorRate > 0 && rule.MinRequests == 0 {
+		rule.MinRequests = DefaultCancelMinRequests
+	}
+
+	return rule, nil
+}
+
+// validate checks that the rule has exactly one trigger and sensible values
+func (r CancelRule) validate() error {
+	triggers := 0
+	for _, set := range []bool{r.Count != 0, r.Consecutive != 0, r.ErrorRate != 0} {
+		if set {
+			triggers++
+		}
+	}
+	if triggers != 1 {
+		return fmt.Errorf("set exactly one of count, consecutive or error-rate")
+	}
+
+	if r.ErrorRate != 0 {
+		if r.Status != "" || r.Window != 0 {
+			return fmt.Errorf("error-rate cannot be combined with status or window")
+		}
+		if r.ErrorRate <= 0 || r.ErrorRate > 1 {
+			return fmt.Errorf("error-rate must be between 0 and 1")
+		}
+		if r.MinRequests < 0 {
+			return fmt.Errorf("min-requests cannot be negative")
+		}
+		return nil
+	}
+
+	if r.Status == "" {
+		return fmt.Errorf("status is required")
+	}
+	if r.MinRequests != 0 {
+		return fmt.Errorf("min-requests only applies to error-rate")
+	}
+	if r.Count < 0 || r.Consecutive < 0 {
+		return fmt.Errorf("count and consecutive must be positive")
+	}
+	if r.Window < 0 {
+		return fmt.Errorf("window cannot be negative")
+	}
+	if r.Window > 0 && r.Count == 0 {
+		return fmt.Errorf("window only applies to count")
+	}
+	return nil
+}
+
+// validStatusPattern reports whether pattern is a status code, a status class
+// or "error"
+func validStatusPattern(pattern string) bool {
+	if pattern == "error" {
+		return true
+	}
+	if len(pattern) == 3 && strings.HasSuffix(pattern, "xx") {
+		return pattern[0] >= '1' && pattern[0] <= '5'
+	}
+	code, err := strconv.Atoi(pattern)
+	return err == nil && code >= 100 && code <= 599
+}
+
+// matches reports whether a response status satisfies the rule's status
+// pattern; a zero status means the request got no response
+func (r CancelRule) matches(statusCode int) bool {
+	switch {
+	case r.Status == "error":
+		return statusCode == 0
+	case strings.HasSuffix(r.Status, "xx"):
+		return statusCode/100 == int(r.Status[0]-'0')
+	default:
+		return strconv.Itoa(statusCode) == r.Status
+	}
+}
+
+// cancelRuleState tracks the responses one rule has seen
+type cancelRuleState struct {
+	rule CancelRule
+
+	matches     []time.Time
+	consecutive int
+	requests    int
+	errors      int
+}
+
+// observe records a response and returns why the rule fired, or "" if it did
+// not
+func (s *cancelRuleState) observe(statusCode int, now time.Time) string {
+	rule := s.rule
+
+	if rule.ErrorRate > 0 {
+		s.requests++
+		if statusCode == 0 || statusCode >= 400 {
+			s.errors++
+		}
+		rate := float64(s.errors) / float64(s.requests)
+		if s.requests >= rule.MinRequests && rate >= rule.ErrorRate {
+			return fmt.Sprintf("error rate %.1f%% over %d requests reached %.1f%%", rate*100, s.requests, rule.ErrorRate*100)
+		}
+		return ""
+	}
+
+	if !rule.matches(statusCode) {
+		s.consecutive = 0
+		return ""
+	}
+
+	if rule.Consecutive > 0 {
+		s.consecutive++
+		if s.consecutive >= rule.Consecutive {
+			return fmt.Sprintf("%d consecutive %s responses", s.consecutive, rule.Status)
+		}
+		return ""
+	}
+
+	s.matches = append(s.matches, now)
+	if rule.Window > 0 {
+		cutoff := now.Add(-rule.Window)
+		kept := s.matches[:0]
+		for _, matched := range s.matches {
+			if matched.After(cutoff) {
+				kept = append(kept, matched)
+			}
+		}
+		s.matches = kept
+	}
+	if len(s.matches) >= rule.Count {
+		if rule.Window > 0 {
+			return fmt.Sprintf("%d %s responses within %v", len(s.matches), rule.Status, rule.Window)
+		}
+		return fmt.Sprintf("%d %s responses", len(s.matches), rule.Status)
+	}
+	return ""
+}
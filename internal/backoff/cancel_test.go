@@ -0,0 +1,155 @@
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCancelRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected CancelRule
+		wantErr  bool
+	}{
+		{
+			name:     "status count",
+			spec:     "status=401,count=3",
+			expected: CancelRule{Spec: "status=401,count=3", Status: "401", Count: 3},
+		},
+		{
+			name:     "status count within window",
+			spec:     "status=429,count=10,window=1m",
+			expected: CancelRule{Spec: "status=429,count=10,window=1m", Status: "429", Count: 10, Window: time.Minute},
+		},
+		{
+			name:     "consecutive class",
+			spec:     "status=5XX,consecutive=10",
+			expected: CancelRule{Spec: "status=5XX,consecutive=10", Status: "5xx", Consecutive: 10},
+		},
+		{
+			name:     "error rate with default minimum",
+			spec:     "error-rate=0.5",
+			expected: CancelRule{Spec: "error-rate=0.5", ErrorRate: 0.5, MinRequests: DefaultCancelMinRequests},
+		},
+		{
+			name:     "error rate with minimum",
+			spec:     "error-rate=0.25,min-requests=100",
+			expected: CancelRule{Spec: "error-rate=0.25,min-requests=100", ErrorRate: 0.25, MinRequests: 100},
+		},
+		{name: "missing value", spec: "status", wantErr: true},
+		{name: "unknown key", spec: "status=401,limit=3", wantErr: true},
+		{name: "no trigger", spec: "status=401", wantErr: true},
+		{name: "two triggers", spec: "status=401,count=3,consecutive=3", wantErr: true},
+		{name: "missing status", spec: "count=3", wantErr: true},
+		{name: "invalid status", spec: "status=6xx,count=3", wantErr: true},
+		{name: "window without count", spec: "status=5xx,consecutive=3,window=1m", wantErr: true},
+		{name: "error rate out of range", spec: "error-rate=1.5", wantErr: true},
+		{name: "error rate with status", spec: "status=500,error-rate=0.5", wantErr: true},
+		{name: "negative count", spec: "status=500,count=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := ParseCancelRule(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestCancelRuleFires(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     string
+		statuses []int
+		firesAt  int // 1-based response that fires the rule, 0 for never
+	}{
+		{name: "count", spec: "status=401,count=3", statuses: []int{401, 200, 401, 500, 401}, firesAt: 5},
+		{name: "count below threshold", spec: "status=401,count=3", statuses: []int{401, 200, 401}},
+		{name: "consecutive", spec: "status=5xx,consecutive=3", statuses: []int{500, 502, 200, 503, 500, 504}, firesAt: 6},
+		{name: "consecutive broken", spec: "status=5xx,consecutive=3", statuses: []int{500, 502, 200, 503, 500}},
+		{name: "no response", spec: "status=error,consecutive=2", statuses: []int{0, 0}, firesAt: 2},
+		{name: "error rate", spec: "error-rate=0.5,min-requests=4", statuses: []int{500, 500, 200, 200}, firesAt: 4},
+		{name: "error rate waits for minimum", spec: "error-rate=0.5,min-requests=4", statuses: []int{500, 500, 500}},
+		{name: "error rate below threshold", spec: "error-rate=0.5,min-requests=3", statuses: []int{200, 404, 200, 200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := ParseCancelRule(tt.spec)
+			require.NoError(t, err)
+
+			state := &cancelRuleState{rule: rule}
+			firedAt := 0
+			for i, status := range tt.statuses {
+				if state.observe(status, time.Now()) != "" && firedAt == 0 {
+					firedAt = i + 1
+				}
+			}
+			assert.Equal(t, tt.firesAt, firedAt)
+		})
+	}
+}
+
+func TestCancelRuleWindow(t *testing.T) {
+	t.Parallel()
+
+	rule, err := ParseCancelRule("status=429,count=2,window=1m")
+	require.NoError(t, err)
+
+	state := &cancelRuleState{rule: rule}
+	start := time.Now()
+	assert.Empty(t, state.observe(429, start))
+	assert.Empty(t, state.observe(429, start.Add(2*time.Minute)))
+	assert.Equal(t, "2 429 responses within 1m0s", state.observe(429, start.Add(2*time.Minute+time.Second)))
+}
+
+func TestManagerCancelRules(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	rule, err := ParseCancelRule("status=401,count=2")
+	require.NoError(t, err)
+
+	// Rules apply even when backoff itself is disabled
+	config := getDisabledTestConfig()
+	config.CancelRules = []CancelRule{rule}
+	manager := NewManager(logger, config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.SetCancelFunc(cancel)
+
+	_, _, err = manager.ShouldBackoff(401, time.Millisecond)
+	require.NoError(t, err)
+	_, _, err = manager.ShouldBackoff(401, time.Millisecond)
+	require.Error(t, err)
+
+	assert.True(t, manager.IsCancelled())
+	assert.Error(t, ctx.Err())
+	assert.Equal(t, `cancel rule "status=401,count=2": 2 401 responses`, manager.CancelReason())
+	assert.Equal(t, manager.CancelReason(), manager.GetStats()["cancel_reason"])
+
+	_, _, err = manager.ShouldBackoff(200, time.Millisecond)
+	assert.ErrorContains(t, err, "crawl cancelled due to cancel rule")
+}
@@ -0,0 +1,82 @@
+package backoff
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Gate pauses every worker at once. While a backoff delay is in effect the
+// gate is closed and Wait blocks; requests already in flight are unaffected.
+type Gate struct {
+	mu    sync.Mutex
+	until time.Time
+
+	// held is the total time the gate has been closed for, including the
+	// rest of the hold in effect
+	held time.Duration
+}
+
+// NewGate creates an open gate
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// Hold closes the gate for d from now. A hold never shortens one that is
+// already in effect.
+func (g *Gate) Hold(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if until := now.Add(d); until.After(g.until) {
+		g.held += until.Sub(later(g.until, now))
+		g.until = until
+	}
+}
+
+// Held returns how long the gate has been closed so far, across every hold
+func (g *Gate) Held() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if rest := time.Until(g.until); rest > 0 {
+		return g.held - rest
+	}
+	return g.held
+}
+
+// later returns the later of two times
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Until returns when the gate opens; the zero time or a past time means the
+// gate is open
+func (g *Gate) Until() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.until
+}
+
+// Wait blocks until the gate is open or ctx is done. The gate is checked
+// again after each pause, since another worker may have extended the hold.
+func (g *Gate) Wait(ctx context.Context) error {
+	for {
+		remaining := time.Until(g.Until())
+		if remaining <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
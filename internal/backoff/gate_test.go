@@ -0,0 +1,110 @@
+package backoff
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateOpenByDefault(t *testing.T) {
+	t.Parallel()
+
+	gate := NewGate()
+	start := time.Now()
+	assert.NoError(t, gate.Wait(context.Background()))
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestGateHoldsAllWaiters(t *testing.T) {
+	t.Parallel()
+
+	gate := NewGate()
+	gate.Hold(100 * time.Millisecond)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	waited := make([]time.Duration, 5)
+	for i := range waited {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, gate.Wait(context.Background()))
+			waited[i] = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, duration := range waited {
+		assert.GreaterOrEqual(t, duration, 90*time.Millisecond, "waiter %d passed the gate early", i)
+	}
+}
+
+func TestGateHoldNeverShortens(t *testing.T) {
+	t.Parallel()
+
+	gate := NewGate()
+	gate.Hold(time.Hour)
+	until := gate.Until()
+
+	gate.Hold(time.Millisecond)
+	assert.Equal(t, until, gate.Until())
+
+	gate.Hold(2 * time.Hour)
+	assert.True(t, gate.Until().After(until))
+}
+
+func TestGateHeld(t *testing.T) {
+	t.Parallel()
+
+	gate := NewGate()
+	assert.Zero(t, gate.Held())
+
+	// Only the part of a hold that has passed counts, and overlapping holds
+	// count once
+	gate.Hold(time.Hour)
+	gate.Hold(time.Minute)
+	assert.Less(t, gate.Held(), time.Second)
+
+	gate = NewGate()
+	gate.Hold(20 * time.Millisecond)
+	gate.Hold(30 * time.Millisecond)
+	assert.NoError(t, gate.Wait(context.Background()))
+	held := gate.Held()
+	assert.GreaterOrEqual(t, held, 29*time.Millisecond)
+	assert.Less(t, held, 40*time.Millisecond)
+}
+
+func TestGateWaitCancelled(t *testing.T) {
+	t.Parallel()
+
+	gate := NewGate()
+	gate.Hold(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, gate.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestManagerClosesGateOnBackoff(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	config := getTestConfig()
+	config.InitialDelay = 50 * time.Millisecond
+	manager := NewManager(logger, config)
+
+	shouldBackoff, delay, err := manager.ShouldBackoff(503, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, shouldBackoff)
+	assert.Equal(t, 50*time.Millisecond, delay)
+
+	// Workers that did not see the failure pause as well
+	start := time.Now()
+	assert.NoError(t, manager.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
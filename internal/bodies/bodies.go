@@ -0,0 +1,203 @@
+// Package bodies saves response bodies to a directory so that failed or
+// unusual responses can be inspected after a crawl without requesting them
+// again.
+package bodies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexFile is the name of the index written next to the saved bodies
+const IndexFile = "index.json"
+
+// maxSlugLength limits the URL part of a body file name
+const maxSlugLength = 100
+
+// Entry describes one saved body
+type Entry struct {
+	// File is the body's file name in the directory; it is empty when the
+	// response had no body
+	File        string    `json:"file,omitempty"`
+	URL         string    `json:"url"`
+	StatusCode  int       `json:"status_code"`
+	Failed      bool      `json:"failed"`
+	ContentType string    `json:"content_type,omitempty"`
+	Bytes       int64     `json:"bytes"`
+	Truncated   bool      `json:"truncated,omitempty"`
+	CapturedAt  time.Time `json:"captured_at"`
+}
+
+// Store saves bodies to a directory until their total size reaches a cap.
+// It is safe for concurrent use.
+type Store struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	used    int64
+	entries []Entry
+	skipped int
+}
+
+// NewStore creates the directory, if needed, and a store that saves up to
+// maxBytes of bodies to it
+func NewStore(dir string, maxBytes int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create body capture directory: %w", err)
+	}
+	return &Store{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Capture holds the body of one response as the crawler reads it
+type Capture struct {
+	store     *Store
+	resp      *http.Response
+	failed    bool
+	limit     int64
+	body      bytes.Buffer
+	truncated bool
+}
+
+// Start captures resp's body as it is read, up to the space left under the
+// cap. It returns nil, and counts the response as skipped, once the cap has
+// been reached.
+func (s *Store) Start(resp *http.Response, failed bool) *Capture {
+	s.mu.Lock()
+	remaining := s.maxBytes - s.used
+	if remaining <= 0 {
+		s.skipped++
+	}
+	s.mu.Unlock()
+	if remaining <= 0 {
+		return nil
+	}
+
+	capture := &Capture{store: s, resp: resp, failed: failed, limit: remaining}
+	resp.Body = &captureBody{ReadCloser: resp.Body, capture: capture}
+	return capture
+}
+
+// Limit returns how many body bytes the capture keeps
+func (c *Capture) Limit() int64 {
+	return c.limit
+}
+
+// write keeps body bytes read by the crawler, up to the limit
+func (c *Capture) write(p []byte) {
+	remaining := c.limit - int64(c.body.Len())
+	if int64(len(p)) > remaining {
+		p = p[:remaining]
+		c.truncated = true
+	}
+	c.body.Write(p)
+}
+
+// Save writes the body read so far to the directory. Other captures may have
+// used up the space since this one started, so the body is cut to what is
+// left; when nothing is left it is skipped.
+func (c *Capture) Save() error {
+	s := c.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body := c.body.Bytes()
+	truncated := c.truncated
+	if remaining := s.maxBytes - s.used; int64(len(body)) > remaining {
+		if remaining <= 0 {
+			s.skipped++
+			return nil
+		}
+		body = body[:remaining]
+		truncated = true
+	}
+
+	entry := Entry{
+		URL:         c.resp.Request.URL.String(),
+		StatusCode:  c.resp.StatusCode,
+		Failed:      c.failed,
+		ContentType: c.resp.Header.Get("Content-Type"),
+		Bytes:       int64(len(body)),
+		Truncated:   truncated,
+		CapturedAt:  time.Now().UTC(),
+	}
+	if len(body) > 0 {
+		entry.File = fmt.Sprintf("%06d-%s.body", len(s.entries)+1, slug(entry.URL))
+		if err := os.WriteFile(filepath.Join(s.dir, entry.File), body, 0600); err != nil {
+			return fmt.Errorf("failed to save body of %s: %w", entry.URL, err)
+		}
+	}
+
+	s.used += entry.Bytes
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Summary returns how many bodies were saved, their total size, and how
+// many were skipped because the cap was reached
+func (s *Store) Summary() (saved int, bytes int64, skipped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries), s.used, s.skipped
+}
+
+// WriteIndex writes the index of saved bodies to the directory
+func (s *Store) WriteIndex() error {
+	s.mu.Lock()
+	entries := append([]Entry{}, s.entries...)
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(map[string]interface{}{"bodies": entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode body capture index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, IndexFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write body capture index: %w", err)
+	}
+	return nil
+}
+
+// slug turns a URL into a file name part: the host and path with anything
+// other than letters, digits, dots and dashes replaced
+func slug(rawURL string) string {
+	_, rest, found := strings.Cut(rawURL, "://")
+	if !found {
+		rest = rawURL
+	}
+
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSuffix(rest, "/"))
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	return slug
+}
+
+// captureBody is a response body that copies reads into its capture
+type captureBody struct {
+	io.ReadCloser
+	capture *Capture
+}
+
+// Read reads from the underlying body and keeps the bytes read
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.capture.write(p[:n])
+	}
+	return n, err
+}
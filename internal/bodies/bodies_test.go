@@ -0,0 +1,116 @@
+package bodies
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get fetches path from server with its body captured by store, reading the
+// whole body as the crawler would
+func get(t *testing.T, store *Store, server *httptest.Server, path string) *Capture {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	capture := store.Start(resp, resp.StatusCode >= 400)
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	if capture != nil {
+		require.NoError(t, capture.Save())
+	}
+	return capture
+}
+
+func TestStoreSavesBodiesUpToCap(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "not found")
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, strings.Repeat("a", 10))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dir := filepath.Join(t.TempDir(), "bodies")
+	store, err := NewStore(dir, 15)
+	require.NoError(t, err)
+
+	require.NotNil(t, get(t, store, server, "/missing"))
+	require.NotNil(t, get(t, store, server, "/empty"))
+	require.NotNil(t, get(t, store, server, "/page/one"))
+	assert.Nil(t, get(t, store, server, "/page/two"), "the cap is used up")
+	require.NoError(t, store.WriteIndex())
+
+	saved, size, skipped := store.Summary()
+	assert.Equal(t, 3, saved)
+	assert.Equal(t, int64(15), size)
+	assert.Equal(t, 1, skipped)
+
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	require.NoError(t, err)
+	var index struct {
+		Bodies []Entry `json:"bodies"`
+	}
+	require.NoError(t, json.Unmarshal(data, &index))
+	require.Len(t, index.Bodies, 3)
+
+	missing := index.Bodies[0]
+	assert.Equal(t, server.URL+"/missing", missing.URL)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+	assert.True(t, missing.Failed)
+	assert.Equal(t, "text/plain", missing.ContentType)
+	assert.Equal(t, int64(9), missing.Bytes)
+	assert.True(t, strings.HasPrefix(missing.File, "000001-127.0.0.1_"), missing.File)
+	body, err := os.ReadFile(filepath.Join(dir, missing.File))
+	require.NoError(t, err)
+	assert.Equal(t, "not found", string(body))
+
+	assert.Empty(t, index.Bodies[1].File, "empty bodies have no file")
+
+	page := index.Bodies[2]
+	assert.False(t, page.Failed)
+	assert.True(t, page.Truncated)
+	assert.Equal(t, int64(6), page.Bytes)
+	assert.True(t, strings.HasSuffix(page.File, "_page_one.body"), page.File)
+	body, err = os.ReadFile(filepath.Join(dir, page.File))
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaa", string(body))
+}
+
+func TestSlug(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://example.com/", expected: "example.com"},
+		{url: "https://example.com/a/b?q=1", expected: "example.com_a_b_q_1"},
+		{url: "https://example.com/" + strings.Repeat("x", 200), expected: "example.com_" + strings.Repeat("x", maxSlugLength-len("example.com_"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, slug(tt.url))
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Version is the version of the cassette file format
+const Version = 1
+
+// Cassette holds the HTTP interactions of a run, in the order they completed
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response or error it got
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+
+	// Truncated is set when the body was cut at the recorder's size cap, or
+	// the run closed it before reading it to the end
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Error is the transport error of a request that got no response
+	Error string `json:"error,omitempty"`
+}
+
+// key identifies the request of an interaction
+func (i Interaction) key() string {
+	return i.Method + " " + i.URL
+}
+
+// Recorder is a RoundTripper that records every interaction passing through
+// it to another RoundTripper. Each interaction is written to the cassette
+// file as soon as its response body is closed, so the recorder holds only
+// the bodies still being read.
+type Recorder struct {
+	next         http.RoundTripper
+	maxBodyBytes int64
+
+	mu    sync.Mutex
+	file  *os.File
+	count int
+	err   error
+}
+
+// Create creates a cassette file and a recorder that sends requests through
+// next and writes them to it, keeping up to maxBodyBytes of each response
+// body. Close must be called to complete the file.
+func Create(path string, next http.RoundTripper, maxBodyBytes int64) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	if _, err := fmt.Fprintf(file, "{\n  \"version\": %d,\n  \"interactions\": [", Version); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return &Recorder{next: next, maxBodyBytes: maxBodyBytes, file: file}, nil
+}
+
+// RoundTrip sends the request and records it. The response body is recorded
+// as the caller reads it, so the caller's own limits on reading it still
+// apply.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{Method: req.Method, URL: req.URL.String()}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		r.add(interaction)
+		return nil, err
+	}
+
+	interaction.Status = resp.StatusCode
+	interaction.Header = resp.Header.Clone()
+	if resp.Body == nil || resp.Body == http.NoBody {
+		r.add(interaction)
+		return resp, nil
+	}
+	resp.Body = &recordBody{ReadCloser: resp.Body, recorder: r, interaction: interaction}
+	return resp, nil
+}
+
+// add writes an interaction to the cassette file. The first write error is
+// kept and returned by Close.
+func (r *Recorder) add(interaction Interaction) {
+	data, err := json.Marshal(interaction)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err != nil {
+		r.err = fmt.Errorf("failed to encode cassette interaction: %w", err)
+		return
+	}
+
+	separator := "\n    "
+	if r.count > 0 {
+		separator = "," + separator
+	}
+	if _, err := r.file.Write(append([]byte(separator), data...)); err != nil {
+		r.err = fmt.Errorf("failed to write cassette: %w", err)
+		return
+	}
+	r.count++
+}
+
+// Len returns how many interactions have been recorded
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Close completes and closes the cassette file. Bodies still open are not
+// recorded.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := io.WriteString(r.file, "\n  ]\n}\n")
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// recordBody is a response body that copies reads into its interaction, up
+// to the recorder's size cap, and records the interaction when closed
+type recordBody struct {
+	io.ReadCloser
+	recorder    *Recorder
+	interaction Interaction
+
+	body bytes.Buffer
+	eof  bool
+	once sync.Once
+}
+
+// Read reads from the underlying body and keeps the bytes read
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		kept := p[:n]
+		if remaining := b.recorder.maxBodyBytes - int64(b.body.Len()); int64(n) > remaining {
+			kept = p[:max(remaining, 0)]
+			b.interaction.Truncated = true
+		}
+		b.body.Write(kept)
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// Close closes the underlying body and records the interaction
+func (b *recordBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if !b.eof {
+			b.interaction.Truncated = true
+		}
+		b.interaction.Body = b.body.Bytes()
+		b.recorder.add(b.interaction)
+	})
+	return err
+}
+
+// Player is a RoundTripper that answers requests from a cassette without
+// touching the network
+type Player struct {
+	mu      sync.Mutex
+	queues  map[string][]Interaction
+	missing int
+}
+
+// Load reads a cassette file and creates a player for it
+func Load(path string) (*Player, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if cassette.Version > Version {
+		return nil, fmt.Errorf("cassette %s uses version %d; this version reads up to %d", path, cassette.Version, Version)
+	}
+
+	return NewPlayer(cassette), nil
+}
+
+// NewPlayer creates a player for a cassette. Requests for the same URL are
+// answered with its recorded interactions in order, and with the last one
+// once they run out.
+func NewPlayer(cassette Cassette) *Player {
+	queues := make(map[string][]Interaction)
+	for _, interaction := range cassette.Interactions {
+		queues[interaction.key()] = append(queues[interaction.key()], interaction)
+	}
+	return &Player{queues: queues}
+}
+
+// RoundTrip answers the request from the cassette, failing requests that
+// were not recorded
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	interaction, ok := p.next(Interaction{Method: req.Method, URL: req.URL.String()}.key())
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
+
+// next takes the next interaction recorded for a request
+func (p *Player) next(key string) (Interaction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queue := p.queues[key]
+	if len(queue) == 0 {
+		p.missing++
+		return Interaction{}, false
+	}
+	if len(queue) > 1 {
+		p.queues[key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// Missing returns how many requests had no recorded response
+func (p *Player) Missing() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.missing
+}
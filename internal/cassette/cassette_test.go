@@ -0,0 +1,131 @@
+package cassette
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("X-Cache", "MISS")
+		if n > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := Create(path, http.DefaultTransport, 1024)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder}
+	for range 2 {
+		resp, err := client.Get(server.URL + "/page")
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, "hello", string(body))
+	}
+	assert.Equal(t, 2, recorder.Len())
+	require.NoError(t, recorder.Close())
+	server.Close()
+
+	player, err := Load(path)
+	require.NoError(t, err)
+	client = &http.Client{Transport: player}
+
+	// Interactions replay in order, and the last one repeats
+	for _, expected := range []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusServiceUnavailable} {
+		resp, err := client.Get(server.URL + "/page")
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, expected, resp.StatusCode)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello", string(body))
+	}
+
+	_, err = client.Get(server.URL + "/other")
+	assert.ErrorContains(t, err, "no recorded response for GET")
+	assert.Equal(t, 1, player.Missing())
+}
+
+func TestRecorderStreamsCappedBodies(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := Create(path, http.DefaultTransport, 10)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder}
+
+	// A body read in full is recorded up to the cap, and written to the
+	// file as soon as it is closed
+	resp, err := client.Get(server.URL + "/read")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, body, 100, "the caller still gets the whole body")
+	require.NoError(t, resp.Body.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), server.URL+"/read")
+
+	// A body closed unread is recorded empty
+	resp, err = client.Get(server.URL + "/skipped")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, recorder.Close())
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var cassette Cassette
+	require.NoError(t, json.Unmarshal(data, &cassette))
+	require.Len(t, cassette.Interactions, 2)
+	assert.Equal(t, []byte("xxxxxxxxxx"), cassette.Interactions[0].Body)
+	assert.True(t, cassette.Interactions[0].Truncated)
+	assert.Empty(t, cassette.Interactions[1].Body)
+	assert.True(t, cassette.Interactions[1].Truncated)
+}
+
+func TestReplayTransportError(t *testing.T) {
+	t.Parallel()
+
+	player := NewPlayer(Cassette{Interactions: []Interaction{
+		{Method: http.MethodGet, URL: "https://example.com/down", Error: "connection refused"},
+	}})
+
+	_, err := (&http.Client{Transport: player}).Get("https://example.com/down")
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "interactions": []}`), 0600))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "uses version 2")
+}
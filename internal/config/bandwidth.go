@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bandwidthUnits maps bandwidth suffixes, lowercased, to bytes. Byte units
+// are decimal or binary; bit units are decimal, as network links are rated.
+var bandwidthUnits = map[string]float64{
+	"":     1,
+	"b":    1,
+	"kb":   1000,
+	"mb":   1000 * 1000,
+	"gb":   1000 * 1000 * 1000,
+	"kib":  1024,
+	"mib":  1024 * 1024,
+	"gib":  1024 * 1024 * 1024,
+	"kbit": 1000 / 8.0,
+	"mbit": 1000 * 1000 / 8.0,
+	"gbit": 1000 * 1000 * 1000 / 8.0,
+}
+
+// ParseBandwidth parses a bandwidth such as "500KB", "10MiB" or "50Mbit",
+// per second and optionally written with "/s", into bytes per second. A bare number is bytes per second, and
+// an empty value means no limit and returns 0.
+func ParseBandwidth(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	number := strings.TrimRightFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	unit := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(value[len(number):], "/s")))
+
+	amount, err := strconv.ParseFloat(number, 64)
+	multiplier, ok := bandwidthUnits[unit]
+	if err != nil || !ok {
+		return 0, fmt.Errorf("invalid bandwidth: %s (use a number with an optional unit such as KB, MiB or Mbit)", value)
+	}
+
+	bytesPerSecond := int64(amount * multiplier)
+	if bytesPerSecond < 1 {
+		return 0, fmt.Errorf("bandwidth must be at least 1 byte per second")
+	}
+	return bytesPerSecond, nil
+}
+
+// ParseMemoryLimit parses a memory size such as "512MiB" or "2GB" into
+// bytes. A bare number is bytes, bit units are not accepted, and an empty
+// value means no limit and returns 0.
+func ParseMemoryLimit(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	number := strings.TrimRightFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	unit := strings.ToLower(strings.TrimSpace(value[len(number):]))
+
+	amount, err := strconv.ParseFloat(number, 64)
+	multiplier, ok := bandwidthUnits[unit]
+	if err != nil || !ok || strings.HasSuffix(unit, "bit") {
+		return 0, fmt.Errorf("invalid memory limit: %s (use a number with an optional unit such as MB, MiB or GiB)", value)
+	}
+
+	bytes := int64(amount * multiplier)
+	if bytes < 1 {
+		return 0, fmt.Errorf("memory limit must be at least 1 byte")
+	}
+	return bytes, nil
+}
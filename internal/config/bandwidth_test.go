@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		value     string
+		expected  int64
+		wantError bool
+	}{
+		{name: "empty means no limit", value: "", expected: 0},
+		{name: "bytes", value: "2048", expected: 2048},
+		{name: "kilobytes", value: "500KB", expected: 500000},
+		{name: "mebibytes", value: "10MiB", expected: 10 * 1024 * 1024},
+		{name: "fractional", value: "1.5MB", expected: 1500000},
+		{name: "megabits", value: "50Mbit", expected: 6250000},
+		{name: "per second suffix", value: "5mb/s", expected: 5000000},
+		{name: "space before unit", value: "5 MB", expected: 5000000},
+		{name: "unknown unit", value: "5 parsecs", wantError: true},
+		{name: "no number", value: "MB", wantError: true},
+		{name: "zero", value: "0", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseBandwidth(tt.value)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		value     string
+		expected  int64
+		wantError bool
+	}{
+		{name: "empty means no limit", value: "", expected: 0},
+		{name: "bytes", value: "1048576", expected: 1048576},
+		{name: "mebibytes", value: "512MiB", expected: 512 * 1024 * 1024},
+		{name: "gigabytes", value: "1.5GB", expected: 1500000000},
+		{name: "space before unit", value: "2 GiB", expected: 2 * 1024 * 1024 * 1024},
+		{name: "bits are not a size", value: "512Mbit", wantError: true},
+		{name: "rate suffix", value: "512MB/s", wantError: true},
+		{name: "zero", value: "0", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseMemoryLimit(tt.value)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
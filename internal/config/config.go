@@ -2,8 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -13,16 +19,78 @@ import (
 const (
 	FlagSitemapURL                       = "sitemap-url"
 	FlagMaxWorkers                       = "max-workers"
+	FlagMaxConcurrentPerHost             = "max-concurrent-per-host"
+	FlagHostOrder                        = "host-order"
+	FlagHostOrderThreshold               = "host-order-threshold"
+	FlagPriorityPattern                  = "priority-pattern"
+	FlagOrderBy                          = "order-by"
 	FlagRequestRate                      = "request-rate"
+	FlagRequestBurst                     = "request-burst"
+	FlagStrictPacing                     = "strict-pacing"
+	FlagRateRamp                         = "rate-ramp"
+	FlagFinishBy                         = "finish-by"
+	FlagMaxBandwidth                     = "max-bandwidth"
+	FlagMemoryLimit                      = "memory-limit"
+	FlagRequestMode                      = "request-mode"
+	FlagRangeExtensions                  = "range-extensions"
+	FlagRangeContentTypes                = "range-content-types"
 	FlagRequestTimeout                   = "request-timeout"
+	FlagConnectTimeout                   = "connect-timeout"
+	FlagTLSHandshakeTimeout              = "tls-handshake-timeout"
+	FlagResponseHeaderTimeout            = "response-header-timeout"
+	FlagBodyTimeout                      = "body-timeout"
 	FlagUserAgent                        = "user-agent"
 	FlagHeaders                          = "headers"
+	FlagRequestTemplate                  = "request-template"
+	FlagDevice                           = "device"
+	FlagDeviceProfiles                   = "device-profiles"
+	FlagDeviceReport                     = "device-report"
+	FlagDeviceSizeTolerance              = "device-size-tolerance"
 	FlagCacheVerificationMode            = "cache-verification-mode"
 	FlagCacheHeader                      = "cache-header"
+	FlagCacheEfficacyReport              = "cache-efficacy-report"
+	FlagCompareHeaders                   = "compare-headers"
+	FlagHeaderDiffReport                 = "header-diff-report"
+	FlagEdge                             = "edge"
+	FlagEdgeReport                       = "edge-report"
+	FlagPurge                            = "purge"
+	FlagPurgeMethod                      = "purge-method"
+	FlagPurgeWait                        = "purge-wait"
+	FlagPurgeTags                        = "purge-tags"
+	FlagCDN                              = "cdn"
+	FlagCloudflareZoneID                 = "cloudflare-zone-id"
+	FlagFastlyServiceID                  = "fastly-service-id"
+	FlagFastlySoftPurge                  = "fastly-soft-purge"
+	FlagPing                             = "ping"
+	FlagPingMinSuccessRate               = "ping-min-success-rate"
+	FlagIndexNowKey                      = "indexnow-key"
+	FlagIndexNowKeyLocation              = "indexnow-key-location"
+	FlagIndexNowEndpoint                 = "indexnow-endpoint"
+	FlagVerifyPublished                  = "verify-published"
+	FlagVerifyPublishedTimeout           = "verify-published-timeout"
 	FlagOutputFormat                     = "output-format"
 	FlagQuiet                            = "quiet"
 	FlagProgressInterval                 = "progress-interval"
 	FlagDebug                            = "debug"
+	FlagVerbose                          = "verbose"
+	FlagColor                            = "color"
+	FlagGitHubAnnotations                = "github-annotations"
+	FlagFailuresFile                     = "failures-file"
+	FlagBadgeFile                        = "badge-file"
+	FlagCSVDelimiter                     = "csv-delimiter"
+	FlagCSVQuote                         = "csv-quote"
+	FlagCleanSitemap                     = "clean-sitemap"
+	FlagResultsFile                      = "results-file"
+	FlagResultsSampleRate                = "results-sample-rate"
+	FlagExport                           = "export"
+	FlagEnvFile                          = "env-file"
+	FlagRepeat                           = "repeat"
+	FlagRepeatInterval                   = "repeat-interval"
+	FlagRepeatSchedule                   = "repeat-schedule"
+	FlagSeed                             = "seed"
+	FlagReportFormat                     = "report-format"
+	FlagLatencyRegressionRatio           = "latency-regression-ratio"
+	FlagLatencyRegressionMin             = "latency-regression-min"
 	FlagBackoffEnabled                   = "backoff-enabled"
 	FlagBackoffInitialDelay              = "backoff-initial-delay"
 	FlagBackoffMaxDelay                  = "backoff-max-delay"
@@ -30,10 +98,187 @@ const (
 	FlagResponseTimeDegradationThreshold = "response-time-degradation-threshold"
 	FlagForbiddenErrorThreshold          = "forbidden-error-threshold"
 	FlagForbiddenErrorWindow             = "forbidden-error-window"
+	FlagBackoffRecovery                  = "backoff-recovery"
+	FlagBackoffDecayInterval             = "backoff-decay-interval"
+	FlagCancelOn                         = "cancel-on"
+	FlagRetry                            = "retry"
+	FlagExpectStatus                     = "expect-status"
+	FlagCoverageReport                   = "coverage-report"
+	FlagCoverageFormat                   = "coverage-format"
+	FlagTimelineReport                   = "timeline-report"
+	FlagTimelineFormat                   = "timeline-format"
+	FlagAuditReport                      = "audit-report"
+	FlagAuditVariants                    = "audit-variants"
+	FlagAnalyzers                        = "analyzers"
+	FlagFindingsReport                   = "findings-report"
+	FlagLastModReport                    = "lastmod-report"
+	FlagLastModTolerance                 = "lastmod-tolerance"
+	FlagDuplicatesReport                 = "duplicates-report"
+	FlagDuplicatesDistance               = "duplicates-distance"
+	FlagSourceIP                         = "source-ip"
+	FlagInterface                        = "interface"
+	FlagRecord                           = "record"
+	FlagRecordMaxBodyBytes               = "record-max-body-bytes"
+	FlagHealthAddr                       = "health-addr"
+	FlagDial                             = "dial"
+	FlagResolver                         = "resolver"
+	FlagProxy                            = "proxy"
+	FlagProxyStrict                      = "proxy-strict"
+	FlagSigV4Service                     = "sigv4-service"
+	FlagSigV4Region                      = "sigv4-region"
+	FlagAWSProfile                       = "aws-profile"
+	FlagReplay                           = "replay"
+	FlagDualStack                        = "dual-stack"
+	FlagDualStackReport                  = "dual-stack-report"
+	FlagDualStackSlowdownRatio           = "dual-stack-slowdown-ratio"
+	FlagDualStackSlowdownMin             = "dual-stack-slowdown-min"
+	FlagHARFile                          = "har-file"
+	FlagHARMode                          = "har-mode"
+	FlagHARSampleRate                    = "har-sample-rate"
+	FlagHARMaxBodyBytes                  = "har-max-body-bytes"
+	FlagHARIncludeSecrets                = "har-include-secrets"
+	FlagTraceFile                        = "trace-file"
+	FlagCaptureDir                       = "capture-dir"
+	FlagCaptureSampleRate                = "capture-sample-rate"
+	FlagCaptureMaxBytes                  = "capture-max-bytes"
+	FlagRender                           = "render"
+	FlagRenderPattern                    = "render-pattern"
+	FlagRenderLimit                      = "render-limit"
+	FlagRenderTimeout                    = "render-timeout"
+	FlagRenderReport                     = "render-report"
+	FlagChromePath                       = "chrome-path"
+	FlagMaxSitemapBytes                  = "max-sitemap-bytes"
+	FlagMaxSitemapDepth                  = "max-sitemap-depth"
+	FlagMaxSitemapURLs                   = "max-sitemap-urls"
+	FlagSitemapRefresh                   = "sitemap-refresh"
+	FlagCrawlMedia                       = "crawl-media"
+	FlagCrawlAlternates                  = "crawl-alternates"
+	FlagBlockDomains                     = "block-domains"
+	FlagModifiedSince                    = "modified-since"
+	FlagModifiedWithin                   = "modified-within"
+	FlagRejectedReport                   = "rejected-report"
 )
 
+// Command name constants for the supported subcommands
+const (
+	CommandCrawl       = "crawl"
+	CommandAudit       = "audit"
+	CommandReportDiff  = "report diff"
+	CommandReportMerge = "report merge"
+	CommandParse       = "parse"
+	CommandParseStats  = "parse --stats"
+	CommandParseCheck  = "parse --validate"
+	CommandWarm        = "warm"
+	CommandValidate    = "validate"
+)
+
+// Request modes
+const (
+	// RequestModeGet fetches each URL in full
+	RequestModeGet = "get"
+
+	// RequestModeHead sends HEAD requests
+	RequestModeHead = "head"
+
+	// RequestModeRange requests only the first byte of each URL, which
+	// makes most CDNs fetch and cache the whole object
+	RequestModeRange = "range"
+
+	// RequestModeAssets fetches pages in full but requests only the first
+	// byte of large assets such as video and archives
+	RequestModeAssets = "assets"
+)
+
+// Crawl orders
+const (
+	// OrderBySitemap crawls URLs in the order the sitemap lists them
+	OrderBySitemap = "sitemap"
+
+	// OrderByPriority crawls URLs with a higher sitemap <priority> first
+	OrderByPriority = "priority"
+)
+
+// Repeat schedules
+const (
+	// RepeatScheduleAll crawls every URL on every repeat iteration
+	RepeatScheduleAll = "all"
+
+	// RepeatScheduleChangeFreq crawls a URL again only once its sitemap
+	// <changefreq>, scaled by its <priority>, says it is due
+	RepeatScheduleChangeFreq = "changefreq"
+)
+
+// defaultRangeExtensions are the file extensions of the large assets that
+// the assets request mode requests a byte of
+var defaultRangeExtensions = []string{
+	"mp4", "m4v", "mov", "webm", "mkv", "avi", "m3u8", "ts",
+	"mp3", "m4a", "aac", "wav", "flac", "ogg",
+	"zip", "gz", "tgz", "bz2", "xz", "7z", "rar", "tar",
+	"iso", "dmg", "exe", "msi", "pkg", "deb", "rpm", "apk",
+}
+
+// defaultRangeContentTypes are the media types, or type prefixes ending in
+// "/", of the large assets that the assets request mode requests a byte of
+var defaultRangeContentTypes = []string{
+	"video/", "audio/",
+	"application/zip", "application/gzip", "application/x-tar", "application/x-7z-compressed",
+	"application/vnd.rar", "application/x-bzip2", "application/x-xz",
+	"application/x-iso9660-image", "application/x-apple-diskimage", "application/vnd.android.package-archive",
+	"application/octet-stream",
+}
+
+// defaultCompareHeaders are the response headers compared between cache
+// verification passes; a change in any of them usually means the two
+// requests were answered by different backends
+var defaultCompareHeaders = []string{"Cache-Control", "Vary", "Content-Length"}
+
+// warmDefaults are the defaults of the warm command, applied to settings
+// not given on the command line or in the environment
+var warmDefaults = map[string]interface{}{
+	FlagRequestMode: RequestModeRange,
+	FlagRequestRate: 20,
+	FlagRateRamp:    time.Minute,
+	FlagRetry:       []string{"5xx=2"},
+}
+
+// Environment variables holding CDN API tokens. They are not flags so the
+// tokens stay out of process listings.
+const (
+	CloudflareAPITokenEnv = "CLOUDFLARE_API_TOKEN"
+	FastlyAPITokenEnv     = "FASTLY_API_TOKEN"
+)
+
+// cdnCacheHeaders are the cache status headers of the supported CDNs, used
+// when --cache-header is not given
+var cdnCacheHeaders = map[string]string{
+	"cloudflare": "CF-Cache-Status",
+	"fastly":     "X-Cache",
+}
+
+// indexNowKeyPattern matches the keys the IndexNow protocol accepts
+var indexNowKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9-]{8,128}$`)
+
 // Config holds all configuration for the sitemap crawler
 type Config struct {
+	// Command is the subcommand selected on the command line
+	Command string `mapstructure:"-"`
+
+	// CommandArgs holds the positional arguments of the subcommand
+	CommandArgs []string `mapstructure:"-"`
+
+	// ValidatedCommand and Violations are set by the validate command: the
+	// command the configuration was checked for and the problems found,
+	// which other commands fail on instead
+	ValidatedCommand string      `mapstructure:"-"`
+	Violations       []Violation `mapstructure:"-"`
+
+	// Version is the build version, recorded in the run metadata of every
+	// output
+	Version string `mapstructure:"-"`
+
+	// EnvFile is the .env file loaded before reading the environment
+	EnvFile string `mapstructure:"env-file"`
+
 	// Sitemap configuration
 	SitemapURL string `mapstructure:"sitemap-url"`
 
@@ -41,19 +286,304 @@ type Config struct {
 	MaxWorkers     int           `mapstructure:"max-workers"`
 	RequestRate    int           `mapstructure:"request-rate"`
 	RequestTimeout time.Duration `mapstructure:"request-timeout"`
+	Repeat         int           `mapstructure:"repeat"`
 	UserAgent      string        `mapstructure:"user-agent"`
 
+	// RepeatInterval is the least time between the starts of repeat
+	// iterations, and RepeatSchedule which URLs each iteration crawls
+	RepeatInterval time.Duration `mapstructure:"repeat-interval"`
+	RepeatSchedule string        `mapstructure:"repeat-schedule"`
+
+	// Seed seeds the run's random choices, such as which requests are
+	// sampled; zero picks a random seed
+	Seed uint64 `mapstructure:"seed"`
+
+	// MaxConcurrentPerHost caps the requests in flight to any one host;
+	// zero leaves MaxWorkers as the only cap
+	MaxConcurrentPerHost int `mapstructure:"max-concurrent-per-host"`
+
+	// HostOrder lists groups of hosts crawled one after another, such as
+	// "api.example.com>www.example.com"; the crawler parses it. A group
+	// starts once HostOrderThreshold of the earlier groups' URLs completed.
+	HostOrder          string  `mapstructure:"host-order"`
+	HostOrderThreshold float64 `mapstructure:"host-order-threshold"`
+
+	// PriorityPatterns are regular expressions for high priority URLs,
+	// which are crawled first within their host group. Once FinishBy has
+	// passed, the other URLs not yet sent to workers are skipped.
+	PriorityPatterns []string `mapstructure:"priority-pattern"`
+
+	// OrderBy is the order URLs are crawled in within their host group and
+	// priority lane: sitemap order, or by sitemap <priority>
+	OrderBy string `mapstructure:"order-by"`
+
+	// Phase timeouts limit parts of a request within RequestTimeout;
+	// response header and body timeouts of zero mean no limit
+	ConnectTimeout        time.Duration `mapstructure:"connect-timeout"`
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls-handshake-timeout"`
+	ResponseHeaderTimeout time.Duration `mapstructure:"response-header-timeout"`
+	BodyTimeout           time.Duration `mapstructure:"body-timeout"`
+
+	// RequestBurst is how many requests may go out at once before the rate
+	// applies; zero means the rate. StrictPacing spaces every request
+	// evenly, as a burst of 1.
+	RequestBurst int  `mapstructure:"request-burst"`
+	StrictPacing bool `mapstructure:"strict-pacing"`
+
+	// RateRamp raises the request rate from a tenth of RequestRate to all
+	// of it over this long at the start of each pass
+	RateRamp time.Duration `mapstructure:"rate-ramp"`
+
+	// FinishBy is a duration from the start of the run, or a timestamp, the
+	// crawl should finish by; ParseFinishBy reads it. The request rate
+	// follows what finishing on time needs, up to RequestRate.
+	FinishBy string `mapstructure:"finish-by"`
+
+	// MaxBandwidth caps total download throughput, as a rate such as
+	// "10MB" or "50Mbit" per second; ParseBandwidth reads it
+	MaxBandwidth string `mapstructure:"max-bandwidth"`
+
+	// MemoryLimit is the crawler's memory budget, as a size such as
+	// "512MiB"; ParseMemoryLimit reads it. Workers are reduced, and intake
+	// paused, as memory use approaches it.
+	MemoryLimit string `mapstructure:"memory-limit"`
+
+	// RequestMode is how URLs are requested without a request template:
+	// get, head, range or assets
+	RequestMode string `mapstructure:"request-mode"`
+
+	// RangeExtensions and RangeContentTypes identify the large assets that
+	// the assets request mode requests a byte of
+	RangeExtensions   []string `mapstructure:"range-extensions"`
+	RangeContentTypes []string `mapstructure:"range-content-types"`
+
 	// Headers configuration
 	Headers map[string]string `mapstructure:"headers"`
 
+	// RequestTemplate is a YAML file setting the method, headers and body
+	// of requests
+	RequestTemplate string `mapstructure:"request-template"`
+
+	// Devices crawls every URL once per named device profile and compares
+	// the responses
+	Devices             []string `mapstructure:"device"`
+	DeviceProfiles      string   `mapstructure:"device-profiles"`
+	DeviceReport        string   `mapstructure:"device-report"`
+	DeviceSizeTolerance float64  `mapstructure:"device-size-tolerance"`
+
+	// Sitemap resource limits
+	MaxSitemapBytes int64 `mapstructure:"max-sitemap-bytes"`
+	MaxSitemapDepth int   `mapstructure:"max-sitemap-depth"`
+	MaxSitemapURLs  int   `mapstructure:"max-sitemap-urls"`
+
+	// SitemapRefresh is how often the sitemap is fetched again during a
+	// crawl, to crawl URLs added since and drop removed ones not yet
+	// crawled; zero never fetches it again
+	SitemapRefresh time.Duration `mapstructure:"sitemap-refresh"`
+
+	// CrawlMedia also crawls the image and video URLs listed in the
+	// sitemap's Google extensions
+	CrawlMedia bool `mapstructure:"crawl-media"`
+
+	// CrawlAlternates also crawls the localized variants the sitemap's
+	// hreflang links name
+	CrawlAlternates bool `mapstructure:"crawl-alternates"`
+
+	// Sitemap URLs that are not crawled
+	BlockDomains   []string `mapstructure:"block-domains"`
+	RejectedReport string   `mapstructure:"rejected-report"`
+
+	// ModifiedSince and ModifiedWithin crawl only the URLs whose <lastmod>
+	// is at or after a timestamp, or within a duration of the start of the
+	// run; ParseModifiedCutoff reads them
+	ModifiedSince  string        `mapstructure:"modified-since"`
+	ModifiedWithin time.Duration `mapstructure:"modified-within"`
+
+	// Network configuration
+	SourceIP  string `mapstructure:"source-ip"`
+	Interface string `mapstructure:"interface"`
+
+	// Record writes every sitemap and page response to a cassette file;
+	// Replay answers requests from one instead of the network
+	Record string `mapstructure:"record"`
+	Replay string `mapstructure:"replay"`
+
+	// RecordMaxBodyBytes caps the bytes of each response body recorded
+	RecordMaxBodyBytes int64 `mapstructure:"record-max-body-bytes"`
+
+	// Dial holds rules that send connections for a host to a Unix socket or
+	// another address; the crawler parses them
+	Dial []string `mapstructure:"dial"`
+
+	// Resolver is a DNS server or DNS-over-HTTPS endpoint for hostname
+	// lookups; the crawler parses it
+	Resolver string `mapstructure:"resolver"`
+
+	// Proxy is the proxy every request goes through; empty uses the one the
+	// environment configures, if any. ProxyStrict fails the run rather than
+	// let any connection or lookup of a crawled host bypass it.
+	Proxy       string `mapstructure:"proxy"`
+	ProxyStrict bool   `mapstructure:"proxy-strict"`
+
+	// SigV4Service signs every request with AWS Signature Version 4 for
+	// this service, in SigV4Region; the crawler falls back to AWS_REGION
+	// for an empty region. AWSProfile names the shared credentials profile.
+	SigV4Service string `mapstructure:"sigv4-service"`
+	SigV4Region  string `mapstructure:"sigv4-region"`
+	AWSProfile   string `mapstructure:"aws-profile"`
+
+	// HealthAddr serves /healthz and /readyz probes for the life of a run
+	HealthAddr string `mapstructure:"health-addr"`
+
+	// DualStack requests every URL over IPv4 and again over IPv6 and
+	// reports URLs that fail or are much slower over one family
+	DualStack              bool          `mapstructure:"dual-stack"`
+	DualStackReport        string        `mapstructure:"dual-stack-report"`
+	DualStackSlowdownRatio float64       `mapstructure:"dual-stack-slowdown-ratio"`
+	DualStackSlowdownMin   time.Duration `mapstructure:"dual-stack-slowdown-min"`
+
 	// Cache verification mode
 	CacheVerificationMode bool   `mapstructure:"cache-verification-mode"`
 	CacheHeader           string `mapstructure:"cache-header"`
 
+	// CacheEfficacyReport ranks URLs by how little warming helped them
+	CacheEfficacyReport string `mapstructure:"cache-efficacy-report"`
+
+	// CompareHeaders are the response headers compared between the warm-up
+	// and verification passes; HeaderDiffReport lists the URLs where they
+	// changed
+	CompareHeaders   []string `mapstructure:"compare-headers"`
+	HeaderDiffReport string   `mapstructure:"header-diff-report"`
+
+	// Edges are CDN edge locations, in the form [name=]address[:port], that
+	// the verification pass is sent to one after another; EdgeReport
+	// compares their hit rates
+	Edges      []string `mapstructure:"edge"`
+	EdgeReport string   `mapstructure:"edge-report"`
+
+	// Purge invalidates every URL before it is warmed
+	Purge       string        `mapstructure:"purge"`
+	PurgeMethod string        `mapstructure:"purge-method"`
+	PurgeWait   time.Duration `mapstructure:"purge-wait"`
+	PurgeTags   []string      `mapstructure:"purge-tags"`
+
+	// CDN selects CDN-specific cache status and request ID headers
+	CDN string `mapstructure:"cdn"`
+
+	// Cloudflare API access; the token is only read from the environment
+	CloudflareZoneID   string `mapstructure:"cloudflare-zone-id"`
+	CloudflareAPIToken string `mapstructure:"-"`
+
+	// Fastly API access; the token is only read from the environment
+	FastlyServiceID string `mapstructure:"fastly-service-id"`
+	FastlySoftPurge bool   `mapstructure:"fastly-soft-purge"`
+	FastlyAPIToken  string `mapstructure:"-"`
+
+	// Search engine notification after a successful crawl
+	Ping                []string `mapstructure:"ping"`
+	PingMinSuccessRate  float64  `mapstructure:"ping-min-success-rate"`
+	IndexNowKey         string   `mapstructure:"indexnow-key"`
+	IndexNowKeyLocation string   `mapstructure:"indexnow-key-location"`
+	IndexNowEndpoint    string   `mapstructure:"indexnow-endpoint"`
+
+	// VerifyPublished is the URL the clean sitemap is published at, fetched
+	// after it is written to check that it was deployed; VerifyPublishedTimeout
+	// is how long to keep checking for it
+	VerifyPublished        string        `mapstructure:"verify-published"`
+	VerifyPublishedTimeout time.Duration `mapstructure:"verify-published-timeout"`
+
 	// Output configuration
 	OutputFormat     string        `mapstructure:"output-format"`
 	Quiet            bool          `mapstructure:"quiet"`
 	ProgressInterval time.Duration `mapstructure:"progress-interval"`
+	Verbose          int           `mapstructure:"verbose"`
+	Color            string        `mapstructure:"color"`
+
+	// GitHub Actions annotation output
+	GitHubAnnotations bool `mapstructure:"github-annotations"`
+
+	// CSV output configuration
+	CSVDelimiter string `mapstructure:"csv-delimiter"`
+	CSVQuote     string `mapstructure:"csv-quote"`
+
+	// Failures export configuration
+	FailuresFile string `mapstructure:"failures-file"`
+
+	// Status badge export configuration
+	BadgeFile string `mapstructure:"badge-file"`
+
+	// Per-URL results export configuration. The sample rate applies to
+	// successful results that were not cache misses; the rest are always
+	// written.
+	ResultsFile       string  `mapstructure:"results-file"`
+	ResultsSampleRate float64 `mapstructure:"results-sample-rate"`
+
+	// Stats exporters, each name=destination, that receive the final
+	// statistics and every result once the crawl finishes
+	Export []string `mapstructure:"export"`
+
+	// Report command configuration
+	ReportFormat           string        `mapstructure:"report-format"`
+	LatencyRegressionRatio float64       `mapstructure:"latency-regression-ratio"`
+	LatencyRegressionMin   time.Duration `mapstructure:"latency-regression-min"`
+
+	// Clean sitemap export configuration
+	CleanSitemap string `mapstructure:"clean-sitemap"`
+
+	// Coverage report configuration
+	CoverageReport string `mapstructure:"coverage-report"`
+	CoverageFormat string `mapstructure:"coverage-format"`
+
+	// Latency-over-time report configuration
+	TimelineReport string `mapstructure:"timeline-report"`
+	TimelineFormat string `mapstructure:"timeline-format"`
+
+	// Audit report configuration
+	AuditReport string `mapstructure:"audit-report"`
+
+	// AuditVariants is the fraction of pages returning 200 whose
+	// trailing-slash and case variants are probed after an audit crawl
+	AuditVariants float64 `mapstructure:"audit-variants"`
+
+	// Page analyzer configuration. Analyzers are built-in checks run over
+	// each HTML response; an empty list with a findings report runs them
+	// all.
+	Analyzers      []string `mapstructure:"analyzers"`
+	FindingsReport string   `mapstructure:"findings-report"`
+
+	// Lastmod comparison configuration
+	LastModReport    string        `mapstructure:"lastmod-report"`
+	LastModTolerance time.Duration `mapstructure:"lastmod-tolerance"`
+
+	// Duplicate content configuration
+	DuplicatesReport   string `mapstructure:"duplicates-report"`
+	DuplicatesDistance int    `mapstructure:"duplicates-distance"`
+
+	// HAR export configuration
+	HARFile         string  `mapstructure:"har-file"`
+	HARMode         string  `mapstructure:"har-mode"`
+	HARSampleRate   float64 `mapstructure:"har-sample-rate"`
+	HARMaxBodyBytes int     `mapstructure:"har-max-body-bytes"`
+
+	// HARIncludeSecrets keeps the values of credential headers, such as
+	// Authorization, Cookie and Set-Cookie, which are redacted by default
+	HARIncludeSecrets bool `mapstructure:"har-include-secrets"`
+
+	// TraceFile records each worker's requests and waits as a Chrome trace
+	TraceFile string `mapstructure:"trace-file"`
+
+	// Response body capture configuration
+	CaptureDir        string  `mapstructure:"capture-dir"`
+	CaptureSampleRate float64 `mapstructure:"capture-sample-rate"`
+	CaptureMaxBytes   int64   `mapstructure:"capture-max-bytes"`
+
+	// JavaScript rendering configuration
+	Render        bool          `mapstructure:"render"`
+	RenderPattern string        `mapstructure:"render-pattern"`
+	RenderLimit   int           `mapstructure:"render-limit"`
+	RenderTimeout time.Duration `mapstructure:"render-timeout"`
+	RenderReport  string        `mapstructure:"render-report"`
+	ChromePath    string        `mapstructure:"chrome-path"`
 
 	// Debug mode
 	Debug bool `mapstructure:"debug"`
@@ -66,24 +596,40 @@ type Config struct {
 	ResponseTimeDegradationThreshold float64       `mapstructure:"response-time-degradation-threshold"`
 	ForbiddenErrorThreshold          int           `mapstructure:"forbidden-error-threshold"`
 	ForbiddenErrorWindow             time.Duration `mapstructure:"forbidden-error-window"`
+	BackoffRecovery                  string        `mapstructure:"backoff-recovery"`
+	BackoffDecayInterval             time.Duration `mapstructure:"backoff-decay-interval"`
+
+	// CancelOn holds extra rules that cancel the crawl; the crawler parses
+	// them
+	CancelOn []string `mapstructure:"cancel-on"`
+
+	// Retry holds rules for retrying failed requests by status code, status
+	// class or error category; the crawler parses them
+	Retry []string `mapstructure:"retry"`
+
+	// ExpectStatus holds per-pattern success criteria that replace the
+	// 200-399 success window for matching URLs; the crawler parses them
+	ExpectStatus []string `mapstructure:"expect-status"`
 }
 
 // Load loads configuration from command line flags and environment variables
 func Load() (*Config, error) {
-	cmd := createCommand()
+	command := CommandCrawl
+	var args []string
+	cmd := createCommand(&command, &args)
 
 	if err := addFlags(cmd); err != nil {
 		return nil, fmt.Errorf("failed to add flags: %w", err)
 	}
 
-	if err := markRequiredFlags(cmd); err != nil {
-		return nil, fmt.Errorf("failed to mark required flags: %w", err)
-	}
-
 	if err := cmd.Execute(); err != nil {
 		return nil, fmt.Errorf("failed to parse command line: %w", err)
 	}
 
+	if err := loadEnvFileFlag(cmd); err != nil {
+		return nil, err
+	}
+
 	if err := bindFlags(cmd); err != nil {
 		return nil, fmt.Errorf("failed to bind flags: %w", err)
 	}
@@ -92,7 +638,7 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse headers: %w", err)
 	}
 
-	cfg, err := createConfig()
+	cfg, err := createConfig(command, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config: %w", err)
 	}
@@ -100,9 +646,28 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// createCommand creates the cobra command with basic configuration
-func createCommand() *cobra.Command {
-	return &cobra.Command{
+// loadEnvFileFlag loads the .env file named by --env-file or
+// SITEMAP_CRAWLER_ENV_FILE, falling back to an optional .env in the working
+// directory
+func loadEnvFileFlag(cmd *cobra.Command) error {
+	path, err := cmd.PersistentFlags().GetString(FlagEnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s flag: %w", FlagEnvFile, err)
+	}
+	if path == "" {
+		path = os.Getenv("SITEMAP_CRAWLER_ENV_FILE")
+	}
+	if path == "" {
+		return loadEnvFile(DefaultEnvFile, false)
+	}
+	return loadEnvFile(path, true)
+}
+
+// createCommand creates the cobra command tree. The selected subcommand and
+// its positional arguments are recorded in command and args so that main can
+// dispatch on them.
+func createCommand(command *string, args *[]string) *cobra.Command {
+	rootCmd := &cobra.Command{
 		Use:   "sitemap-crawler",
 		Short: "A configurable sitemap crawling tool",
 		Long: `A sitemap crawling tool that can interpret common sitemap formats,
@@ -112,69 +677,327 @@ parallel workers, custom headers, and cache verification mode.`,
 			return nil // We'll handle execution in main
 		},
 	}
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   CommandAudit,
+		Short: "Audit sitemap URLs for indexability problems",
+		Long: `Crawl the sitemap once and combine status checks, redirect detection,
+canonical verification, noindex detection, and soft-404 heuristics into a
+consolidated per-URL SEO report.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			*command = CommandAudit
+			return nil
+		},
+	})
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   CommandWarm,
+		Short: "Warm caches with cache-oriented defaults",
+		Long: `Crawl the sitemap to warm caches. Unless set otherwise, URLs are requested
+with ranged GETs for their first byte, the rate ramps up to 20 requests per
+second over a minute, and 5xx responses are retried twice.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			*command = CommandWarm
+			return nil
+		},
+	})
+
+	parseCmd := &cobra.Command{
+		Use:   CommandParse,
+		Short: "List sitemap URLs without crawling them",
+		Long: `Parse the sitemap and print the URLs it lists, one per line. With --stats,
+report lastmod age and priority distributions, URLs per child sitemap and per
+host, and duplicate URLs instead, as a cheap check of sitemap generation.
+With --validate, check each sitemap file against the sitemaps.org protocol
+and report its violations, exiting with status 1 if any are found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stats, err := cmd.Flags().GetBool("stats")
+			if err != nil {
+				return err
+			}
+			validate, err := cmd.Flags().GetBool("validate")
+			if err != nil {
+				return err
+			}
+			*command = CommandParse
+			switch {
+			case stats && validate:
+				return fmt.Errorf("--stats and --validate cannot be combined")
+			case stats:
+				*command = CommandParseStats
+			case validate:
+				*command = CommandParseCheck
+			}
+			return nil
+		},
+	}
+	parseCmd.Flags().Bool("stats", false, "Report sitemap metadata statistics instead of listing URLs")
+	parseCmd.Flags().Bool("validate", false, "Check each sitemap file against the sitemaps.org protocol instead of listing URLs")
+	rootCmd.AddCommand(parseCmd)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   CommandValidate + " [crawl|audit|warm]",
+		Short: "Check a configuration without crawling",
+		Long: `Check the flags and environment for a command (crawl by default) and report
+every problem found, with the flags involved, instead of stopping at the
+first. Use --report-format json for tools that generate configurations.
+Exits with status 1 when the configuration is invalid.`,
+		ValidArgs: []string{CommandCrawl, CommandAudit, CommandWarm},
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			*command = CommandValidate
+			*args = positional
+			return nil
+		},
+	})
+
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Work with crawl results files",
+	}
+	reportCmd.AddCommand(&cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "Compare two results files",
+		Long: `Compare two results files written with --results-file and summarize
+status flips, latency regressions, and cache status changes per URL.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			*command = CommandReportDiff
+			*args = positional
+			return nil
+		},
+	})
+	reportCmd.AddCommand(&cobra.Command{
+		Use:   "merge FILE...",
+		Short: "Combine results files into one aggregate report",
+		Long: `Combine results files written with --results-file by the shards of a
+distributed crawl, or by separate runs, into one aggregate report. Each URL
+keeps one result per phase, from the most recently written file, and the
+success rates and latency percentiles are recomputed over the merged
+results. With --results-file the merged results are also written out.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			*command = CommandReportMerge
+			*args = positional
+			return nil
+		},
+	})
+	rootCmd.AddCommand(reportCmd)
+
+	return rootCmd
 }
 
 // addFlags adds all command line flags to the command
 func addFlags(cmd *cobra.Command) error {
 	addBasicFlags(cmd)
+	addNetworkFlags(cmd)
 	addCacheFlags(cmd)
 	addOutputFlags(cmd)
+	addRenderFlags(cmd)
 	addBackoffFlags(cmd)
+	addPingFlags(cmd)
+	addReportFlags(cmd)
 	return nil
 }
 
 // addBasicFlags adds basic crawler configuration flags
 func addBasicFlags(cmd *cobra.Command) {
-	cmd.Flags().String(FlagSitemapURL, "", "URL of the sitemap to crawl (required)")
-	cmd.Flags().Int(FlagMaxWorkers, 10, "Maximum number of parallel workers")
-	cmd.Flags().Int(FlagRequestRate, 100, "Maximum requests per second")
-	cmd.Flags().Duration(FlagRequestTimeout, 30*time.Second, "Request timeout")
-	cmd.Flags().String(FlagUserAgent, "SitemapCrawler/1.0", "User agent string")
-	cmd.Flags().StringSlice(FlagHeaders, []string{}, "Custom headers in format 'Key:Value'")
+	cmd.PersistentFlags().String(FlagEnvFile, "", "Load environment variables from this file (default: .env in the working directory, if present)")
+	cmd.PersistentFlags().String(FlagSitemapURL, "", "URL of the sitemap to crawl, a file:// URL, or - for standard input (required)")
+	cmd.PersistentFlags().Int(FlagMaxWorkers, 10, "Maximum number of parallel workers")
+	cmd.PersistentFlags().Int(FlagMaxConcurrentPerHost, 0, "Maximum parallel requests to any one host (default: no limit beyond the worker count)")
+	cmd.PersistentFlags().String(FlagHostOrder, "", "Crawl groups of hosts one after another, e.g. api.example.com>www.example.com; * places the hosts not named (default: last)")
+	cmd.PersistentFlags().Float64(FlagHostOrderThreshold, 1, "Fraction of a host group's URLs that must complete before the next group starts (0.0-1.0)")
+	cmd.PersistentFlags().StringArray(FlagPriorityPattern, []string{}, "Crawl URLs matching this regular expression first, and keep crawling them once --finish-by has passed while the rest are skipped (repeatable)")
+	cmd.PersistentFlags().String(FlagOrderBy, OrderBySitemap, "Order URLs are crawled in (sitemap, or priority for the highest sitemap priority first)")
+	cmd.PersistentFlags().Int(FlagRequestRate, 100, "Maximum requests per second")
+	cmd.PersistentFlags().Int(FlagRequestBurst, 0, "Requests allowed at once before the rate applies (default: the request rate)")
+	cmd.PersistentFlags().Bool(FlagStrictPacing, false, "Space requests evenly at the request rate, with no bursts")
+	cmd.PersistentFlags().Duration(FlagRateRamp, 0, "Raise the request rate from a tenth to the full rate over this long at the start of each pass")
+	cmd.PersistentFlags().String(FlagFinishBy, "", "Pace requests to finish by this time (duration such as 45m, or RFC 3339 timestamp), never faster than the request rate")
+	cmd.PersistentFlags().String(FlagMaxBandwidth, "", "Maximum download rate per second across all requests, such as 5MB or 50Mbit (default: no limit)")
+	cmd.PersistentFlags().String(FlagMemoryLimit, "", "Memory budget such as 512MiB; workers are reduced and intake paused as memory use approaches it (default: no limit)")
+	cmd.PersistentFlags().String(FlagRequestMode, RequestModeGet, "How URLs are requested (get, head, range for the first byte only, assets for the first byte of large assets only)")
+	cmd.PersistentFlags().StringSlice(FlagRangeExtensions, defaultRangeExtensions, "File extensions of the large assets requested a byte at a time in assets mode")
+	cmd.PersistentFlags().StringSlice(FlagRangeContentTypes, defaultRangeContentTypes, "Content types, or prefixes such as video/, of the large assets requested a byte at a time in assets mode")
+	cmd.PersistentFlags().Duration(FlagRequestTimeout, 30*time.Second, "Request timeout")
+	cmd.PersistentFlags().Duration(FlagConnectTimeout, 30*time.Second, "Timeout for establishing a TCP connection")
+	cmd.PersistentFlags().Duration(FlagTLSHandshakeTimeout, 10*time.Second, "Timeout for the TLS handshake")
+	cmd.PersistentFlags().Duration(FlagResponseHeaderTimeout, 0, "Timeout for response headers once the request is sent (0 for no limit)")
+	cmd.PersistentFlags().Duration(FlagBodyTimeout, 0, "Timeout for reading the response body once headers arrive (0 for no limit)")
+	cmd.PersistentFlags().Int(FlagRepeat, 1, "Number of times to crawl the full URL set")
+	cmd.PersistentFlags().Duration(FlagRepeatInterval, 0, "Least time between the starts of repeat iterations (0 to start each as soon as the last ends)")
+	cmd.PersistentFlags().String(FlagRepeatSchedule, RepeatScheduleAll, "URLs each repeat iteration crawls (all, or changefreq for only those their sitemap changefreq and priority say are due)")
+	cmd.PersistentFlags().Uint64(FlagSeed, 0, "Seed for the run's random choices, to reproduce an earlier run (default: a random seed, recorded in the run metadata)")
+	cmd.PersistentFlags().String(FlagUserAgent, "SitemapCrawler/1.0", "User agent string")
+	cmd.PersistentFlags().StringSlice(FlagHeaders, []string{}, "Custom headers in format 'Key:Value'")
+	cmd.PersistentFlags().String(FlagRequestTemplate, "", "YAML file setting the method, headers and body of requests, per URL pattern")
+	cmd.PersistentFlags().StringSlice(FlagDevice, []string{}, "Crawl every URL once per device profile and compare the responses (desktop, mobile, tablet or a custom profile)")
+	cmd.PersistentFlags().String(FlagDeviceProfiles, "", "YAML file defining custom device profiles")
+	cmd.PersistentFlags().String(FlagDeviceReport, "", "Write the device comparison to this file")
+	cmd.PersistentFlags().Float64(FlagDeviceSizeTolerance, 0.1, "Response size difference between devices before a URL is reported (0.1 = 10%)")
+	cmd.PersistentFlags().Int64(FlagMaxSitemapBytes, 50*1024*1024, "Maximum size of a single sitemap document in bytes")
+	cmd.PersistentFlags().Int(FlagMaxSitemapDepth, 10, "Maximum nesting depth of sitemap indexes")
+	cmd.PersistentFlags().Int(FlagMaxSitemapURLs, 1000000, "Maximum number of URLs collected across all sitemaps")
+	cmd.PersistentFlags().Duration(FlagSitemapRefresh, 0, "Fetch the sitemap again this often during the crawl, crawling added URLs and dropping removed ones not yet crawled (default: never)")
+	cmd.PersistentFlags().Bool(FlagCrawlMedia, false, "Also crawl the image, video thumbnail and video file URLs listed in image and video sitemap extensions")
+	cmd.PersistentFlags().Bool(FlagCrawlAlternates, false, "Also crawl the localized variants listed in xhtml:link hreflang alternates")
+	cmd.PersistentFlags().StringSlice(FlagBlockDomains, []string{}, "Reject sitemap URLs on these domains and their subdomains")
+	cmd.PersistentFlags().String(FlagRejectedReport, "", "Write sitemap URLs that were not crawled, and why, to this file")
+	cmd.PersistentFlags().String(FlagModifiedSince, "", "Only crawl URLs whose sitemap lastmod is at or after this RFC 3339 time or date (2024-05-01); URLs without a lastmod are still crawled")
+	cmd.PersistentFlags().Duration(FlagModifiedWithin, 0, "Only crawl URLs whose sitemap lastmod is within this duration of the start of the run; URLs without a lastmod are still crawled")
+}
+
+// addNetworkFlags adds flags controlling how requests reach the network
+func addNetworkFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(FlagSourceIP, "", "Local IP address requests egress from")
+	cmd.PersistentFlags().String(FlagInterface, "", "Network interface requests egress from (uses its primary address)")
+	cmd.PersistentFlags().String(FlagRecord, "", "Record every sitemap and page response to this cassette file")
+	cmd.PersistentFlags().Int64(FlagRecordMaxBodyBytes, 10*1024*1024, "Maximum response body bytes recorded per cassette interaction")
+	cmd.PersistentFlags().String(FlagReplay, "", "Answer sitemap and page requests from this cassette file instead of the network")
+	cmd.PersistentFlags().StringSlice(FlagDial, []string{}, "Connect to a host through a Unix socket or another address, e.g. origin.internal=unix:/var/run/envoy.sock or *=tcp:127.0.0.1:15001 (repeatable)")
+	cmd.PersistentFlags().String(FlagResolver, "", "Resolve hostnames with this DNS server (IP or IP:port) or DNS-over-HTTPS endpoint (https:// URL) instead of the system resolver")
+	cmd.PersistentFlags().String(FlagProxy, "", "Send every request through this proxy (http, https, socks5 or socks5h URL) instead of the one HTTPS_PROXY or HTTP_PROXY configures")
+	cmd.PersistentFlags().Bool(FlagProxyStrict, false, "Fail the run if any connection or DNS lookup would bypass --proxy")
+	cmd.PersistentFlags().String(FlagSigV4Service, "", "Sign requests with AWS Signature Version 4 for this service, such as s3 or execute-api, using credentials from the standard AWS chain")
+	cmd.PersistentFlags().String(FlagSigV4Region, "", "AWS region requests are signed for (default: AWS_REGION or AWS_DEFAULT_REGION)")
+	cmd.PersistentFlags().String(FlagAWSProfile, "", "Shared credentials profile to sign requests with (default: AWS_PROFILE, or default)")
+	cmd.PersistentFlags().String(FlagHealthAddr, "", "Serve /healthz and /readyz probes and per-URL status on this address (host:port) while running")
+	cmd.PersistentFlags().Bool(FlagDualStack, false, "Request every URL over IPv4 and over IPv6 and report URLs that fail or are slower over one of them")
+	cmd.PersistentFlags().String(FlagDualStackReport, "", "Write the IPv4/IPv6 comparison to this file")
+	cmd.PersistentFlags().Float64(FlagDualStackSlowdownRatio, 2.0, "How many times slower one address family must be to be reported")
+	cmd.PersistentFlags().Duration(FlagDualStackSlowdownMin, 100*time.Millisecond, "Minimum slowdown of one address family to be reported")
 }
 
 // addCacheFlags adds cache verification flags
 func addCacheFlags(cmd *cobra.Command) {
-	cmd.Flags().Bool(FlagCacheVerificationMode, false, "Enable cache verification mode")
-	cmd.Flags().String(FlagCacheHeader, "X-Cache", "Header to check for cache status")
+	cmd.PersistentFlags().Bool(FlagCacheVerificationMode, false, "Enable cache verification mode")
+	cmd.PersistentFlags().String(FlagCacheHeader, "X-Cache", "Header to check for cache status")
+	cmd.PersistentFlags().String(FlagCacheEfficacyReport, "", "Write per-URL cache efficacy scores, least benefited by warming first, to this file")
+	cmd.PersistentFlags().StringSlice(FlagCompareHeaders, defaultCompareHeaders, "Response headers to compare between the warm-up and verification passes")
+	cmd.PersistentFlags().String(FlagHeaderDiffReport, "", "Write the URLs whose compared headers changed between passes to this file")
+	cmd.PersistentFlags().StringSlice(FlagEdge, []string{}, "Run the verification pass against each CDN edge, e.g. lhr=151.101.1.1 or 151.101.65.1:443, and report per-edge hit rates (repeatable)")
+	cmd.PersistentFlags().String(FlagEdgeReport, "", "Write the cache hit rate of each edge to this file")
+	cmd.PersistentFlags().String(FlagPurge, "", "Purge every URL before warming it (request)")
+	cmd.PersistentFlags().String(FlagPurgeMethod, "PURGE", "HTTP method of purge requests, e.g. PURGE or BAN")
+	cmd.PersistentFlags().Duration(FlagPurgeWait, 0, "How long to wait after purging for the purge to propagate")
+	cmd.PersistentFlags().StringSlice(FlagPurgeTags, []string{}, "Purge these cache tags or surrogate keys instead of the sitemap URLs (cloudflare, fastly)")
+	cmd.PersistentFlags().String(FlagCDN, "", "CDN in front of the site, for its cache status and request ID headers (cloudflare, fastly)")
+	cmd.PersistentFlags().String(FlagCloudflareZoneID, "", "Cloudflare zone ID to purge (token from "+CloudflareAPITokenEnv+")")
+	cmd.PersistentFlags().String(FlagFastlyServiceID, "", "Fastly service ID for surrogate key purges (token from "+FastlyAPITokenEnv+")")
+	cmd.PersistentFlags().Bool(FlagFastlySoftPurge, true, "Mark content stale instead of evicting it when purging through Fastly")
 }
 
 // addOutputFlags adds output configuration flags
 func addOutputFlags(cmd *cobra.Command) {
-	cmd.Flags().String(FlagOutputFormat, "text", "Output format (text, json, csv)")
-	cmd.Flags().Bool(FlagQuiet, false, "Suppress progress output")
-	cmd.Flags().Duration(FlagProgressInterval, 5*time.Second, "Progress report interval")
-	cmd.Flags().Bool(FlagDebug, false, "Enable debug logging")
+	cmd.PersistentFlags().String(FlagOutputFormat, "text", "Output format (text, json, csv, xml)")
+	cmd.PersistentFlags().Bool(FlagQuiet, false, "Suppress progress output")
+	cmd.PersistentFlags().Duration(FlagProgressInterval, 5*time.Second, "Progress report interval")
+	cmd.PersistentFlags().Bool(FlagDebug, false, "Enable debug logging")
+	cmd.PersistentFlags().CountP(FlagVerbose, "v", "Log per-URL results (-v for failures, -vv for every URL)")
+	cmd.PersistentFlags().String(FlagColor, "auto", "Colorize terminal output (auto, always, never)")
+	cmd.PersistentFlags().Bool(FlagGitHubAnnotations, false, "Print GitHub Actions annotations for failed URLs and breached thresholds")
+	cmd.PersistentFlags().String(FlagCSVDelimiter, ",", "CSV field delimiter: a single character, or tab, comma, semicolon or pipe")
+	cmd.PersistentFlags().String(FlagCSVQuote, "minimal", "CSV quoting (minimal, all)")
+	cmd.PersistentFlags().String(FlagFailuresFile, "", "Write failed URLs and cache misses to this CSV file")
+	cmd.PersistentFlags().String(FlagBadgeFile, "", "Write a shields.io endpoint badge of the success and cache hit rates to this JSON file")
+	cmd.PersistentFlags().String(FlagResultsFile, "", "Write every request's result to this file, JSON or XML with --output-format xml, for comparison with report diff")
+	cmd.PersistentFlags().Float64(FlagResultsSampleRate, 1.0, "Fraction of successful results written to the results file; failures and cache misses are always written (0.0-1.0)")
+	cmd.PersistentFlags().StringArray(FlagExport, []string{}, "Send the final statistics and every result to an exporter, as name=destination, e.g. json=stats.json (repeatable)")
+	cmd.PersistentFlags().String(FlagCleanSitemap, "", "Write a sitemap containing only the URLs that returned 200 to this file")
+	cmd.PersistentFlags().String(FlagCoverageReport, "", "Write a sitemap coverage report (orphan and unlisted pages) to this file")
+	cmd.PersistentFlags().String(FlagCoverageFormat, "json", "Coverage report format (json, csv, html)")
+	cmd.PersistentFlags().String(FlagTimelineReport, "", "Write request counts, error rates and p95 latency per minute to this file")
+	cmd.PersistentFlags().String(FlagTimelineFormat, "csv", "Timeline report format (text, json, csv, html)")
+	cmd.PersistentFlags().String(FlagAuditReport, "", "Write the audit report to this file instead of stdout")
+	cmd.PersistentFlags().Float64(FlagAuditVariants, 0, "Fraction of audited pages whose trailing-slash and case variants are probed for redirects, 404s and duplicate content (0.0-1.0)")
+	cmd.PersistentFlags().StringSlice(FlagAnalyzers, []string{}, "Page analyzers to run (mixed-content, structured-data, broken-links); all of them by default when --findings-report is set")
+	cmd.PersistentFlags().String(FlagFindingsReport, "", "Write the findings of the page analyzers, and of the audit when auditing, to this file")
+	cmd.PersistentFlags().String(FlagLastModReport, "", "Compare sitemap lastmod with Last-Modified headers and write discrepancies to this file")
+	cmd.PersistentFlags().Duration(FlagLastModTolerance, 24*time.Hour, "Maximum lastmod difference before a URL is reported")
+	cmd.PersistentFlags().String(FlagDuplicatesReport, "", "Write clusters of URLs serving identical or near-identical content to this file")
+	cmd.PersistentFlags().Int(FlagDuplicatesDistance, 3, "Maximum simhash distance in bits for near-identical content (0 for byte-identical only, max 8)")
+	cmd.PersistentFlags().String(FlagHARFile, "", "Record requests and responses to this HAR file")
+	cmd.PersistentFlags().String(FlagHARMode, "failures", "Which requests to record in the HAR file (all, failures, sample)")
+	cmd.PersistentFlags().Float64(FlagHARSampleRate, 0.1, "Fraction of successful requests recorded in sample mode (0.0-1.0)")
+	cmd.PersistentFlags().Int(FlagHARMaxBodyBytes, 64*1024, "Maximum response body bytes stored per HAR entry")
+	cmd.PersistentFlags().Bool(FlagHARIncludeSecrets, false, "Keep Authorization, Cookie, Set-Cookie and other credential header values in the HAR file instead of redacting them")
+	cmd.PersistentFlags().String(FlagTraceFile, "", "Write each worker's requests, rate limiter waits and backoff pauses to this file as a Chrome trace")
+	cmd.PersistentFlags().String(FlagCaptureDir, "", "Save the response bodies of failed and sampled requests to this directory")
+	cmd.PersistentFlags().Float64(FlagCaptureSampleRate, 0.01, "Fraction of successful responses whose bodies are saved (0.0-1.0)")
+	cmd.PersistentFlags().Int64(FlagCaptureMaxBytes, 50*1024*1024, "Maximum total bytes of response bodies saved to the capture directory")
+}
+
+// addRenderFlags adds headless browser rendering flags
+func addRenderFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(FlagRender, false, "Also render a subset of URLs in headless Chrome")
+	cmd.PersistentFlags().String(FlagRenderPattern, "", "Only render URLs matching this regular expression")
+	cmd.PersistentFlags().Int(FlagRenderLimit, 20, "Maximum number of URLs to render (0 = no limit)")
+	cmd.PersistentFlags().Duration(FlagRenderTimeout, 30*time.Second, "Page load timeout for rendered URLs")
+	cmd.PersistentFlags().String(FlagRenderReport, "", "Write the render report to this file instead of stdout")
+	cmd.PersistentFlags().String(FlagChromePath, "", "Path to the Chrome or Chromium executable (default: search PATH)")
 }
 
 // addBackoffFlags adds backoff configuration flags
 func addBackoffFlags(cmd *cobra.Command) {
-	cmd.Flags().Bool(FlagBackoffEnabled, true, "Enable backoff on server errors and response time degradation")
-	cmd.Flags().Duration(FlagBackoffInitialDelay, 1*time.Second, "Initial backoff delay")
-	cmd.Flags().Duration(FlagBackoffMaxDelay, 30*time.Second, "Maximum backoff delay")
-	cmd.Flags().Float64(FlagBackoffMultiplier, 2.0, "Backoff delay multiplier")
-	cmd.Flags().Float64(FlagResponseTimeDegradationThreshold, 0.5, "Response time degradation threshold (0.5 = 50% slower)")
-	cmd.Flags().Int(FlagForbiddenErrorThreshold, 5, "Number of 403 errors within window to cancel crawl")
-	cmd.Flags().Duration(FlagForbiddenErrorWindow, 5*time.Second, "Time window for 403 error tracking")
+	cmd.PersistentFlags().Bool(FlagBackoffEnabled, true, "Enable backoff on server errors and response time degradation")
+	cmd.PersistentFlags().Duration(FlagBackoffInitialDelay, 1*time.Second, "Initial backoff delay")
+	cmd.PersistentFlags().Duration(FlagBackoffMaxDelay, 30*time.Second, "Maximum backoff delay")
+	cmd.PersistentFlags().Float64(FlagBackoffMultiplier, 2.0, "Backoff delay multiplier")
+	cmd.PersistentFlags().Float64(FlagResponseTimeDegradationThreshold, 0.5, "Response time degradation threshold (0.5 = 50% slower)")
+	cmd.PersistentFlags().Int(FlagForbiddenErrorThreshold, 5, "Number of 403 errors within window to cancel crawl")
+	cmd.PersistentFlags().Duration(FlagForbiddenErrorWindow, 5*time.Second, "Time window for 403 error tracking")
+	cmd.PersistentFlags().String(FlagBackoffRecovery, "reset", "How backoff eases off once the server is healthy (reset, decay)")
+	cmd.PersistentFlags().Duration(FlagBackoffDecayInterval, 5*time.Second, "How often decay recovery halves the backoff delay")
+	cmd.PersistentFlags().StringArray(FlagCancelOn, []string{}, "Rule that cancels the crawl, e.g. status=401,count=3 or status=5xx,consecutive=10 or error-rate=0.5 (repeatable)")
+	cmd.PersistentFlags().StringSlice(FlagRetry, []string{}, "Retry rule match=retries[:delay], e.g. 503=3:1s, 5xx=2, timeout=2 or 4xx=0 (repeatable)")
+	cmd.PersistentFlags().StringArray(FlagExpectStatus, []string{}, "Statuses that count as success for paths matching a pattern, e.g. /api/*=2xx, /legacy/*=2xx,301 or /gone/*=410 (repeatable)")
 }
 
-// markRequiredFlags marks flags that are required
-func markRequiredFlags(cmd *cobra.Command) error {
-	return cmd.MarkFlagRequired(FlagSitemapURL)
+// addPingFlags adds flags for notifying search engines after a crawl
+func addPingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringArray(FlagPing, []string{}, "URL to request after a successful crawl; {sitemap} is replaced by the sitemap URL (repeatable)")
+	cmd.PersistentFlags().Float64(FlagPingMinSuccessRate, 99, "Minimum success rate, in percent, for a crawl to notify search engines")
+	cmd.PersistentFlags().String(FlagIndexNowKey, "", "IndexNow key; submits the URLs that returned 200 after a successful crawl")
+	cmd.PersistentFlags().String(FlagIndexNowKeyLocation, "", "URL of the IndexNow key file (default: /<key>.txt on each host)")
+	cmd.PersistentFlags().String(FlagIndexNowEndpoint, "https://api.indexnow.org/indexnow", "IndexNow endpoint")
+	cmd.PersistentFlags().String(FlagVerifyPublished, "", "URL the clean sitemap is published at; fails the run unless it serves the sitemap just written")
+	cmd.PersistentFlags().Duration(FlagVerifyPublishedTimeout, 0, "How long to keep checking the published sitemap before failing (default: check once)")
+}
+
+// addReportFlags adds flags for the report subcommands
+func addReportFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(FlagReportFormat, "text", "Format of report diff, report merge and validate output (text, json, markdown)")
+	cmd.PersistentFlags().Float64(FlagLatencyRegressionRatio, 1.5, "How many times slower a URL must get to count as a latency regression")
+	cmd.PersistentFlags().Duration(FlagLatencyRegressionMin, 100*time.Millisecond, "Minimum slowdown counted as a latency regression")
 }
 
 // bindFlags binds all flags to viper
 func bindFlags(cmd *cobra.Command) error {
 	flagNames := []string{
-		FlagSitemapURL, FlagMaxWorkers, FlagRequestRate, FlagRequestTimeout, FlagUserAgent,
-		FlagCacheVerificationMode, FlagCacheHeader, FlagOutputFormat, FlagQuiet,
-		FlagProgressInterval, FlagDebug, FlagBackoffEnabled, FlagBackoffInitialDelay,
+		FlagEnvFile, FlagSitemapURL, FlagMaxWorkers, FlagMaxConcurrentPerHost, FlagHostOrder, FlagHostOrderThreshold, FlagPriorityPattern, FlagOrderBy, FlagRepeat, FlagRepeatInterval, FlagRepeatSchedule, FlagSeed, FlagRequestRate, FlagRequestBurst, FlagStrictPacing, FlagRateRamp, FlagFinishBy, FlagMaxBandwidth, FlagMemoryLimit, FlagRequestMode, FlagRangeExtensions, FlagRangeContentTypes, FlagRequestTimeout,
+		FlagConnectTimeout, FlagTLSHandshakeTimeout, FlagResponseHeaderTimeout, FlagBodyTimeout, FlagUserAgent, FlagRequestTemplate,
+		FlagDevice, FlagDeviceProfiles, FlagDeviceReport, FlagDeviceSizeTolerance,
+		FlagCacheVerificationMode, FlagCacheHeader, FlagCacheEfficacyReport, FlagCompareHeaders, FlagHeaderDiffReport, FlagEdge, FlagEdgeReport, FlagPurge, FlagPurgeMethod, FlagPurgeWait, FlagPurgeTags,
+		FlagCDN, FlagCloudflareZoneID, FlagFastlyServiceID, FlagFastlySoftPurge, FlagOutputFormat,
+		FlagPing, FlagPingMinSuccessRate, FlagIndexNowKey, FlagIndexNowKeyLocation, FlagIndexNowEndpoint, FlagVerifyPublished, FlagVerifyPublishedTimeout, FlagQuiet,
+		FlagProgressInterval, FlagDebug, FlagVerbose, FlagColor, FlagGitHubAnnotations, FlagFailuresFile, FlagBadgeFile,
+		FlagCSVDelimiter, FlagCSVQuote, FlagCleanSitemap, FlagResultsFile, FlagResultsSampleRate, FlagExport,
+		FlagReportFormat, FlagLatencyRegressionRatio, FlagLatencyRegressionMin,
+		FlagBackoffEnabled, FlagBackoffInitialDelay,
 		FlagBackoffMaxDelay, FlagBackoffMultiplier, FlagResponseTimeDegradationThreshold,
-		FlagForbiddenErrorThreshold, FlagForbiddenErrorWindow,
+		FlagForbiddenErrorThreshold, FlagForbiddenErrorWindow, FlagBackoffRecovery, FlagBackoffDecayInterval, FlagCancelOn, FlagRetry, FlagExpectStatus, FlagCoverageReport, FlagCoverageFormat,
+		FlagTimelineReport, FlagTimelineFormat,
+		FlagAuditReport, FlagAuditVariants, FlagAnalyzers, FlagFindingsReport, FlagLastModReport, FlagLastModTolerance, FlagDuplicatesReport, FlagDuplicatesDistance, FlagSourceIP, FlagInterface, FlagRecord, FlagRecordMaxBodyBytes, FlagReplay, FlagDial, FlagResolver, FlagProxy, FlagProxyStrict, FlagSigV4Service, FlagSigV4Region, FlagAWSProfile, FlagHealthAddr,
+		FlagDualStack, FlagDualStackReport, FlagDualStackSlowdownRatio, FlagDualStackSlowdownMin,
+		FlagHARFile, FlagHARMode, FlagHARSampleRate, FlagHARMaxBodyBytes, FlagHARIncludeSecrets, FlagTraceFile,
+		FlagCaptureDir, FlagCaptureSampleRate, FlagCaptureMaxBytes,
+		FlagRender, FlagRenderPattern, FlagRenderLimit, FlagRenderTimeout, FlagRenderReport, FlagChromePath,
+		FlagMaxSitemapBytes, FlagMaxSitemapDepth, FlagMaxSitemapURLs, FlagSitemapRefresh, FlagCrawlMedia, FlagCrawlAlternates, FlagBlockDomains, FlagRejectedReport, FlagModifiedSince, FlagModifiedWithin,
 	}
 
 	for _, flagName := range flagNames {
-		if err := viper.BindPFlag(flagName, cmd.Flags().Lookup(flagName)); err != nil {
+		if err := viper.BindPFlag(flagName, cmd.PersistentFlags().Lookup(flagName)); err != nil {
 			return fmt.Errorf("failed to bind %s flag: %w", flagName, err)
 		}
 	}
@@ -196,139 +1019,888 @@ func parseHeaders() error {
 	return nil
 }
 
-// createConfig creates and validates the final configuration
-func createConfig() (*Config, error) {
+// createConfig creates and validates the final configuration for the
+// selected command
+func createConfig(command string, args []string) (*Config, error) {
 	// Set environment variable prefix
 	viper.SetEnvPrefix("SITEMAP_CRAWLER")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
+	// validate checks the configuration of the command it names
+	target := command
+	if command == CommandValidate {
+		target = CommandCrawl
+		if len(args) > 0 {
+			target = args[0]
+		}
+	}
+
+	if target == CommandWarm {
+		applyDefaults(viper.GetViper(), warmDefaults)
+	}
+
 	// Create config struct
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.CloudflareAPIToken = os.Getenv(CloudflareAPITokenEnv)
+	cfg.FastlyAPIToken = os.Getenv(FastlyAPITokenEnv)
+	if header, ok := cdnCacheHeaders[cfg.CDN]; ok && !viper.IsSet(FlagCacheHeader) {
+		cfg.CacheHeader = header
+	}
+
+	// Unmarshal splits an environment value on commas, which cancel rules
+	// and patterns use inside a value; GetStringSlice splits it on spaces
+	// instead
+	cfg.CancelOn = viper.GetStringSlice(FlagCancelOn)
+	cfg.Ping = viper.GetStringSlice(FlagPing)
+	cfg.PriorityPatterns = viper.GetStringSlice(FlagPriorityPattern)
+	cfg.ExpectStatus = viper.GetStringSlice(FlagExpectStatus)
+	cfg.Command = target
+	cfg.CommandArgs = args
 
 	// Validate configuration
-	if err := validateConfig(&cfg); err != nil {
+	err := validateConfig(&cfg)
+	if command == CommandValidate {
+		return validationResult(&cfg, err)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	return &cfg, nil
 }
 
-// validateConfig validates the configuration values
+// validationResult records the violations validateConfig found for the
+// validate command to report, rather than failing on them. Only an invalid
+// report format, which the report itself needs, is an error.
+func validationResult(cfg *Config, err error) (*Config, error) {
+	validFormats := map[string]bool{"text": true, "json": true, "markdown": true}
+	if !validFormats[cfg.ReportFormat] {
+		return nil, fmt.Errorf("invalid report format: %s (valid: text, json, markdown)", cfg.ReportFormat)
+	}
+
+	cfg.ValidatedCommand = cfg.Command
+	cfg.Command = CommandValidate
+	cfg.Violations = Violations(err)
+	if err != nil && cfg.Violations == nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyDefaults sets each setting that was not given on the command line or
+// in the environment
+func applyDefaults(v *viper.Viper, defaults map[string]interface{}) {
+	for key, value := range defaults {
+		if !v.IsSet(key) {
+			v.Set(key, value)
+		}
+	}
+}
+
+// validateConfig validates the configuration values. Every violation is
+// reported in a ValidationError, not just the first.
 func validateConfig(cfg *Config) error {
-	if err := validateBasicConfig(cfg); err != nil {
-		return err
+	if cfg.Command == CommandReportDiff || cfg.Command == CommandReportMerge {
+		return validateReportConfig(cfg)
 	}
 
-	if err := validateCacheConfig(cfg); err != nil {
-		return err
+	var v violations
+	v.merge(validateBasicConfig(cfg))
+	v.merge(validateNetworkConfig(cfg))
+	v.merge(validateCacheConfig(cfg))
+	v.merge(validateOutputConfig(cfg))
+	v.merge(validateRenderConfig(cfg))
+	v.merge(validateBackoffConfig(cfg))
+	v.merge(validatePingConfig(cfg))
+	return v.err()
+}
+
+// validatePingConfig validates search engine notification
+func validatePingConfig(cfg *Config) error {
+	var v violations
+	for _, pingURL := range cfg.Ping {
+		if !isHTTPURL(pingURL) {
+			v.add(fmt.Sprintf("invalid ping URL: %s", pingURL), FlagPing)
+		}
+	}
+	if len(cfg.Ping) > 0 && isLocalSitemap(cfg.SitemapURL) {
+		v.add("pinging requires a sitemap search engines can fetch, not a local file or standard input", FlagPing, FlagSitemapURL)
 	}
 
-	if err := validateOutputConfig(cfg); err != nil {
-		return err
+	if cfg.PingMinSuccessRate < 0 || cfg.PingMinSuccessRate > 100 {
+		v.add("ping minimum success rate must be between 0 and 100", FlagPingMinSuccessRate)
 	}
 
-	if err := validateBackoffConfig(cfg); err != nil {
-		return err
+	if cfg.VerifyPublished != "" {
+		if cfg.CleanSitemap == "" {
+			v.add("published sitemap verification requires a clean sitemap", FlagVerifyPublished, FlagCleanSitemap)
+		}
+		if !isHTTPURL(cfg.VerifyPublished) {
+			v.add(fmt.Sprintf("invalid published sitemap URL: %s", cfg.VerifyPublished), FlagVerifyPublished)
+		}
+	}
+	if cfg.VerifyPublishedTimeout < 0 {
+		v.add("published sitemap verification timeout cannot be negative", FlagVerifyPublishedTimeout)
 	}
 
-	return nil
+	if cfg.IndexNowKey == "" {
+		return v.err()
+	}
+
+	if !indexNowKeyPattern.MatchString(cfg.IndexNowKey) {
+		v.add("IndexNow key must be 8 to 128 letters, digits or dashes", FlagIndexNowKey)
+	}
+	if !isHTTPURL(cfg.IndexNowEndpoint) {
+		v.add(fmt.Sprintf("invalid IndexNow endpoint: %s", cfg.IndexNowEndpoint), FlagIndexNowEndpoint)
+	}
+	if cfg.IndexNowKeyLocation != "" && !isHTTPURL(cfg.IndexNowKeyLocation) {
+		v.add(fmt.Sprintf("invalid IndexNow key location: %s", cfg.IndexNowKeyLocation), FlagIndexNowKeyLocation)
+	}
+
+	return v.err()
+}
+
+// validateReportConfig validates report command configuration. Crawl
+// settings are ignored since reports do not make requests.
+func validateReportConfig(cfg *Config) error {
+	var v violations
+	validFormats := map[string]bool{"text": true, "json": true, "markdown": true}
+	if !validFormats[cfg.ReportFormat] {
+		v.add(fmt.Sprintf("invalid report format: %s (valid: text, json, markdown)", cfg.ReportFormat), FlagReportFormat)
+	}
+
+	if cfg.LatencyRegressionRatio < 1 {
+		v.add("latency regression ratio must be at least 1", FlagLatencyRegressionRatio)
+	}
+
+	if cfg.LatencyRegressionMin < 0 {
+		v.add("latency regression minimum cannot be negative", FlagLatencyRegressionMin)
+	}
+
+	return v.err()
+}
+
+// StdinSitemap is the --sitemap-url that reads the sitemap from standard
+// input
+const StdinSitemap = "-"
+
+// isLocalSitemap reports whether the sitemap is read from a file:// URL or
+// standard input rather than fetched
+func isLocalSitemap(sitemapURL string) bool {
+	return sitemapURL == StdinSitemap || strings.HasPrefix(strings.ToLower(sitemapURL), "file:")
 }
 
 // validateBasicConfig validates basic crawler configuration
 func validateBasicConfig(cfg *Config) error {
+	var v violations
 	if cfg.SitemapURL == "" {
-		return fmt.Errorf("sitemap URL is required")
+		v.add("sitemap URL is required", FlagSitemapURL)
 	}
 
 	if cfg.MaxWorkers < 1 {
-		return fmt.Errorf("max workers must be at least 1")
+		v.add("max workers must be at least 1", FlagMaxWorkers)
+	}
+
+	if cfg.MaxConcurrentPerHost < 0 {
+		v.add("max concurrent requests per host cannot be negative", FlagMaxConcurrentPerHost)
+	}
+
+	if cfg.HostOrder != "" && (cfg.HostOrderThreshold <= 0 || cfg.HostOrderThreshold > 1) {
+		v.add("host order threshold must be greater than 0.0 and at most 1.0", FlagHostOrderThreshold)
+	}
+
+	for _, pattern := range cfg.PriorityPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.add(fmt.Sprintf("invalid priority pattern: %v", err), FlagPriorityPattern)
+		}
+	}
+
+	switch cfg.OrderBy {
+	case "", OrderBySitemap, OrderByPriority:
+	default:
+		v.add(fmt.Sprintf("invalid order: %s (valid: sitemap, priority)", cfg.OrderBy), FlagOrderBy)
 	}
 
 	if cfg.RequestRate < 1 {
-		return fmt.Errorf("request rate must be at least 1")
+		v.add("request rate must be at least 1", FlagRequestRate)
 	}
 
+	v.merge(validatePacing(cfg))
+	v.merge(validateRequestMode(cfg))
+
 	if cfg.RequestTimeout < time.Second {
-		return fmt.Errorf("request timeout must be at least 1 second")
+		v.add("request timeout must be at least 1 second", FlagRequestTimeout)
 	}
 
-	return nil
+	v.merge(validatePhaseTimeouts(cfg))
+
+	if cfg.Repeat < 0 {
+		v.add("repeat count cannot be negative", FlagRepeat)
+	}
+	if cfg.RepeatInterval < 0 {
+		v.add("repeat interval cannot be negative", FlagRepeatInterval)
+	}
+	switch cfg.RepeatSchedule {
+	case "", RepeatScheduleAll:
+	case RepeatScheduleChangeFreq:
+		if cfg.SitemapRefresh > 0 {
+			v.add("the changefreq repeat schedule cannot be combined with sitemap refresh", FlagRepeatSchedule, FlagSitemapRefresh)
+		}
+	default:
+		v.add(fmt.Sprintf("invalid repeat schedule: %s (valid: all, changefreq)", cfg.RepeatSchedule), FlagRepeatSchedule)
+	}
+
+	v.merge(validateDeviceConfig(cfg))
+	v.merge(validateSitemapLimits(cfg))
+	return v.err()
+}
+
+// validatePhaseTimeouts validates the connect, TLS, header and body timeouts
+func validatePhaseTimeouts(cfg *Config) error {
+	timeouts := []struct {
+		flag  string
+		value time.Duration
+	}{
+		{FlagConnectTimeout, cfg.ConnectTimeout},
+		{FlagTLSHandshakeTimeout, cfg.TLSHandshakeTimeout},
+		{FlagResponseHeaderTimeout, cfg.ResponseHeaderTimeout},
+		{FlagBodyTimeout, cfg.BodyTimeout},
+	}
+
+	var negative []string
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			negative = append(negative, timeout.flag)
+		}
+	}
+
+	var v violations
+	if len(negative) > 0 {
+		v.add("connect, TLS handshake, response header and body timeouts cannot be negative", negative...)
+	}
+	return v.err()
+}
+
+// validatePacing validates the request burst, strict pacing, rate ramp and
+// bandwidth limit
+func validatePacing(cfg *Config) error {
+	var v violations
+	if cfg.RequestBurst < 0 {
+		v.add("request burst cannot be negative", FlagRequestBurst)
+	}
+
+	if cfg.RateRamp < 0 {
+		v.add("rate ramp cannot be negative", FlagRateRamp)
+	}
+
+	_, err := ParseFinishBy(cfg.FinishBy, time.Now())
+	v.merge(err, FlagFinishBy)
+
+	_, err = ParseBandwidth(cfg.MaxBandwidth)
+	v.merge(err, FlagMaxBandwidth)
+
+	_, err = ParseMemoryLimit(cfg.MemoryLimit)
+	v.merge(err, FlagMemoryLimit)
+
+	if cfg.StrictPacing && cfg.RequestBurst > 1 {
+		v.add(fmt.Sprintf("strict pacing sends one request at a time and cannot be combined with a request burst of %d", cfg.RequestBurst), FlagStrictPacing, FlagRequestBurst)
+	}
+
+	return v.err()
+}
+
+// validateRequestMode validates the request mode. Partial responses cannot
+// be analyzed, so reports that read pages need full GETs; the assets mode
+// still fetches pages in full.
+func validateRequestMode(cfg *Config) error {
+	var v violations
+	switch cfg.RequestMode {
+	case "", RequestModeGet:
+		return nil
+	case RequestModeHead, RequestModeRange, RequestModeAssets:
+	default:
+		v.add(fmt.Sprintf("invalid request mode: %s (valid: get, head, range, assets)", cfg.RequestMode), FlagRequestMode)
+		return v.err()
+	}
+
+	if cfg.RequestTemplate != "" {
+		v.add(fmt.Sprintf("request mode %s cannot be combined with a request template", cfg.RequestMode), FlagRequestMode, FlagRequestTemplate)
+	}
+	if cfg.RequestMode == RequestModeAssets {
+		v.merge(validateRangeAssets(cfg))
+		return v.err()
+	}
+	if cfg.Command == CommandAudit || cfg.CoverageReport != "" || cfg.FindingsReport != "" || cfg.Render {
+		flags := []string{FlagRequestMode}
+		if cfg.CoverageReport != "" {
+			flags = append(flags, FlagCoverageReport)
+		}
+		if cfg.FindingsReport != "" {
+			flags = append(flags, FlagFindingsReport)
+		}
+		if cfg.Render {
+			flags = append(flags, FlagRender)
+		}
+		v.add(fmt.Sprintf("request mode %s does not fetch whole pages, which audits, coverage reports and rendering need", cfg.RequestMode), flags...)
+	}
+	return v.err()
+}
+
+// validateAnalyzers validates the page analyzers. Their findings have
+// nowhere to go without a findings report.
+func validateAnalyzers(cfg *Config) error {
+	var v violations
+	if len(cfg.Analyzers) > 0 && cfg.FindingsReport == "" {
+		v.add(fmt.Sprintf("analyzers require --%s", FlagFindingsReport), FlagAnalyzers, FlagFindingsReport)
+	}
+	for _, name := range cfg.Analyzers {
+		switch name {
+		case "mixed-content", "structured-data", "broken-links":
+		default:
+			v.add(fmt.Sprintf("invalid analyzer: %s (valid: mixed-content, structured-data, broken-links)", name), FlagAnalyzers)
+		}
+	}
+	return v.err()
+}
+
+// validateRangeAssets validates how the assets request mode identifies
+// large assets
+func validateRangeAssets(cfg *Config) error {
+	var v violations
+	if len(cfg.RangeExtensions) == 0 && len(cfg.RangeContentTypes) == 0 {
+		v.add("request mode assets needs range extensions or range content types to identify assets", FlagRangeExtensions, FlagRangeContentTypes)
+	}
+	for _, contentType := range cfg.RangeContentTypes {
+		if !strings.Contains(contentType, "/") {
+			v.add(fmt.Sprintf("invalid range content type: %q (use a media type such as application/zip or a prefix such as video/)", contentType), FlagRangeContentTypes)
+		}
+	}
+	return v.err()
+}
+
+// validateDeviceConfig validates device matrix crawling. Profile names are
+// resolved when the crawler is created, since custom profiles come from a
+// file.
+func validateDeviceConfig(cfg *Config) error {
+	var v violations
+	if len(cfg.Devices) == 0 {
+		if cfg.DeviceReport != "" || cfg.DeviceProfiles != "" {
+			v.add("device report and profiles require at least one device", FlagDeviceReport, FlagDeviceProfiles, FlagDevice)
+		}
+		return v.err()
+	}
+
+	if cfg.CacheVerificationMode {
+		v.add("device matrix crawling cannot be combined with cache verification mode", FlagDevice, FlagCacheVerificationMode)
+	}
+
+	if cfg.DeviceSizeTolerance < 0 {
+		v.add("device size tolerance cannot be negative", FlagDeviceSizeTolerance)
+	}
+
+	return v.err()
+}
+
+// validateSitemapLimits validates the sitemap resource limits, blocked
+// domains and lastmod cutoff. A zero limit keeps the parser's default.
+func validateSitemapLimits(cfg *Config) error {
+	var v violations
+	if cfg.MaxSitemapBytes < 0 {
+		v.add("max sitemap bytes cannot be negative", FlagMaxSitemapBytes)
+	}
+
+	if cfg.MaxSitemapDepth < 0 {
+		v.add("max sitemap depth cannot be negative", FlagMaxSitemapDepth)
+	}
+
+	if cfg.MaxSitemapURLs < 0 {
+		v.add("max sitemap URLs cannot be negative", FlagMaxSitemapURLs)
+	}
+
+	if cfg.SitemapRefresh < 0 {
+		v.add("sitemap refresh interval cannot be negative", FlagSitemapRefresh)
+	}
+	if cfg.SitemapRefresh > 0 && cfg.SitemapURL == StdinSitemap {
+		v.add("a sitemap read from standard input cannot be refreshed", FlagSitemapRefresh, FlagSitemapURL)
+	}
+
+	for _, domain := range cfg.BlockDomains {
+		if domain == "" || strings.ContainsAny(domain, "/:") {
+			v.add(fmt.Sprintf("invalid blocked domain %q: expected a domain name such as example.com", domain), FlagBlockDomains)
+		}
+	}
+
+	_, err := ParseModifiedCutoff(cfg.ModifiedSince, cfg.ModifiedWithin, time.Now())
+	v.merge(err, FlagModifiedSince, FlagModifiedWithin)
+
+	return v.err()
+}
+
+// validateNetworkConfig validates network egress configuration
+func validateNetworkConfig(cfg *Config) error {
+	var v violations
+	if cfg.SourceIP != "" && cfg.Interface != "" {
+		v.add("source IP and interface cannot both be specified", FlagSourceIP, FlagInterface)
+	}
+
+	if cfg.SourceIP != "" && net.ParseIP(cfg.SourceIP) == nil {
+		v.add(fmt.Sprintf("invalid source IP: %s", cfg.SourceIP), FlagSourceIP)
+	}
+
+	if cfg.Record != "" && cfg.Replay != "" {
+		v.add("record and replay cannot both be specified", FlagRecord, FlagReplay)
+	}
+
+	if cfg.RecordMaxBodyBytes < 0 {
+		v.add("record max body bytes cannot be negative", FlagRecordMaxBodyBytes)
+	}
+
+	if cfg.HealthAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.HealthAddr); err != nil {
+			v.add(fmt.Sprintf("invalid health address: %s (expected host:port)", cfg.HealthAddr), FlagHealthAddr)
+		}
+	}
+
+	v.merge(validateProxyConfig(cfg))
+	v.merge(validateSigV4Config(cfg))
+	v.merge(validateDualStackConfig(cfg))
+	return v.err()
+}
+
+// proxySchemes are the supported proxy URL schemes
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// validateProxyConfig validates the proxy, and that in strict mode nothing
+// is configured to connect or resolve hostnames around it
+func validateProxyConfig(cfg *Config) error {
+	var v violations
+	if cfg.Proxy != "" {
+		parsed, err := url.Parse(cfg.Proxy)
+		if err != nil || !slices.Contains(proxySchemes, strings.ToLower(parsed.Scheme)) || parsed.Hostname() == "" {
+			v.add(fmt.Sprintf("invalid proxy: %s (expected an http, https, socks5 or socks5h URL)", cfg.Proxy), FlagProxy)
+		}
+		// Each family would only reach the proxy over it
+		if cfg.DualStack {
+			v.add("dual-stack crawling cannot be combined with a proxy", FlagDualStack, FlagProxy)
+		}
+	}
+
+	if !cfg.ProxyStrict {
+		return v.err()
+	}
+	if cfg.Proxy == "" {
+		v.add("strict proxy mode requires a proxy", FlagProxyStrict, FlagProxy)
+	}
+	if len(cfg.Dial) > 0 || len(cfg.Edges) > 0 {
+		v.add("strict proxy mode cannot be combined with dial rules or edges, which connect around the proxy", FlagProxyStrict, FlagDial, FlagEdge)
+	}
+	if cfg.Resolver != "" {
+		v.add("strict proxy mode cannot be combined with a resolver; the proxy resolves crawled hosts", FlagProxyStrict, FlagResolver)
+	}
+	if cfg.Render {
+		v.add("strict proxy mode cannot be combined with rendering, whose browser connects directly", FlagProxyStrict, FlagRender)
+	}
+	// The credential chain may ask the instance metadata service directly
+	if cfg.SigV4Service != "" {
+		v.add("strict proxy mode cannot be combined with request signing, whose credential lookup connects directly", FlagProxyStrict, FlagSigV4Service)
+	}
+	return v.err()
+}
+
+// sigV4NamePattern matches AWS service and region names
+var sigV4NamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// validateSigV4Config validates request signing
+func validateSigV4Config(cfg *Config) error {
+	var v violations
+	if cfg.SigV4Service == "" {
+		if cfg.SigV4Region != "" || cfg.AWSProfile != "" {
+			v.add("sigv4 region and AWS profile require a sigv4 service", FlagSigV4Region, FlagAWSProfile, FlagSigV4Service)
+		}
+		return v.err()
+	}
+
+	if !sigV4NamePattern.MatchString(cfg.SigV4Service) {
+		v.add(fmt.Sprintf("invalid sigv4 service: %s", cfg.SigV4Service), FlagSigV4Service)
+	}
+
+	if cfg.SigV4Region != "" && !sigV4NamePattern.MatchString(cfg.SigV4Region) {
+		v.add(fmt.Sprintf("invalid sigv4 region: %s", cfg.SigV4Region), FlagSigV4Region)
+	}
+
+	return v.err()
+}
+
+// validateDualStackConfig validates the IPv4/IPv6 comparison
+func validateDualStackConfig(cfg *Config) error {
+	var v violations
+	if !cfg.DualStack {
+		if cfg.DualStackReport != "" {
+			v.add(fmt.Sprintf("dual-stack report requires --%s", FlagDualStack), FlagDualStackReport, FlagDualStack)
+		}
+		return v.err()
+	}
+
+	// A source IP belongs to a single family
+	if cfg.SourceIP != "" {
+		v.add("dual-stack crawling cannot be combined with a source IP", FlagDualStack, FlagSourceIP)
+	}
+
+	if cfg.CacheVerificationMode || len(cfg.Devices) > 0 {
+		v.add("dual-stack crawling cannot be combined with cache verification mode or devices", FlagDualStack, FlagCacheVerificationMode, FlagDevice)
+	}
+
+	// Each family dials its own connections, which a cassette cannot stand in for
+	if cfg.Record != "" || cfg.Replay != "" {
+		v.add("dual-stack crawling cannot be combined with record or replay", FlagDualStack, FlagRecord, FlagReplay)
+	}
+
+	if cfg.DualStackSlowdownRatio < 1 {
+		v.add("dual-stack slowdown ratio must be at least 1", FlagDualStackSlowdownRatio)
+	}
+
+	if cfg.DualStackSlowdownMin < 0 {
+		v.add("dual-stack slowdown minimum cannot be negative", FlagDualStackSlowdownMin)
+	}
+
+	return v.err()
 }
 
 // validateCacheConfig validates cache verification configuration
 func validateCacheConfig(cfg *Config) error {
+	var v violations
 	if cfg.CacheVerificationMode && cfg.CacheHeader == "" {
-		return fmt.Errorf("cache header must be specified when cache verification mode is enabled")
+		v.add("cache header must be specified when cache verification mode is enabled", FlagCacheHeader)
 	}
 
-	return nil
+	if cfg.CacheEfficacyReport != "" && !cfg.CacheVerificationMode {
+		v.add(fmt.Sprintf("cache efficacy report requires --%s", FlagCacheVerificationMode), FlagCacheEfficacyReport, FlagCacheVerificationMode)
+	}
+
+	if cfg.HeaderDiffReport != "" && !cfg.CacheVerificationMode {
+		v.add(fmt.Sprintf("header diff report requires --%s", FlagCacheVerificationMode), FlagHeaderDiffReport, FlagCacheVerificationMode)
+	}
+	if cfg.HeaderDiffReport != "" && len(cfg.CompareHeaders) == 0 {
+		v.add("header diff report needs at least one header to compare", FlagHeaderDiffReport, FlagCompareHeaders)
+	}
+	for _, name := range cfg.CompareHeaders {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			v.add(fmt.Sprintf("invalid header to compare: %q", name), FlagCompareHeaders)
+		}
+	}
+
+	if len(cfg.Edges) > 0 && !cfg.CacheVerificationMode {
+		v.add(fmt.Sprintf("edges require --%s", FlagCacheVerificationMode), FlagEdge, FlagCacheVerificationMode)
+	}
+	if cfg.EdgeReport != "" && len(cfg.Edges) == 0 {
+		v.add(fmt.Sprintf("edge report requires --%s", FlagEdge), FlagEdgeReport, FlagEdge)
+	}
+
+	// Each edge dials its own connections, which a cassette cannot stand in for
+	if len(cfg.Edges) > 0 && (cfg.Record != "" || cfg.Replay != "") {
+		v.add("edges cannot be combined with record or replay", FlagEdge, FlagRecord, FlagReplay)
+	}
+
+	v.merge(validatePurgeConfig(cfg))
+	return v.err()
+}
+
+// validatePurgeConfig validates the purge performed before warming
+func validatePurgeConfig(cfg *Config) error {
+	var v violations
+	if _, ok := cdnCacheHeaders[cfg.CDN]; cfg.CDN != "" && !ok {
+		v.add(fmt.Sprintf("invalid CDN: %s (valid: cloudflare, fastly)", cfg.CDN), FlagCDN)
+	}
+
+	if len(cfg.PurgeTags) > 0 && cfg.Purge != "cloudflare" && cfg.Purge != "fastly" {
+		v.add("purge tags require cloudflare or fastly purge mode", FlagPurgeTags, FlagPurge)
+	}
+
+	switch cfg.Purge {
+	case "":
+		return v.err()
+	case "request":
+		if !isHTTPMethod(cfg.PurgeMethod) {
+			v.add(fmt.Sprintf("invalid purge method: %q", cfg.PurgeMethod), FlagPurgeMethod)
+		}
+	case "cloudflare":
+		if cfg.CloudflareZoneID == "" {
+			v.add("cloudflare purge requires a zone ID", FlagCloudflareZoneID)
+		}
+		// The token only comes from the environment, so no flag is named
+		if cfg.CloudflareAPIToken == "" {
+			v.add(fmt.Sprintf("cloudflare purge requires an API token in %s", CloudflareAPITokenEnv))
+		}
+	case "fastly":
+		if cfg.FastlyAPIToken == "" {
+			v.add(fmt.Sprintf("fastly purge requires an API token in %s", FastlyAPITokenEnv))
+		}
+		if len(cfg.PurgeTags) > 0 && cfg.FastlyServiceID == "" {
+			v.add("fastly surrogate key purge requires a service ID", FlagFastlyServiceID)
+		}
+	default:
+		v.add(fmt.Sprintf("invalid purge mode: %s (valid: request, cloudflare, fastly)", cfg.Purge), FlagPurge)
+	}
+
+	if cfg.PurgeWait < 0 {
+		v.add("purge wait cannot be negative", FlagPurgeWait)
+	}
+
+	return v.err()
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// isHTTPMethod reports whether method is a non-empty HTTP token
+func isHTTPMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, r := range method {
+		if r < '!' || r > '~' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
 }
 
 // validateOutputConfig validates output configuration
 func validateOutputConfig(cfg *Config) error {
-	validFormats := map[string]bool{"text": true, "json": true, "csv": true}
+	var v violations
+	validFormats := map[string]bool{"text": true, "json": true, "csv": true, "xml": true}
 	if !validFormats[cfg.OutputFormat] {
-		return fmt.Errorf("invalid output format: %s (valid: text, json, csv)", cfg.OutputFormat)
+		v.add(fmt.Sprintf("invalid output format: %s (valid: text, json, csv, xml)", cfg.OutputFormat), FlagOutputFormat)
 	}
 
-	return nil
+	validColorModes := map[string]bool{"auto": true, "always": true, "never": true}
+	if cfg.Color != "" && !validColorModes[cfg.Color] {
+		v.add(fmt.Sprintf("invalid color mode: %s (valid: auto, always, never)", cfg.Color), FlagColor)
+	}
+
+	_, err := ParseCSVDelimiter(cfg.CSVDelimiter)
+	v.merge(err, FlagCSVDelimiter)
+
+	if cfg.CSVQuote != "" && cfg.CSVQuote != "minimal" && cfg.CSVQuote != "all" {
+		v.add(fmt.Sprintf("invalid CSV quoting: %s (valid: minimal, all)", cfg.CSVQuote), FlagCSVQuote)
+	}
+
+	if cfg.Verbose < 0 || cfg.Verbose > 2 {
+		v.add("verbosity must be between 0 and 2 (-v or -vv)", FlagVerbose)
+	}
+
+	if cfg.LastModReport != "" && cfg.LastModTolerance < 0 {
+		v.add("lastmod tolerance cannot be negative", FlagLastModTolerance)
+	}
+
+	if cfg.AuditVariants != 0 {
+		if cfg.AuditVariants < 0 || cfg.AuditVariants > 1 {
+			v.add("audit variants sample rate must be between 0.0 and 1.0", FlagAuditVariants)
+		}
+		if cfg.Command != CommandAudit {
+			v.add("probing URL variants requires the audit command", FlagAuditVariants)
+		}
+	}
+
+	if cfg.DuplicatesReport != "" && (cfg.DuplicatesDistance < 0 || cfg.DuplicatesDistance > 8) {
+		v.add("duplicates distance must be between 0 and 8", FlagDuplicatesDistance)
+	}
+
+	if cfg.CoverageReport != "" {
+		validCoverageFormats := map[string]bool{"json": true, "csv": true, "html": true}
+		if !validCoverageFormats[cfg.CoverageFormat] {
+			v.add(fmt.Sprintf("invalid coverage format: %s (valid: json, csv, html)", cfg.CoverageFormat), FlagCoverageFormat)
+		}
+	}
+
+	v.merge(validateAnalyzers(cfg))
+
+	if cfg.TimelineReport != "" {
+		switch cfg.TimelineFormat {
+		case "", "text", "json", "csv", "html":
+		default:
+			v.add(fmt.Sprintf("invalid timeline format: %s (valid: text, json, csv, html)", cfg.TimelineFormat), FlagTimelineFormat)
+		}
+	}
+
+	v.merge(validateHARConfig(cfg))
+	if cfg.ResultsFile != "" && (cfg.ResultsSampleRate < 0 || cfg.ResultsSampleRate > 1) {
+		v.add("results sample rate must be between 0.0 and 1.0", FlagResultsSampleRate)
+	}
+
+	for _, spec := range cfg.Export {
+		_, _, err := ParseExport(spec)
+		v.merge(err, FlagExport)
+	}
+
+	v.merge(validateCaptureConfig(cfg))
+	return v.err()
 }
 
-// validateBackoffConfig validates backoff configuration
-func validateBackoffConfig(cfg *Config) error {
-	if !cfg.BackoffEnabled {
+// ParseExport splits an --export value into the exporter name and its
+// destination. The destination may be empty for exporters that need none.
+func ParseExport(spec string) (name, destination string, err error) {
+	name, destination, _ = strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", fmt.Errorf("invalid export %q: expected name=destination", spec)
+	}
+	return name, strings.TrimSpace(destination), nil
+}
+
+// ParseCSVDelimiter converts a --csv-delimiter value to the delimiter rune.
+// An empty value is a comma.
+func ParseCSVDelimiter(value string) (rune, error) {
+	switch strings.ToLower(value) {
+	case "", "comma":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' || runes[0] == utf8.RuneError {
+		return 0, fmt.Errorf("invalid CSV delimiter: %q (use a single character other than a quote or newline)", value)
+	}
+	return runes[0], nil
+}
+
+// validateHARConfig validates HAR export configuration
+func validateHARConfig(cfg *Config) error {
+	if cfg.HARFile == "" {
 		return nil
 	}
 
-	if err := validateBackoffDelays(cfg); err != nil {
-		return err
+	var v violations
+	validModes := map[string]bool{"all": true, "failures": true, "sample": true}
+	if !validModes[cfg.HARMode] {
+		v.add(fmt.Sprintf("invalid HAR mode: %s (valid: all, failures, sample)", cfg.HARMode), FlagHARMode)
 	}
 
-	if err := validateBackoffThresholds(cfg); err != nil {
-		return err
+	if cfg.HARSampleRate < 0 || cfg.HARSampleRate > 1 {
+		v.add("HAR sample rate must be between 0.0 and 1.0", FlagHARSampleRate)
 	}
 
-	return nil
+	if cfg.HARMaxBodyBytes < 0 {
+		v.add("HAR max body bytes cannot be negative", FlagHARMaxBodyBytes)
+	}
+
+	return v.err()
+}
+
+// validateCaptureConfig validates response body capture configuration
+func validateCaptureConfig(cfg *Config) error {
+	if cfg.CaptureDir == "" {
+		return nil
+	}
+
+	var v violations
+	if cfg.CaptureSampleRate < 0 || cfg.CaptureSampleRate > 1 {
+		v.add("capture sample rate must be between 0.0 and 1.0", FlagCaptureSampleRate)
+	}
+
+	if cfg.CaptureMaxBytes <= 0 {
+		v.add("capture max bytes must be positive", FlagCaptureMaxBytes)
+	}
+
+	return v.err()
+}
+
+// validateRenderConfig validates headless browser rendering configuration
+func validateRenderConfig(cfg *Config) error {
+	if !cfg.Render {
+		return nil
+	}
+
+	var v violations
+	if cfg.RenderLimit < 0 {
+		v.add("render limit cannot be negative", FlagRenderLimit)
+	}
+
+	if cfg.RenderTimeout <= 0 {
+		v.add("render timeout must be positive", FlagRenderTimeout)
+	}
+
+	if _, err := regexp.Compile(cfg.RenderPattern); err != nil {
+		v.add(fmt.Sprintf("invalid render pattern: %v", err), FlagRenderPattern)
+	}
+
+	return v.err()
+}
+
+// validateBackoffConfig validates backoff configuration
+func validateBackoffConfig(cfg *Config) error {
+	if !cfg.BackoffEnabled {
+		return nil
+	}
+
+	var v violations
+	v.merge(validateBackoffDelays(cfg))
+	v.merge(validateBackoffThresholds(cfg))
+	v.merge(validateBackoffRecovery(cfg))
+	return v.err()
 }
 
 // validateBackoffDelays validates backoff delay configuration
 func validateBackoffDelays(cfg *Config) error {
+	var v violations
 	if cfg.BackoffInitialDelay <= 0 {
-		return fmt.Errorf("backoff initial delay must be greater than 0")
+		v.add("backoff initial delay must be greater than 0", FlagBackoffInitialDelay)
 	}
 
 	if cfg.BackoffMaxDelay <= 0 {
-		return fmt.Errorf("backoff max delay must be greater than 0")
-	}
-
-	if cfg.BackoffInitialDelay > cfg.BackoffMaxDelay {
-		return fmt.Errorf("backoff initial delay cannot be greater than max delay")
+		v.add("backoff max delay must be greater than 0", FlagBackoffMaxDelay)
+	} else if cfg.BackoffInitialDelay > cfg.BackoffMaxDelay {
+		v.add("backoff initial delay cannot be greater than max delay", FlagBackoffInitialDelay, FlagBackoffMaxDelay)
 	}
 
 	if cfg.BackoffMultiplier <= 1.0 {
-		return fmt.Errorf("backoff multiplier must be greater than 1.0")
+		v.add("backoff multiplier must be greater than 1.0", FlagBackoffMultiplier)
 	}
 
-	return nil
+	return v.err()
+}
+
+// validateBackoffRecovery validates how backoff eases off
+func validateBackoffRecovery(cfg *Config) error {
+	var v violations
+	switch cfg.BackoffRecovery {
+	case "", "reset":
+	case "decay":
+		if cfg.BackoffDecayInterval <= 0 {
+			v.add("backoff decay interval must be greater than 0", FlagBackoffDecayInterval)
+		}
+	default:
+		v.add(fmt.Sprintf("invalid backoff recovery: %s (valid: reset, decay)", cfg.BackoffRecovery), FlagBackoffRecovery)
+	}
+	return v.err()
 }
 
 // validateBackoffThresholds validates backoff threshold configuration
 func validateBackoffThresholds(cfg *Config) error {
+	var v violations
 	if cfg.ResponseTimeDegradationThreshold <= 0 || cfg.ResponseTimeDegradationThreshold > 1.0 {
-		return fmt.Errorf("response time degradation threshold must be between 0 and 1.0")
+		v.add("response time degradation threshold must be between 0 and 1.0", FlagResponseTimeDegradationThreshold)
 	}
 
 	if cfg.ForbiddenErrorThreshold < 1 {
-		return fmt.Errorf("forbidden error threshold must be at least 1")
+		v.add("forbidden error threshold must be at least 1", FlagForbiddenErrorThreshold)
 	}
 
 	if cfg.ForbiddenErrorWindow <= 0 {
-		return fmt.Errorf("forbidden error window must be greater than 0")
+		v.add("forbidden error window must be greater than 0", FlagForbiddenErrorWindow)
 	}
 
-	return nil
+	return v.err()
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseFinishBy parses a --finish-by value: a duration such as "45m",
+// counted from start, or an RFC 3339 timestamp. An empty value means no
+// deadline and returns the zero time.
+func ParseFinishBy(value string, start time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		if duration <= 0 {
+			return time.Time{}, fmt.Errorf("finish-by duration must be positive: %s", value)
+		}
+		return start.Add(duration), nil
+	}
+
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid finish-by: %s (expected a duration such as 45m or an RFC 3339 time)", value)
+	}
+	if !deadline.After(start) {
+		return time.Time{}, fmt.Errorf("finish-by time is in the past: %s", value)
+	}
+	return deadline, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFinishBy(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		value     string
+		expected  time.Time
+		wantError string
+	}{
+		{name: "empty means no deadline", value: "", expected: time.Time{}},
+		{name: "duration", value: "45m", expected: start.Add(45 * time.Minute)},
+		{name: "timestamp", value: "2024-05-01T14:30:00Z", expected: time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)},
+		{name: "timestamp with offset", value: "2024-05-01T14:30:00+02:00", expected: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)},
+		{name: "zero duration", value: "0s", wantError: "must be positive"},
+		{name: "negative duration", value: "-5m", wantError: "must be positive"},
+		{name: "past timestamp", value: "2024-05-01T11:00:00Z", wantError: "in the past"},
+		{name: "garbage", value: "tomorrow", wantError: "invalid finish-by"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseFinishBy(tt.value, start)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.expected.Equal(got), "expected %v, got %v", tt.expected, got)
+		})
+	}
+}
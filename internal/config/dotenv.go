@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultEnvFile is loaded from the working directory when --env-file is not
+// given
+const DefaultEnvFile = ".env"
+
+// loadEnvFile sets environment variables from a .env file. Variables already
+// set in the environment win, so the precedence is flags, then the
+// environment, then the .env file, then defaults. An explicit path must
+// exist; the default file is optional.
+func loadEnvFile(path string, explicit bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	values, err := parseEnvFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse env file %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s from env file: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines. Blank lines, comments and an optional
+// "export " prefix are allowed. Single-quoted values are taken literally;
+// double-quoted values support \n, \t, \" and \\ escapes; unquoted values end
+// at an inline " #" comment.
+func parseEnvFile(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		parsed, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		values[key] = parsed
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseEnvValue unquotes a single .env value
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var builder strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return builder.String(), nil
+			case '\\':
+				if i+1 == len(value) {
+					return "", fmt.Errorf("unterminated double quote")
+				}
+				i++
+				switch value[i] {
+				case 'n':
+					builder.WriteByte('\n')
+				case 't':
+					builder.WriteByte('\t')
+				default:
+					builder.WriteByte(value[i])
+				}
+			default:
+				builder.WriteByte(value[i])
+			}
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = value[:comment]
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Parallel()
+
+	content := `# Crawler settings
+SITEMAP_CRAWLER_SITEMAP_URL=https://example.com/sitemap.xml
+export SITEMAP_CRAWLER_MAX_WORKERS = 20
+SITEMAP_CRAWLER_USER_AGENT="Crawler \"test\"\tv1" # inline comments after quotes are ignored
+SITEMAP_CRAWLER_CACHE_HEADER='X-Cache #literal'
+SITEMAP_CRAWLER_OUTPUT_FORMAT=json # unquoted inline comment
+
+EMPTY=
+`
+
+	values, err := parseEnvFile(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"SITEMAP_CRAWLER_SITEMAP_URL":   "https://example.com/sitemap.xml",
+		"SITEMAP_CRAWLER_MAX_WORKERS":   "20",
+		"SITEMAP_CRAWLER_USER_AGENT":    "Crawler \"test\"\tv1",
+		"SITEMAP_CRAWLER_CACHE_HEADER":  "X-Cache #literal",
+		"SITEMAP_CRAWLER_OUTPUT_FORMAT": "json",
+		"EMPTY":                         "",
+	}, values)
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{name: "missing equals", content: "JUST_A_KEY", errMsg: "line 1: expected KEY=VALUE"},
+		{name: "space in key", content: "\nBAD KEY=value", errMsg: "line 2: expected KEY=VALUE"},
+		{name: "unterminated double quote", content: `KEY="value`, errMsg: "unterminated double quote"},
+		{name: "unterminated single quote", content: `KEY='value`, errMsg: "unterminated single quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseEnvFile(strings.NewReader(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	// Not parallel: modifies environment variables
+	path := filepath.Join(t.TempDir(), "test.env")
+	require.NoError(t, os.WriteFile(path, []byte("SITEMAP_CRAWLER_TEST_FROM_FILE=file\nSITEMAP_CRAWLER_TEST_EXISTING=file\n"), 0600))
+
+	t.Setenv("SITEMAP_CRAWLER_TEST_EXISTING", "environment")
+	t.Setenv("SITEMAP_CRAWLER_TEST_FROM_FILE", "")
+	require.NoError(t, os.Unsetenv("SITEMAP_CRAWLER_TEST_FROM_FILE"))
+
+	require.NoError(t, loadEnvFile(path, true))
+	assert.Equal(t, "file", os.Getenv("SITEMAP_CRAWLER_TEST_FROM_FILE"))
+	assert.Equal(t, "environment", os.Getenv("SITEMAP_CRAWLER_TEST_EXISTING"), "the environment takes precedence")
+
+	missing := filepath.Join(t.TempDir(), "missing.env")
+	assert.NoError(t, loadEnvFile(missing, false), "a missing default file is ignored")
+	assert.Error(t, loadEnvFile(missing, true), "a missing explicit file is an error")
+}
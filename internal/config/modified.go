@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseModifiedCutoff reads --modified-since and --modified-within into the
+// earliest <lastmod> a URL may have to be crawled. Since is an RFC 3339
+// timestamp or a date such as 2024-05-01; within is counted back from now.
+// Neither set means every URL is crawled and returns the zero time.
+func ParseModifiedCutoff(since string, within time.Duration, now time.Time) (time.Time, error) {
+	since = strings.TrimSpace(since)
+	switch {
+	case since != "" && within != 0:
+		return time.Time{}, fmt.Errorf("modified-since and modified-within cannot both be specified")
+	case within < 0:
+		return time.Time{}, fmt.Errorf("modified-within cannot be negative: %s", within)
+	case within > 0:
+		return now.Add(-within), nil
+	case since == "":
+		return time.Time{}, nil
+	}
+
+	if cutoff, err := time.Parse(time.RFC3339, since); err == nil {
+		return cutoff, nil
+	}
+	cutoff, err := time.Parse(time.DateOnly, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid modified-since: %s (expected an RFC 3339 time or a date such as 2024-05-01)", since)
+	}
+	return cutoff, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModifiedCutoff(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		since     string
+		within    time.Duration
+		expected  time.Time
+		wantError string
+	}{
+		{name: "neither means every URL", expected: time.Time{}},
+		{name: "within", within: 24 * time.Hour, expected: now.Add(-24 * time.Hour)},
+		{name: "timestamp", since: "2024-04-30T08:00:00+02:00", expected: time.Date(2024, 4, 30, 6, 0, 0, 0, time.UTC)},
+		{name: "date", since: "2024-04-30", expected: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{name: "both", since: "2024-04-30", within: time.Hour, wantError: "cannot both be specified"},
+		{name: "negative within", within: -time.Hour, wantError: "cannot be negative"},
+		{name: "garbage", since: "last week", wantError: "invalid modified-since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseModifiedCutoff(tt.since, tt.within, now)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.expected.Equal(got), "expected %v, got %v", tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secret values in a configuration snapshot
+const Redacted = "[REDACTED]"
+
+// sensitiveNames mark header names and settings whose values are secrets
+var sensitiveNames = []string{"authorization", "cookie", "token", "key", "secret", "password"}
+
+// Snapshot returns the configuration keyed by setting name, for recording
+// alongside a run's outputs. Settings that are not read from flags are
+// left out, and header values, keys and credentials in URLs are redacted.
+func (c *Config) Snapshot() map[string]interface{} {
+	snapshot := make(map[string]interface{})
+
+	value := reflect.ValueOf(*c)
+	for i := range value.NumField() {
+		name := value.Type().Field(i).Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		snapshot[name] = snapshotValue(name, value.Field(i).Interface())
+	}
+	return snapshot
+}
+
+// snapshotValue converts a setting to a JSON-friendly value with secrets
+// redacted
+func snapshotValue(name string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case string:
+		if v != "" && IsSensitive(name) {
+			return Redacted
+		}
+		return redactURL(v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, item := range v {
+			redacted[i] = redactURL(item)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, item := range v {
+			if IsSensitive(key) {
+				item = Redacted
+			}
+			redacted[key] = item
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// IsSensitive reports whether a setting or header name holds a secret.
+// Surrogate-Key names cache tags rather than a credential.
+func IsSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, "-location") || lower == "surrogate-key" {
+		return false
+	}
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL hides the password of a URL with credentials; other values are
+// returned unchanged
+func redactURL(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return parsed.Redacted()
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Violation is one problem found in a configuration
+type Violation struct {
+	Message string `json:"message"`
+
+	// Flags names the settings involved, without their leading dashes;
+	// empty for settings that only come from the environment
+	Flags []string `json:"flags,omitempty"`
+}
+
+// ValidationError reports every violation found in a configuration, so that
+// they can all be fixed at once
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error lists the violations, one per line when there are several
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return e.Violations[0].Message
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d problems:", len(e.Violations))
+	for _, violation := range e.Violations {
+		builder.WriteString("\n  - " + violation.Message)
+	}
+	return builder.String()
+}
+
+// Violations returns the violations in err, which is a ValidationError or
+// wraps one, or nil when it does not
+func Violations(err error) []Violation {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Violations
+	}
+	return nil
+}
+
+// violations collects the problems found by a validator
+type violations []Violation
+
+// add records a problem with the given settings
+func (v *violations) add(message string, flags ...string) {
+	*v = append(*v, Violation{Message: message, Flags: flags})
+}
+
+// merge records the violations of an error another validator or parser
+// returned. A plain error is one violation of flags.
+func (v *violations) merge(err error, flags ...string) {
+	if err == nil {
+		return
+	}
+	if found := Violations(err); found != nil {
+		*v = append(*v, found...)
+		return
+	}
+	v.add(err.Error(), flags...)
+}
+
+// err returns a ValidationError of the violations, or nil if there are none
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v}
+}
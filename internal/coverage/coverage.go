@@ -0,0 +1,134 @@
+package coverage
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+)
+
+// UnlistedPage represents an internal page that is linked from crawled pages
+// but is missing from the sitemap
+type UnlistedPage struct {
+	URL        string `json:"url"`
+	LinkedFrom int    `json:"linked_from"`
+}
+
+// Report represents the result of comparing the sitemap with the internal link graph
+type Report struct {
+	SitemapURLs  int            `json:"sitemap_urls"`
+	PagesScanned int            `json:"pages_scanned"`
+	Orphans      []string       `json:"orphans"`
+	Unlisted     []UnlistedPage `json:"unlisted"`
+}
+
+// Collector records the internal links discovered on crawled pages
+type Collector struct {
+	mu    sync.Mutex
+	links map[string]map[string]bool
+}
+
+// NewCollector creates a new link collector
+func NewCollector() *Collector {
+	return &Collector{
+		links: make(map[string]map[string]bool),
+	}
+}
+
+// Record stores the internal links found on a page. Recording the same page
+// more than once (e.g. in cache verification mode) merges the link sets.
+func (c *Collector) Record(pageURL string, links []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	page := normalizeURL(pageURL)
+	if c.links[page] == nil {
+		c.links[page] = make(map[string]bool)
+	}
+	for _, link := range links {
+		if link != page {
+			c.links[page][link] = true
+		}
+	}
+}
+
+// Report builds a coverage report for the given sitemap URLs
+func (c *Collector) Report(sitemapURLs []string) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inSitemap := make(map[string]bool, len(sitemapURLs))
+	for _, sitemapURL := range sitemapURLs {
+		inSitemap[normalizeURL(sitemapURL)] = true
+	}
+
+	inbound := make(map[string]int)
+	for _, pageLinks := range c.links {
+		for link := range pageLinks {
+			inbound[link]++
+		}
+	}
+
+	return &Report{
+		SitemapURLs:  len(inSitemap),
+		PagesScanned: len(c.links),
+		Orphans:      findOrphans(inSitemap, inbound),
+		Unlisted:     findUnlisted(inSitemap, inbound),
+	}
+}
+
+// findOrphans returns sitemap URLs that no scanned page links to
+func findOrphans(inSitemap map[string]bool, inbound map[string]int) []string {
+	orphans := make([]string, 0)
+	for sitemapURL := range inSitemap {
+		if inbound[sitemapURL] == 0 {
+			orphans = append(orphans, sitemapURL)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// findUnlisted returns linked internal pages that are not in the sitemap
+func findUnlisted(inSitemap map[string]bool, inbound map[string]int) []UnlistedPage {
+	unlisted := make([]UnlistedPage, 0)
+	for link, count := range inbound {
+		if !inSitemap[link] {
+			unlisted = append(unlisted, UnlistedPage{URL: link, LinkedFrom: count})
+		}
+	}
+	sort.Slice(unlisted, func(i, j int) bool {
+		if unlisted[i].LinkedFrom != unlisted[j].LinkedFrom {
+			return unlisted[i].LinkedFrom > unlisted[j].LinkedFrom
+		}
+		return unlisted[i].URL < unlisted[j].URL
+	})
+	return unlisted
+}
+
+// Name returns the analyzer name of the coverage report
+func (c *Collector) Name() string {
+	return "coverage"
+}
+
+// Analyze records the links of a page that loaded. Coverage is a report
+// over the whole crawl, so a page has no findings of its own.
+func (c *Collector) Analyze(page *analysis.Page) []analysis.Finding {
+	if page.StatusCode == http.StatusOK && page.Document != nil {
+		c.Record(page.URL, page.Links())
+	}
+	return nil
+}
+
+// normalizeURL strips fragments so links and sitemap entries compare equal
+func normalizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
@@ -0,0 +1,71 @@
+package coverage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorAnalyze(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "relative and absolute links",
+			body:     `<a href="/about">About</a><a href="https://example.com/contact#form">Contact</a>`,
+			expected: []string{"https://example.com/about", "https://example.com/contact"},
+		},
+		{
+			name:     "external and non-http links are ignored",
+			body:     `<a href="https://other.com/">Other</a><a href="mailto:me@example.com">Mail</a>`,
+			expected: nil,
+		},
+		{
+			name:     "anchors without href are ignored",
+			body:     `<a name="top">Top</a><link href="/style.css">`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			collector := NewCollector()
+			resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+			collector.Analyze(analysis.NewPage("https://example.com/page", resp, []byte(tt.body)))
+
+			// With no sitemap URLs, every recorded link is unlisted
+			var links []string
+			for _, page := range collector.Report(nil).Unlisted {
+				links = append(links, page.URL)
+			}
+			assert.Equal(t, tt.expected, links)
+		})
+	}
+}
+
+func TestCollectorReport(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector()
+	collector.Record("https://example.com/", []string{"https://example.com/a", "https://example.com/hidden"})
+	collector.Record("https://example.com/a", []string{"https://example.com/", "https://example.com/hidden", "https://example.com/a"})
+	collector.Record("https://example.com/orphan", nil)
+
+	report := collector.Report([]string{
+		"https://example.com/",
+		"https://example.com/a",
+		"https://example.com/orphan",
+	})
+
+	assert.Equal(t, 3, report.SitemapURLs)
+	assert.Equal(t, 3, report.PagesScanned)
+	assert.Equal(t, []string{"https://example.com/orphan"}, report.Orphans)
+	assert.Equal(t, []UnlistedPage{{URL: "https://example.com/hidden", LinkedFrom: 2}}, report.Unlisted)
+}
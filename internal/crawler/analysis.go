@@ -0,0 +1,452 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+	"github.com/benvon/sitemap-crawler/internal/annotations"
+	"github.com/benvon/sitemap-crawler/internal/bodies"
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/duplicates"
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/benvon/sitemap-crawler/internal/output"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// newAnalyzers creates the pipeline of the enabled page analyses: the
+// audit, the coverage report and the analyzers of the findings report. It
+// returns nil if none are enabled.
+func (c *Crawler) newAnalyzers() (*analysis.Pipeline, error) {
+	var analyzers []analysis.Analyzer
+	if c.audit != nil {
+		analyzers = append(analyzers, c.audit)
+	}
+	if c.coverage != nil {
+		analyzers = append(analyzers, c.coverage)
+	}
+	if c.config.FindingsReport != "" {
+		names := c.config.Analyzers
+		if len(names) == 0 {
+			names = analysis.Names
+		}
+		for _, name := range names {
+			analyzer, ok := analysis.New(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown analyzer: %s", name)
+			}
+			analyzers = append(analyzers, analyzer)
+		}
+	}
+
+	if len(analyzers) == 0 {
+		return nil, nil
+	}
+	return analysis.NewPipeline(analyzers...), nil
+}
+
+// analyzeResponse feeds a response to the enabled page analyses. The body
+// is read and parsed once so that every analyzer shares the same page.
+func (c *Crawler) analyzeResponse(url string, resp *http.Response) {
+	if c.analyzers == nil {
+		return
+	}
+
+	var body []byte
+	if isHTML(resp) {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxAnalysisBodyBytes))
+		if err != nil {
+			c.logger.WithError(err).WithField("url", url).Debug("Failed to read response body for analysis")
+		}
+	}
+
+	c.analyzers.Analyze(analysis.NewPage(url, resp, body))
+}
+
+// analyzeFailure records requests that failed without a response
+func (c *Crawler) analyzeFailure(url string) {
+	if c.analyzers != nil {
+		c.analyzers.Analyze(analysis.FailedPage(url))
+	}
+}
+
+// checkLastMod compares the sitemap lastmod with the server's Last-Modified header
+func (c *Crawler) checkLastMod(entry parser.URL, resp *http.Response) {
+	if c.freshness == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+	c.freshness.Check(entry, resp.Header)
+}
+
+// recordHAR adds a captured exchange to the HAR file when the recording policy selects it
+func (c *Crawler) recordHAR(capture *har.Capture, resp *http.Response, err error) {
+	if capture == nil {
+		return
+	}
+
+	failed := err != nil || resp.StatusCode < 200 || resp.StatusCode >= 400
+	if c.harPolicy.ShouldRecord(failed) {
+		c.harRecorder.Add(capture.Entry(resp, err))
+	}
+}
+
+// startBodyCapture starts saving the response body if body capture is on
+// and the response is a failure or falls in the sample
+func (c *Crawler) startBodyCapture(resp *http.Response) *bodies.Capture {
+	if c.bodies == nil {
+		return nil
+	}
+
+	failed := resp.StatusCode < 200 || resp.StatusCode >= 400
+	if !c.bodyPolicy.ShouldRecord(failed) {
+		return nil
+	}
+	return c.bodies.Start(resp, failed)
+}
+
+// saveBody saves a captured response body once it has been read
+func (c *Crawler) saveBody(capture *bodies.Capture) {
+	if capture == nil {
+		return
+	}
+
+	if err := capture.Save(); err != nil {
+		c.logger.WithError(err).Warn("Failed to save response body")
+	}
+}
+
+// startFingerprint starts hashing the body of a successful response if a
+// duplicates report was requested
+func (c *Crawler) startFingerprint(resp *http.Response) *duplicates.Hasher {
+	if c.duplicates == nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	hasher := duplicates.NewHasher(maxResponseDrainBytes)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, hasher), resp.Body}
+	return hasher
+}
+
+// addFingerprint records the fingerprint of a body once it has been read
+func (c *Crawler) addFingerprint(url string, hasher *duplicates.Hasher) {
+	if hasher == nil {
+		return
+	}
+	c.duplicates.Add(url, hasher.Fingerprint())
+}
+
+// newFormatter creates a formatter that applies the configured CSV options
+// and embeds the run metadata
+func (c *Crawler) newFormatter(format string) *output.Formatter {
+	// The delimiter was checked when the configuration was validated
+	delimiter, _ := config.ParseCSVDelimiter(c.config.CSVDelimiter)
+	return output.New(format).WithRun(c.run).WithCSVOptions(output.CSVOptions{
+		Delimiter: delimiter,
+		QuoteAll:  c.config.CSVQuote == "all",
+	})
+}
+
+// isHTML reports whether a response carries an HTML document
+func isHTML(resp *http.Response) bool {
+	return strings.Contains(resp.Header.Get("Content-Type"), "text/html")
+}
+
+// writeCoverageReport writes the sitemap coverage report if one was requested
+func (c *Crawler) writeCoverageReport(urls []parser.URL) error {
+	if c.coverage == nil {
+		return nil
+	}
+
+	report := c.coverage.Report(parser.Locations(urls))
+	formatter := c.newFormatter(c.config.CoverageFormat)
+	if err := formatter.WriteToFile(c.config.CoverageReport, formatter.FormatCoverageReport(report)); err != nil {
+		return fmt.Errorf("failed to write coverage report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":          c.config.CoverageReport,
+		"pages_scanned": report.PagesScanned,
+		"orphans":       len(report.Orphans),
+		"unlisted":      len(report.Unlisted),
+	}).Info("Coverage report written")
+	return nil
+}
+
+// writeLastModReport writes the lastmod comparison report if one was requested
+func (c *Crawler) writeLastModReport() error {
+	if c.freshness == nil {
+		return nil
+	}
+
+	report := c.freshness.Report()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.LastModReport, formatter.FormatLastModReport(report)); err != nil {
+		return fmt.Errorf("failed to write lastmod report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":                  c.config.LastModReport,
+		"checked":               report.Checked,
+		"missing_last_modified": report.MissingLastModified,
+		"discrepancies":         len(report.Discrepancies),
+	}).Info("Lastmod report written")
+	return nil
+}
+
+// writeRejectedReport writes the sitemap URLs that were not crawled if a
+// rejected report was requested. It is written even when nothing was
+// rejected, so that a clean sitemap leaves an empty report.
+func (c *Crawler) writeRejectedReport(rejected []parser.Rejection) error {
+	if c.config.RejectedReport == "" {
+		return nil
+	}
+
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.RejectedReport, formatter.FormatRejectedReport(rejected)); err != nil {
+		return fmt.Errorf("failed to write rejected report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":     c.config.RejectedReport,
+		"rejected": len(rejected),
+	}).Info("Rejected report written")
+	return nil
+}
+
+// writeDuplicatesReport writes the clusters of URLs serving duplicate
+// content if a duplicates report was requested
+func (c *Crawler) writeDuplicatesReport() error {
+	if c.duplicates == nil {
+		return nil
+	}
+
+	report := c.duplicates.Report(c.config.DuplicatesDistance)
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.DuplicatesReport, formatter.FormatDuplicatesReport(report)); err != nil {
+		return fmt.Errorf("failed to write duplicates report: %w", err)
+	}
+
+	urls := 0
+	for _, cluster := range report.Clusters {
+		urls += len(cluster.URLs)
+	}
+	c.logger.WithFields(logrus.Fields{
+		"file":     c.config.DuplicatesReport,
+		"checked":  report.Checked,
+		"clusters": len(report.Clusters),
+		"urls":     urls,
+	}).Info("Duplicates report written")
+	return nil
+}
+
+// writeTimelineReport writes per-minute request counts, error rates and
+// p95 latency if a timeline report was requested
+func (c *Crawler) writeTimelineReport() error {
+	if c.config.TimelineReport == "" {
+		return nil
+	}
+
+	timeline := c.Stats().GetTimeline()
+	formatter := c.newFormatter(c.config.TimelineFormat)
+	if err := formatter.WriteToFile(c.config.TimelineReport, formatter.FormatTimeline(timeline)); err != nil {
+		return fmt.Errorf("failed to write timeline report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":    c.config.TimelineReport,
+		"minutes": len(timeline),
+	}).Info("Timeline report written")
+	return nil
+}
+
+// writeCacheEfficacyReport writes per-URL cache efficacy scores if a report
+// was requested
+func (c *Crawler) writeCacheEfficacyReport() error {
+	if c.config.CacheEfficacyReport == "" {
+		return nil
+	}
+
+	scores := c.Stats().GetCacheEfficacy()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.CacheEfficacyReport, formatter.FormatCacheEfficacy(scores)); err != nil {
+		return fmt.Errorf("failed to write cache efficacy report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file": c.config.CacheEfficacyReport,
+		"urls": len(scores),
+	}).Info("Cache efficacy report written")
+	return nil
+}
+
+// writeFindingsReport writes the findings of the page analyzers if a
+// findings report was requested. It is written even when nothing was found,
+// so that a clean crawl leaves an empty report.
+func (c *Crawler) writeFindingsReport() error {
+	if c.config.FindingsReport == "" {
+		return nil
+	}
+
+	findings := c.analyzers.Findings()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.FindingsReport, formatter.FormatFindings(findings)); err != nil {
+		return fmt.Errorf("failed to write findings report: %w", err)
+	}
+
+	pages := make(map[string]bool)
+	for _, finding := range findings {
+		pages[finding.URL] = true
+	}
+	c.logger.WithFields(logrus.Fields{
+		"file":     c.config.FindingsReport,
+		"findings": len(findings),
+		"pages":    len(pages),
+	}).Info("Findings report written")
+	return nil
+}
+
+// writeAuditReport writes the SEO audit report to the configured file or stdout
+func (c *Crawler) writeAuditReport() error {
+	if c.audit == nil {
+		return nil
+	}
+
+	formatter := c.newFormatter(c.config.OutputFormat)
+	content := formatter.FormatAuditReport(c.audit.Reports())
+
+	if c.config.AuditReport == "" {
+		if _, err := fmt.Fprintln(os.Stdout, content); err != nil {
+			return fmt.Errorf("failed to write audit report: %w", err)
+		}
+		return nil
+	}
+
+	if err := formatter.WriteToFile(c.config.AuditReport, content); err != nil {
+		return fmt.Errorf("failed to write audit report: %w", err)
+	}
+
+	c.logger.WithField("file", c.config.AuditReport).Info("Audit report written")
+	return nil
+}
+
+// writeHARFile writes the recorded requests and responses if a HAR file was requested
+func (c *Crawler) writeHARFile() error {
+	if c.harRecorder == nil {
+		return nil
+	}
+
+	data, err := c.harRecorder.Marshal(c.run)
+	if err != nil {
+		return err
+	}
+
+	if err := c.newFormatter("json").WriteToFile(c.config.HARFile, string(data)); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":    c.config.HARFile,
+		"entries": c.harRecorder.Len(),
+	}).Info("HAR file written")
+	return nil
+}
+
+// writeBodyIndex writes the index of captured response bodies
+func (c *Crawler) writeBodyIndex() error {
+	if c.bodies == nil {
+		return nil
+	}
+
+	if err := c.bodies.WriteIndex(); err != nil {
+		return err
+	}
+
+	saved, size, skipped := c.bodies.Summary()
+	fields := logrus.Fields{
+		"dir":     c.config.CaptureDir,
+		"bodies":  saved,
+		"bytes":   size,
+		"skipped": skipped,
+	}
+	if skipped > 0 {
+		c.logger.WithFields(fields).Warn("Response body capture reached its size cap")
+		return nil
+	}
+	c.logger.WithFields(fields).Info("Response bodies captured")
+	return nil
+}
+
+// writeAnnotations prints GitHub Actions annotations to stdout if they were requested
+func (c *Crawler) writeAnnotations() error {
+	if c.annotations == nil {
+		return nil
+	}
+
+	finalStats := c.Stats().GetFinalStats()
+	summary := annotations.Summary{Final: &finalStats}
+	if c.config.CacheVerificationMode {
+		cacheStats := c.Stats().GetCacheStats()
+		summary.Cache = &cacheStats
+	}
+	if cancelled, ok := c.backoffManager.GetStats()["cancelled"].(bool); ok {
+		summary.Cancelled = cancelled
+		summary.CancelReason = c.backoffManager.CancelReason()
+	}
+
+	return annotations.Write(os.Stdout, c.annotations.Annotations(summary))
+}
+
+// writeFailuresFile writes failed and missed URLs if a failures file was requested
+func (c *Crawler) writeFailuresFile() error {
+	if c.failures == nil {
+		return nil
+	}
+
+	formatter := c.newFormatter("csv")
+	if err := formatter.WriteToFile(c.config.FailuresFile, formatter.FormatFailures(c.failures.Records())); err != nil {
+		return fmt.Errorf("failed to write failures file: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":    c.config.FailuresFile,
+		"entries": c.failures.Len(),
+	}).Info("Failures file written")
+	return nil
+}
+
+// writeBadgeFile writes a shields.io endpoint badge if a badge file was
+// requested
+func (c *Crawler) writeBadgeFile() error {
+	if c.config.BadgeFile == "" {
+		return nil
+	}
+
+	finalStats := c.Stats().GetFinalStats()
+	var cacheStats *stats.CacheStats
+	if c.config.CacheVerificationMode {
+		cache := c.Stats().GetCacheStats()
+		cacheStats = &cache
+	}
+
+	badge := output.NewBadge(&finalStats, cacheStats)
+	formatter := c.newFormatter("json")
+	if err := formatter.WriteToFile(c.config.BadgeFile, formatter.FormatBadge(badge)); err != nil {
+		return fmt.Errorf("failed to write badge file: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":    c.config.BadgeFile,
+		"message": badge.Message,
+		"color":   badge.Color,
+	}).Info("Badge file written")
+	return nil
+}
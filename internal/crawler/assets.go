@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// rangeAssets identifies the large assets that the assets request mode
+// requests a byte of: by their extension, or by their Content-Type once a
+// full GET has returned it, after which the URL is requested a byte at a
+// time in later passes
+type rangeAssets struct {
+	extensions   map[string]bool
+	contentTypes []string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// newRangeAssets creates the asset matcher from extensions, with or without
+// a leading dot, and media types or type prefixes ending in "/"
+func newRangeAssets(extensions, contentTypes []string) *rangeAssets {
+	assets := &rangeAssets{
+		extensions: make(map[string]bool, len(extensions)),
+		seen:       make(map[string]bool),
+	}
+	for _, extension := range extensions {
+		assets.extensions["."+strings.ToLower(strings.TrimPrefix(strings.TrimSpace(extension), "."))] = true
+	}
+	for _, contentType := range contentTypes {
+		assets.contentTypes = append(assets.contentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+	}
+	return assets
+}
+
+// matchesURL reports whether a URL is a large asset, by its extension or
+// because an earlier response for it had an asset Content-Type
+func (a *rangeAssets) matchesURL(rawURL string) bool {
+	if parsed, err := url.Parse(rawURL); err == nil && a.extensions[strings.ToLower(path.Ext(parsed.Path))] {
+		return true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.seen[rawURL]
+}
+
+// matchesResponse reports whether a response carries a large asset by its
+// Content-Type, and remembers its URL if so
+func (a *rangeAssets) matchesResponse(rawURL string, resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, contentType := range a.contentTypes {
+		if mediaType == contentType || (strings.HasSuffix(contentType, "/") && strings.HasPrefix(mediaType, contentType)) {
+			a.mu.Lock()
+			a.seen[rawURL] = true
+			a.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
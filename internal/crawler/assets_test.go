@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeAssets(t *testing.T) {
+	t.Parallel()
+
+	assets := newRangeAssets([]string{".MP4", "zip"}, []string{"video/", "application/x-tar"})
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		wantURL     bool
+		wantType    bool
+	}{
+		{name: "extension", url: "https://example.com/intro.mp4?v=2", wantURL: true},
+		{name: "extension case", url: "https://example.com/archive.ZIP", wantURL: true},
+		{name: "page", url: "https://example.com/about", contentType: "text/html; charset=utf-8"},
+		{name: "type prefix", url: "https://example.com/stream", contentType: "video/webm", wantType: true},
+		{name: "exact type", url: "https://example.com/backup", contentType: "application/x-tar", wantType: true},
+		{name: "type is not a prefix", url: "https://example.com/tarball", contentType: "application/x-tarball"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.wantURL, assets.matchesURL(tt.url))
+
+			resp := &http.Response{Header: http.Header{"Content-Type": {tt.contentType}}}
+			assert.Equal(t, tt.wantType, assets.matchesResponse(tt.url, resp))
+			assert.Equal(t, tt.wantURL || tt.wantType, assets.matchesURL(tt.url), "URLs found by type are remembered")
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"net/http"
+
+	"github.com/benvon/sitemap-crawler/internal/cassette"
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// newRoundTripper wraps the HTTP transport in a cassette recorder or
+// replaces it with a cassette player, as configured. The recorder and player
+// are returned so the run can save and report on them.
+func newRoundTripper(cfg *config.Config, next http.RoundTripper) (http.RoundTripper, *cassette.Recorder, *cassette.Player, error) {
+	switch {
+	case cfg.Replay != "":
+		player, err := cassette.Load(cfg.Replay)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return player, nil, player, nil
+	case cfg.Record != "":
+		recorder, err := cassette.Create(cfg.Record, next, cfg.RecordMaxBodyBytes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return recorder, recorder, nil, nil
+	default:
+		return next, nil, nil, nil
+	}
+}
+
+// writeCassette completes the cassette file if recording, and warns about
+// requests a replayed cassette had no response for
+func (c *Crawler) writeCassette() error {
+	if c.player != nil && c.player.Missing() > 0 {
+		c.logger.WithFields(logrus.Fields{
+			"file":    c.config.Replay,
+			"missing": c.player.Missing(),
+		}).Warn("Cassette had no recorded response for some requests")
+	}
+
+	if c.recorder == nil {
+		return nil
+	}
+
+	if err := c.recorder.Close(); err != nil {
+		return err
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":         c.config.Record,
+		"interactions": c.recorder.Len(),
+	}).Info("Cassette written")
+	return nil
+}
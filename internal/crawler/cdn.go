@@ -0,0 +1,26 @@
+package crawler
+
+import "net/http"
+
+// cdnTraceHeaders are the headers that identify a request in each supported
+// CDN's logs, recorded as the result's trace ID
+var cdnTraceHeaders = map[string]string{
+	"cloudflare": "CF-Ray",
+	"fastly":     "X-Served-By",
+}
+
+// cdnRequestHeaders are sent with every request to a CDN. Fastly strips
+// Surrogate-Key from responses unless Fastly-Debug is set.
+var cdnRequestHeaders = map[string]map[string]string{
+	"fastly": {"Fastly-Debug": "1"},
+}
+
+// setCDNHeaders adds the configured CDN's request headers unless they are
+// already set
+func (c *Crawler) setCDNHeaders(req *http.Request) {
+	for key, value := range cdnRequestHeaders[c.config.CDN] {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
+}
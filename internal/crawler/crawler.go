@@ -2,36 +2,215 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+	"github.com/benvon/sitemap-crawler/internal/annotations"
+	"github.com/benvon/sitemap-crawler/internal/audit"
 	"github.com/benvon/sitemap-crawler/internal/backoff"
+	"github.com/benvon/sitemap-crawler/internal/bodies"
+	"github.com/benvon/sitemap-crawler/internal/cassette"
 	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/coverage"
+	"github.com/benvon/sitemap-crawler/internal/device"
+	"github.com/benvon/sitemap-crawler/internal/dualstack"
+	"github.com/benvon/sitemap-crawler/internal/duplicates"
+	"github.com/benvon/sitemap-crawler/internal/expect"
+	"github.com/benvon/sitemap-crawler/internal/failures"
+	"github.com/benvon/sitemap-crawler/internal/freshness"
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/benvon/sitemap-crawler/internal/health"
+	"github.com/benvon/sitemap-crawler/internal/output"
 	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/purge"
+	"github.com/benvon/sitemap-crawler/internal/request"
+	"github.com/benvon/sitemap-crawler/internal/retry"
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/schedule"
+	"github.com/benvon/sitemap-crawler/internal/sigv4"
 	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/trace"
+	"github.com/benvon/sitemap-crawler/internal/transport"
+	"github.com/benvon/sitemap-crawler/internal/urlstate"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 )
 
-const maxResponseDrainBytes = 512 * 1024
+const (
+	maxResponseDrainBytes = 512 * 1024
+	maxAnalysisBodyBytes  = 5 * 1024 * 1024
+
+	// maxSurrogateKeyStats bounds the per-key lines, since a page can carry
+	// dozens of keys
+	maxSurrogateKeyStats = 20
+
+	// maxRedirects is how many redirects are followed, as by default
+	maxRedirects = 10
+
+	// maxValidatorChanges bounds the per-URL lines for unstable validators
+	maxValidatorChanges = 20
+
+	// maxEfficacyURLs bounds the per-URL lines for URLs warming did not help
+	maxEfficacyURLs = 10
+
+	// lowEfficacyScore is the cache efficacy score of a cache hit that was
+	// no faster and had no Age
+	lowEfficacyScore = 50
+)
 
 // Crawler handles the crawling process
 type Crawler struct {
 	config         *config.Config
-	logger         *logrus.Logger
+	logger         logrus.FieldLogger
+	run            *runinfo.Run
 	parser         *parser.Parser
 	stats          *stats.Stats
 	client         *http.Client
+	requests       *request.Builder
+	purger         purge.Purger
 	backoffManager *backoff.Manager
+	retries        *retry.Policy
+	expect         *expect.Criteria
+	coverage       *coverage.Collector
+	audit          *audit.Collector
+	analyzers      *analysis.Pipeline
+	freshness      *freshness.Collector
+	duplicates     *duplicates.Collector
+	harRecorder    *har.Recorder
+	harPolicy      *har.Policy
+	trace          *trace.Recorder
+	bodies         *bodies.Store
+	bodyPolicy     *har.Policy
+	rangeAssets    *rangeAssets
+	hostSlots      *hostSlots
+	memory         *memoryGuard
+	hostOrder      *hostOrder
+	priority       *priorityLanes
+	priorityOrder  bool
+	scheduler      *schedule.Scheduler
+	refresh        *sitemapRefresh
+	recorder       *cassette.Recorder
+	player         *cassette.Player
+	proxyGuard     *transport.ProxyGuard
+	resolver       transport.Resolver
+	health         *health.Probe
+	urlState       *urlstate.Tracker
+	deadline       time.Time
+	modifiedCutoff time.Time
+	deadlineWarned atomic.Bool
+	annotations    *annotations.Collector
+	failures       *failures.Collector
+	okURLs         *okURLs
+	results        *resultLog
+	exporters      []namedExporter
+	exportResults  *resultLog
+	progress       *progressSettings
+
+	// Device matrix crawls; device is the profile of the current pass
+	devices      []device.Profile
+	device       *device.Profile
+	deviceMatrix *device.Collector
+
+	// Dual-stack crawls; family is the address family of the current pass
+	families  []familyClient
+	family    *familyClient
+	dualStack *dualstack.Collector
+
+	// Cache verification against CDN edges; edge is the one the current
+	// verification pass is sent to
+	edges []edgeClient
+	edge  *edgeClient
+
+	// Workers each pass runs, which setWorkers can change while pool, the
+	// running pass's workers, is crawling
+	poolMu  sync.Mutex
+	workers int
+	pool    *workerPool
+
+	// Set during repeat crawls
+	aggregate      *stats.Stats
+	iterationCache *iterationCache
+	palette        output.Palette
+
+	// events delivers progress and results to Subscribe callers
+	events subscribers
 }
 
-// New creates a new crawler instance
-func New(cfg *config.Config, logger *logrus.Logger) *Crawler {
+// New creates a new crawler instance. The logger may be a *logrus.Logger,
+// an entry with fields already set, or a logger from logging.NewSlog that
+// writes through a log/slog handler.
+func New(cfg *config.Config, logger logrus.FieldLogger) (*Crawler, error) {
+	var proxyGuard *transport.ProxyGuard
+	if cfg.ProxyStrict {
+		proxyGuard = transport.NewProxyGuard()
+	}
+	transportCfg, err := transportConfig(cfg, "", proxyGuard)
+	if err != nil {
+		return nil, err
+	}
+	httpTransport, err := transport.New(transportCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP transport: %w", err)
+	}
+	// Every client shares one bandwidth limit
+	bandwidth, err := newBandwidthLimiter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	roundTripper, recorder, player, err := newRoundTripper(cfg, throttle(sign(httpTransport, signer), bandwidth))
+	if err != nil {
+		return nil, err
+	}
+
 	sitemapParser := parser.NewParser(cfg.RequestTimeout)
 	sitemapParser.SetUserAgent(cfg.UserAgent)
+	sitemapParser.SetTransport(roundTripper)
+	sitemapParser.SetLimits(parser.Limits{
+		MaxBytes: cfg.MaxSitemapBytes,
+		MaxDepth: cfg.MaxSitemapDepth,
+		MaxURLs:  cfg.MaxSitemapURLs,
+	})
+	sitemapParser.SetBlockedDomains(cfg.BlockDomains)
+
+	cancelRules := make([]backoff.CancelRule, 0, len(cfg.CancelOn))
+	for _, spec := range cfg.CancelOn {
+		rule, err := backoff.ParseCancelRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		cancelRules = append(cancelRules, rule)
+	}
+
+	retryRules := make([]retry.Rule, 0, len(cfg.Retry))
+	for _, spec := range cfg.Retry {
+		rule, err := retry.ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		retryRules = append(retryRules, rule)
+	}
+
+	expectRules := make([]expect.Rule, 0, len(cfg.ExpectStatus))
+	for _, spec := range cfg.ExpectStatus {
+		rule, err := expect.ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		expectRules = append(expectRules, rule)
+	}
 
 	// Create backoff manager
 	backoffManager := backoff.NewManager(logger, backoff.Config{
@@ -42,29 +221,229 @@ func New(cfg *config.Config, logger *logrus.Logger) *Crawler {
 		ResponseTimeDegradationThreshold: cfg.ResponseTimeDegradationThreshold,
 		ForbiddenErrorThreshold:          cfg.ForbiddenErrorThreshold,
 		ForbiddenErrorWindow:             cfg.ForbiddenErrorWindow,
+		Recovery:                         cfg.BackoffRecovery,
+		DecayInterval:                    cfg.BackoffDecayInterval,
+		CancelRules:                      cancelRules,
 	})
 
-	return &Crawler{
+	c := &Crawler{
 		config:         cfg,
 		logger:         logger,
+		run:            runinfo.New(cfg.Version, []string{cfg.SitemapURL}, cfg.Snapshot()),
 		parser:         sitemapParser,
 		stats:          stats.New(),
 		backoffManager: backoffManager,
+		retries:        retry.NewPolicy(retryRules),
+		expect:         expect.NewCriteria(expectRules),
+		hostSlots:      newHostSlots(cfg.MaxConcurrentPerHost),
+		workers:        cfg.MaxWorkers,
+		recorder:       recorder,
+		player:         player,
+		proxyGuard:     proxyGuard,
+		resolver:       transportCfg.Resolver,
+		priorityOrder:  cfg.OrderBy == config.OrderByPriority,
+		scheduler:      newScheduler(cfg),
+		palette:        output.NewPalette(colorEnabled(logger, cfg.Color)),
+		progress:       newProgressSettings(cfg.ProgressInterval, cfg.Quiet, logger),
 		client: &http.Client{
-			Timeout: cfg.RequestTimeout,
+			Timeout:   cfg.RequestTimeout,
+			Transport: roundTripper,
 		},
 	}
+	c.run.Seed = newSeed(cfg.Seed)
+	c.stats.SetThrottle(c.throttle)
+
+	memoryLimit, err := config.ParseMemoryLimit(cfg.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	c.memory = newMemoryGuard(memoryLimit, cfg.MaxWorkers, logger, c.setWorkers, c.runningWorkers)
+
+	if cfg.HostOrder != "" {
+		c.hostOrder, err = parseHostOrder(cfg.HostOrder, cfg.HostOrderThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.PriorityPatterns) > 0 {
+		c.priority, err = newPriorityLanes(cfg.PriorityPatterns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// CDN purge APIs authenticate with their own tokens, which a signature
+	// would replace
+	apiClient := c.client
+	if signer != nil {
+		apiClient = &http.Client{Timeout: cfg.RequestTimeout, Transport: throttle(httpTransport, bandwidth)}
+	}
+	c.purger = c.newPurger(apiClient)
+
+	if cfg.RequestTemplate != "" {
+		c.requests, err = request.Load(cfg.RequestTemplate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.CoverageReport != "" {
+		c.coverage = coverage.NewCollector()
+	}
+
+	if cfg.LastModReport != "" {
+		c.freshness = freshness.NewCollector(cfg.LastModTolerance)
+	}
+
+	if cfg.DuplicatesReport != "" {
+		c.duplicates = duplicates.NewCollector()
+	}
+
+	if cfg.TraceFile != "" {
+		c.trace = trace.NewRecorder()
+	}
+
+	if cfg.HARFile != "" {
+		c.harRecorder = har.NewRecorder()
+		if !cfg.HARIncludeSecrets {
+			c.harRecorder.RedactHeaders(config.IsSensitive, config.Redacted)
+		}
+		c.harPolicy = har.NewPolicy(cfg.HARMode, cfg.HARSampleRate, c.newRandom(randomHARSample))
+	}
+
+	if cfg.RequestMode == config.RequestModeAssets {
+		c.rangeAssets = newRangeAssets(cfg.RangeExtensions, cfg.RangeContentTypes)
+	}
+
+	if cfg.CaptureDir != "" {
+		c.bodies, err = bodies.NewStore(cfg.CaptureDir, cfg.CaptureMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		c.bodyPolicy = har.NewPolicy(har.ModeSample, cfg.CaptureSampleRate, c.newRandom(randomBodySample))
+	}
+
+	if cfg.FailuresFile != "" {
+		c.failures = failures.NewCollector()
+	}
+
+	if cfg.ResultsFile != "" {
+		c.results = newResultLog(cfg.ResultsSampleRate, c.newRandom(randomResultSample))
+	}
+
+	if c.exporters, err = newExporters(cfg.Export); err != nil {
+		return nil, err
+	}
+	if len(c.exporters) > 0 {
+		c.exportResults = newResultLog(1, nil)
+	}
+
+	if cfg.CleanSitemap != "" || cfg.IndexNowKey != "" {
+		c.okURLs = newOKURLs()
+	}
+
+	if len(cfg.Devices) > 0 {
+		c.devices, err = newDeviceProfiles(cfg.Devices, cfg.DeviceProfiles)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(c.devices))
+		for i, profile := range c.devices {
+			names[i] = profile.Name
+		}
+		c.deviceMatrix = device.NewCollector(names, cfg.DeviceSizeTolerance)
+	}
+
+	if cfg.DualStack {
+		c.families, err = newFamilyClients(cfg, bandwidth, signer, proxyGuard)
+		if err != nil {
+			return nil, err
+		}
+		c.dualStack = dualstack.NewCollector(dualstack.Thresholds{
+			Ratio: cfg.DualStackSlowdownRatio,
+			Min:   cfg.DualStackSlowdownMin,
+		})
+	}
+
+	if len(cfg.Edges) > 0 {
+		c.edges, err = newEdgeClients(cfg, bandwidth, signer, proxyGuard)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.GitHubAnnotations {
+		c.annotations = annotations.NewCollector()
+	}
+
+	if cfg.Command == config.CommandAudit {
+		c.audit = audit.NewCollector()
+		// Audits report redirects instead of following them
+		c.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else if c.expect.Redirects() {
+		c.client.CheckRedirect = c.checkExpectedRedirect
+	}
+
+	c.analyzers, err = c.newAnalyzers()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
 }
 
-// Run executes the crawling process
+// Run executes the crawling process. With a strict proxy, the run fails if
+// any connection was refused for bypassing it, even if the crawl completed.
 func (c *Crawler) Run() error {
+	defer c.events.close()
+
+	err := c.execute()
+	if bypass := c.proxyGuard.Err(); bypass != nil {
+		return errors.Join(bypass, err)
+	}
+	return err
+}
+
+// execute runs the command the crawler was configured for
+func (c *Crawler) execute() error {
+	switch c.config.Command {
+	case config.CommandParse, config.CommandParseStats:
+		return c.parseOnly()
+	case config.CommandParseCheck:
+		return c.validateSitemap()
+	}
+
 	c.logger.Info("Starting sitemap crawler")
-	c.logger.WithFields(logrus.Fields{
-		"sitemap_url":  c.config.SitemapURL,
-		"max_workers":  c.config.MaxWorkers,
-		"request_rate": c.config.RequestRate,
-		"cache_mode":   c.config.CacheVerificationMode,
-	}).Info("Configuration loaded")
+	c.logger.WithFields(c.configurationFields()).Info("Configuration loaded")
+
+	deadline, err := config.ParseFinishBy(c.config.FinishBy, time.Now())
+	if err != nil {
+		return err
+	}
+	c.deadline = deadline
+
+	c.modifiedCutoff, err = config.ParseModifiedCutoff(c.config.ModifiedSince, c.config.ModifiedWithin, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Readiness stays false until the sitemap is loaded
+	stopHealth, err := c.startHealthServer()
+	if err != nil {
+		return err
+	}
+	defer stopHealth()
+
+	// The memory budget covers sitemap parsing as well as the crawl
+	stopMemory := c.memory.watch(memoryCheckInterval)
+	defer stopMemory()
+
+	// SIGHUP turns up progress output without restarting the crawl
+	stopSignal := c.watchProgressSignal()
+	defer stopSignal()
 
 	// Parse sitemap to get URLs
 	urls, err := c.parser.ParseSitemap(c.config.SitemapURL, c.config.Headers)
@@ -74,26 +453,307 @@ func (c *Crawler) Run() error {
 
 	c.logger.WithField("total_urls", len(urls)).Info("Sitemap parsed successfully")
 
+	// Pages are filtered by lastmod before the media and alternates they
+	// lead to are added
+	urls, err = c.filterModified(urls)
+	if err != nil {
+		return err
+	}
+	urls = c.withDerived(urls)
+
 	// Filter valid URLs
-	validURLs := c.filterValidURLs(urls)
+	validURLs, err := c.filterValidURLs(urls)
+	if err != nil {
+		return err
+	}
 	c.logger.WithField("valid_urls", len(validURLs)).Info("URLs filtered")
 
 	if len(validURLs) == 0 {
 		return fmt.Errorf("no valid URLs found in sitemap")
 	}
+	c.trackURLs(validURLs)
+	c.setReady(true)
+
+	if c.config.SitemapRefresh > 0 {
+		c.refresh = newSitemapRefresh(validURLs)
+		stopRefresh := c.refreshSitemap(c.config.SitemapRefresh)
+		defer stopRefresh()
+	}
+
+	if err := c.purgeURLs(validURLs); err != nil {
+		return err
+	}
+
+	if err := c.crawlRepeatedly(validURLs); err != nil {
+		return err
+	}
+
+	// Media and alternate URLs are crawled, but only the pages the sitemap
+	// lists are rendered, reported on and announced
+	pages := parser.Listed(validURLs)
+
+	c.probeVariants()
+
+	if err := c.renderPages(pages); err != nil {
+		return err
+	}
+
+	c.run.Finish()
+
+	// Writing reports is the run shutting down
+	c.setReady(false)
+
+	if err := c.writeReports(pages); err != nil {
+		return err
+	}
+
+	// The sitemap is checked and announced only once every report is
+	// written
+	if err := c.verifyPublishedSitemap(); err != nil {
+		return err
+	}
+
+	return c.pingSearchEngines(pages)
+}
+
+// writeReports writes every report and export the run is configured for.
+// A failing one does not stop the rest from being written; their errors are
+// returned together.
+func (c *Crawler) writeReports(pages []parser.URL) error {
+	writers := []func() error{
+		func() error { return c.writeCoverageReport(pages) },
+		c.writeLastModReport,
+		c.writeDuplicatesReport,
+		c.writeDeviceReport,
+		c.writeDualStackReport,
+		c.writeTimelineReport,
+		c.writeCacheEfficacyReport,
+		c.writeHeaderDiffReport,
+		c.writeEdgeReport,
+		c.writeFindingsReport,
+		c.writeHARFile,
+		c.writeTraceFile,
+		c.writeBodyIndex,
+		c.writeCassette,
+		c.writeResultsFile,
+		func() error { return c.writeCleanSitemap(pages) },
+		c.writeFailuresFile,
+		c.writeBadgeFile,
+		c.writeAnnotations,
+		c.writeAuditReport,
+		c.exportStats,
+	}
+
+	var errs []error
+	for _, write := range writers {
+		if err := write(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transportConfig returns the egress options, phase timeouts, dial rules and
+// proxy of HTTP transports, limited to an address family when one is given.
+// The guard, shared by every transport, is set when the proxy is strict.
+func transportConfig(cfg *config.Config, family string, guard *transport.ProxyGuard) (transport.Config, error) {
+	dialRules := make([]transport.DialRule, 0, len(cfg.Dial))
+	for _, spec := range cfg.Dial {
+		rule, err := transport.ParseDialRule(spec)
+		if err != nil {
+			return transport.Config{}, err
+		}
+		dialRules = append(dialRules, rule)
+	}
+
+	var resolver transport.Resolver
+	if cfg.Resolver != "" {
+		var err error
+		if resolver, err = transport.ParseResolver(cfg.Resolver); err != nil {
+			return transport.Config{}, err
+		}
+	}
+
+	var proxy *url.URL
+	if cfg.Proxy != "" {
+		var err error
+		if proxy, err = transport.ParseProxy(cfg.Proxy); err != nil {
+			return transport.Config{}, err
+		}
+	}
+
+	return transport.Config{
+		SourceIP:              cfg.SourceIP,
+		Interface:             cfg.Interface,
+		Family:                family,
+		ConnectTimeout:        cfg.ConnectTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		DialRules:             dialRules,
+		Resolver:              resolver,
+		Proxy:                 proxy,
+		ProxyGuard:            guard,
+	}, nil
+}
+
+// colorEnabled reports whether the logger's output gets colors. Only a
+// *logrus.Logger exposes its output; other loggers are not colored.
+func colorEnabled(logger logrus.FieldLogger, mode string) bool {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return output.ColorEnabled(l.Out, mode)
+	case *logrus.Entry:
+		return output.ColorEnabled(l.Logger.Out, mode)
+	default:
+		return false
+	}
+}
+
+// Stats returns the statistics collected during the crawl, combined across
+// iterations for repeat crawls
+func (c *Crawler) Stats() *stats.Stats {
+	if c.aggregate != nil {
+		return c.aggregate
+	}
+	return c.stats
+}
+
+// RunInfo returns the metadata embedded in the crawl's outputs
+func (c *Crawler) RunInfo() *runinfo.Run {
+	return c.run
+}
+
+// BackoffStats returns the backoff manager's state after the crawl
+func (c *Crawler) BackoffStats() map[string]interface{} {
+	return c.backoffManager.GetStats()
+}
+
+// crawl runs the crawler in the configured mode
+func (c *Crawler) crawl(urls []parser.URL) error {
+	urls = c.refresh.current(urls)
+	if len(c.devices) > 0 {
+		return c.crawlDevices(urls)
+	}
+	if len(c.families) > 0 {
+		return c.crawlFamilies(urls)
+	}
 
-	// Run crawler
 	if c.config.CacheVerificationMode {
-		c.stats.SetTotalURLs(len(validURLs) * 2)
-		return c.runWithCacheVerification(validURLs)
+		c.stats.PlanPhases(len(urls), append([]string{stats.PhaseWarmUp}, c.verifyPhases()...)...)
+		return c.runWithCacheVerification(urls)
+	}
+
+	c.stats.SetTotalURLs(len(urls))
+	return c.runStandardCrawl(urls)
+}
+
+// configurationFields returns the run configuration recorded in the logs
+func (c *Crawler) configurationFields() logrus.Fields {
+	fields := logrus.Fields{
+		"run_id":        c.run.ID,
+		"seed":          c.run.Seed,
+		"sitemap_url":   c.config.SitemapURL,
+		"max_workers":   c.config.MaxWorkers,
+		"request_rate":  c.config.RequestRate,
+		"request_burst": c.newLimiter().Burst(),
+		"cache_mode":    c.config.CacheVerificationMode,
+	}
+	if c.retries.Enabled() {
+		fields["retry"] = strings.Join(c.config.Retry, " ")
+	}
+	if len(c.config.ExpectStatus) > 0 {
+		fields["expect_status"] = c.config.ExpectStatus
+	}
+	if len(c.config.Dial) > 0 {
+		fields["dial"] = strings.Join(c.config.Dial, " ")
+	}
+	if !c.resolver.IsZero() {
+		fields["resolver"] = c.resolver.String()
+	}
+	if len(c.config.Edges) > 0 {
+		fields["edges"] = strings.Join(c.config.Edges, " ")
+	}
+	if len(c.config.Analyzers) > 0 {
+		fields["analyzers"] = strings.Join(c.config.Analyzers, ",")
+	}
+	if c.config.HostOrder != "" {
+		fields["host_order"] = c.config.HostOrder
+	}
+	if len(c.config.PriorityPatterns) > 0 {
+		fields["priority_patterns"] = c.config.PriorityPatterns
+	}
+	if c.priorityOrder {
+		fields["order_by"] = c.config.OrderBy
+	}
+	if c.config.RequestMode != "" && c.config.RequestMode != config.RequestModeGet {
+		fields["request_mode"] = c.config.RequestMode
+	}
+	if c.config.RateRamp > 0 {
+		fields["rate_ramp"] = c.config.RateRamp
+	}
+	if c.config.FinishBy != "" {
+		fields["finish_by"] = c.config.FinishBy
+	}
+	if c.config.ModifiedSince != "" {
+		fields["modified_since"] = c.config.ModifiedSince
+	}
+	if c.config.ModifiedWithin > 0 {
+		fields["modified_within"] = c.config.ModifiedWithin
+	}
+	if c.config.SitemapRefresh > 0 {
+		fields["sitemap_refresh"] = c.config.SitemapRefresh
+	}
+	if len(c.exporters) > 0 {
+		names := make([]string, len(c.exporters))
+		for i, exporter := range c.exporters {
+			names[i] = exporter.name
+		}
+		fields["export"] = strings.Join(names, ",")
+	}
+	if c.config.AuditVariants > 0 {
+		fields["audit_variants"] = c.config.AuditVariants
+	}
+	if c.config.CrawlMedia {
+		fields["crawl_media"] = true
+	}
+	if c.config.CrawlAlternates {
+		fields["crawl_alternates"] = true
+	}
+	if proxy, err := url.Parse(c.config.Proxy); err == nil && c.config.Proxy != "" {
+		fields["proxy"] = proxy.Redacted()
+	}
+	if c.config.ProxyStrict {
+		fields["proxy_strict"] = true
+	}
+	if c.config.MaxConcurrentPerHost > 0 {
+		fields["max_concurrent_per_host"] = c.config.MaxConcurrentPerHost
+	}
+	if c.config.MaxBandwidth != "" {
+		fields["max_bandwidth"] = c.config.MaxBandwidth
+	}
+	if c.config.MemoryLimit != "" {
+		fields["memory_limit"] = c.config.MemoryLimit
+	}
+	if c.config.SigV4Service != "" {
+		fields["sigv4_service"] = c.config.SigV4Service
+	}
+
+	sourceIP, err := transport.SourceAddress(transport.Config{
+		SourceIP:  c.config.SourceIP,
+		Interface: c.config.Interface,
+	})
+	if err == nil && sourceIP != nil {
+		fields["source_ip"] = sourceIP.String()
+		if c.config.Interface != "" {
+			fields["interface"] = c.config.Interface
+		}
 	}
 
-	c.stats.SetTotalURLs(len(validURLs))
-	return c.runStandardCrawl(validURLs)
+	return fields
 }
 
 // runStandardCrawl runs the standard crawling process
-func (c *Crawler) runStandardCrawl(urls []string) error {
+func (c *Crawler) runStandardCrawl(urls []parser.URL) error {
 	// Create cancellable context for handling 403 errors
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -102,53 +762,39 @@ func (c *Crawler) runStandardCrawl(urls []string) error {
 	c.backoffManager.SetCancelFunc(cancel)
 
 	// Create rate limiter
-	limiter := rate.NewLimiter(rate.Limit(c.config.RequestRate), c.config.RequestRate)
+	limiter := c.newPassLimiter(ctx)
+	defer c.tracePhase(failures.PhaseCrawl)()
 
 	// Create worker pool
-	urlChan := make(chan string, c.config.MaxWorkers)
+	urlChan := make(chan parser.URL, c.config.MaxWorkers)
 	resultChan := make(chan *stats.Result, c.config.MaxWorkers)
 
-	// Start workers
-	var wg sync.WaitGroup
-	for i := 0; i < c.config.MaxWorkers; i++ {
-		wg.Add(1)
-		go c.worker(ctx, i, urlChan, resultChan, limiter, &wg)
-	}
+	// Start workers; resultChan is closed once they are done
+	c.startWorkerPool(ctx, urlChan, resultChan, limiter)
 
 	// Send URLs to workers
-	go func() {
-		defer close(urlChan)
-		for _, url := range urls {
-			select {
-			case urlChan <- url:
-			case <-ctx.Done():
-				return // Exit early if cancelled
-			}
-		}
-	}()
-
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	feed := c.newPassFeed(urls)
+	go feed.send(ctx, urlChan)
 
 	// Start progress reporter
-	if !c.config.Quiet {
-		go c.startProgressReporter(ctx)
-	}
+	go c.startProgressReporter(ctx)
 
 	// Process results and update stats
 	for result := range resultChan {
+		feed.done(result.URL)
+		c.observeResult(failures.PhaseCrawl, result)
 		c.stats.AddResult(result)
 	}
 
 	c.printFinalStats()
+	c.printHostStats()
+	c.printServerTimingStats()
+	c.printRangeStats()
 	return nil
 }
 
 // runWithCacheVerification runs crawling with cache verification
-func (c *Crawler) runWithCacheVerification(urls []string) error {
+func (c *Crawler) runWithCacheVerification(urls []parser.URL) error {
 	c.logger.Info("Running in cache verification mode")
 
 	// Create cancellable context for handling 403 errors
@@ -158,6 +804,9 @@ func (c *Crawler) runWithCacheVerification(urls []string) error {
 	// Set the cancel function in the backoff manager
 	c.backoffManager.SetCancelFunc(cancel)
 
+	// One reporter covers both passes; progress names the running one
+	go c.startProgressReporter(ctx)
+
 	// First pass: warm up cache
 	c.logger.Info("Phase 1: Warming up cache")
 	if err := c.warmUpCache(ctx, urls); err != nil {
@@ -176,116 +825,145 @@ func (c *Crawler) runWithCacheVerification(urls []string) error {
 	}
 
 	c.printCacheStats()
+	c.printEdgeStats()
+	c.printSurrogateKeyStats()
+	c.printValidatorChanges()
+	c.printHeaderChanges()
+	c.printCacheEfficacy()
+	c.printHostStats()
+	c.printServerTimingStats()
+	c.printRangeStats()
 	return nil
 }
 
 // warmUpCache performs initial requests to warm up the cache
-func (c *Crawler) warmUpCache(ctx context.Context, urls []string) error {
-	limiter := rate.NewLimiter(rate.Limit(c.config.RequestRate), c.config.RequestRate)
+func (c *Crawler) warmUpCache(ctx context.Context, urls []parser.URL) error {
+	limiter := c.newPassLimiter(ctx)
 
-	c.stats.StartWarmUp()
-	defer c.stats.FinishWarmUp()
+	c.stats.StartPhase(stats.PhaseWarmUp)
+	defer c.stats.EndPhase(stats.PhaseWarmUp)
+	defer c.tracePhase(stats.PhaseWarmUp)()
 
-	urlChan := make(chan string, c.config.MaxWorkers)
+	urlChan := make(chan parser.URL, c.config.MaxWorkers)
 	resultChan := make(chan *stats.Result, c.config.MaxWorkers)
 
-	var wg sync.WaitGroup
-	for i := 0; i < c.config.MaxWorkers; i++ {
-		wg.Add(1)
-		go c.worker(ctx, i, urlChan, resultChan, limiter, &wg)
-	}
+	c.startWorkerPool(ctx, urlChan, resultChan, limiter)
 
-	go func() {
-		defer close(urlChan)
-		for _, url := range urls {
-			select {
-			case urlChan <- url:
-			case <-ctx.Done():
-				return // Exit early if cancelled
-			}
-		}
-	}()
-
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	// Start progress reporter for warm-up phase
-	if !c.config.Quiet {
-		go c.startProgressReporter(ctx)
-	}
+	feed := c.newPassFeed(urls)
+	go feed.send(ctx, urlChan)
 
 	for result := range resultChan {
+		feed.done(result.URL)
+		c.observeResult(stats.PhaseWarmUp, result)
 		c.stats.AddWarmUpResult(result)
 	}
 
 	return nil
 }
 
-// verifyCache performs second requests to check cache status
-func (c *Crawler) verifyCache(ctx context.Context, urls []string) error {
-	limiter := rate.NewLimiter(rate.Limit(c.config.RequestRate), c.config.RequestRate)
+// verifyCache performs second requests to check cache status, once per
+// edge when edges are configured
+func (c *Crawler) verifyCache(ctx context.Context, urls []parser.URL) error {
+	// Server-Timing reports describe the verification pass, so the warm-up
+	// pass's metrics are discarded. Per-edge passes keep each other's.
+	c.stats.ResetServerTiming()
 
-	c.stats.StartVerify()
-	defer c.stats.FinishVerify()
+	if len(c.edges) > 0 {
+		c.verifyEdges(ctx, urls)
+		return nil
+	}
+	c.verifyPass(ctx, stats.PhaseVerify, urls)
+	return nil
+}
 
-	urlChan := make(chan string, c.config.MaxWorkers)
-	resultChan := make(chan *stats.Result, c.config.MaxWorkers)
+// verifyPass requests every URL once as the named verification phase
+func (c *Crawler) verifyPass(ctx context.Context, phase string, urls []parser.URL) {
+	limiter := c.newPassLimiter(ctx)
 
-	var wg sync.WaitGroup
-	for i := 0; i < c.config.MaxWorkers; i++ {
-		wg.Add(1)
-		go c.worker(ctx, i, urlChan, resultChan, limiter, &wg)
-	}
+	c.stats.StartPhase(phase)
+	defer c.stats.EndPhase(phase)
+	defer c.tracePhase(phase)()
 
-	go func() {
-		defer close(urlChan)
-		for _, url := range urls {
-			select {
-			case urlChan <- url:
-			case <-ctx.Done():
-				return // Exit early if cancelled
-			}
-		}
-	}()
+	urlChan := make(chan parser.URL, c.config.MaxWorkers)
+	resultChan := make(chan *stats.Result, c.config.MaxWorkers)
 
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	c.startWorkerPool(ctx, urlChan, resultChan, limiter)
 
-	// Start progress reporter for cache verification phase
-	if !c.config.Quiet {
-		go c.startProgressReporter(ctx)
-	}
+	feed := c.newPassFeed(urls)
+	go feed.send(ctx, urlChan)
 
 	for result := range resultChan {
+		feed.done(result.URL)
+		c.observeResult(stats.PhaseVerify, result)
 		c.stats.AddCacheResult(result)
 	}
+}
 
-	return nil
+// crawlPass crawls every URL once as a named phase, handing each result to
+// collect as well as to the statistics. Device and dual-stack crawls run one
+// pass per variant.
+func (c *Crawler) crawlPass(ctx context.Context, phase string, urls []parser.URL, collect func(*stats.Result)) {
+	limiter := c.newPassLimiter(ctx)
+
+	c.stats.StartPhase(phase)
+	defer c.stats.EndPhase(phase)
+	defer c.tracePhase(phase)()
+
+	urlChan := make(chan parser.URL, c.config.MaxWorkers)
+	resultChan := make(chan *stats.Result, c.config.MaxWorkers)
+
+	c.startWorkerPool(ctx, urlChan, resultChan, limiter)
+
+	feed := c.newPassFeed(urls)
+	go feed.send(ctx, urlChan)
+
+	for result := range resultChan {
+		feed.done(result.URL)
+		c.observeResult(phase, result)
+		collect(result)
+		c.stats.AddResult(result)
+	}
 }
 
-// worker processes URLs from the channel
-func (c *Crawler) worker(ctx context.Context, id int, urlChan <-chan string, resultChan chan<- *stats.Result, limiter *rate.Limiter, wg *sync.WaitGroup) {
-	defer wg.Done()
+// worker processes URLs from its pool's channel until the channel is
+// drained, the crawl is cancelled, or the pool shrinks
+func (c *Crawler) worker(pool *workerPool, id int) {
+	ctx, limiter := pool.ctx, pool.limiter
+	retired, drained := false, false
+	defer func() {
+		if !retired {
+			pool.exit(drained)
+		}
+	}()
 
 	for {
+		// A shrinking pool stops surplus workers between URLs
+		if retired = pool.retire(); retired {
+			c.logger.WithField("worker_id", id).Debug("Worker stopping as the pool shrinks")
+			return
+		}
+
 		select {
-		case url, ok := <-urlChan:
+		case entry, ok := <-pool.urlChan:
 			if !ok {
+				drained = true
 				return // Channel closed
 			}
 
 			// Check if we should continue
 			if c.backoffManager.IsCancelled() {
+				c.hostSlots.release(entry.Loc)
 				c.logger.Warn("Worker stopping due to crawl cancellation")
 				return
 			}
 
-			// Wait for rate limiter
-			if err := limiter.Wait(ctx); err != nil {
+			// Wait for rate limiter. The feed took a slot on the URL's
+			// host, held until the URL is done, retries included.
+			track, waitStart := trace.WorkerTrack(id), time.Now()
+			err := limiter.Wait(ctx)
+			c.trace.Wait(track, "rate limiter", waitStart)
+			if err != nil {
+				c.hostSlots.release(entry.Loc)
 				if ctx.Err() != nil {
 					c.logger.Debug("Worker stopping due to context cancellation")
 					return
@@ -294,41 +972,36 @@ func (c *Crawler) worker(ctx context.Context, id int, urlChan <-chan string, res
 				continue
 			}
 
-			// Crawl URL
-			result := c.crawlURL(url)
-
-			// Check for backoff after getting the result
-			shouldBackoff, backoffDelay, err := c.backoffManager.ShouldBackoff(result.StatusCode, result.Duration)
+			// Wait while backoff has paused all workers
+			waitStart = time.Now()
+			err = c.backoffManager.Wait(ctx)
+			c.trace.Wait(track, "backoff", waitStart)
 			if err != nil {
-				c.logger.WithError(err).Error("Backoff manager error, stopping worker")
+				c.hostSlots.release(entry.Loc)
+				c.logger.WithField("worker_id", id).Debug("Worker stopping due to context cancellation")
 				return
 			}
 
-			// Apply backoff if needed
-			if shouldBackoff && backoffDelay > 0 {
-				c.logger.WithFields(logrus.Fields{
-					"worker_id": id,
-					"delay":     backoffDelay,
-					"url":       url,
-					"status":    result.StatusCode,
-				}).Info("Applying backoff delay")
-
-				select {
-				case <-time.After(backoffDelay):
-					// Backoff completed
-				case <-ctx.Done():
-					// Context cancelled during backoff
-					return
-				}
+			// Crawl URL, retrying failures the retry policy allows
+			c.urlState.Start(entry.Loc)
+			result, err := c.crawlWithRetries(ctx, id, entry, limiter)
+			c.hostSlots.release(entry.Loc)
+			if err != nil {
+				c.logger.WithError(err).Error("Backoff manager error, stopping worker")
+				return
 			}
+			c.urlState.Finish(result)
 
 			// Send result (non-blocking to prevent deadlock if context is cancelled)
 			select {
-			case resultChan <- result:
+			case pool.resultChan <- result:
 			case <-ctx.Done():
 				return
 			}
 
+		case <-pool.resized():
+			// Check again whether this worker is surplus
+
 		case <-ctx.Done():
 			c.logger.WithField("worker_id", id).Debug("Worker stopping due to context cancellation")
 			return
@@ -336,85 +1009,495 @@ func (c *Crawler) worker(ctx context.Context, id int, urlChan <-chan string, res
 	}
 }
 
+// crawlWithRetries crawls a URL and retries failures the retry policy
+// allows. Every attempt counts toward the rate limit and the backoff
+// manager; the last attempt's result is returned with the number of
+// attempts made.
+func (c *Crawler) crawlWithRetries(ctx context.Context, id int, entry parser.URL, limiter *rate.Limiter) (*stats.Result, error) {
+	for attempts := 1; ; attempts++ {
+		start := time.Now()
+		result := c.crawlURL(entry)
+		result.Attempts = attempts
+		result.Sitemap = entry.Sitemap
+		if c.edge != nil {
+			result.Edge = c.edge.name
+		}
+		c.traceRequest(id, entry, result, start)
+
+		if err := c.checkBackoff(id, entry, result); err != nil {
+			return nil, err
+		}
+
+		delay, retry := c.retries.Next(result, attempts)
+		if !retry || c.backoffManager.IsCancelled() {
+			return result, nil
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"url":     entry.Loc,
+			"status":  result.StatusCode,
+			"attempt": attempts,
+			"delay":   delay,
+		}).Debug("Retrying request")
+
+		waitStart := time.Now()
+		waited := c.waitToRetry(ctx, delay, limiter)
+		c.trace.Wait(trace.WorkerTrack(id), "retry delay", waitStart)
+		if !waited {
+			return result, nil
+		}
+	}
+}
+
+// checkBackoff feeds a result to the backoff manager. When it closes its
+// gate, every worker, including this one, waits there before its next
+// request.
+func (c *Crawler) checkBackoff(id int, entry parser.URL, result *stats.Result) error {
+	shouldBackoff, backoffDelay, err := c.backoffManager.ShouldBackoff(result.StatusCode, result.Duration)
+	if err != nil {
+		c.recordCancelReason()
+		return err
+	}
+
+	if shouldBackoff && backoffDelay > 0 {
+		c.logger.WithFields(logrus.Fields{
+			"worker_id": id,
+			"delay":     backoffDelay,
+			"url":       entry.Loc,
+			"status":    result.StatusCode,
+		}).Info("Pausing all workers for backoff delay")
+		c.traceBackoff(id, entry, result, backoffDelay)
+	}
+	return nil
+}
+
+// waitToRetry waits out a retry delay, the rate limiter and any backoff
+// pause. It returns false if the crawl was cancelled meanwhile.
+func (c *Crawler) waitToRetry(ctx context.Context, delay time.Duration, limiter *rate.Limiter) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return false
+	}
+
+	return limiter.Wait(ctx) == nil && c.backoffManager.Wait(ctx) == nil
+}
+
+// elapsed returns the time since start, read from the monotonic clock, and
+// the completion time in UTC. The completion time is start plus the elapsed
+// time, so a wall clock step during a request cannot put it before start.
+func elapsed(start time.Time) (time.Duration, time.Time) {
+	duration := time.Since(start)
+	return duration, start.Add(duration).UTC()
+}
+
 // crawlURL crawls a single URL and returns the result
-func (c *Crawler) crawlURL(url string) *stats.Result {
+func (c *Crawler) crawlURL(entry parser.URL) *stats.Result {
+	url := entry.Loc
 	start := time.Now()
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest(url)
 	if err != nil {
+		duration, end := elapsed(start)
 		return &stats.Result{
 			URL:      url,
 			Success:  false,
 			Error:    err.Error(),
-			Duration: time.Since(start),
+			Duration: duration,
+			Start:    start.UTC(),
+			End:      end,
 		}
 	}
 
-	// Add custom headers
-	for key, value := range c.config.Headers {
-		req.Header.Set(key, value)
-	}
+	c.setRequestHeaders(req)
 
-	// Set user agent
-	if req.Header.Get("User-Agent") == "" {
-		req.Header.Set("User-Agent", c.config.UserAgent)
+	// The body timeout cancels the request once headers have arrived
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	var capture *har.Capture
+	if c.harRecorder != nil {
+		capture, req = har.NewCapture(req, c.config.HARMaxBodyBytes)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
+		c.analyzeFailure(url)
+		c.recordHAR(capture, nil, err)
+		duration, end := elapsed(start)
 		return &stats.Result{
-			URL:      url,
-			Success:  false,
-			Error:    err.Error(),
-			Duration: time.Since(start),
+			URL:           url,
+			Success:       false,
+			Error:         err.Error(),
+			ErrorCategory: stats.ClassifyError(err),
+			Duration:      duration,
+			Start:         start.UTC(),
+			End:           end,
 		}
 	}
+
+	// The request is timed up to its response headers, before the body is
+	// read for analysis
+	duration, end := elapsed(start)
+
+	if capture != nil {
+		capture.WrapBody(resp)
+	}
+	bodyCapture := c.startBodyCapture(resp)
+	ranged := req.Header.Get("Range") != ""
+
+	// An asset found by its Content-Type is not downloaded; later passes
+	// request a byte of it
+	skipDrain := !ranged && c.rangeAssets != nil && c.rangeAssets.matchesResponse(url, resp)
+	var fingerprint *duplicates.Hasher
+	if !ranged && !skipDrain {
+		fingerprint = c.startFingerprint(resp)
+	}
+	body := &countingBody{ReadCloser: resp.Body}
+	resp.Body = body
+
+	var bodyTimedOut atomic.Bool
+	if c.config.BodyTimeout > 0 {
+		timer := time.AfterFunc(c.config.BodyTimeout, func() {
+			bodyTimedOut.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+
+	// result is declared here so that its size can be set, and a body
+	// timeout recorded, once the body has been drained
+	var result *stats.Result
 	defer func() {
-		if _, copyErr := io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrainBytes)); copyErr != nil {
+		drainLimit := int64(maxResponseDrainBytes)
+		if skipDrain {
+			drainLimit = 0
+		}
+		if bodyCapture != nil {
+			drainLimit = max(drainLimit, bodyCapture.Limit())
+		}
+		_, copyErr := io.Copy(io.Discard, io.LimitReader(resp.Body, drainLimit))
+		if copyErr != nil {
 			c.logger.WithError(copyErr).Debug("Failed to drain response body")
 		}
+		if copyErr != nil && bodyTimedOut.Load() {
+			result.Success = false
+			result.Error = fmt.Sprintf("response body not read within %s", c.config.BodyTimeout)
+			result.ErrorCategory = stats.ErrorBodyTimeout
+		}
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			c.logger.WithError(closeErr).Warn("Failed to close response body")
 		}
+		c.recordHAR(capture, resp, nil)
+		c.saveBody(bodyCapture)
+		c.addFingerprint(url, fingerprint)
+
+		result.Size = resp.ContentLength
+		if result.Size < 0 {
+			result.Size = body.read
+		}
 	}()
 
+	c.analyzeResponse(url, resp)
+	c.checkLastMod(entry, resp)
+
 	// Check cache status if in verification mode
 	cacheStatus := ""
-	if c.config.CacheVerificationMode {
+	if c.recordsCacheStatus() {
 		cacheStatus = resp.Header.Get(c.config.CacheHeader)
 	}
 
-	return &stats.Result{
-		URL:         url,
-		Success:     resp.StatusCode >= 200 && resp.StatusCode < 400,
-		StatusCode:  resp.StatusCode,
-		Duration:    time.Since(start),
-		CacheStatus: cacheStatus,
+	result = &stats.Result{
+		URL:          url,
+		Success:      c.succeeded(url, resp.StatusCode),
+		StatusCode:   resp.StatusCode,
+		Duration:     duration,
+		Start:        start.UTC(),
+		End:          end,
+		CacheStatus:  cacheStatus,
+		ServerTiming: stats.ParseServerTiming(resp.Header.Values("Server-Timing")),
+
+		SurrogateKeys: stats.ParseSurrogateKeys(resp.Header.Get("Surrogate-Key")),
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		Age:           stats.ParseAge(resp.Header.Get("Age")),
+		Ranged:        ranged,
+		Headers:       c.comparedHeaders(resp.Header),
+	}
+	if header, ok := cdnTraceHeaders[c.config.CDN]; ok {
+		result.TraceID = resp.Header.Get(header)
+	}
+	if !result.Success {
+		result.ErrorCategory = stats.ErrorHTTPStatus
+		if rule, ok := c.expect.Match(url); ok {
+			result.Error = fmt.Sprintf("HTTP %d, expected %s for %s", resp.StatusCode, rule.Expected(), rule.Pattern)
+		}
+	}
+	return result
+}
+
+// succeeded reports whether a status code counts as success for a URL:
+// within the statuses of the first --expect-status rule matching it, or
+// 200-399 when none does
+func (c *Crawler) succeeded(url string, status int) bool {
+	if rule, ok := c.expect.Match(url); ok {
+		return rule.Allows(status)
+	}
+	return status >= 200 && status < 400
+}
+
+// checkExpectedRedirect stops at the redirect of a URL whose --expect-status
+// rule expects one, so that the redirect itself is checked, and follows
+// redirects of other URLs as usual
+func (c *Crawler) checkExpectedRedirect(req *http.Request, via []*http.Request) error {
+	if rule, ok := c.expect.Match(via[0].URL.String()); ok && rule.Redirects() {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// countingBody counts the bytes read from a response body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+// newRequest builds the request for a URL from the request template, if one
+// was given, or as the request mode says
+func (c *Crawler) newRequest(url string) (*http.Request, error) {
+	if c.requests != nil {
+		return c.requests.NewRequest(url)
+	}
+
+	switch c.config.RequestMode {
+	case config.RequestModeHead:
+		return http.NewRequest(http.MethodHead, url, nil)
+	case config.RequestModeRange:
+		return newRangeRequest(url)
+	case config.RequestModeAssets:
+		if c.rangeAssets.matchesURL(url) {
+			return newRangeRequest(url)
+		}
+		return http.NewRequest(http.MethodGet, url, nil)
+	default:
+		return http.NewRequest(http.MethodGet, url, nil)
 	}
 }
 
-// filterValidURLs filters out invalid URLs
-func (c *Crawler) filterValidURLs(urls []string) []string {
-	var validURLs []string
-	for _, url := range urls {
-		if c.parser.ValidateURL(url) {
-			validURLs = append(validURLs, url)
+// setRequestHeaders adds the device, configured, User-Agent and CDN headers
+// to a request. A device profile's headers replace the configured ones, and
+// a request template's headers take precedence over both.
+func (c *Crawler) setRequestHeaders(req *http.Request) {
+	c.setDeviceHeaders(req)
+	for key, value := range c.config.Headers {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
 		}
 	}
-	return validURLs
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	c.setCDNHeaders(req)
+}
+
+// newRangeRequest builds a GET for the first byte of a URL
+func newRangeRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	return req, nil
+}
+
+// newLimiter creates the rate limiter shared by a pass's workers. The burst
+// defaults to the rate, so a second's worth of requests may go out at once;
+// strict pacing spaces every request evenly instead.
+func (c *Crawler) newLimiter() *rate.Limiter {
+	burst := c.config.RequestBurst
+	if c.config.StrictPacing {
+		burst = 1
+	}
+	if burst == 0 {
+		burst = c.config.RequestRate
+	}
+	return rate.NewLimiter(rate.Limit(c.config.RequestRate), burst)
+}
+
+// newBandwidthLimiter creates the limiter of --max-bandwidth, or returns nil
+// when downloads are not limited
+func newBandwidthLimiter(cfg *config.Config) (*rate.Limiter, error) {
+	bytesPerSecond, err := config.ParseBandwidth(cfg.MaxBandwidth)
+	if err != nil || bytesPerSecond == 0 {
+		return nil, err
+	}
+	return transport.NewBandwidthLimiter(bytesPerSecond), nil
+}
+
+// throttle limits the downloads of a transport to the bandwidth limiter,
+// if there is one
+func throttle(next http.RoundTripper, bandwidth *rate.Limiter) http.RoundTripper {
+	if bandwidth == nil {
+		return next
+	}
+	return transport.Throttle(next, bandwidth)
+}
+
+// signerCheckTimeout bounds the search for AWS credentials before a signed
+// crawl starts
+const signerCheckTimeout = 10 * time.Second
+
+// newSigner creates the request signer of --sigv4-service, or returns nil
+// when requests are not signed or are replayed from a cassette. It fails
+// when no region is set or no credentials are found.
+func newSigner(cfg *config.Config) (*sigv4.Signer, error) {
+	if cfg.SigV4Service == "" || cfg.Replay != "" {
+		return nil, nil
+	}
+
+	region := cfg.SigV4Region
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region == "" {
+			region = os.Getenv(name)
+		}
+	}
+	if region == "" {
+		return nil, fmt.Errorf("signing requests requires --sigv4-region or AWS_REGION")
+	}
+
+	signer := sigv4.NewSigner(cfg.SigV4Service, region, sigv4.NewChain(cfg.AWSProfile))
+	ctx, cancel := context.WithTimeout(context.Background(), signerCheckTimeout)
+	defer cancel()
+	if err := signer.Check(ctx); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// sign signs the requests of a transport with the signer, if there is one
+func sign(next http.RoundTripper, signer *sigv4.Signer) http.RoundTripper {
+	if signer == nil {
+		return next
+	}
+	return sigv4.Transport(next, signer)
 }
 
-// startProgressReporter starts a ticker-based progress reporter
+// rampSteps is how many steps a rate ramp rises in
+const rampSteps = 10
+
+// newPassLimiter creates the rate limiter of a crawl pass. With a rate ramp
+// it starts at a tenth of the request rate and burst and rises in even
+// steps to both in full over the ramp, or until ctx is done. With a
+// deadline the rate follows what finishing on time needs, never above the
+// ramp.
+func (c *Crawler) newPassLimiter(ctx context.Context) *rate.Limiter {
+	limiter := c.newLimiter()
+	if c.config.RateRamp <= 0 && c.deadline.IsZero() {
+		return limiter
+	}
+
+	pacing := newPassPacing(limiter)
+	if c.config.RateRamp > 0 {
+		pacing.setRamp(1.0 / rampSteps)
+		go func() {
+			ticker := time.NewTicker(c.config.RateRamp / (rampSteps - 1))
+			defer ticker.Stop()
+			for step := 2; step <= rampSteps; step++ {
+				select {
+				case <-ticker.C:
+					pacing.setRamp(float64(step) / rampSteps)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	if !c.deadline.IsZero() {
+		c.paceToDeadline(ctx, pacing)
+	}
+	return limiter
+}
+
+// throttle returns the running pass's request rate, as the ramp and
+// deadline have set it, and the backoff pauses, for time-left estimates
+func (c *Crawler) throttle() stats.Throttle {
+	var throttle stats.Throttle
+	throttle.PausedUntil, throttle.Paused = c.backoffManager.Pause()
+
+	c.poolMu.Lock()
+	pool := c.pool
+	c.poolMu.Unlock()
+	if pool != nil {
+		if limit := pool.limiter.Limit(); limit != rate.Inf {
+			throttle.Rate = float64(limit)
+		}
+	}
+	return throttle
+}
+
+// filterValidURLs filters out URLs that cannot be crawled. Rejected URLs
+// are counted in the final statistics, logged, and written to the rejected
+// report if one was requested.
+func (c *Crawler) filterValidURLs(urls []parser.URL) ([]parser.URL, error) {
+	validURLs, rejected := c.parser.FilterURLs(urls)
+
+	counts := make(map[string]int)
+	for _, rejection := range rejected {
+		counts[rejection.Reason]++
+		c.logger.WithFields(logrus.Fields{
+			"url":     rejection.URL,
+			"sitemap": rejection.Sitemap,
+			"reason":  rejection.Reason,
+			"detail":  rejection.Detail,
+		}).Debug("Sitemap URL rejected")
+	}
+	c.stats.SetRejectedURLs(counts)
+
+	if len(rejected) > 0 {
+		fields := logrus.Fields{"rejected_urls": len(rejected)}
+		for reason, count := range counts {
+			fields["rejected_"+reason] = count
+		}
+		c.logger.WithFields(fields).Warn("Sitemap URLs rejected")
+	}
+
+	return validURLs, c.writeRejectedReport(rejected)
+}
+
+// startProgressReporter starts a ticker-based progress reporter that logs
+// progress unless quiet and sends it to subscribers. The interval and quiet
+// mode are re-read whenever they change during the run.
 func (c *Crawler) startProgressReporter(ctx context.Context) {
-	ticker := time.NewTicker(c.config.ProgressInterval)
+	settings, changed := c.progress.watch()
+	ticker := time.NewTicker(settings.Interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-changed:
+			settings, changed = c.progress.watch()
+			ticker.Reset(settings.Interval)
 		case <-ticker.C:
-			c.printProgress()
+			if !settings.Quiet {
+				c.printProgress()
+			}
+			c.publishProgress()
 		}
 	}
 }
@@ -428,6 +1511,11 @@ func (c *Crawler) printProgress() {
 		return
 	}
 
+	if c.structuredOutput() {
+		c.printFormatted(c.newFormatter(c.config.OutputFormat).FormatProgress(&progress), "progress")
+		return
+	}
+
 	// Format durations for better readability
 	elapsedFormatted := c.formatDuration(progress.ElapsedTime)
 	etaFormatted := c.formatDuration(progress.EstimatedTimeLeft)
@@ -436,12 +1524,23 @@ func (c *Crawler) printProgress() {
 	// Get backoff stats
 	backoffStats := c.backoffManager.GetStats()
 
+	// A multi-pass crawl counts and times the running pass on its own, so
+	// that the warm-up is not measured against both passes' URLs
+	prefix := ""
+	processed, total, percentage := progress.Processed, progress.Total, progress.Percentage
+	if phase := progress.Phase; phase != nil {
+		prefix = fmt.Sprintf("Phase %d/%d (%s) | ", phase.Number, phase.Count, phase.Name)
+		processed, total, percentage = phase.Processed, phase.Total, phase.Percentage
+		etaFormatted = c.formatDuration(phase.EstimatedTimeLeft)
+	}
+
 	// Create a human-readable progress message
-	baseMessage := fmt.Sprintf("Progress: %d/%d (%.1f%%) | Success Rate: %.1f%% | Speed: %.1f req/s | Elapsed: %s | ETA: %s | Avg Response: %s",
-		progress.Processed,
-		progress.Total,
-		progress.Percentage,
-		progress.SuccessRate,
+	baseMessage := fmt.Sprintf("%sProgress: %d/%d (%.1f%%) | Success Rate: %s | Speed: %.1f req/s | Elapsed: %s | ETA: %s | Avg Response: %s",
+		prefix,
+		processed,
+		total,
+		percentage,
+		c.palette.SuccessRate(progress.SuccessRate),
 		progress.RequestsPerSecond,
 		elapsedFormatted,
 		etaFormatted,
@@ -463,6 +1562,71 @@ func (c *Crawler) printProgress() {
 	c.logger.Info(baseMessage)
 }
 
+// observeResult logs a result from a crawl phase and records it for the
+// enabled outputs
+func (c *Crawler) observeResult(phase string, result *stats.Result) {
+	c.logResult(result)
+	c.publishResult(phase, result)
+	if c.annotations != nil {
+		c.annotations.Add(result)
+	}
+	if c.failures != nil {
+		c.failures.Add(phase, result)
+	}
+	if c.okURLs != nil {
+		c.okURLs.add(result)
+	}
+	if c.results != nil {
+		c.results.add(phase, result)
+	}
+	if c.exportResults != nil {
+		c.exportResults.add(phase, result)
+	}
+	if c.iterationCache != nil {
+		c.addToAggregate(phase, result)
+		c.iterationCache.add(phase, result)
+	}
+}
+
+// logResult logs a single result according to the verbosity level: -v logs
+// failures and -vv logs every URL
+func (c *Crawler) logResult(result *stats.Result) {
+	if c.config.Verbose == 0 || (result.Success && c.config.Verbose < 2) {
+		return
+	}
+
+	fields := logrus.Fields{
+		"url":      result.URL,
+		"status":   result.StatusCode,
+		"duration": result.Duration,
+	}
+	if !result.Start.IsZero() {
+		fields["start"] = result.Start.Format(time.RFC3339Nano)
+	}
+	if result.CacheStatus != "" {
+		fields["cache_status"] = result.CacheStatus
+	}
+	if result.TraceID != "" {
+		fields["trace_id"] = result.TraceID
+	}
+	if result.Ranged {
+		fields["range_honored"] = result.StatusCode == http.StatusPartialContent
+	}
+
+	if result.Success {
+		c.logger.WithFields(fields).Info("Request succeeded")
+		return
+	}
+
+	if result.Error != "" {
+		fields["error"] = result.Error
+	}
+	if result.ErrorCategory != "" {
+		fields["category"] = result.ErrorCategory
+	}
+	c.logger.WithFields(fields).Warn("Request failed")
+}
+
 // formatDuration formats a duration for human-readable display
 func (c *Crawler) formatDuration(d time.Duration) string {
 	if d == 0 {
@@ -484,16 +1648,27 @@ func (c *Crawler) formatDuration(d time.Duration) string {
 	}
 }
 
-// printFinalStats prints final statistics
+// printFinalStats prints final statistics, in the output format when a
+// structured one is selected
 func (c *Crawler) printFinalStats() {
-	stats := c.stats.GetFinalStats()
+	if c.structuredOutput() {
+		final := c.stats.GetFinalStats()
+		c.printFormatted(c.newFormatter(c.config.OutputFormat).FormatFinalStats(&final), "final statistics")
+		return
+	}
+	c.logger.WithFields(c.finalStatsFields(c.stats)).Info("Crawling completed")
+}
+
+// finalStatsFields returns the final statistics of collected as log fields
+func (c *Crawler) finalStatsFields(collected *stats.Stats) logrus.Fields {
+	stats := collected.GetFinalStats()
 	backoffStats := c.backoffManager.GetStats()
 
 	fields := logrus.Fields{
 		"total_processed": stats.TotalProcessed,
 		"total_success":   stats.TotalSuccess,
 		"total_errors":    stats.TotalErrors,
-		"success_rate":    fmt.Sprintf("%.1f%%", stats.SuccessRate),
+		"success_rate":    c.palette.SuccessRate(stats.SuccessRate),
 		"avg_duration":    stats.AverageDuration,
 		"min_duration":    stats.MinDuration,
 		"max_duration":    stats.MaxDuration,
@@ -514,20 +1689,187 @@ func (c *Crawler) printFinalStats() {
 
 	if cancelled, ok := backoffStats["cancelled"].(bool); ok && cancelled {
 		fields["crawl_cancelled"] = true
+		if stats.CancelReason != "" {
+			fields["cancel_reason"] = stats.CancelReason
+		}
 	}
 
-	c.logger.WithFields(fields).Info("Crawling completed")
+	if stats.RejectedURLs > 0 {
+		fields["rejected_urls"] = stats.RejectedURLs
+	}
+
+	if stats.SkippedURLs > 0 {
+		fields["skipped_urls"] = stats.SkippedURLs
+	}
+
+	if stats.RangeRequests > 0 {
+		fields["range_requests"] = stats.RangeRequests
+		fields["range_honored"] = stats.RangeHonored
+	}
+
+	if stats.TotalRetries > 0 {
+		fields["total_retries"] = stats.TotalRetries
+		fields["success_after_retry"] = stats.SuccessAfterRetry
+		fields["first_attempt_success_rate"] = c.palette.SuccessRate(stats.FirstAttemptSuccessRate)
+	}
+
+	for category, count := range stats.ErrorsByCategory {
+		fields["errors_"+string(category)] = count
+	}
+
+	return fields
 }
 
-// printCacheStats prints cache verification statistics
+// printCacheStats prints cache verification statistics, in the output
+// format when a structured one is selected
 func (c *Crawler) printCacheStats() {
 	cacheStats := c.stats.GetCacheStats()
+	if c.structuredOutput() {
+		c.printFormatted(c.newFormatter(c.config.OutputFormat).FormatCacheStats(&cacheStats), "cache statistics")
+		return
+	}
 
-	c.logger.WithFields(logrus.Fields{
+	fields := logrus.Fields{
 		"cache_hits":     cacheStats.CacheHits,
 		"cache_misses":   cacheStats.CacheMisses,
-		"cache_hit_rate": fmt.Sprintf("%.1f%%", cacheStats.CacheHitRate),
+		"cache_hit_rate": c.palette.HitRate(cacheStats.CacheHitRate),
 		"warm_up_time":   cacheStats.WarmUpTime,
 		"verify_time":    cacheStats.VerifyTime,
-	}).Info("Cache verification completed")
+	}
+	if cacheStats.ValidatorChanges > 0 {
+		fields["validator_changes"] = cacheStats.ValidatorChanges
+	}
+	if cacheStats.HeaderChanges > 0 {
+		fields["header_changes"] = cacheStats.HeaderChanges
+	}
+	c.logger.WithFields(fields).Info("Cache verification completed")
+}
+
+// structuredOutput reports whether a machine-readable output format is
+// selected, in which the statistics are printed to stdout instead of logged
+func (c *Crawler) structuredOutput() bool {
+	switch c.config.OutputFormat {
+	case "json", "csv", "xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// printFormatted prints formatted statistics to stdout
+func (c *Crawler) printFormatted(content, name string) {
+	if _, err := fmt.Fprintln(os.Stdout, content); err != nil {
+		c.logger.WithError(err).Warnf("Failed to print %s", name)
+	}
+}
+
+// printSurrogateKeyStats prints the cache hit rate per surrogate key, worst
+// first, if responses carried a Surrogate-Key header
+func (c *Crawler) printSurrogateKeyStats() {
+	keys := c.stats.GetSurrogateKeyStats()
+	for i, key := range keys {
+		if i == maxSurrogateKeyStats {
+			c.logger.WithField("keys", len(keys)-i).Info("More surrogate keys omitted")
+			return
+		}
+		c.logger.WithFields(logrus.Fields{
+			"key":            key.Key,
+			"cache_hits":     key.CacheHits,
+			"cache_misses":   key.CacheMisses,
+			"cache_hit_rate": c.palette.HitRate(key.CacheHitRate),
+		}).Info("Surrogate key cache hit rate")
+	}
+}
+
+// printValidatorChanges warns about URLs whose ETag or Last-Modified
+// changed between warm-up and verification, which means the page is
+// regenerated on every request
+func (c *Crawler) printValidatorChanges() {
+	changes := c.stats.GetValidatorChanges()
+	for i, change := range changes {
+		if i == maxValidatorChanges {
+			c.logger.WithField("urls", len(changes)-i).Warn("More changed validators omitted")
+			return
+		}
+
+		fields := logrus.Fields{"url": change.URL}
+		if change.ETagChanged() {
+			fields["warm_up_etag"] = change.WarmUpETag
+			fields["verify_etag"] = change.VerifyETag
+		}
+		if change.LastModifiedChanged() {
+			fields["warm_up_last_modified"] = change.WarmUpLastModified
+			fields["verify_last_modified"] = change.VerifyLastModified
+		}
+		c.logger.WithFields(fields).Warn("Validators changed between passes")
+	}
+}
+
+// printCacheEfficacy logs the URLs that warming helped least, those scoring
+// below what a cache hit alone earns
+func (c *Crawler) printCacheEfficacy() {
+	for i, score := range c.stats.GetCacheEfficacy() {
+		if score.Score >= lowEfficacyScore {
+			return
+		}
+		if i == maxEfficacyURLs {
+			c.logger.Info("More URLs with low cache efficacy omitted")
+			return
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"url":          score.URL,
+			"score":        fmt.Sprintf("%.1f", score.Score),
+			"cache_status": score.CacheStatus,
+			"speedup":      fmt.Sprintf("%+.0f%%", score.Speedup*100),
+		}).Info("Warming had little effect")
+	}
+}
+
+// printHostStats prints latency percentiles per host for multi-host crawls
+func (c *Crawler) printHostStats() {
+	hosts := c.stats.GetHostStats()
+	if len(hosts) < 2 {
+		return
+	}
+
+	for _, host := range hosts {
+		c.logger.WithFields(logrus.Fields{
+			"host":     host.Host,
+			"requests": host.Requests,
+			"errors":   host.Errors,
+			"p50":      host.P50,
+			"p90":      host.P90,
+			"p95":      host.P95,
+			"p99":      host.P99,
+			"max":      host.Max,
+		}).Info("Host latency")
+	}
+}
+
+// printRangeStats warns when the origin answered range requests in full.
+// Such an origin sends whole objects, so range warming saves nothing there.
+func (c *Crawler) printRangeStats() {
+	finalStats := c.stats.GetFinalStats()
+	if finalStats.RangeHonored == finalStats.RangeRequests {
+		return
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"range_requests": finalStats.RangeRequests,
+		"range_ignored":  finalStats.RangeRequests - finalStats.RangeHonored,
+	}).Warn("Origin answered range requests with full responses")
+}
+
+// printServerTimingStats prints Server-Timing metrics aggregated per URL class
+func (c *Crawler) printServerTimingStats() {
+	for _, timing := range c.stats.GetServerTimingStats() {
+		c.logger.WithFields(logrus.Fields{
+			"class":   timing.Class,
+			"metric":  timing.Metric,
+			"count":   timing.Count,
+			"average": timing.Average,
+			"max":     timing.Max,
+		}).Info("Server timing")
+	}
 }
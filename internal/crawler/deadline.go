@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// deadlineInterval is how often the request rate is recomputed to finish
+// by the deadline
+const deadlineInterval = time.Second
+
+// passPacing combines the limits on a pass's request rate: the configured
+// rate and burst, scaled by the rate ramp, and the rate the deadline needs
+type passPacing struct {
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	limit   rate.Limit
+	burst   int
+
+	// ramp is the fraction of the configured rate and burst the ramp allows
+	ramp float64
+
+	// needed is the rate that finishes on time; zero leaves the rate alone
+	needed rate.Limit
+}
+
+// newPassPacing starts pacing limiter from its current rate and burst
+func newPassPacing(limiter *rate.Limiter) *passPacing {
+	return &passPacing{limiter: limiter, limit: limiter.Limit(), burst: limiter.Burst(), ramp: 1}
+}
+
+// setRamp sets the fraction of the configured rate the ramp allows
+func (p *passPacing) setRamp(fraction float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ramp = fraction
+	p.applyLocked()
+}
+
+// setNeeded sets the rate needed to finish on time
+func (p *passPacing) setNeeded(needed rate.Limit) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.needed = needed
+	p.applyLocked()
+}
+
+// applyLocked sets the limiter to the lowest of the limits. The burst
+// follows the rate so that a slowed crawl is not sent out in bursts.
+func (p *passPacing) applyLocked() {
+	limit := p.limit * rate.Limit(p.ramp)
+	burst := max(1, int(float64(p.burst)*p.ramp))
+	if p.needed > 0 && p.needed < limit {
+		limit = p.needed
+		burst = max(1, min(burst, int(limit)))
+	}
+	p.limiter.SetLimit(limit)
+	p.limiter.SetBurst(burst)
+}
+
+// paceToDeadline sets the rate the pass needs to finish by the deadline now
+// and then every deadlineInterval until ctx is done
+func (c *Crawler) paceToDeadline(ctx context.Context, pacing *passPacing) {
+	pacing.setNeeded(c.deadlineRate(pacing.limit))
+
+	go func() {
+		ticker := time.NewTicker(deadlineInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pacing.setNeeded(c.deadlineRate(pacing.limit))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// deadlineRate returns the request rate that finishes the remaining requests
+// by the deadline, or zero to run at the full rate once the deadline has
+// passed. When even maxRate is too slow it warns, once per run, with the
+// projected overrun.
+func (c *Crawler) deadlineRate(maxRate rate.Limit) rate.Limit {
+	progress := c.Stats().GetProgress()
+	remaining := progress.Total - progress.Processed
+	if remaining <= 0 {
+		return 0
+	}
+
+	left := time.Until(c.deadline)
+	if left <= 0 {
+		c.warnDeadline(remaining, maxRate, left)
+		return 0
+	}
+
+	needed := rate.Limit(float64(remaining) / left.Seconds())
+	if needed > maxRate {
+		c.warnDeadline(remaining, maxRate, left)
+	}
+	return needed
+}
+
+// warnDeadline warns that the crawl will overrun the deadline, the first
+// time it is called in a run
+func (c *Crawler) warnDeadline(remaining int, maxRate rate.Limit, left time.Duration) {
+	if c.deadlineWarned.Swap(true) {
+		return
+	}
+
+	projected := time.Duration(float64(remaining) / float64(maxRate) * float64(time.Second))
+	c.logger.WithFields(logrus.Fields{
+		"finish_by":         c.deadline.Format(time.RFC3339),
+		"remaining":         remaining,
+		"projected_overrun": (projected - left).Round(time.Second),
+	}).Warn("Crawl will not finish by the deadline even at the full request rate")
+}
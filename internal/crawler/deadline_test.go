@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// newDeadlineCrawler creates a crawler with total requests to make before
+// the deadline
+func newDeadlineCrawler(total int, left time.Duration) (*Crawler, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	c := &Crawler{
+		config:   &config.Config{RequestRate: 100},
+		logger:   logger,
+		stats:    stats.New(),
+		deadline: time.Now().Add(left),
+	}
+	c.stats.SetTotalURLs(total)
+	return c, hook
+}
+
+func TestDeadlinePacing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		total     int
+		left      time.Duration
+		expected  rate.Limit
+		wantBurst int
+		wantWarn  bool
+	}{
+		{name: "spreads requests until the deadline", total: 50, left: 10 * time.Second, expected: 5, wantBurst: 5},
+		{name: "capped at the request rate", total: 10000, left: 10 * time.Second, expected: 100, wantBurst: 100, wantWarn: true},
+		{name: "full rate after the deadline", total: 50, left: -time.Second, expected: 100, wantBurst: 100, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, hook := newDeadlineCrawler(tt.total, tt.left)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			limiter := c.newPassLimiter(ctx)
+			assert.InDelta(t, float64(tt.expected), float64(limiter.Limit()), 0.1)
+			assert.Equal(t, tt.wantBurst, limiter.Burst())
+
+			if !tt.wantWarn {
+				assert.Empty(t, hook.AllEntries())
+				return
+			}
+			require.Len(t, hook.AllEntries(), 1)
+			entry := hook.LastEntry()
+			assert.Equal(t, logrus.WarnLevel, entry.Level)
+			assert.Contains(t, entry.Message, "will not finish by the deadline")
+		})
+	}
+}
+
+func TestDeadlineWarnsOnce(t *testing.T) {
+	t.Parallel()
+
+	c, hook := newDeadlineCrawler(10000, 10*time.Second)
+	c.deadlineRate(100)
+	c.deadlineRate(100)
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, 90*time.Second, hook.LastEntry().Data["projected_overrun"])
+}
+
+func TestDeadlineWithRateRamp(t *testing.T) {
+	t.Parallel()
+
+	// The ramp still holds back the start of a pass with plenty of time
+	c, _ := newDeadlineCrawler(50, 10*time.Second)
+	c.config.RateRamp = time.Hour
+
+	limiter := c.newPassLimiter(context.Background())
+	assert.InDelta(t, 5, float64(limiter.Limit()), 0.1)
+
+	c, _ = newDeadlineCrawler(10000, 10*time.Second)
+	c.config.RateRamp = time.Hour
+	limiter = c.newPassLimiter(context.Background())
+	assert.Equal(t, rate.Limit(10), limiter.Limit())
+}
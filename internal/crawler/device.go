@@ -0,0 +1,157 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/benvon/sitemap-crawler/internal/device"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// maxDeviceDifferences bounds the per-URL lines logged for device
+// differences; the report file lists all of them
+const maxDeviceDifferences = 20
+
+// newDeviceProfiles resolves the configured device profiles, loading custom
+// profiles from the profiles file if one was given
+func newDeviceProfiles(names []string, path string) ([]device.Profile, error) {
+	var custom map[string]device.Profile
+	if path != "" {
+		var err error
+		custom, err = device.Load(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return device.Resolve(names, custom)
+}
+
+// setDeviceHeaders sets the User-Agent and client hints of the device being
+// crawled as, unless the request template set them
+func (c *Crawler) setDeviceHeaders(req *http.Request) {
+	if c.device == nil {
+		return
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.device.UserAgent)
+	}
+	for key, value := range c.device.Headers {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
+}
+
+// crawlDevices crawls the URL set once per device profile. Each pass is
+// recorded as a phase named after its profile.
+func (c *Crawler) crawlDevices(urls []parser.URL) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.backoffManager.SetCancelFunc(cancel)
+
+	profiles := make([]string, len(c.devices))
+	for i, device := range c.devices {
+		profiles[i] = device.Name
+	}
+	c.stats.PlanPhases(len(urls), profiles...)
+	go c.startProgressReporter(ctx)
+
+	for i := range c.devices {
+		c.device = &c.devices[i]
+		c.logger.WithFields(logrus.Fields{
+			"device":     c.device.Name,
+			"user_agent": c.device.UserAgent,
+		}).Info("Crawling as device")
+
+		profile := c.device.Name
+		c.crawlPass(ctx, profile, urls, func(result *stats.Result) {
+			c.deviceMatrix.Add(profile, result)
+		})
+
+		if c.backoffManager.IsCancelled() {
+			c.logger.WithField("device", c.device.Name).Warn("Crawl cancelled, skipping remaining devices")
+			break
+		}
+	}
+	c.device = nil
+
+	c.printFinalStats()
+	c.printDeviceStats()
+	c.printHostStats()
+	c.printServerTimingStats()
+	c.printRangeStats()
+	return nil
+}
+
+// printDeviceStats logs each device's summary and the URLs whose responses
+// differ between devices
+func (c *Crawler) printDeviceStats() {
+	report := c.deviceMatrix.Report()
+
+	for _, summary := range report.Profiles {
+		fields := logrus.Fields{
+			"device":       summary.Profile,
+			"requests":     summary.Requests,
+			"errors":       summary.Errors,
+			"average_size": summary.AverageSize,
+		}
+		if summary.CacheHits+summary.CacheMisses > 0 {
+			fields["cache_hit_rate"] = c.palette.HitRate(summary.CacheHitRate)
+		}
+		c.logger.WithFields(fields).Info("Device summary")
+	}
+
+	for i, difference := range report.Differences {
+		if i == maxDeviceDifferences {
+			c.logger.WithField("urls", len(report.Differences)-i).Warn("More device differences omitted")
+			break
+		}
+
+		fields := logrus.Fields{"url": difference.URL, "differs_in": difference.Reasons}
+		for _, variant := range difference.Variants {
+			fields[variant.Profile] = formatVariant(variant)
+		}
+		c.logger.WithFields(fields).Warn("Responses differ between devices")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"compared":    report.Compared,
+		"differences": len(report.Differences),
+	}).Info("Device comparison completed")
+}
+
+// formatVariant summarizes a device's response for a log field
+func formatVariant(variant device.Variant) string {
+	if variant.Error != "" {
+		return "error"
+	}
+	summary := fmt.Sprintf("%d %dB", variant.StatusCode, variant.Size)
+	if variant.CacheStatus != "" {
+		summary += " " + variant.CacheStatus
+	}
+	return summary
+}
+
+// writeDeviceReport writes the device comparison if a report was requested
+func (c *Crawler) writeDeviceReport() error {
+	if c.deviceMatrix == nil || c.config.DeviceReport == "" {
+		return nil
+	}
+
+	report := c.deviceMatrix.Report()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.DeviceReport, formatter.FormatDeviceReport(report)); err != nil {
+		return fmt.Errorf("failed to write device report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":        c.config.DeviceReport,
+		"compared":    report.Compared,
+		"differences": len(report.Differences),
+	}).Info("Device report written")
+	return nil
+}
@@ -0,0 +1,157 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/dualstack"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/sigv4"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/transport"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// maxDualStackProblems bounds the per-URL lines logged for address family
+// problems; the report file lists all of them
+const maxDualStackProblems = 20
+
+// familyClient is an HTTP client limited to one address family
+type familyClient struct {
+	name   string
+	client *http.Client
+}
+
+// newFamilyClients creates an IPv4 and an IPv6 client with the configured
+// egress options and timeouts, sharing the bandwidth limiter and request
+// signer if there are any
+func newFamilyClients(cfg *config.Config, bandwidth *rate.Limiter, signer *sigv4.Signer, guard *transport.ProxyGuard) ([]familyClient, error) {
+	families := []string{dualstack.IPv4, dualstack.IPv6}
+	clients := make([]familyClient, len(families))
+	for i, family := range families {
+		transportCfg, err := transportConfig(cfg, family, guard)
+		if err != nil {
+			return nil, err
+		}
+		familyTransport, err := transport.New(transportCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s transport: %w", family, err)
+		}
+		clients[i] = familyClient{
+			name:   family,
+			client: &http.Client{Timeout: cfg.RequestTimeout, Transport: throttle(sign(familyTransport, signer), bandwidth)},
+		}
+	}
+	return clients, nil
+}
+
+// httpClient returns the client for crawl requests: the current address
+// family's during a dual-stack crawl, the current edge's while verifying
+// against edges, otherwise the shared one
+func (c *Crawler) httpClient() *http.Client {
+	if c.edge != nil {
+		return c.edge.client
+	}
+	if c.family != nil {
+		return c.family.client
+	}
+	return c.client
+}
+
+// crawlFamilies crawls the URL set over IPv4 and then over IPv6. Each pass
+// is recorded as a phase named after its family.
+func (c *Crawler) crawlFamilies(urls []parser.URL) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.backoffManager.SetCancelFunc(cancel)
+
+	families := make([]string, len(c.families))
+	for i, family := range c.families {
+		families[i] = family.name
+	}
+	c.stats.PlanPhases(len(urls), families...)
+	go c.startProgressReporter(ctx)
+
+	for i := range c.families {
+		c.family = &c.families[i]
+		c.logger.WithField("family", c.family.name).Info("Crawling over address family")
+
+		family := c.family.name
+		c.crawlPass(ctx, family, urls, func(result *stats.Result) {
+			c.dualStack.Add(family, result)
+		})
+
+		if c.backoffManager.IsCancelled() {
+			c.logger.WithField("family", family).Warn("Crawl cancelled, skipping remaining address families")
+			break
+		}
+	}
+	c.family = nil
+
+	c.printFinalStats()
+	c.printDualStackStats()
+	c.printHostStats()
+	c.printServerTimingStats()
+	c.printRangeStats()
+	return nil
+}
+
+// printDualStackStats logs the IPv4/IPv6 comparison per host and the URLs
+// that failed or were much slower over one family
+func (c *Crawler) printDualStackStats() {
+	report := c.dualStack.Report()
+
+	for _, host := range report.Hosts {
+		c.logger.WithFields(logrus.Fields{
+			"host":         host.Host,
+			"ipv4_errors":  host.IPv4.Errors,
+			"ipv6_errors":  host.IPv6.Errors,
+			"ipv4_avg":     host.IPv4.AverageDuration,
+			"ipv6_avg":     host.IPv6.AverageDuration,
+			"problem_urls": host.Problems,
+		}).Info("Host reachability")
+	}
+
+	for i, problem := range report.Problems {
+		if i == maxDualStackProblems {
+			c.logger.WithField("urls", len(report.Problems)-i).Warn("More address family problems omitted")
+			break
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"url":    problem.URL,
+			"reason": problem.Reason,
+			"ipv4":   problem.IPv4.String(),
+			"ipv6":   problem.IPv6.String(),
+		}).Warn("Address families differ")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"compared": report.Compared,
+		"problems": len(report.Problems),
+	}).Info("Dual-stack comparison completed")
+}
+
+// writeDualStackReport writes the IPv4/IPv6 comparison if a report was
+// requested
+func (c *Crawler) writeDualStackReport() error {
+	if c.dualStack == nil || c.config.DualStackReport == "" {
+		return nil
+	}
+
+	report := c.dualStack.Report()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.DualStackReport, formatter.FormatDualStackReport(report)); err != nil {
+		return fmt.Errorf("failed to write dual-stack report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":     c.config.DualStackReport,
+		"compared": report.Compared,
+		"problems": len(report.Problems),
+	}).Info("Dual-stack report written")
+	return nil
+}
@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/sigv4"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/transport"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// edgeClient is an HTTP client whose connections all go to one CDN edge
+type edgeClient struct {
+	name   string
+	client *http.Client
+}
+
+// newEdgeClients creates a client per configured edge with the configured
+// egress options and timeouts, sharing the bandwidth limiter and request
+// signer if there are any. Dial rules for a specific host still apply to it.
+func newEdgeClients(cfg *config.Config, bandwidth *rate.Limiter, signer *sigv4.Signer, guard *transport.ProxyGuard) ([]edgeClient, error) {
+	edges, err := transport.ParseEdges(cfg.Edges)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]edgeClient, len(edges))
+	for i, edge := range edges {
+		transportCfg, err := transportConfig(cfg, "", guard)
+		if err != nil {
+			return nil, err
+		}
+		transportCfg.DialRules = append([]transport.DialRule{edge.DialRule()}, transportCfg.DialRules...)
+		edgeTransport, err := transport.New(transportCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transport for edge %s: %w", edge.Name, err)
+		}
+		clients[i] = edgeClient{
+			name:   edge.Name,
+			client: &http.Client{Timeout: cfg.RequestTimeout, Transport: throttle(sign(edgeTransport, signer), bandwidth)},
+		}
+	}
+	return clients, nil
+}
+
+// verifyPhases returns the names of the verification passes: one per edge,
+// or the single verification pass
+func (c *Crawler) verifyPhases() []string {
+	if len(c.edges) == 0 {
+		return []string{stats.PhaseVerify}
+	}
+	phases := make([]string, len(c.edges))
+	for i, edge := range c.edges {
+		phases[i] = stats.EdgePhase(edge.name)
+	}
+	return phases
+}
+
+// verifyEdges runs the verification pass against each edge in turn
+func (c *Crawler) verifyEdges(ctx context.Context, urls []parser.URL) {
+	defer func() { c.edge = nil }()
+
+	for i := range c.edges {
+		c.edge = &c.edges[i]
+		c.logger.WithField("edge", c.edge.name).Info("Verifying cache at edge")
+		c.verifyPass(ctx, stats.EdgePhase(c.edge.name), urls)
+
+		if c.backoffManager.IsCancelled() {
+			c.logger.WithField("edge", c.edge.name).Warn("Crawl cancelled, skipping remaining edges")
+			return
+		}
+	}
+}
+
+// printEdgeStats logs the cache hit rate of each edge, and warns about the
+// edges where nothing hit
+func (c *Crawler) printEdgeStats() {
+	for _, edge := range c.stats.GetEdgeStats() {
+		fields := logrus.Fields{
+			"edge":           edge.Edge,
+			"requests":       edge.Requests,
+			"errors":         edge.Errors,
+			"cache_hits":     edge.CacheHits,
+			"cache_misses":   edge.CacheMisses,
+			"cache_hit_rate": c.palette.HitRate(edge.CacheHitRate),
+			"avg_duration":   edge.AverageDuration,
+		}
+		if edge.Cold() {
+			c.logger.WithFields(fields).Warn("Edge cache was not warmed")
+			continue
+		}
+		c.logger.WithFields(fields).Info("Edge cache hit rate")
+	}
+}
+
+// writeEdgeReport writes the cache hit rate of each edge if a report was
+// requested
+func (c *Crawler) writeEdgeReport() error {
+	if c.config.EdgeReport == "" {
+		return nil
+	}
+
+	edges := c.Stats().GetEdgeStats()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.EdgeReport, formatter.FormatEdgeStats(edges)); err != nil {
+		return fmt.Errorf("failed to write edge report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":  c.config.EdgeReport,
+		"edges": len(edges),
+	}).Info("Edge report written")
+	return nil
+}
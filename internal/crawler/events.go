@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// EventType identifies what an Event carries
+type EventType string
+
+// Event types
+const (
+	// EventProgress carries a progress snapshot, sent every progress interval
+	EventProgress EventType = "progress"
+
+	// EventResult carries the result of one request
+	EventResult EventType = "result"
+)
+
+// Event is sent to subscribers while the crawler runs
+type Event struct {
+	Type EventType
+
+	// Phase is the crawl phase of a result, such as crawl, warmup or verify
+	Phase string
+
+	// Result is set for EventResult and Progress for EventProgress
+	Result   *stats.Result
+	Progress *stats.Progress
+}
+
+// subscribers fans events out to the channels returned by Subscribe
+type subscribers struct {
+	mu       sync.RWMutex
+	channels []chan Event
+	closed   bool
+}
+
+// Subscribe returns a channel of progress and result events, so that
+// programs embedding the crawler can show their own progress without
+// polling statistics or parsing logs. The channel is closed when Run
+// returns. Events are delivered in order and none are dropped, so the
+// subscriber must keep reading: once buffer events are waiting, the crawl
+// waits for the subscriber.
+func (c *Crawler) Subscribe(buffer int) <-chan Event {
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+
+	channel := make(chan Event, max(buffer, 0))
+	if c.events.closed {
+		close(channel)
+		return channel
+	}
+	c.events.channels = append(c.events.channels, channel)
+	return channel
+}
+
+// active reports whether anyone is subscribed
+func (s *subscribers) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.channels) > 0
+}
+
+// publish sends an event to every subscriber
+func (s *subscribers) publish(event Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, channel := range s.channels {
+		channel <- event
+	}
+}
+
+// close closes every subscriber's channel; later subscribers get a closed
+// channel
+func (s *subscribers) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, channel := range s.channels {
+		close(channel)
+	}
+	s.channels = nil
+	s.closed = true
+}
+
+// publishResult sends a copy of a result to subscribers
+func (c *Crawler) publishResult(phase string, result *stats.Result) {
+	if !c.events.active() {
+		return
+	}
+	copied := *result
+	c.events.publish(Event{Type: EventResult, Phase: phase, Result: &copied})
+}
+
+// publishProgress sends the current progress to subscribers
+func (c *Crawler) publishProgress() {
+	if !c.events.active() {
+		return
+	}
+	progress := c.stats.GetProgress()
+	c.events.publish(Event{Type: EventProgress, Progress: &progress})
+}
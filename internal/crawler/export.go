@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/export"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// namedExporter is an exporter and the name it was configured by. The
+// destination is left out of logs and errors as it may hold credentials.
+type namedExporter struct {
+	name string
+	export.Exporter
+}
+
+// newExporters creates the exporters named by --export values
+func newExporters(specs []string) ([]namedExporter, error) {
+	exporters := make([]namedExporter, 0, len(specs))
+	for _, spec := range specs {
+		name, destination, err := config.ParseExport(spec)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := export.New(name, destination)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, namedExporter{name: name, Exporter: exporter})
+	}
+	return exporters, nil
+}
+
+// exportStats hands the final statistics and every result to the
+// configured exporters. Every exporter is called even if an earlier one
+// fails.
+func (c *Crawler) exportStats() error {
+	if len(c.exporters) == 0 {
+		return nil
+	}
+
+	entries, _ := c.exportResults.snapshot()
+	results := make([]stats.Result, len(entries))
+	for i, entry := range entries {
+		results[i] = entry.Result
+	}
+	collected := c.Stats()
+	final, cache := collected.GetFinalStats(), collected.GetCacheStats()
+
+	var errs []error
+	for _, exporter := range c.exporters {
+		if err := exporter.Export(final, cache, results); err != nil {
+			errs = append(errs, fmt.Errorf("failed to export stats to %s: %w", exporter.name, err))
+			continue
+		}
+		c.logger.WithFields(logrus.Fields{
+			"exporter": exporter.name,
+			"results":  len(results),
+		}).Info("Stats exported")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// maxHeaderChanges bounds the per-URL lines for headers that changed
+// between passes
+const maxHeaderChanges = 20
+
+// comparedHeaders returns the response headers compared between cache
+// verification passes, or nil outside cache verification mode. Repeated
+// headers are joined as they would be on one line.
+func (c *Crawler) comparedHeaders(header http.Header) map[string]string {
+	if !c.config.CacheVerificationMode || len(c.config.CompareHeaders) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(c.config.CompareHeaders))
+	for _, name := range c.config.CompareHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if values := header.Values(name); len(values) > 0 {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+// printHeaderChanges warns about URLs whose compared headers changed
+// between warm-up and verification, which usually means the two requests
+// reached different backends
+func (c *Crawler) printHeaderChanges() {
+	changes := c.stats.GetHeaderChanges()
+	if len(changes) == 0 {
+		return
+	}
+
+	fields := logrus.Fields{}
+	for name, count := range stats.CountHeaderChanges(changes) {
+		fields[name] = count
+	}
+	c.logger.WithFields(fields).Warn("URLs with headers changed between passes")
+
+	lines := 0
+	for i, change := range changes {
+		for _, diff := range change.Headers {
+			if lines == maxHeaderChanges {
+				c.logger.WithField("urls", len(changes)-i).Warn("More changed headers omitted")
+				return
+			}
+			lines++
+			c.logger.WithFields(logrus.Fields{
+				"url":     change.URL,
+				"header":  diff.Name,
+				"warm_up": diff.WarmUp,
+				"verify":  diff.Verify,
+			}).Warn("Header changed between passes")
+		}
+	}
+}
+
+// writeHeaderDiffReport writes the URLs whose compared headers changed
+// between passes if a report was requested
+func (c *Crawler) writeHeaderDiffReport() error {
+	if c.config.HeaderDiffReport == "" {
+		return nil
+	}
+
+	changes := c.Stats().GetHeaderChanges()
+	formatter := c.newFormatter(c.config.OutputFormat)
+	if err := formatter.WriteToFile(c.config.HeaderDiffReport, formatter.FormatHeaderChanges(changes)); err != nil {
+		return fmt.Errorf("failed to write header diff report: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file": c.config.HeaderDiffReport,
+		"urls": len(changes),
+	}).Info("Header diff report written")
+	return nil
+}
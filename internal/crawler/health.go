@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/health"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/urlstate"
+)
+
+// healthShutdownTimeout bounds how long in-flight probes may hold up the end
+// of a run
+const healthShutdownTimeout = 5 * time.Second
+
+// startHealthServer serves health probes if a health address was configured.
+// The probe starts out not ready; the returned function stops the server.
+// The server also reports the state of each URL as the run goes and lets
+// operators change the progress settings.
+func (c *Crawler) startHealthServer() (func(), error) {
+	if c.config.HealthAddr == "" {
+		return func() {}, nil
+	}
+
+	c.health = &health.Probe{}
+	c.urlState = urlstate.NewTracker()
+	c.health.Mount(urlstate.StatusPath, c.urlState.Handler())
+	c.health.Mount(urlstate.URLsPath, c.urlState.Handler())
+	c.health.Mount(progressPath, c.progress.handler())
+	server, err := health.Listen(c.config.HealthAddr, c.health)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.WithField("addr", server.Addr()).Info("Serving health probes")
+
+	return func() {
+		c.setReady(false)
+		ctx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			c.logger.WithError(err).Warn("Failed to stop health probes")
+		}
+	}, nil
+}
+
+// setReady updates the readiness probe, if one is served
+func (c *Crawler) setReady(ready bool) {
+	if c.health == nil {
+		return
+	}
+	c.health.SetReady(ready)
+	c.logger.WithField("ready", ready).Debug("Readiness changed")
+}
+
+// trackURLs starts tracking the state of the URLs to crawl, if the state is
+// served
+func (c *Crawler) trackURLs(urls []parser.URL) {
+	if c.urlState == nil {
+		return
+	}
+	locs := make([]string, len(urls))
+	for i, url := range urls {
+		locs[i] = url.Loc
+	}
+	c.urlState.Add(locs)
+}
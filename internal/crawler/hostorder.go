@@ -0,0 +1,367 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// otherHosts stands for every host not named in a host order
+const otherHosts = "*"
+
+// hostOrder puts hosts into groups that are crawled one after another, so
+// that, for example, an API is warmed before the pages that call it
+type hostOrder struct {
+	groups    [][]string
+	index     map[string]int
+	others    int
+	threshold float64
+}
+
+// parseHostOrder parses groups of hosts separated by ">", each a
+// comma-separated list of hosts, such as "api.example.com>www.example.com".
+// "*" places the hosts not named; they are crawled last by default. A group
+// starts once threshold of the URLs in every earlier group have completed.
+func parseHostOrder(spec string, threshold float64) (*hostOrder, error) {
+	order := &hostOrder{index: make(map[string]int), others: -1, threshold: threshold}
+	for _, field := range strings.Split(spec, ">") {
+		var group []string
+		for _, host := range strings.Split(field, ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" {
+				continue
+			}
+			if _, seen := order.index[host]; seen || (host == otherHosts && order.others >= 0) {
+				return nil, fmt.Errorf("invalid host order %q: %s appears more than once", spec, host)
+			}
+			if host == otherHosts {
+				order.others = len(order.groups)
+			} else {
+				order.index[host] = len(order.groups)
+			}
+			group = append(group, host)
+		}
+		if len(group) == 0 {
+			return nil, fmt.Errorf("invalid host order %q: empty host group", spec)
+		}
+		order.groups = append(order.groups, group)
+	}
+
+	if len(order.groups) < 2 {
+		return nil, fmt.Errorf("invalid host order %q: expected at least two groups separated by >", spec)
+	}
+	if order.others < 0 {
+		order.others = len(order.groups)
+		order.groups = append(order.groups, []string{otherHosts})
+	}
+	return order, nil
+}
+
+// group returns the index of the group a URL's host belongs to. A host may
+// be named with or without its port.
+func (o *hostOrder) group(rawURL string) int {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return o.others
+	}
+	if group, ok := o.index[strings.ToLower(parsed.Host)]; ok {
+		return group
+	}
+	if group, ok := o.index[strings.ToLower(parsed.Hostname())]; ok {
+		return group
+	}
+	return o.others
+}
+
+// passFeed sends one pass's URLs to the workers. Under a host order it holds
+// each group back until enough URLs of the groups before it have completed;
+// without one it sends every URL at once. With priority lanes each group
+// sends its high priority URLs first, and once the deadline has passed the
+// rest are skipped. Under --max-concurrent-per-host it hands out only URLs
+// whose host has a free slot. A sitemap refresh may add and drop URLs while
+// the pass runs.
+type passFeed struct {
+	order    *hostOrder
+	priority *priorityLanes
+	slots    *hostSlots
+	logger   logrus.FieldLogger
+	deadline time.Time
+	skip     func(count int)
+
+	// closed is called once every URL has been sent
+	closed func()
+
+	mu        sync.Mutex
+	groups    []feedGroup
+	completed []int
+	changed   chan struct{}
+}
+
+// feedGroup holds the URLs of a host group not yet sent, in order, in their
+// priority lanes
+type feedGroup struct {
+	high   lane
+	normal lane
+
+	// size counts the group's URLs, sent or not, for the threshold
+	size int
+}
+
+// newPassFeed splits a pass's URLs into the host order's groups and
+// priority lanes, keeping their order, or the order of their sitemap
+// priority with --order-by priority, within each
+func (c *Crawler) newPassFeed(urls []parser.URL) *passFeed {
+	feed := &passFeed{
+		order:    c.hostOrder,
+		priority: c.priority,
+		slots:    c.hostSlots,
+		logger:   c.logger,
+		deadline: c.deadline,
+		skip:     c.skipURLs,
+		changed:  make(chan struct{}, 1),
+	}
+	groups := 1
+	if c.hostOrder != nil {
+		groups = len(c.hostOrder.groups)
+	}
+	feed.groups = make([]feedGroup, groups)
+	feed.completed = make([]int, groups)
+	c.hostSlots.reset()
+
+	// The feed follows the latest sitemap, if it is refreshed
+	if c.refresh != nil {
+		urls = c.refresh.track(feed)
+		feed.closed = func() { c.refresh.untrack(feed) }
+	}
+	if c.priorityOrder {
+		urls = byPriority(urls)
+	}
+	feed.add(urls)
+	return feed
+}
+
+// group returns the index of the group a URL belongs to
+func (f *passFeed) group(rawURL string) int {
+	if f.order == nil {
+		return 0
+	}
+	return f.order.group(rawURL)
+}
+
+// host returns the host a URL is queued under: its own under a per-host
+// cap, otherwise one shared by every URL
+func (f *passFeed) host(rawURL string) string {
+	if f.slots == nil {
+		return ""
+	}
+	return hostKey(rawURL)
+}
+
+// add queues URLs at the end of their group and lane
+func (f *passFeed) add(urls []parser.URL) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, entry := range urls {
+		group := &f.groups[f.group(entry.Loc)]
+		if f.priority != nil && f.priority.high(entry.Loc) {
+			group.high.push(f.host(entry.Loc), entry)
+		} else {
+			group.normal.push(f.host(entry.Loc), entry)
+		}
+		group.size++
+	}
+	f.notify()
+}
+
+// remove drops the URLs not yet sent that are in drop, and returns how many
+// it dropped
+func (f *passFeed) remove(drop map[string]bool) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	removed := 0
+	for i := range f.groups {
+		group := &f.groups[i]
+		count := group.high.remove(drop) + group.normal.remove(drop)
+		group.size -= count
+		removed += count
+	}
+	if removed > 0 {
+		f.notify()
+	}
+	return removed
+}
+
+// send sends the URLs to urlChan, group by group, and closes it when done
+func (f *passFeed) send(ctx context.Context, urlChan chan<- parser.URL) {
+	defer close(urlChan)
+	if f.closed != nil {
+		defer f.closed()
+	}
+
+	for i := range f.groups {
+		pending := f.pending(i)
+		if pending == 0 {
+			continue
+		}
+		if i > 0 && f.order != nil {
+			if !f.waitForGroups(ctx, i, urlChan) {
+				return
+			}
+			f.logger.WithFields(logrus.Fields{
+				"group": i + 1,
+				"hosts": strings.Join(f.order.groups[i], ","),
+				"urls":  pending,
+			}).Info("Starting host group")
+		}
+		if !f.sendGroup(ctx, i, urlChan) {
+			return
+		}
+	}
+
+	// A sitemap refresh may have added URLs to groups already sent
+	for i := range f.groups {
+		if !f.sendGroup(ctx, i, urlChan) {
+			return
+		}
+	}
+}
+
+// sendGroup sends a group's URLs until none are left, waiting while every
+// URL left is on a host with no free slot. It returns false if ctx is done
+// first.
+func (f *passFeed) sendGroup(ctx context.Context, group int, urlChan chan<- parser.URL) bool {
+	for {
+		url, ok, waiting := f.next(group)
+		if waiting {
+			select {
+			case <-f.slots.released():
+			case <-f.changed:
+			case <-ctx.Done():
+				return false
+			}
+			continue
+		}
+		if !ok {
+			return true
+		}
+		select {
+		case urlChan <- url:
+		case <-ctx.Done():
+			return false // Exit early if cancelled
+		}
+	}
+}
+
+// pending returns how many of a group's URLs have not been sent
+func (f *passFeed) pending(group int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.groups[group].high.len() + f.groups[group].normal.len()
+}
+
+// next takes the next URL to send from a group: high priority first, then
+// the rest unless the deadline has passed, passing over URLs whose host has
+// no free slot. It takes a slot for the URL it returns. waiting reports that
+// URLs are left but none of their hosts has a free slot.
+func (f *passFeed) next(group int) (url parser.URL, ok, waiting bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	g := &f.groups[group]
+	if url, host, ok := g.high.pop(f.slots.full); ok {
+		f.slots.take(host)
+		return url, true, false
+	}
+	if g.normal.len() > 0 && f.pastDeadline() {
+		f.skipRestLocked(group)
+	}
+	if url, host, ok := g.normal.pop(f.slots.full); ok {
+		f.slots.take(host)
+		return url, true, false
+	}
+	return parser.URL{}, false, g.high.len()+g.normal.len() > 0
+}
+
+// pastDeadline reports whether normal priority URLs are to be skipped: there
+// are priority lanes and the deadline has passed
+func (f *passFeed) pastDeadline() bool {
+	return f.priority != nil && !f.deadline.IsZero() && !time.Now().Before(f.deadline)
+}
+
+// skipRestLocked skips the normal priority URLs left in a group. They count
+// as completed, so that later groups are not held back waiting for them.
+func (f *passFeed) skipRestLocked(group int) {
+	skipped := f.groups[group].normal.clear()
+
+	fields := logrus.Fields{"skipped": skipped}
+	if f.order != nil {
+		fields["group"] = group + 1
+	}
+	f.logger.WithFields(fields).Warn("Deadline passed, skipping normal priority URLs")
+	f.skip(skipped)
+	f.completed[group] += skipped
+	f.notify()
+}
+
+// waitForGroups waits until the threshold is reached in every group before
+// next, sending any URLs added to those groups meanwhile. It returns false
+// if ctx is done first.
+func (f *passFeed) waitForGroups(ctx context.Context, next int, urlChan chan<- parser.URL) bool {
+	for {
+		for group := range next {
+			if !f.sendGroup(ctx, group, urlChan) {
+				return false
+			}
+		}
+		if f.groupsReady(next) {
+			return true
+		}
+		select {
+		case <-f.changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// groupsReady reports whether the threshold is reached in every group before
+// next
+func (f *passFeed) groupsReady(next int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range next {
+		needed := int(math.Ceil(f.order.threshold * float64(f.groups[i].size)))
+		if f.completed[i] < needed {
+			return false
+		}
+	}
+	return true
+}
+
+// done records that a URL has completed, whether or not it succeeded
+func (f *passFeed) done(rawURL string) {
+	if f.order == nil {
+		return
+	}
+
+	f.mu.Lock()
+	f.completed[f.order.group(rawURL)]++
+	f.mu.Unlock()
+	f.notify()
+}
+
+// notify wakes a send waiting for the groups before the next one
+func (f *passFeed) notify() {
+	select {
+	case f.changed <- struct{}{}:
+	default:
+	}
+}
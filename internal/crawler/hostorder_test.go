@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec      string
+		expected  [][]string
+		wantError string
+	}{
+		{spec: "api.example.com>www.example.com", expected: [][]string{{"api.example.com"}, {"www.example.com"}, {"*"}}},
+		{spec: "API.example.com, img.example.com > *", expected: [][]string{{"api.example.com", "img.example.com"}, {"*"}}},
+		{spec: "* > www.example.com", expected: [][]string{{"*"}, {"www.example.com"}}},
+		{spec: "api.example.com", wantError: "at least two groups"},
+		{spec: "api.example.com>>www.example.com", wantError: "empty host group"},
+		{spec: "api.example.com>api.example.com", wantError: "more than once"},
+		{spec: "*>www.example.com>*", wantError: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			order, err := parseHostOrder(tt.spec, 1)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, order.groups)
+		})
+	}
+}
+
+func TestHostOrderGroup(t *testing.T) {
+	t.Parallel()
+
+	order, err := parseHostOrder("api.example.com,127.0.0.1:8080>www.example.com", 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, order.group("https://API.example.com/v1/menu"))
+	assert.Equal(t, 0, order.group("https://api.example.com:8443/v1/menu"))
+	assert.Equal(t, 0, order.group("http://127.0.0.1:8080/"))
+	assert.Equal(t, 1, order.group("https://www.example.com/"))
+	assert.Equal(t, 2, order.group("http://127.0.0.1:9090/"))
+	assert.Equal(t, 2, order.group("https://cdn.example.com/logo.png"))
+}
+
+func TestPassFeedHoldsLaterGroups(t *testing.T) {
+	t.Parallel()
+
+	order, err := parseHostOrder("api.example.com>www.example.com", 0.5)
+	require.NoError(t, err)
+	logger, hook := test.NewNullLogger()
+	c := &Crawler{hostOrder: order, logger: logger}
+
+	feed := c.newPassFeed([]parser.URL{
+		{Loc: "https://www.example.com/"},
+		{Loc: "https://api.example.com/a"},
+		{Loc: "https://api.example.com/b"},
+		{Loc: "https://api.example.com/c"},
+	})
+	urlChan := make(chan parser.URL, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feed.send(ctx, urlChan)
+
+	// The API group is sent first and the page waits for it
+	for _, expected := range []string{"https://api.example.com/a", "https://api.example.com/b", "https://api.example.com/c"} {
+		assert.Equal(t, expected, (<-urlChan).Loc)
+	}
+	select {
+	case entry := <-urlChan:
+		t.Fatalf("Expected %s to wait for the API group", entry.Loc)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Half of three URLs rounds up to two
+	feed.done("https://api.example.com/a")
+	select {
+	case entry := <-urlChan:
+		t.Fatalf("Expected %s to wait for a second API URL", entry.Loc)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	feed.done("https://api.example.com/b")
+	assert.Equal(t, "https://www.example.com/", (<-urlChan).Loc)
+	_, open := <-urlChan
+	assert.False(t, open)
+
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, "Starting host group", hook.LastEntry().Message)
+	assert.Equal(t, "www.example.com", hook.LastEntry().Data["hosts"])
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"net/url"
+	"sync"
+)
+
+// hostSlots caps the requests in flight to any one host. The pass feed takes
+// a slot before it hands a URL to a worker, and passes over the URLs of full
+// hosts, so that workers are not held up by one busy host. A nil *hostSlots
+// imposes no cap.
+type hostSlots struct {
+	limit int
+
+	mu       sync.Mutex
+	inFlight map[string]int
+	freed    chan struct{}
+}
+
+// newHostSlots creates a cap of limit requests per host, or returns nil
+// when limit is zero
+func newHostSlots(limit int) *hostSlots {
+	if limit <= 0 {
+		return nil
+	}
+	return &hostSlots{limit: limit, inFlight: make(map[string]int), freed: make(chan struct{}, 1)}
+}
+
+// hostKey returns the host a URL's slots are counted against
+func hostKey(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		return parsed.Host
+	}
+	return rawURL
+}
+
+// full reports whether every slot of a host is taken
+func (h *hostSlots) full(host string) bool {
+	if h == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.inFlight[host] >= h.limit
+}
+
+// take takes a slot of a host, which the caller has checked is not full
+func (h *hostSlots) take(host string) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight[host]++
+}
+
+// release frees the slot taken for a URL once it is done, retries included
+func (h *hostSlots) release(rawURL string) {
+	if h == nil {
+		return
+	}
+
+	host := hostKey(rawURL)
+	h.mu.Lock()
+	if h.inFlight[host] > 0 {
+		h.inFlight[host]--
+	}
+	if h.inFlight[host] == 0 {
+		delete(h.inFlight, host)
+	}
+	h.mu.Unlock()
+
+	select {
+	case h.freed <- struct{}{}:
+	default:
+	}
+}
+
+// released returns a channel that receives after a slot is freed. Without a
+// cap it never receives.
+func (h *hostSlots) released() <-chan struct{} {
+	if h == nil {
+		return nil
+	}
+	return h.freed
+}
+
+// reset frees every slot. A new pass starts from it, so that URLs a
+// cancelled pass handed out but never crawled hold no slot.
+func (h *hostSlots) reset() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.inFlight)
+}
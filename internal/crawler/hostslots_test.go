@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostSlots(t *testing.T) {
+	t.Parallel()
+
+	slots := newHostSlots(2)
+	hostA := hostKey("https://a.example.com/1")
+
+	slots.take(hostA)
+	assert.False(t, slots.full(hostA))
+	slots.take(hostA)
+	assert.True(t, slots.full(hostA))
+
+	// Another host has slots of its own
+	assert.False(t, slots.full(hostKey("https://b.example.com/1")))
+
+	slots.release("https://a.example.com/1")
+	assert.False(t, slots.full(hostA))
+	select {
+	case <-slots.released():
+	default:
+		t.Error("Expected a freed slot to be signalled")
+	}
+
+	slots.take(hostA)
+	slots.reset()
+	assert.False(t, slots.full(hostA))
+}
+
+func TestHostSlotsDisabled(t *testing.T) {
+	t.Parallel()
+
+	slots := newHostSlots(0)
+	assert.Nil(t, slots)
+
+	slots.take("a.example.com")
+	assert.False(t, slots.full("a.example.com"))
+	slots.release("https://a.example.com/1")
+	assert.Nil(t, slots.released())
+}
+
+func TestPassFeedSkipsFullHosts(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{hostSlots: newHostSlots(1), logger: logger}
+
+	feed := c.newPassFeed([]parser.URL{
+		{Loc: "https://a.example.com/1"},
+		{Loc: "https://a.example.com/2"},
+		{Loc: "https://a.example.com/3"},
+		{Loc: "https://b.example.com/1"},
+	})
+	urlChan := make(chan parser.URL, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feed.send(ctx, urlChan)
+
+	// The second host is sent while the first is at its cap
+	assert.Equal(t, "https://a.example.com/1", (<-urlChan).Loc)
+	assert.Equal(t, "https://b.example.com/1", (<-urlChan).Loc)
+	select {
+	case entry := <-urlChan:
+		t.Fatalf("Expected %s to wait for a free slot", entry.Loc)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Each freed slot lets the next URL of the host through, in order
+	c.hostSlots.release("https://a.example.com/1")
+	assert.Equal(t, "https://a.example.com/2", (<-urlChan).Loc)
+	c.hostSlots.release("https://a.example.com/2")
+	assert.Equal(t, "https://a.example.com/3", (<-urlChan).Loc)
+	_, open := <-urlChan
+	assert.False(t, open)
+}
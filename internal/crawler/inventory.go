@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/inventory"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// parseOnly runs the parse command: it prints the sitemap's URLs, or with
+// --stats a report on its metadata, without requesting any page
+func (c *Crawler) parseOnly() error {
+	documents, err := c.parser.ParseSitemapDocuments(c.config.SitemapURL, c.config.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	c.run.Finish()
+	if err := c.writeCassette(); err != nil {
+		return err
+	}
+
+	var content string
+	if c.config.Command == config.CommandParseStats {
+		report := inventory.Analyze(documents, time.Now())
+		content = c.newFormatter(c.config.OutputFormat).FormatInventoryReport(report)
+
+		c.logger.WithFields(logrus.Fields{
+			"sitemaps":           len(report.Sitemaps),
+			"listed":             report.Listed,
+			"unique_urls":        report.UniqueURLs,
+			"duplicate_listings": report.DuplicateListings(),
+			"missing_lastmod":    report.MissingLastMod,
+		}).Info("Sitemap statistics")
+	} else {
+		seen := make(map[string]bool)
+		var entries []parser.URL
+		for _, document := range documents {
+			for _, entry := range document.Entries {
+				if !seen[entry.Loc] {
+					seen[entry.Loc] = true
+					entries = append(entries, entry)
+				}
+			}
+		}
+		content = strings.Join(parser.Locations(entries), "\n")
+	}
+
+	if _, err := fmt.Fprintln(os.Stdout, content); err != nil {
+		return fmt.Errorf("failed to write sitemap: %w", err)
+	}
+	return nil
+}
+
+// validateSitemap runs parse --validate: it checks every sitemap file
+// against the sitemaps protocol, prints the report and fails if any file
+// breaks it or could not be read
+func (c *Crawler) validateSitemap() error {
+	reports, err := c.parser.ValidateSitemap(c.config.SitemapURL, c.config.Headers)
+	if err != nil {
+		return err
+	}
+	c.run.Finish()
+	if err := c.writeCassette(); err != nil {
+		return err
+	}
+
+	invalid, violations := 0, 0
+	for _, report := range reports {
+		if !report.Valid() {
+			invalid++
+		}
+		violations += len(report.Violations) + report.Omitted
+	}
+	c.logger.WithFields(logrus.Fields{
+		"sitemaps":   len(reports),
+		"invalid":    invalid,
+		"violations": violations,
+	}).Info("Sitemap validation")
+
+	content := c.newFormatter(c.config.OutputFormat).FormatSitemapValidation(reports)
+	if _, err := fmt.Fprintln(os.Stdout, content); err != nil {
+		return fmt.Errorf("failed to write sitemap validation report: %w", err)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d sitemaps do not follow the sitemaps protocol", invalid, len(reports))
+	}
+	return nil
+}
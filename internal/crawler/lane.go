@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"slices"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// lane holds URLs waiting to be sent, in order. They are queued per host, so
+// that the URLs of a host at its cap can be passed over without scanning
+// them.
+type lane struct {
+	hosts  []string
+	queues map[string][]laneEntry
+	seq    int
+	size   int
+}
+
+// laneEntry is a queued URL and its place in the lane
+type laneEntry struct {
+	url parser.URL
+	seq int
+}
+
+// len returns how many URLs are queued
+func (l *lane) len() int {
+	return l.size
+}
+
+// push queues a URL at the end of the lane under a host
+func (l *lane) push(host string, entry parser.URL) {
+	if l.queues == nil {
+		l.queues = make(map[string][]laneEntry)
+	}
+	if len(l.queues[host]) == 0 {
+		l.hosts = append(l.hosts, host)
+	}
+	l.queues[host] = append(l.queues[host], laneEntry{url: entry, seq: l.seq})
+	l.seq++
+	l.size++
+}
+
+// pop takes the earliest queued URL whose host is not full
+func (l *lane) pop(full func(host string) bool) (parser.URL, string, bool) {
+	best := -1
+	for i, host := range l.hosts {
+		if full(host) {
+			continue
+		}
+		if best < 0 || l.queues[host][0].seq < l.queues[l.hosts[best]][0].seq {
+			best = i
+		}
+	}
+	if best < 0 {
+		return parser.URL{}, "", false
+	}
+
+	host := l.hosts[best]
+	queue := l.queues[host]
+	entry := queue[0].url
+	if len(queue) == 1 {
+		delete(l.queues, host)
+		l.hosts = slices.Delete(l.hosts, best, best+1)
+	} else {
+		l.queues[host] = queue[1:]
+	}
+	l.size--
+	return entry, host, true
+}
+
+// remove drops the queued URLs in drop and returns how many it dropped
+func (l *lane) remove(drop map[string]bool) int {
+	removed := 0
+	for host, queue := range l.queues {
+		kept := slices.DeleteFunc(queue, func(entry laneEntry) bool { return drop[entry.url.Loc] })
+		removed += len(queue) - len(kept)
+		if len(kept) == 0 {
+			delete(l.queues, host)
+		} else {
+			l.queues[host] = kept
+		}
+	}
+	if removed > 0 {
+		l.hosts = slices.DeleteFunc(l.hosts, func(host string) bool { return len(l.queues[host]) == 0 })
+		l.size -= removed
+	}
+	return removed
+}
+
+// clear drops every queued URL and returns how many there were
+func (l *lane) clear() int {
+	count := l.size
+	*l = lane{seq: l.seq}
+	return count
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"context"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Memory use, as a fraction of the budget, at which the memory guard acts
+const (
+	// memoryReduceRatio halves the workers
+	memoryReduceRatio = 0.8
+
+	// memoryPauseRatio stops every worker, pausing intake, until memory is
+	// freed
+	memoryPauseRatio = 0.95
+
+	// memoryRecoverRatio doubles the workers again, up to all of them
+	memoryRecoverRatio = 0.6
+)
+
+// memoryCheckInterval is how often the memory guard reads memory use
+const memoryCheckInterval = time.Second
+
+// memoryGuard keeps the crawler within a memory budget by shrinking the
+// worker pool, down to no workers at all to pause intake, as memory use
+// approaches it. A nil *memoryGuard imposes no budget.
+type memoryGuard struct {
+	budget  uint64
+	workers int
+	logger  logrus.FieldLogger
+
+	// read returns the memory in use, in bytes
+	read func() uint64
+
+	// resize sets the number of workers, and running returns how many are
+	// still running, including those finishing a URL before they stop
+	resize  func(int)
+	running func() int
+
+	// allowed is the number of workers the guard last set; only check
+	// changes it
+	allowed int
+}
+
+// newMemoryGuard creates a guard for a budget shared by up to workers
+// workers, or returns nil when budget is zero
+func newMemoryGuard(budget int64, workers int, logger logrus.FieldLogger, resize func(int), running func() int) *memoryGuard {
+	if budget <= 0 {
+		return nil
+	}
+	return &memoryGuard{
+		budget:  uint64(budget),
+		workers: workers,
+		logger:  logger,
+		read:    readMemoryInUse,
+		resize:  resize,
+		running: running,
+		allowed: workers,
+	}
+}
+
+// readMemoryInUse returns the memory the Go runtime holds from the operating
+// system, less what it has released back; it tracks the process's RSS
+// closely without reading /proc
+func readMemoryInUse() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// watch sets the runtime's soft memory limit to the budget, so that garbage
+// collection works harder as it nears, and checks memory use every interval
+// until the returned function is called
+func (g *memoryGuard) watch(interval time.Duration) func() {
+	if g == nil {
+		return func() {}
+	}
+
+	previous := debug.SetMemoryLimit(int64(g.budget))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				g.check()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		debug.SetMemoryLimit(previous)
+	}
+}
+
+// check reads memory use and resizes the worker pool
+func (g *memoryGuard) check() {
+	used := g.read()
+	usage := float64(used) / float64(g.budget)
+
+	allowed := g.allowed
+	message := ""
+	switch {
+	case usage >= memoryPauseRatio && g.allowed > 1:
+		allowed = 0
+		message = "Memory budget reached, pausing intake"
+	case usage >= memoryPauseRatio && g.allowed == 0 && g.running() == 0:
+		// Nothing in flight holds the memory, so pausing cannot free it;
+		// the one worker is not paused again while usage stays this high
+		allowed = 1
+		message = "Memory budget exceeded with no requests in flight, continuing with one worker"
+	case usage >= memoryPauseRatio:
+	case g.allowed == 0:
+		allowed = 1
+		message = "Memory use below budget, resuming intake with one worker"
+	case usage >= memoryReduceRatio && g.allowed > 1:
+		allowed = g.allowed / 2
+		message = "Memory budget approached, reducing workers"
+	case usage < memoryRecoverRatio && g.allowed < g.workers:
+		allowed = min(max(g.allowed*2, 1), g.workers)
+		message = "Memory use recovered, restoring workers"
+	}
+	if allowed == g.allowed {
+		return
+	}
+
+	fields := logrus.Fields{
+		"memory_bytes": used,
+		"budget_bytes": g.budget,
+		"workers":      allowed,
+		"max_workers":  g.workers,
+	}
+	if allowed < g.allowed || usage >= memoryPauseRatio {
+		g.logger.WithFields(fields).Warn(message)
+	} else {
+		g.logger.WithFields(fields).Info(message)
+	}
+
+	g.allowed = allowed
+	g.resize(allowed)
+
+	// Return freed memory promptly rather than at the next scavenge
+	if allowed == 0 {
+		go debug.FreeOSMemory()
+	}
+}
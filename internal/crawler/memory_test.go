@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryGuardResizesPool(t *testing.T) {
+	t.Parallel()
+
+	logger, hook := test.NewNullLogger()
+	used, workers, running := uint64(0), 8, 8
+	guard := newMemoryGuard(1000, 8, logger, func(size int) { workers = size }, func() int { return running })
+	guard.read = func() uint64 { return used }
+
+	steps := []struct {
+		used     uint64
+		running  int
+		expected int
+		message  string
+	}{
+		{used: 500, running: 8, expected: 8},
+		{used: 850, running: 8, expected: 4, message: "Memory budget approached, reducing workers"},
+		{used: 850, running: 4, expected: 2, message: "Memory budget approached, reducing workers"},
+		{used: 960, running: 2, expected: 0, message: "Memory budget reached, pausing intake"},
+
+		// The requests in flight may be what holds the memory
+		{used: 960, running: 1, expected: 0},
+
+		// With nothing in flight, pausing cannot free memory, so one worker
+		// carries on rather than the crawl stalling
+		{used: 960, running: 0, expected: 1, message: "Memory budget exceeded with no requests in flight, continuing with one worker"},
+		{used: 960, running: 1, expected: 1},
+		{used: 700, running: 1, expected: 1},
+		{used: 300, running: 1, expected: 2, message: "Memory use recovered, restoring workers"},
+		{used: 960, running: 2, expected: 0, message: "Memory budget reached, pausing intake"},
+		{used: 700, running: 0, expected: 1, message: "Memory use below budget, resuming intake with one worker"},
+		{used: 300, running: 1, expected: 2, message: "Memory use recovered, restoring workers"},
+		{used: 300, running: 2, expected: 4, message: "Memory use recovered, restoring workers"},
+		{used: 300, running: 4, expected: 8, message: "Memory use recovered, restoring workers"},
+	}
+
+	for i, step := range steps {
+		hook.Reset()
+		used, running = step.used, step.running
+		guard.check()
+		assert.Equal(t, step.expected, workers, "step %d", i)
+		if step.message == "" {
+			assert.Nil(t, hook.LastEntry(), "step %d", i)
+			continue
+		}
+		require.NotNil(t, hook.LastEntry(), "step %d", i)
+		assert.Equal(t, step.message, hook.LastEntry().Message, "step %d", i)
+	}
+}
+
+func TestMemoryGuardWithoutBudget(t *testing.T) {
+	t.Parallel()
+
+	guard := newMemoryGuard(0, 4, nil, nil, nil)
+	assert.Nil(t, guard)
+	guard.watch(time.Millisecond)()
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// keepModified returns the sitemap URLs whose <lastmod> is at or after the
+// cutoff, along with the number left out and the number kept only because
+// they have no lastmod to judge by. A zero cutoff keeps every URL.
+func keepModified(urls []parser.URL, cutoff time.Time) (kept []parser.URL, unchanged, undated int) {
+	if cutoff.IsZero() {
+		return urls, 0, 0
+	}
+	kept = make([]parser.URL, 0, len(urls))
+	for _, entry := range urls {
+		switch {
+		case entry.LastMod.IsZero():
+			undated++
+		case entry.LastMod.Before(cutoff):
+			unchanged++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept, unchanged, undated
+}
+
+// filterModified restricts the crawl to the sitemap URLs changed since the
+// --modified-since or --modified-within cutoff, if one was set
+func (c *Crawler) filterModified(urls []parser.URL) ([]parser.URL, error) {
+	if c.modifiedCutoff.IsZero() {
+		return urls, nil
+	}
+
+	kept, unchanged, undated := keepModified(urls, c.modifiedCutoff)
+	c.logger.WithFields(logrus.Fields{
+		"modified_since": c.modifiedCutoff.Format(time.RFC3339),
+		"modified_urls":  len(kept) - undated,
+		"unchanged_urls": unchanged,
+		"no_lastmod":     undated,
+	}).Info("URLs filtered by lastmod")
+
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no sitemap URLs modified since %s", c.modifiedCutoff.Format(time.RFC3339))
+	}
+	return kept, nil
+}
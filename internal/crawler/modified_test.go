@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepModified(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	urls := []parser.URL{
+		{Loc: "https://example.com/new", LastMod: cutoff.Add(time.Hour)},
+		{Loc: "https://example.com/old", LastMod: cutoff.Add(-time.Hour)},
+		{Loc: "https://example.com/undated"},
+		{Loc: "https://example.com/exact", LastMod: cutoff},
+	}
+
+	kept, unchanged, undated := keepModified(urls, cutoff)
+	assert.Equal(t, []parser.URL{urls[0], urls[2], urls[3]}, kept)
+	assert.Equal(t, 1, unchanged)
+	assert.Equal(t, 1, undated)
+
+	kept, unchanged, undated = keepModified(urls, time.Time{})
+	assert.Equal(t, urls, kept, "a zero cutoff keeps every URL")
+	assert.Zero(t, unchanged)
+	assert.Zero(t, undated)
+}
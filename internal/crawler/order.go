@@ -0,0 +1,30 @@
+package crawler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// byPriority orders the pages the sitemap lists by their <priority>,
+// highest first, keeping sitemap order among equal priorities. A missing or
+// out of range <priority> counts as the protocol's default of 0.5. Image, video
+// and alternate URLs have no priority of their own and follow the pages.
+func byPriority(urls []parser.URL) []parser.URL {
+	ordered := make([]parser.URL, 0, len(urls))
+	for _, entry := range urls {
+		if !entry.Derived {
+			ordered = append(ordered, entry)
+		}
+	}
+	slices.SortStableFunc(ordered, func(a, b parser.URL) int {
+		return cmp.Compare(b.EffectivePriority(), a.EffectivePriority())
+	})
+	for _, entry := range urls {
+		if entry.Derived {
+			ordered = append(ordered, entry)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByPriority(t *testing.T) {
+	t.Parallel()
+
+	urls := []parser.URL{
+		{Loc: "https://example.com/blog", Priority: 0.3, HasPriority: true},
+		{Loc: "https://example.com/lowest", Priority: 0, HasPriority: true},
+		{Loc: "https://example.com/no-priority"},
+		{Loc: "https://example.com/", Priority: 1.0, HasPriority: true},
+		{Loc: "https://example.com/hero.jpg", Derived: true},
+		{Loc: "https://example.com/about", Priority: 0.5, HasPriority: true},
+		{Loc: "https://example.com/invalid", Priority: 7, HasPriority: true},
+		{Loc: "https://example.com/pricing", Priority: 0.9, HasPriority: true},
+	}
+
+	var locs []string
+	for _, entry := range byPriority(urls) {
+		locs = append(locs, entry.Loc)
+	}
+	assert.Equal(t, []string{
+		"https://example.com/",
+		"https://example.com/pricing",
+		"https://example.com/no-priority",
+		"https://example.com/about",
+		"https://example.com/invalid",
+		"https://example.com/blog",
+		"https://example.com/lowest",
+		"https://example.com/hero.jpg",
+	}, locs, "missing and invalid priorities count as 0.5, an explicit 0.0 comes last, ties keep sitemap order and derived URLs follow the pages")
+	assert.Equal(t, "https://example.com/blog", urls[0].Loc, "the input is not reordered")
+}
+
+func TestPassFeedOrdersByPriorityWithinLanes(t *testing.T) {
+	t.Parallel()
+
+	lanes, err := newPriorityLanes([]string{`/signup`})
+	require.NoError(t, err)
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{priority: lanes, priorityOrder: true, logger: logger, stats: stats.New()}
+
+	urls := []parser.URL{
+		{Loc: "https://www.example.com/blog", Priority: 0.2, HasPriority: true},
+		{Loc: "https://www.example.com/signup", Priority: 0.1, HasPriority: true},
+		{Loc: "https://www.example.com/", Priority: 1.0, HasPriority: true},
+	}
+	assert.Equal(t, []string{
+		"https://www.example.com/signup",
+		"https://www.example.com/",
+		"https://www.example.com/blog",
+	}, sent(c.newPassFeed(urls)), "priority patterns still go first")
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/ping"
+	"github.com/sirupsen/logrus"
+)
+
+// pingSearchEngines notifies the configured ping URLs and IndexNow that the
+// sitemap is fresh. Crawls that were cancelled or fell below the minimum
+// success rate are not announced.
+func (c *Crawler) pingSearchEngines(urls []parser.URL) error {
+	if len(c.config.Ping) == 0 && c.config.IndexNowKey == "" {
+		return nil
+	}
+
+	if c.backoffManager.IsCancelled() {
+		c.logger.Warn("Crawl was cancelled, not notifying search engines")
+		return nil
+	}
+	successRate := c.Stats().GetFinalStats().SuccessRate
+	if successRate < c.config.PingMinSuccessRate {
+		c.logger.WithFields(logrus.Fields{
+			"success_rate":     successRate,
+			"min_success_rate": c.config.PingMinSuccessRate,
+		}).Warn("Success rate below threshold, not notifying search engines")
+		return nil
+	}
+
+	ctx := context.Background()
+	for _, pingURL := range c.config.Ping {
+		if err := ping.Sitemap(ctx, c.client, pingURL, c.config.SitemapURL); err != nil {
+			return fmt.Errorf("failed to ping %s: %w", pingURL, err)
+		}
+		c.logger.WithField("ping_url", pingURL).Info("Search engine pinged")
+	}
+
+	if c.config.IndexNowKey == "" {
+		return nil
+	}
+
+	kept := c.okURLs.filter(urls)
+	submitted := make([]string, len(kept))
+	for i, url := range kept {
+		submitted[i] = url.Loc
+	}
+	if len(submitted) == 0 {
+		return nil
+	}
+
+	indexNow := ping.IndexNow{
+		Endpoint:    c.config.IndexNowEndpoint,
+		Key:         c.config.IndexNowKey,
+		KeyLocation: c.config.IndexNowKeyLocation,
+	}
+	if err := indexNow.Submit(ctx, c.client, submitted); err != nil {
+		return err
+	}
+	c.logger.WithFields(logrus.Fields{
+		"endpoint": c.config.IndexNowEndpoint,
+		"urls":     len(submitted),
+	}).Info("URLs submitted to IndexNow")
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus"
+)
+
+const stubBody = "<html><head><title>Stub</title></head><body>ok</body></html>"
+
+// stubTransport answers every request in memory so that benchmarks measure
+// the worker pipeline rather than the network
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(stubBody)),
+		ContentLength: int64(len(stubBody)),
+		Request:       req,
+	}, nil
+}
+
+func newBenchmarkCrawler(b *testing.B, workers int) *Crawler {
+	b.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	c, err := New(&config.Config{
+		Command:        config.CommandCrawl,
+		MaxWorkers:     workers,
+		RequestRate:    1000000,
+		RequestTimeout: 30 * time.Second,
+		UserAgent:      "SitemapCrawler/1.0",
+		Quiet:          true,
+		// Backoff reacts to timing noise and would add sleeps to the measurement
+		BackoffEnabled: false,
+	}, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+	c.client.Transport = stubTransport{}
+	return c
+}
+
+func BenchmarkStandardCrawl(b *testing.B) {
+	urls := make([]parser.URL, 1000)
+	for i := range urls {
+		urls[i] = parser.URL{Loc: fmt.Sprintf("https://example.com/pages/%d", i)}
+	}
+
+	for _, workers := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			c := newBenchmarkCrawler(b, workers)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				c.stats.Reset()
+				c.stats.SetTotalURLs(len(urls))
+				if err := c.runStandardCrawl(urls); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(urls)), "ns/url")
+		})
+	}
+}
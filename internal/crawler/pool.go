@@ -0,0 +1,168 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"golang.org/x/time/rate"
+)
+
+// workerPool runs the workers of one pass. Its size can change while the
+// pass runs: growing starts workers at once, and shrinking stops surplus
+// workers once they finish the URL in hand, so no URL is dropped. A pool of
+// size zero takes no URLs until it grows again.
+type workerPool struct {
+	crawler    *Crawler
+	ctx        context.Context
+	urlChan    <-chan parser.URL
+	resultChan chan<- *stats.Result
+	limiter    *rate.Limiter
+
+	mu      sync.Mutex
+	size    int
+	running int
+	nextID  int
+	drained bool
+	changed chan struct{}
+	done    chan struct{}
+}
+
+// startWorkerPool starts the crawler's current number of workers on
+// urlChan. resultChan is closed once urlChan is drained, or ctx is done, and
+// every worker has stopped. The pool follows setWorkers until then.
+func (c *Crawler) startWorkerPool(ctx context.Context, urlChan <-chan parser.URL, resultChan chan<- *stats.Result, limiter *rate.Limiter) *workerPool {
+	pool := &workerPool{
+		crawler:    c,
+		ctx:        ctx,
+		urlChan:    urlChan,
+		resultChan: resultChan,
+		limiter:    limiter,
+		changed:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	c.poolMu.Lock()
+	c.pool = pool
+	pool.resize(c.workers)
+	c.poolMu.Unlock()
+
+	go func() {
+		select {
+		case <-pool.done:
+		case <-ctx.Done():
+			// Workers stop on their own; a pool of size zero has none
+			pool.mu.Lock()
+			pool.finishLocked()
+			pool.mu.Unlock()
+			<-pool.done
+		}
+
+		c.poolMu.Lock()
+		if c.pool == pool {
+			c.pool = nil
+		}
+		c.poolMu.Unlock()
+		close(resultChan)
+	}()
+	return pool
+}
+
+// setWorkers sets the number of workers passes run, resizing the running
+// pass's pool if there is one
+func (c *Crawler) setWorkers(workers int) {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	c.workers = workers
+	if c.pool != nil {
+		c.pool.resize(workers)
+	}
+}
+
+// runningWorkers returns the number of workers in the running pass's pool,
+// including those finishing a URL before they stop
+func (c *Crawler) runningWorkers() int {
+	c.poolMu.Lock()
+	pool := c.pool
+	c.poolMu.Unlock()
+	if pool == nil {
+		return 0
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.running
+}
+
+// resize sets the number of workers, starting new ones and waking idle ones
+// so that surplus workers stop
+func (p *workerPool) resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.size = max(size, 0)
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	if p.drained {
+		return
+	}
+	for p.running < p.size {
+		p.running++
+		p.nextID++
+		go p.crawler.worker(p, p.nextID-1)
+	}
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// retire reports whether a worker should stop because the pool has shrunk,
+// and if so counts it as stopped
+func (p *workerPool) retire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running <= p.size {
+		return false
+	}
+	p.running--
+	p.finishLocked()
+	return true
+}
+
+// resized returns a channel closed the next time the pool is resized
+func (p *workerPool) resized() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.changed
+}
+
+// exit counts a worker as stopped. drained reports that it found urlChan
+// closed, after which the pool starts no more workers.
+func (p *workerPool) exit(drained bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running--
+	if drained {
+		p.drained = true
+	}
+	p.finishLocked()
+}
+
+// finishLocked closes done once no workers are left and none will be
+// started: urlChan is drained, ctx is done, or every worker has stopped
+// although the pool has not shrunk, which only a backoff error does
+func (p *workerPool) finishLocked() {
+	if p.running > 0 || (!p.drained && p.ctx.Err() == nil && p.size == 0) {
+		return
+	}
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+}
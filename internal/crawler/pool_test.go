@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newPoolTestCrawler(t *testing.T, workers int) *Crawler {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := New(&config.Config{
+		Command:        config.CommandCrawl,
+		MaxWorkers:     workers,
+		RequestRate:    1000000,
+		RequestTimeout: 30 * time.Second,
+		UserAgent:      "SitemapCrawler/1.0",
+		Quiet:          true,
+	}, logger)
+	require.NoError(t, err)
+	c.client.Transport = stubTransport{}
+	return c
+}
+
+func TestWorkerPoolResizes(t *testing.T) {
+	t.Parallel()
+
+	c := newPoolTestCrawler(t, 4)
+	urlChan := make(chan parser.URL)
+	resultChan := make(chan *stats.Result, 10)
+	c.startWorkerPool(context.Background(), urlChan, resultChan, rate.NewLimiter(rate.Inf, 1))
+	assert.Equal(t, 4, c.runningWorkers())
+
+	// Idle workers stop as soon as the pool shrinks, without a URL
+	c.setWorkers(0)
+	assert.Eventually(t, func() bool { return c.runningWorkers() == 0 }, time.Second, time.Millisecond)
+
+	// A paused pool takes no URLs until it grows
+	sent := make(chan struct{})
+	go func() {
+		for i := range 3 {
+			urlChan <- parser.URL{Loc: fmt.Sprintf("https://example.com/pages/%d", i)}
+		}
+		close(urlChan)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("Expected a paused pool to take no URLs")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.setWorkers(2)
+	var results []string
+	for result := range resultChan {
+		results = append(results, result.URL)
+	}
+	assert.ElementsMatch(t, []string{
+		"https://example.com/pages/0",
+		"https://example.com/pages/1",
+		"https://example.com/pages/2",
+	}, results)
+	assert.Equal(t, 0, c.runningWorkers())
+
+	// Later passes start with the size set last
+	assert.Equal(t, 2, c.workers)
+}
+
+func TestWorkerPoolStopsWhenCancelledWhilePaused(t *testing.T) {
+	t.Parallel()
+
+	c := newPoolTestCrawler(t, 2)
+	c.setWorkers(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultChan := make(chan *stats.Result)
+	c.startWorkerPool(ctx, make(chan parser.URL), resultChan, rate.NewLimiter(rate.Inf, 1))
+	cancel()
+
+	select {
+	case _, open := <-resultChan:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the results channel to close once the pass was cancelled")
+	}
+}
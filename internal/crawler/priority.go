@@ -0,0 +1,55 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// priorityLanes splits URLs into a high priority lane, matched by any of the
+// patterns, and a normal lane for the rest. The high priority lane is
+// crawled first, and is not skipped once the deadline has passed.
+type priorityLanes struct {
+	patterns []*regexp.Regexp
+}
+
+// newPriorityLanes compiles the high priority patterns
+func newPriorityLanes(patterns []string) (*priorityLanes, error) {
+	lanes := &priorityLanes{}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority pattern %q: %w", pattern, err)
+		}
+		lanes.patterns = append(lanes.patterns, compiled)
+	}
+	return lanes, nil
+}
+
+// high reports whether a URL is in the high priority lane
+func (l *priorityLanes) high(rawURL string) bool {
+	for _, pattern := range l.patterns {
+		if pattern.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipURLs records URLs skipped once the deadline passed, in the pass's
+// statistics and those of the whole run
+func (c *Crawler) skipURLs(count int) {
+	c.stats.AddSkippedURLs(count)
+	if c.aggregate != nil {
+		c.aggregate.AddSkippedURLs(count)
+	}
+}
+
+// recordCancelReason records the rule that cancelled the crawl in the
+// pass's statistics and those of the whole run
+func (c *Crawler) recordCancelReason() {
+	reason := c.backoffManager.CancelReason()
+	c.stats.SetCancelReason(reason)
+	if c.aggregate != nil {
+		c.aggregate.SetCancelReason(reason)
+	}
+}
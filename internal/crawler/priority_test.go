@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sent drains a feed, returning the URLs it sent in order
+func sent(feed *passFeed) []string {
+	urlChan := make(chan parser.URL, 100)
+	feed.send(context.Background(), urlChan)
+
+	var urls []string
+	for entry := range urlChan {
+		urls = append(urls, entry.Loc)
+	}
+	return urls
+}
+
+func TestNewPriorityLanes(t *testing.T) {
+	t.Parallel()
+
+	lanes, err := newPriorityLanes([]string{`/pricing$`, `^https://www\.example\.com/$`})
+	require.NoError(t, err)
+	assert.True(t, lanes.high("https://www.example.com/pricing"))
+	assert.True(t, lanes.high("https://www.example.com/"))
+	assert.False(t, lanes.high("https://www.example.com/blog/"))
+
+	_, err = newPriorityLanes([]string{`(`})
+	assert.ErrorContains(t, err, "invalid priority pattern")
+}
+
+func TestPassFeedSendsHighPriorityFirst(t *testing.T) {
+	t.Parallel()
+
+	lanes, err := newPriorityLanes([]string{`/pricing`, `/signup`})
+	require.NoError(t, err)
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{priority: lanes, logger: logger, stats: stats.New()}
+
+	urls := []parser.URL{
+		{Loc: "https://www.example.com/blog/a"},
+		{Loc: "https://www.example.com/signup"},
+		{Loc: "https://www.example.com/blog/b"},
+		{Loc: "https://www.example.com/pricing"},
+	}
+	assert.Equal(t, []string{
+		"https://www.example.com/signup",
+		"https://www.example.com/pricing",
+		"https://www.example.com/blog/a",
+		"https://www.example.com/blog/b",
+	}, sent(c.newPassFeed(urls)))
+
+	// The pass's URLs keep their order for later passes
+	assert.Equal(t, "https://www.example.com/blog/a", urls[0].Loc)
+}
+
+func TestPassFeedSkipsNormalPriorityAfterDeadline(t *testing.T) {
+	t.Parallel()
+
+	order, err := parseHostOrder("api.example.com>www.example.com", 1)
+	require.NoError(t, err)
+	lanes, err := newPriorityLanes([]string{`/pricing`, `/v1/menu`})
+	require.NoError(t, err)
+	logger, hook := test.NewNullLogger()
+	c := &Crawler{
+		hostOrder: order,
+		priority:  lanes,
+		logger:    logger,
+		stats:     stats.New(),
+		deadline:  time.Now().Add(-time.Minute),
+	}
+
+	// Skipped URLs count as completed, so the second group is not held
+	// back waiting for them
+	feed := c.newPassFeed([]parser.URL{
+		{Loc: "https://www.example.com/blog"},
+		{Loc: "https://www.example.com/pricing"},
+		{Loc: "https://api.example.com/v1/stores"},
+		{Loc: "https://api.example.com/v1/menu"},
+	})
+	urlChan := make(chan parser.URL, 10)
+	go feed.send(context.Background(), urlChan)
+
+	assert.Equal(t, "https://api.example.com/v1/menu", (<-urlChan).Loc)
+	feed.done("https://api.example.com/v1/menu")
+	assert.Equal(t, "https://www.example.com/pricing", (<-urlChan).Loc)
+	_, open := <-urlChan
+	assert.False(t, open)
+
+	assert.Equal(t, 2, c.stats.GetFinalStats().SkippedURLs)
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, "Deadline passed, skipping normal priority URLs", hook.LastEntry().Message)
+}
+
+func TestPassFeedSkipsNothingWithoutPriorityLanes(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{logger: logger, stats: stats.New(), deadline: time.Now().Add(-time.Minute)}
+
+	urls := sent(c.newPassFeed([]parser.URL{{Loc: "https://www.example.com/a"}, {Loc: "https://www.example.com/b"}}))
+	assert.Len(t, urls, 2)
+	assert.Zero(t, c.stats.GetFinalStats().SkippedURLs)
+}
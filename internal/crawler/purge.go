@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/purge"
+	"github.com/sirupsen/logrus"
+)
+
+// newPurger creates the purger for the configured purge mode, or nil if no
+// purge was requested. CDN purge APIs are called with apiClient; purge
+// requests to the site itself use the crawler's client.
+func (c *Crawler) newPurger(apiClient *http.Client) purge.Purger {
+	switch c.config.Purge {
+	case purge.ModeCloudflare:
+		return purge.NewCloudflarePurger(apiClient, purge.CloudflareOptions{
+			ZoneID: c.config.CloudflareZoneID,
+			Token:  c.config.CloudflareAPIToken,
+			ByTag:  len(c.config.PurgeTags) > 0,
+		})
+	case purge.ModeFastly:
+		return purge.NewFastlyPurger(apiClient, purge.FastlyOptions{
+			ServiceID: c.config.FastlyServiceID,
+			Token:     c.config.FastlyAPIToken,
+			Soft:      c.config.FastlySoftPurge,
+			ByKey:     len(c.config.PurgeTags) > 0,
+			Workers:   c.config.MaxWorkers,
+		})
+	case purge.ModeRequest:
+		return purge.NewRequestPurger(c.client, purge.RequestOptions{
+			Method:    c.config.PurgeMethod,
+			Headers:   c.config.Headers,
+			UserAgent: c.config.UserAgent,
+			Workers:   c.config.MaxWorkers,
+			Limiter:   c.newLimiter(),
+		})
+	default:
+		return nil
+	}
+}
+
+// purgeURLs invalidates every URL, or the configured cache tags, before
+// the URLs are warmed, if a purge was requested. Warming on top of a failed
+// purge would verify stale copies, so any failure stops the run.
+func (c *Crawler) purgeURLs(urls []parser.URL) error {
+	if c.purger == nil {
+		return nil
+	}
+
+	kind := "URLs"
+	targets := c.config.PurgeTags
+	if len(targets) > 0 {
+		kind = "tags"
+	} else {
+		targets = make([]string, len(urls))
+		for i, url := range urls {
+			targets[i] = url.Loc
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"targets": len(targets),
+		"kind":    kind,
+		"mode":    c.config.Purge,
+	}).Info("Purging before warming")
+
+	start := time.Now()
+	report := c.purger.Purge(context.Background(), targets)
+	for _, failure := range report.Failures {
+		c.logger.WithError(failure.Err).WithField("target", failure.Target).Warn("Purge failed")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"purged":   report.Purged,
+		"failed":   len(report.Failures),
+		"duration": time.Since(start),
+	}).Info("Purge completed")
+
+	if len(report.Failures) > 0 {
+		return fmt.Errorf("purge failed for %d of %d %s", len(report.Failures), len(targets), kind)
+	}
+
+	if c.config.PurgeWait > 0 {
+		c.logger.WithField("wait", c.config.PurgeWait).Info("Waiting for purge to propagate")
+		time.Sleep(c.config.PurgeWait)
+	}
+
+	return nil
+}
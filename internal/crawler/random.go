@@ -0,0 +1,26 @@
+package crawler
+
+import "math/rand/v2"
+
+// Streams of the run's random numbers, one per use, so that adding a use
+// leaves the numbers drawn by the others unchanged
+const (
+	randomHARSample uint64 = iota + 1
+	randomBodySample
+	randomResultSample
+	randomVariantSample
+)
+
+// newSeed returns the configured seed, or a random one when none was given
+func newSeed(configured uint64) uint64 {
+	for configured == 0 {
+		configured = rand.Uint64()
+	}
+	return configured
+}
+
+// newRandom returns the random numbers for one use, drawn from the run's
+// seed
+func (c *Crawler) newRandom(stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(c.run.Seed, stream))
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benvon/sitemap-crawler/internal/config"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/time/rate"
 )
@@ -219,3 +220,70 @@ func abs(x int) int {
 	}
 	return x
 }
+
+// TestNewLimiterBurst verifies the burst chosen for the request rate
+func TestNewLimiterBurst(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   *config.Config
+		expected int
+	}{
+		{name: "defaults to the rate", config: &config.Config{RequestRate: 50}, expected: 50},
+		{name: "explicit burst", config: &config.Config{RequestRate: 50, RequestBurst: 5}, expected: 5},
+		{name: "strict pacing", config: &config.Config{RequestRate: 50, StrictPacing: true}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := (&Crawler{config: tt.config}).newLimiter()
+			assert.Equal(t, tt.expected, limiter.Burst())
+			assert.Equal(t, rate.Limit(tt.config.RequestRate), limiter.Limit())
+		})
+	}
+}
+
+// TestStrictPacingSpacesRequests verifies that strict pacing sends no
+// burst at the start of a pass
+func TestStrictPacingSpacesRequests(t *testing.T) {
+	t.Parallel()
+
+	limiter := (&Crawler{config: &config.Config{RequestRate: 20, StrictPacing: true}}).newLimiter()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for range 5 {
+		assert.NoError(t, limiter.Wait(ctx))
+	}
+
+	// The first request goes out at once and the other four 50ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestRateRamp(t *testing.T) {
+	t.Parallel()
+
+	c := &Crawler{config: &config.Config{RequestRate: 100, RateRamp: 90 * time.Millisecond}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limiter := c.newPassLimiter(ctx)
+	assert.Equal(t, rate.Limit(10), limiter.Limit())
+	assert.Equal(t, 10, limiter.Burst())
+
+	assert.Eventually(t, func() bool {
+		return limiter.Limit() == 100 && limiter.Burst() == 100
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRateRampDisabled(t *testing.T) {
+	t.Parallel()
+
+	limiter := (&Crawler{config: &config.Config{RequestRate: 100}}).newPassLimiter(context.Background())
+	assert.Equal(t, rate.Limit(100), limiter.Limit())
+	assert.Equal(t, 100, limiter.Burst())
+}
@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// sitemapRefresh holds the latest sitemap URLs of a crawl that re-fetches
+// its sitemap, and the pass feeds to update when they change
+type sitemapRefresh struct {
+	mu    sync.Mutex
+	urls  []parser.URL
+	feeds map[*passFeed]struct{}
+}
+
+// newSitemapRefresh starts from the URLs the crawl was started with
+func newSitemapRefresh(urls []parser.URL) *sitemapRefresh {
+	return &sitemapRefresh{urls: urls, feeds: make(map[*passFeed]struct{})}
+}
+
+// current returns the latest URLs, or urls if the sitemap is not refreshed
+func (r *sitemapRefresh) current(urls []parser.URL) []parser.URL {
+	if r == nil {
+		return urls
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.urls
+}
+
+// track updates a feed on every change from now on and returns the latest
+// URLs for it to start from
+func (r *sitemapRefresh) track(feed *passFeed) []parser.URL {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.feeds[feed] = struct{}{}
+	return r.urls
+}
+
+// untrack stops updating a feed
+func (r *sitemapRefresh) untrack(feed *passFeed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.feeds, feed)
+}
+
+// update replaces the URLs with those of a fresh sitemap. Added URLs are
+// queued on the running passes, and removed ones they have not sent yet are
+// dropped. It returns the URLs added, the number removed, and how many
+// requests the running passes gained, which is negative if they lost some.
+func (r *sitemapRefresh) update(fresh []parser.URL) (added []parser.URL, removed, queued int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	known := make(map[string]bool, len(r.urls))
+	for _, entry := range r.urls {
+		known[entry.Loc] = true
+	}
+	seen := make(map[string]bool, len(fresh))
+	for _, entry := range fresh {
+		seen[entry.Loc] = true
+		if !known[entry.Loc] {
+			added = append(added, entry)
+		}
+	}
+	gone := make(map[string]bool)
+	for _, entry := range r.urls {
+		if !seen[entry.Loc] {
+			gone[entry.Loc] = true
+		}
+	}
+	if len(added) == 0 && len(gone) == 0 {
+		return nil, 0, 0
+	}
+
+	r.urls = fresh
+	for feed := range r.feeds {
+		feed.add(added)
+		queued += len(added) - feed.remove(gone)
+	}
+	return added, len(gone), queued
+}
+
+// refreshSitemap re-fetches the sitemap every interval until the returned
+// function is called, which waits for a fetch in progress to finish
+func (c *Crawler) refreshSitemap(interval time.Duration) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.refreshOnce()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// refreshOnce fetches the sitemap and applies its changes. A sitemap that
+// fails to load, or has no valid URLs, is ignored rather than emptying the
+// crawl.
+func (c *Crawler) refreshOnce() {
+	urls, err := c.parser.ParseSitemap(c.config.SitemapURL, c.config.Headers)
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to refresh sitemap, keeping the current URLs")
+		return
+	}
+	urls, _, _ = keepModified(urls, c.modifiedCutoff)
+	valid, _ := c.parser.FilterURLs(c.withDerived(urls))
+	if len(valid) == 0 {
+		c.logger.Warn("Refreshed sitemap has no valid URLs, keeping the current URLs")
+		return
+	}
+
+	added, removed, queued := c.refresh.update(valid)
+	if len(added) == 0 && removed == 0 {
+		c.logger.Debug("Sitemap unchanged")
+		return
+	}
+	c.stats.AdjustTotalURLs(queued)
+	c.trackURLs(added)
+
+	c.logger.WithFields(logrus.Fields{
+		"added":   len(added),
+		"removed": removed,
+		"total":   len(valid),
+	}).Info("Sitemap refreshed")
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// locs builds sitemap URLs from their locations
+func locs(urls ...string) []parser.URL {
+	entries := make([]parser.URL, len(urls))
+	for i, url := range urls {
+		entries[i] = parser.URL{Loc: url}
+	}
+	return entries
+}
+
+func TestSitemapRefreshUpdatesRunningPass(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{logger: logger, stats: stats.New()}
+	c.refresh = newSitemapRefresh(locs("https://example.com/a", "https://example.com/b", "https://example.com/c"))
+
+	feed := c.newPassFeed(nil)
+	added, removed, queued := c.refresh.update(locs("https://example.com/a", "https://example.com/d"))
+	assert.Equal(t, locs("https://example.com/d"), added)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, -1, queued)
+	assert.Equal(t, []string{"https://example.com/a", "https://example.com/d"}, sent(feed))
+
+	// Later passes start from the latest sitemap, and the finished pass is
+	// no longer updated
+	assert.Equal(t, locs("https://example.com/a", "https://example.com/d"), c.refresh.current(nil))
+	assert.Empty(t, c.refresh.feeds)
+}
+
+func TestSitemapRefreshUnchanged(t *testing.T) {
+	t.Parallel()
+
+	refresh := newSitemapRefresh(locs("https://example.com/a", "https://example.com/b"))
+	added, removed, queued := refresh.update(locs("https://example.com/b", "https://example.com/a"))
+	assert.Empty(t, added)
+	assert.Zero(t, removed)
+	assert.Zero(t, queued)
+
+	var none *sitemapRefresh
+	assert.Equal(t, locs("https://example.com/a"), none.current(locs("https://example.com/a")))
+}
+
+func TestPassFeedAddsToGroupsAlreadySent(t *testing.T) {
+	t.Parallel()
+
+	order, err := parseHostOrder("api.example.com>www.example.com", 1)
+	assert.NoError(t, err)
+	logger, _ := test.NewNullLogger()
+	c := &Crawler{hostOrder: order, logger: logger}
+
+	feed := c.newPassFeed(locs("https://api.example.com/a", "https://www.example.com/"))
+	urlChan := make(chan parser.URL, 10)
+	go feed.send(t.Context(), urlChan)
+
+	assert.Equal(t, "https://api.example.com/a", (<-urlChan).Loc)
+
+	// A URL added to the API group holds back the next group too
+	feed.add(locs("https://api.example.com/b"))
+	feed.done("https://api.example.com/a")
+	assert.Equal(t, "https://api.example.com/b", (<-urlChan).Loc)
+	feed.done("https://api.example.com/b")
+	assert.Equal(t, "https://www.example.com/", (<-urlChan).Loc)
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/render"
+	"github.com/sirupsen/logrus"
+)
+
+// renderPages renders the configured subset of URLs in headless Chrome and
+// writes the render report
+func (c *Crawler) renderPages(urls []parser.URL) error {
+	if !c.config.Render {
+		return nil
+	}
+
+	var pattern *regexp.Regexp
+	if c.config.RenderPattern != "" {
+		pattern = regexp.MustCompile(c.config.RenderPattern)
+	}
+
+	selected := render.Select(parser.Locations(urls), pattern, c.config.RenderLimit)
+	c.logger.WithField("urls", len(selected)).Info("Starting headless rendering")
+	if len(selected) == 0 {
+		return nil
+	}
+
+	renderer, err := render.New(render.Config{
+		ChromePath:  c.config.ChromePath,
+		UserAgent:   c.config.UserAgent,
+		Headers:     c.config.Headers,
+		Timeout:     c.config.RenderTimeout,
+		CacheHeader: c.config.CacheHeader,
+	})
+	if err != nil {
+		return err
+	}
+	defer renderer.Close()
+
+	results := make([]render.Result, 0, len(selected))
+	for _, url := range selected {
+		result := renderer.Render(url)
+		c.logger.WithFields(logrus.Fields{
+			"url":            url,
+			"render_time":    result.RenderTime,
+			"console_errors": len(result.ConsoleErrors),
+			"network_errors": len(result.NetworkErrors),
+		}).Debug("Rendered URL")
+		results = append(results, result)
+	}
+
+	return c.writeRenderReport(results)
+}
+
+// writeRenderReport writes the render report to the configured file or stdout
+func (c *Crawler) writeRenderReport(results []render.Result) error {
+	formatter := c.newFormatter(c.config.OutputFormat)
+	content := formatter.FormatRenderReport(results)
+
+	if c.config.RenderReport == "" {
+		if _, err := fmt.Fprintln(os.Stdout, content); err != nil {
+			return fmt.Errorf("failed to write render report: %w", err)
+		}
+		return nil
+	}
+
+	if err := formatter.WriteToFile(c.config.RenderReport, content); err != nil {
+		return fmt.Errorf("failed to write render report: %w", err)
+	}
+
+	c.logger.WithField("file", c.config.RenderReport).Info("Render report written")
+	return nil
+}
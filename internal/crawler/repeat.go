@@ -0,0 +1,181 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/schedule"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// iterationCache counts cache statuses seen during one iteration of a repeat
+// crawl. Warm-up requests are expected to miss and are not counted.
+type iterationCache struct {
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// add counts the cache status of a result
+func (i *iterationCache) add(phase string, result *stats.Result) {
+	if phase == stats.PhaseWarmUp || result.CacheStatus == "" {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if stats.IsCacheHit(result.CacheStatus) {
+		i.hits++
+	} else {
+		i.misses++
+	}
+}
+
+// hitRate returns the hit percentage and whether any statuses were seen
+func (i *iterationCache) hitRate() (float64, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	total := i.hits + i.misses
+	if total == 0 {
+		return 0, false
+	}
+	return float64(i.hits) / float64(total) * 100, true
+}
+
+// newScheduler creates the scheduler of the changefreq repeat schedule, or
+// returns nil when every iteration crawls every URL. URLs without a
+// changefreq are due every --repeat-interval.
+func newScheduler(cfg *config.Config) *schedule.Scheduler {
+	if cfg.RepeatSchedule != config.RepeatScheduleChangeFreq {
+		return nil
+	}
+	return schedule.New(cfg.RepeatInterval)
+}
+
+// crawlRepeatedly crawls the URL set the configured number of times. Each
+// iteration resets the crawler's statistics and logs its own summary; every
+// result is also added to an aggregate, which Stats returns afterwards so
+// that reports cover the whole run. Under the changefreq schedule an
+// iteration crawls only the URLs that are due.
+func (c *Crawler) crawlRepeatedly(urls []parser.URL) error {
+	if c.config.Repeat <= 1 {
+		return c.crawl(urls)
+	}
+
+	c.aggregate = stats.New()
+	c.aggregate.SetThrottle(c.throttle)
+	c.aggregate.SetRejectedURLs(c.stats.GetFinalStats().RejectedByReason)
+	requestsPerURL := 1
+	if c.config.CacheVerificationMode {
+		requestsPerURL *= 2
+	}
+	if len(c.devices) > 0 {
+		requestsPerURL *= len(c.devices)
+	}
+	if len(c.families) > 0 {
+		requestsPerURL *= len(c.families)
+	}
+	c.aggregate.SetTotalURLs(len(urls) * requestsPerURL * c.config.Repeat)
+
+	start := time.Now()
+	planned := 0
+	for iteration := 1; iteration <= c.config.Repeat; iteration++ {
+		if iteration > 1 {
+			c.waitForIteration(start.Add(time.Duration(iteration-1) * c.config.RepeatInterval))
+		}
+
+		due := urls
+		if c.scheduler != nil {
+			due = c.scheduler.Due(urls, time.Now())
+
+			// Only the URLs due so far are known to be crawled
+			planned += len(due) * requestsPerURL
+			c.aggregate.SetTotalURLs(planned)
+		}
+
+		fields := logrus.Fields{
+			"iteration":  iteration,
+			"iterations": c.config.Repeat,
+		}
+		if c.scheduler != nil {
+			fields["due"] = len(due)
+		}
+		if len(due) == 0 {
+			c.logger.WithFields(fields).Info("No URLs due, skipping iteration")
+			continue
+		}
+		c.logger.WithFields(fields).Info("Starting iteration")
+
+		if iteration > 1 {
+			c.stats.Reset()
+		}
+		c.iterationCache = &iterationCache{}
+
+		if err := c.crawl(due); err != nil {
+			return err
+		}
+		c.printIterationStats(iteration)
+
+		if cancelled, ok := c.backoffManager.GetStats()["cancelled"].(bool); ok && cancelled {
+			c.logger.WithField("iteration", iteration).Warn("Crawl cancelled, skipping remaining iterations")
+			break
+		}
+	}
+
+	c.iterationCache = nil
+	c.logger.WithFields(c.finalStatsFields(c.aggregate)).Info("All iterations completed")
+	return nil
+}
+
+// waitForIteration waits until an iteration is due to start under
+// --repeat-interval
+func (c *Crawler) waitForIteration(at time.Time) {
+	wait := time.Until(at)
+	if wait <= 0 {
+		return
+	}
+	c.logger.WithField("wait", wait.Round(time.Millisecond).String()).Info("Waiting for the next iteration")
+	time.Sleep(wait)
+}
+
+// addToAggregate adds a result to the aggregate statistics of a repeat crawl
+func (c *Crawler) addToAggregate(phase string, result *stats.Result) {
+	switch phase {
+	case stats.PhaseWarmUp:
+		c.aggregate.AddWarmUpResult(result)
+	case stats.PhaseVerify:
+		c.aggregate.AddCacheResult(result)
+	default:
+		c.aggregate.AddResult(result)
+	}
+}
+
+// printIterationStats logs the summary of one iteration, including the cache
+// hit rate so improvement across iterations is visible
+func (c *Crawler) printIterationStats(iteration int) {
+	finalStats := c.stats.GetFinalStats()
+
+	fields := logrus.Fields{
+		"iteration":    iteration,
+		"processed":    finalStats.TotalProcessed,
+		"errors":       finalStats.TotalErrors,
+		"success_rate": c.palette.SuccessRate(finalStats.SuccessRate),
+		"avg_duration": finalStats.AverageDuration,
+	}
+	if hitRate, ok := c.iterationCache.hitRate(); ok {
+		fields["cache_hit_rate"] = c.palette.HitRate(hitRate)
+	}
+
+	c.logger.WithFields(fields).Info("Iteration completed")
+}
+
+// recordsCacheStatus reports whether responses should record the cache
+// header. Repeat crawls record it so cache warming can be measured without
+// cache verification mode, and device matrix crawls to compare devices.
+func (c *Crawler) recordsCacheStatus() bool {
+	return c.config.CacheVerificationMode || c.config.Repeat > 1 || len(c.config.Devices) > 0
+}
@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/benvon/sitemap-crawler/internal/output"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// resultLog keeps the results for the results file. With a sampling
+// policy it keeps every failure and cache miss and a sample of the other
+// results, and counts those it leaves out.
+type resultLog struct {
+	policy *har.Policy
+
+	mu       sync.Mutex
+	entries  []output.ResultEntry
+	sampling *output.Sampling
+}
+
+// newResultLog creates a result log that keeps the given fraction of
+// successful results
+func newResultLog(sampleRate float64, random *rand.Rand) *resultLog {
+	if sampleRate >= 1 {
+		return &resultLog{}
+	}
+	return &resultLog{
+		policy:   har.NewPolicy(har.ModeSample, sampleRate, random),
+		sampling: &output.Sampling{Rate: sampleRate, Omitted: make(map[string]output.Omitted)},
+	}
+}
+
+// add records a result from a crawl phase, or counts it if the sample
+// leaves it out
+func (r *resultLog) add(phase string, result *stats.Result) {
+	hit := result.CacheStatus != "" && stats.IsCacheHit(result.CacheStatus)
+	interesting := !result.Success || (result.CacheStatus != "" && !hit)
+	keep := r.policy == nil || r.policy.ShouldRecord(interesting)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if keep {
+		r.entries = append(r.entries, output.ResultEntry{Phase: phase, Result: *result})
+		return
+	}
+	omitted := r.sampling.Omitted[phase]
+	omitted.Results++
+	if hit {
+		omitted.CacheHits++
+	}
+	r.sampling.Omitted[phase] = omitted
+}
+
+// snapshot returns the recorded entries, and the sampling if results were
+// sampled
+func (r *resultLog) snapshot() ([]output.ResultEntry, *output.Sampling) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := append([]output.ResultEntry(nil), r.entries...)
+	if r.sampling == nil {
+		return entries, nil
+	}
+	sampling := &output.Sampling{Rate: r.sampling.Rate, Omitted: make(map[string]output.Omitted, len(r.sampling.Omitted))}
+	for phase, omitted := range r.sampling.Omitted {
+		sampling.Omitted[phase] = omitted
+	}
+	return entries, sampling
+}
+
+// writeResultsFile writes every result if a results file was requested
+func (c *Crawler) writeResultsFile() error {
+	if c.results == nil {
+		return nil
+	}
+
+	// Results files are JSON, or XML when XML output was asked for, so
+	// that report diff can read them back
+	format := "json"
+	if c.config.OutputFormat == "xml" {
+		format = "xml"
+	}
+
+	entries, sampling := c.results.snapshot()
+	formatter := c.newFormatter(format)
+	if err := formatter.WriteToFile(c.config.ResultsFile, formatter.FormatSampledResults(entries, sampling)); err != nil {
+		return fmt.Errorf("failed to write results file: %w", err)
+	}
+
+	fields := logrus.Fields{
+		"file":    c.config.ResultsFile,
+		"entries": len(entries),
+	}
+	if sampling != nil {
+		omitted := 0
+		for _, phase := range sampling.Omitted {
+			omitted += phase.Results
+		}
+		fields["omitted"] = omitted
+	}
+	c.logger.WithFields(fields).Info("Results file written")
+	return nil
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/output"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/ping"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// okURLs tracks which URLs returned 200 for the cleaned sitemap and
+// IndexNow submissions
+type okURLs struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newOKURLs() *okURLs {
+	return &okURLs{seen: make(map[string]bool)}
+}
+
+// add records a result if it returned 200
+func (o *okURLs) add(result *stats.Result) {
+	if result.StatusCode != http.StatusOK {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.seen[result.URL] = true
+}
+
+// filter returns the entries that returned 200, in sitemap order
+func (o *okURLs) filter(urls []parser.URL) []parser.URL {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var ok []parser.URL
+	for _, url := range urls {
+		if o.seen[url.Loc] {
+			ok = append(ok, url)
+		}
+	}
+	return ok
+}
+
+// writeCleanSitemap writes a sitemap of the URLs that returned 200 if one was requested
+func (c *Crawler) writeCleanSitemap(urls []parser.URL) error {
+	if c.config.CleanSitemap == "" {
+		return nil
+	}
+
+	kept := c.okURLs.filter(urls)
+	formatter := c.newFormatter("xml")
+	if err := formatter.WriteToFile(c.config.CleanSitemap, formatter.FormatSitemap(kept)); err != nil {
+		return fmt.Errorf("failed to write clean sitemap: %w", err)
+	}
+
+	if len(kept) > output.MaxSitemapURLs {
+		c.logger.WithField("urls", len(kept)).Warnf("Clean sitemap exceeds the protocol limit of %d URLs; split it before submitting", output.MaxSitemapURLs)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":    c.config.CleanSitemap,
+		"kept":    len(kept),
+		"removed": len(urls) - len(kept),
+	}).Info("Clean sitemap written")
+	return nil
+}
+
+// verifyPublishedSitemap checks that the clean sitemap just written is the
+// one served at the published URL, failing the run if it never appears
+func (c *Crawler) verifyPublishedSitemap() error {
+	if c.config.VerifyPublished == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(c.config.CleanSitemap)
+	if err != nil {
+		return fmt.Errorf("failed to read clean sitemap: %w", err)
+	}
+	if err := ping.VerifyPublished(context.Background(), c.client, c.config.VerifyPublished, content, c.config.VerifyPublishedTimeout, ping.PublishedPollInterval); err != nil {
+		return fmt.Errorf("failed to verify published sitemap: %w", err)
+	}
+
+	c.logger.WithField("url", c.config.VerifyPublished).Info("Published sitemap verified")
+	return nil
+}
+
+// withDerived adds the image and video URLs and the hreflang alternates the
+// sitemap lists with its pages, when they are crawled too
+func (c *Crawler) withDerived(urls []parser.URL) []parser.URL {
+	var derived []parser.URL
+	if c.config.CrawlMedia {
+		media := parser.MediaURLs(urls)
+		c.logger.WithField("media_urls", len(media)).Debug("Sitemap media URLs added")
+		derived = append(derived, media...)
+	}
+	if c.config.CrawlAlternates {
+		// An alternate that is also an image is crawled once
+		alternates := parser.AlternateURLs(slices.Concat(urls, derived))
+		c.logger.WithField("alternate_urls", len(alternates)).Debug("Sitemap alternate URLs added")
+		derived = append(derived, alternates...)
+	}
+	return append(urls, derived...)
+}
@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// tracePhase starts a phase span on the trace and returns the function that
+// ends it
+func (c *Crawler) tracePhase(phase string) func() {
+	start := time.Now()
+	return func() {
+		c.trace.Add(trace.Span{
+			Track:    trace.TrackPhases,
+			Name:     phase,
+			Category: trace.CategoryPhase,
+			Start:    start,
+			Duration: time.Since(start),
+		})
+	}
+}
+
+// traceRequest records one attempt at a URL on its worker's track
+func (c *Crawler) traceRequest(id int, entry parser.URL, result *stats.Result, start time.Time) {
+	if c.trace == nil {
+		return
+	}
+	args := map[string]interface{}{
+		"status":  result.StatusCode,
+		"attempt": result.Attempts,
+	}
+	if result.Error != "" {
+		args["error"] = result.Error
+	}
+	if result.CacheStatus != "" {
+		args["cache_status"] = result.CacheStatus
+	}
+	c.trace.Add(trace.Span{
+		Track:    trace.WorkerTrack(id),
+		Name:     entry.Loc,
+		Category: trace.CategoryRequest,
+		Start:    start,
+		Duration: time.Since(start),
+		Args:     args,
+	})
+}
+
+// traceBackoff records a pause of every worker on the backoff track
+func (c *Crawler) traceBackoff(id int, entry parser.URL, result *stats.Result, delay time.Duration) {
+	c.trace.Add(trace.Span{
+		Track:    trace.TrackBackoff,
+		Name:     "backoff pause",
+		Category: trace.CategoryBackoff,
+		Start:    time.Now(),
+		Duration: delay,
+		Args: map[string]interface{}{
+			"worker": id,
+			"url":    entry.Loc,
+			"status": result.StatusCode,
+		},
+	})
+}
+
+// writeTraceFile writes the worker trace if one was requested
+func (c *Crawler) writeTraceFile() error {
+	if c.trace == nil {
+		return nil
+	}
+
+	data, err := c.trace.Marshal(c.run)
+	if err != nil {
+		return err
+	}
+
+	if err := c.newFormatter("json").WriteToFile(c.config.TraceFile, string(data)); err != nil {
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"file":  c.config.TraceFile,
+		"spans": c.trace.Len(),
+	}).Info("Trace file written")
+	return nil
+}
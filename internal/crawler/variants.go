@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/benvon/sitemap-crawler/internal/audit"
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/sirupsen/logrus"
+)
+
+// probeVariants probes the trailing-slash and case variants of a sample of
+// the audited pages that returned 200 and records them on their reports.
+// Probes go out at the configured request rate.
+func (c *Crawler) probeVariants() {
+	if c.audit == nil || c.config.AuditVariants <= 0 {
+		return
+	}
+
+	policy := har.NewPolicy(har.ModeSample, c.config.AuditVariants, c.newRandom(randomVariantSample))
+	var sampled []string
+	for _, report := range c.audit.Reports() {
+		if report.StatusCode == http.StatusOK && policy.ShouldRecord(false) {
+			sampled = append(sampled, report.URL)
+		}
+	}
+	c.logger.WithField("urls", len(sampled)).Info("Probing URL variants")
+
+	limiter := c.newLimiter()
+	fetch := func(url string) (*http.Response, error) {
+		if err := limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.setRequestHeaders(req)
+		// The audit client reports redirects instead of following them
+		return c.httpClient().Do(req)
+	}
+
+	for _, pageURL := range sampled {
+		variants := audit.ProbeVariants(pageURL, fetch)
+		for _, variant := range variants {
+			c.logger.WithFields(logrus.Fields{
+				"url":     variant.URL,
+				"page":    pageURL,
+				"kind":    variant.Kind,
+				"status":  variant.StatusCode,
+				"outcome": variant.Outcome,
+			}).Debug("Probed URL variant")
+		}
+		c.audit.AddVariants(pageURL, variants)
+	}
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// progressPath is where the health server reports and changes the progress
+// settings
+const progressPath = "/status/progress"
+
+// boostedProgressInterval is the longest progress interval SIGHUP switches
+// to
+const boostedProgressInterval = time.Second
+
+// progressView is the progress output in effect
+type progressView struct {
+	Interval time.Duration
+	Quiet    bool
+}
+
+// MarshalJSON writes the interval as a duration string
+func (v progressView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Interval string `json:"interval"`
+		Quiet    bool   `json:"quiet"`
+	}{v.Interval.String(), v.Quiet})
+}
+
+// progressSettings holds the progress interval and quiet mode, which
+// operators can change while a crawl runs. Each change wakes every running
+// progress reporter so a shorter interval applies at once.
+type progressSettings struct {
+	mu         sync.Mutex
+	current    progressView
+	configured progressView
+	changed    chan struct{}
+	logger     logrus.FieldLogger
+}
+
+// newProgressSettings starts out with the configured settings
+func newProgressSettings(interval time.Duration, quiet bool, logger logrus.FieldLogger) *progressSettings {
+	view := progressView{Interval: interval, Quiet: quiet}
+	return &progressSettings{current: view, configured: view, changed: make(chan struct{}), logger: logger}
+}
+
+// watch returns the settings in effect and a channel closed on their next
+// change
+func (s *progressSettings) watch() (progressView, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current, s.changed
+}
+
+// set replaces the settings in effect and wakes the reporters
+func (s *progressSettings) set(view progressView, source string) {
+	s.mu.Lock()
+	s.current = view
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"interval": view.Interval.String(),
+		"quiet":    view.Quiet,
+		"source":   source,
+	}).Info("Progress settings changed")
+}
+
+// toggle switches between the configured settings and boosted ones, which
+// print progress at least every boostedProgressInterval
+func (s *progressSettings) toggle(source string) {
+	s.mu.Lock()
+	current, configured := s.current, s.configured
+	s.mu.Unlock()
+
+	if current != configured {
+		s.set(configured, source)
+		return
+	}
+	s.set(progressView{Interval: min(configured.Interval, boostedProgressInterval)}, source)
+}
+
+// handler reports the settings in effect on GET, changes them from the
+// interval and quiet parameters on PUT, and restores the configured ones on
+// DELETE
+func (s *progressSettings) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			current, _ := s.watch()
+			view, err := parseProgressView(r, current)
+			if err != nil {
+				writeProgressJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.set(view, "api")
+		case http.MethodDelete:
+			s.mu.Lock()
+			configured := s.configured
+			s.mu.Unlock()
+			s.set(configured, "api")
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeProgressJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		current, _ := s.watch()
+		writeProgressJSON(w, http.StatusOK, current)
+	})
+}
+
+// parseProgressView applies the interval and quiet parameters of a request
+// to the settings in effect
+func parseProgressView(r *http.Request, view progressView) (progressView, error) {
+	values := r.URL.Query()
+	if values.Has("interval") {
+		interval, err := time.ParseDuration(values.Get("interval"))
+		if err != nil || interval <= 0 {
+			return progressView{}, fmt.Errorf("interval must be a positive duration such as 1s")
+		}
+		view.Interval = interval
+	}
+	if values.Has("quiet") {
+		quiet, err := strconv.ParseBool(values.Get("quiet"))
+		if err != nil {
+			return progressView{}, fmt.Errorf("quiet must be true or false")
+		}
+		view.Quiet = quiet
+	}
+	return view, nil
+}
+
+// writeProgressJSON writes a JSON response that must not be cached
+func writeProgressJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(body)
+}
+
+// watchProgressSignal toggles boosted progress output on each SIGHUP until
+// the returned function is called
+func (c *Crawler) watchProgressSignal() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-signals:
+				c.progress.toggle("signal")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
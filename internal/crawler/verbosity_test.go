@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestProgress sends a request to the progress handler and decodes the
+// response
+func requestProgress(t *testing.T, handler http.Handler, method, target string) (int, map[string]interface{}) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return recorder.Code, body
+}
+
+func TestProgressSettingsHandler(t *testing.T) {
+	t.Parallel()
+
+	logger, hook := test.NewNullLogger()
+	settings := newProgressSettings(30*time.Second, true, logger)
+	handler := settings.handler()
+
+	status, body := requestProgress(t, handler, http.MethodGet, progressPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"interval": "30s", "quiet": true}, body)
+
+	_, changed := settings.watch()
+	status, body = requestProgress(t, handler, http.MethodPut, progressPath+"?interval=2s&quiet=false")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"interval": "2s", "quiet": false}, body)
+	view, _ := settings.watch()
+	assert.Equal(t, progressView{Interval: 2 * time.Second}, view)
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected a change to wake the progress reporters")
+	}
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, "Progress settings changed", hook.LastEntry().Message)
+	assert.Equal(t, "api", hook.LastEntry().Data["source"])
+
+	status, body = requestProgress(t, handler, http.MethodPut, progressPath+"?interval=0s")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body["error"], "interval must be a positive duration")
+
+	status, body = requestProgress(t, handler, http.MethodPut, progressPath+"?quiet=maybe")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "quiet must be true or false", body["error"])
+
+	status, body = requestProgress(t, handler, http.MethodDelete, progressPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"interval": "30s", "quiet": true}, body)
+
+	status, _ = requestProgress(t, handler, http.MethodPost, progressPath)
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+}
+
+func TestProgressSettingsToggle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		quiet    bool
+		boosted  progressView
+	}{
+		{
+			name:     "quiet with a long interval",
+			interval: time.Minute,
+			quiet:    true,
+			boosted:  progressView{Interval: boostedProgressInterval},
+		},
+		{
+			name:     "interval already short",
+			interval: 500 * time.Millisecond,
+			boosted:  progressView{Interval: 500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, _ := test.NewNullLogger()
+			settings := newProgressSettings(tt.interval, tt.quiet, logger)
+
+			settings.toggle("signal")
+			view, _ := settings.watch()
+			assert.Equal(t, tt.boosted, view)
+
+			settings.toggle("signal")
+			view, _ = settings.watch()
+			assert.Equal(t, progressView{Interval: tt.interval, Quiet: tt.quiet}, view)
+		})
+	}
+}
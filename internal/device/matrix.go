@@ -0,0 +1,189 @@
+package device
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// Reasons a URL's variants are reported as different
+const (
+	DiffStatus      = "status"
+	DiffSize        = "size"
+	DiffCacheStatus = "cache_status"
+)
+
+// Variant is the response one profile received for a URL
+type Variant struct {
+	Profile     string
+	StatusCode  int
+	Size        int64
+	CacheStatus string
+	Error       string
+}
+
+// Difference is a URL whose variants disagree across profiles
+type Difference struct {
+	URL      string
+	Reasons  []string
+	Variants []Variant
+}
+
+// Summary aggregates the responses of one profile
+type Summary struct {
+	Profile      string
+	Requests     int
+	Errors       int
+	CacheHits    int
+	CacheMisses  int
+	CacheHitRate float64
+	AverageSize  int64
+}
+
+// Report is the comparison of every URL crawled with all profiles
+type Report struct {
+	Profiles    []Summary
+	Compared    int
+	Differences []Difference
+}
+
+// Collector accumulates the variants of each URL from concurrent workers
+type Collector struct {
+	mu            sync.Mutex
+	profiles      []string
+	sizeTolerance float64
+	variants      map[string]map[string]Variant
+}
+
+// NewCollector creates a collector for the named profiles. Sizes that
+// differ by more than sizeTolerance (0.1 = 10%) of the largest count as
+// different.
+func NewCollector(profiles []string, sizeTolerance float64) *Collector {
+	return &Collector{
+		profiles:      profiles,
+		sizeTolerance: sizeTolerance,
+		variants:      make(map[string]map[string]Variant),
+	}
+}
+
+// Add records the result a profile received
+func (c *Collector) Add(profile string, result *stats.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byProfile, ok := c.variants[result.URL]
+	if !ok {
+		byProfile = make(map[string]Variant, len(c.profiles))
+		c.variants[result.URL] = byProfile
+	}
+	byProfile[profile] = Variant{
+		Profile:     profile,
+		StatusCode:  result.StatusCode,
+		Size:        result.Size,
+		CacheStatus: result.CacheStatus,
+		Error:       result.Error,
+	}
+}
+
+// Report compares the URLs that every profile crawled, sorted by URL, and
+// summarizes each profile
+func (c *Collector) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &Report{Profiles: c.summariesLocked()}
+	for url, byProfile := range c.variants {
+		if len(byProfile) < len(c.profiles) {
+			continue
+		}
+		report.Compared++
+
+		variants := make([]Variant, len(c.profiles))
+		for i, profile := range c.profiles {
+			variants[i] = byProfile[profile]
+		}
+		if reasons := c.compare(variants); len(reasons) > 0 {
+			report.Differences = append(report.Differences, Difference{URL: url, Reasons: reasons, Variants: variants})
+		}
+	}
+
+	sort.Slice(report.Differences, func(i, j int) bool {
+		return report.Differences[i].URL < report.Differences[j].URL
+	})
+	return report
+}
+
+// compare returns why variants differ. Cache statuses are compared as hit
+// or miss, since the raw values often name the edge that answered.
+func (c *Collector) compare(variants []Variant) []string {
+	var reasons []string
+	first := variants[0]
+
+	minSize, maxSize := first.Size, first.Size
+	for _, variant := range variants[1:] {
+		minSize = min(minSize, variant.Size)
+		maxSize = max(maxSize, variant.Size)
+	}
+
+	for _, variant := range variants[1:] {
+		if variant.StatusCode != first.StatusCode {
+			reasons = append(reasons, DiffStatus)
+			break
+		}
+	}
+	if maxSize > 0 && float64(maxSize-minSize) > float64(maxSize)*c.sizeTolerance {
+		reasons = append(reasons, DiffSize)
+	}
+	for _, variant := range variants[1:] {
+		if (variant.CacheStatus == "") != (first.CacheStatus == "") ||
+			stats.IsCacheHit(variant.CacheStatus) != stats.IsCacheHit(first.CacheStatus) {
+			reasons = append(reasons, DiffCacheStatus)
+			break
+		}
+	}
+	return reasons
+}
+
+// summariesLocked aggregates the variants of each profile
+func (c *Collector) summariesLocked() []Summary {
+	summaries := make([]Summary, len(c.profiles))
+	totalSizes := make([]int64, len(c.profiles))
+	for i, profile := range c.profiles {
+		summaries[i].Profile = profile
+	}
+
+	for _, byProfile := range c.variants {
+		for i, profile := range c.profiles {
+			variant, ok := byProfile[profile]
+			if !ok {
+				continue
+			}
+			summary := &summaries[i]
+			summary.Requests++
+			totalSizes[i] += variant.Size
+			if variant.Error != "" || variant.StatusCode >= 400 {
+				summary.Errors++
+			}
+			if variant.CacheStatus == "" {
+				continue
+			}
+			if stats.IsCacheHit(variant.CacheStatus) {
+				summary.CacheHits++
+			} else {
+				summary.CacheMisses++
+			}
+		}
+	}
+
+	for i := range summaries {
+		summary := &summaries[i]
+		if summary.Requests > 0 {
+			summary.AverageSize = totalSizes[i] / int64(summary.Requests)
+		}
+		if checked := summary.CacheHits + summary.CacheMisses; checked > 0 {
+			summary.CacheHitRate = float64(summary.CacheHits) / float64(checked) * 100
+		}
+	}
+	return summaries
+}
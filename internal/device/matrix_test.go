@@ -0,0 +1,52 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorReport(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector([]string{"desktop", "mobile"}, 0.1)
+	add := func(profile, url string, status int, size int64, cacheStatus string) {
+		collector.Add(profile, &stats.Result{URL: url, StatusCode: status, Size: size, CacheStatus: cacheStatus})
+	}
+
+	add("desktop", "/same", 200, 1000, "HIT")
+	add("mobile", "/same", 200, 1050, "HIT, HIT")
+	add("desktop", "/status", 200, 1000, "HIT")
+	add("mobile", "/status", 404, 1000, "HIT")
+	add("desktop", "/uncached", 200, 5000, "HIT")
+	add("mobile", "/uncached", 200, 2000, "MISS")
+	add("desktop", "/desktop-only", 200, 1000, "HIT")
+
+	report := collector.Report()
+
+	assert.Equal(t, 3, report.Compared)
+	assert.Equal(t, []Difference{
+		{
+			URL:     "/status",
+			Reasons: []string{DiffStatus},
+			Variants: []Variant{
+				{Profile: "desktop", StatusCode: 200, Size: 1000, CacheStatus: "HIT"},
+				{Profile: "mobile", StatusCode: 404, Size: 1000, CacheStatus: "HIT"},
+			},
+		},
+		{
+			URL:     "/uncached",
+			Reasons: []string{DiffSize, DiffCacheStatus},
+			Variants: []Variant{
+				{Profile: "desktop", StatusCode: 200, Size: 5000, CacheStatus: "HIT"},
+				{Profile: "mobile", StatusCode: 200, Size: 2000, CacheStatus: "MISS"},
+			},
+		},
+	}, report.Differences)
+
+	assert.Equal(t, []Summary{
+		{Profile: "desktop", Requests: 4, CacheHits: 4, CacheHitRate: 100, AverageSize: 2000},
+		{Profile: "mobile", Requests: 3, Errors: 1, CacheHits: 2, CacheMisses: 1, CacheHitRate: float64(2) / 3 * 100, AverageSize: 1350},
+	}, report.Profiles)
+}
@@ -0,0 +1,108 @@
+package device
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Profile is a device to crawl as: a User-Agent and the client hint headers
+// a browser on that device sends
+type Profile struct {
+	Name      string            `yaml:"name"`
+	UserAgent string            `yaml:"user_agent"`
+	Headers   map[string]string `yaml:"headers"`
+}
+
+// Builtin profiles, named after the device class they represent
+var Builtin = map[string]Profile{
+	"desktop": {
+		Name:      "desktop",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		Headers: map[string]string{
+			"Sec-CH-UA":          `"Chromium";v="126", "Google Chrome";v="126", "Not-A.Brand";v="99"`,
+			"Sec-CH-UA-Mobile":   "?0",
+			"Sec-CH-UA-Platform": `"Windows"`,
+		},
+	},
+	"mobile": {
+		Name:      "mobile",
+		UserAgent: "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+		Headers: map[string]string{
+			"Sec-CH-UA":          `"Chromium";v="126", "Google Chrome";v="126", "Not-A.Brand";v="99"`,
+			"Sec-CH-UA-Mobile":   "?1",
+			"Sec-CH-UA-Platform": `"Android"`,
+		},
+	},
+	"tablet": {
+		Name:      "tablet",
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+	},
+}
+
+// profileFile is a YAML file of additional profiles
+type profileFile struct {
+	Profiles []Profile `yaml:"profiles"`
+}
+
+// Load reads a YAML file of profiles. Unknown keys are rejected so that
+// typos do not silently crawl with the wrong headers.
+func Load(path string) (map[string]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device profiles: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML file of profiles keyed by name
+func Parse(data []byte) (map[string]Profile, error) {
+	var file profileFile
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse device profiles: %w", err)
+	}
+
+	profiles := make(map[string]Profile, len(file.Profiles))
+	for i, profile := range file.Profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("invalid device profile %d: name is required", i+1)
+		}
+		if profile.UserAgent == "" {
+			return nil, fmt.Errorf("invalid device profile %s: user_agent is required", profile.Name)
+		}
+		if _, ok := profiles[profile.Name]; ok {
+			return nil, fmt.Errorf("duplicate device profile %s", profile.Name)
+		}
+		profiles[profile.Name] = profile
+	}
+	return profiles, nil
+}
+
+// Resolve looks up profiles by name, in custom first and then in Builtin
+func Resolve(names []string, custom map[string]Profile) ([]Profile, error) {
+	profiles := make([]Profile, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("device profile %s given more than once", name)
+		}
+		seen[name] = true
+
+		profile, ok := custom[name]
+		if !ok {
+			profile, ok = Builtin[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown device profile %q", name)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
@@ -0,0 +1,57 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		yaml     string
+		errorMsg string
+	}{
+		{name: "empty file", yaml: ""},
+		{name: "valid profile", yaml: "profiles:\n  - name: iphone\n    user_agent: Mozilla/5.0 (iPhone)\n    headers:\n      Sec-CH-UA-Mobile: \"?1\"\n"},
+		{name: "missing name", yaml: "profiles:\n  - user_agent: Mozilla/5.0\n", errorMsg: "name is required"},
+		{name: "missing user agent", yaml: "profiles:\n  - name: iphone\n", errorMsg: "user_agent is required"},
+		{name: "duplicate", yaml: "profiles:\n  - name: a\n    user_agent: x\n  - name: a\n    user_agent: y\n", errorMsg: "duplicate device profile a"},
+		{name: "unknown key", yaml: "profiles:\n  - name: a\n    useragent: x\n", errorMsg: "failed to parse device profiles"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(tt.yaml))
+			if tt.errorMsg != "" {
+				assert.ErrorContains(t, err, tt.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	custom := map[string]Profile{
+		"mobile": {Name: "mobile", UserAgent: "Custom Mobile"},
+	}
+
+	profiles, err := Resolve([]string{"desktop", "mobile"}, custom)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, Builtin["desktop"].UserAgent, profiles[0].UserAgent)
+	assert.Equal(t, "Custom Mobile", profiles[1].UserAgent, "custom profiles override built-in ones")
+
+	_, err = Resolve([]string{"watch"}, nil)
+	assert.ErrorContains(t, err, `unknown device profile "watch"`)
+
+	_, err = Resolve([]string{"desktop", "desktop"}, nil)
+	assert.ErrorContains(t, err, "given more than once")
+}
@@ -0,0 +1,221 @@
+package dualstack
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// Address families, in the order they are crawled
+const (
+	IPv4 = "ipv4"
+	IPv6 = "ipv6"
+)
+
+// Reasons a URL is reported
+const (
+	ReasonIPv4Failed = "ipv4_failed"
+	ReasonIPv6Failed = "ipv6_failed"
+	ReasonIPv4Slower = "ipv4_slower"
+	ReasonIPv6Slower = "ipv6_slower"
+)
+
+// Attempt is the outcome of requesting a URL over one family
+type Attempt struct {
+	Success    bool
+	StatusCode int
+	Duration   time.Duration
+	Error      string
+}
+
+// String describes the attempt by its error, or its status and duration
+func (a Attempt) String() string {
+	if a.Error != "" {
+		return a.Error
+	}
+	return fmt.Sprintf("%d in %s", a.StatusCode, a.Duration)
+}
+
+// Problem is a URL that failed, or was much slower, over one family only
+type Problem struct {
+	URL    string
+	Host   string
+	Reason string
+	IPv4   Attempt
+	IPv6   Attempt
+}
+
+// FamilyStats summarizes the requests to a host over one family
+type FamilyStats struct {
+	Requests        int
+	Errors          int
+	AverageDuration time.Duration
+}
+
+// HostSummary compares the families for one host
+type HostSummary struct {
+	Host     string
+	IPv4     FamilyStats
+	IPv6     FamilyStats
+	Problems int
+}
+
+// Report is the comparison of every URL requested over both families
+type Report struct {
+	Compared int
+	Hosts    []HostSummary
+	Problems []Problem
+}
+
+// Thresholds decide when one family counts as significantly slower: by
+// more than Ratio times the other family and by at least Min
+type Thresholds struct {
+	Ratio float64
+	Min   time.Duration
+}
+
+// Collector accumulates the attempts for each URL from concurrent workers
+type Collector struct {
+	mu         sync.Mutex
+	thresholds Thresholds
+	attempts   map[string]map[string]Attempt
+}
+
+// NewCollector creates a collector that reports slowdowns above thresholds
+func NewCollector(thresholds Thresholds) *Collector {
+	return &Collector{
+		thresholds: thresholds,
+		attempts:   make(map[string]map[string]Attempt),
+	}
+}
+
+// Add records the result of requesting a URL over family
+func (c *Collector) Add(family string, result *stats.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byFamily, ok := c.attempts[result.URL]
+	if !ok {
+		byFamily = make(map[string]Attempt, 2)
+		c.attempts[result.URL] = byFamily
+	}
+	byFamily[family] = Attempt{
+		Success:    result.Success,
+		StatusCode: result.StatusCode,
+		Duration:   result.Duration,
+		Error:      result.Error,
+	}
+}
+
+// hostTotals accumulates a host's attempts before averaging
+type hostTotals struct {
+	summary   HostSummary
+	durations [2]time.Duration
+}
+
+// Report compares the URLs requested over both families. Hosts are sorted
+// by name and problems by host and URL.
+func (c *Collector) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &Report{}
+	hosts := make(map[string]*hostTotals)
+	for rawURL, byFamily := range c.attempts {
+		ipv4, hasIPv4 := byFamily[IPv4]
+		ipv6, hasIPv6 := byFamily[IPv6]
+		if !hasIPv4 || !hasIPv6 {
+			continue
+		}
+		report.Compared++
+
+		host := rawURL
+		if parsed, err := url.Parse(rawURL); err == nil {
+			host = parsed.Host
+		}
+		totals, ok := hosts[host]
+		if !ok {
+			totals = &hostTotals{summary: HostSummary{Host: host}}
+			hosts[host] = totals
+		}
+		totals.add(ipv4, ipv6)
+
+		if reason := c.compare(ipv4, ipv6); reason != "" {
+			totals.summary.Problems++
+			report.Problems = append(report.Problems, Problem{URL: rawURL, Host: host, Reason: reason, IPv4: ipv4, IPv6: ipv6})
+		}
+	}
+
+	for _, totals := range hosts {
+		report.Hosts = append(report.Hosts, totals.finish())
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].Host < report.Hosts[j].Host
+	})
+	sort.Slice(report.Problems, func(i, j int) bool {
+		if report.Problems[i].Host != report.Problems[j].Host {
+			return report.Problems[i].Host < report.Problems[j].Host
+		}
+		return report.Problems[i].URL < report.Problems[j].URL
+	})
+	return report
+}
+
+// compare returns why a URL is reported, or "" if both families behaved
+// alike. URLs that fail over both families are not a family problem.
+func (c *Collector) compare(ipv4, ipv6 Attempt) string {
+	switch {
+	case !ipv4.Success && ipv6.Success:
+		return ReasonIPv4Failed
+	case ipv4.Success && !ipv6.Success:
+		return ReasonIPv6Failed
+	case !ipv4.Success:
+		return ""
+	case c.slower(ipv4.Duration, ipv6.Duration):
+		return ReasonIPv4Slower
+	case c.slower(ipv6.Duration, ipv4.Duration):
+		return ReasonIPv6Slower
+	default:
+		return ""
+	}
+}
+
+// slower reports whether duration is significantly slower than other
+func (c *Collector) slower(duration, other time.Duration) bool {
+	return duration-other >= c.thresholds.Min && float64(duration) > float64(other)*c.thresholds.Ratio
+}
+
+// add counts one URL's attempts towards the host's totals
+func (h *hostTotals) add(ipv4, ipv6 Attempt) {
+	for i, attempt := range [2]Attempt{ipv4, ipv6} {
+		family := h.family(i)
+		family.Requests++
+		if !attempt.Success {
+			family.Errors++
+		}
+		h.durations[i] += attempt.Duration
+	}
+}
+
+// family returns the stats of the family at index i, IPv4 first
+func (h *hostTotals) family(i int) *FamilyStats {
+	if i == 0 {
+		return &h.summary.IPv4
+	}
+	return &h.summary.IPv6
+}
+
+// finish averages the durations and returns the summary
+func (h *hostTotals) finish() HostSummary {
+	for i := range h.durations {
+		family := h.family(i)
+		if family.Requests > 0 {
+			family.AverageDuration = h.durations[i] / time.Duration(family.Requests)
+		}
+	}
+	return h.summary
+}
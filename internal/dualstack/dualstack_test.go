@@ -0,0 +1,67 @@
+package dualstack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorReport(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector(Thresholds{Ratio: 2, Min: 100 * time.Millisecond})
+	add := func(family, url string, success bool, duration time.Duration) {
+		result := &stats.Result{URL: url, Success: success, Duration: duration}
+		if success {
+			result.StatusCode = 200
+		} else {
+			result.Error = "connection refused"
+		}
+		collector.Add(family, result)
+	}
+
+	add(IPv4, "https://a.example.com/ok", true, 100*time.Millisecond)
+	add(IPv6, "https://a.example.com/ok", true, 150*time.Millisecond)
+	add(IPv4, "https://a.example.com/v6-down", true, 100*time.Millisecond)
+	add(IPv6, "https://a.example.com/v6-down", false, 10*time.Millisecond)
+	add(IPv4, "https://b.example.com/slow", true, 100*time.Millisecond)
+	add(IPv6, "https://b.example.com/slow", true, 900*time.Millisecond)
+	add(IPv4, "https://b.example.com/fast", true, 10*time.Millisecond)
+	add(IPv6, "https://b.example.com/fast", true, 50*time.Millisecond)
+	add(IPv4, "https://b.example.com/down", false, 0)
+	add(IPv6, "https://b.example.com/down", false, 0)
+	add(IPv4, "https://b.example.com/v4-only", true, 0)
+
+	report := collector.Report()
+
+	assert.Equal(t, 5, report.Compared)
+	assert.Equal(t, []Problem{
+		{
+			URL: "https://a.example.com/v6-down", Host: "a.example.com", Reason: ReasonIPv6Failed,
+			IPv4: Attempt{Success: true, StatusCode: 200, Duration: 100 * time.Millisecond},
+			IPv6: Attempt{Duration: 10 * time.Millisecond, Error: "connection refused"},
+		},
+		{
+			URL: "https://b.example.com/slow", Host: "b.example.com", Reason: ReasonIPv6Slower,
+			IPv4: Attempt{Success: true, StatusCode: 200, Duration: 100 * time.Millisecond},
+			IPv6: Attempt{Success: true, StatusCode: 200, Duration: 900 * time.Millisecond},
+		},
+	}, report.Problems)
+
+	assert.Equal(t, []HostSummary{
+		{
+			Host:     "a.example.com",
+			IPv4:     FamilyStats{Requests: 2, AverageDuration: 100 * time.Millisecond},
+			IPv6:     FamilyStats{Requests: 2, Errors: 1, AverageDuration: 80 * time.Millisecond},
+			Problems: 1,
+		},
+		{
+			Host:     "b.example.com",
+			IPv4:     FamilyStats{Requests: 3, Errors: 1, AverageDuration: 110 * time.Millisecond / 3},
+			IPv6:     FamilyStats{Requests: 3, Errors: 1, AverageDuration: 950 * time.Millisecond / 3},
+			Problems: 1,
+		},
+	}, report.Hosts)
+}
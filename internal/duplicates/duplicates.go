@@ -0,0 +1,303 @@
+// Package duplicates finds sitemap URLs that return the same content, which
+// usually means a canonicalization or routing bug is inflating the sitemap
+package duplicates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"sync"
+	"unicode"
+)
+
+// MaxDistance is the largest simhash distance accepted for near-identical
+// content; beyond it unrelated pages from the same template start to match
+const MaxDistance = 8
+
+// shingleWords is the number of consecutive words hashed together for the
+// simhash
+const shingleWords = 3
+
+// Fingerprint identifies a response body exactly and approximately
+type Fingerprint struct {
+	SHA256  string
+	SimHash uint64
+	Bytes   int64
+}
+
+// Hasher fingerprints a response body as it is read. It keeps up to limit
+// bytes for the simhash; the SHA-256 covers every byte written.
+type Hasher struct {
+	sum   hash.Hash
+	text  bytes.Buffer
+	limit int
+	bytes int64
+}
+
+// NewHasher creates a hasher that keeps up to limit bytes of text
+func NewHasher(limit int) *Hasher {
+	return &Hasher{sum: sha256.New(), limit: limit}
+}
+
+// Write adds body bytes to the fingerprint
+func (h *Hasher) Write(p []byte) (int, error) {
+	h.sum.Write(p)
+	h.bytes += int64(len(p))
+	if remaining := h.limit - h.text.Len(); remaining > 0 {
+		h.text.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
+
+// Fingerprint returns the fingerprint of the bytes written so far
+func (h *Hasher) Fingerprint() Fingerprint {
+	return Fingerprint{
+		SHA256:  hex.EncodeToString(h.sum.Sum(nil)),
+		SimHash: SimHash(h.text.Bytes()),
+		Bytes:   h.bytes,
+	}
+}
+
+// SimHash returns a 64-bit simhash of the words in a document, with HTML
+// markup skipped, so that documents differing in a few words have hashes a
+// few bits apart
+func SimHash(document []byte) uint64 {
+	words := textWords(document)
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	shingles := max(len(words)-shingleWords+1, 1)
+	for i := range shingles {
+		hasher := fnv.New64a()
+		for _, word := range words[i:min(i+shingleWords, len(words))] {
+			_, _ = hasher.Write([]byte(word))
+			_, _ = hasher.Write([]byte{' '})
+		}
+		sum := hasher.Sum64()
+		for bit := range 64 {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var simHash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			simHash |= 1 << bit
+		}
+	}
+	return simHash
+}
+
+// textWords splits a document into lower-case words, skipping anything
+// between < and >
+func textWords(document []byte) []string {
+	var words []string
+	var word []rune
+	inTag := false
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range string(document) {
+		switch {
+		case r == '<':
+			flush()
+			inTag = true
+		case r == '>':
+			inTag = false
+		case inTag:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// Distance returns the number of bits in which two simhashes differ
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Member is a URL in a cluster of duplicates
+type Member struct {
+	URL    string
+	SHA256 string
+	Bytes  int64
+
+	// Distance is the simhash distance from the cluster's first URL
+	Distance int
+}
+
+// Cluster is a group of distinct URLs that returned the same or nearly the
+// same content
+type Cluster struct {
+	// Identical reports that every URL returned byte-identical content
+	Identical bool
+	URLs      []Member
+}
+
+// Report lists the clusters of duplicates found in a crawl
+type Report struct {
+	Checked  int
+	Clusters []Cluster
+}
+
+// Collector accumulates fingerprints from concurrent workers
+type Collector struct {
+	mu           sync.Mutex
+	fingerprints map[string]Fingerprint
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{fingerprints: make(map[string]Fingerprint)}
+}
+
+// Add records the fingerprint of a URL's body. Empty bodies are ignored, and
+// a URL crawled more than once keeps its latest fingerprint.
+func (c *Collector) Add(url string, fingerprint Fingerprint) {
+	if fingerprint.Bytes == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fingerprints[url] = fingerprint
+}
+
+// Report groups URLs whose bodies are byte-identical or, when maxDistance is
+// above zero, whose simhashes differ in at most maxDistance bits. Clusters
+// are linked transitively, and the largest come first.
+func (c *Collector) Report(maxDistance int) *Report {
+	c.mu.Lock()
+	urls := make([]string, 0, len(c.fingerprints))
+	for url := range c.fingerprints {
+		urls = append(urls, url)
+	}
+	fingerprints := make(map[string]Fingerprint, len(c.fingerprints))
+	for url, fingerprint := range c.fingerprints {
+		fingerprints[url] = fingerprint
+	}
+	c.mu.Unlock()
+	sort.Strings(urls)
+
+	// Byte-identical bodies form one node each
+	nodes := make(map[string]int)
+	var nodeURLs [][]string
+	var nodeHashes []uint64
+	for _, url := range urls {
+		fingerprint := fingerprints[url]
+		node, ok := nodes[fingerprint.SHA256]
+		if !ok {
+			node = len(nodeURLs)
+			nodes[fingerprint.SHA256] = node
+			nodeURLs = append(nodeURLs, nil)
+			nodeHashes = append(nodeHashes, fingerprint.SimHash)
+		}
+		nodeURLs[node] = append(nodeURLs[node], url)
+	}
+
+	parents := make([]int, len(nodeURLs))
+	for node := range parents {
+		parents[node] = node
+	}
+	if maxDistance > 0 {
+		linkNearNodes(nodeHashes, maxDistance, parents)
+	}
+
+	components := make(map[int][]int)
+	for node := range nodeURLs {
+		root := find(parents, node)
+		components[root] = append(components[root], node)
+	}
+
+	report := &Report{Checked: len(urls)}
+	for _, component := range components {
+		var members []string
+		for _, node := range component {
+			members = append(members, nodeURLs[node]...)
+		}
+		if len(members) < 2 {
+			continue
+		}
+		sort.Strings(members)
+
+		first := fingerprints[members[0]]
+		cluster := Cluster{Identical: len(component) == 1}
+		for _, url := range members {
+			fingerprint := fingerprints[url]
+			cluster.URLs = append(cluster.URLs, Member{
+				URL:      url,
+				SHA256:   fingerprint.SHA256,
+				Bytes:    fingerprint.Bytes,
+				Distance: Distance(first.SimHash, fingerprint.SimHash),
+			})
+		}
+		report.Clusters = append(report.Clusters, cluster)
+	}
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i], report.Clusters[j]
+		if len(a.URLs) != len(b.URLs) {
+			return len(a.URLs) > len(b.URLs)
+		}
+		return a.URLs[0].URL < b.URLs[0].URL
+	})
+	return report
+}
+
+// linkNearNodes joins nodes whose simhashes are within maxDistance bits.
+// The hash is cut into maxDistance+1 bands: two hashes that close must agree
+// on at least one band, so only hashes sharing a band are compared.
+func linkNearNodes(hashes []uint64, maxDistance int, parents []int) {
+	bands := maxDistance + 1
+	for band := range bands {
+		start := band * 64 / bands
+		width := (band+1)*64/bands - start
+		mask := uint64(1)<<width - 1
+
+		buckets := make(map[uint64][]int)
+		for node, simHash := range hashes {
+			// Bodies without words have no simhash to compare
+			if simHash == 0 {
+				continue
+			}
+			key := simHash >> start & mask
+			buckets[key] = append(buckets[key], node)
+		}
+		for _, bucket := range buckets {
+			for i, a := range bucket {
+				for _, b := range bucket[i+1:] {
+					if Distance(hashes[a], hashes[b]) <= maxDistance {
+						parents[find(parents, a)] = find(parents, b)
+					}
+				}
+			}
+		}
+	}
+}
+
+// find returns the root of a node, compressing the path to it
+func find(parents []int, node int) int {
+	for parents[node] != node {
+		parents[node] = parents[parents[node]]
+		node = parents[node]
+	}
+	return node
+}
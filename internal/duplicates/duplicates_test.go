@@ -0,0 +1,84 @@
+package duplicates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// article renders a page from the same template with its own text
+func article(title string, paragraphs int) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<html><head><title>%s</title></head><body><nav>Home About Contact</nav>`, title)
+	for i := range paragraphs {
+		fmt.Fprintf(&builder, "<p>%s paragraph %d covers the %s story in some detail with many words.</p>", title, i, title)
+	}
+	return builder.String() + "</body></html>"
+}
+
+// fingerprint hashes a body in small writes, as the crawler reads it
+func fingerprint(body string) Fingerprint {
+	hasher := NewHasher(1024 * 1024)
+	for len(body) > 0 {
+		n := min(len(body), 100)
+		_, _ = hasher.Write([]byte(body[:n]))
+		body = body[n:]
+	}
+	return hasher.Fingerprint()
+}
+
+func TestSimHashDistance(t *testing.T) {
+	t.Parallel()
+
+	page := article("harbor", 40)
+	edited := strings.Replace(page, "paragraph 7 ", "paragraph seven ", 1)
+	other := article("mountain", 40)
+
+	assert.Equal(t, SimHash([]byte(page)), SimHash([]byte(page)))
+	assert.LessOrEqual(t, Distance(SimHash([]byte(page)), SimHash([]byte(edited))), 3)
+	assert.Greater(t, Distance(SimHash([]byte(page)), SimHash([]byte(other))), MaxDistance)
+
+	// Markup is not text
+	assert.Equal(t, SimHash([]byte("<b>same words here</b>")), SimHash([]byte(`<i class="x">same words here</i>`)))
+	assert.Zero(t, SimHash([]byte("<br/>")))
+}
+
+func TestCollectorReport(t *testing.T) {
+	t.Parallel()
+
+	page := article("harbor", 40)
+	edited := strings.Replace(page, "paragraph 7 ", "paragraph seven ", 1)
+
+	collector := NewCollector()
+	collector.Add("https://example.com/harbor", fingerprint(page))
+	collector.Add("https://example.com/harbor/", fingerprint(page))
+	collector.Add("https://example.com/harbor?ref=nav", fingerprint(edited))
+	collector.Add("https://example.com/mountain", fingerprint(article("mountain", 40)))
+	collector.Add("https://example.com/a.txt", fingerprint("same"))
+	collector.Add("https://example.com/b.txt", fingerprint("same"))
+	collector.Add("https://example.com/empty", fingerprint(""))
+
+	exact := collector.Report(0)
+	assert.Equal(t, 6, exact.Checked)
+	require.Len(t, exact.Clusters, 2)
+	for _, cluster := range exact.Clusters {
+		assert.True(t, cluster.Identical)
+		assert.Len(t, cluster.URLs, 2)
+	}
+	assert.Equal(t, "https://example.com/a.txt", exact.Clusters[0].URLs[0].URL)
+
+	near := collector.Report(3)
+	require.Len(t, near.Clusters, 2)
+	harbor := near.Clusters[0]
+	assert.False(t, harbor.Identical)
+	require.Len(t, harbor.URLs, 3)
+	assert.Equal(t, "https://example.com/harbor", harbor.URLs[0].URL)
+	assert.Zero(t, harbor.URLs[1].Distance)
+	assert.Equal(t, harbor.URLs[0].SHA256, harbor.URLs[1].SHA256)
+	assert.Equal(t, "https://example.com/harbor?ref=nav", harbor.URLs[2].URL)
+	assert.NotEqual(t, harbor.URLs[0].SHA256, harbor.URLs[2].SHA256)
+	assert.Equal(t, int64(len(page)), harbor.URLs[0].Bytes)
+}
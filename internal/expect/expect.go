@@ -0,0 +1,147 @@
+// Package expect holds per-pattern success criteria, which override the
+// usual 200-399 success window for the URLs they match
+package expect
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statusClassPattern matches a status class such as 2xx
+var statusClassPattern = regexp.MustCompile(`^[1-5]xx$`)
+
+// StatusRange is an inclusive range of status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Rule declares which status codes count as success for the URLs whose path
+// matches Pattern. A "*" in the pattern matches any run of characters,
+// including slashes, so /api/* matches every path under /api/.
+type Rule struct {
+	Pattern  string
+	Statuses []StatusRange
+
+	// spec is the statuses as given, for messages
+	spec    string
+	matcher *regexp.Regexp
+}
+
+// ParseRule parses a rule in the form pattern=statuses, where statuses is
+// a comma-separated list of status codes (410), classes (2xx) and ranges
+// (200-299), such as /api/*=2xx, /legacy/*=2xx,301 or /gone/*=410
+func ParseRule(spec string) (Rule, error) {
+	i := strings.LastIndex(spec, "=")
+	if i < 0 {
+		return Rule{}, fmt.Errorf("invalid expected status %q: expected pattern=statuses", spec)
+	}
+
+	rule := Rule{Pattern: strings.TrimSpace(spec[:i]), spec: strings.TrimSpace(spec[i+1:])}
+	if !strings.HasPrefix(rule.Pattern, "/") {
+		return Rule{}, fmt.Errorf("invalid expected status %q: pattern must be a path starting with /", spec)
+	}
+	rule.matcher = regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(rule.Pattern), `\*`, ".*") + "$")
+
+	for _, item := range strings.Split(rule.spec, ",") {
+		statuses, err := parseStatuses(strings.ToLower(strings.TrimSpace(item)))
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid expected status %q: %w", spec, err)
+		}
+		rule.Statuses = append(rule.Statuses, statuses)
+	}
+	return rule, nil
+}
+
+// parseStatuses parses a status code, class or range
+func parseStatuses(item string) (StatusRange, error) {
+	if statusClassPattern.MatchString(item) {
+		base := int(item[0]-'0') * 100
+		return StatusRange{Min: base, Max: base + 99}, nil
+	}
+
+	low, high, isRange := strings.Cut(item, "-")
+	if !isRange {
+		high = low
+	}
+	lowCode, lowErr := strconv.Atoi(low)
+	highCode, highErr := strconv.Atoi(high)
+	if lowErr != nil || highErr != nil || lowCode < 100 || highCode > 599 || lowCode > highCode {
+		return StatusRange{}, fmt.Errorf("%q must be a status code, a status class such as 2xx or a range such as 200-299", item)
+	}
+	return StatusRange{Min: lowCode, Max: highCode}, nil
+}
+
+// Allows reports whether a status code counts as success under the rule
+func (r Rule) Allows(status int) bool {
+	for _, statuses := range r.Statuses {
+		if status >= statuses.Min && status <= statuses.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// Redirects reports whether the rule expects a redirect, which must then be
+// reported rather than followed
+func (r Rule) Redirects() bool {
+	for _, statuses := range r.Statuses {
+		if statuses.Min < 400 && statuses.Max >= 300 {
+			return true
+		}
+	}
+	return false
+}
+
+// Expected describes the statuses the rule allows, e.g. "2xx,301"
+func (r Rule) Expected() string {
+	return r.spec
+}
+
+// Criteria finds the rule for a URL. A nil Criteria has no rules.
+type Criteria struct {
+	rules []Rule
+}
+
+// NewCriteria creates criteria from rules. When several rules match a URL,
+// the first one wins.
+func NewCriteria(rules []Rule) *Criteria {
+	return &Criteria{rules: rules}
+}
+
+// Match returns the first rule whose pattern matches the path of a URL
+func (c *Criteria) Match(rawURL string) (Rule, bool) {
+	if c == nil || len(c.rules) == 0 {
+		return Rule{}, false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return Rule{}, false
+	}
+	path := parsed.Path
+	if path == "" {
+		path = "/"
+	}
+	for _, rule := range c.rules {
+		if rule.matcher.MatchString(path) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Redirects reports whether any rule expects a redirect
+func (c *Criteria) Redirects() bool {
+	if c == nil {
+		return false
+	}
+	for _, rule := range c.rules {
+		if rule.Redirects() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package expect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     string
+		pattern  string
+		statuses []StatusRange
+		errorMsg string
+	}{
+		{name: "status code", spec: "/gone/*=410", pattern: "/gone/*", statuses: []StatusRange{{410, 410}}},
+		{name: "status class", spec: "/api/*=2XX", pattern: "/api/*", statuses: []StatusRange{{200, 299}}},
+		{name: "range and code", spec: "/legacy/* = 200-299, 301", pattern: "/legacy/*", statuses: []StatusRange{{200, 299}, {301, 301}}},
+		{name: "equals in pattern", spec: "/search=all=2xx", pattern: "/search=all", statuses: []StatusRange{{200, 299}}},
+		{name: "missing statuses", spec: "/gone/*", errorMsg: "expected pattern=statuses"},
+		{name: "relative pattern", spec: "gone/*=410", errorMsg: "must be a path"},
+		{name: "unknown status", spec: "/gone/*=gone", errorMsg: "must be a status code"},
+		{name: "status out of range", spec: "/gone/*=700", errorMsg: "must be a status code"},
+		{name: "reversed range", spec: "/api/*=299-200", errorMsg: "must be a status code"},
+		{name: "empty item", spec: "/api/*=2xx,", errorMsg: "must be a status code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := ParseRule(tt.spec)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.pattern, rule.Pattern)
+			assert.Equal(t, tt.statuses, rule.Statuses)
+		})
+	}
+}
+
+func TestCriteriaMatch(t *testing.T) {
+	t.Parallel()
+
+	var rules []Rule
+	for _, spec := range []string{"/api/*=2xx", "/legacy/*=2xx,301", "/gone/*=410", "/*.pdf=200"} {
+		rule, err := ParseRule(spec)
+		require.NoError(t, err)
+		rules = append(rules, rule)
+	}
+	criteria := NewCriteria(rules)
+
+	tests := []struct {
+		name    string
+		url     string
+		pattern string
+		allowed []int
+		denied  []int
+	}{
+		{name: "api", url: "https://example.com/api/v1/users?page=2", pattern: "/api/*", allowed: []int{200, 204}, denied: []int{301, 404}},
+		{name: "legacy redirect", url: "https://example.com/legacy/about", pattern: "/legacy/*", allowed: []int{200, 301}, denied: []int{302, 500}},
+		{name: "tombstone", url: "https://example.com/gone/old-product", pattern: "/gone/*", allowed: []int{410}, denied: []int{200, 404}},
+		{name: "wildcard crosses slashes", url: "https://example.com/docs/guide/setup.pdf", pattern: "/*.pdf", allowed: []int{200}, denied: []int{206}},
+		{name: "no rule", url: "https://example.com/apis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, ok := criteria.Match(tt.url)
+			require.Equal(t, tt.pattern != "", ok)
+			assert.Equal(t, tt.pattern, rule.Pattern)
+			for _, status := range tt.allowed {
+				assert.True(t, rule.Allows(status), "status %d", status)
+			}
+			for _, status := range tt.denied {
+				assert.False(t, rule.Allows(status), "status %d", status)
+			}
+		})
+	}
+
+	assert.True(t, criteria.Redirects())
+	assert.True(t, rules[1].Redirects())
+	assert.False(t, rules[0].Redirects())
+	assert.Equal(t, "2xx,301", rules[1].Expected())
+}
+
+func TestNilCriteria(t *testing.T) {
+	t.Parallel()
+
+	var criteria *Criteria
+	_, ok := criteria.Match("https://example.com/")
+	assert.False(t, ok)
+	assert.False(t, criteria.Redirects())
+	assert.False(t, NewCriteria(nil).Redirects())
+}
@@ -0,0 +1,103 @@
+// Package export hands a finished crawl's statistics to metrics sinks. A
+// sink implements Exporter and registers a factory under its name, so the
+// crawler calls every configured sink from one place in its completion path
+// instead of each sink being wired into the run.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// Built-in exporter names, as given to --export
+const (
+	JSONExporter = "json"
+)
+
+// Exporter sends a finished crawl's statistics to a sink
+type Exporter interface {
+	// Export receives the final and cache statistics, combined across
+	// passes and iterations, and every result of the run
+	Export(final stats.FinalStats, cache stats.CacheStats, results []stats.Result) error
+}
+
+// Factory creates an exporter that sends to a destination, such as a file,
+// URL or connection string, whose meaning is up to the exporter
+type Factory func(destination string) (Exporter, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		JSONExporter: newJSONExporter,
+	}
+)
+
+// Register makes an exporter available under a name. It panics if the name
+// is already registered, as two sinks answering to one name is a
+// programming error.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("export: exporter %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Names returns the registered exporter names, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the exporter registered under name for a destination
+func New(name, destination string) (Exporter, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown exporter: %s (registered: %v)", name, Names())
+	}
+	exporter, err := factory(destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", name, err)
+	}
+	return exporter, nil
+}
+
+// jsonExporter writes the statistics and results to a JSON file
+type jsonExporter struct {
+	path string
+}
+
+// newJSONExporter creates an exporter writing to the file at destination
+func newJSONExporter(destination string) (Exporter, error) {
+	if destination == "" {
+		return nil, fmt.Errorf("missing file path")
+	}
+	return &jsonExporter{path: destination}, nil
+}
+
+// Export writes the statistics and results as one JSON document
+func (e *jsonExporter) Export(final stats.FinalStats, cache stats.CacheStats, results []stats.Result) error {
+	data, err := json.MarshalIndent(struct {
+		FinalStats stats.FinalStats `json:"final_stats"`
+		CacheStats stats.CacheStats `json:"cache_stats"`
+		Results    []stats.Result   `json:"results"`
+	}{final, cache, results}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExporter keeps what it was asked to export
+type recordingExporter struct {
+	destination string
+	final       stats.FinalStats
+	results     []stats.Result
+}
+
+func (e *recordingExporter) Export(final stats.FinalStats, _ stats.CacheStats, results []stats.Result) error {
+	e.final = final
+	e.results = results
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	Register("test-recording", func(destination string) (Exporter, error) {
+		return &recordingExporter{destination: destination}, nil
+	})
+	assert.Contains(t, Names(), "test-recording")
+	assert.Panics(t, func() {
+		Register("test-recording", newJSONExporter)
+	}, "registering a name twice panics")
+
+	exporter, err := New("test-recording", "udp://127.0.0.1:8125")
+	require.NoError(t, err)
+	recording, ok := exporter.(*recordingExporter)
+	require.True(t, ok)
+	assert.Equal(t, "udp://127.0.0.1:8125", recording.destination)
+
+	require.NoError(t, exporter.Export(stats.FinalStats{TotalProcessed: 2}, stats.CacheStats{}, []stats.Result{{URL: "https://example.com/"}}))
+	assert.Equal(t, 2, recording.final.TotalProcessed)
+	assert.Len(t, recording.results, 1)
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("carrier-pigeon", "")
+	assert.ErrorContains(t, err, "unknown exporter: carrier-pigeon")
+
+	_, err = New(JSONExporter, "")
+	assert.ErrorContains(t, err, "failed to create json exporter: missing file path")
+}
+
+func TestJSONExporter(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "stats.json")
+	exporter, err := New(JSONExporter, path)
+	require.NoError(t, err)
+
+	final := stats.FinalStats{TotalProcessed: 3, TotalSuccess: 2, TotalErrors: 1}
+	cache := stats.CacheStats{CacheHits: 1, CacheMisses: 1, CacheHitRate: 50}
+	results := []stats.Result{
+		{URL: "https://example.com/a", Success: true, StatusCode: 200},
+		{URL: "https://example.com/b", StatusCode: 500},
+	}
+	require.NoError(t, exporter.Export(final, cache, results))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var exported struct {
+		FinalStats stats.FinalStats `json:"final_stats"`
+		CacheStats stats.CacheStats `json:"cache_stats"`
+		Results    []stats.Result   `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, final.TotalProcessed, exported.FinalStats.TotalProcessed)
+	assert.InDelta(t, 50, exported.CacheStats.CacheHitRate, 0.001)
+	require.Len(t, exported.Results, 2)
+	assert.Equal(t, 500, exported.Results[1].StatusCode)
+}
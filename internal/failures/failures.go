@@ -0,0 +1,94 @@
+package failures
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// PhaseCrawl names the single pass of a standard crawl; cache verification
+// uses stats.PhaseWarmUp and stats.PhaseVerify
+const PhaseCrawl = "crawl"
+
+// Reasons a URL is included in the failures file
+const (
+	ReasonFailed    = "failed"
+	ReasonCacheMiss = "cache_miss"
+)
+
+// Record is a failed request, or a cache miss during cache verification
+type Record struct {
+	URL           string
+	Phase         string
+	Reason        string
+	StatusCode    int
+	ErrorCategory stats.ErrorCategory
+	Error         string
+	Duration      time.Duration
+	CacheStatus   string
+	TraceID       string
+
+	// Start and End are when the request was sent and when it completed
+	Start time.Time
+	End   time.Time
+}
+
+// Collector accumulates failed and missed URLs from concurrent workers
+type Collector struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Add records a result from the given phase if it failed, or if it was a
+// cache miss in the verify phase. Other results are ignored.
+func (c *Collector) Add(phase string, result *stats.Result) {
+	var reason string
+	switch {
+	case !result.Success:
+		reason = ReasonFailed
+	case phase == stats.PhaseVerify && result.CacheStatus != "" && !stats.IsCacheHit(result.CacheStatus):
+		reason = ReasonCacheMiss
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = append(c.records, Record{
+		URL:           result.URL,
+		Phase:         phase,
+		Reason:        reason,
+		StatusCode:    result.StatusCode,
+		ErrorCategory: result.ErrorCategory,
+		Error:         result.Error,
+		Duration:      result.Duration,
+		CacheStatus:   result.CacheStatus,
+		TraceID:       result.TraceID,
+		Start:         result.Start,
+		End:           result.End,
+	})
+}
+
+// Records returns the collected records in the order they were added
+func (c *Collector) Records() []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records := make([]Record, len(c.records))
+	copy(records, c.records)
+	return records
+}
+
+// Len returns the number of collected records
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.records)
+}
@@ -0,0 +1,47 @@
+package failures
+
+import (
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorAdd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		phase    string
+		result   *stats.Result
+		expected string
+	}{
+		{name: "success is ignored", phase: PhaseCrawl, result: &stats.Result{Success: true, StatusCode: 200}},
+		{name: "failure is recorded", phase: PhaseCrawl, result: &stats.Result{StatusCode: 500}, expected: ReasonFailed},
+		{name: "warm-up miss is ignored", phase: stats.PhaseWarmUp, result: &stats.Result{Success: true, CacheStatus: "MISS"}},
+		{name: "verify miss is recorded", phase: stats.PhaseVerify, result: &stats.Result{Success: true, CacheStatus: "MISS"}, expected: ReasonCacheMiss},
+		{name: "verify hit is ignored", phase: stats.PhaseVerify, result: &stats.Result{Success: true, CacheStatus: "HIT"}},
+		{name: "verify without cache header is ignored", phase: stats.PhaseVerify, result: &stats.Result{Success: true}},
+		{name: "verify failure is a failure", phase: stats.PhaseVerify, result: &stats.Result{StatusCode: 503, CacheStatus: "MISS"}, expected: ReasonFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collector := NewCollector()
+			tt.result.URL = "https://example.com/page"
+			collector.Add(tt.phase, tt.result)
+
+			if tt.expected == "" {
+				assert.Equal(t, 0, collector.Len())
+				return
+			}
+			records := collector.Records()
+			assert.Len(t, records, 1)
+			assert.Equal(t, tt.expected, records[0].Reason)
+			assert.Equal(t, tt.phase, records[0].Phase)
+			assert.Equal(t, "https://example.com/page", records[0].URL)
+		})
+	}
+}
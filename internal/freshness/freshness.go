@@ -0,0 +1,119 @@
+package freshness
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// Discrepancy represents a URL whose sitemap lastmod disagrees with the
+// Last-Modified header returned by the server
+type Discrepancy struct {
+	URL            string
+	SitemapLastMod time.Time
+	HeaderLastMod  time.Time
+	ETag           string
+	Difference     time.Duration
+}
+
+// Report summarizes the lastmod comparison across a crawl
+type Report struct {
+	Checked             int
+	MissingLastModified int
+	Discrepancies       []Discrepancy
+}
+
+// Compare checks a sitemap entry against response headers. The second return
+// value is false when the entry is within tolerance or cannot be compared.
+func Compare(entry parser.URL, header http.Header, tolerance time.Duration) (Discrepancy, bool) {
+	if entry.LastMod.IsZero() {
+		return Discrepancy{}, false
+	}
+
+	headerLastMod, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return Discrepancy{}, false
+	}
+
+	difference := headerLastMod.Sub(entry.LastMod)
+	if difference < 0 {
+		difference = -difference
+	}
+	if difference <= tolerance {
+		return Discrepancy{}, false
+	}
+
+	return Discrepancy{
+		URL:            entry.Loc,
+		SitemapLastMod: entry.LastMod,
+		HeaderLastMod:  headerLastMod,
+		ETag:           header.Get("ETag"),
+		Difference:     difference,
+	}, true
+}
+
+// Collector accumulates lastmod comparisons from concurrent workers
+type Collector struct {
+	mu            sync.Mutex
+	tolerance     time.Duration
+	checked       map[string]bool
+	missingHeader map[string]bool
+	discrepancies map[string]Discrepancy
+}
+
+// NewCollector creates a collector that flags differences above tolerance
+func NewCollector(tolerance time.Duration) *Collector {
+	return &Collector{
+		tolerance:     tolerance,
+		checked:       make(map[string]bool),
+		missingHeader: make(map[string]bool),
+		discrepancies: make(map[string]Discrepancy),
+	}
+}
+
+// Check compares a sitemap entry with the response headers it produced.
+// Entries without a sitemap lastmod are ignored.
+func (c *Collector) Check(entry parser.URL, header http.Header) {
+	if entry.LastMod.IsZero() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checked[entry.Loc] = true
+	if header.Get("Last-Modified") == "" {
+		c.missingHeader[entry.Loc] = true
+		return
+	}
+
+	if discrepancy, found := Compare(entry, header, c.tolerance); found {
+		c.discrepancies[entry.Loc] = discrepancy
+	}
+}
+
+// Report returns the comparison summary with the largest discrepancies first
+func (c *Collector) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	discrepancies := make([]Discrepancy, 0, len(c.discrepancies))
+	for _, discrepancy := range c.discrepancies {
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].Difference != discrepancies[j].Difference {
+			return discrepancies[i].Difference > discrepancies[j].Difference
+		}
+		return discrepancies[i].URL < discrepancies[j].URL
+	})
+
+	return &Report{
+		Checked:             len(c.checked),
+		MissingLastModified: len(c.missingHeader),
+		Discrepancies:       discrepancies,
+	}
+}
@@ -0,0 +1,98 @@
+package freshness
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func headerWith(lastModified, etag string) http.Header {
+	header := make(http.Header)
+	if lastModified != "" {
+		header.Set("Last-Modified", lastModified)
+	}
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	return header
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	lastMod := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		entry      parser.URL
+		header     http.Header
+		wantFound  bool
+		difference time.Duration
+	}{
+		{
+			name:      "within tolerance",
+			entry:     parser.URL{Loc: "https://example.com/a", LastMod: lastMod},
+			header:    headerWith("Mon, 01 Jan 2024 12:00:00 GMT", ""),
+			wantFound: false,
+		},
+		{
+			name:       "server newer than sitemap",
+			entry:      parser.URL{Loc: "https://example.com/b", LastMod: lastMod},
+			header:     headerWith("Wed, 10 Jan 2024 00:00:00 GMT", `"abc"`),
+			wantFound:  true,
+			difference: 9 * 24 * time.Hour,
+		},
+		{
+			name:       "sitemap newer than server",
+			entry:      parser.URL{Loc: "https://example.com/c", LastMod: lastMod},
+			header:     headerWith("Fri, 29 Dec 2023 00:00:00 GMT", ""),
+			wantFound:  true,
+			difference: 3 * 24 * time.Hour,
+		},
+		{
+			name:      "missing sitemap lastmod",
+			entry:     parser.URL{Loc: "https://example.com/d"},
+			header:    headerWith("Wed, 10 Jan 2024 00:00:00 GMT", ""),
+			wantFound: false,
+		},
+		{
+			name:      "unparsable header",
+			entry:     parser.URL{Loc: "https://example.com/e", LastMod: lastMod},
+			header:    headerWith("yesterday", ""),
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			discrepancy, found := Compare(tt.entry, tt.header, 24*time.Hour)
+			assert.Equal(t, tt.wantFound, found)
+			if tt.wantFound {
+				assert.Equal(t, tt.difference, discrepancy.Difference)
+				assert.Equal(t, tt.entry.Loc, discrepancy.URL)
+			}
+		})
+	}
+}
+
+func TestCollectorReport(t *testing.T) {
+	t.Parallel()
+
+	lastMod := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	collector := NewCollector(time.Hour)
+
+	collector.Check(parser.URL{Loc: "https://example.com/small", LastMod: lastMod}, headerWith("Tue, 02 Jan 2024 00:00:00 GMT", ""))
+	collector.Check(parser.URL{Loc: "https://example.com/large", LastMod: lastMod}, headerWith("Mon, 01 Apr 2024 00:00:00 GMT", ""))
+	collector.Check(parser.URL{Loc: "https://example.com/etag-only", LastMod: lastMod}, headerWith("", `"v1"`))
+	collector.Check(parser.URL{Loc: "https://example.com/no-lastmod"}, headerWith("Tue, 02 Jan 2024 00:00:00 GMT", ""))
+
+	report := collector.Report()
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 1, report.MissingLastModified)
+	assert.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, "https://example.com/large", report.Discrepancies[0].URL)
+}
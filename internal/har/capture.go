@@ -0,0 +1,241 @@
+package har
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Capture records the timings, headers, and a size-capped body of a single
+// request/response exchange
+type Capture struct {
+	mu           sync.Mutex
+	request      *http.Request
+	maxBodyBytes int
+	body         bytes.Buffer
+	bodyBytes    int
+	truncated    bool
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// NewCapture starts capturing a request. It returns the request with an
+// httptrace attached, which must be the one sent by the client.
+func NewCapture(req *http.Request, maxBodyBytes int) (*Capture, *http.Request) {
+	capture := &Capture{
+		maxBodyBytes: maxBodyBytes,
+		start:        time.Now(),
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { capture.mark(&capture.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { capture.mark(&capture.dnsDone) },
+		ConnectStart: func(string, string) {
+			capture.mark(&capture.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			capture.mark(&capture.connectDone)
+		},
+		TLSHandshakeStart: func() { capture.mark(&capture.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			capture.mark(&capture.tlsDone)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			capture.mark(&capture.wroteRequest)
+		},
+		GotFirstResponseByte: func() { capture.mark(&capture.firstByte) },
+	}
+
+	capture.request = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return capture, capture.request
+}
+
+// mark stores the current time in the given field the first time it fires
+func (c *Capture) mark(field *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+// WrapBody tees the response body so whatever the crawler reads is also
+// captured, up to the body size cap
+func (c *Capture) WrapBody(resp *http.Response) {
+	resp.Body = &captureBody{ReadCloser: resp.Body, capture: c}
+}
+
+// write appends body bytes read by the crawler
+func (c *Capture) write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bodyBytes += len(p)
+	remaining := c.maxBodyBytes - c.body.Len()
+	if remaining <= 0 {
+		c.truncated = c.truncated || len(p) > 0
+		return
+	}
+	if len(p) > remaining {
+		p = p[:remaining]
+		c.truncated = true
+	}
+	c.body.Write(p)
+}
+
+// Entry builds the HAR entry for the exchange. resp may be nil when the
+// request failed, in which case err is recorded as the entry comment.
+func (c *Capture) Entry(resp *http.Response, err error) Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := time.Now()
+	entry := Entry{
+		StartedDateTime: c.start.Format(time.RFC3339Nano),
+		Time:            milliseconds(end.Sub(c.start)),
+		Request: Request{
+			Method:      c.request.Method,
+			URL:         c.request.URL.String(),
+			HTTPVersion: httpVersion(c.request.Proto),
+			Headers:     nameValues(c.request.Header),
+			QueryString: nameValues(c.request.URL.Query()),
+			Cookies:     make([]NameValue, 0),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: Response{
+			Headers:     make([]NameValue, 0),
+			Cookies:     make([]NameValue, 0),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: c.timings(end),
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	}
+	if resp == nil {
+		return entry
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = http.StatusText(resp.StatusCode)
+	entry.Response.HTTPVersion = httpVersion(resp.Proto)
+	entry.Response.Headers = nameValues(resp.Header)
+	entry.Response.RedirectURL = resp.Header.Get("Location")
+	entry.Response.BodySize = c.bodyBytes
+	entry.Response.Content = Content{
+		Size:     c.bodyBytes,
+		MimeType: mimeType,
+	}
+	if isTextual(mimeType) {
+		entry.Response.Content.Text = c.body.String()
+	}
+	if c.truncated {
+		entry.Response.Content.Comment = "body truncated"
+	}
+
+	return entry
+}
+
+// timings converts the traced phase timestamps into HAR timings
+func (c *Capture) timings(end time.Time) Timings {
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	if !c.dnsStart.IsZero() && !c.dnsDone.IsZero() {
+		timings.DNS = milliseconds(c.dnsDone.Sub(c.dnsStart))
+	}
+	if !c.connectStart.IsZero() && !c.connectDone.IsZero() {
+		// HAR connect time includes the TLS handshake
+		connectEnd := c.connectDone
+		if c.tlsDone.After(connectEnd) {
+			connectEnd = c.tlsDone
+		}
+		timings.Connect = milliseconds(connectEnd.Sub(c.connectStart))
+	}
+	if !c.tlsStart.IsZero() && !c.tlsDone.IsZero() {
+		timings.SSL = milliseconds(c.tlsDone.Sub(c.tlsStart))
+	}
+
+	if c.wroteRequest.IsZero() {
+		return timings
+	}
+	timings.Send = 0
+	if c.firstByte.IsZero() {
+		return timings
+	}
+	timings.Wait = milliseconds(c.firstByte.Sub(c.wroteRequest))
+	timings.Receive = milliseconds(end.Sub(c.firstByte))
+	return timings
+}
+
+// captureBody is a response body that copies reads into its capture
+type captureBody struct {
+	io.ReadCloser
+	capture *Capture
+}
+
+// Read reads from the underlying body and records the bytes read
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.capture.write(p[:n])
+	}
+	return n, err
+}
+
+// nameValues converts headers or query values into sorted HAR pairs
+func nameValues(values map[string][]string) []NameValue {
+	pairs := make([]NameValue, 0, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+// httpVersion returns the protocol string, defaulting to HTTP/1.1
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
+
+// isTextual reports whether a body with the given content type can be stored as text
+func isTextual(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", mediaType == "application/xml",
+		mediaType == "application/javascript", mediaType == "application/xhtml+xml":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,238 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+)
+
+// Recording modes select which requests are written to the HAR file
+const (
+	ModeAll      = "all"
+	ModeFailures = "failures"
+	ModeSample   = "sample"
+)
+
+const (
+	harVersion  = "1.2"
+	creatorName = "sitemap-crawler"
+)
+
+// File is the top-level HAR document
+type File struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the HAR entries and the tool that produced them
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+
+	// Run is a custom field identifying the crawl that recorded the entries
+	Run *runinfo.Run `json:"_run,omitempty"`
+}
+
+// Creator identifies the application that produced the HAR file
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry represents a single recorded request/response exchange
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// Request describes the recorded request
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	Cookies     []NameValue `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response describes the recorded response
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Cookies     []NameValue `json:"cookies"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Content holds the (possibly truncated) response body
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// NameValue is a HAR name/value pair used for headers and query parameters
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Timings holds the request phase durations in milliseconds; -1 means the
+// phase did not apply to the request
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Policy decides which exchanges are recorded
+type Policy struct {
+	mu         sync.Mutex
+	mode       string
+	sampleRate float64
+	random     *rand.Rand
+}
+
+// NewPolicy creates a recording policy. The sample rate only applies in
+// sample mode, where failures are always recorded as well.
+func NewPolicy(mode string, sampleRate float64, random *rand.Rand) *Policy {
+	return &Policy{
+		mode:       mode,
+		sampleRate: sampleRate,
+		random:     random,
+	}
+}
+
+// ShouldRecord reports whether an exchange with the given outcome is recorded
+func (p *Policy) ShouldRecord(failed bool) bool {
+	switch p.mode {
+	case ModeAll:
+		return true
+	case ModeFailures:
+		return failed
+	case ModeSample:
+		if failed {
+			return true
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.random.Float64() < p.sampleRate
+	default:
+		return false
+	}
+}
+
+// Recorder collects HAR entries from concurrent workers
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+
+	// sensitive marks the headers whose values are replaced by redacted;
+	// nil keeps every value
+	sensitive func(name string) bool
+	redacted  string
+}
+
+// NewRecorder creates a new HAR recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		entries: make([]Entry, 0),
+	}
+}
+
+// RedactHeaders replaces the values of the request and response headers
+// that sensitive reports, such as Authorization and Set-Cookie, with
+// redacted in the entries added from then on. HAR files are shared outside
+// the team, so credentials must not end up in them.
+func (r *Recorder) RedactHeaders(sensitive func(name string) bool, redacted string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sensitive = sensitive
+	r.redacted = redacted
+}
+
+// Add records an entry
+func (r *Recorder) Add(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sensitive != nil {
+		entry.Request.Headers = r.redact(entry.Request.Headers)
+		entry.Response.Headers = r.redact(entry.Response.Headers)
+	}
+	r.entries = append(r.entries, entry)
+}
+
+// redact returns headers with the values of sensitive ones replaced
+func (r *Recorder) redact(headers []NameValue) []NameValue {
+	redacted := make([]NameValue, len(headers))
+	for i, header := range headers {
+		if r.sensitive(header.Name) {
+			header.Value = r.redacted
+		}
+		redacted[i] = header
+	}
+	return redacted
+}
+
+// Len returns the number of recorded entries
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Marshal encodes the recorded entries as a HAR document, tagged with the
+// run that recorded them when run is set
+func (r *Recorder) Marshal(run *runinfo.Run) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file := File{
+		Log: Log{
+			Version: harVersion,
+			Creator: Creator{Name: creatorName, Version: creatorVersion()},
+			Entries: r.entries,
+			Run:     run,
+		},
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode HAR file: %w", err)
+	}
+	return data, nil
+}
+
+// creatorVersion returns the module version embedded in the binary, if any
+func creatorVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
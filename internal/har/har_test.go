@@ -0,0 +1,166 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyShouldRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		mode       string
+		sampleRate float64
+		failed     bool
+		expected   bool
+	}{
+		{name: "all records successes", mode: ModeAll, failed: false, expected: true},
+		{name: "failures skips successes", mode: ModeFailures, failed: false, expected: false},
+		{name: "failures records failures", mode: ModeFailures, failed: true, expected: true},
+		{name: "sample zero skips successes", mode: ModeSample, sampleRate: 0, failed: false, expected: false},
+		{name: "sample one records successes", mode: ModeSample, sampleRate: 1, failed: false, expected: true},
+		{name: "sample always records failures", mode: ModeSample, sampleRate: 0, failed: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policy := NewPolicy(tt.mode, tt.sampleRate, rand.New(rand.NewPCG(1, 2)))
+			assert.Equal(t, tt.expected, policy.ShouldRecord(tt.failed))
+		})
+	}
+}
+
+func TestCaptureEntry(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Cache", "HIT")
+		_, _ = fmt.Fprint(w, strings.Repeat("a", 100))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/page?id=7", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "test-agent")
+
+	capture, req := NewCapture(req, 10)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	capture.WrapBody(resp)
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	entry := capture.Entry(resp, nil)
+
+	assert.Equal(t, http.MethodGet, entry.Request.Method)
+	assert.Contains(t, entry.Request.Headers, NameValue{Name: "User-Agent", Value: "test-agent"})
+	assert.Equal(t, []NameValue{{Name: "id", Value: "7"}}, entry.Request.QueryString)
+	assert.Equal(t, http.StatusOK, entry.Response.Status)
+	assert.Contains(t, entry.Response.Headers, NameValue{Name: "X-Cache", Value: "HIT"})
+	assert.Equal(t, 100, entry.Response.Content.Size)
+	assert.Equal(t, strings.Repeat("a", 10), entry.Response.Content.Text)
+	assert.Equal(t, "body truncated", entry.Response.Content.Comment)
+	assert.GreaterOrEqual(t, entry.Timings.Wait, 0.0)
+	assert.GreaterOrEqual(t, entry.Timings.Connect, 0.0)
+	assert.Equal(t, -1.0, entry.Timings.SSL)
+}
+
+func TestCaptureEntryWithoutResponse(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	require.NoError(t, err)
+
+	capture, _ := NewCapture(req, 10)
+	entry := capture.Entry(nil, fmt.Errorf("connection refused"))
+
+	assert.Equal(t, "connection refused", entry.Comment)
+	assert.Equal(t, 0, entry.Response.Status)
+	assert.Equal(t, -1, entry.Response.BodySize)
+}
+
+func TestRecorderMarshal(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewRecorder()
+	recorder.Add(Entry{Request: Request{Method: http.MethodGet, URL: "https://example.com/"}})
+
+	data, err := recorder.Marshal(nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "_run")
+
+	var file File
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Equal(t, "1.2", file.Log.Version)
+	assert.Equal(t, "sitemap-crawler", file.Log.Creator.Name)
+	require.Len(t, file.Log.Entries, 1)
+	assert.Equal(t, "https://example.com/", file.Log.Entries[0].Request.URL)
+
+	run := runinfo.New("1.2.3", []string{"https://example.com/sitemap.xml"}, nil)
+	data, err = recorder.Marshal(run)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &file))
+	require.NotNil(t, file.Log.Run)
+	assert.Equal(t, run.ID, file.Log.Run.ID)
+}
+
+func TestRecorderRedactsHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc123; HttpOnly")
+		w.Header().Set("X-Cache", "HIT")
+	}))
+	defer server.Close()
+
+	sensitive := func(name string) bool {
+		return strings.Contains(strings.ToLower(name), "authorization") || strings.Contains(strings.ToLower(name), "cookie")
+	}
+	entry := func() Entry {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("User-Agent", "test-agent")
+		capture, req := NewCapture(req, 0)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return capture.Entry(resp, nil)
+	}
+
+	recorder := NewRecorder()
+	recorder.RedactHeaders(sensitive, "[REDACTED]")
+	recorder.Add(entry())
+	data, err := recorder.Marshal(nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-token")
+	assert.NotContains(t, string(data), "abc123")
+
+	var file File
+	require.NoError(t, json.Unmarshal(data, &file))
+	recorded := file.Log.Entries[0]
+	assert.Contains(t, recorded.Request.Headers, NameValue{Name: "Authorization", Value: "[REDACTED]"})
+	assert.Contains(t, recorded.Request.Headers, NameValue{Name: "User-Agent", Value: "test-agent"})
+	assert.Contains(t, recorded.Response.Headers, NameValue{Name: "Set-Cookie", Value: "[REDACTED]"})
+	assert.Contains(t, recorded.Response.Headers, NameValue{Name: "X-Cache", Value: "HIT"})
+
+	unredacted := NewRecorder()
+	unredacted.Add(entry())
+	data, err = unredacted.Marshal(nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "secret-token", "without redaction every value is kept")
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Probe paths served by Handler
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// readHeaderTimeout bounds how long a probe client may take to send headers
+const readHeaderTimeout = 5 * time.Second
+
+// Probe tracks whether the process is ready. It starts out not ready.
+type Probe struct {
+	ready  atomic.Bool
+	routes map[string]http.Handler
+}
+
+// Mount serves handler under path alongside the probes. Routes must be
+// mounted before the server starts.
+func (p *Probe) Mount(path string, handler http.Handler) {
+	if p.routes == nil {
+		p.routes = make(map[string]http.Handler)
+	}
+	p.routes[path] = handler
+}
+
+// SetReady marks the process ready or not ready
+func (p *Probe) SetReady(ready bool) {
+	p.ready.Store(ready)
+}
+
+// Ready reports whether the process is ready
+func (p *Probe) Ready() bool {
+	return p.ready.Load()
+}
+
+// Handler serves LivenessPath, which succeeds while the process can answer
+// at all, and ReadinessPath, which fails with 503 while the process is not
+// ready, along with any mounted routes
+func (p *Probe) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for path, handler := range p.routes {
+		mux.Handle(path, handler)
+	}
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, _ *http.Request) {
+		if !p.Ready() {
+			writeStatus(w, http.StatusServiceUnavailable, "not ready")
+			return
+		}
+		writeStatus(w, http.StatusOK, "ready")
+	})
+	return mux
+}
+
+// writeStatus writes a plain text probe response
+func writeStatus(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintln(w, body)
+}
+
+// Server serves a probe's endpoints in the background
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	done     chan struct{}
+}
+
+// Listen starts serving the probe's endpoints on addr
+func Listen(addr string, probe *Probe) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for health probes on %s: %w", addr, err)
+	}
+
+	s := &Server{
+		server:   &http.Server{Handler: probe.Handler(), ReadHeaderTimeout: readHeaderTimeout},
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_ = listener.Close()
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Shutdown stops the server, waiting for in-flight probes until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+	<-s.done
+	return err
+}
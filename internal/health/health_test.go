@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		ready    bool
+		path     string
+		expected int
+	}{
+		{name: "live while not ready", ready: false, path: LivenessPath, expected: http.StatusOK},
+		{name: "live while ready", ready: true, path: LivenessPath, expected: http.StatusOK},
+		{name: "not ready", ready: false, path: ReadinessPath, expected: http.StatusServiceUnavailable},
+		{name: "ready", ready: true, path: ReadinessPath, expected: http.StatusOK},
+		{name: "unknown path", ready: true, path: "/metrics", expected: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			probe := &Probe{}
+			probe.SetReady(tt.ready)
+
+			recorder := httptest.NewRecorder()
+			probe.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expected, recorder.Code)
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	probe := &Probe{}
+	server, err := Listen("127.0.0.1:0", probe)
+	require.NoError(t, err)
+
+	get := func(path string) int {
+		resp, err := http.Get("http://" + server.Addr() + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get(ReadinessPath))
+	probe.SetReady(true)
+	assert.Equal(t, http.StatusOK, get(ReadinessPath))
+	assert.Equal(t, http.StatusOK, get(LivenessPath))
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	_, err = http.Get("http://" + server.Addr() + LivenessPath)
+	assert.Error(t, err)
+}
+
+func TestMount(t *testing.T) {
+	t.Parallel()
+
+	probe := &Probe{}
+	probe.Mount("/status", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	recorder := httptest.NewRecorder()
+	probe.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	probe.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
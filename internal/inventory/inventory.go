@@ -0,0 +1,188 @@
+package inventory
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// ageBuckets are the lastmod age ranges of the report, each holding ages
+// below its limit
+var ageBuckets = []struct {
+	label string
+	limit time.Duration
+}{
+	{"under 1 day", 24 * time.Hour},
+	{"1-7 days", 7 * 24 * time.Hour},
+	{"7-30 days", 30 * 24 * time.Hour},
+	{"30-90 days", 90 * 24 * time.Hour},
+	{"90-365 days", 365 * 24 * time.Hour},
+	{"over 1 year", math.MaxInt64},
+}
+
+// Count is the number of URLs of a sitemap, host or distribution bucket
+type Count struct {
+	Name string
+	URLs int
+}
+
+// Duplicate is a URL listed more than once
+type Duplicate struct {
+	URL      string
+	Listings int
+
+	// Sitemaps lists each sitemap the URL appears in, once
+	Sitemaps []string
+}
+
+// Report describes the URLs a sitemap declares, without requesting them
+type Report struct {
+	// Listed counts every entry; UniqueURLs counts each URL once
+	Listed     int
+	UniqueURLs int
+
+	// Sitemaps counts the entries of each sitemap of URLs, in the order
+	// they were reached
+	Sitemaps []Count
+
+	// Hosts counts unique URLs per host, most URLs first
+	Hosts []Count
+
+	Duplicates []Duplicate
+
+	// LastModAge buckets unique URLs by how long ago their lastmod was.
+	// URLs without a lastmod, or with one in the future, are counted apart.
+	LastModAge     []Count
+	MissingLastMod int
+	FutureLastMod  int
+
+	// Priority buckets unique URLs by priority, rounded to one decimal,
+	// highest first. Priorities outside 0.0-1.0 are counted apart.
+	Priority        []Count
+	MissingPriority int
+	InvalidPriority int
+}
+
+// Analyze summarizes the documents of a sitemap as of now. The first listing
+// of a URL is the one whose metadata counts.
+func Analyze(documents []parser.Document, now time.Time) *Report {
+	report := &Report{
+		Sitemaps:   make([]Count, 0, len(documents)),
+		LastModAge: make([]Count, len(ageBuckets)),
+	}
+	for i, bucket := range ageBuckets {
+		report.LastModAge[i].Name = bucket.label
+	}
+
+	listings := make(map[string]*Duplicate)
+	var order []string
+	hosts := make(map[string]int)
+	priorities := make(map[string]int)
+
+	for _, document := range documents {
+		report.Sitemaps = append(report.Sitemaps, Count{Name: document.URL, URLs: len(document.Entries)})
+		report.Listed += len(document.Entries)
+
+		for _, entry := range document.Entries {
+			if listing, ok := listings[entry.Loc]; ok {
+				listing.Listings++
+				if listing.Sitemaps[len(listing.Sitemaps)-1] != document.URL {
+					listing.Sitemaps = append(listing.Sitemaps, document.URL)
+				}
+				continue
+			}
+			listings[entry.Loc] = &Duplicate{URL: entry.Loc, Listings: 1, Sitemaps: []string{document.URL}}
+			order = append(order, entry.Loc)
+
+			hosts[host(entry.Loc)]++
+			report.addLastMod(entry.LastMod, now)
+			if label, ok := report.priorityLabel(entry); ok {
+				priorities[label]++
+			}
+		}
+	}
+
+	report.UniqueURLs = len(order)
+	for _, loc := range order {
+		if listing := listings[loc]; listing.Listings > 1 {
+			report.Duplicates = append(report.Duplicates, *listing)
+		}
+	}
+
+	report.Hosts = sortedCounts(hosts, func(a, b Count) bool {
+		if a.URLs != b.URLs {
+			return a.URLs > b.URLs
+		}
+		return a.Name < b.Name
+	})
+	report.Priority = sortedCounts(priorities, func(a, b Count) bool {
+		return a.Name > b.Name
+	})
+
+	return report
+}
+
+// DuplicateListings returns how many entries repeat a URL listed before
+func (r *Report) DuplicateListings() int {
+	return r.Listed - r.UniqueURLs
+}
+
+// addLastMod counts a lastmod in its age bucket
+func (r *Report) addLastMod(lastMod, now time.Time) {
+	if lastMod.IsZero() {
+		r.MissingLastMod++
+		return
+	}
+
+	age := now.Sub(lastMod)
+	if age < 0 {
+		r.FutureLastMod++
+		return
+	}
+	for i, bucket := range ageBuckets {
+		if age < bucket.limit {
+			r.LastModAge[i].URLs++
+			return
+		}
+	}
+}
+
+// priorityLabel returns the bucket of an entry's priority, counting missing
+// and invalid priorities instead
+func (r *Report) priorityLabel(entry parser.URL) (string, bool) {
+	priority := entry.Priority
+	switch {
+	case !entry.HasPriority:
+		r.MissingPriority++
+		return "", false
+	case priority < 0 || priority > 1:
+		r.InvalidPriority++
+		return "", false
+	}
+	return fmt.Sprintf("%.1f", priority), true
+}
+
+// host returns the host of a URL, or the URL itself if it cannot be parsed
+func host(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	return parsed.Host
+}
+
+// sortedCounts turns a map of counts into a slice sorted by less
+func sortedCounts(counts map[string]int, less func(a, b Count) bool) []Count {
+	result := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, Count{Name: name, URLs: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+	return result
+}
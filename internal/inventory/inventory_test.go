@@ -0,0 +1,84 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	documents := []parser.Document{
+		{
+			URL: "https://example.com/pages.xml",
+			Entries: []parser.URL{
+				{Loc: "https://example.com/a", LastMod: now.Add(-2 * time.Hour), Priority: 1, HasPriority: true},
+				{Loc: "https://example.com/b", LastMod: now.Add(-10 * 24 * time.Hour), Priority: 0.5, HasPriority: true},
+				{Loc: "https://example.com/a", Priority: 0.1, HasPriority: true},
+				{Loc: "https://cdn.example.com/c", LastMod: now.Add(24 * time.Hour), Priority: 1.5, HasPriority: true},
+			},
+		},
+		{
+			URL: "https://example.com/posts.xml",
+			Entries: []parser.URL{
+				{Loc: "https://example.com/b"},
+				{Loc: "https://example.com/d", LastMod: now.Add(-400 * 24 * time.Hour), Priority: 0.52, HasPriority: true},
+			},
+		},
+	}
+
+	report := Analyze(documents, now)
+
+	assert.Equal(t, 6, report.Listed)
+	assert.Equal(t, 4, report.UniqueURLs)
+	assert.Equal(t, 2, report.DuplicateListings())
+	assert.Equal(t, []Count{
+		{Name: "https://example.com/pages.xml", URLs: 4},
+		{Name: "https://example.com/posts.xml", URLs: 2},
+	}, report.Sitemaps)
+	assert.Equal(t, []Count{
+		{Name: "example.com", URLs: 3},
+		{Name: "cdn.example.com", URLs: 1},
+	}, report.Hosts)
+
+	assert.Equal(t, []Duplicate{
+		{URL: "https://example.com/a", Listings: 2, Sitemaps: []string{"https://example.com/pages.xml"}},
+		{URL: "https://example.com/b", Listings: 2, Sitemaps: []string{"https://example.com/pages.xml", "https://example.com/posts.xml"}},
+	}, report.Duplicates)
+
+	assert.Equal(t, []Count{
+		{Name: "under 1 day", URLs: 1},
+		{Name: "1-7 days"},
+		{Name: "7-30 days", URLs: 1},
+		{Name: "30-90 days"},
+		{Name: "90-365 days"},
+		{Name: "over 1 year", URLs: 1},
+	}, report.LastModAge)
+	assert.Equal(t, 0, report.MissingLastMod)
+	assert.Equal(t, 1, report.FutureLastMod)
+
+	assert.Equal(t, []Count{
+		{Name: "1.0", URLs: 1},
+		{Name: "0.5", URLs: 2},
+	}, report.Priority)
+	assert.Equal(t, 0, report.MissingPriority)
+	assert.Equal(t, 1, report.InvalidPriority)
+}
+
+func TestAnalyzeMissingMetadata(t *testing.T) {
+	t.Parallel()
+
+	report := Analyze([]parser.Document{{
+		URL:     "https://example.com/sitemap.txt",
+		Entries: []parser.URL{{Loc: "https://example.com/a"}, {Loc: "https://example.com/b"}},
+	}}, time.Now())
+
+	assert.Equal(t, 2, report.MissingLastMod)
+	assert.Equal(t, 2, report.MissingPriority)
+	assert.Empty(t, report.Priority)
+	assert.Empty(t, report.Duplicates)
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewSlog creates a logger that writes every entry through a log/slog
+// handler, so programs embedding the crawler can send its logs to their own
+// logging stack. Levels the handler does not enable are dropped; logrus
+// trace maps to slog debug and fatal and panic to slog error.
+func NewSlog(handler slog.Handler) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(&slogHook{handler: handler})
+	return logger
+}
+
+// slogHook forwards logrus entries to a slog handler
+type slogHook struct {
+	handler slog.Handler
+}
+
+// Levels returns every level; the handler decides what to keep
+func (h *slogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire converts an entry to a slog record and hands it to the handler
+func (h *slogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	for _, key := range slices.Sorted(maps.Keys(entry.Data)) {
+		value := entry.Data[key]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		record.AddAttrs(slog.Any(key, value))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// slogLevel maps a logrus level to the nearest slog level
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlog(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	logger := NewSlog(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	logger.Debug("Dropped below the handler level")
+	logger.WithField("url", "https://example.com/").WithError(errors.New("timeout")).Warn("Request failed")
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "Request failed", record["msg"])
+	assert.Equal(t, "https://example.com/", record["url"])
+	assert.Equal(t, "timeout", record["error"])
+}
+
+func TestSlogLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		level    string
+		expected slog.Level
+	}{
+		{name: "trace", level: "trace", expected: slog.LevelDebug},
+		{name: "debug", level: "debug", expected: slog.LevelDebug},
+		{name: "info", level: "info", expected: slog.LevelInfo},
+		{name: "warning", level: "warning", expected: slog.LevelWarn},
+		{name: "error", level: "error", expected: slog.LevelError},
+		{name: "fatal", level: "fatal", expected: slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			level, err := logrus.ParseLevel(tt.level)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, slogLevel(level))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/audit"
+)
+
+// FormatAuditReport formats the consolidated per-URL SEO audit report
+func (f *Formatter) FormatAuditReport(reports []audit.PageReport) string {
+	switch f.format {
+	case "json":
+		return f.formatAuditReportJSON(reports)
+	case "csv":
+		return f.formatAuditReportCSV(reports)
+	default:
+		return f.runHeader() + f.formatAuditReportText(reports)
+	}
+}
+
+// formatAuditReportText formats the audit report as text, listing only URLs with issues
+func (f *Formatter) formatAuditReportText(reports []audit.PageReport) string {
+	var builder strings.Builder
+	indexable := 0
+	for _, report := range reports {
+		if report.Indexable {
+			indexable++
+		}
+	}
+
+	fmt.Fprintf(&builder, "\nSEO Audit Report:\n================\nURLs Audited:     %d\nIndexable:        %d\nWith Issues:      %d\n",
+		len(reports), indexable, len(reports)-indexable)
+
+	for _, report := range reports {
+		if report.Indexable {
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s [%d]\n  Issues: %s\n", report.URL, report.StatusCode, strings.Join(report.Issues, ", "))
+		if report.RedirectTarget != "" {
+			fmt.Fprintf(&builder, "  Redirects To: %s\n", report.RedirectTarget)
+		}
+		if report.Canonical != "" {
+			fmt.Fprintf(&builder, "  Canonical:    %s\n", report.Canonical)
+		}
+	}
+
+	formatAuditVariantsText(&builder, reports)
+	return builder.String()
+}
+
+// formatAuditVariantsText lists the probed URL variants that neither
+// redirect nor return 404, if any URLs were probed
+func formatAuditVariantsText(builder *strings.Builder, reports []audit.PageReport) {
+	probed, duplicates := 0, 0
+	for _, report := range reports {
+		for _, variant := range report.Variants {
+			probed++
+			if variant.Outcome == audit.OutcomeDuplicate {
+				duplicates++
+			}
+		}
+	}
+	if probed == 0 {
+		return
+	}
+
+	fmt.Fprintf(builder, "\nURL Variants:\n=============\nVariants Probed:  %d\nDuplicates:       %d\n", probed, duplicates)
+	for _, report := range reports {
+		for _, variant := range report.Variants {
+			if variant.Outcome == audit.OutcomeRedirect || variant.Outcome == audit.OutcomeNotFound {
+				continue
+			}
+			fmt.Fprintf(builder, "\n%s [%d]\n  Variant Of: %s\n  Kind:       %s\n  Outcome:    %s\n",
+				variant.URL, variant.StatusCode, report.URL, variant.Kind, variant.Outcome)
+		}
+	}
+}
+
+// formatAuditReportJSON formats the audit report as JSON
+func (f *Formatter) formatAuditReportJSON(reports []audit.PageReport) string {
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"pages":     reports,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatAuditReportCSV formats the audit report as CSV with one row per URL
+func (f *Formatter) formatAuditReportCSV(reports []audit.PageReport) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"status_code",
+		"indexable",
+		"redirect_target",
+		"canonical",
+		"noindex",
+		"soft_404",
+		"issues",
+		"variants",
+	}); err != nil {
+		return ""
+	}
+
+	for _, report := range reports {
+		if err := writer.Write([]string{
+			report.URL,
+			strconv.Itoa(report.StatusCode),
+			strconv.FormatBool(report.Indexable),
+			report.RedirectTarget,
+			report.Canonical,
+			strconv.FormatBool(report.Noindex),
+			strconv.FormatBool(report.SoftNotFound),
+			strings.Join(report.Issues, ";"),
+			formatVariantOutcomes(report.Variants),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
+
+// formatVariantOutcomes joins the kind and outcome of each probed variant
+// into one CSV field, e.g. trailing_slash:redirect;case:duplicate
+func formatVariantOutcomes(variants []audit.VariantReport) string {
+	outcomes := make([]string, len(variants))
+	for i, variant := range variants {
+		outcomes[i] = variant.Kind + ":" + variant.Outcome
+	}
+	return strings.Join(outcomes, ";")
+}
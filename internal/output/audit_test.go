@@ -0,0 +1,63 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/audit"
+)
+
+func TestFormatAuditReport(t *testing.T) {
+	t.Parallel()
+
+	reports := []audit.PageReport{
+		{URL: "https://example.com/ok", StatusCode: 200, Indexable: true, Issues: []string{}},
+		{URL: "https://example.com/old", StatusCode: 301, RedirectTarget: "https://example.com/new", Issues: []string{audit.IssueRedirect}},
+		{URL: "https://example.com/page", StatusCode: 200, Indexable: true, Issues: []string{}, Variants: []audit.VariantReport{
+			{Kind: audit.VariantTrailingSlash, URL: "https://example.com/page/", StatusCode: 301, RedirectTarget: "https://example.com/page", Outcome: audit.OutcomeRedirect},
+			{Kind: audit.VariantCase, URL: "https://example.com/PAGE", StatusCode: 200, Outcome: audit.OutcomeDuplicate},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format lists only problems",
+			format:   "text",
+			expected: []string{"URLs Audited:     3", "https://example.com/old [301]", "Redirects To: https://example.com/new", "Duplicates:       1", "https://example.com/PAGE [200]\n  Variant Of: https://example.com/page"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"redirect_target": "https://example.com/new"`, `"indexable": true`, `"outcome": "duplicate"`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"url,status_code,indexable", "https://example.com/old,301,false,https://example.com/new,,false,false,redirect,", "trailing_slash:redirect;case:duplicate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatAuditReport(reports)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+
+	if strings.Contains(New("text").FormatAuditReport(reports), "https://example.com/ok [") {
+		t.Error("Expected text report to omit indexable URLs")
+	}
+	if strings.Contains(New("text").FormatAuditReport(reports), "https://example.com/page/ [") {
+		t.Error("Expected text report to omit variants that redirect")
+	}
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// BadgeLabel is the left-hand text of the status badge
+const BadgeLabel = "sitemap crawl"
+
+// Badge colors, ordered from best to worst
+const (
+	badgeGreen  = "brightgreen"
+	badgeYellow = "yellow"
+	badgeRed    = "red"
+	badgeGrey   = "lightgrey"
+)
+
+// Badge is a shields.io endpoint badge, see https://shields.io/badges/endpoint-badge
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// NewBadge summarizes a crawl as a badge. The message shows the success
+// rate, and the cache hit rate when cache is set; the color is the worse of
+// the two by the rate thresholds.
+func NewBadge(finalStats *stats.FinalStats, cacheStats *stats.CacheStats) Badge {
+	badge := Badge{SchemaVersion: 1, Label: BadgeLabel}
+	if finalStats == nil || finalStats.TotalProcessed == 0 {
+		badge.Message = "no URLs"
+		badge.Color = badgeGrey
+		return badge
+	}
+
+	badge.Message = fmt.Sprintf("%.1f%% ok", finalStats.SuccessRate)
+	badge.Color = badgeColor(finalStats.SuccessRate, SuccessRateGood, SuccessRateWarn)
+
+	if cacheStats != nil && cacheStats.CacheHits+cacheStats.CacheMisses > 0 {
+		badge.Message += fmt.Sprintf(", %.1f%% hit", cacheStats.CacheHitRate)
+		if color := badgeColor(cacheStats.CacheHitRate, HitRateGood, HitRateWarn); worseBadgeColor(color, badge.Color) {
+			badge.Color = color
+		}
+	}
+
+	return badge
+}
+
+// FormatBadge formats a badge as shields.io endpoint JSON
+func (f *Formatter) FormatBadge(badge Badge) string {
+	jsonData, _ := json.MarshalIndent(badge, "", "  ")
+	return string(jsonData)
+}
+
+// badgeColor picks the badge color of a rate by its thresholds
+func badgeColor(rate, good, warn float64) string {
+	switch {
+	case rate >= good:
+		return badgeGreen
+	case rate >= warn:
+		return badgeYellow
+	default:
+		return badgeRed
+	}
+}
+
+// worseBadgeColor reports whether color a is worse than color b
+func worseBadgeColor(a, b string) bool {
+	rank := map[string]int{badgeGreen: 0, badgeYellow: 1, badgeRed: 2}
+	return rank[a] > rank[b]
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestNewBadge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		finalStats      *stats.FinalStats
+		cacheStats      *stats.CacheStats
+		expectedMessage string
+		expectedColor   string
+	}{
+		{
+			name:            "no URLs",
+			finalStats:      &stats.FinalStats{},
+			expectedMessage: "no URLs",
+			expectedColor:   "lightgrey",
+		},
+		{
+			name:            "healthy crawl",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 100},
+			expectedMessage: "100.0% ok",
+			expectedColor:   "brightgreen",
+		},
+		{
+			name:            "success rate below target",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 97},
+			expectedMessage: "97.0% ok",
+			expectedColor:   "yellow",
+		},
+		{
+			name:            "success rate below threshold",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 50},
+			expectedMessage: "50.0% ok",
+			expectedColor:   "red",
+		},
+		{
+			name:            "cache hit rate is worse",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 100},
+			cacheStats:      &stats.CacheStats{CacheHits: 60, CacheMisses: 40, CacheHitRate: 60},
+			expectedMessage: "100.0% ok, 60.0% hit",
+			expectedColor:   "red",
+		},
+		{
+			name:            "success rate is worse",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 97},
+			cacheStats:      &stats.CacheStats{CacheHits: 95, CacheMisses: 5, CacheHitRate: 95},
+			expectedMessage: "97.0% ok, 95.0% hit",
+			expectedColor:   "yellow",
+		},
+		{
+			name:            "no cache headers seen",
+			finalStats:      &stats.FinalStats{TotalProcessed: 100, SuccessRate: 100},
+			cacheStats:      &stats.CacheStats{},
+			expectedMessage: "100.0% ok",
+			expectedColor:   "brightgreen",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			badge := NewBadge(tt.finalStats, tt.cacheStats)
+
+			if badge.SchemaVersion != 1 || badge.Label != BadgeLabel {
+				t.Errorf("Unexpected badge header: %+v", badge)
+			}
+			if badge.Message != tt.expectedMessage {
+				t.Errorf("Expected message '%s', got '%s'", tt.expectedMessage, badge.Message)
+			}
+			if badge.Color != tt.expectedColor {
+				t.Errorf("Expected color '%s', got '%s'", tt.expectedColor, badge.Color)
+			}
+		})
+	}
+}
+
+func TestFormatBadge(t *testing.T) {
+	t.Parallel()
+
+	result := New("text").FormatBadge(Badge{SchemaVersion: 1, Label: BadgeLabel, Message: "99.0% ok", Color: "brightgreen"})
+	for _, expected := range []string{`"schemaVersion": 1`, `"label": "sitemap crawl"`, `"message": "99.0% ok"`, `"color": "brightgreen"`} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Color modes accepted by --color
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Rate thresholds: rates at or above the first value are green, at or above
+// the second yellow, and anything lower red
+const (
+	SuccessRateGood = 99.0
+	SuccessRateWarn = 95.0
+	HitRateGood     = 90.0
+	HitRateWarn     = 70.0
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// ColorEnabled decides whether to colorize output written to w. In auto mode
+// colors are used only for terminals, and never when NO_COLOR is set or TERM
+// is "dumb".
+func ColorEnabled(w io.Writer, mode string) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Palette colors rates by threshold; a disabled palette returns plain text
+type Palette struct {
+	enabled bool
+}
+
+// NewPalette creates a palette that colors output when enabled
+func NewPalette(enabled bool) Palette {
+	return Palette{enabled: enabled}
+}
+
+// SuccessRate formats a success percentage, colored by the success thresholds
+func (p Palette) SuccessRate(rate float64) string {
+	return p.rate(rate, SuccessRateGood, SuccessRateWarn)
+}
+
+// HitRate formats a cache hit percentage, colored by the hit rate thresholds
+func (p Palette) HitRate(rate float64) string {
+	return p.rate(rate, HitRateGood, HitRateWarn)
+}
+
+func (p Palette) rate(rate, good, warn float64) string {
+	text := fmt.Sprintf("%.1f%%", rate)
+	if !p.enabled {
+		return text
+	}
+
+	color := ansiRed
+	switch {
+	case rate >= good:
+		color = ansiGreen
+	case rate >= warn:
+		color = ansiYellow
+	}
+	return color + text + ansiReset
+}
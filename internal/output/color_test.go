@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestPaletteRates(t *testing.T) {
+	t.Parallel()
+
+	enabled := NewPalette(true)
+	disabled := NewPalette(false)
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{name: "good success rate", got: enabled.SuccessRate(99.5), expected: "\x1b[32m99.5%\x1b[0m"},
+		{name: "warning success rate", got: enabled.SuccessRate(96), expected: "\x1b[33m96.0%\x1b[0m"},
+		{name: "bad success rate", got: enabled.SuccessRate(80), expected: "\x1b[31m80.0%\x1b[0m"},
+		{name: "good hit rate", got: enabled.HitRate(90), expected: "\x1b[32m90.0%\x1b[0m"},
+		{name: "warning hit rate", got: enabled.HitRate(75), expected: "\x1b[33m75.0%\x1b[0m"},
+		{name: "disabled", got: disabled.SuccessRate(80), expected: "80.0%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// Not parallel: modifies environment variables
+	var buffer bytes.Buffer
+
+	if !ColorEnabled(&buffer, ColorAlways) {
+		t.Error("Expected always to enable colors")
+	}
+	if ColorEnabled(os.Stderr, ColorNever) {
+		t.Error("Expected never to disable colors")
+	}
+	if ColorEnabled(&buffer, ColorAuto) {
+		t.Error("Expected auto to disable colors for a non-terminal writer")
+	}
+
+	file, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	if ColorEnabled(file, ColorAuto) {
+		t.Error("Expected auto to disable colors for a regular file")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if ColorEnabled(os.Stderr, ColorAuto) {
+		t.Error("Expected NO_COLOR to disable colors even when empty")
+	}
+	if !ColorEnabled(os.Stderr, ColorAlways) {
+		t.Error("Expected always to override NO_COLOR")
+	}
+}
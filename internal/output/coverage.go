@@ -0,0 +1,90 @@
+package output
+
+import (
+	"encoding/json"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/benvon/sitemap-crawler/internal/coverage"
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+)
+
+var coverageHTMLTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sitemap Coverage Report</title></head>
+<body>
+<h1>Sitemap Coverage Report</h1>
+{{with .Run}}<p>Run {{.ID}} | Version {{.Version}} | Started {{.StartedAt.Format "2006-01-02T15:04:05Z07:00"}}{{range .SitemapURLs}} | Sitemap {{.}}{{end}}</p>
+{{end -}}
+<p>Sitemap URLs: {{.SitemapURLs}} | Pages scanned: {{.PagesScanned}}</p>
+<h2>Sitemap URLs not linked from crawled pages ({{len .Orphans}})</h2>
+<ul>
+{{range .Orphans}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+<h2>Linked pages missing from the sitemap ({{len .Unlisted}})</h2>
+<table>
+<tr><th>URL</th><th>Linked From</th></tr>
+{{range .Unlisted}}<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{.LinkedFrom}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// coverageDocument is a coverage report with the run that produced it
+type coverageDocument struct {
+	Run *runinfo.Run `json:"run,omitempty"`
+	*coverage.Report
+}
+
+// FormatCoverageReport formats a sitemap coverage report
+func (f *Formatter) FormatCoverageReport(report *coverage.Report) string {
+	switch f.format {
+	case "csv":
+		return f.formatCoverageReportCSV(report)
+	case "html":
+		return f.formatCoverageReportHTML(report)
+	default:
+		return f.formatCoverageReportJSON(report)
+	}
+}
+
+// formatCoverageReportJSON formats a coverage report as JSON
+func (f *Formatter) formatCoverageReportJSON(report *coverage.Report) string {
+	jsonData, _ := json.MarshalIndent(coverageDocument{Run: f.run, Report: report}, "", "  ")
+	return string(jsonData)
+}
+
+// formatCoverageReportCSV formats a coverage report as CSV with one row per finding
+func (f *Formatter) formatCoverageReportCSV(report *coverage.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"type", "url", "linked_from"}); err != nil {
+		return ""
+	}
+
+	for _, orphan := range report.Orphans {
+		if err := writer.Write([]string{"orphan", orphan, "0"}); err != nil {
+			return ""
+		}
+	}
+
+	for _, page := range report.Unlisted {
+		if err := writer.Write([]string{"unlisted", page.URL, strconv.Itoa(page.LinkedFrom)}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
+
+// formatCoverageReportHTML formats a coverage report as a standalone HTML page
+func (f *Formatter) formatCoverageReportHTML(report *coverage.Report) string {
+	var builder strings.Builder
+	if err := coverageHTMLTemplate.Execute(&builder, coverageDocument{Run: f.run, Report: report}); err != nil {
+		return ""
+	}
+	return builder.String()
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/coverage"
+)
+
+func TestFormatCoverageReport(t *testing.T) {
+	t.Parallel()
+
+	report := &coverage.Report{
+		SitemapURLs:  2,
+		PagesScanned: 2,
+		Orphans:      []string{"https://example.com/orphan"},
+		Unlisted:     []coverage.UnlistedPage{{URL: "https://example.com/hidden", LinkedFrom: 3}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"orphans": [`, `"linked_from": 3`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"type,url,linked_from", "orphan,https://example.com/orphan,0", "unlisted,https://example.com/hidden,3"},
+		},
+		{
+			name:     "html format",
+			format:   "html",
+			expected: []string{"<h1>Sitemap Coverage Report</h1>", "<td>3</td>", `href="https://example.com/orphan"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatCoverageReport(report)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
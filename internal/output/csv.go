@@ -0,0 +1,80 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// CSVOptions controls the delimiter and quoting of CSV output
+type CSVOptions struct {
+	// Delimiter separates fields; zero means a comma
+	Delimiter rune
+
+	// QuoteAll quotes every field instead of only those that need it
+	QuoteAll bool
+}
+
+// WithCSVOptions sets the delimiter and quoting used by every CSV format
+func (f *Formatter) WithCSVOptions(options CSVOptions) *Formatter {
+	f.csv = options
+	return f
+}
+
+// csvWriter writes CSV records; it is satisfied by *csv.Writer
+type csvWriter interface {
+	Write(record []string) error
+	Flush()
+}
+
+// newCSVWriter creates a CSV writer using the formatter's CSV options, with
+// a run_id column when a run is set
+func (f *Formatter) newCSVWriter(w io.Writer) csvWriter {
+	writer := f.newPlainCSVWriter(w)
+	if f.run != nil {
+		return &runColumnWriter{csvWriter: writer, runID: f.run.ID}
+	}
+	return writer
+}
+
+// newPlainCSVWriter creates a CSV writer using the formatter's CSV options
+func (f *Formatter) newPlainCSVWriter(w io.Writer) csvWriter {
+	delimiter := f.csv.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+
+	if f.csv.QuoteAll {
+		return &quoteAllWriter{w: w, delimiter: string(delimiter)}
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	return writer
+}
+
+// quoteAllWriter writes CSV with every field quoted, for tools that treat
+// unquoted fields as numbers or dates
+type quoteAllWriter struct {
+	w         io.Writer
+	delimiter string
+	err       error
+}
+
+// Write writes a single quoted record
+func (q *quoteAllWriter) Write(record []string) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	fields := make([]string, len(record))
+	for i, field := range record {
+		fields[i] = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	}
+
+	_, q.err = io.WriteString(q.w, strings.Join(fields, q.delimiter)+"\n")
+	return q.err
+}
+
+// Flush is a no-op; records are written directly
+func (q *quoteAllWriter) Flush() {}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestCSVOptions(t *testing.T) {
+	t.Parallel()
+
+	progress := &stats.Progress{
+		Processed:       5,
+		Total:           10,
+		Percentage:      50,
+		SuccessRate:     100,
+		AverageDuration: 250 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name     string
+		options  CSVOptions
+		expected string
+	}{
+		{
+			name:     "default comma",
+			options:  CSVOptions{},
+			expected: "timestamp,processed,total,percentage,success_rate,average_duration\n",
+		},
+		{
+			name:     "tab delimiter",
+			options:  CSVOptions{Delimiter: '\t'},
+			expected: "timestamp\tprocessed\ttotal\tpercentage\tsuccess_rate\taverage_duration\n",
+		},
+		{
+			name:     "semicolon with quoting",
+			options:  CSVOptions{Delimiter: ';', QuoteAll: true},
+			expected: `"timestamp";"processed";"total";"percentage";"success_rate";"average_duration"` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New("csv").WithCSVOptions(tt.options).FormatProgress(progress)
+			header := result[:len(tt.expected)]
+			if header != tt.expected {
+				t.Errorf("Expected header %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestQuoteAllEscapesQuotes(t *testing.T) {
+	t.Parallel()
+
+	formatter := New("csv").WithCSVOptions(CSVOptions{Delimiter: ';', QuoteAll: true})
+	var builder strings.Builder
+	writer := formatter.newCSVWriter(&builder)
+	if err := writer.Write([]string{`say "hi"`, "a;b", ""}); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	expected := `"say ""hi""";"a;b";""` + "\n"
+	if builder.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, builder.String())
+	}
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/device"
+)
+
+// FormatDeviceReport formats the comparison of a device matrix crawl
+func (f *Formatter) FormatDeviceReport(report *device.Report) string {
+	switch f.format {
+	case "json":
+		return f.formatDeviceReportJSON(report)
+	case "csv":
+		return f.formatDeviceReportCSV(report)
+	default:
+		return f.runHeader() + f.formatDeviceReportText(report)
+	}
+}
+
+// formatDeviceReportText formats the device report as text
+func (f *Formatter) formatDeviceReportText(report *device.Report) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Device Comparison:
+=================
+URLs Compared: %d
+Differences:   %d
+`, report.Compared, len(report.Differences))
+
+	for _, summary := range report.Profiles {
+		fmt.Fprintf(&builder, "\n%s\n  Requests:       %d\n  Errors:         %d\n  Cache Hit Rate: %.1f%%\n  Average Size:   %d bytes\n",
+			summary.Profile,
+			summary.Requests,
+			summary.Errors,
+			summary.CacheHitRate,
+			summary.AverageSize,
+		)
+	}
+
+	for _, difference := range report.Differences {
+		fmt.Fprintf(&builder, "\n%s (%s)\n", difference.URL, strings.Join(difference.Reasons, ", "))
+		for _, variant := range difference.Variants {
+			fmt.Fprintf(&builder, "  %-10s %d  %d bytes  %s%s\n",
+				variant.Profile+":",
+				variant.StatusCode,
+				variant.Size,
+				variant.CacheStatus,
+				variant.Error,
+			)
+		}
+	}
+
+	return builder.String()
+}
+
+// formatDeviceReportJSON formats the device report as JSON
+func (f *Formatter) formatDeviceReportJSON(report *device.Report) string {
+	profiles := make([]map[string]interface{}, len(report.Profiles))
+	for i, summary := range report.Profiles {
+		profiles[i] = map[string]interface{}{
+			"device":         summary.Profile,
+			"requests":       summary.Requests,
+			"errors":         summary.Errors,
+			"cache_hits":     summary.CacheHits,
+			"cache_misses":   summary.CacheMisses,
+			"cache_hit_rate": summary.CacheHitRate,
+			"average_size":   summary.AverageSize,
+		}
+	}
+
+	differences := make([]map[string]interface{}, len(report.Differences))
+	for i, difference := range report.Differences {
+		variants := make([]map[string]interface{}, len(difference.Variants))
+		for j, variant := range difference.Variants {
+			variants[j] = map[string]interface{}{
+				"device":       variant.Profile,
+				"status_code":  variant.StatusCode,
+				"size":         variant.Size,
+				"cache_status": variant.CacheStatus,
+				"error":        variant.Error,
+			}
+		}
+		differences[i] = map[string]interface{}{
+			"url":        difference.URL,
+			"differs_in": difference.Reasons,
+			"variants":   variants,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"compared":    report.Compared,
+		"devices":     profiles,
+		"differences": differences,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatDeviceReportCSV formats the device differences as CSV, one row per
+// URL and device
+func (f *Formatter) formatDeviceReportCSV(report *device.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"differs_in",
+		"device",
+		"status_code",
+		"size",
+		"cache_status",
+		"error",
+	}); err != nil {
+		return ""
+	}
+
+	for _, difference := range report.Differences {
+		for _, variant := range difference.Variants {
+			if err := writer.Write([]string{
+				difference.URL,
+				strings.Join(difference.Reasons, " "),
+				variant.Profile,
+				fmt.Sprintf("%d", variant.StatusCode),
+				fmt.Sprintf("%d", variant.Size),
+				variant.CacheStatus,
+				variant.Error,
+			}); err != nil {
+				return ""
+			}
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/device"
+)
+
+func TestFormatDeviceReport(t *testing.T) {
+	t.Parallel()
+
+	report := &device.Report{
+		Profiles: []device.Summary{
+			{Profile: "desktop", Requests: 1, CacheHits: 1, CacheHitRate: 100, AverageSize: 2048},
+			{Profile: "mobile", Requests: 1, CacheMisses: 1, AverageSize: 1024},
+		},
+		Compared: 1,
+		Differences: []device.Difference{{
+			URL:     "https://example.com/page",
+			Reasons: []string{device.DiffSize, device.DiffCacheStatus},
+			Variants: []device.Variant{
+				{Profile: "desktop", StatusCode: 200, Size: 2048, CacheStatus: "HIT"},
+				{Profile: "mobile", StatusCode: 200, Size: 1024, CacheStatus: "MISS"},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: "https://example.com/page (size, cache_status)"},
+		{name: "json format", format: "json", expected: `"cache_status": "MISS"`},
+		{name: "csv format", format: "csv", expected: "https://example.com/page,size cache_status,mobile,200,1024,MISS,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatDeviceReport(report)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default latency regression thresholds for DiffResults
+const (
+	DefaultLatencyRatio    = 1.5
+	DefaultLatencyMinDelta = 100 * time.Millisecond
+)
+
+// DiffOptions controls what counts as a latency regression
+type DiffOptions struct {
+	// LatencyRatio is how many times slower a URL must get; zero uses
+	// DefaultLatencyRatio
+	LatencyRatio float64
+
+	// LatencyMinDelta ignores slowdowns smaller than this; zero uses
+	// DefaultLatencyMinDelta
+	LatencyMinDelta time.Duration
+}
+
+// StatusFlip is a URL whose status code or success changed between runs
+type StatusFlip struct {
+	URL        string
+	OldStatus  int
+	NewStatus  int
+	OldSuccess bool
+	NewSuccess bool
+	NewError   string
+}
+
+// LatencyRegression is a URL that got slower between runs
+type LatencyRegression struct {
+	URL         string
+	OldDuration time.Duration
+	NewDuration time.Duration
+}
+
+// CacheChange is a URL whose cache status changed between runs
+type CacheChange struct {
+	URL       string
+	OldStatus string
+	NewStatus string
+}
+
+// ResultDiff summarizes the differences between two results files
+type ResultDiff struct {
+	OldURLs int
+	NewURLs int
+
+	// Added and Removed list URLs present in only one of the runs. They
+	// are not compared when either file is sampled, since a sampled file
+	// leaves out URLs that were crawled.
+	Added   []string
+	Removed []string
+	Sampled bool
+
+	// Broken and Fixed count the status flips that changed success
+	Broken int
+	Fixed  int
+
+	StatusFlips        []StatusFlip
+	LatencyRegressions []LatencyRegression
+	CacheChanges       []CacheChange
+}
+
+// DiffResults compares two results files URL by URL
+func DiffResults(oldResults, newResults *ResultsFile, options DiffOptions) *ResultDiff {
+	if options.LatencyRatio <= 0 {
+		options.LatencyRatio = DefaultLatencyRatio
+	}
+	if options.LatencyMinDelta <= 0 {
+		options.LatencyMinDelta = DefaultLatencyMinDelta
+	}
+
+	oldIndex := oldResults.byURL()
+	newIndex := newResults.byURL()
+	diff := &ResultDiff{
+		OldURLs: len(oldIndex),
+		NewURLs: len(newIndex),
+		Sampled: oldResults.Sampling != nil || newResults.Sampling != nil,
+	}
+
+	for url := range oldIndex {
+		if _, ok := newIndex[url]; !ok && !diff.Sampled {
+			diff.Removed = append(diff.Removed, url)
+		}
+	}
+
+	for url, newEntry := range newIndex {
+		oldEntry, ok := oldIndex[url]
+		if !ok {
+			if !diff.Sampled {
+				diff.Added = append(diff.Added, url)
+			}
+			continue
+		}
+
+		if oldEntry.StatusCode != newEntry.StatusCode || oldEntry.Success != newEntry.Success {
+			diff.StatusFlips = append(diff.StatusFlips, StatusFlip{
+				URL:        url,
+				OldStatus:  oldEntry.StatusCode,
+				NewStatus:  newEntry.StatusCode,
+				OldSuccess: oldEntry.Success,
+				NewSuccess: newEntry.Success,
+				NewError:   newEntry.Error,
+			})
+			switch {
+			case oldEntry.Success && !newEntry.Success:
+				diff.Broken++
+			case !oldEntry.Success && newEntry.Success:
+				diff.Fixed++
+			}
+		}
+
+		// Only compare latency for requests that succeeded in both runs;
+		// failures end at arbitrary points such as timeouts
+		if oldEntry.Success && newEntry.Success &&
+			newEntry.Duration-oldEntry.Duration >= options.LatencyMinDelta &&
+			float64(newEntry.Duration) >= float64(oldEntry.Duration)*options.LatencyRatio {
+			diff.LatencyRegressions = append(diff.LatencyRegressions, LatencyRegression{
+				URL:         url,
+				OldDuration: oldEntry.Duration,
+				NewDuration: newEntry.Duration,
+			})
+		}
+
+		if oldEntry.CacheStatus != newEntry.CacheStatus {
+			diff.CacheChanges = append(diff.CacheChanges, CacheChange{
+				URL:       url,
+				OldStatus: oldEntry.CacheStatus,
+				NewStatus: newEntry.CacheStatus,
+			})
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.StatusFlips, func(i, j int) bool { return diff.StatusFlips[i].URL < diff.StatusFlips[j].URL })
+	sort.Slice(diff.LatencyRegressions, func(i, j int) bool {
+		return diff.LatencyRegressions[i].slowdown() > diff.LatencyRegressions[j].slowdown()
+	})
+	sort.Slice(diff.CacheChanges, func(i, j int) bool { return diff.CacheChanges[i].URL < diff.CacheChanges[j].URL })
+
+	return diff
+}
+
+// urlChanges summarizes the URLs added and removed
+func (d *ResultDiff) urlChanges() string {
+	if d.Sampled {
+		return "sampled, added and removed not compared"
+	}
+	return fmt.Sprintf("%d added, %d removed", len(d.Added), len(d.Removed))
+}
+
+// slowdown returns how much slower the URL got
+func (l LatencyRegression) slowdown() time.Duration {
+	return l.NewDuration - l.OldDuration
+}
+
+// FormatResultDiff formats a results comparison as text, JSON or Markdown
+func (f *Formatter) FormatResultDiff(diff *ResultDiff) string {
+	switch f.format {
+	case "json":
+		return f.formatResultDiffJSON(diff)
+	case "markdown":
+		return f.formatResultDiffMarkdown(diff)
+	default:
+		return f.formatResultDiffText(diff)
+	}
+}
+
+// formatResultDiffText formats a results comparison as text
+func (f *Formatter) formatResultDiffText(diff *ResultDiff) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Results Comparison:
+==================
+URLs:                %d -> %d (%s)
+Status Flips:        %d (%d broken, %d fixed)
+Latency Regressions: %d
+Cache Changes:       %d
+`, diff.OldURLs, diff.NewURLs, diff.urlChanges(),
+		len(diff.StatusFlips), diff.Broken, diff.Fixed, len(diff.LatencyRegressions), len(diff.CacheChanges))
+
+	if len(diff.StatusFlips) > 0 {
+		builder.WriteString("\nStatus Flips:\n")
+		for _, flip := range diff.StatusFlips {
+			fmt.Fprintf(&builder, "  %s: %s -> %s\n", flip.URL, statusLabel(flip.OldStatus), statusLabel(flip.NewStatus))
+		}
+	}
+
+	if len(diff.LatencyRegressions) > 0 {
+		builder.WriteString("\nLatency Regressions:\n")
+		for _, regression := range diff.LatencyRegressions {
+			fmt.Fprintf(&builder, "  %s: %s -> %s\n", regression.URL, regression.OldDuration, regression.NewDuration)
+		}
+	}
+
+	if len(diff.CacheChanges) > 0 {
+		builder.WriteString("\nCache Changes:\n")
+		for _, change := range diff.CacheChanges {
+			fmt.Fprintf(&builder, "  %s: %s -> %s\n", change.URL, cacheLabel(change.OldStatus), cacheLabel(change.NewStatus))
+		}
+	}
+
+	return builder.String()
+}
+
+// formatResultDiffJSON formats a results comparison as JSON
+func (f *Formatter) formatResultDiffJSON(diff *ResultDiff) string {
+	flips := make([]map[string]interface{}, len(diff.StatusFlips))
+	for i, flip := range diff.StatusFlips {
+		flips[i] = map[string]interface{}{
+			"url":         flip.URL,
+			"old_status":  flip.OldStatus,
+			"new_status":  flip.NewStatus,
+			"old_success": flip.OldSuccess,
+			"new_success": flip.NewSuccess,
+			"new_error":   flip.NewError,
+		}
+	}
+
+	regressions := make([]map[string]interface{}, len(diff.LatencyRegressions))
+	for i, regression := range diff.LatencyRegressions {
+		regressions[i] = map[string]interface{}{
+			"url":          regression.URL,
+			"old_duration": regression.OldDuration.String(),
+			"new_duration": regression.NewDuration.String(),
+		}
+	}
+
+	changes := make([]map[string]interface{}, len(diff.CacheChanges))
+	for i, change := range diff.CacheChanges {
+		changes[i] = map[string]interface{}{
+			"url":        change.URL,
+			"old_status": change.OldStatus,
+			"new_status": change.NewStatus,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp":           time.Now().Format(time.RFC3339),
+		"old_urls":            diff.OldURLs,
+		"new_urls":            diff.NewURLs,
+		"added":               nonNil(diff.Added),
+		"removed":             nonNil(diff.Removed),
+		"sampled":             diff.Sampled,
+		"broken":              diff.Broken,
+		"fixed":               diff.Fixed,
+		"status_flips":        flips,
+		"latency_regressions": regressions,
+		"cache_changes":       changes,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatResultDiffMarkdown formats a results comparison as Markdown, for
+// pull request comments and job summaries
+func (f *Formatter) formatResultDiffMarkdown(diff *ResultDiff) string {
+	var builder strings.Builder
+	builder.WriteString("## Results Comparison\n\n")
+	builder.WriteString("| | Count |\n|---|---|\n")
+	fmt.Fprintf(&builder, "| URLs | %d → %d (%s) |\n", diff.OldURLs, diff.NewURLs, diff.urlChanges())
+	fmt.Fprintf(&builder, "| Status flips | %d (%d broken, %d fixed) |\n", len(diff.StatusFlips), diff.Broken, diff.Fixed)
+	fmt.Fprintf(&builder, "| Latency regressions | %d |\n", len(diff.LatencyRegressions))
+	fmt.Fprintf(&builder, "| Cache changes | %d |\n", len(diff.CacheChanges))
+
+	if len(diff.StatusFlips) > 0 {
+		builder.WriteString("\n### Status Flips\n\n| URL | Old | New |\n|---|---|---|\n")
+		for _, flip := range diff.StatusFlips {
+			fmt.Fprintf(&builder, "| %s | %s | %s |\n", markdownCell(flip.URL), statusLabel(flip.OldStatus), statusLabel(flip.NewStatus))
+		}
+	}
+
+	if len(diff.LatencyRegressions) > 0 {
+		builder.WriteString("\n### Latency Regressions\n\n| URL | Old | New |\n|---|---|---|\n")
+		for _, regression := range diff.LatencyRegressions {
+			fmt.Fprintf(&builder, "| %s | %s | %s |\n", markdownCell(regression.URL), regression.OldDuration, regression.NewDuration)
+		}
+	}
+
+	if len(diff.CacheChanges) > 0 {
+		builder.WriteString("\n### Cache Changes\n\n| URL | Old | New |\n|---|---|---|\n")
+		for _, change := range diff.CacheChanges {
+			fmt.Fprintf(&builder, "| %s | %s | %s |\n", markdownCell(change.URL), cacheLabel(change.OldStatus), cacheLabel(change.NewStatus))
+		}
+	}
+
+	return builder.String()
+}
+
+// statusLabel shows a status code, or "error" when there was no response
+func statusLabel(status int) string {
+	if status == 0 {
+		return "error"
+	}
+	return fmt.Sprintf("%d", status)
+}
+
+// cacheLabel shows a cache status, or "none" when the header was missing
+func cacheLabel(status string) string {
+	if status == "" {
+		return "none"
+	}
+	return status
+}
+
+// markdownCell escapes pipes so a value stays in its table cell
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// nonNil returns an empty slice for nil so JSON shows [] rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,219 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func resultsFile(entries ...ResultEntry) *ResultsFile {
+	return &ResultsFile{Results: entries}
+}
+
+func entry(url string, status int, duration time.Duration, cacheStatus string) ResultEntry {
+	return ResultEntry{
+		Phase: "crawl",
+		Result: stats.Result{
+			URL:         url,
+			Success:     status >= 200 && status < 400,
+			StatusCode:  status,
+			Duration:    duration,
+			CacheStatus: cacheStatus,
+		},
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	t.Parallel()
+
+	oldResults := resultsFile(
+		entry("https://example.com/same", 200, 100*time.Millisecond, "HIT"),
+		entry("https://example.com/broken", 200, 100*time.Millisecond, ""),
+		entry("https://example.com/fixed", 500, 100*time.Millisecond, ""),
+		entry("https://example.com/slow", 200, 100*time.Millisecond, ""),
+		entry("https://example.com/slightly-slow", 200, 100*time.Millisecond, ""),
+		entry("https://example.com/cold", 200, 100*time.Millisecond, "HIT"),
+		entry("https://example.com/removed", 200, 100*time.Millisecond, ""),
+	)
+	newResults := resultsFile(
+		entry("https://example.com/same", 200, 110*time.Millisecond, "HIT"),
+		entry("https://example.com/broken", 404, 100*time.Millisecond, ""),
+		entry("https://example.com/fixed", 200, 100*time.Millisecond, ""),
+		entry("https://example.com/slow", 200, 400*time.Millisecond, ""),
+		entry("https://example.com/slightly-slow", 200, 190*time.Millisecond, ""),
+		entry("https://example.com/cold", 200, 100*time.Millisecond, "MISS"),
+		entry("https://example.com/added", 200, 100*time.Millisecond, ""),
+	)
+
+	diff := DiffResults(oldResults, newResults, DiffOptions{})
+
+	if diff.OldURLs != 7 || diff.NewURLs != 7 {
+		t.Errorf("Expected 7 URLs in each run, got %d and %d", diff.OldURLs, diff.NewURLs)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "https://example.com/added" {
+		t.Errorf("Expected one added URL, got %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "https://example.com/removed" {
+		t.Errorf("Expected one removed URL, got %v", diff.Removed)
+	}
+	if len(diff.StatusFlips) != 2 || diff.Broken != 1 || diff.Fixed != 1 {
+		t.Errorf("Expected one broken and one fixed flip, got %+v", diff.StatusFlips)
+	}
+	// slightly-slow is 1.9x slower but only by 90ms, under the default minimum
+	if len(diff.LatencyRegressions) != 1 || diff.LatencyRegressions[0].URL != "https://example.com/slow" {
+		t.Errorf("Expected only /slow to regress, got %+v", diff.LatencyRegressions)
+	}
+	if len(diff.CacheChanges) != 1 || diff.CacheChanges[0].NewStatus != "MISS" {
+		t.Errorf("Expected one cache change to MISS, got %+v", diff.CacheChanges)
+	}
+}
+
+func TestDiffResultsUsesLastPhase(t *testing.T) {
+	t.Parallel()
+
+	warmUp := entry("https://example.com/page", 200, 100*time.Millisecond, "MISS")
+	warmUp.Phase = stats.PhaseWarmUp
+	verify := entry("https://example.com/page", 200, 100*time.Millisecond, "HIT")
+	verify.Phase = stats.PhaseVerify
+
+	diff := DiffResults(resultsFile(warmUp, verify), resultsFile(warmUp, verify), DiffOptions{})
+	if len(diff.CacheChanges) != 0 || diff.OldURLs != 1 {
+		t.Errorf("Expected the verify pass to be compared, got %+v", diff)
+	}
+}
+
+func TestFormatResultDiff(t *testing.T) {
+	t.Parallel()
+
+	diff := DiffResults(
+		resultsFile(entry("https://example.com/a|b", 200, 100*time.Millisecond, "HIT")),
+		resultsFile(entry("https://example.com/a|b", 503, 100*time.Millisecond, "MISS")),
+		DiffOptions{},
+	)
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{name: "text format", format: "text", expected: []string{"Status Flips:        1 (1 broken, 0 fixed)", "https://example.com/a|b: 200 -> 503", "HIT -> MISS"}},
+		{name: "json format", format: "json", expected: []string{`"broken": 1`, `"new_status": 503`, `"added": []`}},
+		{name: "markdown format", format: "markdown", expected: []string{"## Results Comparison", `| https://example.com/a\|b | 200 | 503 |`, "### Cache Changes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatResultDiff(diff)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestResultsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	entries := []ResultEntry{entry("https://example.com/", 200, 150*time.Millisecond, "HIT")}
+	if err := os.WriteFile(path, []byte(New("json").FormatResults(entries)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadResults(path)
+	if err != nil {
+		t.Fatalf("Failed to load results: %v", err)
+	}
+	if len(loaded.Results) != 1 || !reflect.DeepEqual(loaded.Results[0], entries[0]) {
+		t.Errorf("Expected %+v, got %+v", entries, loaded.Results)
+	}
+	if loaded.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion, loaded.SchemaVersion)
+	}
+
+	if _, err := LoadResults(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	newer := filepath.Join(t.TempDir(), "newer.json")
+	if err := os.WriteFile(newer, []byte(`{"schema_version": 99, "results": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadResults(newer); err == nil {
+		t.Error("Expected an error for a newer schema version")
+	}
+}
+
+func TestSampledResultsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	entries := []ResultEntry{entry("https://example.com/down", 503, 50*time.Millisecond, "")}
+	sampling := &Sampling{Rate: 0.01, Omitted: map[string]Omitted{
+		"warm-up": {Results: 980, CacheHits: 0},
+		"verify":  {Results: 975, CacheHits: 975},
+	}}
+
+	for _, format := range []string{"json", "xml"} {
+		path := filepath.Join(t.TempDir(), "results."+format)
+		if err := os.WriteFile(path, []byte(New(format).FormatSampledResults(entries, sampling)), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadResults(path)
+		if err != nil {
+			t.Fatalf("Failed to load %s results: %v", format, err)
+		}
+		if !reflect.DeepEqual(loaded.Sampling, sampling) {
+			t.Errorf("Expected %s sampling %+v, got %+v", format, sampling, loaded.Sampling)
+		}
+		if !reflect.DeepEqual(loaded.Results, entries) {
+			t.Errorf("Expected %s results %+v, got %+v", format, entries, loaded.Results)
+		}
+	}
+
+	if content := New("json").FormatResults(entries); strings.Contains(content, "sampling") {
+		t.Errorf("Expected no sampling in an unsampled results file, got '%s'", content)
+	}
+}
+
+func TestDiffSampledResults(t *testing.T) {
+	t.Parallel()
+
+	oldResults := resultsFile(
+		entry("https://example.com/a", 200, 100*time.Millisecond, "HIT"),
+		entry("https://example.com/b", 200, 100*time.Millisecond, "HIT"),
+	)
+	newResults := resultsFile(
+		entry("https://example.com/b", 500, 100*time.Millisecond, ""),
+		entry("https://example.com/c", 404, 100*time.Millisecond, ""),
+	)
+	newResults.Sampling = &Sampling{Rate: 0.1, Omitted: map[string]Omitted{"crawl": {Results: 9}}}
+
+	// A URL missing from a sampled file may have been left out, so only
+	// the URLs in both are compared
+	diff := DiffResults(oldResults, newResults, DiffOptions{})
+	if !diff.Sampled || len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("Expected a sampled diff without added or removed URLs, got %+v", diff)
+	}
+	if len(diff.StatusFlips) != 1 || diff.Broken != 1 {
+		t.Errorf("Expected one broken URL, got %+v", diff.StatusFlips)
+	}
+
+	for format, expected := range map[string]string{
+		"text":     "2 -> 2 (sampled, added and removed not compared)",
+		"markdown": "| URLs | 2 → 2 (sampled, added and removed not compared) |",
+		"json":     `"sampled": true`,
+	} {
+		if result := New(format).FormatResultDiff(diff); !strings.Contains(result, expected) {
+			t.Errorf("Expected %s diff to contain '%s', got '%s'", format, expected, result)
+		}
+	}
+}
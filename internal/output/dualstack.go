@@ -0,0 +1,143 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/dualstack"
+)
+
+// FormatDualStackReport formats the IPv4/IPv6 comparison of a dual-stack
+// crawl
+func (f *Formatter) FormatDualStackReport(report *dualstack.Report) string {
+	switch f.format {
+	case "json":
+		return f.formatDualStackReportJSON(report)
+	case "csv":
+		return f.formatDualStackReportCSV(report)
+	default:
+		return f.runHeader() + f.formatDualStackReportText(report)
+	}
+}
+
+// formatDualStackReportText formats the dual-stack report as text
+func (f *Formatter) formatDualStackReportText(report *dualstack.Report) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Dual-Stack Comparison:
+=====================
+URLs Compared: %d
+Problems:      %d
+`, report.Compared, len(report.Problems))
+
+	for _, host := range report.Hosts {
+		fmt.Fprintf(&builder, "\n%s\n  IPv4: %d requests, %d errors, avg %s\n  IPv6: %d requests, %d errors, avg %s\n",
+			host.Host,
+			host.IPv4.Requests, host.IPv4.Errors, host.IPv4.AverageDuration,
+			host.IPv6.Requests, host.IPv6.Errors, host.IPv6.AverageDuration,
+		)
+	}
+
+	for _, problem := range report.Problems {
+		fmt.Fprintf(&builder, "\n%s (%s)\n  IPv4: %s\n  IPv6: %s\n",
+			problem.URL,
+			problem.Reason,
+			problem.IPv4,
+			problem.IPv6,
+		)
+	}
+
+	return builder.String()
+}
+
+// formatDualStackReportJSON formats the dual-stack report as JSON
+func (f *Formatter) formatDualStackReportJSON(report *dualstack.Report) string {
+	hosts := make([]map[string]interface{}, len(report.Hosts))
+	for i, host := range report.Hosts {
+		hosts[i] = map[string]interface{}{
+			"host":     host.Host,
+			"ipv4":     familyStatsJSON(host.IPv4),
+			"ipv6":     familyStatsJSON(host.IPv6),
+			"problems": host.Problems,
+		}
+	}
+
+	problems := make([]map[string]interface{}, len(report.Problems))
+	for i, problem := range report.Problems {
+		problems[i] = map[string]interface{}{
+			"url":    problem.URL,
+			"host":   problem.Host,
+			"reason": problem.Reason,
+			"ipv4":   attemptJSON(problem.IPv4),
+			"ipv6":   attemptJSON(problem.IPv6),
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"compared":  report.Compared,
+		"hosts":     hosts,
+		"problems":  problems,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// familyStatsJSON converts one family's host stats for JSON output
+func familyStatsJSON(stats dualstack.FamilyStats) map[string]interface{} {
+	return map[string]interface{}{
+		"requests":         stats.Requests,
+		"errors":           stats.Errors,
+		"average_duration": stats.AverageDuration.String(),
+	}
+}
+
+// attemptJSON converts one family's attempt for JSON output
+func attemptJSON(attempt dualstack.Attempt) map[string]interface{} {
+	return map[string]interface{}{
+		"success":     attempt.Success,
+		"status_code": attempt.StatusCode,
+		"duration":    attempt.Duration.String(),
+		"error":       attempt.Error,
+	}
+}
+
+// formatDualStackReportCSV formats the dual-stack problems as CSV
+func (f *Formatter) formatDualStackReportCSV(report *dualstack.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"host",
+		"reason",
+		"ipv4_status_code",
+		"ipv4_duration_ms",
+		"ipv4_error",
+		"ipv6_status_code",
+		"ipv6_duration_ms",
+		"ipv6_error",
+	}); err != nil {
+		return ""
+	}
+
+	for _, problem := range report.Problems {
+		if err := writer.Write([]string{
+			problem.URL,
+			problem.Host,
+			problem.Reason,
+			fmt.Sprintf("%d", problem.IPv4.StatusCode),
+			fmt.Sprintf("%d", problem.IPv4.Duration.Milliseconds()),
+			problem.IPv4.Error,
+			fmt.Sprintf("%d", problem.IPv6.StatusCode),
+			fmt.Sprintf("%d", problem.IPv6.Duration.Milliseconds()),
+			problem.IPv6.Error,
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
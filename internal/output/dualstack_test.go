@@ -0,0 +1,50 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/dualstack"
+)
+
+func TestFormatDualStackReport(t *testing.T) {
+	t.Parallel()
+
+	report := &dualstack.Report{
+		Compared: 1,
+		Hosts: []dualstack.HostSummary{{
+			Host:     "example.com",
+			IPv4:     dualstack.FamilyStats{Requests: 1, AverageDuration: 80 * time.Millisecond},
+			IPv6:     dualstack.FamilyStats{Requests: 1, Errors: 1},
+			Problems: 1,
+		}},
+		Problems: []dualstack.Problem{{
+			URL:    "https://example.com/page",
+			Host:   "example.com",
+			Reason: dualstack.ReasonIPv6Failed,
+			IPv4:   dualstack.Attempt{Success: true, StatusCode: 200, Duration: 80 * time.Millisecond},
+			IPv6:   dualstack.Attempt{Error: "connect: network is unreachable"},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: "  IPv6: connect: network is unreachable"},
+		{name: "json format", format: "json", expected: `"reason": "ipv6_failed"`},
+		{name: "csv format", format: "csv", expected: "https://example.com/page,example.com,ipv6_failed,200,80,,0,0,connect: network is unreachable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatDualStackReport(report)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
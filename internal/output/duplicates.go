@@ -0,0 +1,120 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/duplicates"
+)
+
+// FormatDuplicatesReport formats the clusters of URLs serving duplicate
+// content
+func (f *Formatter) FormatDuplicatesReport(report *duplicates.Report) string {
+	switch f.format {
+	case "json":
+		return f.formatDuplicatesReportJSON(report)
+	case "csv":
+		return f.formatDuplicatesReportCSV(report)
+	default:
+		return f.runHeader() + f.formatDuplicatesReportText(report)
+	}
+}
+
+// duplicateKind describes whether a cluster's content is identical or only
+// nearly so
+func duplicateKind(cluster duplicates.Cluster) string {
+	if cluster.Identical {
+		return "identical"
+	}
+	return "near-identical"
+}
+
+// formatDuplicatesReportText formats the duplicates report as text
+func (f *Formatter) formatDuplicatesReportText(report *duplicates.Report) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Duplicate Content:
+==================
+URLs Checked: %d
+Clusters:     %d
+`, report.Checked, len(report.Clusters))
+
+	for i, cluster := range report.Clusters {
+		fmt.Fprintf(&builder, "\nCluster %d (%d URLs, %s)\n", i+1, len(cluster.URLs), duplicateKind(cluster))
+		for _, member := range cluster.URLs {
+			if cluster.Identical {
+				fmt.Fprintf(&builder, "  %s\n", member.URL)
+			} else {
+				fmt.Fprintf(&builder, "  %s (distance %d)\n", member.URL, member.Distance)
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// formatDuplicatesReportJSON formats the duplicates report as JSON
+func (f *Formatter) formatDuplicatesReportJSON(report *duplicates.Report) string {
+	clusters := make([]map[string]interface{}, len(report.Clusters))
+	for i, cluster := range report.Clusters {
+		urls := make([]map[string]interface{}, len(cluster.URLs))
+		for j, member := range cluster.URLs {
+			urls[j] = map[string]interface{}{
+				"url":      member.URL,
+				"sha256":   member.SHA256,
+				"bytes":    member.Bytes,
+				"distance": member.Distance,
+			}
+		}
+		clusters[i] = map[string]interface{}{
+			"identical": cluster.Identical,
+			"urls":      urls,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"checked":   report.Checked,
+		"clusters":  clusters,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatDuplicatesReportCSV formats the duplicates report as CSV, one row
+// per URL
+func (f *Formatter) formatDuplicatesReportCSV(report *duplicates.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"cluster",
+		"identical",
+		"url",
+		"sha256",
+		"bytes",
+		"distance",
+	}); err != nil {
+		return ""
+	}
+
+	for i, cluster := range report.Clusters {
+		for _, member := range cluster.URLs {
+			if err := writer.Write([]string{
+				strconv.Itoa(i + 1),
+				strconv.FormatBool(cluster.Identical),
+				member.URL,
+				member.SHA256,
+				strconv.FormatInt(member.Bytes, 10),
+				strconv.Itoa(member.Distance),
+			}); err != nil {
+				return ""
+			}
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/duplicates"
+)
+
+func TestFormatDuplicatesReport(t *testing.T) {
+	t.Parallel()
+
+	report := &duplicates.Report{
+		Checked: 3,
+		Clusters: []duplicates.Cluster{{
+			Identical: false,
+			URLs: []duplicates.Member{
+				{URL: "https://example.com/page", SHA256: "aaa", Bytes: 120},
+				{URL: "https://example.com/page?ref=nav", SHA256: "bbb", Bytes: 124, Distance: 2},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: "https://example.com/page?ref=nav (distance 2)"},
+		{name: "text cluster", format: "text", expected: "Cluster 1 (2 URLs, near-identical)"},
+		{name: "json format", format: "json", expected: `"sha256": "bbb"`},
+		{name: "csv format", format: "csv", expected: "1,false,https://example.com/page?ref=nav,bbb,124,2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatDuplicatesReport(report)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// FormatEdgeStats formats the cache hit rate of each CDN edge the
+// verification pass was sent to
+func (f *Formatter) FormatEdgeStats(edges []stats.EdgeStats) string {
+	switch f.format {
+	case "json":
+		return f.formatEdgeStatsJSON(edges)
+	case "csv":
+		return f.formatEdgeStatsCSV(edges)
+	default:
+		return f.runHeader() + f.formatEdgeStatsText(edges)
+	}
+}
+
+// formatEdgeStatsText formats the edge stats as text, marking the edges
+// where nothing hit
+func (f *Formatter) formatEdgeStatsText(edges []stats.EdgeStats) string {
+	var builder strings.Builder
+	builder.WriteString(`
+Cache Hit Rate by Edge:
+=======================
+`)
+	for _, edge := range edges {
+		fmt.Fprintf(&builder, "\n%s\n  Requests:       %d (%d errors)\n  Cache Hits:     %d\n  Cache Misses:   %d\n  Cache Hit Rate: %.2f%%\n  Avg Response:   %s\n",
+			edge.Edge,
+			edge.Requests,
+			edge.Errors,
+			edge.CacheHits,
+			edge.CacheMisses,
+			edge.CacheHitRate,
+			edge.AverageDuration,
+		)
+		if edge.Cold() {
+			builder.WriteString("  Not warmed: no verification request hit the cache\n")
+		}
+	}
+	return builder.String()
+}
+
+// formatEdgeStatsJSON formats the edge stats as JSON
+func (f *Formatter) formatEdgeStatsJSON(edges []stats.EdgeStats) string {
+	entries := make([]map[string]interface{}, len(edges))
+	for i, edge := range edges {
+		entries[i] = map[string]interface{}{
+			"edge":             edge.Edge,
+			"requests":         edge.Requests,
+			"errors":           edge.Errors,
+			"cache_hits":       edge.CacheHits,
+			"cache_misses":     edge.CacheMisses,
+			"cache_hit_rate":   edge.CacheHitRate,
+			"average_duration": edge.AverageDuration.String(),
+			"cold":             edge.Cold(),
+		}
+	}
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"edges":          entries,
+	})
+}
+
+// formatEdgeStatsCSV formats the edge stats as CSV with one row per edge
+func (f *Formatter) formatEdgeStatsCSV(edges []stats.EdgeStats) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"edge", "requests", "errors", "cache_hits", "cache_misses", "cache_hit_rate", "avg_duration_ms"}); err != nil {
+		return ""
+	}
+
+	for _, edge := range edges {
+		if err := writer.Write([]string{
+			edge.Edge,
+			fmt.Sprintf("%d", edge.Requests),
+			fmt.Sprintf("%d", edge.Errors),
+			fmt.Sprintf("%d", edge.CacheHits),
+			fmt.Sprintf("%d", edge.CacheMisses),
+			fmt.Sprintf("%.2f", edge.CacheHitRate),
+			fmt.Sprintf("%d", edge.AverageDuration.Milliseconds()),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
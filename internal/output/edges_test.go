@@ -0,0 +1,57 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatEdgeStats(t *testing.T) {
+	t.Parallel()
+
+	edges := []stats.EdgeStats{
+		{Edge: "lhr", Requests: 4, CacheHits: 3, CacheMisses: 1, CacheHitRate: 75, AverageDuration: 20 * time.Millisecond},
+		{Edge: "iad", Requests: 4, Errors: 1, CacheMisses: 3, AverageDuration: 150 * time.Millisecond},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Cache Hit Rate by Edge", "lhr\n  Requests:       4 (0 errors)", "Cache Hit Rate: 75.00%", "iad\n", "Not warmed: no verification request hit the cache"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"edge": "lhr"`, `"cache_hit_rate": 75`, `"average_duration": "150ms"`, `"cold": true`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"edge,requests,errors,cache_hits,cache_misses,cache_hit_rate,avg_duration_ms", "lhr,4,0,3,1,75.00,20", "iad,4,1,0,3,0.00,150"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatEdgeStats(edges)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+
+	if text := New("text").FormatEdgeStats(edges[:1]); strings.Contains(text, "Not warmed") {
+		t.Errorf("Expected no warning for a warmed edge, got '%s'", text)
+	}
+}
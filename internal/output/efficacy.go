@@ -0,0 +1,90 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// FormatCacheEfficacy formats the per-URL cache efficacy ranking, least
+// benefited by warming first
+func (f *Formatter) FormatCacheEfficacy(scores []stats.CacheEfficacy) string {
+	switch f.format {
+	case "json":
+		return f.formatCacheEfficacyJSON(scores)
+	case "csv":
+		return f.formatCacheEfficacyCSV(scores)
+	default:
+		return f.runHeader() + f.formatCacheEfficacyText(scores)
+	}
+}
+
+// formatCacheEfficacyText formats the cache efficacy ranking as text
+func (f *Formatter) formatCacheEfficacyText(scores []stats.CacheEfficacy) string {
+	var builder strings.Builder
+	builder.WriteString(`
+Cache Efficacy (least benefited by warming first):
+=================================================
+`)
+	for _, score := range scores {
+		status := score.CacheStatus
+		if status == "" {
+			status = "-"
+		}
+		fmt.Fprintf(&builder, "  %5.1f  %s\n         %s -> %s (%+.0f%%), cache %s, age %s\n",
+			score.Score, score.URL, score.WarmUpDuration, score.VerifyDuration, score.Speedup*100, status, score.Age)
+	}
+	return builder.String()
+}
+
+// formatCacheEfficacyJSON formats the cache efficacy ranking as JSON
+func (f *Formatter) formatCacheEfficacyJSON(scores []stats.CacheEfficacy) string {
+	urls := make([]map[string]interface{}, len(scores))
+	for i, score := range scores {
+		urls[i] = map[string]interface{}{
+			"url":              score.URL,
+			"score":            score.Score,
+			"cache_status":     score.CacheStatus,
+			"warm_up_duration": score.WarmUpDuration.String(),
+			"verify_duration":  score.VerifyDuration.String(),
+			"speedup":          score.Speedup,
+			"age":              score.Age.String(),
+		}
+	}
+
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"urls":           urls,
+	})
+}
+
+// formatCacheEfficacyCSV formats the cache efficacy ranking as CSV with one
+// row per URL
+func (f *Formatter) formatCacheEfficacyCSV(scores []stats.CacheEfficacy) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"url", "score", "cache_status", "warm_up_duration", "verify_duration", "speedup", "age"}); err != nil {
+		return ""
+	}
+
+	for _, score := range scores {
+		if err := writer.Write([]string{
+			score.URL,
+			fmt.Sprintf("%.1f", score.Score),
+			score.CacheStatus,
+			score.WarmUpDuration.String(),
+			score.VerifyDuration.String(),
+			fmt.Sprintf("%.3f", score.Speedup),
+			score.Age.String(),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
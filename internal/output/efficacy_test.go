@@ -0,0 +1,53 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatCacheEfficacy(t *testing.T) {
+	t.Parallel()
+
+	scores := []stats.CacheEfficacy{
+		{URL: "https://example.com/bypassed", Score: 0, CacheStatus: "MISS", WarmUpDuration: 200 * time.Millisecond, VerifyDuration: 300 * time.Millisecond, Speedup: -0.5},
+		{URL: "https://example.com/cached", Score: 97, CacheStatus: "HIT", WarmUpDuration: time.Second, VerifyDuration: 100 * time.Millisecond, Speedup: 0.9, Age: time.Minute},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Cache Efficacy", "    0.0  https://example.com/bypassed", "200ms -> 300ms (-50%), cache MISS", "97.0  https://example.com/cached", "age 1m0s"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"urls": [`, `"url": "https://example.com/bypassed"`, `"score": 97`, `"speedup": 0.9`, `"age": "1m0s"`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"url,score,cache_status,warm_up_duration,verify_duration,speedup,age", "https://example.com/bypassed,0.0,MISS,200ms,300ms,-0.500,0s", "https://example.com/cached,97.0,HIT,1s,100ms,0.900,1m0s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatCacheEfficacy(scores)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
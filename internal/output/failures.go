@@ -0,0 +1,67 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/failures"
+)
+
+// FormatFailures formats failed and missed URLs as CSV. The failures file is
+// always CSV so it can be attached to tickets and opened in a spreadsheet.
+func (f *Formatter) FormatFailures(records []failures.Record) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"phase",
+		"reason",
+		"status_code",
+		"error_category",
+		"error",
+		"duration_ms",
+		"cache_status",
+		"trace_id",
+		"start",
+		"end",
+	}); err != nil {
+		return ""
+	}
+
+	for _, record := range records {
+		statusCode := ""
+		if record.StatusCode != 0 {
+			statusCode = fmt.Sprintf("%d", record.StatusCode)
+		}
+
+		if err := writer.Write([]string{
+			record.URL,
+			record.Phase,
+			record.Reason,
+			statusCode,
+			string(record.ErrorCategory),
+			record.Error,
+			fmt.Sprintf("%d", record.Duration.Milliseconds()),
+			record.CacheStatus,
+			record.TraceID,
+			formatTimestamp(record.Start),
+			formatTimestamp(record.End),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
+
+// formatTimestamp formats a request time as RFC 3339 in UTC with
+// nanoseconds, or an empty string for the zero time
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/failures"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatFailures(t *testing.T) {
+	t.Parallel()
+
+	records := []failures.Record{
+		{
+			URL:           "https://example.com/missing",
+			Phase:         failures.PhaseCrawl,
+			Reason:        failures.ReasonFailed,
+			StatusCode:    404,
+			ErrorCategory: stats.ErrorHTTPStatus,
+			Duration:      120 * time.Millisecond,
+		},
+		{
+			URL:           "https://example.com/slow",
+			Phase:         failures.PhaseCrawl,
+			Reason:        failures.ReasonFailed,
+			ErrorCategory: stats.ErrorTimeout,
+			Error:         "context deadline exceeded, giving up",
+			Duration:      30 * time.Second,
+		},
+		{
+			URL:         "https://example.com/cold",
+			Phase:       stats.PhaseVerify,
+			Reason:      failures.ReasonCacheMiss,
+			StatusCode:  200,
+			Duration:    80 * time.Millisecond,
+			CacheStatus: "MISS",
+			TraceID:     "8c2f1a9b4e7d3c21-AMS",
+			Start:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			End:         time.Date(2024, 5, 1, 10, 0, 0, 80_000_000, time.UTC),
+		},
+	}
+
+	expected := "url,phase,reason,status_code,error_category,error,duration_ms,cache_status,trace_id,start,end\n" +
+		"https://example.com/missing,crawl,failed,404,http_status,,120,,,,\n" +
+		"https://example.com/slow,crawl,failed,,timeout,\"context deadline exceeded, giving up\",30000,,,,\n" +
+		"https://example.com/cold,verify,cache_miss,200,,,80,MISS,8c2f1a9b4e7d3c21-AMS,2024-05-01T10:00:00Z,2024-05-01T10:00:00.08Z\n"
+
+	// The format is ignored; the failures file is always CSV
+	for _, format := range []string{"text", "json", "csv"} {
+		if result := New(format).FormatFailures(records); result != expected {
+			t.Errorf("Format %s: expected %q, got %q", format, expected, result)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+)
+
+// FormatFindings formats the findings of the page analyzers
+func (f *Formatter) FormatFindings(findings []analysis.Finding) string {
+	switch f.format {
+	case "json":
+		return f.formatFindingsJSON(findings)
+	case "csv":
+		return f.formatFindingsCSV(findings)
+	default:
+		return f.runHeader() + f.formatFindingsText(findings)
+	}
+}
+
+// formatFindingsText formats the findings as text, grouped by page
+func (f *Formatter) formatFindingsText(findings []analysis.Finding) string {
+	var builder strings.Builder
+	issues := make(map[string]int)
+	pages := 0
+	for i, finding := range findings {
+		issues[finding.Issue]++
+		if i == 0 || finding.URL != findings[i-1].URL {
+			pages++
+		}
+	}
+
+	fmt.Fprintf(&builder, "\nPage Analysis Findings:\n=======================\nFindings:         %d\nPages:            %d\n", len(findings), pages)
+	for _, issue := range slices.Sorted(maps.Keys(issues)) {
+		fmt.Fprintf(&builder, "  %-32s %d\n", issue+":", issues[issue])
+	}
+
+	for i, finding := range findings {
+		if i == 0 || finding.URL != findings[i-1].URL {
+			fmt.Fprintf(&builder, "\n%s\n", finding.URL)
+		}
+		fmt.Fprintf(&builder, "  %s (%s)", finding.Issue, finding.Analyzer)
+		if finding.Detail != "" {
+			fmt.Fprintf(&builder, ": %s", finding.Detail)
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// formatFindingsJSON formats the findings as JSON
+func (f *Formatter) formatFindingsJSON(findings []analysis.Finding) string {
+	if findings == nil {
+		findings = []analysis.Finding{}
+	}
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"findings":       findings,
+	})
+}
+
+// formatFindingsCSV formats the findings as CSV with one row per finding
+func (f *Formatter) formatFindingsCSV(findings []analysis.Finding) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"url", "analyzer", "issue", "detail"}); err != nil {
+		return ""
+	}
+
+	for _, finding := range findings {
+		if err := writer.Write([]string{finding.URL, finding.Analyzer, finding.Issue, finding.Detail}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
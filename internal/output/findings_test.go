@@ -0,0 +1,60 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+)
+
+func TestFormatFindings(t *testing.T) {
+	t.Parallel()
+
+	findings := []analysis.Finding{
+		{URL: "https://example.com/", Analyzer: "broken-links", Issue: "broken_link", Detail: "https://example.com/gone (HTTP 404)"},
+		{URL: "https://example.com/", Analyzer: "mixed-content", Issue: "mixed_content", Detail: "img http://example.com/logo.png"},
+		{URL: "https://example.com/about", Analyzer: "structured-data", Issue: "structured_data_missing_type", Detail: "block 1: item 1"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:   "text format",
+			format: "text",
+			expected: []string{
+				"Page Analysis Findings", "Findings:         3", "Pages:            2", "mixed_content:", "\nhttps://example.com/\n",
+				"  broken_link (broken-links): https://example.com/gone (HTTP 404)\n  mixed_content (mixed-content)",
+			},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"analyzer": "structured-data"`, `"issue": "broken_link"`, `"detail": "block 1: item 1"`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"url,analyzer,issue,detail", "https://example.com/about,structured-data,structured_data_missing_type,block 1: item 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFindings(findings)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+
+	if result := New("json").FormatFindings(nil); !strings.Contains(result, `"findings": []`) {
+		t.Errorf("Expected an empty findings list, got '%s'", result)
+	}
+}
@@ -1,19 +1,22 @@
 package output
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
 	"github.com/benvon/sitemap-crawler/internal/stats"
 )
 
 // Formatter handles output formatting for different formats
 type Formatter struct {
 	format string
+	csv    CSVOptions
+	run    *runinfo.Run
 }
 
 // New creates a new formatter
@@ -30,6 +33,8 @@ func (f *Formatter) FormatProgress(progress *stats.Progress) string {
 		return f.formatProgressJSON(progress)
 	case "csv":
 		return f.formatProgressCSV(progress)
+	case "xml":
+		return f.formatProgressXML(progress)
 	default:
 		return f.formatProgressText(progress)
 	}
@@ -42,8 +47,10 @@ func (f *Formatter) FormatFinalStats(finalStats *stats.FinalStats) string {
 		return f.formatFinalStatsJSON(finalStats)
 	case "csv":
 		return f.formatFinalStatsCSV(finalStats)
+	case "xml":
+		return f.formatFinalStatsXML(finalStats)
 	default:
-		return f.formatFinalStatsText(finalStats)
+		return f.runHeader() + f.formatFinalStatsText(finalStats)
 	}
 }
 
@@ -54,8 +61,10 @@ func (f *Formatter) FormatCacheStats(cacheStats *stats.CacheStats) string {
 		return f.formatCacheStatsJSON(cacheStats)
 	case "csv":
 		return f.formatCacheStatsCSV(cacheStats)
+	case "xml":
+		return f.formatCacheStatsXML(cacheStats)
 	default:
-		return f.formatCacheStatsText(cacheStats)
+		return f.runHeader() + f.formatCacheStatsText(cacheStats)
 	}
 }
 
@@ -64,13 +73,22 @@ func (f *Formatter) WriteToFile(filename string, content string) error {
 	return os.WriteFile(filename, []byte(content), 0600)
 }
 
-// formatProgressText formats progress as text
+// formatProgressText formats progress as text. During a multi-pass crawl
+// the counts are the running pass's.
 func (f *Formatter) formatProgressText(progress *stats.Progress) string {
+	prefix := ""
+	processed, total, percentage := progress.Processed, progress.Total, progress.Percentage
+	if phase := progress.Phase; phase != nil {
+		prefix = fmt.Sprintf("Phase %d/%d (%s) | ", phase.Number, phase.Count, phase.Name)
+		processed, total, percentage = phase.Processed, phase.Total, phase.Percentage
+	}
+
 	return fmt.Sprintf(
-		"Progress: %d/%d (%.1f%%) | Success Rate: %.1f%% | Avg Duration: %s",
-		progress.Processed,
-		progress.Total,
-		progress.Percentage,
+		"%sProgress: %d/%d (%.1f%%) | Success Rate: %.1f%% | Avg Duration: %s",
+		prefix,
+		processed,
+		total,
+		percentage,
 		progress.SuccessRate,
 		progress.AverageDuration,
 	)
@@ -79,6 +97,7 @@ func (f *Formatter) formatProgressText(progress *stats.Progress) string {
 // formatProgressJSON formats progress as JSON
 func (f *Formatter) formatProgressJSON(progress *stats.Progress) string {
 	data := map[string]interface{}{
+		"schema_version":   SchemaVersion,
 		"timestamp":        time.Now().Format(time.RFC3339),
 		"processed":        progress.Processed,
 		"total":            progress.Total,
@@ -86,15 +105,25 @@ func (f *Formatter) formatProgressJSON(progress *stats.Progress) string {
 		"success_rate":     progress.SuccessRate,
 		"average_duration": progress.AverageDuration.String(),
 	}
+	if phase := progress.Phase; phase != nil {
+		data["phase"] = map[string]interface{}{
+			"name":                phase.Name,
+			"number":              phase.Number,
+			"count":               phase.Count,
+			"processed":           phase.Processed,
+			"total":               phase.Total,
+			"percentage":          phase.Percentage,
+			"estimated_time_left": phase.EstimatedTimeLeft.String(),
+		}
+	}
 
-	jsonData, _ := json.MarshalIndent(data, "", "  ")
-	return string(jsonData)
+	return f.marshalJSON(data)
 }
 
 // formatProgressCSV formats progress as CSV
 func (f *Formatter) formatProgressCSV(progress *stats.Progress) string {
 	var builder strings.Builder
-	writer := csv.NewWriter(&builder)
+	writer := f.newCSVWriter(&builder)
 
 	if err := writer.Write([]string{
 		"timestamp",
@@ -124,13 +153,15 @@ func (f *Formatter) formatProgressCSV(progress *stats.Progress) string {
 
 // formatFinalStatsText formats final statistics as text
 func (f *Formatter) formatFinalStatsText(finalStats *stats.FinalStats) string {
-	return fmt.Sprintf(`
+	text := fmt.Sprintf(`
 Final Statistics:
 ================
 Total Processed:  %d
 Total Success:    %d
 Total Errors:     %d
 Success Rate:     %.1f%%
+First-Attempt:    %.1f%%
+Total Retries:    %d (%d URLs retried, %d succeeded after retry, max %d attempts)
 Average Duration: %s
 Min Duration:     %s
 Max Duration:     %s
@@ -140,16 +171,72 @@ Total Duration:   %s
 		finalStats.TotalSuccess,
 		finalStats.TotalErrors,
 		finalStats.SuccessRate,
+		finalStats.FirstAttemptSuccessRate,
+		finalStats.TotalRetries,
+		finalStats.RetriedURLs,
+		finalStats.SuccessAfterRetry,
+		finalStats.MaxAttempts,
 		finalStats.AverageDuration,
 		finalStats.MinDuration,
 		finalStats.MaxDuration,
 		finalStats.TotalDuration,
 	)
+
+	var builder strings.Builder
+	builder.WriteString(text)
+
+	if len(finalStats.ErrorsByCategory) > 0 {
+		builder.WriteString("\nErrors by Category:\n")
+		for _, category := range stats.ErrorCategories() {
+			if count := finalStats.ErrorsByCategory[category]; count > 0 {
+				fmt.Fprintf(&builder, "  %-20s %d\n", category+":", count)
+			}
+		}
+	}
+
+	if finalStats.RejectedURLs > 0 {
+		fmt.Fprintf(&builder, "\nRejected Sitemap URLs: %d\n", finalStats.RejectedURLs)
+		for _, reason := range slices.Sorted(maps.Keys(finalStats.RejectedByReason)) {
+			fmt.Fprintf(&builder, "  %-20s %d\n", reason+":", finalStats.RejectedByReason[reason])
+		}
+	}
+
+	if finalStats.SkippedURLs > 0 {
+		fmt.Fprintf(&builder, "\nSkipped After Deadline: %d\n", finalStats.SkippedURLs)
+	}
+
+	if finalStats.CancelReason != "" {
+		fmt.Fprintf(&builder, "\nCancelled:        %s\n", finalStats.CancelReason)
+	}
+
+	// Percentiles only say something per host when there is more than one
+	if len(finalStats.Hosts) > 1 {
+		builder.WriteString("\nPer-Host Latency:\n")
+		for _, host := range finalStats.Hosts {
+			fmt.Fprintf(&builder, "  %s (%d requests, %d errors)\n    p50: %s  p90: %s  p95: %s  p99: %s  max: %s\n",
+				host.Host, host.Requests, host.Errors, host.P50, host.P90, host.P95, host.P99, host.Max)
+		}
+	}
+
+	// A single minute adds nothing to the totals above
+	if len(finalStats.Timeline) > 1 {
+		writeTimeline(&builder, finalStats.Timeline)
+	}
+
+	if len(finalStats.Phases) > 0 {
+		builder.WriteString("\nPhases:\n")
+		for _, phase := range finalStats.Phases {
+			fmt.Fprintf(&builder, "  %-20s %s\n", phase.Name+":", phase.Duration)
+		}
+	}
+
+	return builder.String()
 }
 
 // formatFinalStatsJSON formats final statistics as JSON
 func (f *Formatter) formatFinalStatsJSON(finalStats *stats.FinalStats) string {
 	data := map[string]interface{}{
+		"schema_version":   SchemaVersion,
 		"timestamp":        time.Now().Format(time.RFC3339),
 		"total_processed":  finalStats.TotalProcessed,
 		"total_success":    finalStats.TotalSuccess,
@@ -159,18 +246,66 @@ func (f *Formatter) formatFinalStatsJSON(finalStats *stats.FinalStats) string {
 		"min_duration":     finalStats.MinDuration.String(),
 		"max_duration":     finalStats.MaxDuration.String(),
 		"total_duration":   finalStats.TotalDuration.String(),
+
+		"first_attempt_success_rate": finalStats.FirstAttemptSuccessRate,
+		"total_attempts":             finalStats.TotalAttempts,
+		"total_retries":              finalStats.TotalRetries,
+		"retried_urls":               finalStats.RetriedURLs,
+		"success_after_retry":        finalStats.SuccessAfterRetry,
+		"max_attempts":               finalStats.MaxAttempts,
+	}
+	if len(finalStats.ErrorsByCategory) > 0 {
+		data["errors_by_category"] = finalStats.ErrorsByCategory
+	}
+	if finalStats.RejectedURLs > 0 {
+		data["rejected_urls"] = finalStats.RejectedURLs
+		data["rejected_by_reason"] = finalStats.RejectedByReason
+	}
+	if finalStats.SkippedURLs > 0 {
+		data["skipped_urls"] = finalStats.SkippedURLs
+	}
+	if finalStats.CancelReason != "" {
+		data["cancel_reason"] = finalStats.CancelReason
+	}
+	if len(finalStats.Hosts) > 0 {
+		hosts := make([]map[string]interface{}, 0, len(finalStats.Hosts))
+		for _, host := range finalStats.Hosts {
+			hosts = append(hosts, map[string]interface{}{
+				"host":     host.Host,
+				"requests": host.Requests,
+				"errors":   host.Errors,
+				"p50":      host.P50.String(),
+				"p90":      host.P90.String(),
+				"p95":      host.P95.String(),
+				"p99":      host.P99.String(),
+				"max":      host.Max.String(),
+			})
+		}
+		data["hosts"] = hosts
+	}
+	if len(finalStats.Timeline) > 0 {
+		data["timeline"] = timelineJSON(finalStats.Timeline)
+	}
+	if len(finalStats.Phases) > 0 {
+		phases := make([]map[string]interface{}, 0, len(finalStats.Phases))
+		for _, phase := range finalStats.Phases {
+			phases = append(phases, map[string]interface{}{
+				"name":     phase.Name,
+				"duration": phase.Duration.String(),
+			})
+		}
+		data["phases"] = phases
 	}
 
-	jsonData, _ := json.MarshalIndent(data, "", "  ")
-	return string(jsonData)
+	return f.marshalJSON(data)
 }
 
 // formatFinalStatsCSV formats final statistics as CSV
 func (f *Formatter) formatFinalStatsCSV(finalStats *stats.FinalStats) string {
 	var builder strings.Builder
-	writer := csv.NewWriter(&builder)
+	writer := f.newCSVWriter(&builder)
 
-	if err := writer.Write([]string{
+	header := []string{
 		"timestamp",
 		"total_processed",
 		"total_success",
@@ -180,11 +315,17 @@ func (f *Formatter) formatFinalStatsCSV(finalStats *stats.FinalStats) string {
 		"min_duration",
 		"max_duration",
 		"total_duration",
-	}); err != nil {
-		return ""
+		"first_attempt_success_rate",
+		"total_attempts",
+		"total_retries",
+		"retried_urls",
+		"success_after_retry",
+		"max_attempts",
+		"rejected_urls",
+		"skipped_urls",
+		"cancel_reason",
 	}
-
-	if err := writer.Write([]string{
+	row := []string{
 		time.Now().Format(time.RFC3339),
 		fmt.Sprintf("%d", finalStats.TotalProcessed),
 		fmt.Sprintf("%d", finalStats.TotalSuccess),
@@ -194,7 +335,28 @@ func (f *Formatter) formatFinalStatsCSV(finalStats *stats.FinalStats) string {
 		finalStats.MinDuration.String(),
 		finalStats.MaxDuration.String(),
 		finalStats.TotalDuration.String(),
-	}); err != nil {
+		fmt.Sprintf("%.1f", finalStats.FirstAttemptSuccessRate),
+		fmt.Sprintf("%d", finalStats.TotalAttempts),
+		fmt.Sprintf("%d", finalStats.TotalRetries),
+		fmt.Sprintf("%d", finalStats.RetriedURLs),
+		fmt.Sprintf("%d", finalStats.SuccessAfterRetry),
+		fmt.Sprintf("%d", finalStats.MaxAttempts),
+		fmt.Sprintf("%d", finalStats.RejectedURLs),
+		fmt.Sprintf("%d", finalStats.SkippedURLs),
+		finalStats.CancelReason,
+	}
+
+	// Every category gets a column so that rows from different runs line up
+	for _, category := range stats.ErrorCategories() {
+		header = append(header, "errors_"+string(category))
+		row = append(row, fmt.Sprintf("%d", finalStats.ErrorsByCategory[category]))
+	}
+
+	if err := writer.Write(header); err != nil {
+		return ""
+	}
+
+	if err := writer.Write(row); err != nil {
 		return ""
 	}
 
@@ -212,34 +374,40 @@ Cache Misses:     %d
 Cache Hit Rate:   %.1f%%
 Warm Up Time:     %s
 Verification Time: %s
+Changed Validators: %d
+Changed Headers:  %d
 `,
 		cacheStats.CacheHits,
 		cacheStats.CacheMisses,
 		cacheStats.CacheHitRate,
 		cacheStats.WarmUpTime,
 		cacheStats.VerifyTime,
+		cacheStats.ValidatorChanges,
+		cacheStats.HeaderChanges,
 	)
 }
 
 // formatCacheStatsJSON formats cache statistics as JSON
 func (f *Formatter) formatCacheStatsJSON(cacheStats *stats.CacheStats) string {
 	data := map[string]interface{}{
-		"timestamp":      time.Now().Format(time.RFC3339),
-		"cache_hits":     cacheStats.CacheHits,
-		"cache_misses":   cacheStats.CacheMisses,
-		"cache_hit_rate": cacheStats.CacheHitRate,
-		"warm_up_time":   cacheStats.WarmUpTime.String(),
-		"verify_time":    cacheStats.VerifyTime.String(),
+		"schema_version":    SchemaVersion,
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"cache_hits":        cacheStats.CacheHits,
+		"cache_misses":      cacheStats.CacheMisses,
+		"cache_hit_rate":    cacheStats.CacheHitRate,
+		"warm_up_time":      cacheStats.WarmUpTime.String(),
+		"verify_time":       cacheStats.VerifyTime.String(),
+		"validator_changes": cacheStats.ValidatorChanges,
+		"header_changes":    cacheStats.HeaderChanges,
 	}
 
-	jsonData, _ := json.MarshalIndent(data, "", "  ")
-	return string(jsonData)
+	return f.marshalJSON(data)
 }
 
 // formatCacheStatsCSV formats cache statistics as CSV
 func (f *Formatter) formatCacheStatsCSV(cacheStats *stats.CacheStats) string {
 	var builder strings.Builder
-	writer := csv.NewWriter(&builder)
+	writer := f.newCSVWriter(&builder)
 
 	if err := writer.Write([]string{
 		"timestamp",
@@ -248,6 +416,8 @@ func (f *Formatter) formatCacheStatsCSV(cacheStats *stats.CacheStats) string {
 		"cache_hit_rate",
 		"warm_up_time",
 		"verify_time",
+		"validator_changes",
+		"header_changes",
 	}); err != nil {
 		return ""
 	}
@@ -259,6 +429,8 @@ func (f *Formatter) formatCacheStatsCSV(cacheStats *stats.CacheStats) string {
 		fmt.Sprintf("%.1f", cacheStats.CacheHitRate),
 		cacheStats.WarmUpTime.String(),
 		cacheStats.VerifyTime.String(),
+		fmt.Sprintf("%d", cacheStats.ValidatorChanges),
+		fmt.Sprintf("%d", cacheStats.HeaderChanges),
 	}); err != nil {
 		return ""
 	}
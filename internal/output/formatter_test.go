@@ -72,6 +72,39 @@ func TestFormatProgress(t *testing.T) {
 	}
 }
 
+func TestFormatProgressPhase(t *testing.T) {
+	t.Parallel()
+
+	progress := &stats.Progress{
+		Processed:   6,
+		Total:       10,
+		Percentage:  60.0,
+		SuccessRate: 100.0,
+		Phase:       &stats.PhaseProgress{Name: stats.PhaseVerify, Number: 2, Count: 2, Processed: 1, Total: 5, Percentage: 20.0, EstimatedTimeLeft: 4 * time.Second},
+	}
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{format: "text", expected: []string{"Phase 2/2 (verify) | Progress: 1/5 (20.0%)"}},
+		{format: "json", expected: []string{`"processed": 6`, `"phase": {`, `"name": "verify"`, `"estimated_time_left": "4s"`}},
+		{format: "xml", expected: []string{`<phase name="verify" number="2" count="2" processed="1" total="5" percentage="20" estimated_time_left="4s">`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatProgress(progress)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
 func TestFormatFinalStats(t *testing.T) {
 	t.Parallel()
 
@@ -120,6 +153,267 @@ func TestFormatFinalStats(t *testing.T) {
 	}
 }
 
+func TestFormatFinalStatsRetries(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{
+		TotalProcessed:          4,
+		TotalSuccess:            3,
+		TotalErrors:             1,
+		SuccessRate:             75.0,
+		TotalAttempts:           9,
+		TotalRetries:            5,
+		RetriedURLs:             2,
+		SuccessAfterRetry:       1,
+		MaxAttempts:             4,
+		FirstAttemptSuccessRate: 50.0,
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"First-Attempt:    50.0%", "Total Retries:    5 (2 URLs retried, 1 succeeded after retry, max 4 attempts)"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"first_attempt_success_rate": 50`, `"total_retries": 5`, `"success_after_retry": 1`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"first_attempt_success_rate,total_attempts,total_retries,retried_urls,success_after_retry,max_attempts", ",50.0,9,5,2,1,4,"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFinalStatsHosts(t *testing.T) {
+	t.Parallel()
+
+	host := func(name string, p50 time.Duration) stats.HostStats {
+		return stats.HostStats{Host: name, Requests: 10, Errors: 1, P50: p50, P90: 2 * p50, P95: 3 * p50, P99: 4 * p50, Max: 5 * p50}
+	}
+
+	singleHost := &stats.FinalStats{Hosts: []stats.HostStats{host("www.example.com", 100*time.Millisecond)}}
+	multiHost := &stats.FinalStats{Hosts: []stats.HostStats{
+		host("assets.example.com", 5*time.Millisecond),
+		host("www.example.com", 100*time.Millisecond),
+	}}
+
+	text := New("text").FormatFinalStats(multiHost)
+	for _, expected := range []string{
+		"Per-Host Latency:",
+		"assets.example.com (10 requests, 1 errors)",
+		"p50: 5ms  p90: 10ms  p95: 15ms  p99: 20ms  max: 25ms",
+		"www.example.com (10 requests, 1 errors)",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected text to contain '%s', got '%s'", expected, text)
+		}
+	}
+
+	if text := New("text").FormatFinalStats(singleHost); strings.Contains(text, "Per-Host Latency") {
+		t.Errorf("Expected no per-host section for a single host, got '%s'", text)
+	}
+
+	jsonOutput := New("json").FormatFinalStats(singleHost)
+	for _, expected := range []string{`"hosts": [`, `"host": "www.example.com"`, `"p99": "400ms"`} {
+		if !strings.Contains(jsonOutput, expected) {
+			t.Errorf("Expected JSON to contain '%s', got '%s'", expected, jsonOutput)
+		}
+	}
+}
+
+func TestFormatFinalStatsErrorCategories(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{
+		TotalProcessed:   10,
+		TotalSuccess:     7,
+		TotalErrors:      3,
+		ErrorsByCategory: map[stats.ErrorCategory]int{stats.ErrorTimeout: 2, stats.ErrorDNS: 1},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Errors by Category:", "dns:", "timeout:"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"errors_by_category": {`, `"timeout": 2`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"errors_dns,errors_connection_refused", ",1,0,0,0,0,0,0,0,2,0,0,0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFinalStatsRejectedURLs(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{
+		RejectedURLs:     3,
+		RejectedByReason: map[string]int{"bad_scheme": 2, "malformed": 1},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Rejected Sitemap URLs: 3", "bad_scheme:          2"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"rejected_urls": 3`, `"bad_scheme": 2`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"max_attempts,rejected_urls", ",0,3,"},
+		},
+		{
+			name:     "xml format",
+			format:   "xml",
+			expected: []string{"<rejected_urls>3</rejected_urls>", `<reason name="bad_scheme">2</reason>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFinalStatsSkippedURLs(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{SkippedURLs: 4}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "Skipped After Deadline: 4"},
+		{format: "json", expected: `"skipped_urls": 4`},
+		{format: "csv", expected: "rejected_urls,skipped_urls"},
+		{format: "xml", expected: "<skipped_urls>4</skipped_urls>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatFinalStatsCancelReason(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{CancelReason: "too many 403 errors"}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "Cancelled:        too many 403 errors"},
+		{format: "json", expected: `"cancel_reason": "too many 403 errors"`},
+		{format: "csv", expected: "skipped_urls,cancel_reason"},
+		{format: "xml", expected: "<cancel_reason>too many 403 errors</cancel_reason>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatFinalStatsPhases(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{Phases: []stats.PhaseStats{
+		{Name: stats.PhaseWarmUp, Duration: 3 * time.Second},
+		{Name: stats.PhaseVerify, Duration: 2 * time.Second},
+	}}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "verify:              2s"},
+		{format: "json", expected: `"name": "warm-up"`},
+		{format: "xml", expected: `<phase name="verify" duration="2s"></phase>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatFinalStats(finalStats)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestFormatCacheStats(t *testing.T) {
 	t.Parallel()
 
@@ -129,6 +423,9 @@ func TestFormatCacheStats(t *testing.T) {
 		CacheHitRate: 60.0,
 		WarmUpTime:   500 * time.Millisecond,
 		VerifyTime:   300 * time.Millisecond,
+
+		ValidatorChanges: 2,
+		HeaderChanges:    1,
 	}
 
 	tests := []struct {
@@ -149,7 +446,22 @@ func TestFormatCacheStats(t *testing.T) {
 		{
 			name:     "csv format",
 			format:   "csv",
-			expected: "timestamp,cache_hits,cache_misses,cache_hit_rate,warm_up_time,verify_time",
+			expected: "timestamp,cache_hits,cache_misses,cache_hit_rate,warm_up_time,verify_time,validator_changes,header_changes",
+		},
+		{
+			name:     "json validator changes",
+			format:   "json",
+			expected: `"validator_changes": 2`,
+		},
+		{
+			name:     "json header changes",
+			format:   "json",
+			expected: `"header_changes": 1`,
+		},
+		{
+			name:     "text header changes",
+			format:   "text",
+			expected: "Changed Headers:  1",
 		},
 	}
 
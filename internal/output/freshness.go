@@ -0,0 +1,98 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/freshness"
+)
+
+// FormatLastModReport formats the sitemap lastmod comparison report
+func (f *Formatter) FormatLastModReport(report *freshness.Report) string {
+	switch f.format {
+	case "json":
+		return f.formatLastModReportJSON(report)
+	case "csv":
+		return f.formatLastModReportCSV(report)
+	default:
+		return f.runHeader() + f.formatLastModReportText(report)
+	}
+}
+
+// formatLastModReportText formats the lastmod report as text
+func (f *Formatter) formatLastModReportText(report *freshness.Report) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Lastmod Comparison:
+==================
+URLs Checked:          %d
+Missing Last-Modified: %d
+Discrepancies:         %d
+`, report.Checked, report.MissingLastModified, len(report.Discrepancies))
+
+	for _, discrepancy := range report.Discrepancies {
+		fmt.Fprintf(&builder, "\n%s\n  Sitemap:  %s\n  Server:   %s\n  Off By:   %s\n",
+			discrepancy.URL,
+			discrepancy.SitemapLastMod.Format(time.RFC3339),
+			discrepancy.HeaderLastMod.Format(time.RFC3339),
+			discrepancy.Difference,
+		)
+	}
+
+	return builder.String()
+}
+
+// formatLastModReportJSON formats the lastmod report as JSON
+func (f *Formatter) formatLastModReportJSON(report *freshness.Report) string {
+	discrepancies := make([]map[string]interface{}, len(report.Discrepancies))
+	for i, discrepancy := range report.Discrepancies {
+		discrepancies[i] = map[string]interface{}{
+			"url":                  discrepancy.URL,
+			"sitemap_lastmod":      discrepancy.SitemapLastMod.Format(time.RFC3339),
+			"header_last_modified": discrepancy.HeaderLastMod.Format(time.RFC3339),
+			"etag":                 discrepancy.ETag,
+			"difference":           discrepancy.Difference.String(),
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp":             time.Now().Format(time.RFC3339),
+		"checked":               report.Checked,
+		"missing_last_modified": report.MissingLastModified,
+		"discrepancies":         discrepancies,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatLastModReportCSV formats the lastmod discrepancies as CSV
+func (f *Formatter) formatLastModReportCSV(report *freshness.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"sitemap_lastmod",
+		"header_last_modified",
+		"etag",
+		"difference",
+	}); err != nil {
+		return ""
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		if err := writer.Write([]string{
+			discrepancy.URL,
+			discrepancy.SitemapLastMod.Format(time.RFC3339),
+			discrepancy.HeaderLastMod.Format(time.RFC3339),
+			discrepancy.ETag,
+			discrepancy.Difference.String(),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/freshness"
+)
+
+func TestFormatLastModReport(t *testing.T) {
+	t.Parallel()
+
+	report := &freshness.Report{
+		Checked: 2,
+		Discrepancies: []freshness.Discrepancy{{
+			URL:            "https://example.com/page",
+			SitemapLastMod: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			HeaderLastMod:  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			Difference:     48 * time.Hour,
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: "Off By:   48h0m0s"},
+		{name: "json format", format: "json", expected: `"difference": "48h0m0s"`},
+		{name: "csv format", format: "csv", expected: "https://example.com/page,2024-01-01T00:00:00Z,2024-01-03T00:00:00Z,,48h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatLastModReport(report)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package output
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// FormatHeaderChanges formats the URLs whose compared response headers
+// changed between the warm-up and verification passes
+func (f *Formatter) FormatHeaderChanges(changes []stats.HeaderChange) string {
+	switch f.format {
+	case "json":
+		return f.formatHeaderChangesJSON(changes)
+	case "csv":
+		return f.formatHeaderChangesCSV(changes)
+	default:
+		return f.runHeader() + f.formatHeaderChangesText(changes)
+	}
+}
+
+// formatHeaderChangesText formats the header changes as text, with a count
+// of changed URLs per header first
+func (f *Formatter) formatHeaderChangesText(changes []stats.HeaderChange) string {
+	var builder strings.Builder
+	builder.WriteString(`
+Header Changes Between Passes:
+==============================
+`)
+	if len(changes) == 0 {
+		builder.WriteString("No compared headers changed\n")
+		return builder.String()
+	}
+
+	counts := stats.CountHeaderChanges(changes)
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(&builder, "%s: %d URLs\n", name, counts[name])
+	}
+	for _, change := range changes {
+		fmt.Fprintf(&builder, "\n%s\n", change.URL)
+		for _, diff := range change.Headers {
+			fmt.Fprintf(&builder, "  %s: %s -> %s\n", diff.Name, headerValue(diff.WarmUp), headerValue(diff.Verify))
+		}
+	}
+	return builder.String()
+}
+
+// headerValue shows a header the response did not carry as a dash
+func headerValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// formatHeaderChangesJSON formats the header changes as JSON
+func (f *Formatter) formatHeaderChangesJSON(changes []stats.HeaderChange) string {
+	if changes == nil {
+		changes = []stats.HeaderChange{}
+	}
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"headers":        stats.CountHeaderChanges(changes),
+		"urls":           changes,
+	})
+}
+
+// formatHeaderChangesCSV formats the header changes as CSV with one row per
+// changed header of each URL
+func (f *Formatter) formatHeaderChangesCSV(changes []stats.HeaderChange) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"url", "header", "warm_up", "verify"}); err != nil {
+		return ""
+	}
+
+	for _, change := range changes {
+		for _, diff := range change.Headers {
+			if err := writer.Write([]string{change.URL, diff.Name, diff.WarmUp, diff.Verify}); err != nil {
+				return ""
+			}
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
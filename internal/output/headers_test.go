@@ -0,0 +1,71 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatHeaderChanges(t *testing.T) {
+	t.Parallel()
+
+	changes := []stats.HeaderChange{
+		{URL: "https://example.com/", Headers: []stats.HeaderDiff{
+			{Name: "Cache-Control", WarmUp: "public, max-age=300", Verify: "private, no-store"},
+			{Name: "Vary", WarmUp: "Accept-Encoding", Verify: ""},
+		}},
+		{URL: "https://example.com/pricing", Headers: []stats.HeaderDiff{
+			{Name: "Cache-Control", WarmUp: "max-age=60", Verify: "max-age=0"},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		changes  []stats.HeaderChange
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			changes:  changes,
+			expected: []string{"Header Changes Between Passes", "Cache-Control: 2 URLs", "Vary: 1 URLs", `  Cache-Control: "public, max-age=300" -> "private, no-store"`, `  Vary: "Accept-Encoding" -> -`},
+		},
+		{
+			name:     "text format without changes",
+			format:   "text",
+			expected: []string{"No compared headers changed"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			changes:  changes,
+			expected: []string{`"Cache-Control": 2`, `"url": "https://example.com/pricing"`, `"warm_up": "max-age=60"`, `"verify": "max-age=0"`},
+		},
+		{
+			name:     "json format without changes",
+			format:   "json",
+			expected: []string{`"urls": []`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			changes:  changes,
+			expected: []string{"url,header,warm_up,verify", `https://example.com/,Cache-Control,"public, max-age=300","private, no-store"`, "https://example.com/,Vary,Accept-Encoding,", "https://example.com/pricing,Cache-Control,max-age=60,max-age=0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatHeaderChanges(tt.changes)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
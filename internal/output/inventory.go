@@ -0,0 +1,142 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/inventory"
+)
+
+// FormatInventoryReport formats the sitemap metadata report of parse --stats
+func (f *Formatter) FormatInventoryReport(report *inventory.Report) string {
+	switch f.format {
+	case "json":
+		return f.formatInventoryReportJSON(report)
+	case "csv":
+		return f.formatInventoryReportCSV(report)
+	default:
+		return f.runHeader() + f.formatInventoryReportText(report)
+	}
+}
+
+// formatInventoryReportText formats the sitemap metadata report as text
+func (f *Formatter) formatInventoryReportText(report *inventory.Report) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Sitemap Statistics:
+==================
+Sitemaps:           %d
+URLs Listed:        %d
+Unique URLs:        %d
+Duplicate Listings: %d
+`, len(report.Sitemaps), report.Listed, report.UniqueURLs, report.DuplicateListings())
+
+	writeCounts(&builder, "URLs per Sitemap", report.Sitemaps)
+	writeCounts(&builder, "URLs per Host", report.Hosts)
+
+	writeCounts(&builder, "Lastmod Age", report.LastModAge)
+	fmt.Fprintf(&builder, "  missing: %d\n  future: %d\n", report.MissingLastMod, report.FutureLastMod)
+
+	writeCounts(&builder, "Priority", report.Priority)
+	fmt.Fprintf(&builder, "  missing: %d\n  invalid: %d\n", report.MissingPriority, report.InvalidPriority)
+
+	if len(report.Duplicates) > 0 {
+		builder.WriteString("\nDuplicates:\n")
+		for _, duplicate := range report.Duplicates {
+			fmt.Fprintf(&builder, "  %s: %d listings in %s\n", duplicate.URL, duplicate.Listings, strings.Join(duplicate.Sitemaps, ", "))
+		}
+	}
+
+	return builder.String()
+}
+
+// writeCounts writes a titled list of counts
+func writeCounts(builder *strings.Builder, title string, counts []inventory.Count) {
+	fmt.Fprintf(builder, "\n%s:\n", title)
+	for _, count := range counts {
+		fmt.Fprintf(builder, "  %s: %d\n", count.Name, count.URLs)
+	}
+}
+
+// formatInventoryReportJSON formats the sitemap metadata report as JSON
+func (f *Formatter) formatInventoryReportJSON(report *inventory.Report) string {
+	duplicates := make([]map[string]interface{}, len(report.Duplicates))
+	for i, duplicate := range report.Duplicates {
+		duplicates[i] = map[string]interface{}{
+			"url":      duplicate.URL,
+			"listings": duplicate.Listings,
+			"sitemaps": duplicate.Sitemaps,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp":          time.Now().Format(time.RFC3339),
+		"sitemaps":           countsJSON(report.Sitemaps, "sitemap"),
+		"listed":             report.Listed,
+		"unique_urls":        report.UniqueURLs,
+		"duplicate_listings": report.DuplicateListings(),
+		"duplicates":         duplicates,
+		"hosts":              countsJSON(report.Hosts, "host"),
+		"lastmod_age":        countsJSON(report.LastModAge, "age"),
+		"missing_lastmod":    report.MissingLastMod,
+		"future_lastmod":     report.FutureLastMod,
+		"priority":           countsJSON(report.Priority, "priority"),
+		"missing_priority":   report.MissingPriority,
+		"invalid_priority":   report.InvalidPriority,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// countsJSON lists counts as objects with the name under key
+func countsJSON(counts []inventory.Count, key string) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(counts))
+	for i, count := range counts {
+		result[i] = map[string]interface{}{key: count.Name, "urls": count.URLs}
+	}
+	return result
+}
+
+// formatInventoryReportCSV formats the sitemap metadata report as CSV, one
+// row per count with the section it belongs to
+func (f *Formatter) formatInventoryReportCSV(report *inventory.Report) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	rows := [][]string{{"section", "name", "urls"}}
+	addRows := func(section string, counts []inventory.Count) {
+		for _, count := range counts {
+			rows = append(rows, []string{section, count.Name, strconv.Itoa(count.URLs)})
+		}
+	}
+	addRows("summary", []inventory.Count{
+		{Name: "listed", URLs: report.Listed},
+		{Name: "unique", URLs: report.UniqueURLs},
+		{Name: "duplicate_listings", URLs: report.DuplicateListings()},
+	})
+	addRows("sitemap", report.Sitemaps)
+	addRows("host", report.Hosts)
+	addRows("lastmod_age", slices.Concat(report.LastModAge, []inventory.Count{
+		{Name: "missing", URLs: report.MissingLastMod},
+		{Name: "future", URLs: report.FutureLastMod},
+	}))
+	addRows("priority", slices.Concat(report.Priority, []inventory.Count{
+		{Name: "missing", URLs: report.MissingPriority},
+		{Name: "invalid", URLs: report.InvalidPriority},
+	}))
+	for _, duplicate := range report.Duplicates {
+		rows = append(rows, []string{"duplicate", duplicate.URL, strconv.Itoa(duplicate.Listings)})
+	}
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/inventory"
+)
+
+func TestFormatInventoryReport(t *testing.T) {
+	t.Parallel()
+
+	report := &inventory.Report{
+		Listed:     3,
+		UniqueURLs: 2,
+		Sitemaps:   []inventory.Count{{Name: "https://example.com/pages.xml", URLs: 3}},
+		Hosts:      []inventory.Count{{Name: "example.com", URLs: 2}},
+		Duplicates: []inventory.Duplicate{{
+			URL:      "https://example.com/a",
+			Listings: 2,
+			Sitemaps: []string{"https://example.com/pages.xml"},
+		}},
+		LastModAge:     []inventory.Count{{Name: "under 1 day", URLs: 1}},
+		MissingLastMod: 1,
+		Priority:       []inventory.Count{{Name: "0.8", URLs: 2}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Duplicate Listings: 1", "  example.com: 2", "  0.8: 2", "https://example.com/a: 2 listings in https://example.com/pages.xml"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"duplicate_listings": 1`, `"host": "example.com"`, `"missing_lastmod": 1`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"section,name,urls", "summary,duplicate_listings,1", "lastmod_age,missing,1", "priority,0.8,2", "duplicate,https://example.com/a,2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatInventoryReport(report)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
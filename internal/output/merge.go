@@ -0,0 +1,312 @@
+package output
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// MergedShard is one of the results files merged into an aggregate
+type MergedShard struct {
+	Path  string `json:"path"`
+	RunID string `json:"run_id,omitempty"`
+
+	// Results is how many entries the file had, and Kept how many of them
+	// the merged results use
+	Results int `json:"results"`
+	Kept    int `json:"kept"`
+}
+
+// PhaseSummary aggregates the merged results of one crawl phase.
+// Percentiles are nearest-rank over every request, as in the host stats.
+type PhaseSummary struct {
+	Phase       string        `json:"phase"`
+	URLs        int           `json:"urls"`
+	Success     int           `json:"success"`
+	Errors      int           `json:"errors"`
+	SuccessRate float64       `json:"success_rate"`
+	Average     time.Duration `json:"average"`
+	Min         time.Duration `json:"min"`
+	P50         time.Duration `json:"p50"`
+	P90         time.Duration `json:"p90"`
+	P95         time.Duration `json:"p95"`
+	P99         time.Duration `json:"p99"`
+	Max         time.Duration `json:"max"`
+
+	// CacheHits and CacheMisses count the results that carried a cache
+	// status
+	CacheHits   int `json:"cache_hits"`
+	CacheMisses int `json:"cache_misses"`
+
+	// Omitted is how many successful results sampled files left out. They
+	// count in URLs, Success and CacheHits, but not in the durations.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// MergedResults combines the results files of several shards or runs
+type MergedResults struct {
+	Shards []MergedShard
+
+	// Duplicates is how many entries were dropped because a newer result
+	// for the same URL and phase was merged
+	Duplicates int
+
+	// Results holds one entry per URL and phase, sorted by phase and URL
+	Results []ResultEntry
+
+	// Phases summarizes the results per phase, in the order the phases
+	// first appear
+	Phases []PhaseSummary
+
+	// Sampling combines the sampling of the merged files; nil if none was
+	// sampled
+	Sampling *Sampling
+}
+
+// mergeKey identifies the result of a URL in a phase, and at an edge for
+// verification passes sent to several
+type mergeKey struct {
+	phase string
+	edge  string
+	url   string
+}
+
+// MergeResults merges results files, given with the paths they were read
+// from. Each URL keeps one result per phase: the one from the most recently
+// written file, or from the later file on the command line when two were
+// written at the same time, and within a file the last one, as repeated
+// passes write them. The result does not depend on how the URLs were split
+// between shards.
+func MergeResults(paths []string, files []*ResultsFile) *MergedResults {
+	// Older files are applied first so that newer ones replace them
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return files[order[a]].Timestamp.Before(files[order[b]].Timestamp)
+	})
+
+	type source struct {
+		shard int
+		entry ResultEntry
+	}
+	merged := &MergedResults{Shards: make([]MergedShard, len(files))}
+	kept := make(map[mergeKey]source)
+	var phases []string
+	total := 0
+	for _, i := range order {
+		file := files[i]
+		merged.Shards[i] = MergedShard{Path: paths[i], Results: len(file.Results)}
+		if file.Run != nil {
+			merged.Shards[i].RunID = file.Run.ID
+		}
+		total += len(file.Results)
+		merged.Sampling = merged.Sampling.combine(file.Sampling)
+
+		for _, entry := range file.Results {
+			key := mergeKey{phase: entry.Phase, edge: entry.Edge, url: entry.URL}
+			if !slices.Contains(phases, entry.Phase) {
+				phases = append(phases, entry.Phase)
+			}
+			kept[key] = source{shard: i, entry: entry}
+		}
+	}
+
+	// A phase whose results were all left out still has a summary
+	if merged.Sampling != nil {
+		for _, phase := range slices.Sorted(maps.Keys(merged.Sampling.Omitted)) {
+			if !slices.Contains(phases, phase) {
+				phases = append(phases, phase)
+			}
+		}
+	}
+
+	phaseIndex := make(map[string]int, len(phases))
+	for i, phase := range phases {
+		phaseIndex[phase] = i
+	}
+	for _, source := range kept {
+		merged.Shards[source.shard].Kept++
+		merged.Results = append(merged.Results, source.entry)
+	}
+	merged.Duplicates = total - len(merged.Results)
+	sort.Slice(merged.Results, func(a, b int) bool {
+		first, second := merged.Results[a], merged.Results[b]
+		if first.Phase != second.Phase {
+			return phaseIndex[first.Phase] < phaseIndex[second.Phase]
+		}
+		if first.URL != second.URL {
+			return first.URL < second.URL
+		}
+		return first.Edge < second.Edge
+	})
+
+	for _, phase := range phases {
+		merged.Phases = append(merged.Phases, summarizePhase(phase, merged.Results, merged.Sampling.omitted(phase)))
+	}
+	return merged
+}
+
+// summarizePhase aggregates the results of one phase, with the successful
+// results sampling left out
+func summarizePhase(phase string, results []ResultEntry, omitted Omitted) PhaseSummary {
+	summary := PhaseSummary{
+		Phase:     phase,
+		URLs:      omitted.Results,
+		Success:   omitted.Results,
+		CacheHits: omitted.CacheHits,
+		Omitted:   omitted.Results,
+	}
+	var durations []time.Duration
+	var total time.Duration
+	for _, entry := range results {
+		if entry.Phase != phase {
+			continue
+		}
+		summary.URLs++
+		if entry.Success {
+			summary.Success++
+		} else {
+			summary.Errors++
+		}
+		if entry.CacheStatus != "" {
+			if stats.IsCacheHit(entry.CacheStatus) {
+				summary.CacheHits++
+			} else {
+				summary.CacheMisses++
+			}
+		}
+		durations = append(durations, entry.Duration)
+		total += entry.Duration
+	}
+	if summary.URLs > 0 {
+		summary.SuccessRate = float64(summary.Success) / float64(summary.URLs) * 100
+	}
+	if len(durations) == 0 {
+		return summary
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	summary.Average = total / time.Duration(len(durations))
+	summary.Min = durations[0]
+	summary.P50 = stats.Percentile(durations, 50)
+	summary.P90 = stats.Percentile(durations, 90)
+	summary.P95 = stats.Percentile(durations, 95)
+	summary.P99 = stats.Percentile(durations, 99)
+	summary.Max = durations[len(durations)-1]
+	return summary
+}
+
+// FormatMergedResults formats the aggregate of merged results files as
+// text, JSON or Markdown
+func (f *Formatter) FormatMergedResults(merged *MergedResults) string {
+	switch f.format {
+	case "json":
+		return f.formatMergedResultsJSON(merged)
+	case "markdown":
+		return f.formatMergedResultsMarkdown(merged)
+	default:
+		return f.formatMergedResultsText(merged)
+	}
+}
+
+// formatMergedResultsText formats merged results as text
+func (f *Formatter) formatMergedResultsText(merged *MergedResults) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Merged Results:
+==============
+Files:      %d
+Results:    %d
+Duplicates: %d
+`, len(merged.Shards), len(merged.Results), merged.Duplicates)
+
+	builder.WriteString("\nFiles:\n")
+	for _, shard := range merged.Shards {
+		fmt.Fprintf(&builder, "  %s: %d results, %d kept%s\n", shard.Path, shard.Results, shard.Kept, runLabel(shard.RunID, " (run %s)"))
+	}
+
+	for _, phase := range merged.Phases {
+		fmt.Fprintf(&builder, `
+Phase %s:
+  URLs:         %d (%d success, %d errors, %.1f%% success rate)
+  Duration:     avg %s, min %s, max %s
+  Percentiles:  p50 %s, p90 %s, p95 %s, p99 %s
+`, phase.Phase, phase.URLs, phase.Success, phase.Errors, phase.SuccessRate,
+			phase.Average, phase.Min, phase.Max, phase.P50, phase.P90, phase.P95, phase.P99)
+		if phase.Omitted > 0 {
+			fmt.Fprintf(&builder, "  Sampled:      %d successful results omitted, not in the durations\n", phase.Omitted)
+		}
+		if phase.CacheHits+phase.CacheMisses > 0 {
+			fmt.Fprintf(&builder, "  Cache:        %d hits, %d misses\n", phase.CacheHits, phase.CacheMisses)
+		}
+	}
+	return builder.String()
+}
+
+// runLabel formats a run ID, or returns nothing for files without one
+func runLabel(id, format string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf(format, id)
+}
+
+// formatMergedResultsJSON formats merged results as JSON
+func (f *Formatter) formatMergedResultsJSON(merged *MergedResults) string {
+	phases := make([]map[string]interface{}, len(merged.Phases))
+	for i, phase := range merged.Phases {
+		phases[i] = map[string]interface{}{
+			"phase":        phase.Phase,
+			"urls":         phase.URLs,
+			"success":      phase.Success,
+			"errors":       phase.Errors,
+			"success_rate": phase.SuccessRate,
+			"average":      phase.Average.String(),
+			"min":          phase.Min.String(),
+			"p50":          phase.P50.String(),
+			"p90":          phase.P90.String(),
+			"p95":          phase.P95.String(),
+			"p99":          phase.P99.String(),
+			"max":          phase.Max.String(),
+			"cache_hits":   phase.CacheHits,
+			"cache_misses": phase.CacheMisses,
+			"omitted":      phase.Omitted,
+		}
+	}
+
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"files":          merged.Shards,
+		"results":        len(merged.Results),
+		"duplicates":     merged.Duplicates,
+		"phases":         phases,
+	})
+}
+
+// formatMergedResultsMarkdown formats merged results as Markdown, for job
+// summaries
+func (f *Formatter) formatMergedResultsMarkdown(merged *MergedResults) string {
+	var builder strings.Builder
+	builder.WriteString("## Merged Results\n\n")
+	fmt.Fprintf(&builder, "%d files, %d results, %d duplicates dropped\n\n", len(merged.Shards), len(merged.Results), merged.Duplicates)
+
+	builder.WriteString("| File | Run | Results | Kept |\n|---|---|---|---|\n")
+	for _, shard := range merged.Shards {
+		fmt.Fprintf(&builder, "| %s | %s | %d | %d |\n", markdownCell(shard.Path), runLabel(shard.RunID, "%s"), shard.Results, shard.Kept)
+	}
+
+	builder.WriteString("\n| Phase | URLs | Success rate | Errors | p50 | p90 | p95 | p99 | Max |\n|---|---|---|---|---|---|---|---|---|\n")
+	for _, phase := range merged.Phases {
+		fmt.Fprintf(&builder, "| %s | %d | %.1f%% | %d | %s | %s | %s | %s | %s |\n",
+			markdownCell(phase.Phase), phase.URLs, phase.SuccessRate, phase.Errors, phase.P50, phase.P90, phase.P95, phase.P99, phase.Max)
+	}
+	return builder.String()
+}
@@ -0,0 +1,194 @@
+package output
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+)
+
+func TestMergeResults(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	shard1 := resultsFile(
+		entry("https://example.com/a", 200, 100*time.Millisecond, "HIT"),
+		entry("https://example.com/b", 200, 200*time.Millisecond, "MISS"),
+		entry("https://example.com/retried", 503, 50*time.Millisecond, ""),
+	)
+	shard1.Timestamp = start
+	shard1.Run = &runinfo.Run{ID: "shard-1"}
+
+	// The newer shard's result for /retried replaces the older one, even
+	// though it comes first on the command line
+	shard2 := resultsFile(
+		entry("https://example.com/c", 200, 300*time.Millisecond, ""),
+		entry("https://example.com/retried", 200, 400*time.Millisecond, ""),
+		entry("https://example.com/d", 404, 10*time.Millisecond, ""),
+	)
+	shard2.Timestamp = start.Add(time.Hour)
+
+	merged := MergeResults([]string{"shard-2.json", "shard-1.json"}, []*ResultsFile{shard2, shard1})
+
+	expectedShards := []MergedShard{
+		{Path: "shard-2.json", Results: 3, Kept: 3},
+		{Path: "shard-1.json", RunID: "shard-1", Results: 3, Kept: 2},
+	}
+	if !reflect.DeepEqual(merged.Shards, expectedShards) {
+		t.Errorf("Expected shards %+v, got %+v", expectedShards, merged.Shards)
+	}
+	if merged.Duplicates != 1 || len(merged.Results) != 5 {
+		t.Errorf("Expected 5 results and 1 duplicate, got %d and %d", len(merged.Results), merged.Duplicates)
+	}
+	urls := make([]string, len(merged.Results))
+	for i, result := range merged.Results {
+		urls[i] = strings.TrimPrefix(result.URL, "https://example.com")
+	}
+	if expected := []string{"/a", "/b", "/c", "/d", "/retried"}; !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected results %v, got %v", expected, urls)
+	}
+	if retried := merged.Results[4]; !retried.Success || retried.StatusCode != 200 {
+		t.Errorf("Expected the newer result for /retried, got %+v", retried)
+	}
+
+	expectedPhases := []PhaseSummary{{
+		Phase: "crawl", URLs: 5, Success: 4, Errors: 1, SuccessRate: 80,
+		Average: 202 * time.Millisecond, Min: 10 * time.Millisecond,
+		P50: 200 * time.Millisecond, P90: 400 * time.Millisecond, P95: 400 * time.Millisecond, P99: 400 * time.Millisecond,
+		Max: 400 * time.Millisecond, CacheHits: 1, CacheMisses: 1,
+	}}
+	if !reflect.DeepEqual(merged.Phases, expectedPhases) {
+		t.Errorf("Expected phases %+v, got %+v", expectedPhases, merged.Phases)
+	}
+}
+
+func TestMergeResultsKeepsEachEdge(t *testing.T) {
+	t.Parallel()
+
+	verify := func(edge string) ResultEntry {
+		result := entry("https://example.com/a", 200, 100*time.Millisecond, "HIT")
+		result.Phase, result.Edge = "verify", edge
+		return result
+	}
+	merged := MergeResults([]string{"edges.json"}, []*ResultsFile{resultsFile(verify("lhr"), verify("iad"))})
+
+	if merged.Duplicates != 0 || len(merged.Results) != 2 {
+		t.Fatalf("Expected a result per edge, got %+v", merged.Results)
+	}
+	if merged.Results[0].Edge != "iad" || merged.Results[1].Edge != "lhr" {
+		t.Errorf("Expected results ordered by edge, got %s and %s", merged.Results[0].Edge, merged.Results[1].Edge)
+	}
+}
+
+func TestMergeResultsIgnoresSharding(t *testing.T) {
+	t.Parallel()
+
+	all := []ResultEntry{
+		entry("https://example.com/1", 200, 10*time.Millisecond, ""),
+		entry("https://example.com/2", 200, 20*time.Millisecond, ""),
+		entry("https://example.com/3", 500, 30*time.Millisecond, ""),
+		entry("https://example.com/4", 200, 40*time.Millisecond, ""),
+	}
+	whole := MergeResults([]string{"all.json"}, []*ResultsFile{resultsFile(all...)})
+	split := MergeResults([]string{"odd.json", "even.json"}, []*ResultsFile{resultsFile(all[0], all[2]), resultsFile(all[1], all[3])})
+
+	if !reflect.DeepEqual(whole.Results, split.Results) || !reflect.DeepEqual(whole.Phases, split.Phases) {
+		t.Errorf("Expected the same aggregate however the URLs were split, got %+v and %+v", whole.Phases, split.Phases)
+	}
+}
+
+func TestFormatMergedResults(t *testing.T) {
+	t.Parallel()
+
+	shard := resultsFile(
+		entry("https://example.com/a", 200, 100*time.Millisecond, "HIT"),
+		entry("https://example.com/b", 500, 300*time.Millisecond, ""),
+	)
+	shard.Run = &runinfo.Run{ID: "20261016T120000Z-0a1b2c3d"}
+	merged := MergeResults([]string{"shard-1.json", "shard-2.json"}, []*ResultsFile{shard, resultsFile()})
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Merged Results", "Files:      2", "shard-1.json: 2 results, 2 kept (run 20261016T120000Z-0a1b2c3d)", "Phase crawl:", "(1 success, 1 errors, 50.0% success rate)", "p50 100ms, p90 300ms", "1 hits, 0 misses"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"duplicates": 0`, `"path": "shard-2.json"`, `"run_id": "20261016T120000Z-0a1b2c3d"`, `"p99": "300ms"`, `"success_rate": 50`},
+		},
+		{
+			name:     "markdown format",
+			format:   "markdown",
+			expected: []string{"## Merged Results", "| shard-1.json | 20261016T120000Z-0a1b2c3d | 2 | 2 |", "| crawl | 2 | 50.0% | 1 | 100ms | 300ms | 300ms | 300ms | 300ms |"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatMergedResults(merged)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSampledResults(t *testing.T) {
+	t.Parallel()
+
+	sampled := resultsFile(
+		entry("https://example.com/a", 200, 100*time.Millisecond, "MISS"),
+		entry("https://example.com/b", 500, 300*time.Millisecond, ""),
+	)
+	sampled.Sampling = &Sampling{Rate: 0.01, Omitted: map[string]Omitted{
+		"crawl":  {Results: 96, CacheHits: 90},
+		"verify": {Results: 50, CacheHits: 50},
+	}}
+	other := resultsFile(entry("https://example.com/c", 200, 200*time.Millisecond, "HIT"))
+	other.Sampling = &Sampling{Rate: 0.1, Omitted: map[string]Omitted{"crawl": {Results: 2, CacheHits: 1}}}
+
+	merged := MergeResults([]string{"sampled.json", "other.json", "full.json"}, []*ResultsFile{sampled, other, resultsFile()})
+
+	expected := &Sampling{Rate: 0.01, Omitted: map[string]Omitted{
+		"crawl":  {Results: 98, CacheHits: 91},
+		"verify": {Results: 50, CacheHits: 50},
+	}}
+	if !reflect.DeepEqual(merged.Sampling, expected) {
+		t.Errorf("Expected sampling %+v, got %+v", expected, merged.Sampling)
+	}
+
+	// Omitted results count as successes, and a phase whose results were
+	// all left out is still summarized
+	if len(merged.Phases) != 2 {
+		t.Fatalf("Expected 2 phases, got %+v", merged.Phases)
+	}
+	crawl, verify := merged.Phases[0], merged.Phases[1]
+	if crawl.URLs != 101 || crawl.Success != 100 || crawl.Errors != 1 || crawl.CacheHits != 92 || crawl.CacheMisses != 1 || crawl.Omitted != 98 {
+		t.Errorf("Unexpected crawl summary %+v", crawl)
+	}
+	if crawl.P50 != 200*time.Millisecond || crawl.Max != 300*time.Millisecond {
+		t.Errorf("Expected durations from the kept results only, got %+v", crawl)
+	}
+	if verify.Phase != "verify" || verify.URLs != 50 || verify.SuccessRate != 100 || verify.Max != 0 {
+		t.Errorf("Unexpected verify summary %+v", verify)
+	}
+
+	if text := New("text").FormatMergedResults(merged); !strings.Contains(text, "Sampled:      98 successful results omitted") {
+		t.Errorf("Expected omitted results in the text output, got '%s'", text)
+	}
+	if merged := MergeResults([]string{"full.json"}, []*ResultsFile{resultsFile()}); merged.Sampling != nil {
+		t.Errorf("Expected no sampling for unsampled files, got %+v", merged.Sampling)
+	}
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// FormatRejectedReport formats the sitemap URLs that were not crawled and
+// why
+func (f *Formatter) FormatRejectedReport(rejected []parser.Rejection) string {
+	switch f.format {
+	case "json":
+		return f.formatRejectedReportJSON(rejected)
+	case "csv":
+		return f.formatRejectedReportCSV(rejected)
+	default:
+		return f.runHeader() + f.formatRejectedReportText(rejected)
+	}
+}
+
+// rejectedCounts counts rejected URLs per reason
+func rejectedCounts(rejected []parser.Rejection) map[string]int {
+	counts := make(map[string]int)
+	for _, rejection := range rejected {
+		counts[rejection.Reason]++
+	}
+	return counts
+}
+
+// formatRejectedReportText formats the rejected URLs as text
+func (f *Formatter) formatRejectedReportText(rejected []parser.Rejection) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `
+Rejected Sitemap URLs:
+======================
+Rejected: %d
+`, len(rejected))
+
+	counts := rejectedCounts(rejected)
+	for _, reason := range parser.RejectReasons() {
+		if count := counts[reason]; count > 0 {
+			fmt.Fprintf(&builder, "  %-16s %d\n", reason+":", count)
+		}
+	}
+
+	for _, rejection := range rejected {
+		fmt.Fprintf(&builder, "\n%s\n  Reason:   %s (%s)\n", rejection.URL, rejection.Reason, rejection.Detail)
+		if rejection.Sitemap != "" {
+			fmt.Fprintf(&builder, "  Sitemap:  %s\n", rejection.Sitemap)
+		}
+	}
+
+	return builder.String()
+}
+
+// formatRejectedReportJSON formats the rejected URLs as JSON
+func (f *Formatter) formatRejectedReportJSON(rejected []parser.Rejection) string {
+	urls := make([]map[string]interface{}, len(rejected))
+	for i, rejection := range rejected {
+		urls[i] = map[string]interface{}{
+			"url":     rejection.URL,
+			"sitemap": rejection.Sitemap,
+			"reason":  rejection.Reason,
+			"detail":  rejection.Detail,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"rejected":  len(rejected),
+		"by_reason": rejectedCounts(rejected),
+		"urls":      urls,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatRejectedReportCSV formats the rejected URLs as CSV
+func (f *Formatter) formatRejectedReportCSV(rejected []parser.Rejection) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"url", "sitemap", "reason", "detail"}); err != nil {
+		return ""
+	}
+
+	for _, rejection := range rejected {
+		if err := writer.Write([]string{
+			rejection.URL,
+			rejection.Sitemap,
+			rejection.Reason,
+			rejection.Detail,
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
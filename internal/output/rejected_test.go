@@ -0,0 +1,40 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+func TestFormatRejectedReport(t *testing.T) {
+	t.Parallel()
+
+	rejected := []parser.Rejection{{
+		URL:     "ftp://example.com/file",
+		Sitemap: "https://example.com/sitemap.xml",
+		Reason:  parser.RejectScheme,
+		Detail:  `unsupported scheme "ftp"`,
+	}}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: `Reason:   bad_scheme (unsupported scheme "ftp")`},
+		{name: "text counts", format: "text", expected: "bad_scheme:      1"},
+		{name: "json format", format: "json", expected: `"reason": "bad_scheme"`},
+		{name: "csv format", format: "csv", expected: `ftp://example.com/file,https://example.com/sitemap.xml,bad_scheme,"unsupported scheme ""ftp"""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatRejectedReport(rejected)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
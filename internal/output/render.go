@@ -0,0 +1,108 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/render"
+)
+
+// FormatRenderReport formats the headless browser render report
+func (f *Formatter) FormatRenderReport(results []render.Result) string {
+	switch f.format {
+	case "json":
+		return f.formatRenderReportJSON(results)
+	case "csv":
+		return f.formatRenderReportCSV(results)
+	default:
+		return f.runHeader() + f.formatRenderReportText(results)
+	}
+}
+
+// formatRenderReportText formats the render report as text
+func (f *Formatter) formatRenderReportText(results []render.Result) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "\nRender Report:\n=============\nURLs Rendered: %d\n", len(results))
+
+	for _, result := range results {
+		fmt.Fprintf(&builder, "\n%s [%d]\n  Render Time:    %s\n  HTML Size:      %d bytes\n",
+			result.URL, result.StatusCode, result.RenderTime, result.HTMLBytes)
+		if result.CacheStatus != "" {
+			fmt.Fprintf(&builder, "  Cache Status:   %s\n", result.CacheStatus)
+		}
+		if result.Error != "" {
+			fmt.Fprintf(&builder, "  Error:          %s\n", result.Error)
+		}
+		for _, message := range result.ConsoleErrors {
+			fmt.Fprintf(&builder, "  Console Error:  %s\n", message)
+		}
+		for _, message := range result.NetworkErrors {
+			fmt.Fprintf(&builder, "  Network Error:  %s\n", message)
+		}
+	}
+
+	return builder.String()
+}
+
+// formatRenderReportJSON formats the render report as JSON
+func (f *Formatter) formatRenderReportJSON(results []render.Result) string {
+	pages := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		pages[i] = map[string]interface{}{
+			"url":            result.URL,
+			"status_code":    result.StatusCode,
+			"cache_status":   result.CacheStatus,
+			"render_time":    result.RenderTime.String(),
+			"html_bytes":     result.HTMLBytes,
+			"console_errors": result.ConsoleErrors,
+			"network_errors": result.NetworkErrors,
+			"error":          result.Error,
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"pages":     pages,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatRenderReportCSV formats the render report as CSV with one row per URL
+func (f *Formatter) formatRenderReportCSV(results []render.Result) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{
+		"url",
+		"status_code",
+		"cache_status",
+		"render_time_ms",
+		"html_bytes",
+		"console_errors",
+		"network_errors",
+		"error",
+	}); err != nil {
+		return ""
+	}
+
+	for _, result := range results {
+		if err := writer.Write([]string{
+			result.URL,
+			strconv.Itoa(result.StatusCode),
+			result.CacheStatus,
+			strconv.FormatInt(result.RenderTime.Milliseconds(), 10),
+			strconv.Itoa(result.HTMLBytes),
+			strings.Join(result.ConsoleErrors, ";"),
+			strings.Join(result.NetworkErrors, ";"),
+			result.Error,
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/render"
+)
+
+func TestFormatRenderReport(t *testing.T) {
+	t.Parallel()
+
+	results := []render.Result{{
+		URL:           "https://example.com/app",
+		StatusCode:    200,
+		CacheStatus:   "HIT",
+		RenderTime:    1500 * time.Millisecond,
+		HTMLBytes:     2048,
+		ConsoleErrors: []string{"Uncaught TypeError"},
+		NetworkErrors: []string{"https://example.com/api: net::ERR_FAILED"},
+	}}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text format", format: "text", expected: "Console Error:  Uncaught TypeError"},
+		{name: "json format", format: "json", expected: `"render_time": "1.5s"`},
+		{name: "csv format", format: "csv", expected: "https://example.com/app,200,HIT,1500,2048,Uncaught TypeError,https://example.com/api: net::ERR_FAILED,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatRenderReport(results)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
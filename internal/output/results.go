@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// ResultEntry is one request in a results file, tagged with the crawl phase
+// it belongs to
+type ResultEntry struct {
+	Phase string `json:"phase"`
+	stats.Result
+}
+
+// ResultsFile is the per-URL results file written by --results-file
+type ResultsFile struct {
+	SchemaVersion int           `json:"schema_version"`
+	Timestamp     time.Time     `json:"timestamp"`
+	Run           *runinfo.Run  `json:"run,omitempty"`
+	Sampling      *Sampling     `json:"sampling,omitempty"`
+	Results       []ResultEntry `json:"results"`
+}
+
+// Sampling describes the results left out of a sampled results file. Only
+// successful results that were not cache misses are sampled; failures and
+// misses are always kept.
+type Sampling struct {
+	// Rate is the fraction of sampled results that were kept
+	Rate float64 `json:"rate"`
+
+	// Omitted counts the results left out in each phase
+	Omitted map[string]Omitted `json:"omitted"`
+}
+
+// Omitted counts the successful results of a phase left out by sampling,
+// and how many of them were cache hits
+type Omitted struct {
+	Results   int `json:"results"`
+	CacheHits int `json:"cache_hits"`
+}
+
+// omitted returns the results left out in a phase; none for an unsampled
+// file
+func (s *Sampling) omitted(phase string) Omitted {
+	if s == nil {
+		return Omitted{}
+	}
+	return s.Omitted[phase]
+}
+
+// combine adds another file's sampling. The rate is the lowest of the two,
+// and a file without sampling kept every result.
+func (s *Sampling) combine(other *Sampling) *Sampling {
+	if other == nil {
+		return s
+	}
+	combined := &Sampling{Rate: other.Rate, Omitted: make(map[string]Omitted)}
+	if s != nil {
+		combined.Rate = min(s.Rate, other.Rate)
+		for phase, omitted := range s.Omitted {
+			combined.Omitted[phase] = omitted
+		}
+	}
+	for phase, omitted := range other.Omitted {
+		total := combined.Omitted[phase]
+		total.Results += omitted.Results
+		total.CacheHits += omitted.CacheHits
+		combined.Omitted[phase] = total
+	}
+	return combined
+}
+
+// FormatResults formats per-URL results as a results file. Results files are
+// JSON, or XML for the xml format; LoadResults reads both back for the report
+// commands.
+func (f *Formatter) FormatResults(entries []ResultEntry) string {
+	return f.FormatSampledResults(entries, nil)
+}
+
+// FormatSampledResults formats per-URL results as a results file that
+// records how they were sampled, or a plain results file for nil sampling
+func (f *Formatter) FormatSampledResults(entries []ResultEntry, sampling *Sampling) string {
+	if f.format == "xml" {
+		return f.formatResultsXML(entries, sampling)
+	}
+
+	file := ResultsFile{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC(),
+		Run:           f.run,
+		Sampling:      sampling,
+		Results:       entries,
+	}
+	if file.Results == nil {
+		file.Results = []ResultEntry{}
+	}
+
+	jsonData, _ := json.MarshalIndent(file, "", "  ")
+	return string(jsonData)
+}
+
+// LoadResults reads a JSON or XML results file. Files written before the
+// schema was versioned have no schema_version and are read as version 1;
+// files from a newer schema version are rejected.
+func LoadResults(path string) (*ResultsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file: %w", err)
+	}
+
+	file := &ResultsFile{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		file, err = parseResultsXML(data)
+	} else {
+		err = json.Unmarshal(data, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results file %s: %w", path, err)
+	}
+	if file.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("results file %s uses schema version %d; this version reads up to %d", path, file.SchemaVersion, SchemaVersion)
+	}
+	return file, nil
+}
+
+// byURL indexes results by URL. When a URL was requested in several phases,
+// the last request wins, so cache verification runs are compared on their
+// verify pass.
+func (r *ResultsFile) byURL() map[string]ResultEntry {
+	index := make(map[string]ResultEntry, len(r.Results))
+	for _, entry := range r.Results {
+		index[entry.URL] = entry
+	}
+	return index
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+)
+
+// WithRun embeds the run's metadata in every format: a "run" object in
+// JSON, a header in text, a trailing run_id column in CSV and a comment in
+// XML
+func (f *Formatter) WithRun(run *runinfo.Run) *Formatter {
+	f.run = run
+	return f
+}
+
+// marshalJSON encodes a report, adding the run metadata when it is set
+func (f *Formatter) marshalJSON(data map[string]interface{}) string {
+	if f.run != nil {
+		data["run"] = f.run
+	}
+
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	return string(jsonData)
+}
+
+// runHeader returns the run metadata as the header of a text report, or an
+// empty string when no run is set
+func (f *Formatter) runHeader() string {
+	if f.run == nil {
+		return ""
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Run:          %s\nVersion:      %s\nStarted:      %s\n",
+		f.run.ID, f.run.Version, f.run.StartedAt.Format(time.RFC3339))
+	if !f.run.FinishedAt.IsZero() {
+		fmt.Fprintf(&builder, "Finished:     %s\n", f.run.FinishedAt.Format(time.RFC3339))
+	}
+	for _, sitemapURL := range f.run.SitemapURLs {
+		fmt.Fprintf(&builder, "Sitemap:      %s\n", sitemapURL)
+	}
+	if f.run.Seed != 0 {
+		fmt.Fprintf(&builder, "Seed:         %d\n", f.run.Seed)
+	}
+	return builder.String()
+}
+
+// runComment returns the run metadata as an XML comment, or an empty string
+// when no run is set
+func (f *Formatter) runComment() string {
+	if f.run == nil {
+		return ""
+	}
+	return fmt.Sprintf("<!-- sitemap-crawler %s run %s started %s -->\n",
+		f.run.Version, f.run.ID, f.run.StartedAt.Format(time.RFC3339))
+}
+
+// runColumnWriter appends a run_id column to every CSV record: the column
+// name on the header and the run ID on each row
+type runColumnWriter struct {
+	csvWriter
+	runID  string
+	header bool
+}
+
+// Write writes a record with the run column appended
+func (r *runColumnWriter) Write(record []string) error {
+	value := r.runID
+	if !r.header {
+		value = "run_id"
+		r.header = true
+	}
+	return r.csvWriter.Write(append(record[:len(record):len(record)], value))
+}
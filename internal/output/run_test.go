@@ -0,0 +1,144 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/coverage"
+	"github.com/benvon/sitemap-crawler/internal/failures"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatterWithRun(t *testing.T) {
+	t.Parallel()
+
+	run := &runinfo.Run{
+		ID:          "20261016T120000Z-0a1b2c3d",
+		Version:     "1.2.3",
+		StartedAt:   time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		FinishedAt:  time.Date(2026, 10, 16, 12, 5, 0, 0, time.UTC),
+		SitemapURLs: []string{"https://example.com/sitemap.xml"},
+		Seed:        42,
+		Config:      map[string]interface{}{"max-workers": 10},
+	}
+	finalStats := &stats.FinalStats{TotalProcessed: 1, TotalSuccess: 1}
+	report := &coverage.Report{SitemapURLs: 1, Orphans: []string{"https://example.com/orphan"}}
+	records := []failures.Record{{URL: "https://example.com/missing", Phase: failures.PhaseCrawl, Reason: failures.ReasonFailed}}
+
+	tests := []struct {
+		name     string
+		format   string
+		output   func(f *Formatter) string
+		expected []string
+	}{
+		{
+			name:   "text",
+			format: "text",
+			output: func(f *Formatter) string { return f.FormatFinalStats(finalStats) },
+			expected: []string{
+				"Run:          20261016T120000Z-0a1b2c3d\n",
+				"Version:      1.2.3\n",
+				"Started:      2026-10-16T12:00:00Z\n",
+				"Finished:     2026-10-16T12:05:00Z\n",
+				"Sitemap:      https://example.com/sitemap.xml\n",
+				"Seed:         42\n",
+				"Final Statistics:",
+			},
+		},
+		{
+			name:   "json",
+			format: "json",
+			output: func(f *Formatter) string { return f.FormatFinalStats(finalStats) },
+			expected: []string{
+				`"run": {`,
+				`"run_id": "20261016T120000Z-0a1b2c3d"`,
+				`"finished_at": "2026-10-16T12:05:00Z"`,
+				`"seed": 42`,
+				`"max-workers": 10`,
+				`"total_processed": 1`,
+			},
+		},
+		{
+			name:   "csv",
+			format: "csv",
+			output: func(f *Formatter) string { return f.FormatFailures(records) },
+			expected: []string{
+				"trace_id,start,end,run_id\n",
+				"https://example.com/missing,crawl,failed,,,,0,,,,,20261016T120000Z-0a1b2c3d\n",
+			},
+		},
+		{
+			name:   "coverage json",
+			format: "json",
+			output: func(f *Formatter) string { return f.FormatCoverageReport(report) },
+			expected: []string{
+				`"run_id": "20261016T120000Z-0a1b2c3d"`,
+				`"orphans": [`,
+			},
+		},
+		{
+			name:   "coverage html",
+			format: "html",
+			output: func(f *Formatter) string { return f.FormatCoverageReport(report) },
+			expected: []string{
+				"<p>Run 20261016T120000Z-0a1b2c3d | Version 1.2.3 | Started 2026-10-16T12:00:00Z | Sitemap https://example.com/sitemap.xml</p>",
+			},
+		},
+		{
+			name:   "results",
+			format: "json",
+			output: func(f *Formatter) string { return f.FormatResults(nil) },
+			expected: []string{
+				`"run_id": "20261016T120000Z-0a1b2c3d"`,
+				`"results": []`,
+			},
+		},
+		{
+			name:   "sitemap",
+			format: "xml",
+			output: func(f *Formatter) string {
+				return f.FormatSitemap([]parser.URL{{Loc: "https://example.com/"}})
+			},
+			expected: []string{
+				"?>\n<!-- sitemap-crawler 1.2.3 run 20261016T120000Z-0a1b2c3d started 2026-10-16T12:00:00Z -->\n<urlset",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := tt.output(New(tt.format).WithRun(run))
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected output to contain %q, got %q", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatterWithoutRun(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{}
+	outputs := map[string]string{
+		"text":    New("text").FormatFinalStats(finalStats),
+		"json":    New("json").FormatFinalStats(finalStats),
+		"csv":     New("csv").FormatFinalStats(finalStats),
+		"results": New("json").FormatResults(nil),
+		"sitemap": New("xml").FormatSitemap(nil),
+	}
+
+	for name, result := range outputs {
+		for _, unwanted := range []string{"run_id", "Run:", "<!--"} {
+			if strings.Contains(result, unwanted) {
+				t.Errorf("%s: expected no run metadata, got %q", name, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package output
+
+// SchemaVersion is the version of the JSON and XML progress, final
+// statistics, cache statistics and results file formats, written to each as
+// schema_version. Changes within a version are additive only: fields are
+// added but never renamed, removed or given a different type, so parsers
+// should ignore fields they do not know. Anything else bumps the version.
+const SchemaVersion = 1
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// TestJSONSchemaV1 guards the documented fields of schema version 1. Fields
+// may be added, but every field listed here must keep its name.
+func TestJSONSchemaV1(t *testing.T) {
+	t.Parallel()
+
+	formatter := New("json")
+	tests := []struct {
+		name   string
+		output string
+		fields []string
+	}{
+		{
+			name:   "progress",
+			output: formatter.FormatProgress(&stats.Progress{}),
+			fields: []string{"schema_version", "timestamp", "processed", "total", "percentage", "success_rate", "average_duration"},
+		},
+		{
+			name:   "final stats",
+			output: formatter.FormatFinalStats(&stats.FinalStats{}),
+			fields: []string{
+				"schema_version", "timestamp", "total_processed", "total_success", "total_errors", "success_rate",
+				"average_duration", "min_duration", "max_duration", "total_duration", "first_attempt_success_rate",
+				"total_attempts", "total_retries", "retried_urls", "success_after_retry", "max_attempts",
+			},
+		},
+		{
+			name:   "cache stats",
+			output: formatter.FormatCacheStats(&stats.CacheStats{}),
+			fields: []string{"schema_version", "timestamp", "cache_hits", "cache_misses", "cache_hit_rate", "warm_up_time", "verify_time", "validator_changes", "header_changes"},
+		},
+		{
+			name:   "results file",
+			output: formatter.FormatResults(nil),
+			fields: []string{"schema_version", "timestamp", "results"},
+		},
+		{
+			name:   "result entry",
+			output: resultEntryJSON(t),
+			fields: []string{"phase", "url", "success", "duration"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.output), &data); err != nil {
+				t.Fatalf("Failed to parse %s: %v", tt.output, err)
+			}
+			for _, field := range tt.fields {
+				if _, ok := data[field]; !ok {
+					t.Errorf("Expected field %q in %s", field, tt.output)
+				}
+			}
+			if version, ok := data["schema_version"]; ok && version != float64(SchemaVersion) {
+				t.Errorf("Expected schema_version %d, got %v", SchemaVersion, version)
+			}
+		})
+	}
+}
+
+// resultEntryJSON encodes one results file entry
+func resultEntryJSON(t *testing.T) string {
+	t.Helper()
+
+	data, err := json.Marshal(ResultEntry{Phase: "crawl", Result: stats.Result{URL: "https://example.com/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// MaxSitemapURLs is the largest number of URLs the sitemap protocol allows
+// in one file
+const MaxSitemapURLs = parser.MaxFileURLs
+
+// sitemapURLSet and sitemapURL mirror the sitemap protocol. parser.URL is not
+// marshaled directly because a zero lastmod and priority must be omitted.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// FormatSitemap formats URLs as a sitemap protocol urlset, keeping their
+// lastmod, changefreq and priority. The sitemap is always XML; the run
+// metadata is a comment so that search engines ignore it.
+func (f *Formatter) FormatSitemap(urls []parser.URL) string {
+	urlSet := sitemapURLSet{
+		Xmlns: parser.SitemapXMLNS,
+		URLs:  make([]sitemapURL, len(urls)),
+	}
+
+	for i, url := range urls {
+		entry := sitemapURL{Loc: url.Loc, ChangeFreq: url.ChangeFreq}
+		if !url.LastMod.IsZero() {
+			entry.LastMod = formatLastMod(url.LastMod)
+		}
+		if url.HasPriority {
+			entry.Priority = strconv.FormatFloat(url.Priority, 'f', 1, 64)
+		}
+		urlSet.URLs[i] = entry
+	}
+
+	data, _ := xml.MarshalIndent(urlSet, "", "  ")
+	return xml.Header + f.runComment() + string(data) + "\n"
+}
+
+// formatLastMod writes a date alone when the sitemap only gave a date
+func formatLastMod(t time.Time) string {
+	if t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+func TestFormatSitemap(t *testing.T) {
+	t.Parallel()
+
+	urls := []parser.URL{
+		{
+			Loc:         "https://example.com/?a=1&b=2",
+			LastMod:     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			ChangeFreq:  "daily",
+			Priority:    0.8,
+			HasPriority: true,
+		},
+		{
+			Loc:         "https://example.com/about",
+			LastMod:     time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			HasPriority: true,
+		},
+		{Loc: "https://example.com/contact"},
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/?a=1&amp;b=2</loc>
+    <lastmod>2024-01-02</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://example.com/about</loc>
+    <lastmod>2024-01-02T15:04:05Z</lastmod>
+    <priority>0.0</priority>
+  </url>
+  <url>
+    <loc>https://example.com/contact</loc>
+  </url>
+</urlset>
+`
+
+	result := New("text").FormatSitemap(urls)
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	// The output must decode back into the same URLs
+	var parsed parser.URLSet
+	if err := xml.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Failed to decode formatted sitemap: %v", err)
+	}
+	if len(parsed.URLs) != len(urls) {
+		t.Fatalf("Expected %d URLs, got %d", len(urls), len(parsed.URLs))
+	}
+	for i, url := range urls {
+		if parsed.URLs[i].Loc != url.Loc || !parsed.URLs[i].LastMod.Equal(url.LastMod) || parsed.URLs[i].Priority != url.Priority || parsed.URLs[i].HasPriority != url.HasPriority {
+			t.Errorf("URL %d did not round-trip: expected %+v, got %+v", i, url, parsed.URLs[i])
+		}
+	}
+}
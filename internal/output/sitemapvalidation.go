@@ -0,0 +1,105 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// FormatSitemapValidation formats the per-file sitemaps protocol report of
+// parse --validate
+func (f *Formatter) FormatSitemapValidation(reports []parser.FileReport) string {
+	switch f.format {
+	case "json":
+		return f.formatSitemapValidationJSON(reports)
+	case "csv":
+		return f.formatSitemapValidationCSV(reports)
+	default:
+		return f.runHeader() + f.formatSitemapValidationText(reports)
+	}
+}
+
+// formatSitemapValidationText formats the report as text, listing only the
+// files that break the protocol or could not be read
+func (f *Formatter) formatSitemapValidationText(reports []parser.FileReport) string {
+	var builder strings.Builder
+	valid := 0
+	for _, report := range reports {
+		if report.Valid() {
+			valid++
+		}
+	}
+
+	fmt.Fprintf(&builder, "\nSitemap Validation:\n==================\nSitemaps:  %d\nValid:     %d\nInvalid:   %d\n",
+		len(reports), valid, len(reports)-valid)
+
+	for _, report := range reports {
+		if report.Valid() {
+			continue
+		}
+		if report.Error != "" {
+			fmt.Fprintf(&builder, "\n%s\n  Error: %s\n", report.URL, report.Error)
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s [%s, %d entries, %d bytes]\n", report.URL, report.Format, report.Entries, report.Bytes)
+		for _, violation := range report.Violations {
+			if violation.Entry > 0 {
+				fmt.Fprintf(&builder, "  %s (entry %d): %s\n", violation.Rule, violation.Entry, violation.Detail)
+			} else {
+				fmt.Fprintf(&builder, "  %s: %s\n", violation.Rule, violation.Detail)
+			}
+		}
+		if report.Omitted > 0 {
+			fmt.Fprintf(&builder, "  ... and %d more\n", report.Omitted)
+		}
+	}
+
+	return builder.String()
+}
+
+// formatSitemapValidationJSON formats the report as JSON
+func (f *Formatter) formatSitemapValidationJSON(reports []parser.FileReport) string {
+	data := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"sitemaps":  reports,
+	}
+
+	return f.marshalJSON(data)
+}
+
+// formatSitemapValidationCSV formats the report as CSV with one row per
+// violation, and one row without a rule for each valid file
+func (f *Formatter) formatSitemapValidationCSV(reports []parser.FileReport) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	rows := [][]string{{"sitemap", "format", "entries", "bytes", "rule", "entry", "detail"}}
+	for _, report := range reports {
+		file := []string{report.URL, report.Format, strconv.Itoa(report.Entries), strconv.Itoa(report.Bytes)}
+		switch {
+		case report.Error != "":
+			rows = append(rows, append(file, "error", "", report.Error))
+		case len(report.Violations) == 0:
+			rows = append(rows, append(file, "", "", ""))
+		}
+		for _, violation := range report.Violations {
+			entry := ""
+			if violation.Entry > 0 {
+				entry = strconv.Itoa(violation.Entry)
+			}
+			rows = append(rows, append(file, violation.Rule, entry, violation.Detail))
+		}
+	}
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+func TestFormatSitemapValidation(t *testing.T) {
+	t.Parallel()
+
+	reports := []parser.FileReport{
+		{URL: "https://example.com/sitemap.xml", Format: parser.FormatIndex, Entries: 2, Bytes: 300, Violations: []parser.Violation{}},
+		{URL: "https://example.com/posts.xml", Format: parser.FormatURLSet, Entries: 120, Bytes: 9000, Omitted: 3, Violations: []parser.Violation{
+			{Rule: parser.RuleNamespace, Detail: `<urlset> has namespace "", expected "http://www.sitemaps.org/schemas/sitemap/0.9"`},
+			{Rule: parser.RuleLastMod, Entry: 7, Detail: `lastmod "yesterday" is not a W3C Datetime`},
+		}},
+		{URL: "https://example.com/missing.xml", Error: "unexpected status code: 404", Violations: []parser.Violation{}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:   "text format lists only invalid files",
+			format: "text",
+			expected: []string{
+				"Sitemaps:  3\nValid:     1\nInvalid:   2",
+				"https://example.com/posts.xml [urlset, 120 entries, 9000 bytes]",
+				`invalid_lastmod (entry 7): lastmod "yesterday" is not a W3C Datetime`,
+				"... and 3 more",
+				"https://example.com/missing.xml\n  Error: unexpected status code: 404",
+			},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"rule": "invalid_lastmod"`, `"omitted_violations": 3`, `"error": "unexpected status code: 404"`},
+		},
+		{
+			name:   "csv format",
+			format: "csv",
+			expected: []string{
+				"sitemap,format,entries,bytes,rule,entry,detail",
+				"https://example.com/sitemap.xml,sitemapindex,2,300,,,\n",
+				`https://example.com/posts.xml,urlset,120,9000,invalid_lastmod,7,"lastmod ""yesterday"" is not a W3C Datetime"`,
+				"https://example.com/missing.xml,,0,0,error,,unexpected status code: 404",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatSitemapValidation(reports)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+
+	if strings.Contains(New("text").FormatSitemapValidation(reports), "https://example.com/sitemap.xml [") {
+		t.Error("Expected text report to omit valid sitemaps")
+	}
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// Chart geometry of the HTML timeline report, in SVG user units
+const (
+	chartWidth  = 720
+	chartHeight = 160
+)
+
+var timelineHTMLTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Latency Over Time</title></head>
+<body>
+<h1>Latency Over Time</h1>
+{{with .Run}}<p>Run {{.ID}} | Version {{.Version}} | Started {{.StartedAt.Format "2006-01-02T15:04:05Z07:00"}}{{range .SitemapURLs}} | Sitemap {{.}}{{end}}</p>
+{{end -}}
+{{range .Charts}}<h2>{{.Title}} (max {{.Max}})</h2>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}">
+<line x1="0" y1="{{$.Height}}" x2="{{$.Width}}" y2="{{$.Height}}" stroke="#999"/>
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Fill}}"><title>{{.Label}}</title></rect>
+{{end}}</svg>
+{{end -}}
+<table>
+<tr><th>Minute</th><th>Requests</th><th>Errors</th><th>Error Rate</th><th>p95</th></tr>
+{{range .Timeline}}<tr><td>{{.Start.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{printf "%.1f%%" .ErrorRate}}</td><td>{{.P95}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// timelineDocument is the data of the HTML timeline report
+type timelineDocument struct {
+	Run      *runinfo.Run
+	Timeline []stats.TimeBucket
+	Charts   []timelineChart
+	Width    int
+	Height   int
+}
+
+// timelineChart is one bar chart of the HTML timeline report
+type timelineChart struct {
+	Title string
+	Max   string
+	Bars  []timelineBar
+}
+
+// timelineBar is one minute of a timeline chart
+type timelineBar struct {
+	X, Y, Width, Height float64
+	Fill                string
+	Label               string
+}
+
+// FormatTimeline formats request counts, error rates and p95 latency per
+// minute of a crawl
+func (f *Formatter) FormatTimeline(timeline []stats.TimeBucket) string {
+	switch f.format {
+	case "json":
+		return f.formatTimelineJSON(timeline)
+	case "csv":
+		return f.formatTimelineCSV(timeline)
+	case "html":
+		return f.formatTimelineHTML(timeline)
+	default:
+		var builder strings.Builder
+		builder.WriteString(f.runHeader())
+		writeTimeline(&builder, timeline)
+		return builder.String()
+	}
+}
+
+// writeTimeline writes the timeline as a text table
+func writeTimeline(builder *strings.Builder, timeline []stats.TimeBucket) {
+	builder.WriteString("\nLatency Over Time:\n")
+	fmt.Fprintf(builder, "  %-8s %8s %8s %10s %12s\n", "minute", "requests", "errors", "error rate", "p95")
+	for _, bucket := range timeline {
+		fmt.Fprintf(builder, "  %-8s %8d %8d %9.1f%% %12s\n",
+			bucket.Start.Format("15:04"), bucket.Requests, bucket.Errors, bucket.ErrorRate, bucket.P95)
+	}
+}
+
+// timelineJSON converts timeline buckets to JSON values
+func timelineJSON(timeline []stats.TimeBucket) []map[string]interface{} {
+	buckets := make([]map[string]interface{}, len(timeline))
+	for i, bucket := range timeline {
+		buckets[i] = map[string]interface{}{
+			"start":      bucket.Start.Format(time.RFC3339),
+			"requests":   bucket.Requests,
+			"errors":     bucket.Errors,
+			"error_rate": bucket.ErrorRate,
+			"p95":        bucket.P95.String(),
+		}
+	}
+	return buckets
+}
+
+// formatTimelineJSON formats the timeline as JSON
+func (f *Formatter) formatTimelineJSON(timeline []stats.TimeBucket) string {
+	return f.marshalJSON(map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"timeline":       timelineJSON(timeline),
+	})
+}
+
+// formatTimelineCSV formats the timeline as CSV with one row per minute
+func (f *Formatter) formatTimelineCSV(timeline []stats.TimeBucket) string {
+	var builder strings.Builder
+	writer := f.newCSVWriter(&builder)
+
+	if err := writer.Write([]string{"start", "requests", "errors", "error_rate", "p95"}); err != nil {
+		return ""
+	}
+
+	for _, bucket := range timeline {
+		if err := writer.Write([]string{
+			bucket.Start.Format(time.RFC3339),
+			strconv.Itoa(bucket.Requests),
+			strconv.Itoa(bucket.Errors),
+			fmt.Sprintf("%.1f", bucket.ErrorRate),
+			bucket.P95.String(),
+		}); err != nil {
+			return ""
+		}
+	}
+
+	writer.Flush()
+	return builder.String()
+}
+
+// formatTimelineHTML formats the timeline as a standalone HTML page with
+// bar charts of p95 latency and error rate
+func (f *Formatter) formatTimelineHTML(timeline []stats.TimeBucket) string {
+	var maxP95 time.Duration
+	var maxErrorRate float64
+	for _, bucket := range timeline {
+		maxP95 = max(maxP95, bucket.P95)
+		maxErrorRate = max(maxErrorRate, bucket.ErrorRate)
+	}
+
+	document := timelineDocument{
+		Run:      f.run,
+		Timeline: timeline,
+		Width:    chartWidth,
+		Height:   chartHeight,
+		Charts: []timelineChart{
+			{
+				Title: "p95 latency per minute",
+				Max:   maxP95.String(),
+				Bars: timelineBars(timeline, "#4878a8", func(bucket stats.TimeBucket) (float64, string) {
+					return float64(bucket.P95) / float64(max(maxP95, 1)), bucket.P95.String()
+				}),
+			},
+			{
+				Title: "Error rate per minute",
+				Max:   fmt.Sprintf("%.1f%%", maxErrorRate),
+				Bars: timelineBars(timeline, "#c0504d", func(bucket stats.TimeBucket) (float64, string) {
+					if maxErrorRate == 0 {
+						return 0, "0.0%"
+					}
+					return bucket.ErrorRate / maxErrorRate, fmt.Sprintf("%.1f%%", bucket.ErrorRate)
+				}),
+			},
+		},
+	}
+
+	var builder strings.Builder
+	if err := timelineHTMLTemplate.Execute(&builder, document); err != nil {
+		return ""
+	}
+	return builder.String()
+}
+
+// timelineBars lays out one bar per bucket. value returns the bar height as
+// a fraction of the chart and the value shown on hover.
+func timelineBars(timeline []stats.TimeBucket, fill string, value func(stats.TimeBucket) (float64, string)) []timelineBar {
+	if len(timeline) == 0 {
+		return nil
+	}
+
+	slot := float64(chartWidth) / float64(len(timeline))
+	bars := make([]timelineBar, len(timeline))
+	for i, bucket := range timeline {
+		fraction, label := value(bucket)
+		height := fraction * chartHeight
+		bars[i] = timelineBar{
+			X:      float64(i) * slot,
+			Y:      chartHeight - height,
+			Width:  slot * 0.8,
+			Height: height,
+			Fill:   fill,
+			Label:  fmt.Sprintf("%s: %s (%d requests)", bucket.Start.Format("15:04"), label, bucket.Requests),
+		}
+	}
+	return bars
+}
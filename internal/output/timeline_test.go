@@ -0,0 +1,77 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatTimeline(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeline := []stats.TimeBucket{
+		{Start: start, Requests: 60, Errors: 0, ErrorRate: 0, P95: 120 * time.Millisecond},
+		{Start: start.Add(time.Minute), Requests: 40, Errors: 10, ErrorRate: 25, P95: 2 * time.Second},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "text format",
+			format:   "text",
+			expected: []string{"Latency Over Time:", "12:00", "12:01", "25.0%", "2s"},
+		},
+		{
+			name:     "json format",
+			format:   "json",
+			expected: []string{`"timeline": [`, `"start": "2024-05-01T12:01:00Z"`, `"error_rate": 25`, `"p95": "120ms"`},
+		},
+		{
+			name:     "csv format",
+			format:   "csv",
+			expected: []string{"start,requests,errors,error_rate,p95", "2024-05-01T12:00:00Z,60,0,0.0,120ms", "2024-05-01T12:01:00Z,40,10,25.0,2s"},
+		},
+		{
+			name:     "html format",
+			format:   "html",
+			expected: []string{"<h1>Latency Over Time</h1>", "<svg", "p95 latency per minute (max 2s)", "Error rate per minute (max 25.0%)", "<td>25.0%</td>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatTimeline(timeline)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain '%s', got '%s'", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFinalStatsTimeline(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	single := &stats.FinalStats{Timeline: []stats.TimeBucket{{Start: start, Requests: 1, P95: time.Millisecond}}}
+	if result := New("text").FormatFinalStats(single); strings.Contains(result, "Latency Over Time") {
+		t.Errorf("Expected no timeline for a single minute, got '%s'", result)
+	}
+
+	multiple := &stats.FinalStats{Timeline: append(single.Timeline, stats.TimeBucket{Start: start.Add(time.Minute), Requests: 1, P95: time.Millisecond})}
+	if result := New("text").FormatFinalStats(multiple); !strings.Contains(result, "Latency Over Time") {
+		t.Errorf("Expected a timeline for two minutes, got '%s'", result)
+	}
+	if result := New("json").FormatFinalStats(multiple); !strings.Contains(result, `"timeline": [`) {
+		t.Errorf("Expected JSON final stats to include the timeline, got '%s'", result)
+	}
+}
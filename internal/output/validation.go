@@ -0,0 +1,86 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigViolation is a problem found in a crawler configuration, with the
+// flags of the settings involved
+type ConfigViolation struct {
+	Message string
+	Flags   []string
+}
+
+// FormatValidation formats the result of validating a configuration for a
+// command, for people or for tools that generate configurations
+func (f *Formatter) FormatValidation(command string, violations []ConfigViolation) string {
+	switch f.format {
+	case "json":
+		return f.formatValidationJSON(command, violations)
+	case "markdown":
+		return f.formatValidationMarkdown(command, violations)
+	default:
+		return f.formatValidationText(command, violations)
+	}
+}
+
+// flagList formats flag names as command line flags
+func flagList(flags []string) string {
+	names := make([]string, len(flags))
+	for i, flag := range flags {
+		names[i] = "--" + flag
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatValidationText formats a validation result as text
+func (f *Formatter) formatValidationText(command string, violations []ConfigViolation) string {
+	if len(violations) == 0 {
+		return fmt.Sprintf("Configuration is valid for %s", command)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Configuration for %s has %d problem(s):\n", command, len(violations))
+	for _, violation := range violations {
+		builder.WriteString("  - " + violation.Message)
+		if len(violation.Flags) > 0 {
+			builder.WriteString(" (" + flagList(violation.Flags) + ")")
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// formatValidationJSON formats a validation result as JSON
+func (f *Formatter) formatValidationJSON(command string, violations []ConfigViolation) string {
+	items := make([]map[string]interface{}, len(violations))
+	for i, violation := range violations {
+		items[i] = map[string]interface{}{
+			"message": violation.Message,
+			"flags":   nonNil(violation.Flags),
+		}
+	}
+
+	return f.marshalJSON(map[string]interface{}{
+		"command":    command,
+		"valid":      len(violations) == 0,
+		"violations": items,
+	})
+}
+
+// formatValidationMarkdown formats a validation result as Markdown
+func (f *Formatter) formatValidationMarkdown(command string, violations []ConfigViolation) string {
+	var builder strings.Builder
+	builder.WriteString("## Configuration Validation\n\n")
+	if len(violations) == 0 {
+		fmt.Fprintf(&builder, "Configuration is valid for `%s`.\n", command)
+		return builder.String()
+	}
+
+	fmt.Fprintf(&builder, "Configuration for `%s` has %d problem(s).\n\n| Problem | Flags |\n|---|---|\n", command, len(violations))
+	for _, violation := range violations {
+		fmt.Fprintf(&builder, "| %s | %s |\n", markdownCell(violation.Message), markdownCell(flagList(violation.Flags)))
+	}
+	return builder.String()
+}
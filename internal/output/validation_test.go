@@ -0,0 +1,43 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatValidation(t *testing.T) {
+	t.Parallel()
+
+	violations := []ConfigViolation{
+		{Message: "max workers must be at least 1", Flags: []string{"max-workers"}},
+		{Message: "cloudflare purge requires an API token in CLOUDFLARE_API_TOKEN"},
+	}
+
+	tests := []struct {
+		name       string
+		format     string
+		violations []ConfigViolation
+		expected   string
+	}{
+		{name: "text valid", format: "text", expected: "Configuration is valid for crawl"},
+		{name: "text count", format: "text", violations: violations, expected: "Configuration for crawl has 2 problem(s):"},
+		{name: "text flags", format: "text", violations: violations, expected: "  - max workers must be at least 1 (--max-workers)\n"},
+		{name: "json valid", format: "json", expected: `"valid": true`},
+		{name: "json violations", format: "json", violations: violations, expected: `"flags": [
+        "max-workers"
+      ],
+      "message": "max workers must be at least 1"`},
+		{name: "json without flags", format: "json", violations: violations, expected: `"flags": [],`},
+		{name: "markdown", format: "markdown", violations: violations, expected: "| max workers must be at least 1 | --max-workers |"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := New(tt.format).FormatValidation("crawl", tt.violations)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// The XML formats mirror the JSON ones: the same names, with durations as
+// Go duration strings and times in RFC 3339. The root element carries the
+// schema version and timestamp as attributes.
+
+// xmlRun is the run metadata. The configuration is left out; XML has no
+// natural form for its arbitrary values.
+type xmlRun struct {
+	ID          string   `xml:"id,attr"`
+	Version     string   `xml:"version,attr"`
+	StartedAt   string   `xml:"started_at,attr"`
+	FinishedAt  string   `xml:"finished_at,attr,omitempty"`
+	Seed        uint64   `xml:"seed,attr,omitempty"`
+	SitemapURLs []string `xml:"sitemap"`
+}
+
+type xmlProgress struct {
+	XMLName         xml.Name  `xml:"progress"`
+	SchemaVersion   int       `xml:"schema_version,attr"`
+	Timestamp       string    `xml:"timestamp,attr"`
+	Run             *xmlRun   `xml:"run,omitempty"`
+	Processed       int       `xml:"processed"`
+	Total           int       `xml:"total"`
+	Percentage      float64   `xml:"percentage"`
+	SuccessRate     float64   `xml:"success_rate"`
+	AverageDuration string    `xml:"average_duration"`
+	Phase           *xmlPhase `xml:"phase,omitempty"`
+}
+
+type xmlPhase struct {
+	Name              string  `xml:"name,attr"`
+	Number            int     `xml:"number,attr"`
+	Count             int     `xml:"count,attr"`
+	Processed         int     `xml:"processed,attr"`
+	Total             int     `xml:"total,attr"`
+	Percentage        float64 `xml:"percentage,attr"`
+	EstimatedTimeLeft string  `xml:"estimated_time_left,attr"`
+}
+
+type xmlFinalStats struct {
+	XMLName                 xml.Name           `xml:"final_stats"`
+	SchemaVersion           int                `xml:"schema_version,attr"`
+	Timestamp               string             `xml:"timestamp,attr"`
+	Run                     *xmlRun            `xml:"run,omitempty"`
+	TotalProcessed          int                `xml:"total_processed"`
+	TotalSuccess            int                `xml:"total_success"`
+	TotalErrors             int                `xml:"total_errors"`
+	SuccessRate             float64            `xml:"success_rate"`
+	AverageDuration         string             `xml:"average_duration"`
+	MinDuration             string             `xml:"min_duration"`
+	MaxDuration             string             `xml:"max_duration"`
+	TotalDuration           string             `xml:"total_duration"`
+	FirstAttemptSuccessRate float64            `xml:"first_attempt_success_rate"`
+	TotalAttempts           int                `xml:"total_attempts"`
+	TotalRetries            int                `xml:"total_retries"`
+	RetriedURLs             int                `xml:"retried_urls"`
+	SuccessAfterRetry       int                `xml:"success_after_retry"`
+	MaxAttempts             int                `xml:"max_attempts"`
+	ErrorsByCategory        []xmlErrorCategory `xml:"errors_by_category>category,omitempty"`
+	RejectedURLs            int                `xml:"rejected_urls,omitempty"`
+	RejectedByReason        []xmlRejectReason  `xml:"rejected_by_reason>reason,omitempty"`
+	SkippedURLs             int                `xml:"skipped_urls,omitempty"`
+	CancelReason            string             `xml:"cancel_reason,omitempty"`
+	Hosts                   []xmlHost          `xml:"hosts>host,omitempty"`
+	Timeline                []xmlTimeBucket    `xml:"timeline>minute,omitempty"`
+	Phases                  []xmlPhaseStats    `xml:"phases>phase,omitempty"`
+}
+
+type xmlErrorCategory struct {
+	Name  string `xml:"name,attr"`
+	Count int    `xml:",chardata"`
+}
+
+type xmlRejectReason struct {
+	Name  string `xml:"name,attr"`
+	Count int    `xml:",chardata"`
+}
+
+type xmlHost struct {
+	Host     string `xml:"name,attr"`
+	Requests int    `xml:"requests,attr"`
+	Errors   int    `xml:"errors,attr"`
+	P50      string `xml:"p50,attr"`
+	P90      string `xml:"p90,attr"`
+	P95      string `xml:"p95,attr"`
+	P99      string `xml:"p99,attr"`
+	Max      string `xml:"max,attr"`
+}
+
+type xmlPhaseStats struct {
+	Name     string `xml:"name,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type xmlTimeBucket struct {
+	Start     string  `xml:"start,attr"`
+	Requests  int     `xml:"requests,attr"`
+	Errors    int     `xml:"errors,attr"`
+	ErrorRate float64 `xml:"error_rate,attr"`
+	P95       string  `xml:"p95,attr"`
+}
+
+type xmlCacheStats struct {
+	XMLName          xml.Name `xml:"cache_stats"`
+	SchemaVersion    int      `xml:"schema_version,attr"`
+	Timestamp        string   `xml:"timestamp,attr"`
+	Run              *xmlRun  `xml:"run,omitempty"`
+	CacheHits        int      `xml:"cache_hits"`
+	CacheMisses      int      `xml:"cache_misses"`
+	CacheHitRate     float64  `xml:"cache_hit_rate"`
+	WarmUpTime       string   `xml:"warm_up_time"`
+	VerifyTime       string   `xml:"verify_time"`
+	ValidatorChanges int      `xml:"validator_changes"`
+	HeaderChanges    int      `xml:"header_changes"`
+}
+
+// xmlResultsFile is the results file in XML; unlike the other XML formats it
+// is read back by LoadResults
+type xmlResultsFile struct {
+	XMLName       xml.Name     `xml:"results"`
+	SchemaVersion int          `xml:"schema_version,attr"`
+	Timestamp     string       `xml:"timestamp,attr"`
+	Run           *xmlRun      `xml:"run,omitempty"`
+	Sampling      *xmlSampling `xml:"sampling,omitempty"`
+	Results       []xmlResult  `xml:"result"`
+}
+
+type xmlSampling struct {
+	Rate    float64      `xml:"rate,attr"`
+	Omitted []xmlOmitted `xml:"omitted"`
+}
+
+type xmlOmitted struct {
+	Phase     string `xml:"phase,attr"`
+	Results   int    `xml:"results,attr"`
+	CacheHits int    `xml:"cache_hits,attr"`
+}
+
+type xmlResult struct {
+	Phase         string          `xml:"phase,attr"`
+	Edge          string          `xml:"edge,attr,omitempty"`
+	URL           string          `xml:"url"`
+	Sitemap       string          `xml:"sitemap,omitempty"`
+	Success       bool            `xml:"success"`
+	StatusCode    int             `xml:"status_code,omitempty"`
+	Error         string          `xml:"error,omitempty"`
+	ErrorCategory string          `xml:"error_category,omitempty"`
+	Duration      string          `xml:"duration"`
+	Start         string          `xml:"start,omitempty"`
+	End           string          `xml:"end,omitempty"`
+	Attempts      int             `xml:"attempts,omitempty"`
+	CacheStatus   string          `xml:"cache_status,omitempty"`
+	Age           string          `xml:"age,omitempty"`
+	Size          int64           `xml:"size,omitempty"`
+	SurrogateKeys []string        `xml:"surrogate_keys>key,omitempty"`
+	TraceID       string          `xml:"trace_id,omitempty"`
+	ETag          string          `xml:"etag,omitempty"`
+	LastModified  string          `xml:"last_modified,omitempty"`
+	ServerTiming  []xmlServerTime `xml:"server_timing>metric,omitempty"`
+	Ranged        bool            `xml:"ranged,omitempty"`
+	Headers       []xmlHeader     `xml:"headers>header,omitempty"`
+}
+
+type xmlServerTime struct {
+	Name     string `xml:"name,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type xmlHeader struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// marshalXML marshals an XML document with its header
+func marshalXML(document interface{}) string {
+	data, _ := xml.MarshalIndent(document, "", "  ")
+	return xml.Header + string(data) + "\n"
+}
+
+// xmlRunInfo returns the formatter's run metadata, or nil when no run is set
+func (f *Formatter) xmlRunInfo() *xmlRun {
+	if f.run == nil {
+		return nil
+	}
+
+	run := &xmlRun{
+		ID:          f.run.ID,
+		Version:     f.run.Version,
+		StartedAt:   f.run.StartedAt.Format(time.RFC3339),
+		Seed:        f.run.Seed,
+		SitemapURLs: f.run.SitemapURLs,
+	}
+	if !f.run.FinishedAt.IsZero() {
+		run.FinishedAt = f.run.FinishedAt.Format(time.RFC3339)
+	}
+	return run
+}
+
+// formatProgressXML formats progress as XML
+func (f *Formatter) formatProgressXML(progress *stats.Progress) string {
+	document := xmlProgress{
+		SchemaVersion:   SchemaVersion,
+		Timestamp:       time.Now().Format(time.RFC3339),
+		Run:             f.xmlRunInfo(),
+		Processed:       progress.Processed,
+		Total:           progress.Total,
+		Percentage:      progress.Percentage,
+		SuccessRate:     progress.SuccessRate,
+		AverageDuration: progress.AverageDuration.String(),
+	}
+	if phase := progress.Phase; phase != nil {
+		document.Phase = &xmlPhase{
+			Name:              phase.Name,
+			Number:            phase.Number,
+			Count:             phase.Count,
+			Processed:         phase.Processed,
+			Total:             phase.Total,
+			Percentage:        phase.Percentage,
+			EstimatedTimeLeft: phase.EstimatedTimeLeft.String(),
+		}
+	}
+	return marshalXML(document)
+}
+
+// formatFinalStatsXML formats final statistics as XML
+func (f *Formatter) formatFinalStatsXML(finalStats *stats.FinalStats) string {
+	document := xmlFinalStats{
+		SchemaVersion:           SchemaVersion,
+		Timestamp:               time.Now().Format(time.RFC3339),
+		Run:                     f.xmlRunInfo(),
+		TotalProcessed:          finalStats.TotalProcessed,
+		TotalSuccess:            finalStats.TotalSuccess,
+		TotalErrors:             finalStats.TotalErrors,
+		SuccessRate:             finalStats.SuccessRate,
+		AverageDuration:         finalStats.AverageDuration.String(),
+		MinDuration:             finalStats.MinDuration.String(),
+		MaxDuration:             finalStats.MaxDuration.String(),
+		TotalDuration:           finalStats.TotalDuration.String(),
+		FirstAttemptSuccessRate: finalStats.FirstAttemptSuccessRate,
+		TotalAttempts:           finalStats.TotalAttempts,
+		TotalRetries:            finalStats.TotalRetries,
+		RetriedURLs:             finalStats.RetriedURLs,
+		SuccessAfterRetry:       finalStats.SuccessAfterRetry,
+		MaxAttempts:             finalStats.MaxAttempts,
+		RejectedURLs:            finalStats.RejectedURLs,
+		SkippedURLs:             finalStats.SkippedURLs,
+		CancelReason:            finalStats.CancelReason,
+	}
+
+	for _, category := range stats.ErrorCategories() {
+		if count := finalStats.ErrorsByCategory[category]; count > 0 {
+			document.ErrorsByCategory = append(document.ErrorsByCategory, xmlErrorCategory{Name: string(category), Count: count})
+		}
+	}
+	reasons := make([]string, 0, len(finalStats.RejectedByReason))
+	for reason := range finalStats.RejectedByReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		document.RejectedByReason = append(document.RejectedByReason, xmlRejectReason{Name: reason, Count: finalStats.RejectedByReason[reason]})
+	}
+	for _, host := range finalStats.Hosts {
+		document.Hosts = append(document.Hosts, xmlHost{
+			Host:     host.Host,
+			Requests: host.Requests,
+			Errors:   host.Errors,
+			P50:      host.P50.String(),
+			P90:      host.P90.String(),
+			P95:      host.P95.String(),
+			P99:      host.P99.String(),
+			Max:      host.Max.String(),
+		})
+	}
+	for _, bucket := range finalStats.Timeline {
+		document.Timeline = append(document.Timeline, xmlTimeBucket{
+			Start:     bucket.Start.Format(time.RFC3339),
+			Requests:  bucket.Requests,
+			Errors:    bucket.Errors,
+			ErrorRate: bucket.ErrorRate,
+			P95:       bucket.P95.String(),
+		})
+	}
+	for _, phase := range finalStats.Phases {
+		document.Phases = append(document.Phases, xmlPhaseStats{Name: phase.Name, Duration: phase.Duration.String()})
+	}
+
+	return marshalXML(document)
+}
+
+// formatCacheStatsXML formats cache statistics as XML
+func (f *Formatter) formatCacheStatsXML(cacheStats *stats.CacheStats) string {
+	return marshalXML(xmlCacheStats{
+		SchemaVersion:    SchemaVersion,
+		Timestamp:        time.Now().Format(time.RFC3339),
+		Run:              f.xmlRunInfo(),
+		CacheHits:        cacheStats.CacheHits,
+		CacheMisses:      cacheStats.CacheMisses,
+		CacheHitRate:     cacheStats.CacheHitRate,
+		WarmUpTime:       cacheStats.WarmUpTime.String(),
+		VerifyTime:       cacheStats.VerifyTime.String(),
+		ValidatorChanges: cacheStats.ValidatorChanges,
+		HeaderChanges:    cacheStats.HeaderChanges,
+	})
+}
+
+// formatResultsXML formats per-URL results as an XML results file
+func (f *Formatter) formatResultsXML(entries []ResultEntry, sampling *Sampling) string {
+	document := xmlResultsFile{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Run:           f.xmlRunInfo(),
+		Results:       make([]xmlResult, len(entries)),
+	}
+	if sampling != nil {
+		document.Sampling = &xmlSampling{Rate: sampling.Rate}
+		for _, phase := range slices.Sorted(maps.Keys(sampling.Omitted)) {
+			omitted := sampling.Omitted[phase]
+			document.Sampling.Omitted = append(document.Sampling.Omitted, xmlOmitted{Phase: phase, Results: omitted.Results, CacheHits: omitted.CacheHits})
+		}
+	}
+
+	for i, entry := range entries {
+		result := xmlResult{
+			Phase:         entry.Phase,
+			Edge:          entry.Edge,
+			URL:           entry.URL,
+			Sitemap:       entry.Sitemap,
+			Success:       entry.Success,
+			StatusCode:    entry.StatusCode,
+			Error:         entry.Error,
+			ErrorCategory: string(entry.ErrorCategory),
+			Duration:      entry.Duration.String(),
+			Start:         formatTimestamp(entry.Start),
+			End:           formatTimestamp(entry.End),
+			Attempts:      entry.Attempts,
+			CacheStatus:   entry.CacheStatus,
+			Size:          entry.Size,
+			SurrogateKeys: entry.SurrogateKeys,
+			TraceID:       entry.TraceID,
+			ETag:          entry.ETag,
+			LastModified:  entry.LastModified,
+			Ranged:        entry.Ranged,
+		}
+		if entry.Age > 0 {
+			result.Age = entry.Age.String()
+		}
+
+		// Metrics are sorted so that the same results give the same file
+		names := make([]string, 0, len(entry.ServerTiming))
+		for name := range entry.ServerTiming {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result.ServerTiming = append(result.ServerTiming, xmlServerTime{Name: name, Duration: entry.ServerTiming[name].String()})
+		}
+		names = names[:0]
+		for name := range entry.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result.Headers = append(result.Headers, xmlHeader{Name: name, Value: entry.Headers[name]})
+		}
+
+		document.Results[i] = result
+	}
+
+	return marshalXML(document)
+}
+
+// parseResultsXML reads an XML results file written by formatResultsXML
+func parseResultsXML(data []byte) (*ResultsFile, error) {
+	var document xmlResultsFile
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	file := &ResultsFile{
+		SchemaVersion: document.SchemaVersion,
+		Results:       make([]ResultEntry, len(document.Results)),
+	}
+	if document.Timestamp != "" {
+		timestamp, err := time.Parse(time.RFC3339, document.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		file.Timestamp = timestamp
+	}
+	if document.Run != nil {
+		run, err := parseRunXML(document.Run)
+		if err != nil {
+			return nil, err
+		}
+		file.Run = run
+	}
+	if document.Sampling != nil {
+		file.Sampling = &Sampling{Rate: document.Sampling.Rate, Omitted: make(map[string]Omitted, len(document.Sampling.Omitted))}
+		for _, omitted := range document.Sampling.Omitted {
+			file.Sampling.Omitted[omitted.Phase] = Omitted{Results: omitted.Results, CacheHits: omitted.CacheHits}
+		}
+	}
+
+	for i, result := range document.Results {
+		entry := ResultEntry{Phase: result.Phase, Result: stats.Result{
+			URL:           result.URL,
+			Edge:          result.Edge,
+			Sitemap:       result.Sitemap,
+			Success:       result.Success,
+			StatusCode:    result.StatusCode,
+			Error:         result.Error,
+			ErrorCategory: stats.ErrorCategory(result.ErrorCategory),
+			Attempts:      result.Attempts,
+			CacheStatus:   result.CacheStatus,
+			Size:          result.Size,
+			SurrogateKeys: result.SurrogateKeys,
+			TraceID:       result.TraceID,
+			ETag:          result.ETag,
+			LastModified:  result.LastModified,
+			Ranged:        result.Ranged,
+		}}
+
+		var err error
+		if entry.Duration, err = parseXMLDuration(result.Duration); err != nil {
+			return nil, fmt.Errorf("invalid duration for %s: %w", result.URL, err)
+		}
+		if entry.Age, err = parseXMLDuration(result.Age); err != nil {
+			return nil, fmt.Errorf("invalid age for %s: %w", result.URL, err)
+		}
+		if entry.Start, err = parseXMLTimestamp(result.Start); err != nil {
+			return nil, fmt.Errorf("invalid start for %s: %w", result.URL, err)
+		}
+		if entry.End, err = parseXMLTimestamp(result.End); err != nil {
+			return nil, fmt.Errorf("invalid end for %s: %w", result.URL, err)
+		}
+		for _, metric := range result.ServerTiming {
+			duration, err := parseXMLDuration(metric.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("invalid server timing for %s: %w", result.URL, err)
+			}
+			if entry.ServerTiming == nil {
+				entry.ServerTiming = make(map[string]time.Duration)
+			}
+			entry.ServerTiming[metric.Name] = duration
+		}
+		for _, header := range result.Headers {
+			if entry.Headers == nil {
+				entry.Headers = make(map[string]string)
+			}
+			entry.Headers[header.Name] = header.Value
+		}
+
+		file.Results[i] = entry
+	}
+	return file, nil
+}
+
+// parseRunXML reads the run metadata of an XML results file
+func parseRunXML(run *xmlRun) (*runinfo.Run, error) {
+	parsed := &runinfo.Run{ID: run.ID, Version: run.Version, SitemapURLs: run.SitemapURLs, Seed: run.Seed}
+
+	var err error
+	if parsed.StartedAt, err = time.Parse(time.RFC3339, run.StartedAt); err != nil {
+		return nil, fmt.Errorf("invalid run start: %w", err)
+	}
+	if run.FinishedAt != "" {
+		if parsed.FinishedAt, err = time.Parse(time.RFC3339, run.FinishedAt); err != nil {
+			return nil, fmt.Errorf("invalid run finish: %w", err)
+		}
+	}
+	return parsed, nil
+}
+
+// parseXMLDuration parses a duration string; an empty one is zero
+func parseXMLDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseXMLTimestamp parses an optional RFC 3339 timestamp
+func parseXMLTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
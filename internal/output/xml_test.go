@@ -0,0 +1,158 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+func TestFormatXML(t *testing.T) {
+	t.Parallel()
+
+	finalStats := &stats.FinalStats{
+		TotalProcessed:   10,
+		TotalSuccess:     8,
+		TotalErrors:      2,
+		SuccessRate:      80.0,
+		AverageDuration:  150 * time.Millisecond,
+		ErrorsByCategory: map[stats.ErrorCategory]int{stats.ErrorTimeout: 2},
+		Hosts:            []stats.HostStats{{Host: "www.example.com", Requests: 10, Errors: 2, P50: 100 * time.Millisecond}},
+	}
+
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			name:   "progress",
+			output: New("xml").FormatProgress(&stats.Progress{Processed: 5, Total: 10, AverageDuration: 150 * time.Millisecond}),
+			expected: []string{
+				`<?xml version="1.0" encoding="UTF-8"?>`,
+				`<progress schema_version="1" timestamp="`,
+				"<processed>5</processed>",
+				"<average_duration>150ms</average_duration>",
+			},
+		},
+		{
+			name:   "final stats",
+			output: New("xml").FormatFinalStats(finalStats),
+			expected: []string{
+				`<final_stats schema_version="1"`,
+				"<total_processed>10</total_processed>",
+				"<success_rate>80</success_rate>",
+				`<category name="timeout">2</category>`,
+				`<host name="www.example.com" requests="10" errors="2" p50="100ms"`,
+			},
+		},
+		{
+			name:   "cache stats",
+			output: New("xml").FormatCacheStats(&stats.CacheStats{CacheHits: 9, CacheMisses: 1, CacheHitRate: 90, WarmUpTime: time.Second}),
+			expected: []string{
+				`<cache_stats schema_version="1"`,
+				"<cache_hits>9</cache_hits>",
+				"<warm_up_time>1s</warm_up_time>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for _, expected := range tt.expected {
+				if !strings.Contains(tt.output, expected) {
+					t.Errorf("Expected output to contain '%s', got '%s'", expected, tt.output)
+				}
+			}
+		})
+	}
+}
+
+func TestResultsXMLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	run := &runinfo.Run{
+		ID:          "20261016T120000Z-0a1b2c3d",
+		Version:     "1.2.3",
+		StartedAt:   time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		SitemapURLs: []string{"https://example.com/sitemap.xml"},
+		Seed:        42,
+	}
+	entries := []ResultEntry{
+		{Phase: "crawl", Result: stats.Result{
+			URL:           "https://example.com/",
+			Success:       true,
+			StatusCode:    200,
+			Duration:      150 * time.Millisecond,
+			CacheStatus:   "HIT",
+			Age:           time.Minute,
+			SurrogateKeys: []string{"home", "all"},
+			ServerTiming:  map[string]time.Duration{"db": 20 * time.Millisecond, "app": 5 * time.Millisecond},
+			Headers:       map[string]string{"Vary": "Accept-Encoding", "Cache-Control": "max-age=60"},
+			Start:         time.Date(2026, 10, 16, 12, 0, 1, 0, time.UTC),
+			End:           time.Date(2026, 10, 16, 12, 0, 1, 150_000_000, time.UTC),
+		}},
+		{Phase: "crawl", Result: stats.Result{
+			URL:           "https://example.com/gone",
+			StatusCode:    404,
+			Error:         "HTTP 404",
+			ErrorCategory: stats.ErrorHTTPStatus,
+			Duration:      10 * time.Millisecond,
+			Attempts:      2,
+		}},
+		{Phase: "verify", Result: stats.Result{
+			URL:         "https://example.com/",
+			Edge:        "lhr",
+			Success:     true,
+			StatusCode:  200,
+			Duration:    30 * time.Millisecond,
+			CacheStatus: "MISS",
+		}},
+		{Phase: "crawl", Result: stats.Result{
+			URL:        "https://example.com/intro.mp4",
+			Success:    true,
+			StatusCode: 206,
+			Duration:   20 * time.Millisecond,
+			Size:       1,
+			Ranged:     true,
+		}},
+	}
+
+	content := New("xml").WithRun(run).FormatResults(entries)
+	for _, expected := range []string{
+		`<results schema_version="1"`,
+		`<run id="20261016T120000Z-0a1b2c3d" version="1.2.3" started_at="2026-10-16T12:00:00Z" seed="42">`,
+		`<result phase="crawl">`,
+		`<result phase="verify" edge="lhr">`,
+		`<metric name="app" duration="5ms"></metric>`,
+		`<header name="Cache-Control">max-age=60</header>`,
+		`<start>2026-10-16T12:00:01Z</start>`,
+		`<end>2026-10-16T12:00:01.15Z</end>`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected results to contain '%s', got '%s'", expected, content)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.xml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadResults(path)
+	if err != nil {
+		t.Fatalf("Failed to load results: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Results, entries) {
+		t.Errorf("Expected %+v, got %+v", entries, loaded.Results)
+	}
+	if loaded.Run == nil || loaded.Run.ID != run.ID || loaded.Run.Seed != run.Seed || !loaded.Run.StartedAt.Equal(run.StartedAt) {
+		t.Errorf("Expected run %+v, got %+v", run, loaded.Run)
+	}
+}
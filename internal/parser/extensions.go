@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+// Image is an <image:image> entry of a sitemap URL
+type Image struct {
+	Loc string
+
+	// Caption and Title are deprecated by Google but still found in
+	// older sitemaps
+	Caption string
+	Title   string
+}
+
+// Video is a <video:video> entry of a sitemap URL
+type Video struct {
+	ThumbnailLoc string
+	Title        string
+	Description  string
+
+	// ContentLoc is the video file and PlayerLoc a page that plays it; a
+	// video has at least one of them
+	ContentLoc string
+	PlayerLoc  string
+
+	// Duration is zero when the sitemap does not give one
+	Duration        time.Duration
+	PublicationDate time.Time
+}
+
+// Alternate is an <xhtml:link rel="alternate"> entry naming a localized
+// variant of a sitemap URL
+type Alternate struct {
+	// Hreflang is a language and optional region, such as en or de-AT, or
+	// x-default
+	Hreflang string
+	Href     string
+}
+
+// News is the <news:news> entry of a sitemap URL
+type News struct {
+	PublicationName     string
+	PublicationLanguage string
+	PublicationDate     time.Time
+	Title               string
+}
+
+// rawImage, rawVideo and rawNews decode the extensions before their values
+// are trimmed and parsed
+type rawImage struct {
+	Loc     string `xml:"http://www.google.com/schemas/sitemap-image/1.1 loc"`
+	Caption string `xml:"http://www.google.com/schemas/sitemap-image/1.1 caption"`
+	Title   string `xml:"http://www.google.com/schemas/sitemap-image/1.1 title"`
+}
+
+type rawVideo struct {
+	ThumbnailLoc    string `xml:"http://www.google.com/schemas/sitemap-video/1.1 thumbnail_loc"`
+	Title           string `xml:"http://www.google.com/schemas/sitemap-video/1.1 title"`
+	Description     string `xml:"http://www.google.com/schemas/sitemap-video/1.1 description"`
+	ContentLoc      string `xml:"http://www.google.com/schemas/sitemap-video/1.1 content_loc"`
+	PlayerLoc       string `xml:"http://www.google.com/schemas/sitemap-video/1.1 player_loc"`
+	Duration        int    `xml:"http://www.google.com/schemas/sitemap-video/1.1 duration"`
+	PublicationDate string `xml:"http://www.google.com/schemas/sitemap-video/1.1 publication_date"`
+}
+
+type rawLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type rawNews struct {
+	PublicationName     string `xml:"http://www.google.com/schemas/sitemap-news/0.9 publication>name"`
+	PublicationLanguage string `xml:"http://www.google.com/schemas/sitemap-news/0.9 publication>language"`
+	PublicationDate     string `xml:"http://www.google.com/schemas/sitemap-news/0.9 publication_date"`
+	Title               string `xml:"http://www.google.com/schemas/sitemap-news/0.9 title"`
+}
+
+// decodeExtensions copies a URL entry's decoded extensions onto it
+func (u *URL) decodeExtensions(images []rawImage, videos []rawVideo, news *rawNews) {
+	for _, image := range images {
+		u.Images = append(u.Images, Image{
+			Loc:     strings.TrimSpace(image.Loc),
+			Caption: strings.TrimSpace(image.Caption),
+			Title:   strings.TrimSpace(image.Title),
+		})
+	}
+	for _, video := range videos {
+		u.Videos = append(u.Videos, Video{
+			ThumbnailLoc:    strings.TrimSpace(video.ThumbnailLoc),
+			Title:           strings.TrimSpace(video.Title),
+			Description:     strings.TrimSpace(video.Description),
+			ContentLoc:      strings.TrimSpace(video.ContentLoc),
+			PlayerLoc:       strings.TrimSpace(video.PlayerLoc),
+			Duration:        time.Duration(max(video.Duration, 0)) * time.Second,
+			PublicationDate: parseLastMod(video.PublicationDate),
+		})
+	}
+	if news != nil {
+		u.News = &News{
+			PublicationName:     strings.TrimSpace(news.PublicationName),
+			PublicationLanguage: strings.TrimSpace(news.PublicationLanguage),
+			PublicationDate:     parseLastMod(news.PublicationDate),
+			Title:               strings.TrimSpace(news.Title),
+		}
+	}
+}
+
+// decodeAlternates keeps the hreflang alternates among a URL entry's links
+func (u *URL) decodeAlternates(links []rawLink) {
+	for _, link := range links {
+		href := strings.TrimSpace(link.Href)
+		hreflang := strings.TrimSpace(link.Hreflang)
+		if !strings.EqualFold(strings.TrimSpace(link.Rel), "alternate") || hreflang == "" || href == "" {
+			continue
+		}
+		u.Alternates = append(u.Alternates, Alternate{Hreflang: hreflang, Href: href})
+	}
+}
+
+// MediaURLs returns the image, video thumbnail and video file URLs listed
+// with the entries
+func MediaURLs(entries []URL) []URL {
+	return derive(entries, func(entry URL) []string {
+		var locs []string
+		for _, image := range entry.Images {
+			locs = append(locs, image.Loc)
+		}
+		for _, video := range entry.Videos {
+			locs = append(locs, video.ThumbnailLoc, video.ContentLoc)
+		}
+		return locs
+	})
+}
+
+// AlternateURLs returns the hreflang alternate URLs listed with the entries
+func AlternateURLs(entries []URL) []URL {
+	return derive(entries, func(entry URL) []string {
+		locs := make([]string, len(entry.Alternates))
+		for i, alternate := range entry.Alternates {
+			locs[i] = alternate.Href
+		}
+		return locs
+	})
+}
+
+// derive returns the URLs locs takes from each entry, each once and not
+// repeating an entry's own URL. Each is marked Derived and attributed to
+// the sitemap that listed the entry.
+func derive(entries []URL, locs func(URL) []string) []URL {
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		seen[entry.Loc] = true
+	}
+
+	var derived []URL
+	for _, entry := range entries {
+		for _, loc := range locs(entry) {
+			if loc == "" || seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			derived = append(derived, URL{Loc: loc, Sitemap: entry.Sitemap, Derived: true})
+		}
+	}
+	return derived
+}
+
+// Listed returns the entries the sitemap lists, leaving out derived URLs
+func Listed(entries []URL) []URL {
+	listed := make([]URL, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Derived {
+			listed = append(listed, entry)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSitemapExtensions(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+	parsed, err := p.parseSitemapContent([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+	xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+	xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+	xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+	<url>
+		<loc>https://example.com/gallery</loc>
+		<image:image>
+			<image:loc> https://cdn.example.com/one.jpg </image:loc>
+			<image:caption>First</image:caption>
+		</image:image>
+		<image:image><image:loc>https://cdn.example.com/two.jpg</image:loc></image:image>
+		<video:video>
+			<video:thumbnail_loc>https://cdn.example.com/thumb.jpg</video:thumbnail_loc>
+			<video:title>Tour</video:title>
+			<video:description>A tour</video:description>
+			<video:content_loc>https://cdn.example.com/tour.mp4</video:content_loc>
+			<video:player_loc>https://example.com/player?video=tour</video:player_loc>
+			<video:duration>90</video:duration>
+			<video:publication_date>2023-01-02T15:04:05Z</video:publication_date>
+		</video:video>
+	</url>
+	<url>
+		<loc>https://example.com/news/launch</loc>
+		<news:news>
+			<news:publication>
+				<news:name>Example Times</news:name>
+				<news:language>en</news:language>
+			</news:publication>
+			<news:publication_date>2023-01-02</news:publication_date>
+			<news:title>Launch</news:title>
+		</news:news>
+	</url>
+</urlset>`))
+	if err != nil {
+		t.Fatalf("parseSitemapContent returned error: %v", err)
+	}
+	urls := parsed.entries
+	if len(urls) != 2 {
+		t.Fatalf("Expected 2 URLs, got %d", len(urls))
+	}
+
+	gallery := urls[0]
+	if len(gallery.Images) != 2 || gallery.Images[0].Loc != "https://cdn.example.com/one.jpg" || gallery.Images[0].Caption != "First" || gallery.Images[1].Loc != "https://cdn.example.com/two.jpg" {
+		t.Errorf("Unexpected images: %+v", gallery.Images)
+	}
+	if len(gallery.Videos) != 1 {
+		t.Fatalf("Expected 1 video, got %+v", gallery.Videos)
+	}
+	video := gallery.Videos[0]
+	if video.ThumbnailLoc != "https://cdn.example.com/thumb.jpg" || video.ContentLoc != "https://cdn.example.com/tour.mp4" || video.PlayerLoc != "https://example.com/player?video=tour" {
+		t.Errorf("Unexpected video locations: %+v", video)
+	}
+	if video.Title != "Tour" || video.Description != "A tour" || video.Duration != 90*time.Second {
+		t.Errorf("Unexpected video metadata: %+v", video)
+	}
+	if !video.PublicationDate.Equal(time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected video publication date %v", video.PublicationDate)
+	}
+	if gallery.News != nil {
+		t.Errorf("Expected no news, got %+v", gallery.News)
+	}
+
+	news := urls[1].News
+	if news == nil {
+		t.Fatal("Expected news")
+	}
+	expected := News{PublicationName: "Example Times", PublicationLanguage: "en", PublicationDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Title: "Launch"}
+	if *news != expected {
+		t.Errorf("Expected news %+v, got %+v", expected, *news)
+	}
+}
+
+func TestMediaURLs(t *testing.T) {
+	t.Parallel()
+
+	entries := []URL{
+		{
+			Loc:     "https://example.com/gallery",
+			Sitemap: "https://example.com/sitemap.xml",
+			Images:  []Image{{Loc: "https://cdn.example.com/one.jpg"}, {Loc: "https://example.com/about"}},
+			Videos:  []Video{{ThumbnailLoc: "https://cdn.example.com/thumb.jpg", PlayerLoc: "https://example.com/player"}},
+		},
+		{
+			Loc:     "https://example.com/about",
+			Sitemap: "https://example.com/pages.xml",
+			Images:  []Image{{Loc: "https://cdn.example.com/one.jpg"}},
+			Videos:  []Video{{ContentLoc: "https://cdn.example.com/tour.mp4"}},
+		},
+	}
+
+	media := MediaURLs(entries)
+	expected := []URL{
+		{Loc: "https://cdn.example.com/one.jpg", Sitemap: "https://example.com/sitemap.xml"},
+		{Loc: "https://cdn.example.com/thumb.jpg", Sitemap: "https://example.com/sitemap.xml"},
+		{Loc: "https://cdn.example.com/tour.mp4", Sitemap: "https://example.com/pages.xml"},
+	}
+	if len(media) != len(expected) {
+		t.Fatalf("Expected %d media URLs, got %+v", len(expected), media)
+	}
+	for i, want := range expected {
+		if media[i].Loc != want.Loc || media[i].Sitemap != want.Sitemap || !media[i].Derived {
+			t.Errorf("Expected media URL %d to be %+v, got %+v", i, want, media[i])
+		}
+	}
+
+	listed := Listed(append(entries, media...))
+	if len(listed) != len(entries) || listed[0].Loc != entries[0].Loc || listed[1].Loc != entries[1].Loc {
+		t.Errorf("Expected only the listed entries, got %+v", listed)
+	}
+}
+
+func TestParseHreflangAlternates(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+	parsed, err := p.parseSitemapContent([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+	<url>
+		<loc>https://example.com/en/</loc>
+		<xhtml:link rel="alternate" hreflang="en" href="https://example.com/en/"/>
+		<xhtml:link rel="alternate" hreflang="de-AT" href=" https://example.com/de-at/ "/>
+		<xhtml:link rel="alternate" hreflang="x-default" href="https://example.com/"/>
+		<xhtml:link rel="canonical" href="https://example.com/en/"/>
+		<xhtml:link rel="alternate" href="https://example.com/no-language/"/>
+	</url>
+	<url>
+		<loc>https://example.com/de-at/</loc>
+		<xhtml:link rel="alternate" hreflang="en" href="https://example.com/en/"/>
+		<xhtml:link rel="alternate" hreflang="fr" href="https://example.com/fr/"/>
+	</url>
+</urlset>`))
+	if err != nil {
+		t.Fatalf("parseSitemapContent returned error: %v", err)
+	}
+	urls := parsed.entries
+	if len(urls) != 2 {
+		t.Fatalf("Expected 2 URLs, got %d", len(urls))
+	}
+
+	expected := []Alternate{
+		{Hreflang: "en", Href: "https://example.com/en/"},
+		{Hreflang: "de-AT", Href: "https://example.com/de-at/"},
+		{Hreflang: "x-default", Href: "https://example.com/"},
+	}
+	if len(urls[0].Alternates) != len(expected) {
+		t.Fatalf("Expected %d alternates, got %+v", len(expected), urls[0].Alternates)
+	}
+	for i, want := range expected {
+		if urls[0].Alternates[i] != want {
+			t.Errorf("Expected alternate %d to be %+v, got %+v", i, want, urls[0].Alternates[i])
+		}
+	}
+
+	// Alternates that are listed pages themselves are not repeated
+	alternates := AlternateURLs(urls)
+	locs := Locations(alternates)
+	if len(locs) != 2 || locs[0] != "https://example.com/" || locs[1] != "https://example.com/fr/" {
+		t.Errorf("Expected the unlisted alternates, got %v", locs)
+	}
+	for _, alternate := range alternates {
+		if !alternate.Derived {
+			t.Errorf("Expected %s to be marked derived", alternate.Loc)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// pubDateLayouts lists the RFC 822 variants feeds use for <pubDate>
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// rssFeed is an RSS 2.0 document, or an RSS 1.0 (RDF) one, whose items sit
+// beside the channel rather than inside it
+type rssFeed struct {
+	Items        []rssItem `xml:"item"`
+	ChannelItems []rssItem `xml:"channel>item"`
+}
+
+type rssItem struct {
+	Link    string  `xml:"link"`
+	GUID    rssGUID `xml:"guid"`
+	PubDate string  `xml:"pubDate"`
+	Date    string  `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type rssGUID struct {
+	Value     string `xml:",chardata"`
+	PermaLink string `xml:"isPermaLink,attr"`
+}
+
+// atomFeed is an Atom document
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Links     []atomLink `xml:"link"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// feedRoot returns the name of the document's root element when it is an
+// RSS or Atom feed: rss, RDF or feed
+func feedRoot(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "rss", "RDF", "feed":
+				return start.Name.Local
+			}
+			return ""
+		}
+	}
+}
+
+// parseFeed extracts the links of an RSS or Atom feed's items as URL
+// entries, taking each item's publication or update time as its LastMod
+func parseFeed(data []byte, root string) ([]URL, error) {
+	var entries []URL
+	if root == "feed" {
+		var feed atomFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("failed to parse Atom feed: %w", err)
+		}
+		for _, entry := range feed.Entries {
+			if loc := entry.link(); loc != "" {
+				entries = append(entries, URL{Loc: loc, LastMod: parseLastMod(firstNonEmpty(entry.Updated, entry.Published))})
+			}
+		}
+	} else {
+		var feed rssFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+		}
+		for _, item := range append(feed.ChannelItems, feed.Items...) {
+			if loc := item.link(); loc != "" {
+				entries = append(entries, URL{Loc: loc, LastMod: item.lastMod()})
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("feed has no item links")
+	}
+	return entries, nil
+}
+
+// link returns the item's link, falling back to a GUID that is a permalink
+func (i rssItem) link() string {
+	if link := strings.TrimSpace(i.Link); link != "" {
+		return link
+	}
+	guid := strings.TrimSpace(i.GUID.Value)
+	if strings.EqualFold(i.GUID.PermaLink, "false") || !(strings.HasPrefix(guid, "http://") || strings.HasPrefix(guid, "https://")) {
+		return ""
+	}
+	return guid
+}
+
+// lastMod returns the item's publication time, or the zero time if it has
+// none or it cannot be parsed
+func (i rssItem) lastMod() time.Time {
+	if value := strings.TrimSpace(i.PubDate); value != "" {
+		for _, layout := range pubDateLayouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed
+			}
+		}
+		return time.Time{}
+	}
+	return parseLastMod(i.Date)
+}
+
+// link returns the entry's alternate link, the page the entry describes
+func (e atomEntry) link() string {
+	for _, link := range e.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFeeds(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected []URL
+	}{
+		{
+			name: "rss",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+	<channel>
+		<title>Blog</title>
+		<link>https://example.com/blog</link>
+		<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+		<item>
+			<title>First</title>
+			<link>https://example.com/blog/first</link>
+			<pubDate>Mon, 02 Jan 2023 15:04:05 +0000</pubDate>
+		</item>
+		<item>
+			<title>Second</title>
+			<guid>https://example.com/blog/second</guid>
+			<pubDate>not a date</pubDate>
+		</item>
+		<item>
+			<title>Linkless</title>
+			<guid isPermaLink="false">post-3</guid>
+		</item>
+	</channel>
+</rss>`,
+			expected: []URL{
+				{Loc: "https://example.com/blog/first", LastMod: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)},
+				{Loc: "https://example.com/blog/second"},
+			},
+		},
+		{
+			name: "rdf",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="https://example.com/">
+		<link>https://example.com/</link>
+	</channel>
+	<item rdf:about="https://example.com/news/1">
+		<link>https://example.com/news/1</link>
+		<dc:date>2023-01-02</dc:date>
+	</item>
+</rdf:RDF>`,
+			expected: []URL{
+				{Loc: "https://example.com/news/1", LastMod: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "atom",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Blog</title>
+	<link href="https://example.com/blog"/>
+	<entry>
+		<link rel="edit" href="https://example.com/api/posts/1"/>
+		<link rel="alternate" href="https://example.com/blog/first"/>
+		<updated>2023-01-02T15:04:05Z</updated>
+	</entry>
+	<entry>
+		<link href="https://example.com/blog/second"/>
+		<published>2023-01-03T00:00:00Z</published>
+	</entry>
+	<entry>
+		<link rel="enclosure" href="https://example.com/audio.mp3"/>
+	</entry>
+</feed>`,
+			expected: []URL{
+				{Loc: "https://example.com/blog/first", LastMod: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)},
+				{Loc: "https://example.com/blog/second", LastMod: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parsed, err := p.parseSitemapContent([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseSitemapContent returned error: %v", err)
+			}
+			if parsed.isIndex {
+				t.Error("Expected a feed not to be treated as a sitemap index")
+			}
+			if len(parsed.entries) != len(tt.expected) {
+				t.Fatalf("Expected %d URLs, got %d: %v", len(tt.expected), len(parsed.entries), parsed.entries)
+			}
+			for i, want := range tt.expected {
+				got := parsed.entries[i]
+				if got.Loc != want.Loc {
+					t.Errorf("Expected URL %d to be %q, got %q", i, want.Loc, got.Loc)
+				}
+				if !got.LastMod.Equal(want.LastMod) {
+					t.Errorf("Expected URL %d to have lastmod %v, got %v", i, want.LastMod, got.LastMod)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeedWithoutLinks(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+	_, err := p.parseSitemapContent([]byte(`<rss version="2.0"><channel><title>Empty</title></channel></rss>`))
+	if err == nil {
+		t.Error("Expected an error for a feed without item links")
+	}
+}
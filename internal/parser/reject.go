@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Reasons a sitemap URL is rejected instead of crawled
+const (
+	RejectMalformed     = "malformed"
+	RejectScheme        = "bad_scheme"
+	RejectBlockedDomain = "blocked_domain"
+)
+
+// RejectReasons returns every rejection reason in a stable order
+func RejectReasons() []string {
+	return []string{RejectMalformed, RejectScheme, RejectBlockedDomain}
+}
+
+// Rejection is a sitemap URL that will not be crawled
+type Rejection struct {
+	URL     string
+	Sitemap string
+	Reason  string
+	Detail  string
+}
+
+// SetBlockedDomains sets the domains whose URLs, and those of their
+// subdomains, are rejected
+func (p *Parser) SetBlockedDomains(domains []string) {
+	p.blockedDomains = nil
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			p.blockedDomains = append(p.blockedDomains, domain)
+		}
+	}
+}
+
+// CheckURL returns why a sitemap URL cannot be crawled, or nil if it can
+func (p *Parser) CheckURL(entry URL) *Rejection {
+	reject := func(reason, detail string) *Rejection {
+		return &Rejection{URL: entry.Loc, Sitemap: entry.Sitemap, Reason: reason, Detail: detail}
+	}
+
+	if entry.Loc == "" {
+		return reject(RejectMalformed, "empty URL")
+	}
+
+	parsedURL, err := url.Parse(entry.Loc)
+	if err != nil {
+		return reject(RejectMalformed, err.Error())
+	}
+
+	// Only absolute http and https URLs can be crawled
+	if parsedURL.Scheme == "" {
+		return reject(RejectMalformed, "missing scheme")
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return reject(RejectScheme, fmt.Sprintf("unsupported scheme %q", parsedURL.Scheme))
+	}
+	if parsedURL.Host == "" {
+		return reject(RejectMalformed, "missing host")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+	for _, domain := range p.blockedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return reject(RejectBlockedDomain, fmt.Sprintf("%s is blocked", domain))
+		}
+	}
+	return nil
+}
+
+// FilterURLs splits sitemap URLs into those that can be crawled, in their
+// original order, and those rejected
+func (p *Parser) FilterURLs(urls []URL) ([]URL, []Rejection) {
+	var valid []URL
+	var rejected []Rejection
+	for _, entry := range urls {
+		if rejection := p.CheckURL(entry); rejection != nil {
+			rejected = append(rejected, *rejection)
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	return valid, rejected
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckURL(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+	p.SetBlockedDomains([]string{"Staging.Example.com", "example.org."})
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"valid", "https://example.com/page", ""},
+		{"valid with query", "http://example.com?param=value", ""},
+		{"no scheme", "not-a-url", RejectMalformed},
+		{"empty", "", RejectMalformed},
+		{"relative path", "/relative/path", RejectMalformed},
+		{"bad escape", "https://example.com/%zz", RejectMalformed},
+		{"missing host", "https:///page", RejectMalformed},
+		{"ftp", "ftp://example.com/file", RejectScheme},
+		{"mailto", "mailto:team@example.com", RejectScheme},
+		{"javascript", "javascript:void(0)", RejectScheme},
+		{"blocked domain", "https://staging.example.com/page", RejectBlockedDomain},
+		{"blocked subdomain", "https://www.example.org:8443/page", RejectBlockedDomain},
+		{"suffix is not a subdomain", "https://myexample.org/page", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rejection := p.CheckURL(URL{Loc: tt.url, Sitemap: "https://example.com/sitemap.xml"})
+			reason := ""
+			if rejection != nil {
+				reason = rejection.Reason
+				if rejection.Detail == "" || rejection.Sitemap != "https://example.com/sitemap.xml" {
+					t.Errorf("Expected a detail and the sitemap, got %+v", rejection)
+				}
+			}
+			if reason != tt.expected {
+				t.Errorf("CheckURL(%q) reason = %q, expected %q", tt.url, reason, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterURLs(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(30 * time.Second)
+	valid, rejected := p.FilterURLs([]URL{
+		{Loc: "https://example.com/a"},
+		{Loc: "ftp://example.com/b"},
+		{Loc: "https://example.com/c"},
+	})
+
+	if len(valid) != 2 || valid[0].Loc != "https://example.com/a" || valid[1].Loc != "https://example.com/c" {
+		t.Errorf("Expected /a and /c to be valid, got %+v", valid)
+	}
+	if len(rejected) != 1 || rejected[0].Reason != RejectScheme {
+		t.Errorf("Expected ftp URL to be rejected for its scheme, got %+v", rejected)
+	}
+}
@@ -1,26 +1,66 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 )
 
+const defaultUserAgent = "SitemapCrawler/1.0"
+
+// Default resource limits. The byte limit matches the sitemaps protocol's
+// 50MB per file; the URL limit bounds the whole tree of sitemaps.
 const (
-	defaultUserAgent = "SitemapCrawler/1.0"
-	maxSitemapBytes  = 50 * 1024 * 1024
-	maxSitemapDepth  = 10
+	DefaultMaxSitemapBytes = 50 * 1024 * 1024
+	DefaultMaxSitemapDepth = 10
+	DefaultMaxSitemapURLs  = 1000000
 )
 
+// Limits bounds the resources a sitemap tree may consume, so that a hostile
+// or corrupted sitemap cannot exhaust memory
+type Limits struct {
+	// MaxBytes is the largest sitemap document accepted
+	MaxBytes int64
+
+	// MaxDepth is how many levels of sitemap indexes may be nested
+	MaxDepth int
+
+	// MaxURLs is the most unique URLs collected across all sitemaps
+	MaxURLs int
+}
+
+// DefaultLimits returns the limits used when none are configured
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBytes: DefaultMaxSitemapBytes,
+		MaxDepth: DefaultMaxSitemapDepth,
+		MaxURLs:  DefaultMaxSitemapURLs,
+	}
+}
+
 type parsedSitemap struct {
-	urls    []string
+	entries []URL
 	isIndex bool
 }
 
+// lastModLayouts lists the W3C datetime variants accepted for <lastmod>
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
 // Sitemap represents a sitemap structure
 type Sitemap struct {
 	XMLName xml.Name `xml:"sitemapindex"`
@@ -33,18 +73,103 @@ type URLSet struct {
 	URLs    []URL    `xml:"url"`
 }
 
+// DefaultPriority is the priority the sitemaps protocol gives a URL whose
+// <priority> is missing
+const DefaultPriority = 0.5
+
 // URL represents a URL entry in a sitemap
 type URL struct {
 	Loc        string    `xml:"loc"`
 	LastMod    time.Time `xml:"lastmod,omitempty"`
 	ChangeFreq string    `xml:"changefreq,omitempty"`
 	Priority   float64   `xml:"priority,omitempty"`
+
+	// HasPriority is set when the entry has a <priority>, so that an
+	// explicit 0.0 can be told apart from a missing one
+	HasPriority bool `xml:"-"`
+
+	// Images, Videos and News hold the entry's Google sitemap extensions
+	Images []Image `xml:"-"`
+	Videos []Video `xml:"-"`
+	News   *News   `xml:"-"`
+
+	// Alternates are the localized variants the entry's hreflang links name
+	Alternates []Alternate `xml:"-"`
+
+	// Derived marks a URL taken from another entry, such as an image or an
+	// hreflang alternate, rather than one the sitemap lists
+	Derived bool `xml:"-"`
+
+	// Sitemap is the URL of the sitemap that listed the entry
+	Sitemap string `xml:"-"`
 }
 
+// UnmarshalXML decodes a URL entry, accepting every W3C datetime precision for
+// <lastmod> and leaving LastMod zero when the value cannot be parsed rather
+// than rejecting the whole sitemap. Image, video and news extensions and
+// hreflang alternates are decoded along with it.
+func (u *URL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw struct {
+		Loc        string   `xml:"loc"`
+		LastMod    string   `xml:"lastmod"`
+		ChangeFreq string   `xml:"changefreq"`
+		Priority   *float64 `xml:"priority"`
+
+		Images []rawImage `xml:"http://www.google.com/schemas/sitemap-image/1.1 image"`
+		Videos []rawVideo `xml:"http://www.google.com/schemas/sitemap-video/1.1 video"`
+		News   *rawNews   `xml:"http://www.google.com/schemas/sitemap-news/0.9 news"`
+		Links  []rawLink  `xml:"http://www.w3.org/1999/xhtml link"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+
+	u.Loc = strings.TrimSpace(raw.Loc)
+	u.LastMod = parseLastMod(raw.LastMod)
+	u.ChangeFreq = strings.TrimSpace(raw.ChangeFreq)
+	if raw.Priority != nil {
+		u.Priority, u.HasPriority = *raw.Priority, true
+	}
+	u.decodeExtensions(raw.Images, raw.Videos, raw.News)
+	u.decodeAlternates(raw.Links)
+	return nil
+}
+
+// EffectivePriority returns the entry's <priority>, or DefaultPriority when
+// it is missing or not between 0.0 and 1.0
+func (u URL) EffectivePriority() float64 {
+	if !u.HasPriority || u.Priority < 0 || u.Priority > 1 {
+		return DefaultPriority
+	}
+	return u.Priority
+}
+
+// parseLastMod parses a W3C datetime, returning the zero time if it is invalid
+func parseLastMod(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// StdinSitemap is the sitemap location that reads the sitemap from
+// standard input
+const StdinSitemap = "-"
+
 // Parser handles parsing of various sitemap formats
 type Parser struct {
 	client    *http.Client
 	userAgent string
+	limits    Limits
+
+	// stdin is read for the StdinSitemap location
+	stdin io.Reader
+
+	// blockedDomains are rejected along with their subdomains
+	blockedDomains []string
 }
 
 // NewParser creates a new sitemap parser
@@ -54,6 +179,8 @@ func NewParser(timeout time.Duration) *Parser {
 			Timeout: timeout,
 		},
 		userAgent: defaultUserAgent,
+		limits:    DefaultLimits(),
+		stdin:     os.Stdin,
 	}
 }
 
@@ -66,53 +193,215 @@ func (p *Parser) SetUserAgent(userAgent string) {
 	p.userAgent = userAgent
 }
 
-// ParseSitemap parses a sitemap and returns all URLs to crawl
-func (p *Parser) ParseSitemap(sitemapURL string, headers map[string]string) ([]string, error) {
+// SetLimits sets the resource limits for sitemap fetches. Zero fields keep
+// their defaults.
+func (p *Parser) SetLimits(limits Limits) {
+	defaults := DefaultLimits()
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = defaults.MaxBytes
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = defaults.MaxDepth
+	}
+	if limits.MaxURLs <= 0 {
+		limits.MaxURLs = defaults.MaxURLs
+	}
+	p.limits = limits
+}
+
+// SetTransport sets the HTTP transport used for sitemap fetches so that they
+// share the crawler's network configuration.
+func (p *Parser) SetTransport(transport http.RoundTripper) {
+	p.client.Transport = transport
+}
+
+// ParseSitemap parses a sitemap and returns all URL entries to crawl, keeping
+// the metadata (lastmod, changefreq, priority) declared in the sitemap and
+// the sitemap each was listed in
+func (p *Parser) ParseSitemap(sitemapURL string, headers map[string]string) ([]URL, error) {
 	seenSitemaps := make(map[string]bool)
 	seenURLs := make(map[string]bool)
-	urls, err := p.parseSitemapRecursive(sitemapURL, headers, 0, seenSitemaps, seenURLs)
+	entries, err := p.parseSitemapRecursive(sitemapURL, headers, 0, seenSitemaps, seenURLs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
 	}
 
-	return urls, nil
+	return entries, nil
 }
 
-func (p *Parser) parseSitemapRecursive(sitemapURL string, headers map[string]string, depth int, seenSitemaps map[string]bool, seenURLs map[string]bool) ([]string, error) {
-	if depth > maxSitemapDepth {
-		return nil, fmt.Errorf("maximum sitemap depth exceeded")
+func (p *Parser) parseSitemapRecursive(sitemapURL string, headers map[string]string, depth int, seenSitemaps map[string]bool, seenURLs map[string]bool) ([]URL, error) {
+	if depth > p.limits.MaxDepth {
+		return nil, fmt.Errorf("sitemap indexes nested deeper than the maximum depth of %d", p.limits.MaxDepth)
 	}
-	if seenSitemaps[sitemapURL] {
+	key := sitemapKey(sitemapURL)
+	if seenSitemaps[key] {
 		return nil, nil
 	}
-	seenSitemaps[sitemapURL] = true
+	seenSitemaps[key] = true
 
 	parsed, err := p.fetchAndParse(sitemapURL, headers)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(parsed.entries) > p.limits.MaxURLs {
+		return nil, fmt.Errorf("sitemap lists %d entries, more than the maximum of %d URLs", len(parsed.entries), p.limits.MaxURLs)
+	}
+
 	if !parsed.isIndex {
-		return addUniqueURLs(nil, parsed.urls, seenURLs), nil
+		entries := addUniqueURLs(nil, parsed.entries, seenURLs)
+		if len(seenURLs) > p.limits.MaxURLs {
+			return nil, fmt.Errorf("sitemaps contain more than the maximum of %d URLs", p.limits.MaxURLs)
+		}
+		return entries, nil
 	}
 
-	var urls []string
-	for _, childSitemap := range parsed.urls {
-		childURLs, err := p.parseSitemapRecursive(childSitemap, headers, depth+1, seenSitemaps, seenURLs)
+	var entries []URL
+	for _, childSitemap := range parsed.entries {
+		if err := checkChild(sitemapURL, childSitemap.Loc); err != nil {
+			return nil, err
+		}
+		childEntries, err := p.parseSitemapRecursive(childSitemap.Loc, headers, depth+1, seenSitemaps, seenURLs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse child sitemap %s: %w", childSitemap, err)
+			return nil, fmt.Errorf("failed to parse child sitemap %s: %w", childSitemap.Loc, err)
+		}
+		entries = append(entries, childEntries...)
+	}
+
+	return entries, nil
+}
+
+// Document is a sitemap of URLs and the entries it lists
+type Document struct {
+	URL     string
+	Entries []URL
+}
+
+// ParseSitemapDocuments parses a sitemap and returns every sitemap of URLs
+// it leads to, in the order they were reached. Unlike ParseSitemap,
+// URLs listed more than once are kept so that duplicates can be reported.
+func (p *Parser) ParseSitemapDocuments(sitemapURL string, headers map[string]string) ([]Document, error) {
+	var documents []Document
+	total := 0
+	if err := p.collectDocuments(sitemapURL, headers, 0, make(map[string]bool), &documents, &total); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+	}
+
+	return documents, nil
+}
+
+func (p *Parser) collectDocuments(sitemapURL string, headers map[string]string, depth int, seenSitemaps map[string]bool, documents *[]Document, total *int) error {
+	if depth > p.limits.MaxDepth {
+		return fmt.Errorf("sitemap indexes nested deeper than the maximum depth of %d", p.limits.MaxDepth)
+	}
+	key := sitemapKey(sitemapURL)
+	if seenSitemaps[key] {
+		return nil
+	}
+	seenSitemaps[key] = true
+
+	parsed, err := p.fetchAndParse(sitemapURL, headers)
+	if err != nil {
+		return err
+	}
+
+	if !parsed.isIndex {
+		*total += len(parsed.entries)
+		if *total > p.limits.MaxURLs {
+			return fmt.Errorf("sitemaps contain more than the maximum of %d URLs", p.limits.MaxURLs)
+		}
+		*documents = append(*documents, Document{URL: sitemapURL, Entries: parsed.entries})
+		return nil
+	}
+
+	if len(parsed.entries) > p.limits.MaxURLs {
+		return fmt.Errorf("sitemap lists %d entries, more than the maximum of %d URLs", len(parsed.entries), p.limits.MaxURLs)
+	}
+	for _, childSitemap := range parsed.entries {
+		if err := checkChild(sitemapURL, childSitemap.Loc); err != nil {
+			return err
 		}
-		urls = append(urls, childURLs...)
+		if err := p.collectDocuments(childSitemap.Loc, headers, depth+1, seenSitemaps, documents, total); err != nil {
+			return fmt.Errorf("failed to parse child sitemap %s: %w", childSitemap.Loc, err)
+		}
+	}
+
+	return nil
+}
+
+// sitemapKey identifies a sitemap for cycle detection, so that an index
+// listing itself, or an ancestor, under another spelling of its URL is still
+// fetched once. Scheme and host case, a default port and the fragment are
+// ignored; a URL that does not parse is its own key.
+func sitemapKey(sitemapURL string) string {
+	parsed, err := url.Parse(sitemapURL)
+	if err != nil || parsed.Host == "" {
+		return sitemapURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	parsed.Host = host
+	if port != "" {
+		parsed.Host = net.JoinHostPort(host, port)
+	}
+	if parsed.Path == "" {
+		parsed.Path = "/"
 	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// IsLocal reports whether a sitemap is read from a file:// URL or standard
+// input rather than fetched
+func IsLocal(sitemapURL string) bool {
+	return sitemapURL == StdinSitemap || strings.HasPrefix(strings.ToLower(sitemapURL), "file:")
+}
 
-	return urls, nil
+// checkChild rejects a local child sitemap listed by a fetched index, which
+// would let a remote server have local files read
+func checkChild(sitemapURL, childURL string) error {
+	if IsLocal(childURL) && !IsLocal(sitemapURL) {
+		return fmt.Errorf("fetched sitemap index lists local sitemap %s", childURL)
+	}
+	return nil
 }
 
-// fetchAndParse fetches and parses a sitemap
+// fetchAndParse fetches, or reads if it is local, and parses a sitemap
 func (p *Parser) fetchAndParse(sitemapURL string, headers map[string]string) (parsedSitemap, error) {
+	body, err := p.read(sitemapURL, headers, p.limits.MaxBytes)
+	if err != nil {
+		return parsedSitemap{}, err
+	}
+
+	parsed, err := p.parseSitemapContent(body)
+	if err != nil {
+		return parsedSitemap{}, err
+	}
+	for i := range parsed.entries {
+		parsed.entries[i].Sitemap = sitemapURL
+	}
+	return parsed, nil
+}
+
+// read fetches, or reads if it is local, up to maxBytes of a sitemap
+func (p *Parser) read(sitemapURL string, headers map[string]string, maxBytes int64) ([]byte, error) {
+	if IsLocal(sitemapURL) {
+		return p.readLocal(sitemapURL, maxBytes)
+	}
+	return p.fetch(sitemapURL, headers, maxBytes)
+}
+
+// fetch requests a sitemap and returns its body
+func (p *Parser) fetch(sitemapURL string, headers map[string]string, maxBytes int64) ([]byte, error) {
 	req, err := http.NewRequest("GET", sitemapURL, nil)
 	if err != nil {
-		return parsedSitemap{}, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add custom headers
@@ -127,24 +416,76 @@ func (p *Parser) fetchAndParse(sitemapURL string, headers map[string]string) (pa
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return parsedSitemap{}, fmt.Errorf("failed to fetch sitemap: %w", err)
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return parsedSitemap{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	body, err := readLimited(resp.Body, maxSitemapBytes)
+	body, err := readLimited(resp.Body, maxBytes)
 	if err != nil {
-		return parsedSitemap{}, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	return body, nil
+}
+
+// readLocal reads a sitemap from standard input or a file:// URL. Files
+// are named by an absolute path, file:///srv/site/sitemap.xml, or a path
+// relative to the working directory, file:sitemap.xml.
+func (p *Parser) readLocal(sitemapURL string, maxBytes int64) ([]byte, error) {
+	if sitemapURL == StdinSitemap {
+		body, err := readLimited(p.stdin, maxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sitemap from standard input: %w", err)
+		}
+		return body, nil
+	}
+
+	path, err := filePath(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
 
-	return p.parseSitemapContent(body)
+	body, err := readLimited(file, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap: %w", err)
+	}
+	return body, nil
 }
 
+// filePath returns the path a file:// URL names. Only files on this host
+// can be read.
+func filePath(sitemapURL string) (string, error) {
+	parsed, err := url.Parse(sitemapURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid sitemap file URL: %w", err)
+	}
+	if parsed.Opaque != "" {
+		return parsed.Opaque, nil
+	}
+	if parsed.Host != "" && parsed.Host != "localhost" {
+		return "", fmt.Errorf("sitemap file URL names host %s; only local files can be read", parsed.Host)
+	}
+	if parsed.Path == "" {
+		return "", fmt.Errorf("sitemap file URL %s has no path", sitemapURL)
+	}
+	return parsed.Path, nil
+}
+
+// errTooLarge is returned for a sitemap larger than the bytes read of it
+var errTooLarge = errors.New("sitemap exceeds maximum size")
+
 func readLimited(reader io.Reader, maxBytes int64) ([]byte, error) {
 	limited := io.LimitReader(reader, maxBytes+1)
 	body, err := io.ReadAll(limited)
@@ -152,105 +493,109 @@ func readLimited(reader io.Reader, maxBytes int64) ([]byte, error) {
 		return nil, err
 	}
 	if int64(len(body)) > maxBytes {
-		return nil, fmt.Errorf("sitemap exceeds maximum size of %d bytes", maxBytes)
+		return nil, fmt.Errorf("%w of %d bytes", errTooLarge, maxBytes)
 	}
 	return body, nil
 }
 
-// parseXML parses XML content and extracts URLs
-func (p *Parser) parseXML(data []byte) ([]string, error) {
-	parsed, err := p.parseSitemapContent(data)
-	if err != nil {
-		return nil, err
+// parseSitemapContent parses a sitemap, sitemap index, feed or plain-text URL
+// list and extracts its URL entries
+func (p *Parser) parseSitemapContent(data []byte) (parsedSitemap, error) {
+	if err := checkDoctype(data); err != nil {
+		return parsedSitemap{}, err
 	}
-	return parsed.urls, nil
-}
 
-func (p *Parser) parseSitemapContent(data []byte) (parsedSitemap, error) {
-	// Try to parse as sitemap index first
+	// RSS and Atom feeds list pages as items
+	if root := feedRoot(data); root != "" {
+		entries, err := parseFeed(data, root)
+		if err != nil {
+			return parsedSitemap{}, err
+		}
+		return parsedSitemap{entries: entries}, nil
+	}
+
+	// Try to parse as sitemap index
 	var sitemap Sitemap
 	if err := xml.Unmarshal(data, &sitemap); err == nil && len(sitemap.URLs) > 0 {
-		urls := make([]string, len(sitemap.URLs))
-		for i, url := range sitemap.URLs {
-			urls[i] = url.Loc
-		}
-		return parsedSitemap{urls: urls, isIndex: true}, nil
+		return parsedSitemap{entries: sitemap.URLs, isIndex: true}, nil
 	}
 
 	// Try to parse as URL set
 	var urlSet URLSet
 	if err := xml.Unmarshal(data, &urlSet); err == nil && len(urlSet.URLs) > 0 {
-		urls := make([]string, len(urlSet.URLs))
-		for i, url := range urlSet.URLs {
-			urls[i] = url.Loc
-		}
-		return parsedSitemap{urls: urls}, nil
+		return parsedSitemap{entries: urlSet.URLs}, nil
 	}
 
 	// Try to parse as plain text (one URL per line)
 	text := string(data)
 	lines := strings.Split(text, "\n")
-	var urls []string
+	var entries []URL
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line != "" && (strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://")) {
-			urls = append(urls, line)
+			entries = append(entries, URL{Loc: line})
 		}
 	}
 
-	if len(urls) > 0 {
-		return parsedSitemap{urls: urls}, nil
+	if len(entries) > 0 {
+		return parsedSitemap{entries: entries}, nil
 	}
 
 	return parsedSitemap{}, fmt.Errorf("unable to parse sitemap format")
 }
 
-func addUniqueURLs(target []string, urls []string, seen map[string]bool) []string {
-	for _, url := range urls {
-		if seen[url] {
-			continue
-		}
-		seen[url] = true
-		target = append(target, url)
+// checkDoctype rejects XML documents whose DOCTYPE declares entities or
+// references an external DTD. encoding/xml never resolves them, but a sitemap
+// has no legitimate use for either, so they are treated as hostile rather
+// than silently ignored. Only the prolog is scanned.
+func checkDoctype(data []byte) error {
+	if !bytes.Contains(data, []byte("<!DOCTYPE")) {
+		return nil
 	}
-	return target
-}
 
-// isSitemapIndex checks if the URLs are likely sitemap URLs
-func (p *Parser) isSitemapIndex(urls []string) bool {
-	for _, url := range urls {
-		if strings.Contains(url, "sitemap") || strings.HasSuffix(url, ".xml") {
-			return true
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// End of input; syntax errors are reported by the sitemap parsers
+			return nil
 		}
-	}
-	return false
-}
-
-// ValidateURL checks if a URL is valid
-func (p *Parser) ValidateURL(urlStr string) bool {
-	if urlStr == "" {
-		return false
-	}
-
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return false
-	}
 
-	// Must have a scheme
-	if parsedURL.Scheme == "" {
-		return false
+		switch tok := token.(type) {
+		case xml.StartElement:
+			return nil
+		case xml.Directive:
+			directive := string(tok)
+			if !strings.HasPrefix(directive, "DOCTYPE") {
+				continue
+			}
+			if strings.Contains(directive, "<!ENTITY") {
+				return fmt.Errorf("sitemap declares XML entities, which are not allowed")
+			}
+			if strings.Contains(directive, "SYSTEM") || strings.Contains(directive, "PUBLIC") {
+				return fmt.Errorf("sitemap references an external DTD, which is not allowed")
+			}
+		}
 	}
+}
 
-	// Only allow http and https schemes
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return false
+func addUniqueURLs(target []URL, entries []URL, seen map[string]bool) []URL {
+	for _, entry := range entries {
+		if seen[entry.Loc] {
+			continue
+		}
+		seen[entry.Loc] = true
+		target = append(target, entry)
 	}
+	return target
+}
 
-	// Must have a host
-	if parsedURL.Host == "" {
-		return false
+// Locations flattens URL entries to their locations
+func Locations(entries []URL) []string {
+	urls := make([]string, len(entries))
+	for i, entry := range entries {
+		urls[i] = entry.Loc
 	}
-
-	return true
+	return urls
 }
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchmarkSizes covers typical sitemaps up to the protocol's 50,000 URL limit
+var benchmarkSizes = []int{1000, 10000, 50000}
+
+func generateURLSet(count int) []byte {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for i := range count {
+		fmt.Fprintf(&builder, "  <url><loc>https://example.com/pages/%d</loc><lastmod>2024-01-15T10:30:00Z</lastmod></url>\n", i)
+	}
+	builder.WriteString("</urlset>\n")
+	return []byte(builder.String())
+}
+
+func generateSitemapIndex(count int) []byte {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for i := range count {
+		fmt.Fprintf(&builder, "  <sitemap><loc>https://example.com/sitemap-%d.xml</loc></sitemap>\n", i)
+	}
+	builder.WriteString("</sitemapindex>\n")
+	return []byte(builder.String())
+}
+
+func generatePlainText(count int) []byte {
+	var builder strings.Builder
+	for i := range count {
+		fmt.Fprintf(&builder, "https://example.com/pages/%d\n", i)
+	}
+	return []byte(builder.String())
+}
+
+func benchmarkParse(b *testing.B, generate func(int) []byte) {
+	p := NewParser(30 * time.Second)
+	for _, size := range benchmarkSizes {
+		data := generate(size)
+		b.Run(fmt.Sprintf("urls=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				parsed, err := p.parseSitemapContent(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				urls := parsed.entries
+				if len(urls) != size {
+					b.Fatalf("Expected %d URLs, got %d", size, len(urls))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseXMLURLSet(b *testing.B) {
+	benchmarkParse(b, generateURLSet)
+}
+
+func BenchmarkParseXMLSitemapIndex(b *testing.B) {
+	benchmarkParse(b, generateSitemapIndex)
+}
+
+func BenchmarkParsePlainText(b *testing.B) {
+	benchmarkParse(b, generatePlainText)
+}
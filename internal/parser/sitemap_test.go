@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -24,37 +27,7 @@ func TestNewParser(t *testing.T) {
 	}
 }
 
-func TestValidateURL(t *testing.T) {
-	t.Parallel()
-
-	p := NewParser(30 * time.Second)
-
-	tests := []struct {
-		name     string
-		url      string
-		expected bool
-	}{
-		{"valid http", "http://example.com", true},
-		{"valid https", "https://example.com", true},
-		{"valid with path", "https://example.com/path", true},
-		{"valid with query", "https://example.com?param=value", true},
-		{"invalid scheme", "ftp://example.com", false},
-		{"invalid format", "not-a-url", false},
-		{"empty string", "", false},
-		{"relative path", "/relative/path", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := p.ValidateURL(tt.url)
-			if result != tt.expected {
-				t.Errorf("ValidateURL(%q) = %v, expected %v", tt.url, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestParseXML(t *testing.T) {
+func TestParseSitemapContent(t *testing.T) {
 	t.Parallel()
 
 	p := NewParser(30 * time.Second)
@@ -125,7 +98,8 @@ https://example.com/page3`),
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			urls, err := p.parseXML(tt.xmlData)
+			parsed, err := p.parseSitemapContent(tt.xmlData)
+			urls := parsed.entries
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -190,26 +164,155 @@ https://example.com/page3`); err != nil {
 		t.Fatalf("ParseSitemap returned error: %v", err)
 	}
 
-	expected := []string{
-		"https://example.com/page1",
-		"https://example.com/page2",
-		"https://example.com/page3",
+	expected := []struct {
+		loc     string
+		sitemap string
+	}{
+		{loc: "https://example.com/page1", sitemap: server.URL + "/one.xml"},
+		{loc: "https://example.com/page2", sitemap: server.URL + "/one.xml"},
+		{loc: "https://example.com/page3", sitemap: server.URL + "/two.xml"},
 	}
 	if len(urls) != len(expected) {
 		t.Fatalf("Expected %d URLs, got %d: %v", len(expected), len(urls), urls)
 	}
-	for i, expectedURL := range expected {
-		if urls[i] != expectedURL {
-			t.Errorf("Expected URL %d to be %q, got %q", i, expectedURL, urls[i])
+	for i, want := range expected {
+		if urls[i].Loc != want.loc {
+			t.Errorf("Expected URL %d to be %q, got %q", i, want.loc, urls[i].Loc)
+		}
+		if urls[i].Sitemap != want.sitemap {
+			t.Errorf("Expected URL %d to come from %q, got %q", i, want.sitemap, urls[i].Sitemap)
+		}
+	}
+}
+
+func TestParseSitemapIndexCycles(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	fetches := make(map[string]int)
+	var mu sync.Mutex
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+
+		// The root lists itself and a child that lists the root back, each
+		// spelled differently
+		var body string
+		switch r.URL.Path {
+		case "/root.xml":
+			body = fmt.Sprintf(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s/root.xml#self</loc></sitemap>
+	<sitemap><loc>%s/child.xml</loc></sitemap>
+	<sitemap><loc>%s/pages.xml</loc></sitemap>
+</sitemapindex>`, server.URL, server.URL, server.URL)
+		case "/child.xml":
+			body = fmt.Sprintf(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s/root.xml</loc></sitemap>
+	<sitemap><loc>%s/pages.xml</loc></sitemap>
+</sitemapindex>`, strings.Replace(server.URL, "http://", "HTTP://", 1), server.URL)
+		case "/pages.xml":
+			body = "https://example.com/1\nhttps://example.com/2\n"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Errorf("Failed to write sitemap: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	p := NewParser(30 * time.Second)
+	urls, err := p.ParseSitemap(server.URL+"/root.xml", nil)
+	if err != nil {
+		t.Fatalf("ParseSitemap returned error: %v", err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("Expected 2 URLs, got %d: %v", len(urls), urls)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for path, count := range fetches {
+		if count != 1 {
+			t.Errorf("Expected %s to be fetched once, got %d", path, count)
+		}
+	}
+}
+
+func TestSitemapKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "https://example.com/sitemap.xml", expected: "https://example.com/sitemap.xml"},
+		{input: "HTTPS://Example.COM:443/sitemap.xml#top", expected: "https://example.com/sitemap.xml"},
+		{input: "http://example.com:80", expected: "http://example.com/"},
+		{input: "http://example.com:8080/sitemap.xml", expected: "http://example.com:8080/sitemap.xml"},
+		{input: "https://example.com/Sitemap.xml?page=2", expected: "https://example.com/Sitemap.xml?page=2"},
+		{input: "not a url", expected: "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := sitemapKey(tt.input); got != tt.expected {
+			t.Errorf("sitemapKey(%q) = %q, want %q", tt.input, got, tt.expected)
 		}
 	}
 }
 
+func TestParseSitemapDocumentsKeepsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			body = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s/one.xml</loc></sitemap>
+	<sitemap><loc>%s/two.xml</loc></sitemap>
+	<sitemap><loc>%s/one.xml</loc></sitemap>
+</sitemapindex>`, server.URL, server.URL, server.URL)
+		case "/one.xml":
+			body = "https://example.com/page1\nhttps://example.com/page2\nhttps://example.com/page1"
+		case "/two.xml":
+			body = "https://example.com/page2"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Errorf("Failed to write sitemap: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	p := NewParser(30 * time.Second)
+	documents, err := p.ParseSitemapDocuments(server.URL+"/sitemap.xml", nil)
+	if err != nil {
+		t.Fatalf("ParseSitemapDocuments returned error: %v", err)
+	}
+
+	if len(documents) != 2 {
+		t.Fatalf("Expected each sitemap once, got %d documents", len(documents))
+	}
+	if documents[0].URL != server.URL+"/one.xml" || len(documents[0].Entries) != 3 {
+		t.Errorf("Expected one.xml with its 3 entries, got %s with %d", documents[0].URL, len(documents[0].Entries))
+	}
+	if documents[1].URL != server.URL+"/two.xml" || len(documents[1].Entries) != 1 {
+		t.Errorf("Expected two.xml with 1 entry, got %s with %d", documents[1].URL, len(documents[1].Entries))
+	}
+}
+
 func TestFetchAndParseRejectsOversizedSitemap(t *testing.T) {
 	t.Parallel()
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if _, err := fmt.Fprint(w, strings.Repeat("x", maxSitemapBytes+1)); err != nil {
+		if _, err := fmt.Fprint(w, strings.Repeat("x", DefaultMaxSitemapBytes+1)); err != nil {
 			t.Errorf("Failed to write oversized sitemap: %v", err)
 		}
 	}))
@@ -225,68 +328,283 @@ func TestFetchAndParseRejectsOversizedSitemap(t *testing.T) {
 	}
 }
 
-func TestIsSitemapIndex(t *testing.T) {
+func TestURLStruct(t *testing.T) {
+	t.Parallel()
+
+	// Test URL struct marshaling
+	url := URL{
+		Loc:        "https://example.com",
+		LastMod:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		ChangeFreq: "daily",
+		Priority:   0.8,
+	}
+
+	if url.Loc != "https://example.com" {
+		t.Errorf("Expected Loc %s, got %s", "https://example.com", url.Loc)
+	}
+
+	if url.ChangeFreq != "daily" {
+		t.Errorf("Expected ChangeFreq %s, got %s", "daily", url.ChangeFreq)
+	}
+
+	if url.Priority != 0.8 {
+		t.Errorf("Expected Priority %f, got %f", 0.8, url.Priority)
+	}
+}
+
+func TestParseSitemapKeepsMetadata(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/page1</loc><lastmod>2024-03-05</lastmod><changefreq>daily</changefreq><priority>0.8</priority></url>
+	<url><loc>https://example.com/page2</loc><lastmod>2024-03-05T10:30+02:00</lastmod></url>
+	<url><loc>https://example.com/page3</loc><lastmod>not a date</lastmod><priority>0.0</priority></url>
+</urlset>`); err != nil {
+			t.Errorf("Failed to write URL set: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	p := NewParser(30 * time.Second)
+	entries, err := p.ParseSitemap(server.URL, nil)
+	if err != nil {
+		t.Fatalf("ParseSitemap returned error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	if !entries[0].LastMod.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected date-only lastmod to parse, got %v", entries[0].LastMod)
+	}
+	if entries[0].ChangeFreq != "daily" || entries[0].Priority != 0.8 || !entries[0].HasPriority {
+		t.Errorf("Expected changefreq and priority to be kept, got %+v", entries[0])
+	}
+	if entries[1].HasPriority || entries[1].EffectivePriority() != DefaultPriority {
+		t.Errorf("Expected a missing priority to default, got %+v", entries[1])
+	}
+	if !entries[2].HasPriority || entries[2].EffectivePriority() != 0 {
+		t.Errorf("Expected an explicit 0.0 priority to be kept, got %+v", entries[2])
+	}
+	if !entries[1].LastMod.Equal(time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected minute-precision lastmod to parse, got %v", entries[1].LastMod)
+	}
+	if !entries[2].LastMod.IsZero() {
+		t.Errorf("Expected invalid lastmod to be ignored, got %v", entries[2].LastMod)
+	}
+}
+
+func TestParseXMLRejectsEntities(t *testing.T) {
 	t.Parallel()
 
 	p := NewParser(30 * time.Second)
 
 	tests := []struct {
-		name     string
-		urls     []string
-		expected bool
+		name    string
+		xml     string
+		wantErr string
 	}{
 		{
-			name:     "sitemap urls",
-			urls:     []string{"https://example.com/sitemap1.xml", "https://example.com/sitemap2.xml"},
-			expected: true,
+			name: "external entity",
+			xml: `<?xml version="1.0"?>
+<!DOCTYPE urlset [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/&xxe;</loc></url></urlset>`,
+			wantErr: "declares XML entities",
 		},
 		{
-			name:     "mixed urls",
-			urls:     []string{"https://example.com/sitemap.xml", "https://example.com/page1"},
-			expected: true,
+			name: "entity expansion",
+			xml: `<?xml version="1.0"?>
+<!DOCTYPE urlset [<!ENTITY a "aaaaaaaaaa"><!ENTITY b "&a;&a;&a;&a;&a;">]>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/&b;</loc></url></urlset>`,
+			wantErr: "declares XML entities",
 		},
 		{
-			name:     "regular urls",
-			urls:     []string{"https://example.com/page1", "https://example.com/page2"},
-			expected: false,
+			name: "external DTD",
+			xml: `<?xml version="1.0"?>
+<!DOCTYPE urlset SYSTEM "http://attacker.example/evil.dtd">
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc></url></urlset>`,
+			wantErr: "external DTD",
 		},
 		{
-			name:     "empty urls",
-			urls:     []string{},
-			expected: false,
+			name: "plain doctype",
+			xml: `<?xml version="1.0"?>
+<!DOCTYPE urlset>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc></url></urlset>`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := p.isSitemapIndex(tt.urls)
-			if result != tt.expected {
-				t.Errorf("isSitemapIndex(%v) = %v, expected %v", tt.urls, result, tt.expected)
+			t.Parallel()
+			parsed, err := p.parseSitemapContent([]byte(tt.xml))
+			urls := parsed.entries
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if len(urls) != 1 {
+					t.Fatalf("Expected 1 URL, got %v", urls)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
 			}
 		})
 	}
 }
 
-func TestURLStruct(t *testing.T) {
+func TestParseSitemapLimits(t *testing.T) {
 	t.Parallel()
 
-	// Test URL struct marshaling
-	url := URL{
-		Loc:        "https://example.com",
-		LastMod:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
-		ChangeFreq: "daily",
-		Priority:   0.8,
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/index.xml":
+			body = fmt.Sprintf(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s/one.xml</loc></sitemap>
+	<sitemap><loc>%s/two.xml</loc></sitemap>
+</sitemapindex>`, server.URL, server.URL)
+		case "/nested.xml":
+			body = fmt.Sprintf(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s/index.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		case "/one.xml":
+			body = "https://example.com/1\nhttps://example.com/2\nhttps://example.com/3\n"
+		case "/two.xml":
+			body = "https://example.com/4\nhttps://example.com/5\n"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Errorf("Failed to write sitemap: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name     string
+		path     string
+		limits   Limits
+		expected int
+		wantErr  string
+	}{
+		{name: "within limits", path: "/nested.xml", limits: Limits{MaxDepth: 2, MaxURLs: 5}, expected: 5},
+		{name: "zero limits use defaults", path: "/nested.xml", expected: 5},
+		{name: "index nesting exceeded", path: "/nested.xml", limits: Limits{MaxDepth: 1}, wantErr: "maximum depth of 1"},
+		{name: "URL count across sitemaps exceeded", path: "/index.xml", limits: Limits{MaxURLs: 4}, wantErr: "maximum of 4 URLs"},
+		{name: "URL count in one sitemap exceeded", path: "/one.xml", limits: Limits{MaxURLs: 2}, wantErr: "more than the maximum of 2 URLs"},
+		{name: "document size exceeded", path: "/one.xml", limits: Limits{MaxBytes: 10}, wantErr: "exceeds maximum size of 10 bytes"},
 	}
 
-	if url.Loc != "https://example.com" {
-		t.Errorf("Expected Loc %s, got %s", "https://example.com", url.Loc)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := NewParser(30 * time.Second)
+			p.SetLimits(tt.limits)
+
+			urls, err := p.ParseSitemap(server.URL+tt.path, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if len(urls) != tt.expected {
+					t.Fatalf("Expected %d URLs, got %d", tt.expected, len(urls))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
 	}
+}
 
-	if url.ChangeFreq != "daily" {
-		t.Errorf("Expected ChangeFreq %s, got %s", "daily", url.ChangeFreq)
+func TestParseLocalSitemaps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	urlset := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/page1</loc></url>
+</urlset>`
+	if err := os.WriteFile(filepath.Join(dir, "pages.xml"), []byte(urlset), 0o600); err != nil {
+		t.Fatalf("Failed to write sitemap: %v", err)
+	}
+	pagesURL := "file://" + filepath.ToSlash(filepath.Join(dir, "pages.xml"))
+	index := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%s</loc></sitemap>
+</sitemapindex>`, pagesURL)
+	if err := os.WriteFile(filepath.Join(dir, "index.xml"), []byte(index), 0o600); err != nil {
+		t.Fatalf("Failed to write sitemap index: %v", err)
 	}
 
-	if url.Priority != 0.8 {
-		t.Errorf("Expected Priority %f, got %f", 0.8, url.Priority)
+	tests := []struct {
+		name     string
+		location string
+		stdin    string
+		sitemap  string
+		wantErr  bool
+	}{
+		{name: "file", location: pagesURL, sitemap: pagesURL},
+		{name: "localhost file", location: "file://localhost" + filepath.ToSlash(filepath.Join(dir, "pages.xml")), sitemap: "file://localhost" + filepath.ToSlash(filepath.Join(dir, "pages.xml"))},
+		{name: "file index", location: "file://" + filepath.ToSlash(filepath.Join(dir, "index.xml")), sitemap: pagesURL},
+		{name: "stdin", location: StdinSitemap, stdin: urlset, sitemap: StdinSitemap},
+		{name: "stdin index", location: StdinSitemap, stdin: index, sitemap: pagesURL},
+		{name: "missing file", location: "file://" + filepath.ToSlash(filepath.Join(dir, "missing.xml")), wantErr: true},
+		{name: "remote host", location: "file://files.example.com/sitemap.xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := NewParser(30 * time.Second)
+			p.stdin = strings.NewReader(tt.stdin)
+
+			urls, err := p.ParseSitemap(tt.location, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error, got URLs %v", urls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSitemap returned error: %v", err)
+			}
+			if len(urls) != 1 || urls[0].Loc != "https://example.com/page1" {
+				t.Fatalf("Expected the one sitemap URL, got %v", urls)
+			}
+			if urls[0].Sitemap != tt.sitemap {
+				t.Errorf("Expected URL to come from %q, got %q", tt.sitemap, urls[0].Sitemap)
+			}
+		})
+	}
+}
+
+func TestFetchedIndexCannotListLocalSitemaps(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>file:///etc/passwd</loc></sitemap>
+</sitemapindex>`); err != nil {
+			t.Errorf("Failed to write sitemap index: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	p := NewParser(30 * time.Second)
+	if _, err := p.ParseSitemap(server.URL+"/sitemap.xml", nil); err == nil {
+		t.Error("Expected ParseSitemap to reject a local child sitemap")
+	}
+	if _, err := p.ParseSitemapDocuments(server.URL+"/sitemap.xml", nil); err == nil {
+		t.Error("Expected ParseSitemapDocuments to reject a local child sitemap")
 	}
 }
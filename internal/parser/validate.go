@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits the sitemaps protocol sets on each sitemap file
+const (
+	MaxFileURLs  = 50000
+	MaxFileBytes = 50 * 1024 * 1024
+	MaxLocLength = 2048
+)
+
+// SitemapXMLNS is the namespace of <urlset> and <sitemapindex> documents
+const SitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Formats of validated sitemap files
+const (
+	FormatURLSet = "urlset"
+	FormatIndex  = "sitemapindex"
+	FormatText   = "text"
+	FormatFeed   = "feed"
+)
+
+// maxViolations bounds the violations listed for one file
+const maxViolations = 100
+
+// Rules a sitemap file can break
+const (
+	RuleMalformed  = "malformed"
+	RuleRoot       = "unknown_root"
+	RuleNamespace  = "namespace"
+	RuleMaxURLs    = "max_urls"
+	RuleMaxBytes   = "max_bytes"
+	RuleEntry      = "unexpected_element"
+	RuleLoc        = "invalid_loc"
+	RuleLastMod    = "invalid_lastmod"
+	RuleChangeFreq = "invalid_changefreq"
+	RulePriority   = "invalid_priority"
+)
+
+// w3cDatetimeLayouts are the W3C Datetime forms the protocol allows for
+// <lastmod>. Unlike lastModLayouts, a time must carry a time zone.
+var w3cDatetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+var changeFreqs = map[string]bool{
+	"always": true, "hourly": true, "daily": true, "weekly": true,
+	"monthly": true, "yearly": true, "never": true,
+}
+
+// Violation is one way a sitemap file breaks the sitemaps protocol
+type Violation struct {
+	Rule string `json:"rule"`
+
+	// Entry is the 1-based position of the <url> or <sitemap> entry, or
+	// line of a text sitemap; zero for the file as a whole
+	Entry  int    `json:"entry,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// FileReport is the result of validating one sitemap file
+type FileReport struct {
+	URL string `json:"url"`
+
+	// Format is urlset, sitemapindex, text or feed; empty when the file
+	// could not be read
+	Format  string `json:"format,omitempty"`
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+
+	// Error is set when the file could not be fetched or read
+	Error string `json:"error,omitempty"`
+
+	// Violations lists the first violations found; Omitted counts those
+	// beyond them
+	Violations []Violation `json:"violations"`
+	Omitted    int         `json:"omitted_violations,omitempty"`
+}
+
+// Valid reports whether the file was read and breaks no rule
+func (r FileReport) Valid() bool {
+	return r.Error == "" && len(r.Violations) == 0
+}
+
+// add records a violation, counting it once the report holds the most
+// listed
+func (r *FileReport) add(rule string, entry int, format string, args ...interface{}) {
+	if len(r.Violations) >= maxViolations {
+		r.Omitted++
+		return
+	}
+	r.Violations = append(r.Violations, Violation{Rule: rule, Entry: entry, Detail: fmt.Sprintf(format, args...)})
+}
+
+// rawEntry is a <url> or <sitemap> entry before its values are checked
+type rawEntry struct {
+	Loc        *string `xml:"loc"`
+	LastMod    *string `xml:"lastmod"`
+	ChangeFreq *string `xml:"changefreq"`
+	Priority   *string `xml:"priority"`
+}
+
+// ValidateSitemap checks a sitemap, and every sitemap an index leads to,
+// against the sitemaps.org protocol without requesting any page: the number
+// of URLs and uncompressed size of each file, that each <loc> is an
+// absolute http or https URL, that <lastmod> is a W3C Datetime, and the
+// namespace of the root element. It returns one report per file in the
+// order they were reached. A child sitemap that cannot be fetched is
+// reported with an error; only the top-level sitemap failing is an error.
+func (p *Parser) ValidateSitemap(sitemapURL string, headers map[string]string) ([]FileReport, error) {
+	var reports []FileReport
+	if err := p.validateRecursive(sitemapURL, headers, 0, make(map[string]bool), &reports); err != nil {
+		return nil, fmt.Errorf("failed to validate sitemap %s: %w", sitemapURL, err)
+	}
+	return reports, nil
+}
+
+func (p *Parser) validateRecursive(sitemapURL string, headers map[string]string, depth int, seenSitemaps map[string]bool, reports *[]FileReport) error {
+	if depth > p.limits.MaxDepth {
+		return fmt.Errorf("sitemap indexes nested deeper than the maximum depth of %d", p.limits.MaxDepth)
+	}
+	key := sitemapKey(sitemapURL)
+	if seenSitemaps[key] {
+		return nil
+	}
+	seenSitemaps[key] = true
+
+	report := FileReport{URL: sitemapURL, Violations: make([]Violation, 0)}
+	body, err := p.read(sitemapURL, headers, MaxFileBytes)
+	switch {
+	case errors.Is(err, errTooLarge):
+		report.Bytes = MaxFileBytes + 1
+		report.add(RuleMaxBytes, 0, "larger than %d bytes uncompressed", MaxFileBytes)
+		*reports = append(*reports, report)
+		return nil
+	case err != nil && depth == 0:
+		return err
+	case err != nil:
+		report.Error = err.Error()
+		*reports = append(*reports, report)
+		return nil
+	}
+
+	report.Bytes = len(body)
+	children := report.check(body)
+	*reports = append(*reports, report)
+
+	for _, child := range children {
+		if err := checkChild(sitemapURL, child); err != nil {
+			*reports = append(*reports, FileReport{URL: child, Error: err.Error(), Violations: make([]Violation, 0)})
+			continue
+		}
+		if err := p.validateRecursive(child, headers, depth+1, seenSitemaps, reports); err != nil {
+			return fmt.Errorf("failed to validate child sitemap %s: %w", child, err)
+		}
+	}
+	return nil
+}
+
+// check validates a sitemap file's content and returns the child sitemaps
+// it lists if it is an index
+func (r *FileReport) check(body []byte) []string {
+	if err := checkDoctype(body); err != nil {
+		r.add(RuleMalformed, 0, "%v", err)
+		return nil
+	}
+
+	if root := feedRoot(body); root != "" {
+		r.Format = FormatFeed
+		entries, err := parseFeed(body, root)
+		if err != nil {
+			r.add(RuleMalformed, 0, "%v", err)
+			return nil
+		}
+		r.Entries = len(entries)
+		for i, entry := range entries {
+			r.checkLoc(i+1, &entry.Loc)
+		}
+		r.checkCount(len(entries))
+		return nil
+	}
+
+	root, ok := xmlRoot(body)
+	if !ok {
+		r.checkText(body)
+		return nil
+	}
+	return r.checkXML(body, root)
+}
+
+// xmlRoot returns the root element of an XML document, and false if the
+// content is not XML
+func xmlRoot(body []byte) (xml.StartElement, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, true
+		}
+	}
+}
+
+// checkXML validates a urlset or sitemapindex document entry by entry
+// without holding its entries in memory
+func (r *FileReport) checkXML(body []byte, root xml.StartElement) []string {
+	var entryName string
+	switch root.Name.Local {
+	case FormatURLSet:
+		entryName = "url"
+	case FormatIndex:
+		entryName = "sitemap"
+	default:
+		r.add(RuleRoot, 0, "root element <%s> is neither <urlset> nor <sitemapindex>", root.Name.Local)
+		return nil
+	}
+	r.Format = root.Name.Local
+	if root.Name.Space != SitemapXMLNS {
+		r.add(RuleNamespace, 0, "<%s> has namespace %q, expected %q", root.Name.Local, root.Name.Space, SitemapXMLNS)
+	}
+
+	var children []string
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.add(RuleMalformed, 0, "%v", err)
+			}
+			break
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 2 {
+				continue
+			}
+			if tok.Name.Local != entryName {
+				r.add(RuleEntry, 0, "<%s> contains <%s>, expected <%s>", root.Name.Local, tok.Name.Local, entryName)
+				continue
+			}
+			var entry rawEntry
+			if err := decoder.DecodeElement(&entry, &tok); err != nil {
+				r.add(RuleMalformed, r.Entries+1, "%v", err)
+				return children
+			}
+			depth--
+			r.Entries++
+			r.checkEntry(r.Entries, entry)
+			if r.Format == FormatIndex && entry.Loc != nil {
+				children = append(children, strings.TrimSpace(*entry.Loc))
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	r.checkCount(r.Entries)
+	return children
+}
+
+// checkEntry checks the values of one <url> or <sitemap> entry
+func (r *FileReport) checkEntry(position int, entry rawEntry) {
+	r.checkLoc(position, entry.Loc)
+
+	if entry.LastMod != nil && !isW3CDatetime(strings.TrimSpace(*entry.LastMod)) {
+		r.add(RuleLastMod, position, "lastmod %q is not a W3C Datetime", strings.TrimSpace(*entry.LastMod))
+	}
+	if entry.ChangeFreq != nil && !changeFreqs[strings.TrimSpace(*entry.ChangeFreq)] {
+		r.add(RuleChangeFreq, position, "changefreq %q is not always, hourly, daily, weekly, monthly, yearly or never", strings.TrimSpace(*entry.ChangeFreq))
+	}
+	if entry.Priority != nil {
+		value := strings.TrimSpace(*entry.Priority)
+		priority, err := strconv.ParseFloat(value, 64)
+		if err != nil || priority < 0 || priority > 1 {
+			r.add(RulePriority, position, "priority %q is not between 0.0 and 1.0", value)
+		}
+	}
+}
+
+// checkLoc checks that a location is an absolute http or https URL within
+// the protocol's length limit
+func (r *FileReport) checkLoc(position int, loc *string) {
+	if loc == nil || strings.TrimSpace(*loc) == "" {
+		r.add(RuleLoc, position, "missing <loc>")
+		return
+	}
+	value := strings.TrimSpace(*loc)
+	if len(value) > MaxLocLength {
+		r.add(RuleLoc, position, "loc is %d characters, longer than %d", len(value), MaxLocLength)
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		r.add(RuleLoc, position, "loc %q: %v", value, err)
+		return
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		r.add(RuleLoc, position, "loc %q is not an absolute http or https URL", value)
+	}
+}
+
+// checkText validates a text sitemap, one URL per line
+func (r *FileReport) checkText(body []byte) {
+	r.Format = FormatText
+	for i, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		r.Entries++
+		r.checkLoc(i+1, &line)
+	}
+	r.checkCount(r.Entries)
+}
+
+// checkCount checks the number of entries against the protocol's limit
+func (r *FileReport) checkCount(entries int) {
+	if entries > MaxFileURLs {
+		r.add(RuleMaxURLs, 0, "lists %d entries, more than %d", entries, MaxFileURLs)
+	}
+}
+
+// isW3CDatetime reports whether a value is in one of the W3C Datetime forms
+func isW3CDatetime(value string) bool {
+	for _, layout := range w3cDatetimeLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rules returns the rules of a report's violations in order
+func rules(report FileReport) []string {
+	found := make([]string, len(report.Violations))
+	for i, violation := range report.Violations {
+		found[i] = violation.Rule
+	}
+	return found
+}
+
+func TestFileReportCheck(t *testing.T) {
+	t.Parallel()
+
+	manyURLs := strings.Repeat("https://example.com/page\n", MaxFileURLs+1)
+
+	tests := []struct {
+		name     string
+		body     string
+		format   string
+		entries  int
+		expected []string
+	}{
+		{
+			name: "valid urlset",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/</loc><lastmod>2024-01-02T15:04:05+00:00</lastmod><changefreq>daily</changefreq><priority>0.8</priority></url>
+	<url><loc>https://example.com/about</loc><lastmod>2024-01</lastmod></url>
+</urlset>`,
+			format:  FormatURLSet,
+			entries: 2,
+		},
+		{
+			name: "invalid values",
+			body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>/relative</loc></url>
+	<url><lastmod>2024-01-02</lastmod></url>
+	<url><loc>https://example.com/a</loc><lastmod>2024-01-02T15:04:05</lastmod></url>
+	<url><loc>https://example.com/b</loc><lastmod>01/02/2024</lastmod><changefreq>sometimes</changefreq><priority>1.5</priority></url>
+</urlset>`,
+			format:   FormatURLSet,
+			entries:  4,
+			expected: []string{RuleLoc, RuleLoc, RuleLastMod, RuleLastMod, RuleChangeFreq, RulePriority},
+		},
+		{
+			name:     "missing namespace",
+			body:     `<urlset><url><loc>https://example.com/</loc></url></urlset>`,
+			format:   FormatURLSet,
+			entries:  1,
+			expected: []string{RuleNamespace},
+		},
+		{
+			name:     "old namespace and wrong entries",
+			body:     `<sitemapindex xmlns="http://www.google.com/schemas/sitemap/0.84"><url><loc>https://example.com/</loc></url></sitemapindex>`,
+			format:   FormatIndex,
+			expected: []string{RuleNamespace, RuleEntry},
+		},
+		{
+			name:     "unknown root",
+			body:     `<html><body>Not a sitemap</body></html>`,
+			expected: []string{RuleRoot},
+		},
+		{
+			name:     "malformed",
+			body:     `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc></url>`,
+			format:   FormatURLSet,
+			entries:  1,
+			expected: []string{RuleMalformed},
+		},
+		{
+			name:     "text",
+			body:     "https://example.com/\n\nftp://example.com/file\n",
+			format:   FormatText,
+			entries:  2,
+			expected: []string{RuleLoc},
+		},
+		{
+			name:     "too many URLs",
+			body:     manyURLs,
+			format:   FormatText,
+			entries:  MaxFileURLs + 1,
+			expected: []string{RuleMaxURLs},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			report := FileReport{}
+			report.check([]byte(tt.body))
+			if report.Format != tt.format {
+				t.Errorf("Expected format %q, got %q", tt.format, report.Format)
+			}
+			if report.Entries != tt.entries {
+				t.Errorf("Expected %d entries, got %d", tt.entries, report.Entries)
+			}
+			if got := rules(report); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected violations %v, got %v", tt.expected, report.Violations)
+			}
+		})
+	}
+}
+
+func TestFileReportCapsViolations(t *testing.T) {
+	t.Parallel()
+
+	report := FileReport{}
+	report.check([]byte(strings.Repeat("not-a-url\n", maxViolations+5)))
+	if len(report.Violations) != maxViolations {
+		t.Errorf("Expected %d violations listed, got %d", maxViolations, len(report.Violations))
+	}
+	if report.Omitted != 5 {
+		t.Errorf("Expected 5 omitted violations, got %d", report.Omitted)
+	}
+}
+
+func TestValidateSitemap(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>%[1]s/pages.xml</loc></sitemap>
+	<sitemap><loc>%[1]s/posts.xml</loc></sitemap>
+	<sitemap><loc>%[1]s/missing.xml</loc></sitemap>
+	<sitemap><loc>%[1]s/sitemap.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		case "/pages.xml":
+			_, _ = fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc></url></urlset>`)
+		case "/posts.xml":
+			_, _ = fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/post</loc><lastmod>yesterday</lastmod></url></urlset>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewParser(30 * time.Second)
+	reports, err := p.ValidateSitemap(server.URL+"/sitemap.xml", nil)
+	if err != nil {
+		t.Fatalf("ValidateSitemap returned error: %v", err)
+	}
+	if len(reports) != 4 {
+		t.Fatalf("Expected 4 reports, one per distinct sitemap, got %d: %v", len(reports), reports)
+	}
+
+	expected := []struct {
+		path  string
+		valid bool
+		rules string
+	}{
+		{path: "/sitemap.xml", valid: true},
+		{path: "/pages.xml", valid: true},
+		{path: "/posts.xml", rules: RuleLastMod},
+		{path: "/missing.xml"},
+	}
+	for i, want := range expected {
+		report := reports[i]
+		if report.URL != server.URL+want.path {
+			t.Errorf("Expected report %d for %s, got %s", i, want.path, report.URL)
+		}
+		if report.Valid() != want.valid {
+			t.Errorf("Expected %s valid=%v, got %+v", want.path, want.valid, report)
+		}
+		if got := strings.Join(rules(report), ","); got != want.rules {
+			t.Errorf("Expected %s violations %q, got %q", want.path, want.rules, got)
+		}
+	}
+	if reports[3].Error == "" {
+		t.Error("Expected the missing child sitemap to be reported with an error")
+	}
+
+	if _, err := p.ValidateSitemap(server.URL+"/missing.xml", nil); err == nil {
+		t.Error("Expected an error when the top-level sitemap cannot be fetched")
+	}
+}
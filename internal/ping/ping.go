@@ -0,0 +1,118 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SitemapPlaceholder is replaced by the escaped sitemap URL in ping URLs
+const SitemapPlaceholder = "{sitemap}"
+
+// DefaultIndexNowEndpoint shares submissions with every search engine that
+// takes part in IndexNow
+const DefaultIndexNowEndpoint = "https://api.indexnow.org/indexnow"
+
+// IndexNowBatchSize is how many URLs one IndexNow submission accepts
+const IndexNowBatchSize = 10000
+
+// Sitemap requests a ping URL, with SitemapPlaceholder replaced by the
+// sitemap URL. A 2xx response counts as accepted.
+func Sitemap(ctx context.Context, client *http.Client, pingURL, sitemapURL string) error {
+	target := strings.ReplaceAll(pingURL, SitemapPlaceholder, url.QueryEscape(sitemapURL))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	return send(client, req)
+}
+
+// IndexNow submits URLs to an IndexNow endpoint
+type IndexNow struct {
+	Endpoint string
+	Key      string
+
+	// KeyLocation is the URL of the key file; empty means /<key>.txt on
+	// each host
+	KeyLocation string
+}
+
+// indexNowRequest is the JSON body of an IndexNow submission
+type indexNowRequest struct {
+	Host        string   `json:"host"`
+	Key         string   `json:"key"`
+	KeyLocation string   `json:"keyLocation,omitempty"`
+	URLList     []string `json:"urlList"`
+}
+
+// Submit sends urls to the endpoint. IndexNow takes one host per
+// submission, so URLs are grouped by host and then batched.
+func (i IndexNow) Submit(ctx context.Context, client *http.Client, urls []string) error {
+	var hosts []string
+	byHost := make(map[string][]string)
+	for _, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid URL %s: %w", raw, err)
+		}
+		if _, ok := byHost[parsed.Host]; !ok {
+			hosts = append(hosts, parsed.Host)
+		}
+		byHost[parsed.Host] = append(byHost[parsed.Host], raw)
+	}
+
+	for _, host := range hosts {
+		hostURLs := byHost[host]
+		for start := 0; start < len(hostURLs); start += IndexNowBatchSize {
+			batch := hostURLs[start:min(start+IndexNowBatchSize, len(hostURLs))]
+			if err := i.submitBatch(ctx, client, host, batch); err != nil {
+				return fmt.Errorf("IndexNow submission for %s failed: %w", host, err)
+			}
+		}
+	}
+	return nil
+}
+
+// submitBatch makes one IndexNow submission
+func (i IndexNow) submitBatch(ctx context.Context, client *http.Client, host string, urls []string) error {
+	payload, err := json.Marshal(indexNowRequest{
+		Host:        host,
+		Key:         i.Key,
+		KeyLocation: i.KeyLocation,
+		URLList:     urls,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return send(client, req)
+}
+
+// send makes a request and treats any non-2xx response as an error
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return nil
+}
@@ -0,0 +1,96 @@
+package ping
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		status    int
+		wantError bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "rejected", status: http.StatusGone, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query().Get("sitemap")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := Sitemap(context.Background(), server.Client(), server.URL+"/ping?sitemap={sitemap}", "https://www.example.com/sitemap.xml?v=2")
+			if tt.wantError {
+				assert.ErrorContains(t, err, "returned status 410")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "https://www.example.com/sitemap.xml?v=2", got)
+		})
+	}
+}
+
+func TestIndexNowSubmit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		submissions []indexNowRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var submission indexNowRequest
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&submission) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		submissions = append(submissions, submission)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	indexNow := IndexNow{Endpoint: server.URL, Key: "5f1c2b3a4d5e6f70"}
+	err := indexNow.Submit(context.Background(), server.Client(), []string{
+		"https://www.example.com/a",
+		"https://blog.example.com/b",
+		"https://www.example.com/c",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []indexNowRequest{
+		{Host: "www.example.com", Key: "5f1c2b3a4d5e6f70", URLList: []string{"https://www.example.com/a", "https://www.example.com/c"}},
+		{Host: "blog.example.com", Key: "5f1c2b3a4d5e6f70", URLList: []string{"https://blog.example.com/b"}},
+	}, submissions)
+}
+
+func TestIndexNowSubmitRejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("key not valid"))
+	}))
+	defer server.Close()
+
+	indexNow := IndexNow{Endpoint: server.URL, Key: "5f1c2b3a4d5e6f70"}
+	err := indexNow.Submit(context.Background(), server.Client(), []string{"https://www.example.com/a"})
+	assert.ErrorContains(t, err, "IndexNow submission for www.example.com failed")
+	assert.ErrorContains(t, err, "key not valid")
+}
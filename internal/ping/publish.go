@@ -0,0 +1,80 @@
+package ping
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PublishedPollInterval is how often VerifyPublished fetches the published
+// sitemap again while it does not match
+const PublishedPollInterval = 5 * time.Second
+
+// maxPublishedBytes bounds the published sitemap read, matching the sitemap
+// protocol's size limit
+const maxPublishedBytes = 50 * 1024 * 1024
+
+// ErrNotPublished is returned when the published sitemap never matched the
+// generated one
+var ErrNotPublished = errors.New("published sitemap does not match the generated sitemap")
+
+// VerifyPublished fetches publishedURL and compares the SHA-256 hash of its
+// body with that of content, fetching it again every interval until they
+// match or timeout has passed. A zero timeout checks once.
+func VerifyPublished(ctx context.Context, client *http.Client, publishedURL string, content []byte, timeout, interval time.Duration) error {
+	want := sha256.Sum256(content)
+	deadline := time.Now().Add(timeout)
+	for {
+		got, err := fetchDigest(ctx, client, publishedURL)
+		if err == nil && got == want {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%w: %s has sha256 %s, expected %s", ErrNotPublished, publishedURL, hex.EncodeToString(got[:]), hex.EncodeToString(want[:]))
+		}
+
+		wait := min(interval, time.Until(deadline))
+		if wait <= 0 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fetchDigest returns the SHA-256 hash of a URL's body
+func fetchDigest(ctx context.Context, client *http.Client, target string) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return digest, err
+	}
+	// The sitemap may be cached at the edge by the crawl that just ran
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return digest, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return digest, fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(resp.Body, maxPublishedBytes)); err != nil {
+		return digest, err
+	}
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
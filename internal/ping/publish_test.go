@@ -0,0 +1,58 @@
+package ping
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPublished(t *testing.T) {
+	t.Parallel()
+
+	const generated = `<?xml version="1.0" encoding="UTF-8"?><urlset></urlset>`
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		deployed  int64
+		status    int
+		wantError string
+	}{
+		{name: "published", status: http.StatusOK},
+		{name: "stale", status: http.StatusOK, deployed: 100, wantError: "does not match"},
+		{name: "published while waiting", status: http.StatusOK, deployed: 2, timeout: time.Second},
+		{name: "missing", status: http.StatusNotFound, wantError: "returned status 404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetches atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "no-cache", r.Header.Get("Cache-Control"))
+				w.WriteHeader(tt.status)
+				if fetches.Add(1) > tt.deployed {
+					_, _ = w.Write([]byte(generated))
+				} else {
+					_, _ = w.Write([]byte("<urlset>old</urlset>"))
+				}
+			}))
+			defer server.Close()
+
+			err := VerifyPublished(context.Background(), server.Client(), server.URL+"/sitemap.xml", []byte(generated), tt.timeout, 10*time.Millisecond)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				assert.Equal(t, int64(1), fetches.Load())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.deployed+1, fetches.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package purge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ModeCloudflare purges through the Cloudflare API
+const ModeCloudflare = "cloudflare"
+
+// CloudflareAPIURL is the base URL of the Cloudflare v4 API
+const CloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// CloudflareBatchSize is how many URLs or tags one purge_cache call accepts
+// on every plan
+const CloudflareBatchSize = 30
+
+// CloudflareOptions configures a CloudflarePurger
+type CloudflareOptions struct {
+	// APIURL defaults to CloudflareAPIURL
+	APIURL string
+	ZoneID string
+	Token  string
+
+	// ByTag purges cache tags instead of URLs
+	ByTag bool
+}
+
+// CloudflarePurger purges URLs or cache tags of a zone through the
+// Cloudflare API
+type CloudflarePurger struct {
+	client  *http.Client
+	options CloudflareOptions
+}
+
+// cloudflareResponse is the envelope of every Cloudflare API response
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewCloudflarePurger creates a purger that calls the API through client
+func NewCloudflarePurger(client *http.Client, options CloudflareOptions) *CloudflarePurger {
+	if options.APIURL == "" {
+		options.APIURL = CloudflareAPIURL
+	}
+	return &CloudflarePurger{client: client, options: options}
+}
+
+// Purge purges targets, URLs or cache tags, in batches. A failed batch
+// fails every target in it.
+func (p *CloudflarePurger) Purge(ctx context.Context, targets []string) Report {
+	var report Report
+	for start := 0; start < len(targets); start += CloudflareBatchSize {
+		batch := targets[start:min(start+CloudflareBatchSize, len(targets))]
+		err := p.purgeBatch(ctx, batch)
+		for _, target := range batch {
+			report.add(target, err)
+		}
+	}
+	return report
+}
+
+// purgeBatch makes one purge_cache call
+func (p *CloudflarePurger) purgeBatch(ctx context.Context, batch []string) error {
+	key := "files"
+	if p.options.ByTag {
+		key = "tags"
+	}
+	payload, err := json.Marshal(map[string][]string{key: batch})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/zones/%s/purge_cache", strings.TrimSuffix(p.options.APIURL, "/"), p.options.ZoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.options.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var result cloudflareResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDrainBytes)).Decode(&result); err != nil {
+		return fmt.Errorf("cloudflare API returned status %d: %w", resp.StatusCode, err)
+	}
+	if !result.Success {
+		messages := make([]string, 0, len(result.Errors))
+		for _, apiErr := range result.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", apiErr.Code, apiErr.Message))
+		}
+		return fmt.Errorf("cloudflare API returned status %d: %s", resp.StatusCode, strings.Join(messages, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package purge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloudflare records purge_cache calls and fails batches containing a
+// target named "bad"
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	batches []map[string][]string
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/zones/zone123/purge_cache" || r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"success":false,"errors":[{"code":10000,"message":"Authentication error"}]}`)
+		return
+	}
+
+	var body map[string][]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.batches = append(f.batches, body)
+	f.mu.Unlock()
+
+	for _, targets := range body {
+		for _, target := range targets {
+			if target == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"success":false,"errors":[{"code":1012,"message":"Request must contain one of purge_everything, files, tags"}]}`)
+				return
+			}
+		}
+	}
+	_, _ = fmt.Fprint(w, `{"success":true,"errors":[],"result":{"id":"zone123"}}`)
+}
+
+func TestCloudflarePurgerBatchesURLs(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeCloudflare{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	urls := make([]string, CloudflareBatchSize+5)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/pages/%d", i)
+	}
+
+	purger := NewCloudflarePurger(server.Client(), CloudflareOptions{APIURL: server.URL, ZoneID: "zone123", Token: "token"})
+	report := purger.Purge(context.Background(), urls)
+
+	assert.Equal(t, len(urls), report.Purged)
+	assert.Empty(t, report.Failures)
+	require.Len(t, api.batches, 2)
+	assert.Len(t, api.batches[0]["files"], CloudflareBatchSize)
+	assert.Len(t, api.batches[1]["files"], 5)
+}
+
+func TestCloudflarePurgerTags(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeCloudflare{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	purger := NewCloudflarePurger(server.Client(), CloudflareOptions{APIURL: server.URL, ZoneID: "zone123", Token: "token", ByTag: true})
+	report := purger.Purge(context.Background(), []string{"product-7", "bad"})
+
+	assert.Zero(t, report.Purged)
+	require.Len(t, report.Failures, 2)
+	assert.ErrorContains(t, report.Failures[0].Err, "1012 Request must contain")
+	require.Len(t, api.batches, 1)
+	assert.Equal(t, []string{"product-7", "bad"}, api.batches[0]["tags"])
+}
+
+func TestCloudflarePurgerAuthError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeCloudflare{})
+	defer server.Close()
+
+	purger := NewCloudflarePurger(server.Client(), CloudflareOptions{APIURL: server.URL, ZoneID: "zone123", Token: "wrong"})
+	report := purger.Purge(context.Background(), []string{"https://example.com/"})
+
+	require.Len(t, report.Failures, 1)
+	assert.EqualError(t, report.Failures[0].Err, "cloudflare API returned status 403: 10000 Authentication error")
+}
@@ -0,0 +1,120 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ModeFastly purges through the Fastly API
+const ModeFastly = "fastly"
+
+// FastlyAPIURL is the base URL of the Fastly API
+const FastlyAPIURL = "https://api.fastly.com"
+
+// FastlyBatchSize is how many surrogate keys one bulk purge accepts
+const FastlyBatchSize = 256
+
+// FastlyOptions configures a FastlyPurger
+type FastlyOptions struct {
+	// APIURL defaults to FastlyAPIURL
+	APIURL    string
+	ServiceID string
+	Token     string
+
+	// Soft marks content stale instead of evicting it, so the origin is
+	// not flooded if warming falls behind
+	Soft bool
+
+	// ByKey purges surrogate keys of the service instead of URLs
+	ByKey bool
+
+	// Workers purge URLs in parallel
+	Workers int
+}
+
+// FastlyPurger purges URLs or surrogate keys through the Fastly API
+type FastlyPurger struct {
+	client  *http.Client
+	options FastlyOptions
+}
+
+// NewFastlyPurger creates a purger that calls the API through client
+func NewFastlyPurger(client *http.Client, options FastlyOptions) *FastlyPurger {
+	if options.APIURL == "" {
+		options.APIURL = FastlyAPIURL
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	return &FastlyPurger{client: client, options: options}
+}
+
+// Purge purges targets, URLs one at a time or surrogate keys in batches
+func (p *FastlyPurger) Purge(ctx context.Context, targets []string) Report {
+	if !p.options.ByKey {
+		return purgeEach(ctx, targets, p.options.Workers, p.purgeURL)
+	}
+
+	var report Report
+	for start := 0; start < len(targets); start += FastlyBatchSize {
+		batch := targets[start:min(start+FastlyBatchSize, len(targets))]
+		err := p.purgeKeys(ctx, batch)
+		for _, key := range batch {
+			report.add(key, err)
+		}
+	}
+	return report
+}
+
+// purgeURL purges one URL, addressed by host and path without the scheme
+func (p *FastlyPurger) purgeURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	cached := parsed.Host + parsed.RequestURI()
+
+	return p.call(ctx, "/purge/"+cached, nil)
+}
+
+// purgeKeys purges a batch of surrogate keys of the service
+func (p *FastlyPurger) purgeKeys(ctx context.Context, keys []string) error {
+	return p.call(ctx, "/service/"+url.PathEscape(p.options.ServiceID)+"/purge", map[string]string{
+		"Surrogate-Key": strings.Join(keys, " "),
+	})
+}
+
+// call makes one purge API request
+func (p *FastlyPurger) call(ctx context.Context, path string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.options.APIURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", p.options.Token)
+	req.Header.Set("Accept", "application/json")
+	if p.options.Soft {
+		req.Header.Set("Fastly-Soft-Purge", "1")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("fastly API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	return nil
+}
@@ -0,0 +1,97 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFastly records purge calls as "path [surrogate keys] soft"
+type fakeFastly struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeFastly) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Fastly-Key") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprint(w, `{"msg":"Provided credentials are missing or invalid"}`)
+		return
+	}
+
+	call := r.URL.Path
+	if keys := r.Header.Get("Surrogate-Key"); keys != "" {
+		call += " [" + keys + "]"
+	}
+	if r.Header.Get("Fastly-Soft-Purge") == "1" {
+		call += " soft"
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	_, _ = fmt.Fprint(w, `{"status":"ok","id":"1234"}`)
+}
+
+func TestFastlyPurgerURLs(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeFastly{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	purger := NewFastlyPurger(server.Client(), FastlyOptions{APIURL: server.URL, Token: "token", Soft: true, Workers: 2})
+	report := purger.Purge(context.Background(), []string{
+		"https://www.example.com/products/7?color=red",
+		"https://www.example.com/",
+	})
+
+	assert.Equal(t, 2, report.Purged)
+	sort.Strings(api.calls)
+	assert.Equal(t, []string{
+		"/purge/www.example.com/ soft",
+		"/purge/www.example.com/products/7 soft",
+	}, api.calls)
+}
+
+func TestFastlyPurgerKeys(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeFastly{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	keys := make([]string, FastlyBatchSize+1)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	purger := NewFastlyPurger(server.Client(), FastlyOptions{APIURL: server.URL, ServiceID: "svc1", Token: "token", ByKey: true})
+	report := purger.Purge(context.Background(), keys)
+
+	assert.Equal(t, len(keys), report.Purged)
+	require.Len(t, api.calls, 2)
+	assert.True(t, strings.HasPrefix(api.calls[0], "/service/svc1/purge [key-0 key-1 "))
+	assert.Equal(t, fmt.Sprintf("/service/svc1/purge [key-%d]", FastlyBatchSize), api.calls[1])
+}
+
+func TestFastlyPurgerAuthError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeFastly{})
+	defer server.Close()
+
+	purger := NewFastlyPurger(server.Client(), FastlyOptions{APIURL: server.URL, Token: "wrong"})
+	report := purger.Purge(context.Background(), []string{"https://www.example.com/"})
+
+	require.Len(t, report.Failures, 1)
+	assert.EqualError(t, report.Failures[0].Err, `fastly API returned status 401: {"msg":"Provided credentials are missing or invalid"}`)
+}
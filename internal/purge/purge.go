@@ -0,0 +1,155 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Purge modes
+const (
+	// ModeRequest sends a purge request (PURGE, BAN) to each URL
+	ModeRequest = "request"
+)
+
+// DefaultMethod is the HTTP method used to purge URLs
+const DefaultMethod = "PURGE"
+
+// maxDrainBytes bounds how much of a purge response is read so the
+// connection can be reused
+const maxDrainBytes = 64 * 1024
+
+// Purger invalidates cached copies of URLs before they are warmed
+type Purger interface {
+	// Purge invalidates targets, URLs or cache tags depending on the
+	// purger, and reports which of them failed
+	Purge(ctx context.Context, targets []string) Report
+}
+
+// Failure is a URL or cache tag that could not be purged
+type Failure struct {
+	Target string
+	Err    error
+}
+
+// Report is the outcome of a purge
+type Report struct {
+	Purged   int
+	Failures []Failure
+}
+
+// add records the outcome of purging one target
+func (r *Report) add(target string, err error) {
+	if err != nil {
+		r.Failures = append(r.Failures, Failure{Target: target, Err: err})
+		return
+	}
+	r.Purged++
+}
+
+// RequestOptions configures a RequestPurger
+type RequestOptions struct {
+	Method    string
+	Headers   map[string]string
+	UserAgent string
+
+	// Workers purge in parallel; Limiter paces them when set
+	Workers int
+	Limiter *rate.Limiter
+}
+
+// RequestPurger purges each URL by sending it a request with a purge
+// method, as Varnish, Fastly and most caching proxies accept
+type RequestPurger struct {
+	client  *http.Client
+	options RequestOptions
+}
+
+// NewRequestPurger creates a purger that sends requests through client
+func NewRequestPurger(client *http.Client, options RequestOptions) *RequestPurger {
+	if options.Method == "" {
+		options.Method = DefaultMethod
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	return &RequestPurger{client: client, options: options}
+}
+
+// Purge sends a purge request to every URL. A 2xx response counts as purged.
+func (p *RequestPurger) Purge(ctx context.Context, urls []string) Report {
+	return purgeEach(ctx, urls, p.options.Workers, p.purgeURL)
+}
+
+// purgeEach purges targets one at a time across workers
+func purgeEach(ctx context.Context, targets []string, workers int, purgeOne func(context.Context, string) error) Report {
+	var (
+		report Report
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+
+	targetChan := make(chan string)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range targetChan {
+				err := purgeOne(ctx, target)
+				mu.Lock()
+				report.add(target, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			mu.Lock()
+			report.add(target, ctx.Err())
+			mu.Unlock()
+			continue
+		}
+		targetChan <- target
+	}
+	close(targetChan)
+	wg.Wait()
+
+	return report
+}
+
+// purgeURL sends one purge request
+func (p *RequestPurger) purgeURL(ctx context.Context, url string) error {
+	if p.options.Limiter != nil {
+		if err := p.options.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.options.Method, url, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range p.options.Headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", p.options.UserAgent)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", p.options.Method, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package purge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestPurger(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		purged []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "BAN" || r.Header.Get("X-Purge-Key") != "secret" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path == "/locked" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		purged = append(purged, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	purger := NewRequestPurger(server.Client(), RequestOptions{
+		Method:  "BAN",
+		Headers: map[string]string{"X-Purge-Key": "secret"},
+		Workers: 3,
+	})
+	report := purger.Purge(context.Background(), []string{
+		server.URL + "/a",
+		server.URL + "/b",
+		server.URL + "/locked",
+		server.URL + "/c",
+	})
+
+	assert.Equal(t, 3, report.Purged)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, server.URL+"/locked", report.Failures[0].Target)
+	assert.ErrorContains(t, report.Failures[0].Err, "BAN returned status 403")
+
+	sort.Strings(purged)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, purged)
+}
+
+func TestRequestPurgerDefaults(t *testing.T) {
+	t.Parallel()
+
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+	}))
+	defer server.Close()
+
+	report := NewRequestPurger(server.Client(), RequestOptions{}).Purge(context.Background(), []string{server.URL})
+	assert.Equal(t, 1, report.Purged)
+	assert.Equal(t, DefaultMethod, method)
+}
+
+func TestRequestPurgerCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := NewRequestPurger(http.DefaultClient, RequestOptions{}).Purge(ctx, []string{"http://127.0.0.1:1/a", "http://127.0.0.1:1/b"})
+	assert.Zero(t, report.Purged)
+	assert.Len(t, report.Failures, 2)
+}
@@ -0,0 +1,236 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Config holds the headless browser configuration
+type Config struct {
+	ChromePath  string
+	UserAgent   string
+	Headers     map[string]string
+	Timeout     time.Duration
+	CacheHeader string
+}
+
+// Result represents the outcome of rendering a single URL in the browser
+type Result struct {
+	URL           string        `json:"url"`
+	StatusCode    int           `json:"status_code"`
+	CacheStatus   string        `json:"cache_status,omitempty"`
+	RenderTime    time.Duration `json:"render_time"`
+	HTMLBytes     int           `json:"html_bytes"`
+	ConsoleErrors []string      `json:"console_errors"`
+	NetworkErrors []string      `json:"network_errors"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// Renderer renders pages in a shared headless Chrome instance
+type Renderer struct {
+	config        Config
+	browserCtx    context.Context
+	cancelBrowser context.CancelFunc
+	cancelAlloc   context.CancelFunc
+}
+
+// New launches headless Chrome. Close must be called to stop the browser.
+func New(cfg Config) (*Renderer, error) {
+	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+	if cfg.ChromePath != "" {
+		opts = append(opts, chromedp.ExecPath(cfg.ChromePath))
+	}
+	if cfg.UserAgent != "" {
+		opts = append(opts, chromedp.UserAgent(cfg.UserAgent))
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+
+	// Running without actions starts the browser so launch errors surface here
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, fmt.Errorf("failed to start headless Chrome: %w", err)
+	}
+
+	return &Renderer{
+		config:        cfg,
+		browserCtx:    browserCtx,
+		cancelBrowser: cancelBrowser,
+		cancelAlloc:   cancelAlloc,
+	}, nil
+}
+
+// Close stops the browser
+func (r *Renderer) Close() {
+	r.cancelBrowser()
+	r.cancelAlloc()
+}
+
+// Render loads a URL in a new tab and waits for the load event
+func (r *Renderer) Render(pageURL string) Result {
+	tabCtx, cancelTab := chromedp.NewContext(r.browserCtx)
+	defer cancelTab()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, r.config.Timeout)
+	defer cancelTimeout()
+
+	recorder := newPageRecorder(pageURL, r.config.CacheHeader)
+	chromedp.ListenTarget(tabCtx, recorder.handle)
+
+	headers := make(network.Headers, len(r.config.Headers))
+	for key, value := range r.config.Headers {
+		headers[key] = value
+	}
+
+	var html string
+	start := time.Now()
+	err := chromedp.Run(tabCtx,
+		network.Enable(),
+		network.SetExtraHTTPHeaders(headers),
+		chromedp.Navigate(pageURL),
+	)
+	renderTime := time.Since(start)
+	if err == nil {
+		err = chromedp.Run(tabCtx, chromedp.OuterHTML("html", &html, chromedp.ByQuery))
+	}
+
+	result := recorder.snapshot()
+	result.RenderTime = renderTime
+	result.HTMLBytes = len(html)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// pageRecorder collects browser events for a single page load
+type pageRecorder struct {
+	mu          sync.Mutex
+	pageURL     string
+	cacheHeader string
+	requests    map[network.RequestID]string
+	page        Result
+}
+
+// newPageRecorder creates a recorder for the given page
+func newPageRecorder(pageURL, cacheHeader string) *pageRecorder {
+	return &pageRecorder{
+		pageURL:     pageURL,
+		cacheHeader: cacheHeader,
+		requests:    make(map[network.RequestID]string),
+		page: Result{
+			URL:           pageURL,
+			ConsoleErrors: make([]string, 0),
+			NetworkErrors: make([]string, 0),
+		},
+	}
+}
+
+// handle records console errors, uncaught exceptions, failed requests, and
+// the main document response
+func (p *pageRecorder) handle(ev any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		if e.Type == runtime.APITypeError || e.Type == runtime.APITypeAssert {
+			p.page.ConsoleErrors = append(p.page.ConsoleErrors, consoleMessage(e.Args))
+		}
+	case *runtime.EventExceptionThrown:
+		if e.ExceptionDetails != nil {
+			p.page.ConsoleErrors = append(p.page.ConsoleErrors, e.ExceptionDetails.Error())
+		}
+	case *network.EventRequestWillBeSent:
+		if e.Request != nil {
+			p.requests[e.RequestID] = e.Request.URL
+		}
+	case *network.EventResponseReceived:
+		if e.Type == network.ResourceTypeDocument && e.Response != nil && p.page.StatusCode == 0 {
+			p.page.StatusCode = int(e.Response.Status)
+			p.page.CacheStatus = headerValue(e.Response.Headers, p.cacheHeader)
+		}
+		if e.Response != nil && e.Response.Status >= http.StatusBadRequest {
+			p.page.NetworkErrors = append(p.page.NetworkErrors,
+				fmt.Sprintf("%s: HTTP %d", e.Response.URL, e.Response.Status))
+		}
+	case *network.EventLoadingFailed:
+		if e.Canceled {
+			return
+		}
+		p.page.NetworkErrors = append(p.page.NetworkErrors,
+			fmt.Sprintf("%s: %s", p.requests[e.RequestID], e.ErrorText))
+	}
+}
+
+// snapshot returns a copy of the recorded result
+func (p *pageRecorder) snapshot() Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := p.page
+	result.ConsoleErrors = append([]string(nil), p.page.ConsoleErrors...)
+	result.NetworkErrors = append([]string(nil), p.page.NetworkErrors...)
+	return result
+}
+
+// consoleMessage joins console call arguments into a single message
+func consoleMessage(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case len(arg.Value) > 0:
+			var value any
+			if err := json.Unmarshal(arg.Value, &value); err != nil {
+				parts = append(parts, string(arg.Value))
+			} else {
+				parts = append(parts, fmt.Sprint(value))
+			}
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// headerValue looks up a header case-insensitively in CDP response headers
+func headerValue(headers network.Headers, name string) string {
+	if name == "" {
+		return ""
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// Select returns the URLs to render: those matching pattern (all when nil),
+// capped at limit (no cap when limit is zero)
+func Select(urls []string, pattern *regexp.Regexp, limit int) []string {
+	selected := make([]string, 0)
+	for _, pageURL := range urls {
+		if pattern != nil && !pattern.MatchString(pageURL) {
+			continue
+		}
+		selected = append(selected, pageURL)
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+	}
+	return selected
+}
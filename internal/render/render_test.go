@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	urls := []string{
+		"https://example.com/",
+		"https://example.com/app/one",
+		"https://example.com/app/two",
+		"https://example.com/about",
+	}
+
+	tests := []struct {
+		name     string
+		pattern  *regexp.Regexp
+		limit    int
+		expected []string
+	}{
+		{name: "no pattern no limit", expected: urls},
+		{name: "limit only", limit: 2, expected: urls[:2]},
+		{name: "pattern only", pattern: regexp.MustCompile("/app/"), expected: urls[1:3]},
+		{name: "pattern and limit", pattern: regexp.MustCompile("/app/"), limit: 1, expected: urls[1:2]},
+		{name: "no matches", pattern: regexp.MustCompile("/blog/"), expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Select(urls, tt.pattern, tt.limit))
+		})
+	}
+}
+
+func TestPageRecorderHandle(t *testing.T) {
+	t.Parallel()
+
+	recorder := newPageRecorder("https://example.com/app", "X-Cache")
+	recorder.handle(&network.EventResponseReceived{
+		Type:     network.ResourceTypeDocument,
+		Response: &network.Response{URL: "https://example.com/app", Status: 200, Headers: network.Headers{"x-cache": "MISS"}},
+	})
+	recorder.handle(&network.EventRequestWillBeSent{
+		RequestID: "42",
+		Request:   &network.Request{URL: "https://example.com/api/data"},
+	})
+	recorder.handle(&network.EventLoadingFailed{RequestID: "42", ErrorText: "net::ERR_CONNECTION_REFUSED"})
+	recorder.handle(&network.EventLoadingFailed{RequestID: "43", ErrorText: "net::ERR_ABORTED", Canceled: true})
+	recorder.handle(&network.EventResponseReceived{
+		Type:     network.ResourceTypeScript,
+		Response: &network.Response{URL: "https://example.com/app.js", Status: 404},
+	})
+	recorder.handle(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeError,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"failed to load"`)}, {Type: runtime.TypeNumber, Value: []byte("3")}},
+	})
+	recorder.handle(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeLog,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"ignored"`)}},
+	})
+
+	result := recorder.snapshot()
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "MISS", result.CacheStatus)
+	assert.Equal(t, []string{"failed to load 3"}, result.ConsoleErrors)
+	assert.Equal(t, []string{
+		"https://example.com/api/data: net::ERR_CONNECTION_REFUSED",
+		"https://example.com/app.js: HTTP 404",
+	}, result.NetworkErrors)
+}
+
+func TestRendererRender(t *testing.T) {
+	t.Parallel()
+
+	chromePath := ""
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(name); err == nil {
+			chromePath = path
+			break
+		}
+	}
+	if chromePath == "" {
+		t.Skip("Chrome is not installed")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html><body><script>console.error("boom")</script></body></html>`)
+	}))
+	defer server.Close()
+
+	renderer, err := New(Config{ChromePath: chromePath, Timeout: 30 * time.Second})
+	require.NoError(t, err)
+	defer renderer.Close()
+
+	result := renderer.Render(server.URL)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Positive(t, result.HTMLBytes)
+	assert.Contains(t, result.ConsoleErrors, "boom")
+}
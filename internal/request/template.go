@@ -0,0 +1,182 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Template describes how to request a URL. Empty fields fall back to the
+// file's defaults, and from there to a plain GET.
+type Template struct {
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+
+	// Body is a text/template rendered with Data
+	Body string `yaml:"body"`
+}
+
+// Rule applies a template to URLs matching a regular expression
+type Rule struct {
+	Match    string `yaml:"match"`
+	Template `yaml:",inline"`
+}
+
+// File is a request template file: defaults for every URL, then rules that
+// override them. The first matching rule wins.
+type File struct {
+	Template `yaml:",inline"`
+	Rules    []Rule `yaml:"rules"`
+}
+
+// Data is available to body templates
+type Data struct {
+	URL   string
+	Host  string
+	Path  string
+	Query url.Values
+}
+
+// templateFuncs are available to body templates. json quotes a value for
+// use inside a JSON payload.
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// compiled is a template with its body parsed
+type compiled struct {
+	match   *regexp.Regexp
+	method  string
+	headers map[string]string
+	body    *template.Template
+}
+
+// Builder creates requests from a template file
+type Builder struct {
+	rules    []compiled
+	defaults compiled
+}
+
+// Load reads a YAML request template file
+func Load(path string) (*Builder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request template: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML request template. Unknown keys are rejected so that
+// typos do not silently send the wrong request.
+func Parse(data []byte) (*Builder, error) {
+	var file File
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	defaults, err := compile(file.Template, compiled{method: http.MethodGet})
+	if err != nil {
+		return nil, fmt.Errorf("invalid request template: %w", err)
+	}
+
+	builder := &Builder{defaults: defaults}
+	for i, rule := range file.Rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("invalid request template rule %d: match is required", i+1)
+		}
+		match, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request template rule %d: %w", i+1, err)
+		}
+
+		entry, err := compile(rule.Template, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request template rule %d: %w", i+1, err)
+		}
+		entry.match = match
+		builder.rules = append(builder.rules, entry)
+	}
+
+	return builder, nil
+}
+
+// compile fills a template's empty fields from base and parses its body
+func compile(tmpl Template, base compiled) (compiled, error) {
+	result := compiled{
+		method:  base.method,
+		headers: make(map[string]string, len(base.headers)+len(tmpl.Headers)),
+		body:    base.body,
+	}
+
+	if tmpl.Method != "" {
+		result.method = strings.ToUpper(tmpl.Method)
+	}
+	for name, value := range base.headers {
+		result.headers[name] = value
+	}
+	for name, value := range tmpl.Headers {
+		result.headers[name] = value
+	}
+
+	if tmpl.Body != "" {
+		body, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl.Body)
+		if err != nil {
+			return compiled{}, fmt.Errorf("invalid body template: %w", err)
+		}
+		result.body = body
+	}
+
+	return result, nil
+}
+
+// NewRequest builds the request for a URL from the first matching rule, or
+// from the defaults if none match
+func (b *Builder) NewRequest(rawURL string) (*http.Request, error) {
+	entry := b.defaults
+	for _, rule := range b.rules {
+		if rule.match.MatchString(rawURL) {
+			entry = rule
+			break
+		}
+	}
+
+	var body io.Reader
+	if entry.body != nil {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var buffer bytes.Buffer
+		data := Data{URL: rawURL, Host: parsed.Host, Path: parsed.Path, Query: parsed.Query()}
+		if err := entry.body.Execute(&buffer, data); err != nil {
+			return nil, fmt.Errorf("failed to render request body: %w", err)
+		}
+		body = &buffer
+	}
+
+	req, err := http.NewRequest(entry.method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range entry.headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
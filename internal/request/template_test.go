@@ -0,0 +1,121 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplate = `
+headers:
+  X-Warm: "1"
+rules:
+  - match: /graphql/
+    method: post
+    headers:
+      Content-Type: application/json
+    body: '{"query":"{ page(path: {{json .Path}}) { id } }","id":{{json (.Query.Get "id")}}}'
+  - match: /prime/
+    method: PURGE
+`
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	builder, err := Parse([]byte(testTemplate))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		url     string
+		method  string
+		body    string
+		headers map[string]string
+	}{
+		{
+			name:    "defaults",
+			url:     "https://example.com/about",
+			method:  http.MethodGet,
+			headers: map[string]string{"X-Warm": "1"},
+		},
+		{
+			name:    "rule with rendered body",
+			url:     "https://example.com/graphql/products?id=7",
+			method:  http.MethodPost,
+			body:    `{"query":"{ page(path: "/graphql/products") { id } }","id":"7"}`,
+			headers: map[string]string{"X-Warm": "1", "Content-Type": "application/json"},
+		},
+		{
+			name:    "rule without body",
+			url:     "https://example.com/prime/home",
+			method:  "PURGE",
+			headers: map[string]string{"X-Warm": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := builder.NewRequest(tt.url)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.method, req.Method)
+			assert.Equal(t, tt.url, req.URL.String())
+			for name, value := range tt.headers {
+				assert.Equal(t, value, req.Header.Get(name))
+			}
+
+			body := ""
+			if req.Body != nil {
+				data, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				body = string(data)
+			}
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{name: "empty", template: ""},
+		{name: "defaults only", template: "method: POST\nbody: '{}'\n"},
+		{name: "unknown key", template: "metod: POST\n", wantErr: "failed to parse request template"},
+		{name: "rule without match", template: "rules:\n  - method: POST\n", wantErr: "match is required"},
+		{name: "invalid match", template: "rules:\n  - match: '('\n", wantErr: "invalid request template rule 1"},
+		{name: "invalid body", template: "body: '{{.URL'\n", wantErr: "invalid body template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse([]byte(tt.template))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewRequestMissingField(t *testing.T) {
+	t.Parallel()
+
+	builder, err := Parse([]byte("body: '{{.Missing}}'\n"))
+	require.NoError(t, err)
+
+	_, err = builder.NewRequest("https://example.com/")
+	assert.ErrorContains(t, err, "failed to render request body")
+}
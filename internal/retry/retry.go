@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// DefaultDelay is the wait before the first retry when a rule gives none
+const DefaultDelay = 500 * time.Millisecond
+
+// MaxDelay caps the doubled wait between retries
+const MaxDelay = time.Minute
+
+// MatchError matches every failure other than an HTTP error status
+const MatchError = "error"
+
+// statusClassPattern matches a status class such as 5xx
+var statusClassPattern = regexp.MustCompile(`^[1-5]xx$`)
+
+// Rule retries failures that match a status code (503), a status class
+// (5xx), an error category (timeout) or any failure other than an error
+// status
+type Rule struct {
+	Match   string
+	Retries int
+
+	// Delay is the wait before the first retry; it doubles for each retry
+	// after that, up to MaxDelay
+	Delay time.Duration
+}
+
+// ParseRule parses a rule in the form match=retries[:delay], such as
+// 503=3:1s, 5xx=2, timeout=2 or 4xx=0
+func ParseRule(spec string) (Rule, error) {
+	match, value, found := strings.Cut(spec, "=")
+	if !found {
+		return Rule{}, fmt.Errorf("invalid retry rule %q: expected match=retries[:delay]", spec)
+	}
+
+	rule := Rule{Match: strings.ToLower(strings.TrimSpace(match)), Delay: DefaultDelay}
+	if !validMatch(rule.Match) {
+		return Rule{}, fmt.Errorf("invalid retry rule %q: match must be a status code, a status class such as 5xx, %q or an error category", spec, MatchError)
+	}
+
+	retries, delay, hasDelay := strings.Cut(value, ":")
+	count, err := strconv.Atoi(strings.TrimSpace(retries))
+	if err != nil || count < 0 {
+		return Rule{}, fmt.Errorf("invalid retry rule %q: retries must be a non-negative number", spec)
+	}
+	rule.Retries = count
+
+	if hasDelay {
+		rule.Delay, err = time.ParseDuration(strings.TrimSpace(delay))
+		if err != nil || rule.Delay < 0 {
+			return Rule{}, fmt.Errorf("invalid retry rule %q: invalid delay %q", spec, delay)
+		}
+	}
+
+	return rule, nil
+}
+
+// validMatch reports whether a rule match is a status code, status class,
+// error category or MatchError
+func validMatch(match string) bool {
+	if code, err := strconv.Atoi(match); err == nil {
+		return code >= 100 && code <= 599
+	}
+	if statusClassPattern.MatchString(match) || match == MatchError {
+		return true
+	}
+	return slices.Contains(stats.ErrorCategories(), stats.ErrorCategory(match)) && match != string(stats.ErrorHTTPStatus)
+}
+
+// Policy decides whether a failed request is retried
+type Policy struct {
+	rules map[string]Rule
+}
+
+// NewPolicy creates a policy from rules. A later rule with the same match
+// replaces an earlier one. Failures no rule matches are not retried.
+func NewPolicy(rules []Rule) *Policy {
+	policy := &Policy{rules: make(map[string]Rule, len(rules))}
+	for _, rule := range rules {
+		policy.rules[rule.Match] = rule
+	}
+	return policy
+}
+
+// Enabled reports whether any rule allows retries
+func (p *Policy) Enabled() bool {
+	for _, rule := range p.rules {
+		if rule.Retries > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Next reports whether a failed request is retried after the given number
+// of attempts, and how long to wait first. The most specific rule wins: a
+// status code over its class, and an error category over MatchError.
+func (p *Policy) Next(result *stats.Result, attempts int) (time.Duration, bool) {
+	if result.Success {
+		return 0, false
+	}
+
+	rule, ok := p.rule(result)
+	if !ok || attempts > rule.Retries {
+		return 0, false
+	}
+	delay := min(rule.Delay, MaxDelay)
+	for range attempts - 1 {
+		delay = min(delay*2, MaxDelay)
+	}
+	return delay, true
+}
+
+// rule finds the most specific rule matching a result
+func (p *Policy) rule(result *stats.Result) (Rule, bool) {
+	var candidates []string
+	switch category := result.ErrorCategory; {
+	case category != "" && category != stats.ErrorHTTPStatus:
+		// A timeout rule covers the timeouts of every phase
+		candidates = []string{string(category)}
+		if category.IsTimeout() {
+			candidates = append(candidates, string(stats.ErrorTimeout))
+		}
+		candidates = append(candidates, MatchError)
+	case result.StatusCode != 0:
+		code := strconv.Itoa(result.StatusCode)
+		candidates = []string{code, code[:1] + "xx"}
+	}
+
+	for _, match := range candidates {
+		if rule, ok := p.rules[match]; ok {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
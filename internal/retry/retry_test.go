@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected Rule
+		errorMsg string
+	}{
+		{name: "status code with delay", spec: "503=3:1s", expected: Rule{Match: "503", Retries: 3, Delay: time.Second}},
+		{name: "status class", spec: "5XX=2", expected: Rule{Match: "5xx", Retries: 2, Delay: DefaultDelay}},
+		{name: "never retry", spec: "4xx=0", expected: Rule{Match: "4xx", Retries: 0, Delay: DefaultDelay}},
+		{name: "error category", spec: "timeout=2:250ms", expected: Rule{Match: "timeout", Retries: 2, Delay: 250 * time.Millisecond}},
+		{name: "any error", spec: "error=1", expected: Rule{Match: "error", Retries: 1, Delay: DefaultDelay}},
+		{name: "missing retries", spec: "503", errorMsg: "expected match=retries"},
+		{name: "unknown match", spec: "slow=1", errorMsg: "match must be"},
+		{name: "status out of range", spec: "700=1", errorMsg: "match must be"},
+		{name: "http_status category", spec: "http_status=1", errorMsg: "match must be"},
+		{name: "negative retries", spec: "503=-1", errorMsg: "non-negative"},
+		{name: "invalid delay", spec: "503=1:soon", errorMsg: "invalid delay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := ParseRule(tt.spec)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestPolicyNext(t *testing.T) {
+	t.Parallel()
+
+	policy := NewPolicy([]Rule{
+		{Match: "503", Retries: 3, Delay: time.Second},
+		{Match: "5xx", Retries: 1, Delay: 100 * time.Millisecond},
+		{Match: "4xx", Retries: 0},
+		{Match: "timeout", Retries: 2, Delay: 200 * time.Millisecond},
+		{Match: "error", Retries: 1, Delay: 50 * time.Millisecond},
+	})
+
+	tests := []struct {
+		name      string
+		result    stats.Result
+		attempts  int
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{name: "success", result: stats.Result{Success: true, StatusCode: 200}, attempts: 1},
+		{name: "status code first retry", result: stats.Result{StatusCode: 503}, attempts: 1, wantDelay: time.Second, wantRetry: true},
+		{name: "status code delay doubles", result: stats.Result{StatusCode: 503}, attempts: 3, wantDelay: 4 * time.Second, wantRetry: true},
+		{name: "status code exhausted", result: stats.Result{StatusCode: 503}, attempts: 4},
+		{name: "status class", result: stats.Result{StatusCode: 502}, attempts: 1, wantDelay: 100 * time.Millisecond, wantRetry: true},
+		{name: "status class exhausted", result: stats.Result{StatusCode: 502}, attempts: 2},
+		{name: "never retried", result: stats.Result{StatusCode: 404}, attempts: 1},
+		{name: "error category", result: stats.Result{ErrorCategory: stats.ErrorTimeout}, attempts: 2, wantDelay: 400 * time.Millisecond, wantRetry: true},
+		{name: "any error", result: stats.Result{ErrorCategory: stats.ErrorDNS}, attempts: 1, wantDelay: 50 * time.Millisecond, wantRetry: true},
+		{name: "phase timeout", result: stats.Result{ErrorCategory: stats.ErrorConnectTimeout}, attempts: 1, wantDelay: 200 * time.Millisecond, wantRetry: true},
+		{name: "body timeout after headers", result: stats.Result{StatusCode: 200, ErrorCategory: stats.ErrorBodyTimeout}, attempts: 1, wantDelay: 200 * time.Millisecond, wantRetry: true},
+		{name: "error status", result: stats.Result{StatusCode: 503, ErrorCategory: stats.ErrorHTTPStatus}, attempts: 1, wantDelay: time.Second, wantRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			delay, retry := policy.Next(&tt.result, tt.attempts)
+			assert.Equal(t, tt.wantRetry, retry)
+			assert.Equal(t, tt.wantDelay, delay)
+		})
+	}
+}
+
+func TestPolicyDelayCapped(t *testing.T) {
+	t.Parallel()
+
+	policy := NewPolicy([]Rule{{Match: "503", Retries: 100, Delay: time.Second}})
+	delay, retry := policy.Next(&stats.Result{StatusCode: 503}, 100)
+	assert.True(t, retry)
+	assert.Equal(t, MaxDelay, delay)
+
+	assert.True(t, policy.Enabled())
+	assert.False(t, NewPolicy([]Rule{{Match: "4xx"}}).Enabled())
+	assert.False(t, NewPolicy(nil).Enabled())
+}
@@ -0,0 +1,54 @@
+package runinfo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Run identifies one crawl in every output it writes, so that reports,
+// results files and sitemaps from the same run can be matched up. Seed
+// seeded the run's random choices, so that --seed can repeat them.
+type Run struct {
+	ID          string                 `json:"run_id"`
+	Version     string                 `json:"version"`
+	StartedAt   time.Time              `json:"started_at"`
+	FinishedAt  time.Time              `json:"finished_at,omitzero"`
+	SitemapURLs []string               `json:"sitemap_urls"`
+	Seed        uint64                 `json:"seed,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+}
+
+// New starts a run. The ID sorts by start time and ends in random
+// characters so that runs started in the same second differ.
+func New(version string, sitemapURLs []string, config map[string]interface{}) *Run {
+	startedAt := time.Now().UTC()
+	return &Run{
+		ID:          newID(startedAt),
+		Version:     version,
+		StartedAt:   startedAt,
+		SitemapURLs: sitemapURLs,
+		Config:      config,
+	}
+}
+
+// Finish records the end of the run
+func (r *Run) Finish() {
+	r.FinishedAt = time.Now().UTC()
+}
+
+// Duration is how long the run took, or has taken so far if it has not
+// finished
+func (r *Run) Duration() time.Duration {
+	if r.FinishedAt.IsZero() {
+		return time.Since(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// newID creates a run ID from the start time and random bytes
+func newID(startedAt time.Time) string {
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	return startedAt.Format("20060102T150405Z") + "-" + hex.EncodeToString(random)
+}
@@ -0,0 +1,67 @@
+package runinfo
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	run := New("1.2.3", []string{"https://example.com/sitemap.xml"}, map[string]interface{}{"max-workers": 10})
+	other := New("1.2.3", nil, nil)
+
+	assert.Regexp(t, regexp.MustCompile(`^\d{8}T\d{6}Z-[0-9a-f]{8}$`), run.ID)
+	assert.NotEqual(t, run.ID, other.ID)
+	assert.True(t, run.FinishedAt.IsZero())
+
+	run.Finish()
+	assert.False(t, run.FinishedAt.Before(run.StartedAt))
+	assert.Equal(t, run.FinishedAt.Sub(run.StartedAt), run.Duration())
+}
+
+func TestRunJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		finish   bool
+		contains []string
+		missing  []string
+	}{
+		{
+			name:     "running",
+			contains: []string{`"run_id"`, `"version":"1.2.3"`, `"started_at"`, `"sitemap_urls":["https://example.com/sitemap.xml"]`},
+			missing:  []string{`"finished_at"`, `"config"`},
+		},
+		{
+			name:     "finished",
+			finish:   true,
+			contains: []string{`"finished_at"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			run := New("1.2.3", []string{"https://example.com/sitemap.xml"}, nil)
+			if tt.finish {
+				run.Finish()
+			}
+
+			data, err := json.Marshal(run)
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, string(data), want)
+			}
+			for _, unwanted := range tt.missing {
+				assert.NotContains(t, string(data), unwanted)
+			}
+		})
+	}
+}
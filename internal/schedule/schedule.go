@@ -0,0 +1,103 @@
+package schedule
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+)
+
+// Never is the interval of URLs that should not be crawled again once they
+// have been crawled
+const Never time.Duration = -1
+
+// changeFreqIntervals maps sitemap changefreq values to re-crawl intervals.
+// "always" is due on every pass.
+var changeFreqIntervals = map[string]time.Duration{
+	"always":  0,
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+	"never":   Never,
+}
+
+// Interval returns how often a URL should be re-crawled. The changefreq sets
+// the interval, falling back to base when it is missing or unknown, and the
+// priority scales it: priority 1.0 halves the interval and priority 0.25
+// doubles it, relative to the protocol's default of 0.5.
+func Interval(entry parser.URL, base time.Duration) time.Duration {
+	interval, ok := changeFreqIntervals[strings.ToLower(entry.ChangeFreq)]
+	if !ok {
+		interval = base
+	}
+	if interval <= 0 {
+		return interval
+	}
+
+	factor := 2.0
+	if priority := entry.EffectivePriority(); priority > 0 {
+		factor = min(max(parser.DefaultPriority/priority, 0.5), 2)
+	}
+	return time.Duration(float64(interval) * factor)
+}
+
+// Scheduler tracks when each URL is next due, so that a long-running crawl
+// re-crawls hourly pages every hour and monthly pages once a month instead
+// of re-crawling everything on every pass
+type Scheduler struct {
+	base time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// New creates a scheduler. base is the interval of URLs without a
+// recognised changefreq.
+func New(base time.Duration) *Scheduler {
+	return &Scheduler{base: base, next: make(map[string]time.Time)}
+}
+
+// Due returns the entries due at now, in their original order, and records
+// when each of them is next due. URLs not seen before are always due.
+func (s *Scheduler) Due(entries []parser.URL, now time.Time) []parser.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []parser.URL
+	for _, entry := range entries {
+		next, seen := s.next[entry.Loc]
+		if seen && (next.IsZero() || now.Before(next)) {
+			continue
+		}
+		due = append(due, entry)
+
+		interval := Interval(entry, s.base)
+		if interval == Never {
+			s.next[entry.Loc] = time.Time{}
+			continue
+		}
+		s.next[entry.Loc] = now.Add(interval)
+	}
+	return due
+}
+
+// NextDue returns the earliest time any tracked URL is due, and false when
+// none will be due again
+func (s *Scheduler) NextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	for _, next := range s.next {
+		if next.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
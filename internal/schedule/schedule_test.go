@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterval(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	tests := []struct {
+		name     string
+		entry    parser.URL
+		expected time.Duration
+	}{
+		{name: "hourly", entry: parser.URL{ChangeFreq: "hourly"}, expected: time.Hour},
+		{name: "monthly", entry: parser.URL{ChangeFreq: "monthly"}, expected: 30 * day},
+		{name: "case insensitive", entry: parser.URL{ChangeFreq: "Daily"}, expected: day},
+		{name: "missing changefreq uses base", entry: parser.URL{}, expected: 12 * time.Hour},
+		{name: "unknown changefreq uses base", entry: parser.URL{ChangeFreq: "often"}, expected: 12 * time.Hour},
+		{name: "always", entry: parser.URL{ChangeFreq: "always", Priority: 1, HasPriority: true}, expected: 0},
+		{name: "never", entry: parser.URL{ChangeFreq: "never"}, expected: Never},
+		{name: "high priority halves", entry: parser.URL{ChangeFreq: "daily", Priority: 1, HasPriority: true}, expected: 12 * time.Hour},
+		{name: "low priority doubles", entry: parser.URL{ChangeFreq: "daily", Priority: 0.25, HasPriority: true}, expected: 2 * day},
+		{name: "very low priority is capped", entry: parser.URL{ChangeFreq: "daily", Priority: 0.1, HasPriority: true}, expected: 2 * day},
+		{name: "explicit zero priority doubles", entry: parser.URL{ChangeFreq: "daily", Priority: 0, HasPriority: true}, expected: 2 * day},
+		{name: "invalid priority uses default", entry: parser.URL{ChangeFreq: "daily", Priority: 3, HasPriority: true}, expected: day},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Interval(tt.entry, 12*time.Hour))
+		})
+	}
+}
+
+func TestSchedulerDue(t *testing.T) {
+	t.Parallel()
+
+	hourly := parser.URL{Loc: "https://example.com/news", ChangeFreq: "hourly"}
+	daily := parser.URL{Loc: "https://example.com/about", ChangeFreq: "daily"}
+	always := parser.URL{Loc: "https://example.com/live", ChangeFreq: "always"}
+	archived := parser.URL{Loc: "https://example.com/2010", ChangeFreq: "never"}
+	entries := []parser.URL{hourly, daily, always, archived}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(24 * time.Hour)
+
+	assert.Equal(t, entries, s.Due(entries, start))
+	assert.Equal(t, []parser.URL{always}, s.Due(entries, start.Add(30*time.Minute)))
+	assert.Equal(t, []parser.URL{hourly, always}, s.Due(entries, start.Add(time.Hour)))
+	assert.Equal(t, []parser.URL{hourly, daily, always}, s.Due(entries, start.Add(24*time.Hour)))
+
+	added := parser.URL{Loc: "https://example.com/new", ChangeFreq: "monthly"}
+	assert.Equal(t, []parser.URL{added}, s.Due([]parser.URL{archived, added}, start.Add(25*time.Hour)))
+}
+
+func TestSchedulerNextDue(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := New(24 * time.Hour)
+	_, ok := s.NextDue()
+	assert.False(t, ok)
+
+	s.Due([]parser.URL{{Loc: "https://example.com/2010", ChangeFreq: "never"}}, start)
+	_, ok = s.NextDue()
+	assert.False(t, ok)
+
+	s.Due([]parser.URL{
+		{Loc: "https://example.com/about", ChangeFreq: "daily"},
+		{Loc: "https://example.com/news", ChangeFreq: "hourly"},
+	}, start)
+	next, ok := s.NextDue()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Hour), next)
+}
@@ -0,0 +1,334 @@
+package sigv4
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are an AWS access key, with a session token and expiry when
+// they are temporary
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expires is zero for credentials that do not expire
+	Expires time.Time
+}
+
+// Provider retrieves credentials
+type Provider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// StaticProvider provides fixed credentials
+type StaticProvider Credentials
+
+// Retrieve returns the credentials
+func (p StaticProvider) Retrieve(context.Context) (Credentials, error) {
+	return Credentials(p), nil
+}
+
+// errNoCredentials reports that a source in the chain has no credentials, so
+// the next is tried
+var errNoCredentials = errors.New("no credentials")
+
+// Default endpoints of the container and instance metadata services
+const (
+	containerEndpoint = "http://169.254.170.2"
+	imdsEndpoint      = "http://169.254.169.254"
+)
+
+// metadataTimeout bounds each call to a metadata service, which is not
+// reachable at all outside AWS
+const metadataTimeout = 2 * time.Second
+
+// refreshWindow is how long before they expire temporary credentials are
+// refreshed
+const refreshWindow = 5 * time.Minute
+
+// Chain looks for credentials where the AWS SDKs do, in order: the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, the
+// shared credentials file, the ECS container endpoint and the EC2 instance
+// metadata service. The credentials found are cached until shortly before
+// they expire.
+type Chain struct {
+	profile string
+	getenv  func(string) string
+	home    func() (string, error)
+	client  *http.Client
+
+	mu     sync.Mutex
+	cached *Credentials
+}
+
+// NewChain creates a chain that reads profile from the shared credentials
+// file; an empty profile means AWS_PROFILE, or else "default"
+func NewChain(profile string) *Chain {
+	return &Chain{
+		profile: profile,
+		getenv:  os.Getenv,
+		home:    os.UserHomeDir,
+		client:  &http.Client{Timeout: metadataTimeout},
+	}
+}
+
+// Retrieve returns cached credentials, or the first found in the chain
+func (c *Chain) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && (c.cached.Expires.IsZero() || time.Until(c.cached.Expires) > refreshWindow) {
+		return *c.cached, nil
+	}
+
+	sources := []func(context.Context) (Credentials, error){
+		c.fromEnvironment,
+		c.fromSharedFile,
+		c.fromContainer,
+		c.fromInstance,
+	}
+	for _, source := range sources {
+		credentials, err := source(ctx)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return Credentials{}, err
+		}
+		c.cached = &credentials
+		return credentials, nil
+	}
+	return Credentials{}, errors.New("no AWS credentials found in the environment, shared credentials file, container or instance metadata")
+}
+
+func (c *Chain) fromEnvironment(context.Context) (Credentials, error) {
+	accessKey := c.getenv("AWS_ACCESS_KEY_ID")
+	secretKey := c.getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return Credentials{}, errNoCredentials
+	}
+	return Credentials{AccessKeyID: accessKey, SecretAccessKey: secretKey, SessionToken: c.getenv("AWS_SESSION_TOKEN")}, nil
+}
+
+// fromSharedFile reads the profile's keys from AWS_SHARED_CREDENTIALS_FILE,
+// or ~/.aws/credentials. A missing file or profile moves on down the chain,
+// but a profile named explicitly must exist.
+func (c *Chain) fromSharedFile(context.Context) (Credentials, error) {
+	path := c.getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := c.home()
+		if err != nil {
+			return Credentials{}, errNoCredentials
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+
+	profile := c.profile
+	if profile == "" {
+		profile = c.getenv("AWS_PROFILE")
+	}
+	explicit := profile != ""
+	if !explicit {
+		profile = "default"
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return Credentials{}, errNoCredentials
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to open shared credentials file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	values, err := readProfile(file, profile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read shared credentials file %s: %w", path, err)
+	}
+	if values == nil {
+		if explicit {
+			return Credentials{}, fmt.Errorf("profile %q not found in shared credentials file %s", profile, path)
+		}
+		return Credentials{}, errNoCredentials
+	}
+	if values["aws_access_key_id"] == "" || values["aws_secret_access_key"] == "" {
+		return Credentials{}, fmt.Errorf("profile %q in %s has no aws_access_key_id and aws_secret_access_key", profile, path)
+	}
+	return Credentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}, nil
+}
+
+// readProfile returns the keys of one [profile] section of an INI file, or
+// nil when there is no such section
+func readProfile(r io.Reader, profile string) (map[string]string, error) {
+	var values map[string]string
+	inProfile := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			if inProfile && values == nil {
+				values = make(map[string]string)
+			}
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
+
+// metadataCredentials is the credentials document the container and
+// instance metadata services return
+type metadataCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// fromContainer asks the ECS container credentials endpoint, when
+// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or _FULL_URI names one
+func (c *Chain) fromContainer(ctx context.Context) (Credentials, error) {
+	endpoint := c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = containerEndpoint + relative
+	}
+	if endpoint == "" {
+		return Credentials{}, errNoCredentials
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid container credentials endpoint: %w", err)
+	}
+	if token := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	var document metadataCredentials
+	if err := c.getJSON(req, &document); err != nil {
+		return Credentials{}, fmt.Errorf("failed to get container credentials: %w", err)
+	}
+	return document.credentials(), nil
+}
+
+// fromInstance asks the EC2 instance metadata service for the credentials of
+// the instance's role, using an IMDSv2 session token. An unreachable service
+// means the crawler is not on EC2.
+func (c *Chain) fromInstance(ctx context.Context) (Credentials, error) {
+	if strings.EqualFold(c.getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, errNoCredentials
+	}
+	endpoint := c.getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = imdsEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid instance metadata endpoint: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := c.getText(req)
+	if err != nil {
+		return Credentials{}, errNoCredentials
+	}
+
+	get := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return req, nil
+	}
+
+	req, err = get("")
+	if err != nil {
+		return Credentials{}, err
+	}
+	roles, err := c.getText(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(roles), "\n")
+	if role == "" {
+		return Credentials{}, errNoCredentials
+	}
+
+	req, err = get(role)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var document metadataCredentials
+	if err := c.getJSON(req, &document); err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance credentials: %w", err)
+	}
+	return document.credentials(), nil
+}
+
+func (d metadataCredentials) credentials() Credentials {
+	return Credentials{
+		AccessKeyID:     d.AccessKeyID,
+		SecretAccessKey: d.SecretAccessKey,
+		SessionToken:    d.Token,
+		Expires:         d.Expiration,
+	}
+}
+
+// getText returns the body of a successful metadata response
+func (c *Chain) getText(req *http.Request) (string, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+// getJSON decodes a metadata credentials document
+func (c *Chain) getJSON(req *http.Request, document *metadataCredentials) error {
+	body, err := c.getText(req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(body), document); err != nil {
+		return err
+	}
+	if document.AccessKeyID == "" || document.SecretAccessKey == "" {
+		return errors.New("response has no access key")
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Package sigv4 signs HTTP requests with AWS Signature Version 4, so that
+// crawls can reach S3 buckets, API Gateway stages and other origins that
+// only answer signed requests
+package sigv4
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm = "AWS4-HMAC-SHA256"
+
+	// timeFormat is the format of X-Amz-Date
+	timeFormat = "20060102T150405Z"
+
+	// emptyPayloadHash is the SHA-256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// unsignedPayload stands in for the hash of a body that cannot be read
+	// twice; S3 accepts it, other services reject the request
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// Signer signs requests for one service in one region
+type Signer struct {
+	service     string
+	region      string
+	credentials Provider
+
+	// now returns the signing time
+	now func() time.Time
+}
+
+// NewSigner creates a signer for a service, such as s3 or execute-api, in a
+// region, with credentials from provider
+func NewSigner(service, region string, provider Provider) *Signer {
+	return &Signer{service: service, region: region, credentials: provider, now: time.Now}
+}
+
+// Sign adds the X-Amz-Date, security token and Authorization headers to a
+// request. The headers signed are the host and the X-Amz-* headers, so that
+// headers a transport adds or changes afterwards do not break the signature.
+func (s *Signer) Sign(req *http.Request) error {
+	credentials, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	payloadHash, err := s.payloadHash(req)
+	if err != nil {
+		return err
+	}
+
+	signingTime := s.now().UTC()
+	amzDate := signingTime.Format(timeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+	// S3 requires the payload hash as a header
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	canonicalHeaders, signedHeaders := s.canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := signingTime.Format("20060102")
+	scope := strings.Join([]string{date, s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// Check retrieves credentials once, so that a crawl with none fails before
+// it starts rather than on every request
+func (s *Signer) Check(ctx context.Context) error {
+	if _, err := s.credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	return nil
+}
+
+// payloadHash returns the hex SHA-256 of the request body, read through
+// GetBody so that the body itself is left for sending
+func (s *Signer) payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return emptyPayloadHash, nil
+	}
+	if req.GetBody == nil {
+		return unsignedPayload, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body for signing: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", fmt.Errorf("failed to read request body for signing: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// canonicalPath returns the URI-encoded path. Every service but S3 encodes
+// the already-encoded path a second time.
+func (s *Signer) canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if s.service == "s3" {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = encode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query parameters sorted by name, then value,
+// each encoded
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, encode(name)+"="+encode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaders returns the signed headers, lower-cased and sorted, one
+// per line, and their names joined by semicolons
+func (s *Signer) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical bytes.Buffer
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// encode percent-encodes everything but the unreserved characters of RFC
+// 3986, as SigV4 requires
+func encode(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') || strings.IndexByte("-_.~", b) >= 0 {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Transport returns a RoundTripper that signs every request before passing
+// it to next. The caller's request is left unchanged.
+func Transport(next http.RoundTripper, signer *Signer) http.RoundTripper {
+	return &signingTransport{next: next, signer: signer}
+}
+
+// signingTransport signs a copy of each request
+type signingTransport struct {
+	next   http.RoundTripper
+	signer *Signer
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if err := t.signer.Sign(signed); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(signed)
+}
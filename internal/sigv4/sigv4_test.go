@@ -0,0 +1,255 @@
+package sigv4
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleCredentials are the credentials of the AWS SigV4 test suite
+var exampleCredentials = StaticProvider{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestSignMatchesTestSuite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		signature string
+	}{
+		{
+			name:      "get-vanilla",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/",
+			signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:      "get-vanilla-query-order-key-case",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:      "post-vanilla",
+			method:    http.MethodPost,
+			url:       "https://example.amazonaws.com/",
+			signature: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := NewSigner("service", "us-east-1", exampleCredentials)
+			signer.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			require.NoError(t, err)
+			require.NoError(t, signer.Sign(req))
+
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+tt.signature,
+				req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestSignS3(t *testing.T) {
+	t.Parallel()
+
+	signer := NewSigner("s3", "eu-west-1", StaticProvider{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"})
+	req, err := http.NewRequest(http.MethodPost, "https://bucket.s3.amazonaws.com/a%20b", strings.NewReader("body"))
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req))
+
+	assert.Equal(t, "230d8358dc8e8890b4c58deeb62912ee2f20357ae92a5cc861b98e68fe31acb5", req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,")
+	assert.Equal(t, "/a%20b", signer.canonicalPath(req.URL))
+
+	// Other services encode the path twice
+	assert.Equal(t, "/a%2520b", NewSigner("execute-api", "eu-west-1", nil).canonicalPath(req.URL))
+}
+
+func TestTransportSignsCopy(t *testing.T) {
+	t.Parallel()
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport, NewSigner("execute-api", "us-east-1", exampleCredentials))}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), authorization)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+// testChain returns a chain that sees only env and no home directory
+func testChain(profile string, env map[string]string) *Chain {
+	chain := NewChain(profile)
+	chain.getenv = func(name string) string { return env[name] }
+	chain.home = func() (string, error) { return "", os.ErrNotExist }
+	return chain
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(`
+[default]
+aws_access_key_id = DEFAULTKEY
+aws_secret_access_key = defaultsecret
+
+[crawler]
+aws_access_key_id = CRAWLERKEY
+aws_secret_access_key = crawlersecret
+aws_session_token = crawlertoken
+`), 0o600))
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	metadata := http.NewServeMux()
+	metadata.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("session"))
+	})
+	metadata.HandleFunc("GET /latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-aws-ec2-metadata-token") != "session" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("crawler-role\n"))
+	})
+	metadata.HandleFunc("GET /latest/meta-data/iam/security-credentials/crawler-role", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"AccessKeyId":"INSTANCEKEY","SecretAccessKey":"instancesecret","Token":"instancetoken","Expiration":"` + expires.Format(time.RFC3339) + `"}`))
+	})
+	metadata.HandleFunc("GET /container", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "container-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId":"CONTAINERKEY","SecretAccessKey":"containersecret","Token":"containertoken"}`))
+	})
+	server := httptest.NewServer(metadata)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name     string
+		profile  string
+		env      map[string]string
+		expected Credentials
+		err      string
+	}{
+		{
+			name: "environment first",
+			env: map[string]string{
+				"AWS_ACCESS_KEY_ID":           "ENVKEY",
+				"AWS_SECRET_ACCESS_KEY":       "envsecret",
+				"AWS_SESSION_TOKEN":           "envtoken",
+				"AWS_SHARED_CREDENTIALS_FILE": credentialsFile,
+			},
+			expected: Credentials{AccessKeyID: "ENVKEY", SecretAccessKey: "envsecret", SessionToken: "envtoken"},
+		},
+		{
+			name:     "default profile",
+			env:      map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile},
+			expected: Credentials{AccessKeyID: "DEFAULTKEY", SecretAccessKey: "defaultsecret"},
+		},
+		{
+			name:     "named profile",
+			profile:  "crawler",
+			env:      map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile, "AWS_PROFILE": "ignored"},
+			expected: Credentials{AccessKeyID: "CRAWLERKEY", SecretAccessKey: "crawlersecret", SessionToken: "crawlertoken"},
+		},
+		{
+			name:    "missing profile",
+			profile: "missing",
+			env:     map[string]string{"AWS_SHARED_CREDENTIALS_FILE": credentialsFile},
+			err:     `profile "missing" not found`,
+		},
+		{
+			name: "container",
+			env: map[string]string{
+				"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL + "/container",
+				"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "container-token",
+			},
+			expected: Credentials{AccessKeyID: "CONTAINERKEY", SecretAccessKey: "containersecret", SessionToken: "containertoken"},
+		},
+		{
+			name:     "instance",
+			env:      map[string]string{"AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL},
+			expected: Credentials{AccessKeyID: "INSTANCEKEY", SecretAccessKey: "instancesecret", SessionToken: "instancetoken", Expires: expires},
+		},
+		{
+			name: "none",
+			env:  map[string]string{"AWS_EC2_METADATA_DISABLED": "true"},
+			err:  "no AWS credentials found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			credentials, err := testChain(tt.profile, tt.env).Retrieve(context.Background())
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.AccessKeyID, credentials.AccessKeyID)
+			assert.Equal(t, tt.expected.SecretAccessKey, credentials.SecretAccessKey)
+			assert.Equal(t, tt.expected.SessionToken, credentials.SessionToken)
+			assert.True(t, tt.expected.Expires.Equal(credentials.Expires), credentials.Expires)
+		})
+	}
+}
+
+func TestChainRefreshesExpiringCredentials(t *testing.T) {
+	t.Parallel()
+
+	key := "FIRST"
+	env := map[string]string{"AWS_SECRET_ACCESS_KEY": "secret", "AWS_EC2_METADATA_DISABLED": "true"}
+	chain := testChain("", env)
+	chain.getenv = func(name string) string {
+		if name == "AWS_ACCESS_KEY_ID" {
+			return key
+		}
+		return env[name]
+	}
+
+	credentials, err := chain.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "FIRST", credentials.AccessKeyID)
+
+	// Credentials without an expiry stay cached
+	key = "SECOND"
+	credentials, err = chain.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "FIRST", credentials.AccessKeyID)
+
+	chain.cached.Expires = time.Now().Add(time.Minute)
+	credentials, err = chain.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "SECOND", credentials.AccessKeyID)
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"strings"
+	"time"
+)
+
+// EdgeStats represents the verification requests sent to one CDN edge
+type EdgeStats struct {
+	Edge            string        `json:"edge"`
+	Requests        int           `json:"requests"`
+	Errors          int           `json:"errors"`
+	CacheHits       int           `json:"cache_hits"`
+	CacheMisses     int           `json:"cache_misses"`
+	CacheHitRate    float64       `json:"cache_hit_rate"`
+	AverageDuration time.Duration `json:"average_duration"`
+}
+
+// Cold reports whether none of the edge's cache checks hit, as when the
+// edge was never warmed
+func (e EdgeStats) Cold() bool {
+	return e.CacheMisses > 0 && e.CacheHits == 0
+}
+
+// EdgePhase returns the name of the verification pass sent to an edge
+func EdgePhase(edge string) string {
+	return PhaseVerify + ":" + edge
+}
+
+// isVerifyPhase reports whether a phase is the verification pass or one of
+// its per-edge passes
+func isVerifyPhase(name string) bool {
+	return name == PhaseVerify || strings.HasPrefix(name, PhaseVerify+":")
+}
+
+// GetEdgeStats returns the cache hit rate of each edge the verification
+// pass was sent to, in the order the edges were first seen
+func (s *Stats) GetEdgeStats() []EdgeStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var edges []EdgeStats
+	index := make(map[string]int)
+	var durations []time.Duration
+	for _, result := range s.cacheResults {
+		if result.Edge == "" {
+			continue
+		}
+		i, ok := index[result.Edge]
+		if !ok {
+			i = len(edges)
+			index[result.Edge] = i
+			edges = append(edges, EdgeStats{Edge: result.Edge})
+			durations = append(durations, 0)
+		}
+
+		edge := &edges[i]
+		edge.Requests++
+		durations[i] += result.Duration
+		if !result.Success {
+			edge.Errors++
+		}
+		if result.CacheStatus == "" {
+			continue
+		}
+		if IsCacheHit(result.CacheStatus) {
+			edge.CacheHits++
+		} else {
+			edge.CacheMisses++
+		}
+	}
+
+	for i := range edges {
+		edge := &edges[i]
+		edge.AverageDuration = durations[i] / time.Duration(edge.Requests)
+		if checks := edge.CacheHits + edge.CacheMisses; checks > 0 {
+			edge.CacheHitRate = float64(edge.CacheHits) / float64(checks) * 100
+		}
+	}
+	return edges
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetEdgeStats(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.PlanPhases(2, PhaseWarmUp, EdgePhase("lhr"), EdgePhase("iad"))
+	s.StartPhase(PhaseWarmUp)
+	s.AddWarmUpResult(&Result{URL: "https://example.com/", Success: true, CacheStatus: "MISS"})
+	s.EndPhase(PhaseWarmUp)
+
+	s.StartPhase(EdgePhase("lhr"))
+	s.AddCacheResult(&Result{URL: "https://example.com/", Edge: "lhr", Success: true, CacheStatus: "HIT", Duration: 10 * time.Millisecond})
+	s.AddCacheResult(&Result{URL: "https://example.com/about", Edge: "lhr", Success: true, CacheStatus: "MISS", Duration: 30 * time.Millisecond})
+	s.EndPhase(EdgePhase("lhr"))
+
+	s.StartPhase(EdgePhase("iad"))
+	if phase := s.GetProgress().Phase; phase == nil || phase.Name != "verify:iad" || phase.Number != 3 {
+		t.Errorf("Expected the iad pass to be the third, got %+v", phase)
+	}
+	s.AddCacheResult(&Result{URL: "https://example.com/", Edge: "iad", Success: true, CacheStatus: "MISS", Duration: 50 * time.Millisecond})
+	s.AddCacheResult(&Result{URL: "https://example.com/about", Edge: "iad", Error: "timeout", Duration: 70 * time.Millisecond})
+	s.EndPhase(EdgePhase("iad"))
+
+	edges := s.GetEdgeStats()
+	if len(edges) != 2 {
+		t.Fatalf("Expected 2 edges, got %+v", edges)
+	}
+
+	lhr, iad := edges[0], edges[1]
+	expectedLHR := EdgeStats{Edge: "lhr", Requests: 2, CacheHits: 1, CacheMisses: 1, CacheHitRate: 50, AverageDuration: 20 * time.Millisecond}
+	if lhr != expectedLHR {
+		t.Errorf("Expected %+v, got %+v", expectedLHR, lhr)
+	}
+	expectedIAD := EdgeStats{Edge: "iad", Requests: 2, Errors: 1, CacheMisses: 1, AverageDuration: 60 * time.Millisecond}
+	if iad != expectedIAD {
+		t.Errorf("Expected %+v, got %+v", expectedIAD, iad)
+	}
+	if lhr.Cold() || !iad.Cold() {
+		t.Errorf("Expected only iad to be cold")
+	}
+
+	// The global hit rate covers every edge, and the verify time both passes
+	cacheStats := s.GetCacheStats()
+	if cacheStats.CacheHits != 1 || cacheStats.CacheMisses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses overall, got %+v", cacheStats)
+	}
+	var verifyTime time.Duration
+	for _, phase := range s.GetFinalStats().Phases {
+		if phase.Name != PhaseWarmUp {
+			verifyTime += phase.Duration
+		}
+	}
+	if cacheStats.VerifyTime != verifyTime {
+		t.Errorf("Expected verify time %v, got %v", verifyTime, cacheStats.VerifyTime)
+	}
+}
+
+func TestEdgePassesKeepServerTiming(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.StartPhase(PhaseWarmUp)
+	s.AddWarmUpResult(&Result{URL: "https://example.com/", ServerTiming: map[string]time.Duration{"db": time.Second}})
+	s.ResetServerTiming()
+	s.StartPhase(EdgePhase("lhr"))
+	s.AddCacheResult(&Result{URL: "https://example.com/", ServerTiming: map[string]time.Duration{"db": time.Millisecond}})
+	s.StartPhase(EdgePhase("iad"))
+	s.AddCacheResult(&Result{URL: "https://example.com/", ServerTiming: map[string]time.Duration{"db": time.Millisecond}})
+
+	// The warm-up pass's metrics are dropped, and starting the second edge
+	// keeps the first one's
+	metrics := s.GetServerTimingStats()
+	if len(metrics) != 1 || metrics[0].Count != 2 || metrics[0].Max != time.Millisecond {
+		t.Errorf("Expected both edges' metrics only, got %+v", metrics)
+	}
+}
+
+func TestGetEdgeStatsWithoutEdges(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddCacheResult(&Result{URL: "https://example.com/", CacheStatus: "HIT"})
+	if edges := s.GetEdgeStats(); len(edges) != 0 {
+		t.Errorf("Expected no edges, got %+v", edges)
+	}
+}
@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Weights of the cache efficacy score components; they add up to 100
+const (
+	efficacyHitWeight     = 50
+	efficacySpeedupWeight = 30
+	efficacyAgeWeight     = 20
+)
+
+// CacheEfficacy scores how much warming helped one URL, from 0 (no
+// benefit) to 100. The verification response counts half: a cache hit
+// scores full marks, a miss none, and a response without a cache header
+// half. The latency reduction from warm-up to verification counts for 30,
+// and an Age header above zero, showing a cached copy was served, for 20.
+type CacheEfficacy struct {
+	URL            string        `json:"url"`
+	Score          float64       `json:"score"`
+	CacheStatus    string        `json:"cache_status,omitempty"`
+	WarmUpDuration time.Duration `json:"warm_up_duration"`
+	VerifyDuration time.Duration `json:"verify_duration"`
+
+	// Speedup is the fraction of the warm-up latency saved on verification,
+	// negative when verification was slower
+	Speedup float64 `json:"speedup"`
+
+	// Age is the verification response's Age header
+	Age time.Duration `json:"age,omitempty"`
+}
+
+// ParseAge parses an Age header, in seconds. A missing or malformed header
+// is zero.
+func ParseAge(value string) time.Duration {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetCacheEfficacy scores every URL requested in both cache verification
+// passes, least benefited by warming first. URLs that failed in either pass
+// are left out.
+func (s *Stats) GetCacheEfficacy() []CacheEfficacy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	warmUp := make(map[string]*Result, len(s.warmUpResults))
+	for _, result := range s.warmUpResults {
+		warmUp[result.URL] = result
+	}
+
+	var scores []CacheEfficacy
+	for _, verify := range s.cacheResults {
+		warm, ok := warmUp[verify.URL]
+		if !ok || !warm.Success || !verify.Success {
+			continue
+		}
+		scores = append(scores, scoreEfficacy(warm, verify))
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].URL < scores[j].URL
+	})
+	return scores
+}
+
+// scoreEfficacy compares a URL's warm-up and verification results
+func scoreEfficacy(warm, verify *Result) CacheEfficacy {
+	efficacy := CacheEfficacy{
+		URL:            verify.URL,
+		CacheStatus:    verify.CacheStatus,
+		WarmUpDuration: warm.Duration,
+		VerifyDuration: verify.Duration,
+		Age:            verify.Age,
+	}
+	if warm.Duration > 0 {
+		efficacy.Speedup = float64(warm.Duration-verify.Duration) / float64(warm.Duration)
+	}
+
+	hit := 0.5
+	if verify.CacheStatus != "" {
+		hit = 0
+		if IsCacheHit(verify.CacheStatus) {
+			hit = 1
+		}
+	}
+	var aged float64
+	if verify.Age > 0 {
+		aged = 1
+	}
+
+	efficacy.Score = efficacyHitWeight*hit +
+		efficacySpeedupWeight*min(max(efficacy.Speedup, 0), 1) +
+		efficacyAgeWeight*aged
+	return efficacy
+}
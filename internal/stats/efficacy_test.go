@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "", expected: 0},
+		{value: "0", expected: 0},
+		{value: "120", expected: 2 * time.Minute},
+		{value: " 30 ", expected: 30 * time.Second},
+		{value: "-5", expected: 0},
+		{value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			if got := ParseAge(tt.value); got != tt.expected {
+				t.Errorf("Expected %v for %q, got %v", tt.expected, tt.value, got)
+			}
+		})
+	}
+}
+
+func TestGetCacheEfficacy(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	add := func(url string, warm, verify *Result) {
+		warm.URL, verify.URL = url, url
+		warm.Success, verify.Success = true, true
+		s.AddWarmUpResult(warm)
+		s.AddCacheResult(verify)
+	}
+
+	// A hit served from cache in a tenth of the time: the ideal
+	add("/cached", &Result{Duration: time.Second}, &Result{Duration: 100 * time.Millisecond, CacheStatus: "HIT", Age: time.Minute})
+	// Still a miss and no faster: warming was wasted
+	add("/bypassed", &Result{Duration: 200 * time.Millisecond}, &Result{Duration: 300 * time.Millisecond, CacheStatus: "MISS"})
+	// No cache header; half faster
+	add("/unknown", &Result{Duration: time.Second}, &Result{Duration: 500 * time.Millisecond})
+	// Failed on verification, so not scored
+	s.AddWarmUpResult(&Result{URL: "/broken", Success: true, Duration: time.Second})
+	s.AddCacheResult(&Result{URL: "/broken", Success: false, Duration: time.Second})
+
+	scores := s.GetCacheEfficacy()
+	if len(scores) != 3 {
+		t.Fatalf("Expected 3 scored URLs, got %d: %+v", len(scores), scores)
+	}
+
+	expected := []struct {
+		url   string
+		score float64
+	}{
+		{url: "/bypassed", score: 0},
+		{url: "/unknown", score: 40},
+		{url: "/cached", score: 97},
+	}
+	for i, want := range expected {
+		got := scores[i]
+		if got.URL != want.url {
+			t.Errorf("Expected rank %d to be %s, got %s", i+1, want.url, got.URL)
+		}
+		if diff := got.Score - want.score; diff > 0.001 || diff < -0.001 {
+			t.Errorf("Expected %s to score %.1f, got %.3f", want.url, want.score, got.Score)
+		}
+	}
+
+	if scores[0].Speedup >= 0 {
+		t.Errorf("Expected a negative speedup when verification is slower, got %f", scores[0].Speedup)
+	}
+	if scores[2].Age != time.Minute || scores[2].CacheStatus != "HIT" {
+		t.Errorf("Expected the verification Age and cache status, got %+v", scores[2])
+	}
+}
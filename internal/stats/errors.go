@@ -0,0 +1,162 @@
+package stats
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrorCategory classifies why a request failed
+type ErrorCategory string
+
+// Error categories, from the network layer up to HTTP
+const (
+	ErrorDNS               ErrorCategory = "dns"
+	ErrorConnectionRefused ErrorCategory = "connection_refused"
+	ErrorConnectionReset   ErrorCategory = "connection_reset"
+	ErrorTLS               ErrorCategory = "tls"
+	ErrorConnectTimeout    ErrorCategory = "connect_timeout"
+	ErrorTLSTimeout        ErrorCategory = "tls_timeout"
+	ErrorHeaderTimeout     ErrorCategory = "header_timeout"
+	ErrorBodyTimeout       ErrorCategory = "body_timeout"
+	ErrorTimeout           ErrorCategory = "timeout"
+	ErrorProtocol          ErrorCategory = "protocol"
+	ErrorHTTPStatus        ErrorCategory = "http_status"
+	ErrorOther             ErrorCategory = "other"
+)
+
+// ErrorCategories lists every category in reporting order
+func ErrorCategories() []ErrorCategory {
+	return []ErrorCategory{
+		ErrorDNS,
+		ErrorConnectionRefused,
+		ErrorConnectionReset,
+		ErrorTLS,
+		ErrorConnectTimeout,
+		ErrorTLSTimeout,
+		ErrorHeaderTimeout,
+		ErrorBodyTimeout,
+		ErrorTimeout,
+		ErrorProtocol,
+		ErrorHTTPStatus,
+		ErrorOther,
+	}
+}
+
+// IsTimeout reports whether the category is a timeout, of any phase
+func (c ErrorCategory) IsTimeout() bool {
+	switch c {
+	case ErrorConnectTimeout, ErrorTLSTimeout, ErrorHeaderTimeout, ErrorBodyTimeout, ErrorTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// ClassifyError returns the category of a request error. Failures with a
+// response use ErrorHTTPStatus instead. Timeouts are classified by the phase
+// that timed out when the transport's limit for that phase fired; the
+// overall request timeout is ErrorTimeout.
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	// DNS lookups can also time out; the lookup is the more useful answer
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorDNS
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorConnectionRefused
+	}
+
+	if category := phaseTimeout(err); category != "" {
+		return category
+	}
+
+	if isTLSError(err) {
+		return ErrorTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorConnectionReset
+	}
+
+	if isProtocolError(err) {
+		return ErrorProtocol
+	}
+
+	return ErrorOther
+}
+
+// phaseTimeout returns the category of a connect, TLS handshake or
+// response header timeout, or an empty category for other errors. net/http
+// does not export types for the latter two, so the messages are matched.
+func phaseTimeout(err error) ErrorCategory {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return ErrorConnectTimeout
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "TLS handshake timeout"):
+		return ErrorTLSTimeout
+	case strings.Contains(message, "timeout awaiting response headers"):
+		return ErrorHeaderTimeout
+	default:
+		return ""
+	}
+}
+
+// isTLSError reports whether err came from the TLS handshake or certificate
+// verification
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &recordErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ")
+}
+
+// isProtocolError reports whether the server's response could not be
+// understood. net/http does not export types for these, so the messages are
+// matched.
+func isProtocolError(err error) bool {
+	message := err.Error()
+	for _, marker := range []string{
+		"malformed HTTP",
+		"http2: ",
+		"stopped after",
+		"unexpected EOF reading trailer",
+		"HTTP/1.x transport connection broken",
+	} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requestError performs a GET and returns the client error
+func requestError(t *testing.T, client *http.Client, url string) error {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatalf("Expected request to %s to fail", url)
+	}
+	return err
+}
+
+// rawServer accepts connections and hands each one to handle
+func rawServer(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			handle(conn)
+		}
+	}()
+	return "http://" + listener.Addr().String()
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedURL := "http://" + closed.Addr().String()
+	_ = closed.Close()
+
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(tlsServer.Close)
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(slowServer.Close)
+
+	garbageURL := rawServer(t, func(conn net.Conn) {
+		_, _ = fmt.Fprint(conn, "NOT HTTP AT ALL\r\n\r\n")
+		_ = conn.Close()
+	})
+	hangupURL := rawServer(t, func(conn net.Conn) {
+		_ = conn.Close()
+	})
+	// The silent server accepts connections and never writes; the client
+	// closes them when it gives up
+	silentURL := rawServer(t, func(net.Conn) {})
+
+	tests := []struct {
+		name     string
+		err      func() error
+		expected ErrorCategory
+	}{
+		{name: "nil", err: func() error { return nil }, expected: ""},
+		{
+			name:     "dns",
+			err:      func() error { return &net.DNSError{Err: "no such host", Name: "missing.invalid", IsNotFound: true} },
+			expected: ErrorDNS,
+		},
+		{
+			name:     "connection refused",
+			err:      func() error { return requestError(t, client, closedURL) },
+			expected: ErrorConnectionRefused,
+		},
+		{
+			name:     "untrusted certificate",
+			err:      func() error { return requestError(t, client, tlsServer.URL) },
+			expected: ErrorTLS,
+		},
+		{
+			name: "timeout",
+			err: func() error {
+				return requestError(t, &http.Client{Timeout: 50 * time.Millisecond}, slowServer.URL)
+			},
+			expected: ErrorTimeout,
+		},
+		{
+			name: "connect timeout",
+			err: func() error {
+				return fmt.Errorf("wrapped: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded})
+			},
+			expected: ErrorConnectTimeout,
+		},
+		{
+			name: "TLS handshake timeout",
+			err: func() error {
+				transport := &http.Transport{TLSHandshakeTimeout: 50 * time.Millisecond}
+				return requestError(t, &http.Client{Timeout: 2 * time.Second, Transport: transport}, strings.Replace(silentURL, "http://", "https://", 1))
+			},
+			expected: ErrorTLSTimeout,
+		},
+		{
+			name: "response header timeout",
+			err: func() error {
+				transport := &http.Transport{ResponseHeaderTimeout: 50 * time.Millisecond}
+				return requestError(t, &http.Client{Timeout: 2 * time.Second, Transport: transport}, slowServer.URL)
+			},
+			expected: ErrorHeaderTimeout,
+		},
+		{
+			name:     "context deadline",
+			err:      func() error { return fmt.Errorf("wrapped: %w", context.DeadlineExceeded) },
+			expected: ErrorTimeout,
+		},
+		{
+			name:     "malformed response",
+			err:      func() error { return requestError(t, client, garbageURL) },
+			expected: ErrorProtocol,
+		},
+		{
+			name:     "connection closed without response",
+			err:      func() error { return requestError(t, client, hangupURL) },
+			expected: ErrorConnectionReset,
+		},
+		{
+			name:     "unknown",
+			err:      func() error { return errors.New("something else") },
+			expected: ErrorOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.err()
+			if got := ClassifyError(err); got != tt.expected {
+				t.Errorf("Expected %q for %v, got %q", tt.expected, err, got)
+			}
+		})
+	}
+}
+
+func TestErrorsByCategory(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddResult(&Result{URL: "https://example.com/1", Success: true})
+	s.AddResult(&Result{URL: "https://example.com/2", StatusCode: 503, ErrorCategory: ErrorHTTPStatus})
+	s.AddResult(&Result{URL: "https://example.com/3", StatusCode: 404, ErrorCategory: ErrorHTTPStatus})
+	s.AddResult(&Result{URL: "https://example.com/4", ErrorCategory: ErrorTimeout})
+	s.AddResult(&Result{URL: "https://example.com/5", Error: "unclassified"})
+
+	got := s.GetFinalStats().ErrorsByCategory
+	expected := map[ErrorCategory]int{ErrorHTTPStatus: 2, ErrorTimeout: 1, ErrorOther: 1}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for category, count := range expected {
+		if got[category] != count {
+			t.Errorf("Expected %d %s errors, got %d", count, category, got[category])
+		}
+	}
+
+	s.Reset()
+	if got := s.GetFinalStats().ErrorsByCategory; got != nil {
+		t.Errorf("Expected Reset to clear error categories, got %v", got)
+	}
+}
+
+func TestErrorCategoryIsTimeout(t *testing.T) {
+	t.Parallel()
+
+	timeouts := map[ErrorCategory]bool{
+		ErrorConnectTimeout: true,
+		ErrorTLSTimeout:     true,
+		ErrorHeaderTimeout:  true,
+		ErrorBodyTimeout:    true,
+		ErrorTimeout:        true,
+	}
+	for _, category := range ErrorCategories() {
+		if got := category.IsTimeout(); got != timeouts[category] {
+			t.Errorf("Expected IsTimeout %v for %q, got %v", timeouts[category], category, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+// HeaderDiff is a response header whose value differed between the passes;
+// an empty value means the response did not carry the header
+type HeaderDiff struct {
+	Name   string `json:"name"`
+	WarmUp string `json:"warm_up"`
+	Verify string `json:"verify"`
+}
+
+// HeaderChange is a URL whose compared response headers differed between
+// the warm-up and verification requests. Headers such as Vary or
+// Cache-Control that change from one response to the next usually mean the
+// requests reached different backends, such as both arms of an A/B test.
+type HeaderChange struct {
+	URL     string       `json:"url"`
+	Headers []HeaderDiff `json:"headers"`
+}
+
+// GetHeaderChanges compares the recorded headers of each URL's warm-up and
+// verification responses, sorted by URL. URLs whose two responses had
+// different status codes are left out, since their headers are expected to
+// differ.
+func (s *Stats) GetHeaderChanges() []HeaderChange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.headerChangesLocked()
+}
+
+func (s *Stats) headerChangesLocked() []HeaderChange {
+	warmUp := make(map[string]*Result, len(s.warmUpResults))
+	for _, result := range s.warmUpResults {
+		warmUp[result.URL] = result
+	}
+
+	var changes []HeaderChange
+	for _, verify := range s.cacheResults {
+		warm, ok := warmUp[verify.URL]
+		if !ok || warm.Headers == nil || verify.Headers == nil || warm.StatusCode != verify.StatusCode {
+			continue
+		}
+
+		names := slices.Sorted(maps.Keys(warm.Headers))
+		for name := range verify.Headers {
+			if _, ok := warm.Headers[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		change := HeaderChange{URL: verify.URL}
+		for _, name := range names {
+			if warm.Headers[name] != verify.Headers[name] {
+				change.Headers = append(change.Headers, HeaderDiff{Name: name, WarmUp: warm.Headers[name], Verify: verify.Headers[name]})
+			}
+		}
+		if len(change.Headers) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].URL < changes[j].URL
+	})
+	return changes
+}
+
+// CountHeaderChanges counts the URLs on which each header changed
+func CountHeaderChanges(changes []HeaderChange) map[string]int {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		for _, diff := range change.Headers {
+			counts[diff.Name]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetHeaderChanges(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddWarmUpResult(&Result{URL: "/stable", StatusCode: 200, Headers: map[string]string{"Vary": "Accept-Encoding"}})
+	s.AddWarmUpResult(&Result{URL: "/vary", StatusCode: 200, Headers: map[string]string{"Vary": "Accept-Encoding", "Cache-Control": "max-age=60"}})
+	s.AddWarmUpResult(&Result{URL: "/added", StatusCode: 200, Headers: map[string]string{}})
+	s.AddWarmUpResult(&Result{URL: "/status", StatusCode: 200, Headers: map[string]string{"Cache-Control": "max-age=60"}})
+	s.AddWarmUpResult(&Result{URL: "/unrecorded", StatusCode: 200})
+
+	s.AddCacheResult(&Result{URL: "/vary", StatusCode: 200, Headers: map[string]string{"Vary": "Accept-Encoding, Cookie", "Cache-Control": "max-age=60"}})
+	s.AddCacheResult(&Result{URL: "/stable", StatusCode: 200, Headers: map[string]string{"Vary": "Accept-Encoding"}})
+	s.AddCacheResult(&Result{URL: "/added", StatusCode: 200, Headers: map[string]string{"Cache-Control": "private"}})
+	s.AddCacheResult(&Result{URL: "/status", StatusCode: 503, Headers: map[string]string{"Cache-Control": "no-store"}})
+	s.AddCacheResult(&Result{URL: "/unrecorded", StatusCode: 200, Headers: map[string]string{"Vary": "Cookie"}})
+	s.AddCacheResult(&Result{URL: "/verify-only", StatusCode: 200, Headers: map[string]string{"Vary": "Cookie"}})
+
+	expected := []HeaderChange{
+		{URL: "/added", Headers: []HeaderDiff{{Name: "Cache-Control", Verify: "private"}}},
+		{URL: "/vary", Headers: []HeaderDiff{{Name: "Vary", WarmUp: "Accept-Encoding", Verify: "Accept-Encoding, Cookie"}}},
+	}
+	result := s.GetHeaderChanges()
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, result)
+	}
+	if counts := CountHeaderChanges(result); counts["Vary"] != 1 || counts["Cache-Control"] != 1 {
+		t.Errorf("Expected one change of each header, got %v", counts)
+	}
+	if cacheStats := s.GetCacheStats(); cacheStats.HeaderChanges != 2 {
+		t.Errorf("Expected 2 header changes in cache stats, got %d", cacheStats.HeaderChanges)
+	}
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+)
+
+// HostStats represents request counts and latency percentiles for one host
+type HostStats struct {
+	Host     string        `json:"host"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+// hostAccumulator keeps every duration seen for a host so that exact
+// percentiles can be computed
+type hostAccumulator struct {
+	durations []time.Duration
+	errors    int
+}
+
+// addHostLocked records a result under its URL's host
+func (s *Stats) addHostLocked(result *Result) {
+	host := "unknown"
+	if parsed, err := url.Parse(result.URL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+
+	if s.hosts == nil {
+		s.hosts = make(map[string]*hostAccumulator)
+	}
+	accumulator, ok := s.hosts[host]
+	if !ok {
+		accumulator = &hostAccumulator{}
+		s.hosts[host] = accumulator
+	}
+
+	accumulator.durations = append(accumulator.durations, result.Duration)
+	if !result.Success {
+		accumulator.errors++
+	}
+}
+
+// GetHostStats returns latency percentiles per host, sorted by host
+func (s *Stats) GetHostStats() []HostStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.hostStatsLocked()
+}
+
+func (s *Stats) hostStatsLocked() []HostStats {
+	hostStats := make([]HostStats, 0, len(s.hosts))
+	for host, accumulator := range s.hosts {
+		sorted := slices.Clone(accumulator.durations)
+		slices.Sort(sorted)
+
+		hostStats = append(hostStats, HostStats{
+			Host:     host,
+			Requests: len(sorted),
+			Errors:   accumulator.errors,
+			P50:      Percentile(sorted, 50),
+			P90:      Percentile(sorted, 90),
+			P95:      Percentile(sorted, 95),
+			P99:      Percentile(sorted, 99),
+			Max:      sorted[len(sorted)-1],
+		})
+	}
+
+	sort.Slice(hostStats, func(i, j int) bool {
+		return hostStats[i].Host < hostStats[j].Host
+	})
+	return hostStats
+}
+
+// Percentile returns the nearest-rank percentile of sorted durations
+func Percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
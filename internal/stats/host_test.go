@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p        int
+		expected time.Duration
+	}{
+		{p: 50, expected: 50 * time.Millisecond},
+		{p: 90, expected: 90 * time.Millisecond},
+		{p: 99, expected: 99 * time.Millisecond},
+		{p: 100, expected: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("p%d", tt.p), func(t *testing.T) {
+			t.Parallel()
+			if got := Percentile(sorted, tt.p); got != tt.expected {
+				t.Errorf("Expected p%d %v, got %v", tt.p, tt.expected, got)
+			}
+		})
+	}
+
+	if got := Percentile([]time.Duration{7 * time.Millisecond}, 50); got != 7*time.Millisecond {
+		t.Errorf("Expected single value percentile 7ms, got %v", got)
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Expected empty percentile 0, got %v", got)
+	}
+}
+
+func TestGetHostStats(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	for i := 1; i <= 10; i++ {
+		s.AddResult(&Result{
+			URL:      fmt.Sprintf("https://assets.example.com/%d.css", i),
+			Success:  true,
+			Duration: time.Duration(i) * time.Millisecond,
+		})
+		s.AddResult(&Result{
+			URL:      fmt.Sprintf("https://www.example.com/page/%d", i),
+			Success:  i != 10,
+			Duration: time.Duration(i) * 100 * time.Millisecond,
+		})
+	}
+
+	hosts := s.GetHostStats()
+	if len(hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(hosts))
+	}
+
+	assets, www := hosts[0], hosts[1]
+	if assets.Host != "assets.example.com" || www.Host != "www.example.com" {
+		t.Fatalf("Expected hosts sorted by name, got %s and %s", assets.Host, www.Host)
+	}
+	if assets.Requests != 10 || assets.Errors != 0 {
+		t.Errorf("Expected 10 requests and 0 errors for assets, got %+v", assets)
+	}
+	if assets.P50 != 5*time.Millisecond || assets.P90 != 9*time.Millisecond || assets.Max != 10*time.Millisecond {
+		t.Errorf("Unexpected assets percentiles: %+v", assets)
+	}
+	if www.Errors != 1 {
+		t.Errorf("Expected 1 error for www, got %d", www.Errors)
+	}
+	if www.P50 != 500*time.Millisecond || www.P99 != time.Second {
+		t.Errorf("Unexpected www percentiles: %+v", www)
+	}
+
+	if got := s.GetFinalStats().Hosts; len(got) != 2 {
+		t.Errorf("Expected final stats to include 2 hosts, got %d", len(got))
+	}
+
+	s.Reset()
+	if got := s.GetHostStats(); len(got) != 0 {
+		t.Errorf("Expected Reset to clear host stats, got %v", got)
+	}
+}
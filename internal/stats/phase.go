@@ -0,0 +1,202 @@
+package stats
+
+import (
+	"slices"
+	"time"
+)
+
+// Phase names used by the crawler's multi-pass modes
+const (
+	PhaseWarmUp = "warm-up"
+	PhaseVerify = "verify"
+)
+
+// PhaseStats reports how long a named crawl phase took
+type PhaseStats struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+
+	// Running is true for a phase that has started but not ended; its
+	// Duration is the time elapsed so far
+	Running bool `json:"running,omitempty"`
+}
+
+// PhaseProgress is the progress of the running pass of a multi-pass crawl,
+// counted against that pass's URLs rather than the whole crawl's
+type PhaseProgress struct {
+	Name              string        `json:"name"`
+	Number            int           `json:"number"`
+	Count             int           `json:"count"`
+	Processed         int           `json:"processed"`
+	Total             int           `json:"total"`
+	Percentage        float64       `json:"percentage"`
+	EstimatedTimeLeft time.Duration `json:"estimated_time_left"`
+}
+
+// phase records the start and end of a named crawl phase
+type phase struct {
+	name  string
+	start time.Time
+	end   time.Time
+
+	// processed counts the results added while the phase was running, and
+	// span the time they cover
+	processed int
+	span      span
+
+	// pausedAtStart is how long the crawl had paused when the phase started
+	pausedAtStart time.Duration
+}
+
+// PlanPhases declares the passes of a multi-pass crawl, in order, each
+// requesting perPhase URLs. It sets the total to every pass's URLs, and
+// progress then also reports the running pass on its own.
+func (s *Stats) PlanPhases(perPhase int, names ...string) {
+	throttle := s.currentThrottle()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalURLs = perPhase * len(names)
+	s.startTime = time.Now()
+	s.pausedAtStart = throttle.Paused
+	s.plannedPhases = names
+	s.phaseTotal = perPhase
+}
+
+// AdjustTotalURLs changes how many URLs the running pass requests by delta,
+// as when a refreshed sitemap adds or drops URLs. The planned passes still
+// to come request the same URLs, so their totals change too.
+func (s *Stats) AdjustTotalURLs(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	passes := 1
+	if s.current != nil {
+		if i := slices.Index(s.plannedPhases, s.current.name); i >= 0 {
+			passes = len(s.plannedPhases) - i
+			s.phaseTotal += delta
+		}
+	}
+	s.totalURLs += delta * passes
+}
+
+// duration returns the phase length, measuring running phases up to now
+func (p *phase) duration(now time.Time) time.Duration {
+	if p.end.IsZero() {
+		return now.Sub(p.start)
+	}
+	return p.end.Sub(p.start)
+}
+
+// StartPhase marks the beginning of a named phase. Starting a phase again
+// restarts its timing.
+func (s *Stats) StartPhase(name string) {
+	throttle := s.currentThrottle()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.phaseLocked(name); p != nil {
+		p.start = time.Now()
+		p.end = time.Time{}
+		p.processed = 0
+		p.span = span{}
+		p.pausedAtStart = throttle.Paused
+		s.current = p
+		return
+	}
+	s.current = &phase{name: name, start: time.Now(), pausedAtStart: throttle.Paused}
+	s.phases = append(s.phases, s.current)
+}
+
+// EndPhase marks the end of a named phase. Ending a phase that was never
+// started, or has already ended, has no effect.
+func (s *Stats) EndPhase(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.phaseLocked(name); p != nil && p.end.IsZero() {
+		p.end = time.Now()
+		if s.current == p {
+			s.current = nil
+		}
+	}
+}
+
+// phaseProgressLocked returns the progress of the running planned phase, or
+// nil when no planned phase is running
+func (s *Stats) phaseProgressLocked(throttle Throttle, now time.Time) *PhaseProgress {
+	if s.current == nil {
+		return nil
+	}
+
+	number := slices.Index(s.plannedPhases, s.current.name) + 1
+	if number == 0 {
+		return nil
+	}
+
+	progress := &PhaseProgress{
+		Name:      s.current.name,
+		Number:    number,
+		Count:     len(s.plannedPhases),
+		Processed: s.current.processed,
+		Total:     s.phaseTotal,
+	}
+	if s.phaseTotal > 0 {
+		progress.Percentage = float64(s.current.processed) / float64(s.phaseTotal) * 100
+	}
+
+	active := activeTime(s.current.span, s.current.start, now, throttle.Paused-s.current.pausedAtStart)
+	progress.EstimatedTimeLeft = estimateTimeLeft(s.phaseTotal-s.current.processed, s.current.processed, active, throttle, now).Round(time.Second)
+	return progress
+}
+
+// phasesLocked returns the phases in the order they were first started, or
+// nil when none was
+func (s *Stats) phasesLocked() []PhaseStats {
+	if len(s.phases) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	phases := make([]PhaseStats, 0, len(s.phases))
+	for _, p := range s.phases {
+		phases = append(phases, PhaseStats{
+			Name:     p.name,
+			Duration: p.duration(now),
+			Running:  p.end.IsZero(),
+		})
+	}
+	return phases
+}
+
+// phaseDurationLocked returns the duration of a completed phase, or zero if
+// it has not completed
+func (s *Stats) phaseDurationLocked(name string) time.Duration {
+	p := s.phaseLocked(name)
+	if p == nil || p.end.IsZero() {
+		return 0
+	}
+	return p.end.Sub(p.start)
+}
+
+// verifyDurationLocked returns the total duration of the completed
+// verification passes: the one pass, or one per edge
+func (s *Stats) verifyDurationLocked() time.Duration {
+	var total time.Duration
+	for _, p := range s.phases {
+		if isVerifyPhase(p.name) && !p.end.IsZero() {
+			total += p.end.Sub(p.start)
+		}
+	}
+	return total
+}
+
+func (s *Stats) phaseLocked(name string) *phase {
+	for _, p := range s.phases {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.EndPhase("never-started")
+
+	s.StartPhase("first")
+	time.Sleep(2 * time.Millisecond)
+	s.EndPhase("first")
+	s.StartPhase("second")
+
+	phases := s.GetFinalStats().Phases
+	if len(phases) != 2 {
+		t.Fatalf("Expected 2 phases, got %d", len(phases))
+	}
+	if phases[0].Name != "first" || phases[1].Name != "second" {
+		t.Errorf("Expected phases in start order, got %v", phases)
+	}
+	if phases[0].Running || phases[0].Duration < 2*time.Millisecond {
+		t.Errorf("Expected completed first phase of at least 2ms, got %+v", phases[0])
+	}
+	if !phases[1].Running {
+		t.Errorf("Expected second phase to be running, got %+v", phases[1])
+	}
+
+	// Ending twice keeps the first end time
+	first := phases[0].Duration
+	time.Sleep(2 * time.Millisecond)
+	s.EndPhase("first")
+	if got := s.GetFinalStats().Phases[0].Duration; got != first {
+		t.Errorf("Expected ending a phase again to have no effect, got %v want %v", got, first)
+	}
+
+	// Restarting a phase resets its timing without reordering
+	s.StartPhase("first")
+	phases = s.GetFinalStats().Phases
+	if len(phases) != 2 || phases[0].Name != "first" || !phases[0].Running {
+		t.Errorf("Expected restarted first phase to be running in place, got %v", phases)
+	}
+}
+
+func TestCacheStatsUseCompletedPhases(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.StartPhase(PhaseWarmUp)
+
+	if got := s.GetCacheStats().WarmUpTime; got != 0 {
+		t.Errorf("Expected no warm-up time while the phase runs, got %v", got)
+	}
+
+	s.EndPhase(PhaseWarmUp)
+	if got := s.GetCacheStats().WarmUpTime; got <= 0 {
+		t.Errorf("Expected positive warm-up time, got %v", got)
+	}
+
+	s.Reset()
+	if phases := s.GetFinalStats().Phases; len(phases) != 0 {
+		t.Errorf("Expected Reset to clear phases, got %v", phases)
+	}
+}
+
+func TestPhaseProgress(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.PlanPhases(4, PhaseWarmUp, PhaseVerify)
+	if total := s.GetProgress().Total; total != 8 {
+		t.Errorf("Expected a total of both passes' URLs, got %d", total)
+	}
+	if phase := s.GetProgress().Phase; phase != nil {
+		t.Errorf("Expected no phase progress before a pass starts, got %+v", phase)
+	}
+
+	s.StartPhase(PhaseWarmUp)
+	for range 4 {
+		s.AddWarmUpResult(&Result{Success: true})
+	}
+	s.EndPhase(PhaseWarmUp)
+
+	s.StartPhase(PhaseVerify)
+	s.AddCacheResult(&Result{Success: true})
+
+	progress := s.GetProgress()
+	if progress.Processed != 5 || progress.Percentage != 62.5 {
+		t.Errorf("Expected 5 of 8 processed overall, got %d (%.1f%%)", progress.Processed, progress.Percentage)
+	}
+	want := PhaseProgress{Name: PhaseVerify, Number: 2, Count: 2, Processed: 1, Total: 4, Percentage: 25}
+	if phase := progress.Phase; phase == nil || phase.Name != want.Name || phase.Number != want.Number ||
+		phase.Count != want.Count || phase.Processed != want.Processed || phase.Total != want.Total || phase.Percentage != want.Percentage {
+		t.Errorf("Expected verify phase progress %+v, got %+v", want, phase)
+	}
+
+	// Unplanned phases, such as those of a single-pass crawl, have no
+	// progress of their own
+	s.EndPhase(PhaseVerify)
+	s.StartPhase("other")
+	if phase := s.GetProgress().Phase; phase != nil {
+		t.Errorf("Expected no progress for an unplanned phase, got %+v", phase)
+	}
+
+	s.Reset()
+	s.StartPhase(PhaseWarmUp)
+	if phase := s.GetProgress().Phase; phase != nil {
+		t.Errorf("Expected Reset to clear the planned phases, got %+v", phase)
+	}
+}
+
+func TestAdjustTotalURLs(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.SetTotalURLs(4)
+	s.AdjustTotalURLs(2)
+	if total := s.GetProgress().Total; total != 6 {
+		t.Errorf("Expected a total of 6, got %d", total)
+	}
+
+	// A change during the first of two planned passes applies to both
+	s = New()
+	s.PlanPhases(4, PhaseWarmUp, PhaseVerify)
+	s.StartPhase(PhaseWarmUp)
+	s.AdjustTotalURLs(-1)
+	progress := s.GetProgress()
+	if progress.Total != 6 || progress.Phase == nil || progress.Phase.Total != 3 {
+		t.Errorf("Expected totals of 6 overall and 3 per pass, got %d and %+v", progress.Total, progress.Phase)
+	}
+
+	s.EndPhase(PhaseWarmUp)
+	s.StartPhase(PhaseVerify)
+	s.AdjustTotalURLs(1)
+	progress = s.GetProgress()
+	if progress.Total != 7 || progress.Phase.Total != 4 {
+		t.Errorf("Expected totals of 7 overall and 4 for the last pass, got %d and %+v", progress.Total, progress.Phase)
+	}
+}
@@ -0,0 +1,157 @@
+package stats
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTimingStats represents the aggregated durations of one Server-Timing
+// metric for one URL class
+type ServerTimingStats struct {
+	Class   string        `json:"class"`
+	Metric  string        `json:"metric"`
+	Count   int           `json:"count"`
+	Average time.Duration `json:"average"`
+	Max     time.Duration `json:"max"`
+}
+
+// timingAccumulator sums the durations of a single metric
+type timingAccumulator struct {
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+// ParseServerTiming parses Server-Timing header values into metric durations.
+// Metrics without a dur parameter are skipped; repeated metrics are summed.
+func ParseServerTiming(values []string) map[string]time.Duration {
+	metrics := make(map[string]time.Duration)
+	for _, value := range values {
+		for _, entry := range splitOutsideQuotes(value, ',') {
+			params := splitOutsideQuotes(entry, ';')
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+			if name == "" {
+				continue
+			}
+
+			for _, param := range params[1:] {
+				key, raw, found := strings.Cut(param, "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "dur") {
+					continue
+				}
+				milliseconds, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(raw), `"`), 64)
+				if err != nil || milliseconds < 0 {
+					break
+				}
+				metrics[name] += time.Duration(milliseconds * float64(time.Millisecond))
+				break
+			}
+		}
+	}
+	return metrics
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside quoted strings
+func splitOutsideQuotes(s string, sep rune) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(parts, current.String())
+}
+
+// URLClass groups a URL by its first path segment, e.g. "/products" for
+// https://example.com/products/123. The site root is "/".
+func URLClass(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "/"
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(parsed.Path, "/"), "/")
+	return "/" + segment
+}
+
+// addServerTimingLocked aggregates the Server-Timing metrics of a result
+func (s *Stats) addServerTimingLocked(result *Result) {
+	if len(result.ServerTiming) == 0 {
+		return
+	}
+
+	if s.serverTiming == nil {
+		s.serverTiming = make(map[string]map[string]*timingAccumulator)
+	}
+
+	class := URLClass(result.URL)
+	metrics, ok := s.serverTiming[class]
+	if !ok {
+		metrics = make(map[string]*timingAccumulator)
+		s.serverTiming[class] = metrics
+	}
+
+	for name, duration := range result.ServerTiming {
+		accumulator, ok := metrics[name]
+		if !ok {
+			accumulator = &timingAccumulator{}
+			metrics[name] = accumulator
+		}
+		accumulator.count++
+		accumulator.total += duration
+		if duration > accumulator.max {
+			accumulator.max = duration
+		}
+	}
+}
+
+// ResetServerTiming discards the Server-Timing metrics recorded so far
+func (s *Stats) ResetServerTiming() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverTiming = nil
+}
+
+// GetServerTimingStats returns Server-Timing metrics aggregated per URL
+// class, sorted by class and metric name
+func (s *Stats) GetServerTimingStats() []ServerTimingStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timingStats := make([]ServerTimingStats, 0)
+	for class, metrics := range s.serverTiming {
+		for name, accumulator := range metrics {
+			timingStats = append(timingStats, ServerTimingStats{
+				Class:   class,
+				Metric:  name,
+				Count:   accumulator.count,
+				Average: accumulator.total / time.Duration(accumulator.count),
+				Max:     accumulator.max,
+			})
+		}
+	}
+
+	sort.Slice(timingStats, func(i, j int) bool {
+		if timingStats[i].Class != timingStats[j].Class {
+			return timingStats[i].Class < timingStats[j].Class
+		}
+		return timingStats[i].Metric < timingStats[j].Metric
+	})
+	return timingStats
+}
@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		values   []string
+		expected map[string]time.Duration
+	}{
+		{
+			name:     "no header",
+			values:   nil,
+			expected: map[string]time.Duration{},
+		},
+		{
+			name:   "multiple metrics in one header",
+			values: []string{"cdn-cache;desc=HIT, edge;dur=4, origin;dur=120.5;desc=\"Origin, total\""},
+			expected: map[string]time.Duration{
+				"edge":   4 * time.Millisecond,
+				"origin": 120500 * time.Microsecond,
+			},
+		},
+		{
+			name:   "multiple headers and case-insensitive names",
+			values: []string{"DB;dur=30", "db;dur=10", "app;dur=\"5\""},
+			expected: map[string]time.Duration{
+				"db":  40 * time.Millisecond,
+				"app": 5 * time.Millisecond,
+			},
+		},
+		{
+			name:     "invalid duration is skipped",
+			values:   []string{"origin;dur=fast, cache;dur=-1"},
+			expected: map[string]time.Duration{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := ParseServerTiming(tt.values)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestURLClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://example.com/", expected: "/"},
+		{url: "https://example.com", expected: "/"},
+		{url: "https://example.com/products/123", expected: "/products"},
+		{url: "https://example.com/about?ref=nav", expected: "/about"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+			if class := URLClass(tt.url); class != tt.expected {
+				t.Errorf("Expected class %s, got %s", tt.expected, class)
+			}
+		})
+	}
+}
+
+func TestGetServerTimingStats(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddResult(&Result{URL: "https://example.com/products/1", Success: true,
+		ServerTiming: map[string]time.Duration{"origin": 100 * time.Millisecond, "cdn-cache": time.Millisecond}})
+	s.AddResult(&Result{URL: "https://example.com/products/2", Success: true,
+		ServerTiming: map[string]time.Duration{"origin": 300 * time.Millisecond}})
+	s.AddResult(&Result{URL: "https://example.com/blog/post", Success: true,
+		ServerTiming: map[string]time.Duration{"origin": 50 * time.Millisecond}})
+	s.AddResult(&Result{URL: "https://example.com/no-timing", Success: true})
+
+	expected := []ServerTimingStats{
+		{Class: "/blog", Metric: "origin", Count: 1, Average: 50 * time.Millisecond, Max: 50 * time.Millisecond},
+		{Class: "/products", Metric: "cdn-cache", Count: 1, Average: time.Millisecond, Max: time.Millisecond},
+		{Class: "/products", Metric: "origin", Count: 2, Average: 200 * time.Millisecond, Max: 300 * time.Millisecond},
+	}
+
+	if result := s.GetServerTimingStats(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	s.Reset()
+	if result := s.GetServerTimingStats(); len(result) != 0 {
+		t.Errorf("Expected no server timing stats after reset, got %+v", result)
+	}
+}
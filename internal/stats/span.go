@@ -0,0 +1,53 @@
+package stats
+
+import "time"
+
+// span is the time covered by the timestamped results added to a pass
+type span struct {
+	first time.Time
+	last  time.Time
+}
+
+// add widens the span to cover a result. Results without an end time are
+// ignored; one without a start time is taken to have started its Duration
+// before it ended.
+func (sp *span) add(result *Result) {
+	if result.End.IsZero() {
+		return
+	}
+	start := result.Start
+	if start.IsZero() {
+		start = result.End.Add(-result.Duration)
+	}
+	if sp.first.IsZero() || start.Before(sp.first) {
+		sp.first = start
+	}
+	if result.End.After(sp.last) {
+		sp.last = result.End
+	}
+}
+
+// elapsed returns the time since started. Results stamped before started
+// were not crawled against this clock, as replayed results and those of
+// other shards were not, so the time they cover is returned instead.
+func (sp span) elapsed(started, now time.Time) time.Duration {
+	if sp.replayed(started) {
+		return sp.last.Sub(sp.first)
+	}
+	return now.Sub(started)
+}
+
+// replayed reports whether results were stamped before started, so that the
+// span rather than the live clock measures the time they took
+func (sp span) replayed(started time.Time) bool {
+	return !sp.first.IsZero() && (started.IsZero() || sp.first.Before(started))
+}
+
+// completedAt returns when a result completed: its end time, or now if it
+// has none
+func completedAt(result *Result, now time.Time) time.Time {
+	if result.End.IsZero() {
+		return now
+	}
+	return result.End
+}
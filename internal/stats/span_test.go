@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+// stamped returns results crawled one after another from start, each taking
+// a second
+func stamped(start time.Time, count int) []*Result {
+	results := make([]*Result, count)
+	for i := range results {
+		begin := start.Add(time.Duration(i) * time.Second)
+		results[i] = &Result{Success: true, Duration: time.Second, Start: begin, End: begin.Add(time.Second)}
+	}
+	return results
+}
+
+func TestProgressFromResultTimestamps(t *testing.T) {
+	t.Parallel()
+
+	// Results recorded an hour ago are timed as they were crawled, not by
+	// when they were added
+	recorded := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	s := New()
+	s.SetTotalURLs(20)
+	for _, result := range stamped(recorded, 10) {
+		s.AddResult(result)
+	}
+
+	progress := s.GetProgress()
+	if progress.ElapsedTime != 10*time.Second {
+		t.Errorf("Expected 10s elapsed, got %v", progress.ElapsedTime)
+	}
+	if progress.RequestsPerSecond != 1 {
+		t.Errorf("Expected 1 request per second, got %.2f", progress.RequestsPerSecond)
+	}
+	if progress.EstimatedTimeLeft != 10*time.Second {
+		t.Errorf("Expected 10s left, got %v", progress.EstimatedTimeLeft)
+	}
+
+	timeline := s.GetTimeline()
+	if len(timeline) == 0 || !timeline[0].Start.Equal(recorded.Add(time.Second).Truncate(TimeBucketSize)) {
+		t.Errorf("Expected the timeline to start at the recorded minute, got %+v", timeline)
+	}
+
+	// Stats that never started timing, as when aggregating shards, are
+	// timed by their results alone
+	s = New()
+	for _, result := range stamped(recorded, 5) {
+		s.AddResult(result)
+	}
+	if elapsed := s.GetProgress().ElapsedTime; elapsed != 5*time.Second {
+		t.Errorf("Expected 5s elapsed without a start time, got %v", elapsed)
+	}
+}
+
+func TestProgressFromLiveTimestamps(t *testing.T) {
+	t.Parallel()
+
+	// Results stamped after timing started keep counting to now
+	s := New()
+	s.SetTotalURLs(4)
+	now := time.Now()
+	s.AddResult(&Result{Success: true, Start: now, End: now})
+	time.Sleep(10 * time.Millisecond)
+
+	if elapsed := s.GetProgress().ElapsedTime; elapsed < 10*time.Millisecond {
+		t.Errorf("Expected elapsed time to run to now, got %v", elapsed)
+	}
+}
+
+func TestPhaseProgressFromResultTimestamps(t *testing.T) {
+	t.Parallel()
+
+	recorded := time.Now().Add(-time.Hour)
+
+	s := New()
+	s.PlanPhases(8, PhaseWarmUp, PhaseVerify)
+	s.StartPhase(PhaseWarmUp)
+	for _, result := range stamped(recorded, 4) {
+		s.AddWarmUpResult(result)
+	}
+
+	phase := s.GetProgress().Phase
+	if phase == nil || phase.EstimatedTimeLeft != 4*time.Second {
+		t.Errorf("Expected 4s left in the warm-up pass, got %+v", phase)
+	}
+
+	// Restarting a phase forgets the time its earlier results covered
+	s.StartPhase(PhaseWarmUp)
+	s.AddWarmUpResult(&Result{Success: true})
+	if phase := s.GetProgress().Phase; phase == nil || phase.EstimatedTimeLeft > time.Second {
+		t.Errorf("Expected the restarted pass to be timed from now, got %+v", phase)
+	}
+}
@@ -1,6 +1,8 @@
 package stats
 
 import (
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
@@ -13,6 +15,57 @@ type Result struct {
 	Error       string        `json:"error,omitempty"`
 	Duration    time.Duration `json:"duration"`
 	CacheStatus string        `json:"cache_status,omitempty"`
+
+	// Sitemap is the URL of the sitemap that listed the URL
+	Sitemap string `json:"sitemap,omitempty"`
+
+	// Size is the response body size in bytes: Content-Length when the
+	// server sent it, otherwise the bytes read
+	Size int64 `json:"size,omitempty"`
+
+	// SurrogateKeys are the keys from the Surrogate-Key header
+	SurrogateKeys []string `json:"surrogate_keys,omitempty"`
+
+	// TraceID identifies the request in the CDN's logs, e.g. Cloudflare's
+	// CF-Ray
+	TraceID string `json:"trace_id,omitempty"`
+
+	// ETag and LastModified are the response's cache validators
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Age is the response's Age header: how long a cache has held it
+	Age time.Duration `json:"age,omitempty"`
+
+	// ErrorCategory classifies a failed request
+	ErrorCategory ErrorCategory `json:"error_category,omitempty"`
+
+	// Attempts is how many requests were made for the URL, including
+	// retries; zero counts as one
+	Attempts int `json:"attempts,omitempty"`
+
+	// ServerTiming holds the metric durations from the Server-Timing header
+	ServerTiming map[string]time.Duration `json:"server_timing,omitempty"`
+
+	// Ranged reports that the request carried a Range header, as the range
+	// and assets request modes send. The origin honored the range if it
+	// answered 206 Partial Content.
+	Ranged bool `json:"ranged,omitempty"`
+
+	// Headers holds the response headers compared between cache
+	// verification passes, keyed by canonical name; headers the response
+	// did not carry are left out
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Edge names the CDN edge a verification request was pinned to
+	Edge string `json:"edge,omitempty"`
+
+	// Start and End are when the request was sent and when it completed.
+	// Stats measures elapsed time and rates from them rather than from when
+	// the result was added, so results replayed or gathered from other
+	// shards are timed as they were crawled. Either may be zero.
+	Start time.Time `json:"start,omitzero"`
+	End   time.Time `json:"end,omitzero"`
 }
 
 // Progress represents current crawling progress
@@ -25,6 +78,10 @@ type Progress struct {
 	ElapsedTime       time.Duration `json:"elapsed_time"`
 	EstimatedTimeLeft time.Duration `json:"estimated_time_left"`
 	RequestsPerSecond float64       `json:"requests_per_second"`
+
+	// Phase is the running pass of a multi-pass crawl, such as the cache
+	// warm-up; it is nil for a single-pass crawl
+	Phase *PhaseProgress `json:"phase,omitempty"`
 }
 
 // FinalStats represents final crawling statistics
@@ -37,6 +94,47 @@ type FinalStats struct {
 	MinDuration     time.Duration `json:"min_duration"`
 	MaxDuration     time.Duration `json:"max_duration"`
 	TotalDuration   time.Duration `json:"total_duration"`
+
+	// ErrorsByCategory counts failures per category; categories without
+	// failures are omitted
+	ErrorsByCategory map[ErrorCategory]int `json:"errors_by_category,omitempty"`
+
+	// Retry accounting. SuccessRate is the eventual success rate;
+	// FirstAttemptSuccessRate only counts URLs that succeeded without a retry.
+	TotalAttempts           int     `json:"total_attempts"`
+	TotalRetries            int     `json:"total_retries"`
+	RetriedURLs             int     `json:"retried_urls"`
+	SuccessAfterRetry       int     `json:"success_after_retry"`
+	MaxAttempts             int     `json:"max_attempts"`
+	FirstAttemptSuccessRate float64 `json:"first_attempt_success_rate"`
+
+	// Range request accounting: how many URLs were requested a byte at a
+	// time, and how many of those the origin answered with 206
+	RangeRequests int `json:"range_requests,omitempty"`
+	RangeHonored  int `json:"range_honored,omitempty"`
+
+	// Sitemap URLs that were not crawled, and how many were rejected for
+	// each reason
+	RejectedURLs     int            `json:"rejected_urls,omitempty"`
+	RejectedByReason map[string]int `json:"rejected_by_reason,omitempty"`
+
+	// SkippedURLs counts URLs not crawled because the deadline passed
+	// before they were reached
+	SkippedURLs int `json:"skipped_urls,omitempty"`
+
+	// CancelReason names the rule that cancelled the crawl, or is empty if
+	// it ran to completion
+	CancelReason string `json:"cancel_reason,omitempty"`
+
+	// Hosts breaks request latency down per host, sorted by host
+	Hosts []HostStats `json:"hosts,omitempty"`
+
+	// Timeline breaks requests down per wall-clock minute, oldest first
+	Timeline []TimeBucket `json:"timeline,omitempty"`
+
+	// Phases are the passes of a multi-pass crawl, in the order they were
+	// first started
+	Phases []PhaseStats `json:"phases,omitempty"`
 }
 
 // CacheStats represents cache verification statistics
@@ -46,6 +144,14 @@ type CacheStats struct {
 	CacheHitRate float64       `json:"cache_hit_rate"`
 	WarmUpTime   time.Duration `json:"warm_up_time"`
 	VerifyTime   time.Duration `json:"verify_time"`
+
+	// ValidatorChanges counts URLs whose ETag or Last-Modified changed
+	// between warm-up and verification
+	ValidatorChanges int `json:"validator_changes"`
+
+	// HeaderChanges counts URLs whose compared response headers changed
+	// between warm-up and verification
+	HeaderChanges int `json:"header_changes"`
 }
 
 // Stats handles all statistics tracking
@@ -62,13 +168,60 @@ type Stats struct {
 	maxDuration   time.Duration
 	startTime     time.Time
 
+	// Time covered by the timestamped results
+	span span
+
+	// throttle reports the crawl's allowed rate and pauses for time-left
+	// estimates, and pausedAtStart how long it had paused when timing
+	// started
+	throttle      func() Throttle
+	pausedAtStart time.Duration
+
+	// Failures per error category
+	errorCategories map[ErrorCategory]int
+
+	// Retry accounting
+	totalAttempts       int
+	retriedURLs         int
+	firstAttemptSuccess int
+	successAfterRetry   int
+	maxAttempts         int
+
+	// Range request accounting
+	rangeRequests int
+	rangeHonored  int
+
+	// Sitemap URLs rejected per reason. They come from the sitemap, not a
+	// pass, so Reset keeps them.
+	rejected map[string]int
+
+	// URLs skipped once the deadline passed
+	skipped int
+
+	// The rule that cancelled the crawl. A cancelled crawl stays
+	// cancelled, so Reset keeps it.
+	cancelReason string
+
 	// Cache verification stats
 	warmUpResults []*Result
 	cacheResults  []*Result
-	warmUpStart   time.Time
-	warmUpEnd     time.Time
-	verifyStart   time.Time
-	verifyEnd     time.Time
+
+	// Named phases in the order they were first started, and the running one
+	phases  []*phase
+	current *phase
+
+	// Passes declared by PlanPhases and the URLs each requests
+	plannedPhases []string
+	phaseTotal    int
+
+	// Request durations keyed by host
+	hosts map[string]*hostAccumulator
+
+	// Request durations keyed by the minute they completed in
+	timeline map[time.Time]*hostAccumulator
+
+	// Server-Timing metrics keyed by URL class and metric name
+	serverTiming map[string]map[string]*timingAccumulator
 }
 
 // New creates a new Stats instance
@@ -80,53 +233,58 @@ func New() *Stats {
 
 // SetTotalURLs sets the total number of URLs to process
 func (s *Stats) SetTotalURLs(total int) {
+	throttle := s.currentThrottle()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.totalURLs = total
 	s.startTime = time.Now() // Start timing when we know the total
+	s.pausedAtStart = throttle.Paused
 }
 
-// AddResult adds a crawling result
-func (s *Stats) AddResult(result *Result) {
+// AddSkippedURLs records URLs that were skipped rather than crawled
+func (s *Stats) AddSkippedURLs(count int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	s.addResultLocked(result)
+	s.skipped += count
 }
 
-// StartWarmUp marks the beginning of the cache warm-up phase.
-func (s *Stats) StartWarmUp() {
+// SetCancelReason records the rule that cancelled the crawl
+func (s *Stats) SetCancelReason(reason string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	s.warmUpStart = time.Now()
-	s.warmUpEnd = time.Time{}
+	s.cancelReason = reason
 }
 
-// AddWarmUpResult adds a warm-up phase result
-func (s *Stats) AddWarmUpResult(result *Result) {
+// SetRejectedURLs records how many sitemap URLs were rejected for each
+// reason
+func (s *Stats) SetRejectedURLs(byReason map[string]int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.warmUpResults = append(s.warmUpResults, result)
-	s.addResultLocked(result)
+	s.rejected = make(map[string]int, len(byReason))
+	for reason, count := range byReason {
+		if count > 0 {
+			s.rejected[reason] = count
+		}
+	}
 }
 
-// FinishWarmUp marks the end of the cache warm-up phase.
-func (s *Stats) FinishWarmUp() {
+// AddResult adds a crawling result
+func (s *Stats) AddResult(result *Result) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.warmUpEnd = time.Now()
+	s.addResultLocked(result)
 }
 
-// StartVerify marks the beginning of the cache verification phase.
-func (s *Stats) StartVerify() {
+// AddWarmUpResult adds a warm-up phase result
+func (s *Stats) AddWarmUpResult(result *Result) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.verifyStart = time.Now()
-	s.verifyEnd = time.Time{}
+	s.warmUpResults = append(s.warmUpResults, result)
+	s.addResultLocked(result)
 }
 
 // AddCacheResult adds a cache verification phase result
@@ -138,16 +296,10 @@ func (s *Stats) AddCacheResult(result *Result) {
 	s.addResultLocked(result)
 }
 
-// FinishVerify marks the end of the cache verification phase.
-func (s *Stats) FinishVerify() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.verifyEnd = time.Now()
-}
-
 // GetProgress returns current progress information
 func (s *Stats) GetProgress() Progress {
+	throttle := s.currentThrottle()
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -167,7 +319,8 @@ func (s *Stats) GetProgress() Progress {
 	}
 
 	// Calculate elapsed time and ETA
-	elapsedTime := time.Since(s.startTime)
+	now := time.Now()
+	elapsedTime := s.span.elapsed(s.startTime, now)
 	var estimatedTimeLeft time.Duration
 	var requestsPerSecond float64
 
@@ -175,11 +328,9 @@ func (s *Stats) GetProgress() Progress {
 		// Calculate requests per second
 		requestsPerSecond = float64(s.processed) / elapsedTime.Seconds()
 
-		// Calculate ETA based on current processing rate
-		remaining := s.totalURLs - s.processed
-		if remaining > 0 && requestsPerSecond > 0 {
-			estimatedTimeLeft = time.Duration(float64(remaining)/requestsPerSecond) * time.Second
-		}
+		// Calculate ETA based on the processing rate the throttle allows
+		active := activeTime(s.span, s.startTime, now, throttle.Paused-s.pausedAtStart)
+		estimatedTimeLeft = estimateTimeLeft(s.totalURLs-s.processed, s.processed, active, throttle, now).Truncate(time.Second)
 	}
 
 	return Progress{
@@ -191,6 +342,7 @@ func (s *Stats) GetProgress() Progress {
 		ElapsedTime:       elapsedTime,
 		EstimatedTimeLeft: estimatedTimeLeft,
 		RequestsPerSecond: requestsPerSecond,
+		Phase:             s.phaseProgressLocked(throttle, now),
 	}
 }
 
@@ -215,16 +367,69 @@ func (s *Stats) GetFinalStats() FinalStats {
 		minDuration = 0
 	}
 
+	var firstAttemptSuccessRate float64
+	if s.processed > 0 {
+		firstAttemptSuccessRate = float64(s.firstAttemptSuccess) / float64(s.processed) * 100
+	}
+
+	var errorsByCategory map[ErrorCategory]int
+	if len(s.errorCategories) > 0 {
+		errorsByCategory = make(map[ErrorCategory]int, len(s.errorCategories))
+		for category, count := range s.errorCategories {
+			errorsByCategory[category] = count
+		}
+	}
+
+	var rejectedURLs int
+	var rejectedByReason map[string]int
+	if len(s.rejected) > 0 {
+		rejectedByReason = make(map[string]int, len(s.rejected))
+		for reason, count := range s.rejected {
+			rejectedByReason[reason] = count
+			rejectedURLs += count
+		}
+	}
+
 	return FinalStats{
-		TotalProcessed:  s.processed,
-		TotalSuccess:    s.successCount,
-		TotalErrors:     s.errorCount,
-		SuccessRate:     successRate,
-		AverageDuration: avgDuration,
-		MinDuration:     minDuration,
-		MaxDuration:     s.maxDuration,
-		TotalDuration:   s.totalDuration,
+		TotalProcessed:   s.processed,
+		TotalSuccess:     s.successCount,
+		TotalErrors:      s.errorCount,
+		SuccessRate:      successRate,
+		AverageDuration:  avgDuration,
+		MinDuration:      minDuration,
+		MaxDuration:      s.maxDuration,
+		TotalDuration:    s.totalDuration,
+		ErrorsByCategory: errorsByCategory,
+
+		TotalAttempts:           s.totalAttempts,
+		TotalRetries:            s.totalAttempts - s.processed,
+		RetriedURLs:             s.retriedURLs,
+		SuccessAfterRetry:       s.successAfterRetry,
+		MaxAttempts:             s.maxAttempts,
+		FirstAttemptSuccessRate: firstAttemptSuccessRate,
+
+		RangeRequests: s.rangeRequests,
+		RangeHonored:  s.rangeHonored,
+
+		RejectedURLs:     rejectedURLs,
+		RejectedByReason: rejectedByReason,
+		SkippedURLs:      s.skipped,
+		CancelReason:     s.cancelReason,
+
+		Hosts:    s.hostStatsLocked(),
+		Timeline: s.timelineLocked(),
+		Phases:   s.phasesLocked(),
+	}
+}
+
+// IsCacheHit reports whether a cache header value counts as a hit. Shielded
+// CDNs such as Fastly list one status per cache layer, e.g. "MISS, HIT";
+// the last one is the edge that answered the request.
+func IsCacheHit(status string) bool {
+	if i := strings.LastIndex(status, ","); i >= 0 {
+		status = strings.TrimSpace(status[i+1:])
 	}
+	return status == "HIT" || status == "hit"
 }
 
 // GetCacheStats returns cache verification statistics
@@ -236,7 +441,7 @@ func (s *Stats) GetCacheStats() CacheStats {
 	var cacheHits, cacheMisses int
 	for _, result := range s.cacheResults {
 		if result.CacheStatus != "" {
-			if result.CacheStatus == "HIT" || result.CacheStatus == "hit" {
+			if IsCacheHit(result.CacheStatus) {
 				cacheHits++
 			} else {
 				cacheMisses++
@@ -250,34 +455,59 @@ func (s *Stats) GetCacheStats() CacheStats {
 		cacheHitRate = float64(cacheHits) / float64(totalCacheChecks) * 100
 	}
 
-	// Calculate timing
-	warmUpTime := time.Duration(0)
-	if !s.warmUpStart.IsZero() && !s.warmUpEnd.IsZero() {
-		warmUpTime = s.warmUpEnd.Sub(s.warmUpStart)
-	}
-
-	verifyTime := time.Duration(0)
-	if !s.verifyStart.IsZero() && !s.verifyEnd.IsZero() {
-		verifyTime = s.verifyEnd.Sub(s.verifyStart)
-	}
-
 	return CacheStats{
 		CacheHits:    cacheHits,
 		CacheMisses:  cacheMisses,
 		CacheHitRate: cacheHitRate,
-		WarmUpTime:   warmUpTime,
-		VerifyTime:   verifyTime,
+		WarmUpTime:   s.phaseDurationLocked(PhaseWarmUp),
+		VerifyTime:   s.verifyDurationLocked(),
+
+		ValidatorChanges: len(s.validatorChangesLocked()),
+		HeaderChanges:    len(s.headerChangesLocked()),
 	}
 }
 
 func (s *Stats) addResultLocked(result *Result) {
 	s.processed++
+	s.span.add(result)
+	if s.current != nil {
+		s.current.processed++
+		s.current.span.add(result)
+	}
 	s.totalDuration += result.Duration
 
+	attempts := max(result.Attempts, 1)
+	s.totalAttempts += attempts
+	s.maxAttempts = max(s.maxAttempts, attempts)
+	if attempts > 1 {
+		s.retriedURLs++
+	}
+
+	if result.Ranged {
+		s.rangeRequests++
+		if result.StatusCode == http.StatusPartialContent {
+			s.rangeHonored++
+		}
+	}
+
 	if result.Success {
 		s.successCount++
+		if attempts == 1 {
+			s.firstAttemptSuccess++
+		} else {
+			s.successAfterRetry++
+		}
 	} else {
 		s.errorCount++
+
+		category := result.ErrorCategory
+		if category == "" {
+			category = ErrorOther
+		}
+		if s.errorCategories == nil {
+			s.errorCategories = make(map[ErrorCategory]int)
+		}
+		s.errorCategories[category]++
 	}
 
 	if result.Duration < s.minDuration {
@@ -286,6 +516,10 @@ func (s *Stats) addResultLocked(result *Result) {
 	if result.Duration > s.maxDuration {
 		s.maxDuration = result.Duration
 	}
+
+	s.addHostLocked(result)
+	s.addTimelineLocked(result, completedAt(result, time.Now()))
+	s.addServerTimingLocked(result)
 }
 
 // Reset resets all statistics
@@ -297,13 +531,26 @@ func (s *Stats) Reset() {
 	s.processed = 0
 	s.successCount = 0
 	s.errorCount = 0
+	s.errorCategories = nil
+	s.totalAttempts = 0
+	s.retriedURLs = 0
+	s.firstAttemptSuccess = 0
+	s.successAfterRetry = 0
+	s.maxAttempts = 0
+	s.rangeRequests = 0
+	s.rangeHonored = 0
+	s.skipped = 0
 	s.totalDuration = 0
 	s.minDuration = time.Hour
 	s.maxDuration = 0
+	s.span = span{}
 	s.warmUpResults = nil
 	s.cacheResults = nil
-	s.warmUpStart = time.Time{}
-	s.warmUpEnd = time.Time{}
-	s.verifyStart = time.Time{}
-	s.verifyEnd = time.Time{}
+	s.phases = nil
+	s.current = nil
+	s.plannedPhases = nil
+	s.phaseTotal = 0
+	s.hosts = nil
+	s.timeline = nil
+	s.serverTiming = nil
 }
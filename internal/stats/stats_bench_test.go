@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func benchmarkResult(i int) *Result {
+	return &Result{
+		URL:        fmt.Sprintf("https://example.com/pages/%d", i),
+		Success:    i%10 != 0,
+		StatusCode: 200,
+		Duration:   time.Duration(i%500) * time.Millisecond,
+		ServerTiming: map[string]time.Duration{
+			"db":  time.Duration(i%50) * time.Millisecond,
+			"app": time.Duration(i%80) * time.Millisecond,
+		},
+	}
+}
+
+func BenchmarkAddResult(b *testing.B) {
+	s := New()
+	result := benchmarkResult(1)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		s.AddResult(result)
+	}
+}
+
+func BenchmarkAddResultParallel(b *testing.B) {
+	s := New()
+	result := benchmarkResult(1)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.AddResult(result)
+		}
+	})
+}
+
+func BenchmarkGetFinalStats(b *testing.B) {
+	s := New()
+	for i := range 100000 {
+		s.AddResult(benchmarkResult(i))
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = s.GetFinalStats()
+	}
+}
+
+func BenchmarkGetServerTimingStats(b *testing.B) {
+	s := New()
+	for i := range 100000 {
+		s.AddResult(benchmarkResult(i))
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = s.GetServerTimingStats()
+	}
+}
+
+func BenchmarkParseServerTiming(b *testing.B) {
+	headers := []string{`cache;desc="Cache Read";dur=23.2, db;dur=53`, `app;dur=47.2, edge;desc="HIT"`}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = ParseServerTiming(headers)
+	}
+}
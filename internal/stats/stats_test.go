@@ -172,7 +172,7 @@ func TestCacheVerification(t *testing.T) {
 
 	s := New()
 	s.SetTotalURLs(4)
-	s.StartWarmUp()
+	s.StartPhase(PhaseWarmUp)
 
 	// Add warm-up results
 	s.AddWarmUpResult(&Result{
@@ -187,10 +187,10 @@ func TestCacheVerification(t *testing.T) {
 		Duration:    200 * time.Millisecond,
 		CacheStatus: "",
 	})
-	s.FinishWarmUp()
+	s.EndPhase(PhaseWarmUp)
 
 	// Add cache verification results
-	s.StartVerify()
+	s.StartPhase(PhaseVerify)
 	s.AddCacheResult(&Result{
 		URL:         "https://example.com/1",
 		Success:     true,
@@ -203,7 +203,7 @@ func TestCacheVerification(t *testing.T) {
 		Duration:    150 * time.Millisecond,
 		CacheStatus: "MISS",
 	})
-	s.FinishVerify()
+	s.EndPhase(PhaseVerify)
 
 	cacheStats := s.GetCacheStats()
 
@@ -292,3 +292,102 @@ func TestEdgeCases(t *testing.T) {
 		t.Errorf("Expected MinDuration 0 for no results, got %v", finalStats.MinDuration)
 	}
 }
+
+func TestRetryAccounting(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddResult(&Result{URL: "https://example.com/1", Success: true})
+	s.AddResult(&Result{URL: "https://example.com/2", Success: true, Attempts: 1})
+	s.AddResult(&Result{URL: "https://example.com/3", Success: true, Attempts: 3})
+	s.AddResult(&Result{URL: "https://example.com/4", Success: false, Attempts: 4})
+
+	finalStats := s.GetFinalStats()
+
+	if finalStats.TotalAttempts != 9 {
+		t.Errorf("Expected TotalAttempts 9, got %d", finalStats.TotalAttempts)
+	}
+	if finalStats.TotalRetries != 5 {
+		t.Errorf("Expected TotalRetries 5, got %d", finalStats.TotalRetries)
+	}
+	if finalStats.RetriedURLs != 2 {
+		t.Errorf("Expected RetriedURLs 2, got %d", finalStats.RetriedURLs)
+	}
+	if finalStats.SuccessAfterRetry != 1 {
+		t.Errorf("Expected SuccessAfterRetry 1, got %d", finalStats.SuccessAfterRetry)
+	}
+	if finalStats.MaxAttempts != 4 {
+		t.Errorf("Expected MaxAttempts 4, got %d", finalStats.MaxAttempts)
+	}
+	if finalStats.SuccessRate != 75.0 {
+		t.Errorf("Expected SuccessRate 75.0, got %.1f", finalStats.SuccessRate)
+	}
+	if finalStats.FirstAttemptSuccessRate != 50.0 {
+		t.Errorf("Expected FirstAttemptSuccessRate 50.0, got %.1f", finalStats.FirstAttemptSuccessRate)
+	}
+
+	s.Reset()
+	if finalStats := s.GetFinalStats(); finalStats.TotalAttempts != 0 || finalStats.MaxAttempts != 0 {
+		t.Errorf("Expected Reset to clear retry accounting, got %+v", finalStats)
+	}
+}
+
+func TestRangeAccounting(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddResult(&Result{URL: "https://example.com/", Success: true, StatusCode: 200})
+	s.AddResult(&Result{URL: "https://example.com/a.mp4", Success: true, StatusCode: 206, Ranged: true})
+	s.AddResult(&Result{URL: "https://example.com/b.zip", Success: true, StatusCode: 200, Ranged: true})
+
+	finalStats := s.GetFinalStats()
+	if finalStats.RangeRequests != 2 {
+		t.Errorf("Expected RangeRequests 2, got %d", finalStats.RangeRequests)
+	}
+	if finalStats.RangeHonored != 1 {
+		t.Errorf("Expected RangeHonored 1, got %d", finalStats.RangeHonored)
+	}
+
+	s.Reset()
+	if finalStats := s.GetFinalStats(); finalStats.RangeRequests != 0 || finalStats.RangeHonored != 0 {
+		t.Errorf("Expected Reset to clear range accounting, got %+v", finalStats)
+	}
+}
+
+func TestRejectedURLs(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.SetRejectedURLs(map[string]int{"bad_scheme": 2, "malformed": 1, "blocked_domain": 0})
+
+	finalStats := s.GetFinalStats()
+	if finalStats.RejectedURLs != 3 {
+		t.Errorf("Expected RejectedURLs 3, got %d", finalStats.RejectedURLs)
+	}
+	if len(finalStats.RejectedByReason) != 2 || finalStats.RejectedByReason["bad_scheme"] != 2 {
+		t.Errorf("Expected counts for the two reasons seen, got %v", finalStats.RejectedByReason)
+	}
+
+	// Rejections come from the sitemap, so a new iteration keeps them
+	s.Reset()
+	if finalStats := s.GetFinalStats(); finalStats.RejectedURLs != 3 {
+		t.Errorf("Expected Reset to keep rejected URLs, got %d", finalStats.RejectedURLs)
+	}
+}
+
+func TestSkippedURLs(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddSkippedURLs(3)
+	s.AddSkippedURLs(2)
+	if skipped := s.GetFinalStats().SkippedURLs; skipped != 5 {
+		t.Errorf("Expected SkippedURLs 5, got %d", skipped)
+	}
+
+	// Skipping happens per pass, so a new iteration starts over
+	s.Reset()
+	if skipped := s.GetFinalStats().SkippedURLs; skipped != 0 {
+		t.Errorf("Expected Reset to clear skipped URLs, got %d", skipped)
+	}
+}
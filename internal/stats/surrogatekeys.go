@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+)
+
+// SurrogateKeyStats represents the cache hit rate of the verification
+// requests tagged with one surrogate key
+type SurrogateKeyStats struct {
+	Key          string  `json:"key"`
+	CacheHits    int     `json:"cache_hits"`
+	CacheMisses  int     `json:"cache_misses"`
+	CacheHitRate float64 `json:"cache_hit_rate"`
+}
+
+// ParseSurrogateKeys splits a Surrogate-Key header into its space-separated
+// keys
+func ParseSurrogateKeys(value string) []string {
+	keys := strings.Fields(value)
+	if len(keys) == 0 {
+		return nil
+	}
+	return keys
+}
+
+// GetSurrogateKeyStats returns the cache hit rate of each surrogate key seen
+// in the verification phase, lowest hit rate first
+func (s *Stats) GetSurrogateKeyStats() []SurrogateKeyStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[string]*SurrogateKeyStats)
+	for _, result := range s.cacheResults {
+		if result.CacheStatus == "" {
+			continue
+		}
+		hit := IsCacheHit(result.CacheStatus)
+		for _, key := range result.SurrogateKeys {
+			keyStats, ok := byKey[key]
+			if !ok {
+				keyStats = &SurrogateKeyStats{Key: key}
+				byKey[key] = keyStats
+			}
+			if hit {
+				keyStats.CacheHits++
+			} else {
+				keyStats.CacheMisses++
+			}
+		}
+	}
+
+	keys := make([]SurrogateKeyStats, 0, len(byKey))
+	for _, keyStats := range byKey {
+		keyStats.CacheHitRate = float64(keyStats.CacheHits) / float64(keyStats.CacheHits+keyStats.CacheMisses) * 100
+		keys = append(keys, *keyStats)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CacheHitRate != keys[j].CacheHitRate {
+			return keys[i].CacheHitRate < keys[j].CacheHitRate
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	return keys
+}
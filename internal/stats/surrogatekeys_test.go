@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSurrogateKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "no header", value: "", expected: nil},
+		{name: "single key", value: "product-7", expected: []string{"product-7"}},
+		{name: "several keys", value: " product-7  category-2 all ", expected: []string{"product-7", "category-2", "all"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if result := ParseSurrogateKeys(tt.value); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsCacheHit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status   string
+		expected bool
+	}{
+		{status: "HIT", expected: true},
+		{status: "hit", expected: true},
+		{status: "MISS", expected: false},
+		{status: "", expected: false},
+		{status: "MISS, HIT", expected: true},
+		{status: "HIT, MISS", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			t.Parallel()
+			if result := IsCacheHit(tt.status); result != tt.expected {
+				t.Errorf("IsCacheHit(%q): expected %v, got %v", tt.status, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetSurrogateKeyStats(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddWarmUpResult(&Result{URL: "/a", Success: true, CacheStatus: "MISS", SurrogateKeys: []string{"all", "product"}})
+	s.AddCacheResult(&Result{URL: "/a", Success: true, CacheStatus: "HIT", SurrogateKeys: []string{"all", "product"}})
+	s.AddCacheResult(&Result{URL: "/b", Success: true, CacheStatus: "MISS", SurrogateKeys: []string{"all", "category"}})
+	s.AddCacheResult(&Result{URL: "/c", Success: true, CacheStatus: "MISS, HIT", SurrogateKeys: []string{"product"}})
+	s.AddCacheResult(&Result{URL: "/d", Success: true, SurrogateKeys: []string{"uncached"}})
+
+	expected := []SurrogateKeyStats{
+		{Key: "category", CacheMisses: 1, CacheHitRate: 0},
+		{Key: "all", CacheHits: 1, CacheMisses: 1, CacheHitRate: 50},
+		{Key: "product", CacheHits: 2, CacheHitRate: 100},
+	}
+	if result := s.GetSurrogateKeyStats(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+}
@@ -0,0 +1,71 @@
+package stats
+
+import "time"
+
+// Throttle describes what holds a crawl back, so that time-left estimates
+// follow the pace the crawl is allowed instead of only the pace it has kept
+type Throttle struct {
+	// Rate is the most requests per second the crawl may send now; zero
+	// means unlimited
+	Rate float64
+
+	// PausedUntil is when a pause of every worker, such as a backoff delay,
+	// ends; a zero or past time means none is in effect
+	PausedUntil time.Time
+
+	// Paused is the total time workers have been paused so far
+	Paused time.Duration
+}
+
+// SetThrottle sets the function progress reports ask for the crawl's
+// throttle. It is called without the statistics locked.
+func (s *Stats) SetThrottle(throttle func() Throttle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttle = throttle
+}
+
+// currentThrottle returns the crawl's throttle, or the zero Throttle when
+// none is set
+func (s *Stats) currentThrottle() Throttle {
+	s.mu.RLock()
+	throttle := s.throttle
+	s.mu.RUnlock()
+
+	if throttle == nil {
+		return Throttle{}
+	}
+	return throttle()
+}
+
+// activeTime returns how long the results of a pass started at started took
+// to crawl, less the time paused meanwhile, so that a backoff pause does not
+// drag the rate down. Pauses are measured on the live clock, so they are
+// left out of the time a span of replayed results covers. Zero means the
+// time is unknown, as when the crawl has been paused for all of it.
+func activeTime(sp span, started, now time.Time, paused time.Duration) time.Duration {
+	if sp.replayed(started) {
+		return sp.last.Sub(sp.first)
+	}
+	return max(now.Sub(started)-max(paused, 0), 0)
+}
+
+// estimateTimeLeft returns how long the remaining requests will take. The
+// rate is that of the requests processed over the active time, capped at the
+// rate the crawl is allowed now. The rest of a pause in effect comes first.
+// Without an active time the estimate is unknown, and zero.
+func estimateTimeLeft(remaining, processed int, active time.Duration, throttle Throttle, now time.Time) time.Duration {
+	if remaining <= 0 || processed <= 0 || active <= 0 {
+		return 0
+	}
+
+	perSecond := float64(processed) / active.Seconds()
+	if throttle.Rate > 0 && perSecond > throttle.Rate {
+		perSecond = throttle.Rate
+	}
+	left := time.Duration(float64(remaining) / perSecond * float64(time.Second))
+	if pause := throttle.PausedUntil.Sub(now); pause > 0 {
+		left += pause
+	}
+	return left
+}
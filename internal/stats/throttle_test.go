@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestActiveTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-10 * time.Second)
+	replay := span{first: now.Add(-time.Hour), last: now.Add(-time.Hour + 20*time.Second)}
+
+	tests := []struct {
+		name     string
+		span     span
+		paused   time.Duration
+		expected time.Duration
+	}{
+		{name: "live", expected: 10 * time.Second},
+		{name: "paused time left out", paused: 5 * time.Second, expected: 5 * time.Second},
+		{name: "paused all along", paused: 10 * time.Second},
+		{name: "paused longer than elapsed", paused: 15 * time.Second},
+		{name: "replay", span: replay, expected: 20 * time.Second},
+		{name: "replay plus pause", span: replay, paused: 5 * time.Second, expected: 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := activeTime(tt.span, started, now, tt.paused); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEstimateTimeLeft(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		remaining int
+		processed int
+		active    time.Duration
+		throttle  Throttle
+		expected  time.Duration
+	}{
+		{name: "historical rate", remaining: 90, processed: 10, active: 10 * time.Second, expected: 90 * time.Second},
+		{name: "capped at the allowed rate", remaining: 90, processed: 10, active: 10 * time.Second, throttle: Throttle{Rate: 0.5}, expected: 180 * time.Second},
+		{name: "allowed rate above the historical one", remaining: 90, processed: 10, active: 10 * time.Second, throttle: Throttle{Rate: 100}, expected: 90 * time.Second},
+		{name: "pause in effect", remaining: 90, processed: 10, active: 10 * time.Second, throttle: Throttle{PausedUntil: now.Add(30 * time.Second)}, expected: 120 * time.Second},
+		{name: "pause over", remaining: 90, processed: 10, active: 10 * time.Second, throttle: Throttle{PausedUntil: now.Add(-time.Second)}, expected: 90 * time.Second},
+		{name: "no active time", remaining: 90, processed: 10},
+		{name: "nothing processed", remaining: 90, active: 10 * time.Second},
+		{name: "nothing remaining", processed: 10, active: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := estimateTimeLeft(tt.remaining, tt.processed, tt.active, tt.throttle, now); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProgressFollowsThrottle(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	throttle := Throttle{Paused: time.Minute}
+	setThrottle := func(update func(*Throttle)) {
+		mu.Lock()
+		defer mu.Unlock()
+		update(&throttle)
+	}
+
+	s := New()
+	s.SetThrottle(func() Throttle {
+		mu.Lock()
+		defer mu.Unlock()
+		return throttle
+	})
+
+	// Pauses before timing started do not count against the pass
+	recorded := time.Now().Add(-time.Hour)
+	s.PlanPhases(20, PhaseWarmUp, PhaseVerify)
+	s.StartPhase(PhaseWarmUp)
+	for _, result := range stamped(recorded, 10) {
+		s.AddWarmUpResult(result)
+	}
+
+	progress := s.GetProgress()
+	if progress.EstimatedTimeLeft != 30*time.Second || progress.Phase.EstimatedTimeLeft != 10*time.Second {
+		t.Errorf("Expected 30s left overall and 10s in the pass, got %v and %v", progress.EstimatedTimeLeft, progress.Phase.EstimatedTimeLeft)
+	}
+
+	// Slowing the allowed rate slows the estimate at once
+	setThrottle(func(throttle *Throttle) { throttle.Rate = 0.5 })
+	progress = s.GetProgress()
+	if progress.EstimatedTimeLeft != time.Minute || progress.Phase.EstimatedTimeLeft != 20*time.Second {
+		t.Errorf("Expected 1m left overall and 20s in the pass, got %v and %v", progress.EstimatedTimeLeft, progress.Phase.EstimatedTimeLeft)
+	}
+
+	// Pauses on the live clock are not taken out of the time recorded
+	// results cover
+	setThrottle(func(throttle *Throttle) { throttle.Rate, throttle.Paused = 0, time.Minute+5*time.Second })
+	progress = s.GetProgress()
+	if progress.EstimatedTimeLeft != 30*time.Second || progress.Phase.EstimatedTimeLeft != 10*time.Second {
+		t.Errorf("Expected 30s left overall and 10s in the pass, got %v and %v", progress.EstimatedTimeLeft, progress.Phase.EstimatedTimeLeft)
+	}
+}
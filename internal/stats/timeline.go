@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// TimeBucketSize is the wall-clock span of one timeline bucket
+const TimeBucketSize = time.Minute
+
+// TimeBucket represents the requests that completed within one wall-clock
+// minute
+type TimeBucket struct {
+	Start     time.Time     `json:"start"`
+	Requests  int           `json:"requests"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	P95       time.Duration `json:"p95"`
+}
+
+// addTimelineLocked records a result under the minute it completed in
+func (s *Stats) addTimelineLocked(result *Result, completed time.Time) {
+	start := completed.Truncate(TimeBucketSize)
+
+	if s.timeline == nil {
+		s.timeline = make(map[time.Time]*hostAccumulator)
+	}
+	accumulator, ok := s.timeline[start]
+	if !ok {
+		accumulator = &hostAccumulator{}
+		s.timeline[start] = accumulator
+	}
+
+	accumulator.durations = append(accumulator.durations, result.Duration)
+	if !result.Success {
+		accumulator.errors++
+	}
+}
+
+// GetTimeline returns request counts, error rates and p95 latency per
+// minute, oldest first. Minutes without completed requests are omitted.
+func (s *Stats) GetTimeline() []TimeBucket {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.timelineLocked()
+}
+
+func (s *Stats) timelineLocked() []TimeBucket {
+	if len(s.timeline) == 0 {
+		return nil
+	}
+
+	timeline := make([]TimeBucket, 0, len(s.timeline))
+	for start, accumulator := range s.timeline {
+		sorted := slices.Clone(accumulator.durations)
+		slices.Sort(sorted)
+
+		timeline = append(timeline, TimeBucket{
+			Start:     start,
+			Requests:  len(sorted),
+			Errors:    accumulator.errors,
+			ErrorRate: float64(accumulator.errors) / float64(len(sorted)) * 100,
+			P95:       Percentile(sorted, 95),
+		})
+	}
+
+	sort.Slice(timeline, func(i, j int) bool {
+		return timeline[i].Start.Before(timeline[j].Start)
+	})
+	return timeline
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeline(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := New()
+	s.mu.Lock()
+	// The second minute is recorded first to check ordering
+	for i := 1; i <= 4; i++ {
+		s.addTimelineLocked(&Result{
+			Success:  i != 4,
+			Duration: time.Duration(i) * 100 * time.Millisecond,
+		}, start.Add(time.Minute+time.Duration(i)*time.Second))
+	}
+	for i := 1; i <= 20; i++ {
+		s.addTimelineLocked(&Result{
+			Success:  true,
+			Duration: time.Duration(i) * time.Millisecond,
+		}, start.Add(time.Duration(i)*2*time.Second))
+	}
+	s.mu.Unlock()
+
+	timeline := s.GetTimeline()
+	if len(timeline) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(timeline))
+	}
+
+	first, second := timeline[0], timeline[1]
+	if !first.Start.Equal(start) || !second.Start.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Expected buckets at %v and %v, got %v and %v", start, start.Add(time.Minute), first.Start, second.Start)
+	}
+	if first.Requests != 20 || first.Errors != 0 || first.ErrorRate != 0 {
+		t.Errorf("Unexpected first bucket: %+v", first)
+	}
+	if first.P95 != 19*time.Millisecond {
+		t.Errorf("Expected first bucket p95 19ms, got %v", first.P95)
+	}
+	if second.Requests != 4 || second.Errors != 1 || second.ErrorRate != 25 {
+		t.Errorf("Unexpected second bucket: %+v", second)
+	}
+	if second.P95 != 400*time.Millisecond {
+		t.Errorf("Expected second bucket p95 400ms, got %v", second.P95)
+	}
+}
+
+func TestTimelineFromResults(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	if got := s.GetFinalStats().Timeline; got != nil {
+		t.Errorf("Expected no timeline before any results, got %v", got)
+	}
+
+	s.AddResult(&Result{URL: "https://example.com/", Success: true, Duration: time.Millisecond})
+	s.AddResult(&Result{URL: "https://example.com/missing", Success: false, Duration: time.Millisecond})
+
+	var requests int
+	for _, bucket := range s.GetFinalStats().Timeline {
+		requests += bucket.Requests
+	}
+	if requests != 2 {
+		t.Errorf("Expected timeline to count 2 requests, got %d", requests)
+	}
+
+	s.Reset()
+	if got := s.GetTimeline(); got != nil {
+		t.Errorf("Expected Reset to clear the timeline, got %v", got)
+	}
+}
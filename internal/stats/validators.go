@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidatorChange is a URL whose ETag or Last-Modified differed between the
+// warm-up and verification requests. Content that is regenerated on every
+// request defeats conditional requests and often caching as well.
+type ValidatorChange struct {
+	URL                string `json:"url"`
+	WarmUpETag         string `json:"warm_up_etag,omitempty"`
+	VerifyETag         string `json:"verify_etag,omitempty"`
+	WarmUpLastModified string `json:"warm_up_last_modified,omitempty"`
+	VerifyLastModified string `json:"verify_last_modified,omitempty"`
+}
+
+// ETagChanged reports whether the ETag differed between the passes
+func (v ValidatorChange) ETagChanged() bool {
+	return v.WarmUpETag != v.VerifyETag
+}
+
+// LastModifiedChanged reports whether Last-Modified differed between the
+// passes
+func (v ValidatorChange) LastModifiedChanged() bool {
+	return v.WarmUpLastModified != v.VerifyLastModified
+}
+
+// GetValidatorChanges compares the validators of each URL's warm-up and
+// verification responses, sorted by URL. A validator is only compared when
+// both responses carried it, and ETags are compared without the weak
+// prefix that compressing proxies add.
+func (s *Stats) GetValidatorChanges() []ValidatorChange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.validatorChangesLocked()
+}
+
+func (s *Stats) validatorChangesLocked() []ValidatorChange {
+	warmUp := make(map[string]*Result, len(s.warmUpResults))
+	for _, result := range s.warmUpResults {
+		warmUp[result.URL] = result
+	}
+
+	var changes []ValidatorChange
+	for _, verify := range s.cacheResults {
+		warm, ok := warmUp[verify.URL]
+		if !ok {
+			continue
+		}
+
+		change := ValidatorChange{URL: verify.URL}
+		if warm.ETag != "" && verify.ETag != "" && strongETag(warm.ETag) != strongETag(verify.ETag) {
+			change.WarmUpETag = warm.ETag
+			change.VerifyETag = verify.ETag
+		}
+		if warm.LastModified != "" && verify.LastModified != "" && warm.LastModified != verify.LastModified {
+			change.WarmUpLastModified = warm.LastModified
+			change.VerifyLastModified = verify.LastModified
+		}
+		if change.ETagChanged() || change.LastModifiedChanged() {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].URL < changes[j].URL
+	})
+	return changes
+}
+
+// strongETag strips the weak validator prefix from an ETag
+func strongETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetValidatorChanges(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	s.AddWarmUpResult(&Result{URL: "/stable", ETag: `"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"})
+	s.AddWarmUpResult(&Result{URL: "/etag", ETag: `"v1"`})
+	s.AddWarmUpResult(&Result{URL: "/lastmod", LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"})
+	s.AddWarmUpResult(&Result{URL: "/weak", ETag: `"abc"`})
+	s.AddWarmUpResult(&Result{URL: "/dropped", ETag: `"abc"`})
+
+	s.AddCacheResult(&Result{URL: "/stable", ETag: `"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"})
+	s.AddCacheResult(&Result{URL: "/etag", ETag: `"v2"`})
+	s.AddCacheResult(&Result{URL: "/lastmod", LastModified: "Mon, 02 Jan 2006 15:04:06 GMT"})
+	s.AddCacheResult(&Result{URL: "/weak", ETag: `W/"abc"`})
+	s.AddCacheResult(&Result{URL: "/dropped"})
+	s.AddCacheResult(&Result{URL: "/verify-only", ETag: `"abc"`})
+
+	expected := []ValidatorChange{
+		{URL: "/etag", WarmUpETag: `"v1"`, VerifyETag: `"v2"`},
+		{URL: "/lastmod", WarmUpLastModified: "Mon, 02 Jan 2006 15:04:05 GMT", VerifyLastModified: "Mon, 02 Jan 2006 15:04:06 GMT"},
+	}
+	result := s.GetValidatorChanges()
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, result)
+	}
+	if !result[0].ETagChanged() || result[0].LastModifiedChanged() {
+		t.Errorf("Expected only the ETag of %s to change", result[0].URL)
+	}
+}
@@ -0,0 +1,136 @@
+package testserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultCacheHeader is the header carrying the simulated cache status
+const DefaultCacheHeader = "X-Cache"
+
+// Cache configures the simulated caching layer in front of the origin
+type Cache struct {
+	// Header carries HIT or MISS; defaults to X-Cache
+	Header string `yaml:"header"`
+
+	// TTL is how long a cached response stays fresh; zero never expires
+	TTL time.Duration `yaml:"ttl"`
+
+	// MissDelay and HitDelay are added to misses and hits respectively
+	MissDelay time.Duration `yaml:"miss_delay"`
+	HitDelay  time.Duration `yaml:"hit_delay"`
+}
+
+// cacheState tracks when each URL was stored in the simulated cache
+type cacheState struct {
+	Cache
+
+	mu     sync.Mutex
+	stored map[string]time.Time
+}
+
+// lookup returns the time a URL was cached and whether it is still fresh
+func (c *cacheState) lookup(key string, now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	storedAt, ok := c.stored[key]
+	if !ok || (c.TTL > 0 && now.Sub(storedAt) >= c.TTL) {
+		return time.Time{}, false
+	}
+	return storedAt, true
+}
+
+// store records a URL as cached at the given time
+func (c *cacheState) store(key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stored[key] = now
+}
+
+// purge evicts a URL from the simulated cache
+func (c *cacheState) purge(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.stored, key)
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status before passing it on
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 status before passing the body on
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// simulateCache emulates a CDN: the first request for a URL is a slow MISS
+// and later requests are fast HITs until the TTL expires. Only 200 responses
+// are cached, and a PURGE or BAN request evicts a URL.
+func (s *Server) simulateCache(next http.Handler) http.Handler {
+	if s.cache == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.RequestURI()
+		if r.Method == "PURGE" || r.Method == "BAN" {
+			s.cache.purge(key)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("Purged"))
+			return
+		}
+
+		now := s.now()
+		storedAt, hit := s.cache.lookup(key, now)
+
+		delay := s.cache.MissDelay
+		status := "MISS"
+		age := 0
+		if hit {
+			delay = s.cache.HitDelay
+			status = "HIT"
+			age = int(now.Sub(storedAt) / time.Second)
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		w.Header().Set(s.cache.Header, status)
+		w.Header().Set("Age", strconv.Itoa(age))
+		if s.cache.TTL > 0 {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.cache.TTL/time.Second)))
+		} else {
+			w.Header().Set("Cache-Control", "public")
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if !hit && recorder.status == http.StatusOK {
+			s.cache.store(key, now)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSimulation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := New(Config{Cache: &Cache{TTL: time.Minute}})
+	server.now = func() time.Time { return now }
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, _ := get(t, ts.URL+"/pages/1")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+	assert.Equal(t, "0", resp.Header.Get("Age"))
+	assert.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+
+	now = now.Add(30 * time.Second)
+	resp, _ = get(t, ts.URL+"/pages/1")
+	assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+	assert.Equal(t, "30", resp.Header.Get("Age"))
+
+	// Other URLs are cached independently
+	resp, _ = get(t, ts.URL+"/pages/1?variant=b")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+
+	// Expired entries miss again
+	now = now.Add(time.Minute)
+	resp, _ = get(t, ts.URL+"/pages/1")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+}
+
+func TestCachePurge(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(New(Config{Cache: &Cache{}}).Handler())
+	defer ts.Close()
+
+	_, _ = get(t, ts.URL+"/pages/1")
+	resp, _ := get(t, ts.URL+"/pages/1")
+	assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+
+	for _, method := range []string{"PURGE", "BAN"} {
+		req, err := http.NewRequest(method, ts.URL+"/pages/1", nil)
+		require.NoError(t, err)
+		purged, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = purged.Body.Close()
+		assert.Equal(t, http.StatusOK, purged.StatusCode)
+
+		resp, _ = get(t, ts.URL+"/pages/1")
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"), method)
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Cache:  &Cache{Header: "CF-Cache-Status"},
+		Faults: []Fault{{Status: http.StatusBadGateway, Every: 2, Burst: 1}},
+	}).Handler())
+	defer server.Close()
+
+	// Faults sit in front of the cache, so the failed request never reaches it
+	resp, _ := get(t, server.URL+"/missing")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	resp, _ = get(t, server.URL+"/missing")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "MISS", resp.Header.Get("CF-Cache-Status"))
+
+	resp, _ = get(t, server.URL+"/missing")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	resp, _ = get(t, server.URL+"/missing")
+	assert.Equal(t, "MISS", resp.Header.Get("CF-Cache-Status"), "404 responses are not cached")
+}
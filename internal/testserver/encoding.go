@@ -0,0 +1,196 @@
+package testserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// encodingVariant describes how a sitemap body is compressed and labeled.
+// Some variants are deliberately wrong to reproduce misconfigured origins.
+type encodingVariant struct {
+	description     string
+	encode          func([]byte) ([]byte, error)
+	contentType     string // empty keeps the inner handler's Content-Type
+	contentEncoding string
+}
+
+// encodingVariants are served under /variants/{name}/...
+var encodingVariants = map[string]encodingVariant{
+	"gzip": {
+		description:     "gzip body with Content-Encoding: gzip",
+		encode:          gzipBytes,
+		contentEncoding: "gzip",
+	},
+	"deflate": {
+		description:     "zlib body with Content-Encoding: deflate",
+		encode:          deflateBytes,
+		contentEncoding: "deflate",
+	},
+	"br": {
+		description:     "brotli body with Content-Encoding: br",
+		encode:          brotliBytes,
+		contentEncoding: "br",
+	},
+	"gzip-file": {
+		description: "gzip file served as application/gzip without Content-Encoding (sitemap.xml.gz)",
+		encode:      gzipBytes,
+		contentType: "application/gzip",
+	},
+	"gzip-unlabeled": {
+		description: "gzip body labeled as XML without Content-Encoding",
+		encode:      gzipBytes,
+	},
+	"gzip-double": {
+		description:     "gzip file additionally encoded with Content-Encoding: gzip",
+		encode:          func(data []byte) ([]byte, error) { return chain(data, gzipBytes, gzipBytes) },
+		contentEncoding: "gzip",
+	},
+	"mislabeled": {
+		description:     "uncompressed body claiming Content-Encoding: gzip",
+		encode:          func(data []byte) ([]byte, error) { return data, nil },
+		contentEncoding: "gzip",
+	},
+	"wrong-type": {
+		description: "uncompressed body served as text/html",
+		encode:      func(data []byte) ([]byte, error) { return data, nil },
+		contentType: "text/html; charset=utf-8",
+	},
+}
+
+// EncodingVariants returns the names of the available encoding variants
+func EncodingVariants() []string {
+	names := make([]string, 0, len(encodingVariants))
+	for name := range encodingVariants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bufferedResponse captures an inner handler's response so it can be re-encoded
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the captured headers
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// Write captures body bytes
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// WriteHeader captures the status code
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// handleVariant serves any other endpoint through an encoding variant, e.g.
+// /variants/br/sample-sitemap.xml or /variants/gzip-file/local-sitemap.xml.
+// The variant is applied regardless of the client's Accept-Encoding.
+func (s *Server) handleVariant(w http.ResponseWriter, r *http.Request) {
+	name, innerPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/variants/"), "/")
+	variant, ok := encodingVariants[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown variant %q (available: %s)", name, strings.Join(EncodingVariants(), ", ")), http.StatusNotFound)
+		return
+	}
+
+	inner := r.Clone(r.Context())
+	inner.URL.Path = "/" + innerPath
+	inner.URL.RawPath = ""
+	inner.RequestURI = inner.URL.RequestURI()
+
+	response := &bufferedResponse{header: make(http.Header)}
+	s.mux.ServeHTTP(response, inner)
+	if response.status == 0 {
+		response.status = http.StatusOK
+	}
+
+	if response.status != http.StatusOK {
+		copyHeader(w.Header(), response.header)
+		w.WriteHeader(response.status)
+		_, _ = w.Write(response.body.Bytes())
+		return
+	}
+
+	body, err := variant.encode(response.body.Bytes())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode body: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	copyHeader(w.Header(), response.header)
+	w.Header().Del("Content-Length")
+	if variant.contentType != "" {
+		w.Header().Set("Content-Type", variant.contentType)
+	}
+	if variant.contentEncoding != "" {
+		w.Header().Set("Content-Encoding", variant.contentEncoding)
+	}
+	w.Header().Set("Vary", "Accept-Encoding")
+	_, _ = w.Write(body)
+}
+
+// copyHeader copies all header values from src to dst
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = append([]string(nil), values...)
+	}
+}
+
+// chain applies encoders in order
+func chain(data []byte, encoders ...func([]byte) ([]byte, error)) ([]byte, error) {
+	var err error
+	for _, encode := range encoders {
+		if data, err = encode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	return compress(data, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+}
+
+// deflateBytes compresses data in the zlib format used by Content-Encoding: deflate
+func deflateBytes(data []byte) ([]byte, error) {
+	return compress(data, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+}
+
+// brotliBytes compresses data with brotli
+func brotliBytes(data []byte) ([]byte, error) {
+	return compress(data, func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+}
+
+// compress runs data through a compressing writer
+func compress(data []byte, newWriter func(io.Writer) io.WriteCloser) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := newWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
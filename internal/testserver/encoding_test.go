@@ -0,0 +1,124 @@
+package testserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestEncodingVariants(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{Pages: 2}).Handler())
+	t.Cleanup(server.Close)
+
+	_, plain := get(t, server.URL+"/local-sitemap.xml")
+
+	tests := []struct {
+		variant         string
+		contentType     string
+		contentEncoding string
+		decode          func(t *testing.T, data []byte) []byte
+	}{
+		{variant: "gzip", contentType: "application/xml", contentEncoding: "gzip", decode: gunzip},
+		{
+			variant: "deflate", contentType: "application/xml", contentEncoding: "deflate",
+			decode: func(t *testing.T, data []byte) []byte {
+				reader, err := zlib.NewReader(bytes.NewReader(data))
+				require.NoError(t, err)
+				decoded, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				return decoded
+			},
+		},
+		{
+			variant: "br", contentType: "application/xml", contentEncoding: "br",
+			decode: func(t *testing.T, data []byte) []byte {
+				decoded, err := io.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+				require.NoError(t, err)
+				return decoded
+			},
+		},
+		{variant: "gzip-file", contentType: "application/gzip", decode: gunzip},
+		{variant: "gzip-unlabeled", contentType: "application/xml", decode: gunzip},
+		{
+			variant: "gzip-double", contentType: "application/xml", contentEncoding: "gzip",
+			decode: func(t *testing.T, data []byte) []byte { return gunzip(t, gunzip(t, data)) },
+		},
+		{
+			variant: "mislabeled", contentType: "application/xml", contentEncoding: "gzip",
+			decode: func(t *testing.T, data []byte) []byte { return data },
+		},
+		{
+			variant: "wrong-type", contentType: "text/html; charset=utf-8",
+			decode: func(t *testing.T, data []byte) []byte { return data },
+		},
+	}
+
+	// Disable transparent decompression to see the raw bytes on the wire
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	for _, tt := range tests {
+		t.Run(tt.variant, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := client.Get(server.URL + "/variants/" + tt.variant + "/local-sitemap.xml")
+			require.NoError(t, err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.contentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.contentEncoding, resp.Header.Get("Content-Encoding"))
+			assert.Equal(t, plain, string(tt.decode(t, body)))
+		})
+	}
+}
+
+func TestEncodingVariantErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	resp, _ := get(t, server.URL+"/variants/zstd/local-sitemap.xml")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Errors from the inner handler pass through unencoded
+	resp, _ = get(t, server.URL+"/variants/gzip/generate/sitemap?urls=-1")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+}
+
+func TestEncodingVariantGzipParses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{Pages: 4}).Handler())
+	defer server.Close()
+
+	urls, err := parser.NewParser(10*time.Second).ParseSitemap(server.URL+"/variants/gzip/local-sitemap.xml", nil)
+	require.NoError(t, err)
+	assert.Len(t, urls, 4)
+}
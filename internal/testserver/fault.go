@@ -0,0 +1,147 @@
+package testserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fault describes an error injected into matching requests, either with a
+// probability or on a schedule of bursts
+type Fault struct {
+	// PathPrefix limits the fault to paths with this prefix; empty matches all
+	PathPrefix string `yaml:"path"`
+
+	// Status is the HTTP status returned (e.g. 500, 502, 503, 403)
+	Status int `yaml:"status"`
+
+	// Reset closes the connection without a response instead of returning Status
+	Reset bool `yaml:"reset"`
+
+	// Probability is the chance that a matching request fails
+	Probability float64 `yaml:"probability"`
+
+	// Every and Burst inject Burst consecutive failures out of every Every
+	// matching requests, starting with the first
+	Every int `yaml:"every"`
+	Burst int `yaml:"burst"`
+}
+
+// faultState tracks how many requests a fault has matched
+type faultState struct {
+	Fault
+
+	mu      sync.Mutex
+	matched int
+}
+
+// scheduled reports whether the next matching request falls inside a burst
+func (f *faultState) scheduled() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := f.matched
+	f.matched++
+	return f.Every > 0 && n%f.Every < f.Burst
+}
+
+// ParseFault parses a fault specification such as
+// "status=503,probability=0.1,path=/pages/" or "reset,every=10,burst=2"
+func ParseFault(spec string) (Fault, error) {
+	var fault Fault
+	for _, part := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		var err error
+		switch key {
+		case "path":
+			fault.PathPrefix = value
+		case "status":
+			fault.Status, err = strconv.Atoi(value)
+		case "reset":
+			fault.Reset = true
+		case "probability":
+			fault.Probability, err = strconv.ParseFloat(value, 64)
+		case "every":
+			fault.Every, err = strconv.Atoi(value)
+		case "burst":
+			fault.Burst, err = strconv.Atoi(value)
+		default:
+			return Fault{}, fmt.Errorf("unknown fault option: %s", key)
+		}
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid fault option %s: %w", key, err)
+		}
+	}
+
+	if err := fault.normalize(); err != nil {
+		return Fault{}, err
+	}
+	return fault, nil
+}
+
+// normalize validates the fault and fills in the default burst size
+func (f *Fault) normalize() error {
+	if !f.Reset && (f.Status < 400 || f.Status > 599) {
+		return fmt.Errorf("fault needs reset or an error status, got %d", f.Status)
+	}
+	if f.Probability == 0 && f.Every == 0 {
+		return fmt.Errorf("fault needs a probability or an every/burst schedule")
+	}
+	if f.Every > 0 && f.Burst <= 0 {
+		f.Burst = 1
+	}
+	return nil
+}
+
+// injectFaults wraps a handler with the configured faults. The first fault
+// that triggers for a request wins.
+func (s *Server) injectFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, fault := range s.faults {
+			if !strings.HasPrefix(r.URL.Path, fault.PathPrefix) {
+				continue
+			}
+
+			triggered := fault.scheduled()
+			if !triggered && fault.Probability > 0 {
+				triggered = s.float64() < fault.Probability
+			}
+			if !triggered {
+				continue
+			}
+
+			if fault.Reset {
+				resetConnection(w)
+				return
+			}
+			http.Error(w, http.StatusText(fault.Status), fault.Status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resetConnection aborts the connection so the client sees a reset rather
+// than a response
+func resetConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection reset not supported", http.StatusInternalServerError)
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		// A zero linger makes Close send RST instead of FIN
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+}
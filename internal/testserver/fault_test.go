@@ -0,0 +1,103 @@
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		spec      string
+		expected  Fault
+		wantError bool
+	}{
+		{
+			name:     "probabilistic status",
+			spec:     "status=503,probability=0.25",
+			expected: Fault{Status: 503, Probability: 0.25},
+		},
+		{
+			name:     "scheduled reset on path",
+			spec:     "reset,every=10,burst=3,path=/pages/",
+			expected: Fault{Reset: true, Every: 10, Burst: 3, PathPrefix: "/pages/"},
+		},
+		{
+			name:     "burst defaults to one",
+			spec:     "status=403,every=5",
+			expected: Fault{Status: 403, Every: 5, Burst: 1},
+		},
+		{name: "unknown option", spec: "status=500,probability=1,color=red", wantError: true},
+		{name: "non-error status", spec: "status=200,probability=1", wantError: true},
+		{name: "no trigger", spec: "status=500", wantError: true},
+		{name: "invalid number", spec: "status=abc,probability=1", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fault, err := ParseFault(tt.spec)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fault)
+		})
+	}
+}
+
+func TestScheduledFaultBursts(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Faults: []Fault{{PathPrefix: "/pages/", Status: http.StatusForbidden, Every: 4, Burst: 2}},
+	}).Handler())
+	defer server.Close()
+
+	var statuses []int
+	for range 8 {
+		resp, _ := get(t, server.URL+"/pages/1")
+		statuses = append(statuses, resp.StatusCode)
+	}
+
+	assert.Equal(t, []int{403, 403, 200, 200, 403, 403, 200, 200}, statuses)
+
+	// Paths outside the prefix are unaffected
+	resp, _ := get(t, server.URL+"/local-sitemap.xml")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestProbabilisticFault(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Faults: []Fault{{Status: http.StatusServiceUnavailable, Probability: 1}},
+	}).Handler())
+	defer server.Close()
+
+	resp, _ := get(t, server.URL+"/pages/1")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestResetFault(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Faults: []Fault{{Reset: true, Every: 1, Burst: 1}},
+	}).Handler())
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(server.URL + "/pages/1")
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package testserver
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// generateParams describes a generated sitemap covering the page range
+// [offset, offset+urls)
+type generateParams struct {
+	urls     int
+	children int
+	depth    int
+	offset   int
+}
+
+// parseGenerateParams reads the generator query parameters
+func parseGenerateParams(query url.Values) (generateParams, error) {
+	params := generateParams{urls: 100, depth: 1}
+
+	fields := []struct {
+		name  string
+		value *int
+	}{
+		{"urls", &params.urls},
+		{"children", &params.children},
+		{"depth", &params.depth},
+		{"offset", &params.offset},
+	}
+	for _, field := range fields {
+		raw := query.Get(field.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return generateParams{}, fmt.Errorf("invalid %s: %q", field.name, raw)
+		}
+		*field.value = value
+	}
+
+	if params.children > 0 && params.depth < 1 {
+		params.depth = 1
+	}
+	return params, nil
+}
+
+// handleGenerateSitemap streams a synthetic sitemap. With children > 0 it
+// returns a sitemap index whose children split the URL range between them,
+// nesting further indexes until depth is exhausted.
+//
+//	/generate/sitemap?urls=1000000&children=50&depth=2
+func (s *Server) handleGenerateSitemap(w http.ResponseWriter, r *http.Request) {
+	params, err := parseGenerateParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	writer := bufio.NewWriter(w)
+	base := baseURL(r)
+
+	if params.children > 0 {
+		writeGeneratedIndex(writer, base, params)
+	} else {
+		writeGeneratedURLSet(writer, base, params)
+	}
+
+	// The status line is already sent, so a failed flush can only be dropped
+	_ = writer.Flush()
+}
+
+// writeGeneratedIndex writes a sitemap index splitting the range across children
+func writeGeneratedIndex(writer *bufio.Writer, base string, params generateParams) {
+	_, _ = writer.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	_, _ = writer.WriteString(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+
+	childDepth := params.depth - 1
+	childChildren := params.children
+	if childDepth == 0 {
+		childChildren = 0
+	}
+
+	perChild := params.urls / params.children
+	remainder := params.urls % params.children
+	offset := params.offset
+	for i := range params.children {
+		count := perChild
+		if i < remainder {
+			count++
+		}
+
+		query := url.Values{}
+		query.Set("urls", strconv.Itoa(count))
+		query.Set("offset", strconv.Itoa(offset))
+		if childChildren > 0 {
+			query.Set("children", strconv.Itoa(childChildren))
+			query.Set("depth", strconv.Itoa(childDepth))
+		}
+
+		_, _ = writer.WriteString("  <sitemap><loc>")
+		_ = xml.EscapeText(writer, []byte(base+"/generate/sitemap?"+query.Encode()))
+		_, _ = writer.WriteString("</loc></sitemap>\n")
+		offset += count
+	}
+
+	_, _ = writer.WriteString("</sitemapindex>\n")
+}
+
+// writeGeneratedURLSet writes a URL set of synthetic pages
+func writeGeneratedURLSet(writer *bufio.Writer, base string, params generateParams) {
+	_, _ = writer.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	_, _ = writer.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for i := range params.urls {
+		_, _ = fmt.Fprintf(writer, "  <url><loc>%s/pages/%d</loc></url>\n", base, params.offset+i+1)
+	}
+	_, _ = writer.WriteString("</urlset>\n")
+}
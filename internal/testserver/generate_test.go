@@ -0,0 +1,56 @@
+package testserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateURLSet(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	resp, body := get(t, server.URL+"/generate/sitemap?urls=5&offset=10")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 5, strings.Count(body, "<loc>"))
+	assert.Contains(t, body, server.URL+"/pages/11</loc>")
+	assert.Contains(t, body, server.URL+"/pages/15</loc>")
+}
+
+func TestGenerateNestedIndex(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	urls, err := parser.NewParser(10*time.Second).ParseSitemap(server.URL+"/generate/sitemap?urls=25&children=3&depth=2", nil)
+	require.NoError(t, err)
+	require.Len(t, urls, 25)
+
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		seen[url.Loc] = true
+	}
+	for i := 1; i <= 25; i++ {
+		assert.True(t, seen[fmt.Sprintf("%s/pages/%d", server.URL, i)], "page %d missing", i)
+	}
+}
+
+func TestGenerateInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	resp, _ := get(t, server.URL+"/generate/sitemap?urls=-1")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,126 @@
+package testserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Latency describes the response delay added to matching requests
+type Latency struct {
+	// PathPrefix limits the rule to paths with this prefix; empty matches all
+	PathPrefix string `yaml:"path"`
+
+	// Base is added to every matching request
+	Base time.Duration `yaml:"base"`
+
+	// Jitter adds a uniformly random extra delay in [0, Jitter)
+	Jitter time.Duration `yaml:"jitter"`
+
+	// Ramp is added for every RampEvery matching requests after the first
+	// RampAfter, producing a steadily degrading origin
+	Ramp      time.Duration `yaml:"ramp"`
+	RampEvery int           `yaml:"ramp_every"`
+	RampAfter int           `yaml:"ramp_after"`
+
+	// Max caps the total delay; zero means no cap
+	Max time.Duration `yaml:"max"`
+}
+
+// latencyState tracks how many requests a latency rule has matched
+type latencyState struct {
+	Latency
+
+	mu      sync.Mutex
+	matched int
+}
+
+// next returns the delay for the next matching request. jitter is a random
+// number in [0, 1).
+func (l *latencyState) next(jitter float64) time.Duration {
+	l.mu.Lock()
+	n := l.matched
+	l.matched++
+	l.mu.Unlock()
+
+	delay := l.Base + time.Duration(jitter*float64(l.Jitter))
+	if l.Ramp > 0 && n >= l.RampAfter {
+		every := max(l.RampEvery, 1)
+		delay += l.Ramp * time.Duration((n-l.RampAfter)/every+1)
+	}
+	if l.Max > 0 && delay > l.Max {
+		delay = l.Max
+	}
+	return delay
+}
+
+// ParseLatency parses a latency specification such as
+// "path=/pages/,base=50ms,jitter=20ms,ramp=10ms,ramp-every=5,ramp-after=100,max=3s"
+func ParseLatency(spec string) (Latency, error) {
+	var latency Latency
+	for _, part := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		var err error
+		switch key {
+		case "path":
+			latency.PathPrefix = value
+		case "base":
+			latency.Base, err = time.ParseDuration(value)
+		case "jitter":
+			latency.Jitter, err = time.ParseDuration(value)
+		case "ramp":
+			latency.Ramp, err = time.ParseDuration(value)
+		case "ramp-every":
+			latency.RampEvery, err = strconv.Atoi(value)
+		case "ramp-after":
+			latency.RampAfter, err = strconv.Atoi(value)
+		case "max":
+			latency.Max, err = time.ParseDuration(value)
+		default:
+			return Latency{}, fmt.Errorf("unknown latency option: %s", key)
+		}
+		if err != nil {
+			return Latency{}, fmt.Errorf("invalid latency option %s: %w", key, err)
+		}
+	}
+
+	if err := latency.validate(); err != nil {
+		return Latency{}, err
+	}
+	return latency, nil
+}
+
+// validate rejects negative durations
+func (l Latency) validate() error {
+	if l.Base < 0 || l.Jitter < 0 || l.Ramp < 0 || l.Max < 0 {
+		return fmt.Errorf("latency durations cannot be negative")
+	}
+	return nil
+}
+
+// shapeLatency delays matching requests before passing them on. The first
+// matching rule wins.
+func (s *Server) shapeLatency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, latency := range s.latencies {
+			if !strings.HasPrefix(r.URL.Path, latency.PathPrefix) {
+				continue
+			}
+
+			timer := time.NewTimer(latency.next(s.float64()))
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+			break
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
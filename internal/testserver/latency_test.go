@@ -0,0 +1,102 @@
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLatency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		spec      string
+		expected  Latency
+		wantError bool
+	}{
+		{
+			name:     "base and jitter",
+			spec:     "base=50ms,jitter=20ms",
+			expected: Latency{Base: 50 * time.Millisecond, Jitter: 20 * time.Millisecond},
+		},
+		{
+			name: "ramp on path",
+			spec: "path=/pages/,ramp=10ms,ramp-every=5,ramp-after=100,max=3s",
+			expected: Latency{
+				PathPrefix: "/pages/",
+				Ramp:       10 * time.Millisecond,
+				RampEvery:  5,
+				RampAfter:  100,
+				Max:        3 * time.Second,
+			},
+		},
+		{name: "unknown option", spec: "base=1s,speed=fast", wantError: true},
+		{name: "invalid duration", spec: "base=soon", wantError: true},
+		{name: "negative duration", spec: "base=-1s", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			latency, err := ParseLatency(tt.spec)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, latency)
+		})
+	}
+}
+
+func TestLatencyRamp(t *testing.T) {
+	t.Parallel()
+
+	state := &latencyState{Latency: Latency{
+		Base:      10 * time.Millisecond,
+		Jitter:    10 * time.Millisecond,
+		Ramp:      5 * time.Millisecond,
+		RampEvery: 2,
+		RampAfter: 2,
+		Max:       25 * time.Millisecond,
+	}}
+
+	var delays []time.Duration
+	for range 8 {
+		delays = append(delays, state.next(0))
+	}
+
+	ms := time.Millisecond
+	assert.Equal(t, []time.Duration{10 * ms, 10 * ms, 15 * ms, 15 * ms, 20 * ms, 20 * ms, 25 * ms, 25 * ms}, delays)
+	assert.Equal(t, 25*ms, state.next(0.5), "delay is capped at max")
+}
+
+func TestLatencyJitter(t *testing.T) {
+	t.Parallel()
+
+	state := &latencyState{Latency: Latency{Base: 10 * time.Millisecond, Jitter: 10 * time.Millisecond}}
+	assert.Equal(t, 15*time.Millisecond, state.next(0.5))
+}
+
+func TestShapeLatencyDelaysMatchingPaths(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Latencies: []Latency{{PathPrefix: "/pages/", Base: 50 * time.Millisecond}},
+	}).Handler())
+	defer server.Close()
+
+	start := time.Now()
+	resp, _ := get(t, server.URL+"/pages/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	start = time.Now()
+	get(t, server.URL+"/local-sitemap.xml")
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package testserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit configures the token bucket that answers excess requests with
+// 429 Too Many Requests
+type RateLimit struct {
+	// Rate is the sustained number of requests per second allowed
+	Rate float64 `yaml:"rate"`
+
+	// Burst is the bucket size; defaults to one second's worth of requests
+	Burst int `yaml:"burst"`
+
+	// RetryAfter is sent in the Retry-After header; zero derives it from the
+	// time until the next token is available
+	RetryAfter time.Duration `yaml:"retry_after"`
+}
+
+// limitRate rejects requests exceeding the configured rate with a 429 and a
+// Retry-After header
+func (s *Server) limitRate(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter.Allow() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := s.config.RateLimit.RetryAfter
+		if retryAfter == 0 {
+			reservation := s.limiter.Reserve()
+			retryAfter = reservation.Delay()
+			reservation.Cancel()
+		}
+
+		// Retry-After is whole seconds; round up so compliant clients succeed
+		seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	})
+}
+
+// newLimiter creates the token bucket for a rate limit configuration
+func newLimiter(cfg *RateLimit) *rate.Limiter {
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = max(int(math.Ceil(cfg.Rate)), 1)
+	}
+	return rate.NewLimiter(rate.Limit(cfg.Rate), burst)
+}
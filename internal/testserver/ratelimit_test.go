@@ -0,0 +1,51 @@
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		rateLimit          *RateLimit
+		expectedRetryAfter string
+	}{
+		{name: "derived retry after", rateLimit: &RateLimit{Rate: 0.5, Burst: 2}, expectedRetryAfter: "2"},
+		{name: "fixed retry after", rateLimit: &RateLimit{Rate: 0.5, Burst: 2, RetryAfter: 5 * time.Second}, expectedRetryAfter: "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(New(Config{RateLimit: tt.rateLimit}).Handler())
+			defer server.Close()
+
+			for range 2 {
+				resp, _ := get(t, server.URL+"/pages/1")
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+
+			resp, _ := get(t, server.URL+"/pages/1")
+			assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			assert.Equal(t, tt.expectedRetryAfter, resp.Header.Get("Retry-After"))
+		})
+	}
+}
+
+func TestRateLimitDefaultBurst(t *testing.T) {
+	t.Parallel()
+
+	limiter := newLimiter(&RateLimit{Rate: 2.5})
+	assert.Equal(t, 3, limiter.Burst())
+
+	limiter = newLimiter(&RateLimit{Rate: 0.1})
+	assert.Equal(t, 1, limiter.Burst())
+}
@@ -0,0 +1,234 @@
+package testserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Route scripts the responses for a path. Each matching request takes the
+// next status from Statuses, so a route can fail a fixed number of times
+// before recovering.
+type Route struct {
+	// Path matches exactly, or as a prefix when it ends in "*"
+	Path string `yaml:"path"`
+
+	// Method limits the route to one HTTP method; empty matches all
+	Method string `yaml:"method"`
+
+	// Statuses is the sequence of status codes returned; defaults to 200
+	Statuses []int `yaml:"statuses"`
+
+	// Repeat cycles through Statuses instead of holding the last one
+	Repeat bool `yaml:"repeat"`
+
+	// Headers are added to every response
+	Headers map[string]string `yaml:"headers"`
+
+	// Latency delays every response
+	Latency time.Duration `yaml:"latency"`
+
+	// ContentType defaults to text/html
+	ContentType string `yaml:"content_type"`
+
+	// Body is a text/template rendered with RouteData; empty sends the
+	// status text
+	Body string `yaml:"body"`
+}
+
+// RouteData is available to route body templates
+type RouteData struct {
+	Path    string
+	Query   url.Values
+	Host    string
+	BaseURL string
+	Count   int
+	Status  int
+
+	// Header holds the request headers
+	Header http.Header
+}
+
+// routeState tracks how many requests a route has matched
+type routeState struct {
+	Route
+
+	body *template.Template
+	err  error
+
+	mu      sync.Mutex
+	matched int
+}
+
+// newRouteState compiles the route body template. A template error is kept
+// and reported on every matching request, since ParseScenario has already
+// rejected invalid templates for file-based scenarios.
+func newRouteState(route Route) *routeState {
+	state := &routeState{Route: route}
+	state.body, state.err = template.New(route.Path).Parse(route.Body)
+	return state
+}
+
+// matches reports whether the route applies to a request
+func (r *routeState) matches(req *http.Request) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+	return req.URL.Path == r.Path
+}
+
+// next returns the 1-based request count and the status for the next
+// matching request
+func (r *routeState) next() (int, int) {
+	r.mu.Lock()
+	n := r.matched
+	r.matched++
+	r.mu.Unlock()
+
+	if len(r.Statuses) == 0 {
+		return n + 1, http.StatusOK
+	}
+	if r.Repeat {
+		return n + 1, r.Statuses[n%len(r.Statuses)]
+	}
+	return n + 1, r.Statuses[min(n, len(r.Statuses)-1)]
+}
+
+// validate checks the route has a path, valid statuses and a parsable body
+func (r Route) validate() error {
+	if r.Path == "" {
+		return fmt.Errorf("route needs a path")
+	}
+	for _, status := range r.Statuses {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("route %s has invalid status %d", r.Path, status)
+		}
+	}
+	if r.Latency < 0 {
+		return fmt.Errorf("route %s latency cannot be negative", r.Path)
+	}
+	if _, err := template.New(r.Path).Parse(r.Body); err != nil {
+		return fmt.Errorf("route %s has invalid body template: %w", r.Path, err)
+	}
+	return nil
+}
+
+// LoadScenario reads a YAML scenario file into a server configuration
+func LoadScenario(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario decodes a YAML scenario into a server configuration. Unknown
+// keys are rejected so that typos do not silently disable a behavior.
+func ParseScenario(data []byte) (Config, error) {
+	var cfg Config
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+
+	for i := range cfg.Faults {
+		if err := cfg.Faults[i].normalize(); err != nil {
+			return Config{}, fmt.Errorf("invalid scenario fault %d: %w", i+1, err)
+		}
+	}
+	for i, latency := range cfg.Latencies {
+		if err := latency.validate(); err != nil {
+			return Config{}, fmt.Errorf("invalid scenario latency %d: %w", i+1, err)
+		}
+	}
+	if cfg.RateLimit != nil && cfg.RateLimit.Rate <= 0 {
+		return Config{}, fmt.Errorf("invalid scenario rate limit: rate must be positive")
+	}
+	for _, route := range cfg.Routes {
+		if err := route.validate(); err != nil {
+			return Config{}, fmt.Errorf("invalid scenario: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// scriptRoutes answers requests matching a scripted route before they reach
+// the built-in endpoints. The first matching route wins.
+func (s *Server) scriptRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range s.scripted {
+			if route.matches(r) {
+				s.serveRoute(w, r, route)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveRoute writes the next scripted response for a route
+func (s *Server) serveRoute(w http.ResponseWriter, r *http.Request, route *routeState) {
+	count, status := route.next()
+
+	if route.Latency > 0 {
+		timer := time.NewTimer(route.Latency)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	if route.err != nil {
+		http.Error(w, fmt.Sprintf("invalid body template: %v", route.err), http.StatusInternalServerError)
+		return
+	}
+
+	var body bytes.Buffer
+	if route.Body == "" {
+		body.WriteString(http.StatusText(status))
+	} else {
+		data := RouteData{
+			Path:    r.URL.Path,
+			Query:   r.URL.Query(),
+			Host:    r.Host,
+			BaseURL: baseURL(r),
+			Count:   count,
+			Status:  status,
+			Header:  r.Header,
+		}
+		if err := route.body.Execute(&body, data); err != nil {
+			http.Error(w, fmt.Sprintf("failed to render body: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	for name, value := range route.Headers {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
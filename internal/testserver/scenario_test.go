@@ -0,0 +1,158 @@
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScenario(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		yaml      string
+		expected  Config
+		wantError bool
+	}{
+		{name: "empty", yaml: "", expected: Config{}},
+		{
+			name: "behaviors and routes",
+			yaml: `
+pages: 5
+seed: 7
+faults:
+  - {path: /pages/, status: 503, every: 4}
+latencies:
+  - {path: /pages/, base: 20ms, jitter: 5ms}
+cache: {ttl: 1m, header: CF-Cache-Status}
+rate_limit: {rate: 10, burst: 2, retry_after: 3s}
+routes:
+  - path: /pages/flaky
+    statuses: [500, 200]
+    headers: {X-Test: "1"}
+    latency: 10ms
+`,
+			expected: Config{
+				Pages:     5,
+				Seed:      7,
+				Faults:    []Fault{{PathPrefix: "/pages/", Status: 503, Every: 4, Burst: 1}},
+				Latencies: []Latency{{PathPrefix: "/pages/", Base: 20 * time.Millisecond, Jitter: 5 * time.Millisecond}},
+				Cache:     &Cache{TTL: time.Minute, Header: "CF-Cache-Status"},
+				RateLimit: &RateLimit{Rate: 10, Burst: 2, RetryAfter: 3 * time.Second},
+				Routes: []Route{{
+					Path:     "/pages/flaky",
+					Statuses: []int{500, 200},
+					Headers:  map[string]string{"X-Test": "1"},
+					Latency:  10 * time.Millisecond,
+				}},
+			},
+		},
+		{name: "unknown key", yaml: "pagez: 5", wantError: true},
+		{name: "invalid fault", yaml: "faults: [{status: 200, probability: 1}]", wantError: true},
+		{name: "negative latency", yaml: "latencies: [{base: -1s}]", wantError: true},
+		{name: "zero rate limit", yaml: "rate_limit: {burst: 1}", wantError: true},
+		{name: "route without path", yaml: "routes: [{statuses: [200]}]", wantError: true},
+		{name: "invalid route status", yaml: "routes: [{path: /a, statuses: [700]}]", wantError: true},
+		{name: "invalid body template", yaml: "routes: [{path: /a, body: '{{.Path'}]", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := ParseScenario([]byte(tt.yaml))
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
+
+func TestLoadScenarioExample(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadScenario("../../examples/scenarios/flaky-origin.yaml")
+	require.NoError(t, err)
+	assert.NotEmpty(t, cfg.Routes)
+
+	_, err = LoadScenario("does-not-exist.yaml")
+	assert.Error(t, err)
+}
+
+func TestScriptedRouteStatusSequence(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Routes: []Route{
+			{Path: "/held", Statuses: []int{503, 503, 200}},
+			{Path: "/cycled", Statuses: []int{200, 429}, Repeat: true},
+		},
+	}).Handler())
+	defer server.Close()
+
+	var held, cycled []int
+	for range 4 {
+		resp, _ := get(t, server.URL+"/held")
+		held = append(held, resp.StatusCode)
+		resp, _ = get(t, server.URL+"/cycled")
+		cycled = append(cycled, resp.StatusCode)
+	}
+
+	assert.Equal(t, []int{503, 503, 200, 200}, held)
+	assert.Equal(t, []int{200, 429, 200, 429}, cycled)
+}
+
+func TestScriptedRouteResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Routes: []Route{
+			{
+				Path:        "/scripted/*",
+				Method:      http.MethodGet,
+				Headers:     map[string]string{"X-Scenario": "yes"},
+				ContentType: "application/xml",
+				Body:        "<loc>{{.BaseURL}}{{.Path}}?n={{.Query.Get \"n\"}}</loc> #{{.Count}} {{.Status}}",
+			},
+		},
+	}).Handler())
+	defer server.Close()
+
+	resp, body := get(t, server.URL+"/scripted/a?n=1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "yes", resp.Header.Get("X-Scenario"))
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "<loc>"+server.URL+"/scripted/a?n=1</loc> #1 200", body)
+
+	_, body = get(t, server.URL+"/scripted/b?n=2")
+	assert.Contains(t, body, "#2")
+
+	// Other methods and paths fall through to the built-in endpoints
+	resp, err := http.Head(server.URL + "/scripted/a")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get(t, server.URL+"/pages/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestScriptedRouteDefaultBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{
+		Routes: []Route{{Path: "/gone", Statuses: []int{http.StatusGone}}},
+	}).Handler())
+	defer server.Close()
+
+	resp, body := get(t, server.URL+"/gone")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+	assert.Equal(t, "Gone", body)
+}
@@ -0,0 +1,184 @@
+package testserver
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultPages is the number of pages listed in the local sitemap
+const DefaultPages = 20
+
+// Config holds the test server configuration
+type Config struct {
+	// ExamplesDir is served as static files (sample sitemaps); empty disables it
+	ExamplesDir string `yaml:"-"`
+
+	// Pages is the number of synthetic pages listed in /local-sitemap.xml
+	Pages int `yaml:"pages"`
+
+	// Faults are injected before requests reach the regular handlers
+	Faults []Fault `yaml:"faults"`
+
+	// Latencies delay matching requests, including injected faults
+	Latencies []Latency `yaml:"latencies"`
+
+	// Cache enables the simulated caching layer when set
+	Cache *Cache `yaml:"cache"`
+
+	// RateLimit answers requests over the configured rate with 429 when set
+	RateLimit *RateLimit `yaml:"rate_limit"`
+
+	// Routes script per-path responses ahead of the built-in endpoints
+	Routes []Route `yaml:"routes"`
+
+	// Seed makes probabilistic behavior reproducible; zero picks a random seed
+	Seed uint64 `yaml:"seed"`
+}
+
+// Server is a configurable test origin for exercising the crawler end-to-end
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+
+	mu        sync.Mutex
+	random    *rand.Rand
+	faults    []*faultState
+	latencies []*latencyState
+	cache     *cacheState
+	limiter   *rate.Limiter
+	scripted  []*routeState
+	now       func() time.Time
+}
+
+// New creates a test server from the configuration
+func New(cfg Config) *Server {
+	if cfg.Pages <= 0 {
+		cfg.Pages = DefaultPages
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	s := &Server{
+		config: cfg,
+		mux:    http.NewServeMux(),
+		random: rand.New(rand.NewPCG(seed, seed)),
+		now:    time.Now,
+	}
+	for _, fault := range cfg.Faults {
+		s.faults = append(s.faults, &faultState{Fault: fault})
+	}
+	for _, latency := range cfg.Latencies {
+		s.latencies = append(s.latencies, &latencyState{Latency: latency})
+	}
+	for _, route := range cfg.Routes {
+		s.scripted = append(s.scripted, newRouteState(route))
+	}
+	if cfg.RateLimit != nil {
+		s.limiter = newLimiter(cfg.RateLimit)
+	}
+	if cfg.Cache != nil {
+		cache := *cfg.Cache
+		if cache.Header == "" {
+			cache.Header = DefaultCacheHeader
+		}
+		s.cache = &cacheState{Cache: cache, stored: make(map[string]time.Time)}
+	}
+
+	s.routes()
+	return s
+}
+
+// Handler returns the HTTP handler with all configured behaviors applied
+func (s *Server) Handler() http.Handler {
+	return s.limitRate(s.shapeLatency(s.injectFaults(s.simulateCache(s.scriptRoutes(s.mux)))))
+}
+
+// routes registers the built-in endpoints
+func (s *Server) routes() {
+	s.mux.HandleFunc("/local-sitemap.xml", s.handleLocalSitemap)
+	s.mux.HandleFunc("/pages/", s.handlePage)
+	s.mux.HandleFunc("/generate/sitemap", s.handleGenerateSitemap)
+	s.mux.HandleFunc("/variants/", s.handleVariant)
+
+	var files http.Handler = http.NotFoundHandler()
+	if s.config.ExamplesDir != "" {
+		files = http.FileServer(http.Dir(s.config.ExamplesDir))
+	}
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			s.handleIndex(w)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// handleIndex lists the available sitemaps
+func (s *Server) handleIndex(w http.ResponseWriter) {
+	html := `<h1>Sitemap Test Server</h1>
+<p>Available sitemaps:</p>
+<ul>
+<li><a href='/local-sitemap.xml'>Local Sitemap</a></li>
+<li><a href='/generate/sitemap?urls=1000&amp;children=10'>Generated Sitemap Index</a></li>
+<li><a href='/sample-sitemap.xml'>Sample Sitemap</a></li>
+<li><a href='/sitemap-index.xml'>Sitemap Index</a></li>
+<li><a href='/plain-sitemap.txt'>Plain Text Sitemap</a></li>
+</ul>
+<p>Prefix any path with /variants/{gzip,deflate,br,gzip-file,gzip-unlabeled,gzip-double,mislabeled,wrong-type}/ to serve it compressed or mislabeled.</p>`
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := fmt.Fprint(w, html); err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// handleLocalSitemap serves a sitemap whose URLs point back at this server
+func (s *Server) handleLocalSitemap(w http.ResponseWriter, r *http.Request) {
+	base := baseURL(r)
+
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for i := 1; i <= s.config.Pages; i++ {
+		fmt.Fprintf(&builder, "  <url><loc>%s/pages/%d</loc></url>\n", base, i)
+	}
+	builder.WriteString("</urlset>\n")
+
+	w.Header().Set("Content-Type", "application/xml")
+	if _, err := fmt.Fprint(w, builder.String()); err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// handlePage serves a small HTML page for any path under /pages/
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := fmt.Fprintf(w, "<html><head><title>%s</title></head><body><h1>%s</h1></body></html>", r.URL.Path, r.URL.Path); err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// float64 returns a random number in [0, 1) from the server's seeded source
+func (s *Server) float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.random.Float64()
+}
+
+// baseURL reconstructs the scheme and host the client used to reach the server
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,52 @@
+package testserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestLocalSitemapListsPages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{Pages: 3}).Handler())
+	defer server.Close()
+
+	resp, body := get(t, server.URL+"/local-sitemap.xml")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, strings.Count(body, "<loc>"))
+	assert.Contains(t, body, "<loc>"+server.URL+"/pages/3</loc>")
+
+	resp, body = get(t, server.URL+"/pages/3")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "/pages/3")
+}
+
+func TestExamplesDirDisabled(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	resp, _ := get(t, server.URL+"/sample-sitemap.xml")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,196 @@
+package testserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// certificateValidity is how long generated certificates remain valid
+const certificateValidity = 30 * 24 * time.Hour
+
+// PEMPair holds a PEM-encoded certificate and private key
+type PEMPair struct {
+	Cert []byte
+	Key  []byte
+}
+
+// Certificates holds a generated CA together with the server and client
+// certificates it signed
+type Certificates struct {
+	CA     PEMPair
+	Server PEMPair
+	Client PEMPair
+
+	caPool *x509.CertPool
+}
+
+// GenerateCertificates creates a throwaway self-signed CA, a server
+// certificate for the given hosts (DNS names or IPs), and a client
+// certificate for mutual TLS
+func GenerateCertificates(hosts []string) (*Certificates, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	caTemplate := certificateTemplate("sitemap-crawler test CA")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	serverTemplate := certificateTemplate("sitemap-crawler test server")
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+
+	clientTemplate := certificateTemplate("sitemap-crawler test client")
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	serverPair, err := signedPair(serverTemplate, caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+	clientPair, err := signedPair(clientTemplate, caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client certificate: %w", err)
+	}
+
+	caKeyPEM, err := encodeKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return &Certificates{
+		CA:     PEMPair{Cert: encodeCert(caDER), Key: caKeyPEM},
+		Server: serverPair,
+		Client: clientPair,
+		caPool: pool,
+	}, nil
+}
+
+// TLSConfig returns the server TLS configuration. With requireClientCert,
+// clients must present a certificate signed by the generated CA.
+func (c *Certificates) TLSConfig(requireClientCert bool) (*tls.Config, error) {
+	serverCert, err := tls.X509KeyPair(c.Server.Cert, c.Server.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if requireClientCert {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = c.caPool
+	}
+	return config, nil
+}
+
+// ClientTLSConfig returns a client configuration that trusts the generated CA
+// and presents the generated client certificate
+func (c *Certificates) ClientTLSConfig() (*tls.Config, error) {
+	clientCert, err := tls.X509KeyPair(c.Client.Cert, c.Client.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      c.caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// WriteFiles writes ca.pem, server.pem, server-key.pem, client.pem, and
+// client-key.pem to dir
+func (c *Certificates) WriteFiles(dir string) error {
+	files := map[string][]byte{
+		"ca.pem":         c.CA.Cert,
+		"server.pem":     c.Server.Cert,
+		"server-key.pem": c.Server.Key,
+		"client.pem":     c.Client.Cert,
+		"client-key.pem": c.Client.Key,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// certificateTemplate returns a certificate template with a random serial
+func certificateTemplate(commonName string) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		serial = big.NewInt(time.Now().UnixNano())
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"sitemap-crawler"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+// signedPair creates a key and a certificate signed by the CA
+func signedPair(template, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (PEMPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return PEMPair{}, err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return PEMPair{}, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return PEMPair{}, err
+	}
+	return PEMPair{Cert: encodeCert(der), Key: keyPEM}, nil
+}
+
+// encodeCert PEM-encodes a DER certificate
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// encodeKey PEM-encodes a private key in PKCS #8 form
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,93 @@
+package testserver
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSServer starts the test server behind TLS using generated certificates
+func newTLSServer(t *testing.T, certs *Certificates, requireClientCert bool) *httptest.Server {
+	t.Helper()
+
+	tlsConfig, err := certs.TLSConfig(requireClientCert)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(New(Config{Pages: 1}).Handler())
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTLSListener(t *testing.T) {
+	t.Parallel()
+
+	certs, err := GenerateCertificates([]string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+
+	clientConfig, err := certs.ClientTLSConfig()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		requireClientCert bool
+		clientConfig      *tls.Config
+		wantError         bool
+	}{
+		{name: "trusted CA", clientConfig: &tls.Config{RootCAs: clientConfig.RootCAs, MinVersion: tls.VersionTLS12}},
+		{name: "untrusted CA", clientConfig: &tls.Config{MinVersion: tls.VersionTLS12}, wantError: true},
+		{name: "insecure skip verify", clientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}}, // #nosec G402 -- exercising --insecure
+		{name: "mutual TLS with client certificate", requireClientCert: true, clientConfig: clientConfig},
+		{
+			name:              "mutual TLS without client certificate",
+			requireClientCert: true,
+			clientConfig:      &tls.Config{RootCAs: clientConfig.RootCAs, MinVersion: tls.VersionTLS12},
+			wantError:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newTLSServer(t, certs, tt.requireClientCert)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tt.clientConfig}}
+
+			resp, err := client.Get(server.URL + "/local-sitemap.xml")
+			if tt.wantError {
+				if resp != nil {
+					_ = resp.Body.Close()
+				}
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestCertificatesWriteFiles(t *testing.T) {
+	t.Parallel()
+
+	certs, err := GenerateCertificates([]string{"localhost"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, certs.WriteFiles(dir))
+
+	for _, name := range []string{"ca.pem", "server.pem", "server-key.pem", "client.pem", "client-key.pem"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.True(t, strings.HasPrefix(string(data), "-----BEGIN "), name)
+	}
+}
@@ -0,0 +1,163 @@
+// Package testutil runs the crawler in-process against the scenario test
+// server, so end-to-end behavior (crawler, backoff and stats together) can be
+// covered by ordinary Go tests.
+package testutil
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/crawler"
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/testserver"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// Harness is a running test server that crawls can be pointed at
+type Harness struct {
+	t      testing.TB
+	Server *httptest.Server
+}
+
+// Result is the outcome of a crawl run by the harness
+type Result struct {
+	// Err is the error returned by Crawler.Run
+	Err error
+
+	Final   stats.FinalStats
+	Cache   stats.CacheStats
+	Timings []stats.ServerTimingStats
+	Backoff map[string]interface{}
+
+	// Logs captures every entry the crawler logged
+	Logs *logtest.Hook
+
+	// Events are the events sent to a subscriber during the crawl
+	Events []crawler.Event
+}
+
+// New starts a test server with the given configuration. The server is
+// closed when the test finishes.
+func New(t testing.TB, cfg testserver.Config) *Harness {
+	t.Helper()
+
+	server := httptest.NewServer(testserver.New(cfg).Handler())
+	t.Cleanup(server.Close)
+
+	return &Harness{t: t, Server: server}
+}
+
+// Load starts a test server from a YAML scenario file
+func Load(t testing.TB, path string) *Harness {
+	t.Helper()
+
+	cfg, err := testserver.LoadScenario(path)
+	if err != nil {
+		t.Fatalf("failed to load scenario: %v", err)
+	}
+	return New(t, cfg)
+}
+
+// URL returns the absolute URL of a path on the test server
+func (h *Harness) URL(path string) string {
+	return h.Server.URL + path
+}
+
+// Config returns a crawl configuration for a sitemap on the test server. It
+// matches the command-line defaults except for fewer workers and short
+// backoff delays, so that tests finish quickly.
+func (h *Harness) Config(sitemapPath string) *config.Config {
+	return &config.Config{
+		Command:                          config.CommandCrawl,
+		SitemapURL:                       h.URL(sitemapPath),
+		MaxWorkers:                       4,
+		RequestRate:                      100,
+		RequestTimeout:                   5 * time.Second,
+		UserAgent:                        "SitemapCrawler/1.0",
+		Headers:                          map[string]string{},
+		CacheHeader:                      testserver.DefaultCacheHeader,
+		OutputFormat:                     "text",
+		Quiet:                            true,
+		ProgressInterval:                 5 * time.Second,
+		CoverageFormat:                   "json",
+		LastModTolerance:                 24 * time.Hour,
+		HARMode:                          har.ModeFailures,
+		HARSampleRate:                    0.1,
+		HARMaxBodyBytes:                  64 * 1024,
+		RecordMaxBodyBytes:               10 * 1024 * 1024,
+		ResultsSampleRate:                1,
+		RenderLimit:                      20,
+		RenderTimeout:                    30 * time.Second,
+		BackoffEnabled:                   true,
+		BackoffInitialDelay:              10 * time.Millisecond,
+		BackoffMaxDelay:                  100 * time.Millisecond,
+		BackoffMultiplier:                2.0,
+		ResponseTimeDegradationThreshold: 0.5,
+		ForbiddenErrorThreshold:          5,
+		ForbiddenErrorWindow:             5 * time.Second,
+		PingMinSuccessRate:               99,
+	}
+}
+
+// Run crawls with the given configuration and collects the results. Errors
+// from Run are returned in the Result; a crawler that cannot be created
+// fails the test.
+func (h *Harness) Run(cfg *config.Config) *Result {
+	h.t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	hook := logtest.NewLocal(logger)
+
+	c, err := crawler.New(cfg, logger)
+	if err != nil {
+		h.t.Fatalf("failed to create crawler: %v", err)
+	}
+
+	var events []crawler.Event
+	done := make(chan struct{})
+	subscription := c.Subscribe(16)
+	go func() {
+		defer close(done)
+		for event := range subscription {
+			events = append(events, event)
+		}
+	}()
+
+	result := &Result{Err: c.Run(), Logs: hook}
+	<-done
+	result.Events = events
+	result.Final = c.Stats().GetFinalStats()
+	result.Cache = c.Stats().GetCacheStats()
+	result.Timings = c.Stats().GetServerTimingStats()
+	result.Backoff = c.BackoffStats()
+	return result
+}
+
+// Cancelled reports whether the backoff manager cancelled the crawl
+func (r *Result) Cancelled() bool {
+	cancelled, _ := r.Backoff["cancelled"].(bool)
+	return cancelled
+}
+
+// BackoffActivated reports whether a crawl ended with backoff active
+func (r *Result) BackoffActivated() bool {
+	active, _ := r.Backoff["backoff_active"].(bool)
+	return active
+}
+
+// Logged reports whether any log entry has the given message
+func (r *Result) Logged(message string) bool {
+	for _, entry := range r.Logs.AllEntries() {
+		if entry.Message == message {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1858 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/analysis"
+	"github.com/benvon/sitemap-crawler/internal/audit"
+	"github.com/benvon/sitemap-crawler/internal/bodies"
+	"github.com/benvon/sitemap-crawler/internal/config"
+	"github.com/benvon/sitemap-crawler/internal/crawler"
+	"github.com/benvon/sitemap-crawler/internal/har"
+	"github.com/benvon/sitemap-crawler/internal/output"
+	"github.com/benvon/sitemap-crawler/internal/parser"
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/benvon/sitemap-crawler/internal/testserver"
+	"github.com/benvon/sitemap-crawler/internal/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthyCrawl(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 10})
+	result := h.Run(h.Config("/local-sitemap.xml"))
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 10, result.Final.TotalProcessed)
+	assert.Equal(t, 10, result.Final.TotalSuccess)
+	assert.Equal(t, 0, result.Final.TotalErrors)
+	assert.False(t, result.Cancelled())
+	assert.True(t, result.Logged("Crawling completed"))
+}
+
+func TestStructuredOutputPrintsFinalStats(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"json", "csv", "xml"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			h := New(t, testserver.Config{Pages: 3})
+			cfg := h.Config("/local-sitemap.xml")
+			cfg.OutputFormat = format
+			result := h.Run(cfg)
+
+			// The final statistics are printed in the format instead of logged
+			require.NoError(t, result.Err)
+			assert.Equal(t, 3, result.Final.TotalProcessed)
+			assert.False(t, result.Logged("Crawling completed"))
+		})
+	}
+}
+
+func TestServerErrorsActivateBackoff(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  10,
+		Routes: []testserver.Route{{Path: "/pages/3", Statuses: []int{http.StatusServiceUnavailable}}},
+	})
+	result := h.Run(h.Config("/local-sitemap.xml"))
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 10, result.Final.TotalProcessed)
+	assert.Equal(t, 1, result.Final.TotalErrors)
+	assert.Equal(t, map[stats.ErrorCategory]int{stats.ErrorHTTPStatus: 1}, result.Final.ErrorsByCategory)
+	assert.True(t, result.Logged("Server error detected, activating backoff"))
+}
+
+func TestVerbosityLogsResults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		verbose       int
+		wantSucceeded bool
+		wantFailed    bool
+	}{
+		{name: "default", verbose: 0, wantSucceeded: false, wantFailed: false},
+		{name: "-v logs failures", verbose: 1, wantSucceeded: false, wantFailed: true},
+		{name: "-vv logs every URL", verbose: 2, wantSucceeded: true, wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := New(t, testserver.Config{
+				Pages:  5,
+				Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+			})
+			cfg := h.Config("/local-sitemap.xml")
+			cfg.Verbose = tt.verbose
+			result := h.Run(cfg)
+
+			require.NoError(t, result.Err)
+			assert.Equal(t, tt.wantSucceeded, result.Logged("Request succeeded"))
+			assert.Equal(t, tt.wantFailed, result.Logged("Request failed"))
+		})
+	}
+}
+
+func TestFailuresFile(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.FailuresFile = filepath.Join(t.TempDir(), "failures.csv")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.FailuresFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], h.URL("/pages/2")+",crawl,failed,404,http_status,"), lines[1])
+}
+
+func TestCleanSitemap(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CleanSitemap = filepath.Join(t.TempDir(), "sitemap.xml")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.CleanSitemap)
+	require.NoError(t, err)
+	assert.Equal(t, 4, strings.Count(string(data), "<loc>"))
+	assert.NotContains(t, string(data), h.URL("/pages/2")+"<")
+	assert.True(t, result.Logged("Clean sitemap written"))
+}
+
+func TestRunMetadataInOutputs(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Version = "1.2.3"
+	cfg.Headers = map[string]string{"Authorization": "Bearer secret"}
+	cfg.ResultsFile = filepath.Join(t.TempDir(), "results.json")
+	cfg.FailuresFile = filepath.Join(t.TempDir(), "failures.csv")
+	cfg.CleanSitemap = filepath.Join(t.TempDir(), "sitemap.xml")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	var runID string
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Configuration loaded" {
+			runID, _ = entry.Data["run_id"].(string)
+		}
+	}
+	require.NotEmpty(t, runID)
+
+	results, err := output.LoadResults(cfg.ResultsFile)
+	require.NoError(t, err)
+	require.NotNil(t, results.Run)
+	assert.Equal(t, runID, results.Run.ID)
+	assert.Equal(t, "1.2.3", results.Run.Version)
+	assert.Equal(t, []string{cfg.SitemapURL}, results.Run.SitemapURLs)
+	assert.False(t, results.Run.FinishedAt.Before(results.Run.StartedAt))
+	assert.Equal(t, map[string]interface{}{"Authorization": config.Redacted}, results.Run.Config[config.FlagHeaders])
+	require.NotEmpty(t, results.Results)
+	for _, entry := range results.Results {
+		assert.Equal(t, cfg.SitemapURL, entry.Sitemap, entry.URL)
+		assert.False(t, entry.Start.IsZero(), entry.URL)
+		assert.Equal(t, entry.Duration, entry.End.Sub(entry.Start), entry.URL)
+		assert.Equal(t, time.UTC, entry.Start.Location(), entry.URL)
+	}
+
+	failures, err := os.ReadFile(cfg.FailuresFile)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(failures)), ","+runID), string(failures))
+
+	sitemap, err := os.ReadFile(cfg.CleanSitemap)
+	require.NoError(t, err)
+	assert.Contains(t, string(sitemap), "run "+runID)
+}
+
+func TestSampledResultsFile(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Cache:  &testserver.Cache{},
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.ResultsFile = filepath.Join(t.TempDir(), "results.json")
+	cfg.ResultsSampleRate = 0
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Results file written"))
+
+	// Every warm-up request misses and is kept, as is the failure in the
+	// verify pass; the verify pass's hits are only counted
+	results, err := output.LoadResults(cfg.ResultsFile)
+	require.NoError(t, err)
+	require.NotNil(t, results.Sampling)
+	assert.Equal(t, 0.0, results.Sampling.Rate)
+	assert.Equal(t, map[string]output.Omitted{"verify": {Results: 4, CacheHits: 4}}, results.Sampling.Omitted)
+
+	phases := make(map[string]int)
+	for _, entry := range results.Results {
+		phases[entry.Phase]++
+		if entry.Phase == "verify" {
+			assert.Equal(t, h.URL("/pages/2"), entry.URL)
+		}
+	}
+	assert.Equal(t, map[string]int{"warm-up": 5, "verify": 1}, phases)
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	t.Parallel()
+
+	// Slow misses keep the warm-up running across several progress ticks
+	h := New(t, testserver.Config{Pages: 5, Cache: &testserver.Cache{MissDelay: 20 * time.Millisecond}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.CacheHeader = testserver.DefaultCacheHeader
+	cfg.Quiet = true
+	cfg.ProgressInterval = time.Millisecond
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	phases := map[string]int{}
+	progress := 0
+	for _, event := range result.Events {
+		switch event.Type {
+		case crawler.EventResult:
+			require.NotNil(t, event.Result)
+			phases[event.Phase]++
+		case crawler.EventProgress:
+			require.NotNil(t, event.Progress)
+			progress++
+
+			// Each pass is measured against its own URLs
+			if phase := event.Progress.Phase; phase != nil {
+				assert.Equal(t, 2, phase.Count)
+				assert.Equal(t, 5, phase.Total)
+				assert.LessOrEqual(t, phase.Percentage, 100.0)
+			}
+		}
+	}
+	assert.Equal(t, map[string]int{stats.PhaseWarmUp: 5, stats.PhaseVerify: 5}, phases)
+	assert.Positive(t, progress)
+}
+
+func TestRetryPolicies(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 5,
+		Routes: []testserver.Route{
+			{Path: "/pages/1", Statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}},
+			{Path: "/pages/2", Statuses: []int{http.StatusBadGateway}},
+			{Path: "/pages/3", Statuses: []int{http.StatusNotFound, http.StatusOK}},
+		},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.BackoffEnabled = false
+	cfg.Retry = []string{"503=3:10ms", "5xx=1:10ms", "4xx=0"}
+	cfg.ResultsFile = filepath.Join(t.TempDir(), "results.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// /pages/1 succeeds on its third attempt, /pages/2 fails after one
+	// retry and /pages/3 is not retried
+	assert.Equal(t, 3, result.Final.TotalSuccess)
+	assert.Equal(t, 3, result.Final.TotalRetries)
+	assert.Equal(t, 2, result.Final.RetriedURLs)
+	assert.Equal(t, 1, result.Final.SuccessAfterRetry)
+	assert.Equal(t, 3, result.Final.MaxAttempts)
+
+	results, err := output.LoadResults(cfg.ResultsFile)
+	require.NoError(t, err)
+	attempts := map[string]int{}
+	for _, entry := range results.Results {
+		attempts[entry.URL] = entry.Attempts
+	}
+	assert.Equal(t, 3, attempts[h.URL("/pages/1")])
+	assert.Equal(t, 2, attempts[h.URL("/pages/2")])
+	assert.Equal(t, 1, attempts[h.URL("/pages/3")])
+}
+
+func TestResolverLogged(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 2})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Resolver = "127.0.0.1"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// The resolver is logged as lookups go to it, with the DNS port
+	var resolver string
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Configuration loaded" {
+			resolver, _ = entry.Data["resolver"].(string)
+		}
+	}
+	assert.Equal(t, "127.0.0.1:53", resolver)
+}
+
+func TestRetryRulesLogged(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		retry  []string
+		logged bool
+	}{
+		{name: "retries enabled", retry: []string{"5xx=2:10ms", "4xx=0"}, logged: true},
+		{name: "only rules that disable retries", retry: []string{"4xx=0"}, logged: false},
+		{name: "no rules", logged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := New(t, testserver.Config{Pages: 2})
+			cfg := h.Config("/local-sitemap.xml")
+			cfg.Retry = tt.retry
+			result := h.Run(cfg)
+			require.NoError(t, result.Err)
+
+			logged := false
+			for _, entry := range result.Logs.AllEntries() {
+				if entry.Message == "Configuration loaded" {
+					_, logged = entry.Data["retry"]
+				}
+			}
+			assert.Equal(t, tt.logged, logged)
+		})
+	}
+}
+
+func TestPhaseTimeouts(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 3,
+		Routes: []testserver.Route{
+			{Path: "/pages/1", Statuses: []int{http.StatusOK}, Latency: 500 * time.Millisecond},
+		},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.BackoffEnabled = false
+	cfg.ResponseHeaderTimeout = 50 * time.Millisecond
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	assert.Equal(t, 2, result.Final.TotalSuccess)
+	assert.Equal(t, 1, result.Final.ErrorsByCategory[stats.ErrorHeaderTimeout])
+}
+
+func TestParseStats(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 10})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Command = config.CommandParseStats
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// Parsing requests no pages
+	assert.Equal(t, 0, result.Final.TotalProcessed)
+
+	entry := result.Logs.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "Sitemap statistics", entry.Message)
+	assert.Equal(t, 10, entry.Data["unique_urls"])
+	assert.Equal(t, 0, entry.Data["duplicate_listings"])
+}
+
+func TestParseValidate(t *testing.T) {
+	t.Parallel()
+
+	index := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>{{.BaseURL}}/local-sitemap.xml</loc></sitemap>
+<sitemap><loc>{{.BaseURL}}/legacy-sitemap.xml</loc></sitemap>
+</sitemapindex>`
+	legacy := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.google.com/schemas/sitemap/0.84">
+<url><loc>{{.BaseURL}}/old</loc><lastmod>2024-13-01</lastmod></url>
+</urlset>`
+	h := New(t, testserver.Config{Pages: 3, Routes: []testserver.Route{
+		{Path: "/validate-index.xml", ContentType: "application/xml", Body: index},
+		{Path: "/legacy-sitemap.xml", ContentType: "application/xml", Body: legacy},
+	}})
+
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Command = config.CommandParseCheck
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 0, result.Final.TotalProcessed)
+
+	cfg = h.Config("/validate-index.xml")
+	cfg.Command = config.CommandParseCheck
+	result = h.Run(cfg)
+	require.ErrorContains(t, result.Err, "1 of 3 sitemaps do not follow the sitemaps protocol")
+	assert.Equal(t, 0, result.Final.TotalProcessed)
+
+	entry := result.Logs.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "Sitemap validation", entry.Message)
+	assert.Equal(t, 2, entry.Data["violations"])
+}
+
+func TestRequestModeHead(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 3,
+		Routes: []testserver.Route{
+			{Path: "/pages/1", Method: http.MethodHead, Statuses: []int{http.StatusMethodNotAllowed}},
+		},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.BackoffEnabled = false
+	cfg.RequestMode = config.RequestModeHead
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// Only a HEAD request matches the route
+	assert.Equal(t, 2, result.Final.TotalSuccess)
+	assert.Equal(t, 1, result.Final.TotalErrors)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 5,
+		Routes: []testserver.Route{
+			{Path: "/pages/1", Statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+		},
+	})
+	cassettePath := filepath.Join(t.TempDir(), "run.cassette")
+
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.BackoffEnabled = false
+	cfg.Record = cassettePath
+	recorded := h.Run(cfg)
+	require.NoError(t, recorded.Err)
+	assert.Equal(t, 5, recorded.Final.TotalSuccess)
+	assert.True(t, recorded.Logged("Cassette written"))
+
+	// The live server would now fail /pages/1; the cassette still has
+	// the recorded success
+	cfg = h.Config("/local-sitemap.xml")
+	cfg.BackoffEnabled = false
+	cfg.Replay = cassettePath
+	replayed := h.Run(cfg)
+	require.NoError(t, replayed.Err)
+	assert.Equal(t, 5, replayed.Final.TotalSuccess)
+	assert.False(t, replayed.Logged("Cassette had no recorded response for some requests"))
+}
+
+func TestMaxConcurrentPerHost(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 4,
+		Routes: []testserver.Route{
+			{Path: "/pages/*", Latency: 50 * time.Millisecond},
+		},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.MaxWorkers = 4
+	cfg.MaxConcurrentPerHost = 1
+
+	start := time.Now()
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// Four workers, but one request at a time to the only host
+	assert.Equal(t, 4, result.Final.TotalSuccess)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestMaxConcurrentPerHostCrawlsOtherHosts(t *testing.T) {
+	t.Parallel()
+
+	// The server answers as two hosts, 127.0.0.1 and localhost; the slow
+	// pages are listed first on the first host
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://127.0.0.1{{slice .Host 9}}/slow/1</loc></url>
+<url><loc>http://127.0.0.1{{slice .Host 9}}/slow/2</loc></url>
+<url><loc>http://127.0.0.1{{slice .Host 9}}/slow/3</loc></url>
+<url><loc>http://127.0.0.1{{slice .Host 9}}/slow/4</loc></url>
+<url><loc>http://localhost{{slice .Host 9}}/fast/1</loc></url>
+<url><loc>http://localhost{{slice .Host 9}}/fast/2</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/hosts-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/slow/*", Latency: 100 * time.Millisecond},
+		{Path: "/fast/*"},
+	}})
+	cfg := h.Config("/hosts-sitemap.xml")
+	cfg.MaxWorkers = 2
+	cfg.MaxConcurrentPerHost = 1
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 6, result.Final.TotalSuccess)
+
+	// The second worker crawls localhost while 127.0.0.1 is at its cap,
+	// rather than waiting behind the slow pages
+	var completed []string
+	for _, event := range result.Events {
+		if event.Type == crawler.EventResult {
+			completed = append(completed, event.Result.URL)
+		}
+	}
+	require.Len(t, completed, 6)
+	for _, url := range completed[:2] {
+		assert.Contains(t, url, "http://localhost")
+	}
+}
+
+func TestTimelineReport(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.TimelineReport = filepath.Join(t.TempDir(), "timeline.csv")
+	cfg.TimelineFormat = "csv"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.TimelineReport)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.GreaterOrEqual(t, len(lines), 2)
+	assert.Equal(t, "start,requests,errors,error_rate,p95,run_id", lines[0])
+
+	// The crawl may straddle a minute boundary, so add up every bucket
+	var requests, errors int
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, ",")
+		require.Len(t, fields, 6)
+		bucketRequests, err := strconv.Atoi(fields[1])
+		require.NoError(t, err)
+		bucketErrors, err := strconv.Atoi(fields[2])
+		require.NoError(t, err)
+		requests += bucketRequests
+		errors += bucketErrors
+	}
+	assert.Equal(t, 5, requests)
+	assert.Equal(t, 1, errors)
+	assert.True(t, result.Logged("Timeline report written"))
+}
+
+func TestBadgeFile(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  10,
+		Routes: []testserver.Route{{Path: "/pages/2", Statuses: []int{http.StatusNotFound}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.BadgeFile = filepath.Join(t.TempDir(), "badge.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.BadgeFile)
+	require.NoError(t, err)
+
+	var badge output.Badge
+	require.NoError(t, json.Unmarshal(data, &badge))
+	assert.Equal(t, output.Badge{SchemaVersion: 1, Label: "sitemap crawl", Message: "90.0% ok", Color: "red"}, badge)
+	assert.True(t, result.Logged("Badge file written"))
+}
+
+func TestReportsWrittenDespiteFailure(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3})
+	dir := t.TempDir()
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.ResultsFile = filepath.Join(dir, "missing", "results.json")
+	cfg.FailuresFile = filepath.Join(dir, "missing", "failures.csv")
+	cfg.BadgeFile = filepath.Join(dir, "badge.json")
+	result := h.Run(cfg)
+
+	// Both failures are reported, and the badge written after them
+	require.Error(t, result.Err)
+	assert.ErrorContains(t, result.Err, "results.json")
+	assert.ErrorContains(t, result.Err, "failures.csv")
+	assert.FileExists(t, cfg.BadgeFile)
+	assert.True(t, result.Logged("Badge file written"))
+}
+
+func TestHealthProbes(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.HealthAddr = "127.0.0.1:0"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	var addr string
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Serving health probes" {
+			addr, _ = entry.Data["addr"].(string)
+		}
+	}
+	require.NotEmpty(t, addr)
+
+	// The probes stop with the run
+	_, err := http.Get("http://" + addr + "/healthz")
+	assert.Error(t, err)
+}
+
+func TestPriorityPatternsOutlastDeadline(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 6})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.PriorityPatterns = []string{`/pages/[25]$`}
+
+	// The deadline has passed by the time the crawl starts
+	cfg.FinishBy = "1ns"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	assert.Equal(t, 2, result.Final.TotalProcessed)
+	assert.Equal(t, 2, result.Final.TotalSuccess)
+	assert.Equal(t, 4, result.Final.SkippedURLs)
+	assert.True(t, result.Logged("Deadline passed, skipping normal priority URLs"))
+}
+
+func TestSitemapRefresh(t *testing.T) {
+	t.Parallel()
+
+	// The first fetch lists /pages/old last; later fetches replace it with
+	// /pages/new
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/pages/1</loc></url>
+<url><loc>{{.BaseURL}}/pages/2</loc></url>
+<url><loc>{{.BaseURL}}/pages/3</loc></url>
+<url><loc>{{.BaseURL}}/pages/4</loc></url>
+<url><loc>{{.BaseURL}}/pages/5</loc></url>
+{{if eq .Count 1}}<url><loc>{{.BaseURL}}/pages/old</loc></url>{{else}}<url><loc>{{.BaseURL}}/pages/new</loc></url>{{end}}
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/refreshed-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/pages/*", Latency: 60 * time.Millisecond},
+	}})
+	cfg := h.Config("/refreshed-sitemap.xml")
+	cfg.MaxWorkers = 1
+	cfg.SitemapRefresh = 100 * time.Millisecond
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	assert.True(t, result.Logged("Sitemap refreshed"))
+	assert.Equal(t, 6, result.Final.TotalProcessed)
+	assert.Equal(t, 6, result.Final.TotalSuccess)
+}
+
+func TestCrawlMedia(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+	xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+	xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">
+<url>
+	<loc>{{.BaseURL}}/pages/1</loc>
+	<image:image><image:loc>{{.BaseURL}}/media/one.jpg</image:loc></image:image>
+	<video:video>
+		<video:thumbnail_loc>{{.BaseURL}}/media/thumb.jpg</video:thumbnail_loc>
+		<video:content_loc>{{.BaseURL}}/media/tour.mp4</video:content_loc>
+	</video:video>
+</url>
+<url>
+	<loc>{{.BaseURL}}/pages/2</loc>
+	<image:image><image:loc>{{.BaseURL}}/media/one.jpg</image:loc></image:image>
+</url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/media-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/*"},
+	}})
+
+	cfg := h.Config("/media-sitemap.xml")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 2, result.Final.TotalProcessed)
+
+	cfg = h.Config("/media-sitemap.xml")
+	cfg.CrawlMedia = true
+	cfg.CleanSitemap = filepath.Join(t.TempDir(), "sitemap.xml")
+	result = h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 5, result.Final.TotalProcessed)
+	assert.Equal(t, 5, result.Final.TotalSuccess)
+
+	// Media URLs are crawled but the clean sitemap lists only the pages
+	data, err := os.ReadFile(cfg.CleanSitemap)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "<loc>"))
+	assert.NotContains(t, string(data), "/media/")
+}
+
+func TestCrawlAlternates(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+<url>
+	<loc>{{.BaseURL}}/en/</loc>
+	<xhtml:link rel="alternate" hreflang="en" href="{{.BaseURL}}/en/"/>
+	<xhtml:link rel="alternate" hreflang="de" href="{{.BaseURL}}/de/"/>
+	<xhtml:link rel="alternate" hreflang="fr" href="{{.BaseURL}}/fr/"/>
+</url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/hreflang-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/fr/", Statuses: []int{http.StatusNotFound}},
+		{Path: "/*"},
+	}})
+	cfg := h.Config("/hreflang-sitemap.xml")
+	cfg.CrawlAlternates = true
+	cfg.FailuresFile = filepath.Join(t.TempDir(), "failures.csv")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// A missing localized variant fails like any other URL
+	assert.Equal(t, 3, result.Final.TotalProcessed)
+	assert.Equal(t, 2, result.Final.TotalSuccess)
+	failures, err := os.ReadFile(cfg.FailuresFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(failures), h.URL("/fr/"))
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3})
+	cfg := h.Config("/local-sitemap.xml")
+	path := filepath.Join(t.TempDir(), "stats.json")
+	cfg.Export = []string{"json=" + path}
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Stats exported"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var exported struct {
+		FinalStats stats.FinalStats `json:"final_stats"`
+		Results    []stats.Result   `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, 3, exported.FinalStats.TotalProcessed)
+	assert.Len(t, exported.Results, 3)
+}
+
+func TestAuditVariants(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/about</loc></url>
+<url><loc>{{.BaseURL}}/Contact</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/variants-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/about", Body: "<html><title>About</title></html>"},
+		{Path: "/about/", Statuses: []int{http.StatusMovedPermanently}, Headers: map[string]string{"Location": "/about"}},
+		{Path: "/ABOUT", Body: "<html><title>About</title></html>"},
+		{Path: "/Contact", Body: "<html><title>Contact</title></html>"},
+		{Path: "/contact", Body: "<html><title>Home</title></html>"},
+	}})
+	cfg := h.Config("/variants-sitemap.xml")
+	cfg.Command = config.CommandAudit
+	cfg.AuditVariants = 1
+	cfg.OutputFormat = "json"
+	cfg.AuditReport = filepath.Join(t.TempDir(), "audit.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.AuditReport)
+	require.NoError(t, err)
+	var report struct {
+		Pages []audit.PageReport `json:"pages"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	outcomes := make(map[string]string)
+	for _, page := range report.Pages {
+		for _, variant := range page.Variants {
+			outcomes[strings.TrimPrefix(variant.URL, h.URL(""))] = variant.Outcome
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/about/":   audit.OutcomeRedirect,
+		"/ABOUT":    audit.OutcomeDuplicate,
+		"/Contact/": audit.OutcomeNotFound,
+		"/contact":  audit.OutcomeDistinct,
+	}, outcomes)
+}
+
+func TestExpectStatus(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/api/ok</loc></url>
+<url><loc>{{.BaseURL}}/api/moved</loc></url>
+<url><loc>{{.BaseURL}}/legacy/about</loc></url>
+<url><loc>{{.BaseURL}}/gone/product</loc></url>
+<url><loc>{{.BaseURL}}/gone/revived</loc></url>
+<url><loc>{{.BaseURL}}/missing</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/expect-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/api/moved", Statuses: []int{http.StatusMovedPermanently}, Headers: map[string]string{"Location": "/api/ok"}},
+		{Path: "/legacy/*", Statuses: []int{http.StatusMovedPermanently}, Headers: map[string]string{"Location": "/missing"}},
+		{Path: "/gone/product", Statuses: []int{http.StatusGone}},
+		{Path: "/missing", Statuses: []int{http.StatusNotFound}},
+		{Path: "/*"},
+	}})
+	cfg := h.Config("/expect-sitemap.xml")
+	cfg.ExpectStatus = []string{"/api/*=2xx", "/legacy/*=2xx,301", "/gone/*=410"}
+	cfg.FailuresFile = filepath.Join(t.TempDir(), "failures.csv")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// The API redirect is followed to a 200, the legacy redirect is not
+	// followed, and only the revived tombstone and the 404 fail
+	assert.Equal(t, 6, result.Final.TotalProcessed)
+	assert.Equal(t, 4, result.Final.TotalSuccess)
+
+	failures, err := os.ReadFile(cfg.FailuresFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(failures), "HTTP 200, expected 410 for /gone/*")
+	assert.Contains(t, string(failures), h.URL("/missing"))
+	assert.NotContains(t, string(failures), h.URL("/legacy/about"))
+}
+
+func TestDialRule(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3})
+	addr := strings.TrimPrefix(h.URL(""), "http://")
+	_, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	// origin.internal does not resolve, so every request must follow the rule
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.SitemapURL = "http://origin.internal:" + port + "/local-sitemap.xml"
+	cfg.Dial = []string{"origin.internal=tcp:" + addr}
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 3, result.Final.TotalSuccess)
+}
+
+func TestStrictProxy(t *testing.T) {
+	t.Parallel()
+
+	// The test server also answers as a forward proxy, and origin.internal
+	// does not resolve, so every request must go through the proxy
+	h := New(t, testserver.Config{Pages: 3})
+	addr := strings.TrimPrefix(h.URL(""), "http://")
+	_, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.SitemapURL = "http://origin.internal:" + port + "/local-sitemap.xml"
+	cfg.Proxy = h.URL("")
+	cfg.ProxyStrict = true
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 3, result.Final.TotalSuccess)
+
+	// A dial rule that routes around the proxy fails the run
+	cfg = h.Config("/local-sitemap.xml")
+	cfg.Proxy = "http://127.0.0.1:1"
+	cfg.ProxyStrict = true
+	cfg.Dial = []string{"*=tcp:" + addr}
+	result = h.Run(cfg)
+	require.Error(t, result.Err)
+	assert.ErrorIs(t, result.Err, transport.ErrProxyBypass)
+	assert.Contains(t, result.Err.Error(), addr)
+}
+
+func TestCacheEfficacyReport(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 5, Cache: &testserver.Cache{MissDelay: 20 * time.Millisecond}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.CacheHeader = testserver.DefaultCacheHeader
+	cfg.OutputFormat = "csv"
+	cfg.CacheEfficacyReport = filepath.Join(t.TempDir(), "efficacy.csv")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.CacheEfficacyReport)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[0], "url,score,cache_status,"), lines[0])
+
+	// Every page was a slow miss and then a fast hit
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, ",")
+		assert.Equal(t, "HIT", fields[2], line)
+		score, err := strconv.ParseFloat(fields[1], 64)
+		require.NoError(t, err)
+		assert.Greater(t, score, 50.0, line)
+	}
+	assert.False(t, result.Logged("Warming had little effect"))
+	assert.True(t, result.Logged("Cache efficacy report written"))
+}
+
+func TestTraceFile(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 5})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.MaxWorkers = 2
+	cfg.RequestRate = 20
+	cfg.StrictPacing = true
+	cfg.TraceFile = filepath.Join(t.TempDir(), "trace.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	data, err := os.ReadFile(cfg.TraceFile)
+	require.NoError(t, err)
+	var file struct {
+		TraceEvents []struct {
+			Name     string `json:"name"`
+			Category string `json:"cat"`
+			Phase    string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(data, &file))
+
+	spans := map[string]int{}
+	waits := map[string]int{}
+	for _, event := range file.TraceEvents {
+		if event.Phase != "X" {
+			continue
+		}
+		spans[event.Category]++
+		if event.Category == "wait" {
+			waits[event.Name]++
+		}
+	}
+	assert.Equal(t, 5, spans["request"])
+	assert.Equal(t, 1, spans["phase"])
+	// At 20 requests per second the workers spend most of their time
+	// waiting for the rate limiter
+	assert.Positive(t, waits["rate limiter"])
+	assert.True(t, result.Logged("Trace file written"))
+}
+
+func TestCaptureFailedBodies(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/3", Statuses: []int{http.StatusServiceUnavailable}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CaptureDir = filepath.Join(t.TempDir(), "bodies")
+	cfg.CaptureMaxBytes = 1024 * 1024
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Response bodies captured"))
+
+	data, err := os.ReadFile(filepath.Join(cfg.CaptureDir, bodies.IndexFile))
+	require.NoError(t, err)
+	var index struct {
+		Bodies []bodies.Entry `json:"bodies"`
+	}
+	require.NoError(t, json.Unmarshal(data, &index))
+
+	// Only the failure is saved when no successes are sampled
+	require.Len(t, index.Bodies, 1)
+	entry := index.Bodies[0]
+	assert.Equal(t, h.URL("/pages/3"), entry.URL)
+	assert.Equal(t, http.StatusServiceUnavailable, entry.StatusCode)
+	assert.True(t, entry.Failed)
+	require.NotEmpty(t, entry.File)
+	body, err := os.ReadFile(filepath.Join(cfg.CaptureDir, entry.File))
+	require.NoError(t, err)
+	assert.Len(t, body, int(entry.Bytes))
+}
+
+func TestRejectedURLsReport(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{{
+			Path:        "/sitemap.xml",
+			ContentType: "application/xml",
+			Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/pages/1</loc></url>
+<url><loc>ftp://example.com/archive.zip</loc></url>
+<url><loc>mailto:team@example.com</loc></url>
+<url><loc>/pages/2</loc></url>
+<url><loc>http://localhost:{{.Query.Get "port"}}/pages/3</loc></url>
+</urlset>`,
+		}},
+	})
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(h.URL(""), "http://"))
+	require.NoError(t, err)
+
+	// localhost and 127.0.0.1 reach the same server as two hosts
+	cfg := h.Config("/sitemap.xml?port=" + port)
+	cfg.BlockDomains = []string{"localhost"}
+	cfg.OutputFormat = "csv"
+	cfg.RejectedReport = filepath.Join(t.TempDir(), "rejected.csv")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Sitemap URLs rejected"))
+
+	assert.Equal(t, 1, result.Final.TotalProcessed)
+	assert.Equal(t, 4, result.Final.RejectedURLs)
+	assert.Equal(t, map[string]int{
+		parser.RejectScheme:        2,
+		parser.RejectMalformed:     1,
+		parser.RejectBlockedDomain: 1,
+	}, result.Final.RejectedByReason)
+
+	data, err := os.ReadFile(cfg.RejectedReport)
+	require.NoError(t, err)
+	report := string(data)
+	assert.Contains(t, report, "ftp://example.com/archive.zip")
+	assert.Contains(t, report, "\n/pages/2,")
+	assert.Contains(t, report, "blocked_domain,localhost is blocked")
+}
+
+func TestDuplicatesReport(t *testing.T) {
+	t.Parallel()
+
+	article := "<html><body><h1>Release notes</h1><p>The crawler now warms every URL listed in the sitemap, " +
+		"follows sitemap indexes, verifies cache headers and writes reports for failures, latency and coverage.</p>"
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/pages/1</loc></url>
+<url><loc>{{.BaseURL}}/news/release</loc></url>
+<url><loc>{{.BaseURL}}/news/release?ref=nav</loc></url>
+<url><loc>{{.BaseURL}}/news/release/print</loc></url>
+</urlset>`,
+			},
+			{Path: "/news/release", ContentType: "text/html", Body: article + "</body></html>"},
+			{Path: "/news/release/print", ContentType: "text/html", Body: article + "<footer>Printed</footer></body></html>"},
+		},
+	})
+	cfg := h.Config("/sitemap.xml")
+	cfg.OutputFormat = "json"
+	cfg.DuplicatesReport = filepath.Join(t.TempDir(), "duplicates.json")
+	cfg.DuplicatesDistance = 8
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Duplicates report written"))
+
+	data, err := os.ReadFile(cfg.DuplicatesReport)
+	require.NoError(t, err)
+	var report struct {
+		Checked  int `json:"checked"`
+		Clusters []struct {
+			Identical bool `json:"identical"`
+			URLs      []struct {
+				URL string `json:"url"`
+			} `json:"urls"`
+		} `json:"clusters"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	// The printable page differs by a word, so it joins the two identical
+	// URLs as a near-identical cluster
+	assert.Equal(t, 4, report.Checked)
+	require.Len(t, report.Clusters, 1)
+	assert.False(t, report.Clusters[0].Identical)
+	var urls []string
+	for _, member := range report.Clusters[0].URLs {
+		urls = append(urls, strings.TrimPrefix(member.URL, h.URL("")))
+	}
+	assert.Equal(t, []string{"/news/release", "/news/release/print", "/news/release?ref=nav"}, urls)
+}
+
+func TestFindingsReport(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/</loc></url>
+<url><loc>{{.BaseURL}}/product</loc></url>
+<url><loc>{{.BaseURL}}/retired</loc></url>
+</urlset>`,
+			},
+			{Path: "/", Body: `<html><body><a href="/product">Product</a><a href="/retired">Retired</a></body></html>`},
+			{
+				Path: "/product",
+				Body: `<html><head><script type="application/ld+json">{"@context":"https://schema.org"}</script></head>` +
+					`<body><a href="/">Home</a></body></html>`,
+			},
+			{Path: "/retired", Statuses: []int{http.StatusGone}},
+		},
+	})
+	cfg := h.Config("/sitemap.xml")
+	cfg.OutputFormat = "json"
+	cfg.FindingsReport = filepath.Join(t.TempDir(), "findings.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Findings report written"))
+
+	data, err := os.ReadFile(cfg.FindingsReport)
+	require.NoError(t, err)
+	var report struct {
+		Findings []analysis.Finding `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	// Every analyzer runs by default; the retired page is only reported
+	// where it is linked from
+	assert.Equal(t, []analysis.Finding{
+		{URL: h.URL("/"), Analyzer: analysis.BrokenLinksAnalyzer, Issue: analysis.IssueBrokenLink, Detail: h.URL("/retired") + " (HTTP 410)"},
+		{URL: h.URL("/product"), Analyzer: analysis.StructuredDataAnalyzer, Issue: analysis.IssueMissingType, Detail: "block 1: item 1"},
+	}, report.Findings)
+}
+
+// TestSigV4Signing sets the AWS environment, so it cannot run in parallel
+func TestSigV4Signing(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	// Unsigned requests get a sitemap of a page that does not exist
+	signed := `{{if and (.Header.Get "Authorization") (eq (.Header.Get "X-Amz-Security-Token") "session")}}/signed{{else}}/missing{{end}}`
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body:        `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>{{.BaseURL}}` + signed + `</loc></url></urlset>`,
+			},
+			{Path: "/signed", Body: `{{.Header.Get "Authorization"}}`},
+		},
+	})
+	cfg := h.Config("/sitemap.xml")
+	cfg.SigV4Service = "s3"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 1, result.Final.TotalProcessed)
+	assert.Equal(t, 1, result.Final.TotalSuccess)
+}
+
+func TestAssetsRequestMode(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/pages/1</loc></url>
+<url><loc>{{.BaseURL}}/media/intro.mp4</loc></url>
+<url><loc>{{.BaseURL}}/download</loc></url>
+</urlset>`,
+			},
+			{Path: "/media/intro.mp4", Statuses: []int{http.StatusPartialContent}, ContentType: "video/mp4"},
+			{Path: "/download", ContentType: "application/zip"},
+		},
+	})
+	cfg := h.Config("/sitemap.xml")
+	cfg.RequestMode = config.RequestModeAssets
+	cfg.RangeExtensions = []string{"mp4"}
+	cfg.RangeContentTypes = []string{"application/zip"}
+	cfg.CacheVerificationMode = true
+	cfg.CacheHeader = testserver.DefaultCacheHeader
+	cfg.ResultsFile = filepath.Join(t.TempDir(), "results.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// The video is ranged by its extension in both passes, and the download
+	// by its Content-Type once warm-up has seen it; it ignores the range
+	assert.Equal(t, 3, result.Final.RangeRequests)
+	assert.Equal(t, 2, result.Final.RangeHonored)
+	assert.True(t, result.Logged("Origin answered range requests with full responses"))
+
+	loaded, err := output.LoadResults(cfg.ResultsFile)
+	require.NoError(t, err)
+	ranged := make(map[string][]bool)
+	for _, entry := range loaded.Results {
+		path := strings.TrimPrefix(entry.URL, h.URL(""))
+		ranged[path] = append(ranged[path], entry.Ranged)
+	}
+	assert.Equal(t, map[string][]bool{
+		"/pages/1":         {false, false},
+		"/media/intro.mp4": {true, true},
+		"/download":        {false, true},
+	}, ranged)
+}
+
+func TestSeedReproducesSampling(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 20})
+
+	// sampled crawls one worker at a time so that responses are sampled in
+	// the same order, and returns the captured URLs and the logged seed
+	sampled := func(seed uint64) ([]string, uint64) {
+		cfg := h.Config("/local-sitemap.xml")
+		cfg.MaxWorkers = 1
+		cfg.Seed = seed
+		cfg.CaptureDir = filepath.Join(t.TempDir(), "bodies")
+		cfg.CaptureSampleRate = 0.5
+		cfg.CaptureMaxBytes = 1024 * 1024
+		result := h.Run(cfg)
+		require.NoError(t, result.Err)
+
+		data, err := os.ReadFile(filepath.Join(cfg.CaptureDir, bodies.IndexFile))
+		require.NoError(t, err)
+		var index struct {
+			Bodies []bodies.Entry `json:"bodies"`
+		}
+		require.NoError(t, json.Unmarshal(data, &index))
+		urls := make([]string, len(index.Bodies))
+		for i, entry := range index.Bodies {
+			urls[i] = entry.URL
+		}
+
+		var logged uint64
+		for _, entry := range result.Logs.AllEntries() {
+			if entry.Message == "Configuration loaded" {
+				logged, _ = entry.Data["seed"].(uint64)
+			}
+		}
+		return urls, logged
+	}
+
+	first, seed := sampled(7)
+	second, _ := sampled(7)
+	assert.Equal(t, uint64(7), seed)
+	assert.NotEmpty(t, first)
+	assert.Less(t, len(first), 20)
+	assert.Equal(t, first, second)
+
+	_, seed = sampled(0)
+	assert.NotZero(t, seed, "a random seed is picked and logged")
+}
+
+func TestHostOrder(t *testing.T) {
+	t.Parallel()
+
+	// localhost and 127.0.0.1 reach the same server as two hosts
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://localhost:{{.Query.Get "port"}}/pages/1</loc></url>
+<url><loc>{{.BaseURL}}/api/1</loc></url>
+<url><loc>http://localhost:{{.Query.Get "port"}}/pages/2</loc></url>
+<url><loc>{{.BaseURL}}/api/2</loc></url>
+<url><loc>{{.BaseURL}}/api/3</loc></url>
+</urlset>`,
+			},
+			{Path: "/api/*", Latency: 20 * time.Millisecond},
+		},
+	})
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(h.URL(""), "http://"))
+	require.NoError(t, err)
+
+	cfg := h.Config("/sitemap.xml?port=" + port)
+	cfg.HostOrder = "127.0.0.1>localhost"
+	cfg.HostOrderThreshold = 1
+	cfg.ResultsFile = filepath.Join(t.TempDir(), "results.json")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 5, result.Final.TotalSuccess)
+	assert.True(t, result.Logged("Starting host group"))
+
+	// Every API request completed before the first page was requested
+	loaded, err := output.LoadResults(cfg.ResultsFile)
+	require.NoError(t, err)
+	require.Len(t, loaded.Results, 5)
+	for i, entry := range loaded.Results {
+		assert.Equal(t, i < 3, strings.Contains(entry.URL, "/api/"), entry.URL)
+	}
+}
+
+func TestPingAfterCrawl(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 5,
+		Routes: []testserver.Route{
+			{Path: "/pages/2", Statuses: []int{http.StatusNotFound}},
+			{Path: "/ping", Statuses: []int{http.StatusOK}},
+			{Path: "/indexnow", Method: http.MethodPost, Statuses: []int{http.StatusAccepted}},
+		},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Ping = []string{h.URL("/ping?sitemap={sitemap}")}
+	cfg.IndexNowKey = "5f1c2b3a4d5e6f70"
+	cfg.IndexNowEndpoint = h.URL("/indexnow")
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// One page failed, so the threshold has to allow it
+	assert.True(t, result.Logged("Success rate below threshold, not notifying search engines"))
+
+	cfg.PingMinSuccessRate = 75
+	result = h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Search engine pinged"))
+
+	submitted := 0
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "URLs submitted to IndexNow" {
+			submitted = entry.Data["urls"].(int)
+		}
+	}
+	assert.Equal(t, 4, submitted)
+}
+
+func TestForbiddenStreakCancelsCrawl(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  200,
+		Faults: []testserver.Fault{{PathPrefix: "/pages/", Status: http.StatusForbidden, Probability: 1}},
+	})
+	result := h.Run(h.Config("/local-sitemap.xml"))
+
+	require.NoError(t, result.Err)
+	assert.True(t, result.Cancelled())
+	assert.Less(t, result.Final.TotalProcessed, 200)
+	assert.True(t, result.Logged("Too many 403 errors detected, cancelling crawl"))
+}
+
+func TestCancelRuleCancelsCrawl(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  200,
+		Faults: []testserver.Fault{{PathPrefix: "/pages/", Status: http.StatusUnauthorized, Probability: 1}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CancelOn = []string{"status=401,count=3"}
+	result := h.Run(cfg)
+
+	require.NoError(t, result.Err)
+	assert.True(t, result.Cancelled())
+	assert.Less(t, result.Final.TotalProcessed, 200)
+	assert.True(t, result.Logged("Cancel rule fired, cancelling crawl"))
+	assert.Contains(t, result.Backoff["cancel_reason"], "status=401,count=3")
+}
+
+func TestRequestTemplateSetsMethod(t *testing.T) {
+	t.Parallel()
+
+	// The pages only answer POST; GET requests are rejected
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/*", Method: http.MethodGet, Statuses: []int{http.StatusMethodNotAllowed}}},
+	})
+	template := filepath.Join(t.TempDir(), "requests.yaml")
+	require.NoError(t, os.WriteFile(template, []byte("rules:\n  - match: /pages/\n    method: POST\n    body: '{\"path\": {{json .Path}}}'\n"), 0o600))
+
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.RequestTemplate = template
+	result := h.Run(cfg)
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 5, result.Final.TotalProcessed)
+	assert.Equal(t, 5, result.Final.TotalSuccess)
+}
+
+func TestPurgeBeforeWarming(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 10, Cache: &testserver.Cache{}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.CacheHeader = testserver.DefaultCacheHeader
+
+	// A first run leaves every page cached
+	require.NoError(t, h.Run(cfg).Err)
+
+	cfg.Purge = "request"
+	cfg.PurgeMethod = "PURGE"
+	cfg.Verbose = 2
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Logged("Purge completed"))
+
+	// Purged pages miss again during warm-up, then hit during verification
+	misses := 0
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Request succeeded" && entry.Data["cache_status"] == "MISS" {
+			misses++
+		}
+	}
+	assert.Equal(t, 10, misses)
+	assert.Equal(t, 10, result.Cache.CacheHits)
+}
+
+func TestFailedPurgeStopsRun(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  5,
+		Routes: []testserver.Route{{Path: "/pages/*", Method: "PURGE", Statuses: []int{http.StatusMethodNotAllowed}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Purge = "request"
+	cfg.PurgeMethod = "PURGE"
+	result := h.Run(cfg)
+
+	require.ErrorContains(t, result.Err, "purge failed for 5 of 5 URLs")
+	assert.Zero(t, result.Final.TotalProcessed)
+}
+
+func TestCloudflareTraceIDs(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  3,
+		Cache:  &testserver.Cache{Header: "CF-Cache-Status"},
+		Routes: []testserver.Route{{Path: "/pages/*", Headers: map[string]string{"CF-Ray": "8c2f1a9b4e7d3c21-AMS"}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CDN = "cloudflare"
+	cfg.CacheVerificationMode = true
+	cfg.CacheHeader = "CF-Cache-Status"
+	cfg.Verbose = 2
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	traced := 0
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Request succeeded" && entry.Data["trace_id"] == "8c2f1a9b4e7d3c21-AMS" {
+			traced++
+		}
+	}
+	assert.Equal(t, 6, traced)
+	assert.Equal(t, 3, result.Cache.CacheHits)
+}
+
+func TestSurrogateKeyHitRates(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  4,
+		Cache:  &testserver.Cache{},
+		Routes: []testserver.Route{{Path: "/pages/*", Headers: map[string]string{"Surrogate-Key": "pages all"}}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CDN = "fastly"
+	cfg.CacheVerificationMode = true
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	keys := map[string]int{}
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Surrogate key cache hit rate" {
+			keys[entry.Data["key"].(string)] = entry.Data["cache_hits"].(int)
+		}
+	}
+	assert.Equal(t, map[string]int{"pages": 4, "all": 4}, keys)
+}
+
+func TestHeaderChangesBetweenPasses(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages: 3,
+		Routes: []testserver.Route{{
+			Path:    "/pages/2",
+			Headers: map[string]string{"Vary": "Accept-Encoding"},
+			Body:    "{{if eq .Count 1}}variant a{{else}}variant b, from another backend{{end}}",
+		}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.CompareHeaders = []string{"vary", "content-length"}
+	cfg.HeaderDiffReport = filepath.Join(t.TempDir(), "headers.csv")
+	cfg.OutputFormat = "csv"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	require.NotNil(t, result.Cache)
+	assert.Equal(t, 1, result.Cache.HeaderChanges)
+	assert.True(t, result.Logged("Header changed between passes"))
+
+	report, err := os.ReadFile(cfg.HeaderDiffReport)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), h.URL("/pages/2")+",Content-Length,9,31")
+	assert.NotContains(t, string(report), "Vary")
+}
+
+func TestEdgeCacheVerification(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3, Cache: &testserver.Cache{}})
+
+	// A second server stands in for an edge the warm-up pass never reached
+	cold := New(t, testserver.Config{Pages: 3, Cache: &testserver.Cache{}})
+	edgeAddress := func(harness *Harness) string {
+		return strings.TrimPrefix(harness.URL(""), "http://")
+	}
+
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	cfg.Edges = []string{"warm=" + edgeAddress(h), "cold=" + edgeAddress(cold)}
+	cfg.EdgeReport = filepath.Join(t.TempDir(), "edges.csv")
+	cfg.OutputFormat = "csv"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	assert.Equal(t, 9, result.Final.TotalProcessed)
+	require.NotNil(t, result.Cache)
+	assert.Equal(t, 3, result.Cache.CacheHits)
+	assert.Equal(t, 3, result.Cache.CacheMisses)
+	assert.True(t, result.Logged("Edge cache was not warmed"))
+
+	report, err := os.ReadFile(cfg.EdgeReport)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "warm,3,0,3,0,100.00,")
+	assert.Contains(t, string(report), "cold,3,0,0,3,0.00,")
+}
+
+func TestDeviceMatrix(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 3, Cache: &testserver.Cache{}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Devices = []string{"desktop", "mobile"}
+	cfg.DeviceSizeTolerance = 0.1
+	cfg.DeviceReport = filepath.Join(t.TempDir(), "devices.json")
+	cfg.OutputFormat = "json"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 6, result.Final.TotalProcessed)
+
+	// The simulated cache ignores the User-Agent, so the desktop pass warms
+	// it for mobile and every URL differs in cache status
+	data, err := os.ReadFile(cfg.DeviceReport)
+	require.NoError(t, err)
+	var report struct {
+		Compared    int `json:"compared"`
+		Differences []struct {
+			DiffersIn []string `json:"differs_in"`
+		} `json:"differences"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 3, report.Compared)
+	require.Len(t, report.Differences, 3)
+	assert.Equal(t, []string{"cache_status"}, report.Differences[0].DiffersIn)
+	assert.True(t, result.Logged("Responses differ between devices"))
+}
+
+func TestDualStack(t *testing.T) {
+	t.Parallel()
+
+	// The test server only listens on 127.0.0.1, so every IPv6 attempt fails
+	h := New(t, testserver.Config{Pages: 3})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.DualStack = true
+	cfg.DualStackSlowdownRatio = 2
+	cfg.DualStackSlowdownMin = 100 * time.Millisecond
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 6, result.Final.TotalProcessed)
+	assert.Equal(t, 3, result.Final.TotalErrors)
+
+	problems := 0
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Address families differ" {
+			assert.Equal(t, "ipv6_failed", entry.Data["reason"])
+			problems++
+		}
+	}
+	assert.Equal(t, 3, problems)
+}
+
+func TestCancelRuleReasonInFinalStats(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Pages:  200,
+		Faults: []testserver.Fault{{PathPrefix: "/pages/", Status: http.StatusServiceUnavailable, Probability: 1}},
+	})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CancelOn = []string{"status=5xx,consecutive=3"}
+	path := filepath.Join(t.TempDir(), "stats.json")
+	cfg.Export = []string{"json=" + path}
+	result := h.Run(cfg)
+
+	require.NoError(t, result.Err)
+	assert.True(t, result.Cancelled())
+	assert.Contains(t, result.Final.CancelReason, "status=5xx,consecutive=3")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var exported struct {
+		FinalStats stats.FinalStats `json:"final_stats"`
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, result.Final.CancelReason, exported.FinalStats.CancelReason)
+}
+
+func TestCacheVerification(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 8, Cache: &testserver.Cache{}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.CacheVerificationMode = true
+	result := h.Run(cfg)
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 8, result.Cache.CacheHits)
+	assert.Equal(t, 0, result.Cache.CacheMisses)
+}
+
+func TestRepeatCrawlMeasuresCacheWarming(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{Pages: 6, Cache: &testserver.Cache{}})
+	cfg := h.Config("/local-sitemap.xml")
+	cfg.Repeat = 3
+	result := h.Run(cfg)
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 18, result.Final.TotalProcessed, "the aggregate covers every iteration")
+	assert.True(t, result.Logged("All iterations completed"))
+
+	var hitRates []interface{}
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Iteration completed" {
+			hitRates = append(hitRates, entry.Data["cache_hit_rate"])
+		}
+	}
+	assert.Equal(t, []interface{}{"0.0%", "100.0%", "100.0%"}, hitRates)
+}
+
+func TestRepeatScheduleChangeFreq(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/pages/live</loc><changefreq>always</changefreq></url>
+<url><loc>{{.BaseURL}}/pages/news</loc><changefreq>hourly</changefreq></url>
+<url><loc>{{.BaseURL}}/pages/archive</loc><changefreq>never</changefreq></url>
+<url><loc>{{.BaseURL}}/pages/about</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/scheduled-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/pages/*"},
+	}})
+	cfg := h.Config("/scheduled-sitemap.xml")
+	cfg.Repeat = 3
+	cfg.RepeatSchedule = config.RepeatScheduleChangeFreq
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+
+	// Every URL is crawled once; after that only the always page and the
+	// page without a changefreq, which is due every --repeat-interval, are
+	// due again
+	assert.Equal(t, 8, result.Final.TotalProcessed)
+	var due []interface{}
+	for _, entry := range result.Logs.AllEntries() {
+		if entry.Message == "Starting iteration" {
+			due = append(due, entry.Data["due"])
+		}
+	}
+	assert.Equal(t, []interface{}{4, 2, 2}, due)
+}
+
+func TestServerTimingAggregated(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/timed/a</loc></url>
+<url><loc>{{.BaseURL}}/timed/b</loc></url>
+</urlset>`,
+			},
+			{Path: "/timed/*", Headers: map[string]string{"Server-Timing": "db;dur=40"}},
+		},
+	})
+	result := h.Run(h.Config("/sitemap.xml"))
+
+	require.NoError(t, result.Err)
+	require.Len(t, result.Timings, 1)
+	assert.Equal(t, "db", result.Timings[0].Metric)
+	assert.Equal(t, 2, result.Timings[0].Count)
+	assert.Equal(t, 40*time.Millisecond, result.Timings[0].Average)
+}
+
+func TestServerTimingCoversVerifyPass(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{
+			{
+				Path:        "/sitemap.xml",
+				ContentType: "application/xml",
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/timed/a</loc></url>
+<url><loc>{{.BaseURL}}/timed/b</loc></url>
+</urlset>`,
+			},
+			{Path: "/timed/*", Headers: map[string]string{"Server-Timing": "db;dur=40"}},
+		},
+	})
+	cfg := h.Config("/sitemap.xml")
+	cfg.CacheVerificationMode = true
+	result := h.Run(cfg)
+
+	// Only the verification pass's metrics are reported
+	require.NoError(t, result.Err)
+	require.Len(t, result.Timings, 1)
+	assert.Equal(t, 2, result.Timings[0].Count)
+
+	phases := make([]string, 0, len(result.Final.Phases))
+	for _, phase := range result.Final.Phases {
+		phases = append(phases, phase.Name)
+	}
+	assert.Equal(t, []string{stats.PhaseWarmUp, stats.PhaseVerify}, phases)
+}
+
+func TestScenarioFile(t *testing.T) {
+	t.Parallel()
+
+	h := Load(t, "../../examples/scenarios/flaky-origin.yaml")
+	result := h.Run(h.Config("/scenario-sitemap.xml"))
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, 4, result.Final.TotalProcessed)
+	// The recovering page fails on its first request
+	assert.GreaterOrEqual(t, result.Final.TotalErrors, 1)
+}
+
+func TestUnparsableSitemapFails(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, testserver.Config{
+		Routes: []testserver.Route{{
+			Path:        "/empty.xml",
+			ContentType: "application/xml",
+			Body:        `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`,
+		}},
+	})
+	result := h.Run(h.Config("/empty.xml"))
+
+	assert.ErrorContains(t, result.Err, "failed to parse sitemap")
+	assert.Equal(t, 0, result.Final.TotalProcessed)
+}
+
+func TestModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/new</loc><lastmod>2024-06-02T10:00:00Z</lastmod></url>
+<url><loc>{{.BaseURL}}/old</loc><lastmod>2024-05-01</lastmod></url>
+<url><loc>{{.BaseURL}}/undated</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/modified-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/new", Body: "<html>new</html>"},
+		{Path: "/old", Body: "<html>old</html>"},
+		{Path: "/undated", Body: "<html>undated</html>"},
+	}})
+
+	cfg := h.Config("/modified-sitemap.xml")
+	cfg.ModifiedSince = "2024-06-01"
+	result := h.Run(cfg)
+	require.NoError(t, result.Err)
+	assert.Equal(t, 2, result.Final.TotalProcessed, "the unchanged page is not crawled")
+	assert.True(t, result.Logged("URLs filtered by lastmod"))
+}
+
+func TestHARRedactsCredentials(t *testing.T) {
+	t.Parallel()
+
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{.BaseURL}}/account</loc></url>
+</urlset>`
+	h := New(t, testserver.Config{Routes: []testserver.Route{
+		{Path: "/har-sitemap.xml", ContentType: "application/xml", Body: sitemap},
+		{Path: "/account", Body: "<html>account</html>", Headers: map[string]string{"Set-Cookie": "session=abc123"}},
+	}})
+
+	for _, includeSecrets := range []bool{false, true} {
+		cfg := h.Config("/har-sitemap.xml")
+		cfg.Headers = map[string]string{"Authorization": "Bearer secret-token"}
+		cfg.HARFile = filepath.Join(t.TempDir(), "crawl.har")
+		cfg.HARMode = har.ModeAll
+		cfg.HARIncludeSecrets = includeSecrets
+		result := h.Run(cfg)
+		require.NoError(t, result.Err)
+
+		data, err := os.ReadFile(cfg.HARFile)
+		require.NoError(t, err)
+		if includeSecrets {
+			assert.Contains(t, string(data), "secret-token")
+			assert.Contains(t, string(data), "session=abc123")
+			continue
+		}
+		assert.NotContains(t, string(data), "secret-token")
+		assert.NotContains(t, string(data), "session=abc123")
+		assert.Contains(t, string(data), config.Redacted)
+	}
+}
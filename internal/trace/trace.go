@@ -0,0 +1,166 @@
+// Package trace records what each worker spends its time on during a crawl
+// and writes it in the Chrome trace event format, which chrome://tracing and
+// ui.perfetto.dev display as one timeline per worker
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+)
+
+// Tracks that are not workers; they come first in the timeline
+const (
+	TrackPhases  = "phases"
+	TrackBackoff = "backoff"
+)
+
+// Span categories
+const (
+	CategoryPhase   = "phase"
+	CategoryRequest = "request"
+	CategoryWait    = "wait"
+	CategoryBackoff = "backoff"
+)
+
+// MinWait is the shortest wait recorded. Shorter waits cannot be seen at the
+// scale of a crawl and would only make the file larger.
+const MinWait = time.Millisecond
+
+// Span is something a track spent time on
+type Span struct {
+	Track    string
+	Name     string
+	Category string
+	Start    time.Time
+	Duration time.Duration
+
+	// Args are shown when the span is selected
+	Args map[string]interface{}
+}
+
+// WorkerTrack names the track of a worker
+func WorkerTrack(id int) string {
+	return fmt.Sprintf("worker %d", id)
+}
+
+// Recorder collects spans from concurrent workers. A nil Recorder ignores
+// them, so callers need not check whether tracing is enabled.
+type Recorder struct {
+	mu     sync.Mutex
+	start  time.Time
+	spans  []Span
+	tracks map[string]int
+	order  []string
+}
+
+// NewRecorder creates a recorder whose timeline starts now
+func NewRecorder() *Recorder {
+	r := &Recorder{start: time.Now(), tracks: make(map[string]int)}
+	r.trackLocked(TrackPhases)
+	r.trackLocked(TrackBackoff)
+	return r
+}
+
+// Add records a span
+func (r *Recorder) Add(span Span) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trackLocked(span.Track)
+	r.spans = append(r.spans, span)
+}
+
+// Wait records a wait on a track that started at start and ends now, if it
+// lasted at least MinWait
+func (r *Recorder) Wait(track, name string, start time.Time) {
+	if r == nil {
+		return
+	}
+	if duration := time.Since(start); duration >= MinWait {
+		r.Add(Span{Track: track, Name: name, Category: CategoryWait, Start: start, Duration: duration})
+	}
+}
+
+// Len returns the number of recorded spans
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.spans)
+}
+
+// trackLocked returns the thread ID of a track, assigning the next one to a
+// new track
+func (r *Recorder) trackLocked(track string) int {
+	if id, ok := r.tracks[track]; ok {
+		return id
+	}
+	id := len(r.order) + 1
+	r.tracks[track] = id
+	r.order = append(r.order, track)
+	return id
+}
+
+// event is a Chrome trace event. Times are in microseconds from the start
+// of the recording.
+type event struct {
+	Name     string                 `json:"name"`
+	Category string                 `json:"cat,omitempty"`
+	Phase    string                 `json:"ph"`
+	Time     float64                `json:"ts"`
+	Duration float64                `json:"dur,omitempty"`
+	Process  int                    `json:"pid"`
+	Thread   int                    `json:"tid"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+}
+
+// document is a Chrome trace file in the JSON object format
+type document struct {
+	TraceEvents     []event      `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+	Run             *runinfo.Run `json:"otherData,omitempty"`
+}
+
+// Marshal encodes the recorded spans as a Chrome trace file, tagged with
+// the run that recorded them when run is set
+func (r *Recorder) Marshal(run *runinfo.Run) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]event, 0, 2*len(r.order)+len(r.spans))
+	for i, track := range r.order {
+		id := r.tracks[track]
+		events = append(events,
+			event{Name: "thread_name", Phase: "M", Process: 1, Thread: id, Args: map[string]interface{}{"name": track}},
+			event{Name: "thread_sort_index", Phase: "M", Process: 1, Thread: id, Args: map[string]interface{}{"sort_index": i}},
+		)
+	}
+	for _, span := range r.spans {
+		events = append(events, event{
+			Name:     span.Name,
+			Category: span.Category,
+			Phase:    "X",
+			Time:     microseconds(span.Start.Sub(r.start)),
+			Duration: microseconds(span.Duration),
+			Process:  1,
+			Thread:   r.tracks[span.Track],
+			Args:     span.Args,
+		})
+	}
+
+	data, err := json.Marshal(document{TraceEvents: events, DisplayTimeUnit: "ms", Run: run})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trace file: %w", err)
+	}
+	return data, nil
+}
+
+// microseconds converts a duration to the trace format's time unit
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,99 @@
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/runinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decoded is a trace file as a viewer reads it
+type decoded struct {
+	TraceEvents []struct {
+		Name     string                 `json:"name"`
+		Category string                 `json:"cat"`
+		Phase    string                 `json:"ph"`
+		Time     float64                `json:"ts"`
+		Duration float64                `json:"dur"`
+		Thread   int                    `json:"tid"`
+		Args     map[string]interface{} `json:"args"`
+	} `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+	Run             *runinfo.Run `json:"otherData"`
+}
+
+func TestRecorderMarshal(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewRecorder()
+	start := recorder.start
+	recorder.Add(Span{
+		Track:    WorkerTrack(0),
+		Name:     "https://example.com/",
+		Category: CategoryRequest,
+		Start:    start.Add(2 * time.Millisecond),
+		Duration: 1500 * time.Microsecond,
+		Args:     map[string]interface{}{"status": 200},
+	})
+	recorder.Add(Span{Track: TrackBackoff, Name: "backoff pause", Category: CategoryBackoff, Start: start.Add(4 * time.Millisecond), Duration: time.Second})
+	recorder.Add(Span{Track: WorkerTrack(1), Name: "rate limiter", Category: CategoryWait, Start: start, Duration: 3 * time.Millisecond})
+	assert.Equal(t, 3, recorder.Len())
+
+	data, err := recorder.Marshal(&runinfo.Run{ID: "20261016T120000Z-0a1b2c3d"})
+	require.NoError(t, err)
+
+	var file decoded
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Equal(t, "ms", file.DisplayTimeUnit)
+	require.NotNil(t, file.Run)
+	assert.Equal(t, "20261016T120000Z-0a1b2c3d", file.Run.ID)
+
+	// Every track is named and sorted, the fixed tracks first
+	tracks := map[int]string{}
+	for _, event := range file.TraceEvents {
+		if event.Phase == "M" && event.Name == "thread_name" {
+			tracks[event.Thread] = event.Args["name"].(string)
+		}
+	}
+	assert.Equal(t, map[int]string{1: TrackPhases, 2: TrackBackoff, 3: "worker 0", 4: "worker 1"}, tracks)
+
+	var spans []string
+	for _, event := range file.TraceEvents {
+		if event.Phase != "X" {
+			continue
+		}
+		spans = append(spans, event.Name)
+		if event.Name == "https://example.com/" {
+			assert.Equal(t, CategoryRequest, event.Category)
+			assert.InDelta(t, 2000, event.Time, 0.001)
+			assert.InDelta(t, 1500, event.Duration, 0.001)
+			assert.Equal(t, 3, event.Thread)
+			assert.EqualValues(t, 200, event.Args["status"])
+		}
+	}
+	assert.Equal(t, []string{"https://example.com/", "backoff pause", "rate limiter"}, spans)
+}
+
+func TestRecorderWait(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewRecorder()
+	recorder.Wait(WorkerTrack(0), "rate limiter", time.Now())
+	assert.Equal(t, 0, recorder.Len(), "waits shorter than MinWait are not recorded")
+
+	recorder.Wait(WorkerTrack(0), "rate limiter", time.Now().Add(-5*time.Millisecond))
+	assert.Equal(t, 1, recorder.Len())
+}
+
+func TestNilRecorder(t *testing.T) {
+	t.Parallel()
+
+	var recorder *Recorder
+	assert.NotPanics(t, func() {
+		recorder.Add(Span{Track: WorkerTrack(0)})
+		recorder.Wait(WorkerTrack(0), "backoff", time.Now().Add(-time.Second))
+	})
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthBurst bounds how many bytes may be read at once, so a high
+// bandwidth limit still spreads reads out instead of allowing a second's
+// worth in one go
+const maxBandwidthBurst = 64 * 1024
+
+// NewBandwidthLimiter creates a limiter allowing bytesPerSecond, to be
+// shared by every transport whose downloads count toward the limit
+func NewBandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(max(1, min(bytesPerSecond, maxBandwidthBurst))))
+}
+
+// Throttle returns a RoundTripper whose response bodies are read no faster
+// than limiter allows
+func Throttle(next http.RoundTripper, limiter *rate.Limiter) http.RoundTripper {
+	return &throttledTransport{next: next, limiter: limiter}
+}
+
+// throttledTransport wraps response bodies in throttledBody
+type throttledTransport struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &throttledBody{ReadCloser: resp.Body, req: req, limiter: t.limiter}
+	return resp, nil
+}
+
+// throttledBody waits for the limiter after each read, so reads stop until
+// the bytes just read are paid for
+type throttledBody struct {
+	io.ReadCloser
+	req     *http.Request
+	limiter *rate.Limiter
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	if burst := b.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.WaitN(b.req.Context(), n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
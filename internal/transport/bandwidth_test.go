@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBandwidthLimiterBurst(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		bytesPerSecond int64
+		expected       int
+	}{
+		{name: "below the cap", bytesPerSecond: 1000, expected: 1000},
+		{name: "above the cap", bytesPerSecond: 10 * 1024 * 1024, expected: maxBandwidthBurst},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, NewBandwidthLimiter(tt.bytesPerSecond).Burst())
+		})
+	}
+}
+
+func TestThrottleLimitsDownloads(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("x", 60000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	// The first 50000 bytes fill the burst and the last 10000 take 200ms
+	client := &http.Client{Transport: Throttle(http.DefaultTransport, NewBandwidthLimiter(50000))}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, body, string(data))
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
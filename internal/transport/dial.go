@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AnyHost matches every host in a dial rule
+const AnyHost = "*"
+
+// Dial rule targets
+const (
+	DialUnix = "unix"
+	DialTCP  = "tcp"
+)
+
+// DialRule sends connections for a host to another address, such as a
+// sidecar proxy listening on a Unix socket. The request keeps its URL, so
+// the Host header and TLS server name are those of the original host.
+type DialRule struct {
+	// Host is a hostname without port, or AnyHost
+	Host string
+
+	// Network is DialUnix or DialTCP and Address a socket path or host:port.
+	// A TCP address without a port, as an edge's may be, keeps the port of
+	// the connection it replaces.
+	Network string
+	Address string
+}
+
+// ParseDialRule parses a rule in the form host=unix:/path/to/socket or
+// host=tcp:address:port, where host may be * to match every host
+func ParseDialRule(spec string) (DialRule, error) {
+	host, target, found := strings.Cut(spec, "=")
+	if !found {
+		return DialRule{}, fmt.Errorf("invalid dial rule %q: expected host=unix:path or host=tcp:address:port", spec)
+	}
+
+	rule := DialRule{Host: strings.ToLower(strings.TrimSpace(host))}
+	if rule.Host == "" || strings.ContainsAny(rule.Host, "/:") {
+		return DialRule{}, fmt.Errorf("invalid dial rule %q: host must be a hostname without port, or %s", spec, AnyHost)
+	}
+
+	network, address, _ := strings.Cut(strings.TrimSpace(target), ":")
+	rule.Network, rule.Address = strings.ToLower(network), address
+	switch rule.Network {
+	case DialUnix:
+		if rule.Address == "" {
+			return DialRule{}, fmt.Errorf("invalid dial rule %q: missing socket path", spec)
+		}
+	case DialTCP:
+		if _, _, err := net.SplitHostPort(rule.Address); err != nil {
+			return DialRule{}, fmt.Errorf("invalid dial rule %q: expected tcp:address:port", spec)
+		}
+	default:
+		return DialRule{}, fmt.Errorf("invalid dial rule %q: target must start with %s: or %s:", spec, DialUnix, DialTCP)
+	}
+
+	return rule, nil
+}
+
+// dialFunc is the signature of http.Transport.DialContext
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// withDialRules routes connections matching a rule to the rule's address.
+// Unix sockets are dialed by unix, which ignores the source address and
+// address family; TCP targets go through next. The first matching rule wins,
+// and an exact host takes precedence over AnyHost wherever it appears.
+func withDialRules(rules []DialRule, next dialFunc, unix dialFunc) dialFunc {
+	if len(rules) == 0 {
+		return next
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+
+		rule, ok := matchDialRule(rules, strings.ToLower(host))
+		switch {
+		case !ok:
+			return next(ctx, network, addr)
+		case rule.Network == DialUnix:
+			return unix(ctx, DialUnix, rule.Address)
+		default:
+			return next(ctx, network, withPort(rule.Address, port))
+		}
+	}
+}
+
+// withPort adds port to an address that has none
+func withPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil || port == "" {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), port)
+}
+
+// matchDialRule returns the first rule for host, or else the first AnyHost
+// rule
+func matchDialRule(rules []DialRule, host string) (DialRule, bool) {
+	var fallback *DialRule
+	for i, rule := range rules {
+		if rule.Host == host {
+			return rule, true
+		}
+		if rule.Host == AnyHost && fallback == nil {
+			fallback = &rules[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return DialRule{}, false
+}
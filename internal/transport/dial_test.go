@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDialRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		spec      string
+		expected  DialRule
+		wantError string
+	}{
+		{name: "unix socket", spec: "origin.internal=unix:/var/run/envoy.sock", expected: DialRule{Host: "origin.internal", Network: DialUnix, Address: "/var/run/envoy.sock"}},
+		{name: "tcp address", spec: "Example.com=tcp:127.0.0.1:15001", expected: DialRule{Host: "example.com", Network: DialTCP, Address: "127.0.0.1:15001"}},
+		{name: "any host", spec: "*=unix:/tmp/proxy.sock", expected: DialRule{Host: AnyHost, Network: DialUnix, Address: "/tmp/proxy.sock"}},
+		{name: "missing target", spec: "example.com", wantError: "expected host=unix:path"},
+		{name: "host with port", spec: "example.com:443=tcp:127.0.0.1:15001", wantError: "host must be a hostname without port"},
+		{name: "empty host", spec: "=unix:/tmp/proxy.sock", wantError: "host must be a hostname without port"},
+		{name: "missing socket path", spec: "example.com=unix:", wantError: "missing socket path"},
+		{name: "tcp without port", spec: "example.com=tcp:127.0.0.1", wantError: "expected tcp:address:port"},
+		{name: "unknown network", spec: "example.com=udp:127.0.0.1:53", wantError: "target must start with unix: or tcp:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rule, err := ParseDialRule(tt.spec)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestMatchDialRule(t *testing.T) {
+	t.Parallel()
+
+	rules := []DialRule{
+		{Host: AnyHost, Network: DialUnix, Address: "/any.sock"},
+		{Host: "example.com", Network: DialUnix, Address: "/example.sock"},
+	}
+
+	rule, ok := matchDialRule(rules, "example.com")
+	require.True(t, ok)
+	assert.Equal(t, "/example.sock", rule.Address, "an exact host wins over *")
+
+	rule, ok = matchDialRule(rules, "other.example.com")
+	require.True(t, ok)
+	assert.Equal(t, "/any.sock", rule.Address)
+
+	_, ok = matchDialRule(rules[1:], "other.example.com")
+	assert.False(t, ok)
+}
+
+func TestNewDialsUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	// Socket paths are limited to about 100 bytes, so keep the directory short
+	dir, err := os.MkdirTemp("", "dial")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "proxy.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "via socket for "+r.Host)
+		})},
+	}
+	server.Start()
+	defer server.Close()
+
+	transport, err := New(Config{DialRules: []DialRule{{Host: "origin.internal", Network: DialUnix, Address: socket}}})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://origin.internal/page")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "via socket for origin.internal", string(body))
+}
+
+func TestNewDialsTCPAddress(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host)
+	}))
+	defer server.Close()
+
+	transport, err := New(Config{DialRules: []DialRule{{Host: "origin.internal", Network: DialTCP, Address: strings.TrimPrefix(server.URL, "http://")}}})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://origin.internal:8080/")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "origin.internal:8080", string(body))
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Edge is a CDN edge location, or point of presence, that requests can be
+// pinned to regardless of what DNS returns for their hosts
+type Edge struct {
+	// Name labels the edge in reports; it defaults to Address
+	Name string
+
+	// Address is the edge's IP address or hostname, with an optional port.
+	// Without a port, connections keep the port of the request's URL.
+	Address string
+}
+
+// ParseEdge parses an edge in the form [name=]address[:port], e.g.
+// lhr=151.101.1.1 or 151.101.65.1:443
+func ParseEdge(spec string) (Edge, error) {
+	name, address, found := strings.Cut(spec, "=")
+	if !found {
+		name, address = "", spec
+	}
+	edge := Edge{Name: strings.TrimSpace(name), Address: strings.TrimSpace(address)}
+
+	if edge.Address == "" || strings.ContainsAny(edge.Address, "/ \t") {
+		return Edge{}, fmt.Errorf("invalid edge %q: expected [name=]address[:port]", spec)
+	}
+	host := edge.Address
+	if h, port, err := net.SplitHostPort(edge.Address); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return Edge{}, fmt.Errorf("invalid edge %q: invalid port %q", spec, port)
+		}
+		host = h
+	}
+	if host == "" {
+		return Edge{}, fmt.Errorf("invalid edge %q: expected [name=]address[:port]", spec)
+	}
+
+	if found && (edge.Name == "" || strings.ContainsAny(edge.Name, " \t")) {
+		return Edge{}, fmt.Errorf("invalid edge %q: name must not be empty or contain spaces", spec)
+	}
+	if edge.Name == "" {
+		edge.Name = edge.Address
+	}
+	return edge, nil
+}
+
+// ParseEdges parses edges, rejecting two with the same name
+func ParseEdges(specs []string) ([]Edge, error) {
+	edges := make([]Edge, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		edge, err := ParseEdge(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[edge.Name] {
+			return nil, fmt.Errorf("duplicate edge name: %s", edge.Name)
+		}
+		seen[edge.Name] = true
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
+// DialRule returns the rule that sends connections for every host to the
+// edge
+func (e Edge) DialRule() DialRule {
+	return DialRule{Host: AnyHost, Network: DialTCP, Address: e.Address}
+}
@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEdge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		spec      string
+		expected  Edge
+		wantError string
+	}{
+		{name: "named", spec: "lhr=151.101.1.1", expected: Edge{Name: "lhr", Address: "151.101.1.1"}},
+		{name: "with port", spec: "iad=151.101.65.1:8443", expected: Edge{Name: "iad", Address: "151.101.65.1:8443"}},
+		{name: "unnamed", spec: "151.101.1.1", expected: Edge{Name: "151.101.1.1", Address: "151.101.1.1"}},
+		{name: "ipv6", spec: "fra=2a04:4e42::1", expected: Edge{Name: "fra", Address: "2a04:4e42::1"}},
+		{name: "ipv6 with port", spec: "[2a04:4e42::1]:443", expected: Edge{Name: "[2a04:4e42::1]:443", Address: "[2a04:4e42::1]:443"}},
+		{name: "empty name", spec: "=151.101.1.1", wantError: "name must not be empty"},
+		{name: "missing address", spec: "lhr=", wantError: "expected [name=]address"},
+		{name: "url", spec: "lhr=https://151.101.1.1", wantError: "expected [name=]address"},
+		{name: "bad port", spec: "lhr=151.101.1.1:https", wantError: "invalid port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			edge, err := ParseEdge(tt.spec)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, edge)
+		})
+	}
+}
+
+func TestParseEdges(t *testing.T) {
+	t.Parallel()
+
+	edges, err := ParseEdges([]string{"lhr=151.101.1.1", "iad=151.101.65.1"})
+	require.NoError(t, err)
+	assert.Equal(t, []Edge{{Name: "lhr", Address: "151.101.1.1"}, {Name: "iad", Address: "151.101.65.1"}}, edges)
+
+	_, err = ParseEdges([]string{"lhr=151.101.1.1", "lhr=151.101.65.1"})
+	assert.ErrorContains(t, err, "duplicate edge name: lhr")
+}
+
+func TestEdgeKeepsRequestPort(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host)
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	edge := Edge{Name: "local", Address: host}
+	transport, err := New(Config{DialRules: []DialRule{edge.DialRule()}})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://www.example.com:" + port + "/")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "www.example.com:"+port, string(body))
+}
@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrProxyBypass is returned for a connection a strict proxy refused
+// because it did not go to the proxy
+var ErrProxyBypass = errors.New("connection would bypass the proxy")
+
+// proxyPorts are the default ports of the supported proxy schemes. Both
+// SOCKS5 schemes send hostnames to the proxy to resolve.
+var proxyPorts = map[string]string{
+	"http":    "80",
+	"https":   "443",
+	"socks5":  "1080",
+	"socks5h": "1080",
+}
+
+// ParseProxy parses a proxy URL such as http://proxy.internal:3128 or
+// socks5h://127.0.0.1:1080
+func ParseProxy(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	proxy.Scheme = strings.ToLower(proxy.Scheme)
+	if _, ok := proxyPorts[proxy.Scheme]; !ok {
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https, socks5 or socks5h", raw)
+	}
+	if proxy.Hostname() == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return proxy, nil
+}
+
+// proxyAddress returns the host:port connections to the proxy dial
+func proxyAddress(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		port = proxyPorts[proxy.Scheme]
+	}
+	return net.JoinHostPort(strings.ToLower(proxy.Hostname()), port)
+}
+
+// ProxyGuard makes a proxy strict: it refuses every connection that does
+// not go to the proxy, so no request and no DNS lookup of a crawled host
+// can leave without it, and records the refused addresses. Several
+// transports may share one guard.
+type ProxyGuard struct {
+	mu      sync.Mutex
+	refused map[string]bool
+}
+
+// NewProxyGuard creates a guard that has refused nothing
+func NewProxyGuard() *ProxyGuard {
+	return &ProxyGuard{refused: make(map[string]bool)}
+}
+
+// Refused returns the addresses the guard refused connections to, sorted
+func (g *ProxyGuard) Refused() []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	refused := make([]string, 0, len(g.refused))
+	for addr := range g.refused {
+		refused = append(refused, addr)
+	}
+	slices.Sort(refused)
+	return refused
+}
+
+// Err returns an error wrapping ErrProxyBypass that names the refused
+// addresses, or nil if the guard refused nothing
+func (g *ProxyGuard) Err() error {
+	refused := g.Refused()
+	if len(refused) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrProxyBypass, strings.Join(refused, ", "))
+}
+
+// dial lets only connections to the proxy address through to next
+func (g *ProxyGuard) dial(proxyAddr string, next dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if !strings.EqualFold(addr, proxyAddr) {
+			g.mu.Lock()
+			g.refused[addr] = true
+			g.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrProxyBypass, addr)
+		}
+		return next(ctx, network, addr)
+	}
+}
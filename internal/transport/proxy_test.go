@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		raw       string
+		address   string
+		wantError bool
+	}{
+		{name: "http", raw: "http://proxy.internal:3128", address: "proxy.internal:3128"},
+		{name: "default port", raw: "HTTPS://Proxy.Internal", address: "proxy.internal:443"},
+		{name: "socks5h", raw: "socks5h://127.0.0.1", address: "127.0.0.1:1080"},
+		{name: "socks5 with credentials", raw: "socks5://user:secret@[::1]:9050", address: "[::1]:9050"},
+		{name: "unsupported scheme", raw: "ftp://proxy.internal", wantError: true},
+		{name: "no scheme", raw: "proxy.internal:3128", wantError: true},
+		{name: "no host", raw: "http://:3128", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			proxy, err := ParseProxy(tt.raw)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.address, proxyAddress(proxy))
+		})
+	}
+}
+
+func TestNewSendsRequestsThroughProxy(t *testing.T) {
+	t.Parallel()
+
+	// A forward proxy receives the absolute URL of plain HTTP requests
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "proxied "+r.URL.String())
+	}))
+	defer proxyServer.Close()
+	proxy, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+
+	guard := NewProxyGuard()
+	transport, err := New(Config{Proxy: proxy, ProxyGuard: guard})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://crawl.invalid/page")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "proxied http://crawl.invalid/page", string(body))
+	assert.NoError(t, guard.Err())
+}
+
+func TestStrictProxyRefusesBypass(t *testing.T) {
+	t.Parallel()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+	originAddr := origin.Listener.Addr().String()
+
+	proxy, err := url.Parse("http://127.0.0.1:1")
+	require.NoError(t, err)
+
+	// A dial rule that would send the proxy connection to the origin instead
+	guard := NewProxyGuard()
+	transport, err := New(Config{
+		Proxy:      proxy,
+		ProxyGuard: guard,
+		DialRules:  []DialRule{{Host: AnyHost, Network: DialTCP, Address: originAddr}},
+	})
+	require.NoError(t, err)
+
+	_, err = (&http.Client{Transport: transport}).Get(origin.URL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrProxyBypass)
+	assert.Equal(t, []string{originAddr}, guard.Refused())
+	assert.ErrorIs(t, guard.Err(), ErrProxyBypass)
+	assert.ErrorContains(t, guard.Err(), originAddr)
+
+	_, err = New(Config{ProxyGuard: NewProxyGuard()})
+	assert.Error(t, err, "a strict proxy without a proxy URL")
+}
@@ -0,0 +1,193 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dnsPort is the port of a DNS server given without one
+const dnsPort = "53"
+
+// dohContentType is the media type of DNS-over-HTTPS messages (RFC 8484)
+const dohContentType = "application/dns-message"
+
+// maxDNSMessageBytes is the largest DNS message a DoH response may carry
+const maxDNSMessageBytes = 65535
+
+// Resolver sends DNS lookups to a chosen server instead of the system
+// resolver. The hosts file is still consulted first.
+type Resolver struct {
+	// Address is a DNS server's host:port, queried over UDP and, for
+	// truncated answers, TCP
+	Address string
+
+	// URL is a DNS-over-HTTPS endpoint, queried with POST
+	URL string
+}
+
+// ParseResolver parses a DNS server address, with or without a port, or
+// the https:// URL of a DNS-over-HTTPS endpoint
+func ParseResolver(spec string) (Resolver, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.Contains(spec, "://") {
+		endpoint, err := url.Parse(spec)
+		if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+			return Resolver{}, fmt.Errorf("invalid resolver %q: DNS-over-HTTPS endpoints must be https:// URLs", spec)
+		}
+		return Resolver{URL: spec}, nil
+	}
+
+	if ip := net.ParseIP(strings.Trim(spec, "[]")); ip != nil {
+		return Resolver{Address: net.JoinHostPort(ip.String(), dnsPort)}, nil
+	}
+	host, port, err := net.SplitHostPort(spec)
+	if err != nil || net.ParseIP(host) == nil || port == "" {
+		return Resolver{}, fmt.Errorf("invalid resolver %q: expected an IP address, address:port or https:// URL", spec)
+	}
+	return Resolver{Address: spec}, nil
+}
+
+// IsZero reports whether no resolver is set, leaving lookups to the system
+func (r Resolver) IsZero() bool {
+	return r.Address == "" && r.URL == ""
+}
+
+// String returns the DNS server address, with its port, or the
+// DNS-over-HTTPS URL that lookups go to
+func (r Resolver) String() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	return r.Address
+}
+
+// netResolver returns a resolver whose lookups go to r. Connections to the
+// DNS server, and to the DNS-over-HTTPS endpoint, are made by dialer, whose
+// own resolver is the system's.
+func (r Resolver) netResolver(dialer *net.Dialer) *net.Resolver {
+	if r.URL != "" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialer.DialContext
+		client := &http.Client{Transport: transport, Timeout: dialer.Timeout}
+
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return &dohConn{ctx: ctx, client: client, url: r.URL}, nil
+			},
+		}
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, r.Address)
+		},
+	}
+}
+
+// dohConn carries the Go resolver's queries over DNS-over-HTTPS. It is a
+// stream connection, so the resolver frames each message with a two-byte
+// length: every Write is one framed query, POSTed as it is written, and
+// Read returns the framed answer.
+type dohConn struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+
+	mu       sync.Mutex
+	deadline time.Time
+	response bytes.Reader
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	if len(b) < 2 || int(binary.BigEndian.Uint16(b)) != len(b)-2 {
+		return 0, errors.New("DNS-over-HTTPS: query is not a single framed message")
+	}
+
+	answer, err := c.exchange(b[2:])
+	if err != nil {
+		return 0, err
+	}
+
+	framed := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(answer)), uint16(len(answer)))
+	c.mu.Lock()
+	c.response.Reset(append(framed, answer...))
+	c.mu.Unlock()
+	return len(b), nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.response.Read(b)
+}
+
+// exchange POSTs a query to the endpoint and returns the answer
+func (c *dohConn) exchange(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("DNS-over-HTTPS: %w", err)
+	}
+	req.Header.Set("Content-Type", dohContentType)
+	req.Header.Set("Accept", dohContentType)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("DNS-over-HTTPS: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS: %s returned status %d", c.url, resp.StatusCode)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, maxDNSMessageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("DNS-over-HTTPS: %w", err)
+	}
+	if len(answer) > maxDNSMessageBytes {
+		return nil, fmt.Errorf("DNS-over-HTTPS: answer exceeds %d bytes", maxDNSMessageBytes)
+	}
+	return answer, nil
+}
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(time.Time) error { return nil }
+func (c *dohConn) Close() error                     { return nil }
+func (c *dohConn) LocalAddr() net.Addr              { return dohAddr(c.url) }
+func (c *dohConn) RemoteAddr() net.Addr             { return dohAddr(c.url) }
+
+// dohAddr is the address of a DNS-over-HTTPS endpoint
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
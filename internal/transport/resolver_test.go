@@ -0,0 +1,184 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testHost is a name only the test DNS servers know
+const testHost = "origin.resolver.test"
+
+// answerQuery answers A queries for testHost with 127.0.0.1 and every other
+// query with NXDOMAIN
+func answerQuery(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true},
+		Questions: []dnsmessage.Question{question},
+	}
+	switch {
+	case !strings.EqualFold(question.Name.String(), testHost+"."):
+		response.RCode = dnsmessage.RCodeNameError
+	case question.Type == dnsmessage.TypeA:
+		response.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+		}}
+	}
+
+	return response.Pack()
+}
+
+// startDNSServer serves answerQuery over UDP and returns its address and a
+// count of the queries it answered
+func startDNSServer(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	var queries atomic.Int32
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			answer, err := answerQuery(buf[:n])
+			if err != nil {
+				t.Errorf("Failed to answer DNS query: %v", err)
+				continue
+			}
+			queries.Add(1)
+			_, _ = conn.WriteTo(answer, addr)
+		}
+	}()
+	return conn.LocalAddr().String(), &queries
+}
+
+// startDoHServer serves answerQuery over DNS-over-HTTPS and returns its URL
+// and a count of the queries it answered. It serves plain HTTP, which
+// ParseResolver rejects, so that the resolver needs no test certificate.
+func startDoHServer(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+
+	var queries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dohContentType {
+			http.Error(w, "expected a POSTed DNS message", http.StatusBadRequest)
+			return
+		}
+		query, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		answer, err := answerQuery(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		queries.Add(1)
+		w.Header().Set("Content-Type", dohContentType)
+		_, _ = w.Write(answer)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/dns-query", &queries
+}
+
+func TestParseResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec      string
+		expected  Resolver
+		wantError string
+	}{
+		{spec: "10.0.0.53", expected: Resolver{Address: "10.0.0.53:53"}},
+		{spec: "10.0.0.53:5353", expected: Resolver{Address: "10.0.0.53:5353"}},
+		{spec: "2001:db8::53", expected: Resolver{Address: "[2001:db8::53]:53"}},
+		{spec: "[2001:db8::53]:5353", expected: Resolver{Address: "[2001:db8::53]:5353"}},
+		{spec: "https://dns.example/dns-query", expected: Resolver{URL: "https://dns.example/dns-query"}},
+		{spec: "dns.example", wantError: "expected an IP address"},
+		{spec: "dns.example:53", wantError: "expected an IP address"},
+		{spec: "http://dns.example/dns-query", wantError: "must be https:// URLs"},
+		{spec: "https:///dns-query", wantError: "must be https:// URLs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			resolver, err := ParseResolver(tt.spec)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resolver)
+		})
+	}
+}
+
+func TestNewResolvesThroughResolver(t *testing.T) {
+	t.Parallel()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host)
+	}))
+	t.Cleanup(origin.Close)
+	_, port, err := net.SplitHostPort(origin.Listener.Addr().String())
+	require.NoError(t, err)
+
+	dnsAddress, dnsQueries := startDNSServer(t)
+	dohURL, dohQueries := startDoHServer(t)
+
+	tests := []struct {
+		name     string
+		resolver Resolver
+		queries  *atomic.Int32
+	}{
+		{name: "DNS server", resolver: Resolver{Address: dnsAddress}, queries: dnsQueries},
+		{name: "DNS-over-HTTPS", resolver: Resolver{URL: dohURL}, queries: dohQueries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport, err := New(Config{Family: FamilyIPv4, Resolver: tt.resolver})
+			require.NoError(t, err)
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get("http://" + net.JoinHostPort(testHost, port) + "/")
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, net.JoinHostPort(testHost, port), string(body))
+			assert.Positive(t, tt.queries.Load())
+
+			_, err = client.Get("http://unknown.resolver.test:" + port + "/")
+			assert.ErrorContains(t, err, "no such host")
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+// Address families a transport can be limited to
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// familyNetworks maps address families to the dial network that forces them
+var familyNetworks = map[string]string{
+	FamilyIPv4: "tcp4",
+	FamilyIPv6: "tcp6",
+}
+
+// Config holds the options used to build the HTTP transport
+type Config struct {
+	// SourceIP is the local address requests egress from
+	SourceIP string
+	// Interface is the network interface whose address requests egress from
+	Interface string
+	// Family limits connections to IPv4 or IPv6; empty allows both
+	Family string
+
+	// ConnectTimeout and TLSHandshakeTimeout limit establishing a
+	// connection; zero keeps the defaults of 30s and 10s.
+	// ResponseHeaderTimeout limits the wait for response headers after the
+	// request is sent; zero means no limit.
+	ConnectTimeout        time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// DialRules send connections for some hosts to another address
+	DialRules []DialRule
+
+	// Resolver looks up hostnames; the zero Resolver uses the system's
+	Resolver Resolver
+
+	// Proxy sends every request through a proxy; nil uses the proxy the
+	// environment configures, if any. ProxyGuard, when set, makes it
+	// strict.
+	Proxy      *url.URL
+	ProxyGuard *ProxyGuard
+}
+
+// New creates an HTTP transport that honors the configured egress options
+func New(cfg Config) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: dialKeepAlive,
+	}
+	if cfg.ConnectTimeout > 0 {
+		dialer.Timeout = cfg.ConnectTimeout
+	}
+
+	network, ok := familyNetworks[cfg.Family]
+	if cfg.Family != "" && !ok {
+		return nil, fmt.Errorf("invalid address family: %s", cfg.Family)
+	}
+
+	sourceIP, err := SourceAddress(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if sourceIP != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: sourceIP}
+	}
+
+	// DNS queries go out over UDP as well as TCP, so they cannot bind the
+	// TCP source address
+	if !cfg.Resolver.IsZero() {
+		dialer.Resolver = cfg.Resolver.netResolver(&net.Dialer{Timeout: dialer.Timeout})
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if network != "" {
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	if cfg.Proxy != nil {
+		transport.Proxy = http.ProxyURL(cfg.Proxy)
+	}
+
+	// Unix sockets cannot bind the TCP source address
+	unixDialer := &net.Dialer{Timeout: dialer.Timeout}
+	unixDial := unixDialer.DialContext
+
+	// The guard sees the address a dial rule sends a connection to, so a
+	// rule cannot route around the proxy
+	if cfg.ProxyGuard != nil {
+		if cfg.Proxy == nil {
+			return nil, fmt.Errorf("a strict proxy requires a proxy URL")
+		}
+		proxyAddr := proxyAddress(cfg.Proxy)
+		transport.DialContext = cfg.ProxyGuard.dial(proxyAddr, transport.DialContext)
+		unixDial = cfg.ProxyGuard.dial(proxyAddr, unixDial)
+	}
+
+	transport.DialContext = withDialRules(cfg.DialRules, transport.DialContext, unixDial)
+	return transport, nil
+}
+
+// SourceAddress resolves the local address requests should egress from. It
+// returns nil when neither a source IP nor an interface is configured.
+func SourceAddress(cfg Config) (net.IP, error) {
+	if cfg.SourceIP != "" {
+		ip := net.ParseIP(cfg.SourceIP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source IP: %s", cfg.SourceIP)
+		}
+		return ip, nil
+	}
+
+	if cfg.Interface != "" {
+		return interfaceAddress(cfg.Interface, cfg.Family)
+	}
+
+	return nil, nil
+}
+
+// interfaceAddress returns the preferred unicast address of a network
+// interface, favoring IPv4 over IPv6 and skipping link-local addresses. With
+// a family, only addresses of that family are considered.
+func interfaceAddress(name, family string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses of interface %s: %w", name, err)
+	}
+
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() || !inFamily(ipNet.IP, family) {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+
+	if fallback == nil && family != "" {
+		return nil, fmt.Errorf("interface %s has no usable %s address", name, family)
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("interface %s has no usable address", name)
+	}
+	return fallback, nil
+}
+
+// inFamily reports whether ip belongs to family; every address belongs to
+// the empty family
+func inFamily(ip net.IP, family string) bool {
+	switch family {
+	case FamilyIPv4:
+		return ip.To4() != nil
+	case FamilyIPv6:
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		config    Config
+		expected  net.IP
+		wantError bool
+	}{
+		{name: "no egress options", config: Config{}, expected: nil},
+		{name: "source IPv4", config: Config{SourceIP: "127.0.0.1"}, expected: net.ParseIP("127.0.0.1")},
+		{name: "source IPv6", config: Config{SourceIP: "::1"}, expected: net.ParseIP("::1")},
+		{name: "invalid source IP", config: Config{SourceIP: "not-an-ip"}, wantError: true},
+		{name: "unknown interface", config: Config{Interface: "does-not-exist0"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ip, err := SourceAddress(tt.config)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(ip), "expected %v, got %v", tt.expected, ip)
+		})
+	}
+}
+
+func TestNewBindsSourceAddress(t *testing.T) {
+	t.Parallel()
+
+	var remoteAddr string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+	defer server.Close()
+
+	transport, err := New(Config{SourceIP: "127.0.0.1"})
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", host)
+}
+
+func TestNewLimitsAddressFamily(t *testing.T) {
+	t.Parallel()
+
+	// The test server only listens on IPv4
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		family    string
+		wantError bool
+	}{
+		{family: ""},
+		{family: FamilyIPv4},
+		{family: FamilyIPv6, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("family "+tt.family, func(t *testing.T) {
+			t.Parallel()
+
+			transport, err := New(Config{Family: tt.family})
+			require.NoError(t, err)
+
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(server.URL)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		})
+	}
+
+	_, err := New(Config{Family: "ipx"})
+	assert.ErrorContains(t, err, "invalid address family")
+}
+
+func TestNewSetsPhaseTimeouts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      Config
+		wantTLS     time.Duration
+		wantHeaders time.Duration
+	}{
+		{name: "defaults", config: Config{}, wantTLS: 10 * time.Second},
+		{
+			name:        "configured",
+			config:      Config{ConnectTimeout: time.Second, TLSHandshakeTimeout: 2 * time.Second, ResponseHeaderTimeout: 3 * time.Second},
+			wantTLS:     2 * time.Second,
+			wantHeaders: 3 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport, err := New(tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTLS, transport.TLSHandshakeTimeout)
+			assert.Equal(t, tt.wantHeaders, transport.ResponseHeaderTimeout)
+		})
+	}
+}
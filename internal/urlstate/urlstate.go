@@ -0,0 +1,297 @@
+// Package urlstate tracks the state of every sitemap URL during a run, so
+// that operators can ask whether a given URL has been crawled yet without
+// waiting for the final report
+package urlstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+)
+
+// Paths served by Handler
+const (
+	StatusPath = "/status"
+	URLsPath   = "/status/urls"
+)
+
+// Page sizes for URL queries
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// State is where a URL is in the crawl
+type State string
+
+// URL states
+const (
+	Pending  State = "pending"
+	InFlight State = "in_flight"
+	Done     State = "done"
+)
+
+// Entry is the state of one URL
+type Entry struct {
+	URL   string `json:"url"`
+	State State  `json:"state"`
+
+	// StatusCode, Success, Error and Attempts describe the URL's last
+	// completed crawl
+	StatusCode int    `json:"status_code,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+
+	// Crawls is how many times the URL has been crawled; repeated passes
+	// crawl it again
+	Crawls int `json:"crawls"`
+
+	// Updated is when the URL last changed state; zero while pending
+	Updated time.Time `json:"updated,omitzero"`
+}
+
+// Counts summarizes the URLs by state
+type Counts struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	InFlight  int `json:"in_flight"`
+	Done      int `json:"done"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// Query selects a page of URLs
+type Query struct {
+	// State keeps only URLs in the state, when set
+	State State
+
+	// Path keeps only URLs with exactly the path, e.g. /pricing
+	Path string
+
+	// Contains keeps only URLs containing the text
+	Contains string
+
+	Offset int
+	Limit  int
+}
+
+// Page is one page of a query's results
+type Page struct {
+	URLs  []Entry `json:"urls"`
+	Total int     `json:"total"`
+
+	// NextOffset is the offset of the next page; zero on the last page
+	NextOffset int `json:"next_offset,omitempty"`
+}
+
+// Tracker records the state of each URL. A nil Tracker ignores updates, so
+// callers need not check whether tracking is enabled.
+type Tracker struct {
+	mu      sync.RWMutex
+	entries []*Entry
+	index   map[string]*Entry
+	now     func() time.Time
+}
+
+// NewTracker creates a tracker with no URLs
+func NewTracker() *Tracker {
+	return &Tracker{index: make(map[string]*Entry), now: time.Now}
+}
+
+// Add tracks URLs, in sitemap order, as pending. URLs already tracked keep
+// their state.
+func (t *Tracker) Add(urls []string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, u := range urls {
+		if _, ok := t.index[u]; ok {
+			continue
+		}
+		entry := &Entry{URL: u, State: Pending}
+		t.entries = append(t.entries, entry)
+		t.index[u] = entry
+	}
+}
+
+// Start marks a URL in flight. The results of its last crawl are kept until
+// the new one finishes.
+func (t *Tracker) Start(u string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.index[u]; ok {
+		entry.State = InFlight
+		entry.Updated = t.now()
+	}
+}
+
+// Finish marks the result's URL done with the result's outcome
+func (t *Tracker) Finish(result *stats.Result) {
+	if t == nil || result == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.index[result.URL]
+	if !ok {
+		return
+	}
+	entry.State = Done
+	entry.StatusCode = result.StatusCode
+	entry.Success = result.Success
+	entry.Error = result.Error
+	entry.Attempts = max(result.Attempts, 1)
+	entry.Crawls++
+	entry.Updated = t.now()
+}
+
+// Counts returns how many URLs are in each state
+func (t *Tracker) Counts() Counts {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	counts := Counts{Total: len(t.entries)}
+	for _, entry := range t.entries {
+		switch entry.State {
+		case Pending:
+			counts.Pending++
+		case InFlight:
+			counts.InFlight++
+		case Done:
+			counts.Done++
+		}
+		if entry.Crawls > 0 {
+			if entry.Success {
+				counts.Succeeded++
+			} else {
+				counts.Failed++
+			}
+		}
+	}
+	return counts
+}
+
+// Query returns the page of URLs the query selects, in sitemap order
+func (t *Tracker) Query(q Query) Page {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	page := Page{URLs: []Entry{}}
+	for _, entry := range t.entries {
+		if !q.matches(entry) {
+			continue
+		}
+		if page.Total >= q.Offset && len(page.URLs) < limit {
+			page.URLs = append(page.URLs, *entry)
+		}
+		page.Total++
+	}
+	if next := q.Offset + limit; next < page.Total {
+		page.NextOffset = next
+	}
+	return page
+}
+
+// matches reports whether the query selects an entry
+func (q Query) matches(entry *Entry) bool {
+	if q.State != "" && entry.State != q.State {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(entry.URL, q.Contains) {
+		return false
+	}
+	if q.Path != "" {
+		parsed, err := url.Parse(entry.URL)
+		if err != nil || parsed.Path != q.Path {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler serves StatusPath, a summary of the URLs by state, and URLsPath,
+// the URLs themselves. URLsPath takes the query parameters state, path, q
+// (a substring of the URL), offset and limit.
+func (t *Tracker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, t.Counts())
+	})
+	mux.HandleFunc(URLsPath, func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQuery(r.URL.Query())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, t.Query(q))
+	})
+	return mux
+}
+
+// parseQuery reads a query from URL parameters
+func parseQuery(values url.Values) (Query, error) {
+	q := Query{
+		State:    State(values.Get("state")),
+		Path:     values.Get("path"),
+		Contains: values.Get("q"),
+	}
+	switch q.State {
+	case "", Pending, InFlight, Done:
+	default:
+		return Query{}, fmt.Errorf("state must be %s, %s or %s", Pending, InFlight, Done)
+	}
+
+	var err error
+	if q.Offset, err = nonNegative(values, "offset"); err != nil {
+		return Query{}, err
+	}
+	if q.Limit, err = nonNegative(values, "limit"); err != nil {
+		return Query{}, err
+	}
+	return q, nil
+}
+
+// nonNegative reads an optional non-negative integer parameter
+func nonNegative(values url.Values, name string) (int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(body)
+}
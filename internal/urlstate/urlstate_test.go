@@ -0,0 +1,160 @@
+package urlstate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benvon/sitemap-crawler/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracked returns a tracker of the URLs, with the first crawled, the second
+// in flight and the rest pending
+func tracked(urls ...string) *Tracker {
+	tracker := NewTracker()
+	tracker.Add(urls)
+	tracker.Start(urls[0])
+	tracker.Finish(&stats.Result{URL: urls[0], Success: true, StatusCode: 200, Attempts: 2})
+	tracker.Start(urls[1])
+	return tracker
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := tracked("https://example.com/", "https://example.com/pricing", "https://example.com/about", "https://example.com/")
+
+	assert.Equal(t, Counts{Total: 3, Pending: 1, InFlight: 1, Done: 1, Succeeded: 1}, tracker.Counts())
+
+	page := tracker.Query(Query{})
+	require.Len(t, page.URLs, 3)
+	assert.Equal(t, "https://example.com/", page.URLs[0].URL)
+	assert.Equal(t, Done, page.URLs[0].State)
+	assert.Equal(t, 200, page.URLs[0].StatusCode)
+	assert.Equal(t, 2, page.URLs[0].Attempts)
+	assert.Equal(t, 1, page.URLs[0].Crawls)
+	assert.Equal(t, InFlight, page.URLs[1].State)
+	assert.Equal(t, Pending, page.URLs[2].State)
+	assert.True(t, page.URLs[2].Updated.IsZero())
+
+	// A later pass keeps the last result until the new one finishes
+	tracker.Start("https://example.com/")
+	entry := tracker.Query(Query{Path: "/"}).URLs[0]
+	assert.Equal(t, InFlight, entry.State)
+	assert.Equal(t, 200, entry.StatusCode)
+	tracker.Finish(&stats.Result{URL: "https://example.com/", StatusCode: 503, Error: "HTTP 503"})
+	entry = tracker.Query(Query{Path: "/"}).URLs[0]
+	assert.Equal(t, 2, entry.Crawls)
+	assert.Equal(t, 1, entry.Attempts)
+	assert.False(t, entry.Success)
+	assert.Equal(t, Counts{Total: 3, Pending: 1, InFlight: 1, Done: 1, Failed: 1}, tracker.Counts())
+
+	// Unknown URLs are ignored
+	tracker.Finish(&stats.Result{URL: "https://example.com/missing"})
+	assert.Equal(t, 3, tracker.Counts().Total)
+
+	// Adding URLs again keeps their state
+	tracker.Add([]string{"https://example.com/", "https://example.com/new"})
+	assert.Equal(t, Counts{Total: 4, Pending: 2, InFlight: 1, Done: 1, Failed: 1}, tracker.Counts())
+}
+
+func TestNilTracker(t *testing.T) {
+	t.Parallel()
+
+	var tracker *Tracker
+	assert.NotPanics(t, func() {
+		tracker.Add([]string{"https://example.com/"})
+		tracker.Start("https://example.com/")
+		tracker.Finish(&stats.Result{URL: "https://example.com/"})
+	})
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	tracker := tracked("https://example.com/", "https://example.com/pricing", "https://example.com/pricing/teams", "https://example.com/about", "https://example.com/blog")
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []string
+		total    int
+		next     int
+	}{
+		{name: "state", query: Query{State: Pending}, expected: []string{"https://example.com/pricing/teams", "https://example.com/about", "https://example.com/blog"}, total: 3},
+		{name: "exact path", query: Query{Path: "/pricing"}, expected: []string{"https://example.com/pricing"}, total: 1},
+		{name: "substring", query: Query{Contains: "pricing"}, expected: []string{"https://example.com/pricing", "https://example.com/pricing/teams"}, total: 2},
+		{name: "first page", query: Query{Limit: 2}, expected: []string{"https://example.com/", "https://example.com/pricing"}, total: 5, next: 2},
+		{name: "middle page", query: Query{Offset: 2, Limit: 2}, expected: []string{"https://example.com/pricing/teams", "https://example.com/about"}, total: 5, next: 4},
+		{name: "last page", query: Query{Offset: 4, Limit: 2}, expected: []string{"https://example.com/blog"}, total: 5},
+		{name: "past the end", query: Query{Offset: 10}, expected: []string{}, total: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := tracker.Query(tt.query)
+			urls := make([]string, len(page.URLs))
+			for i, entry := range page.URLs {
+				urls[i] = entry.URL
+			}
+			assert.Equal(t, tt.expected, urls)
+			assert.Equal(t, tt.total, page.Total)
+			assert.Equal(t, tt.next, page.NextOffset)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	tracker := tracked("https://example.com/", "https://example.com/pricing", "https://example.com/about")
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+		urls     int
+	}{
+		{name: "urls", target: URLsPath, expected: http.StatusOK, urls: 3},
+		{name: "filtered", target: URLsPath + "?path=/pricing&state=in_flight", expected: http.StatusOK, urls: 1},
+		{name: "paginated", target: URLsPath + "?offset=1&limit=1", expected: http.StatusOK, urls: 1},
+		{name: "bad state", target: URLsPath + "?state=queued", expected: http.StatusBadRequest},
+		{name: "bad limit", target: URLsPath + "?limit=-1", expected: http.StatusBadRequest},
+		{name: "bad offset", target: URLsPath + "?offset=first", expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			tracker.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			assert.Equal(t, tt.expected, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			if tt.expected != http.StatusOK {
+				return
+			}
+
+			var page Page
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+			assert.Len(t, page.URLs, tt.urls)
+		})
+	}
+
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := httptest.NewRecorder()
+		tracker.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, StatusPath, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var counts Counts
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &counts))
+		assert.Equal(t, Counts{Total: 3, Pending: 1, InFlight: 1, Done: 1, Succeeded: 1}, counts)
+	})
+}
@@ -1,59 +1,202 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/benvon/sitemap-crawler/internal/testserver"
 )
 
 func main() {
-	// Get the examples directory
-	examplesDir := "./examples"
-	if _, err := os.Stat(examplesDir); os.IsNotExist(err) {
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	examplesDir := flag.String("examples", "./examples", "Directory with example sitemaps to serve")
+	pages := flag.Int("pages", testserver.DefaultPages, "Number of pages listed in /local-sitemap.xml")
+	cache := flag.Bool("cache", false, "Simulate a caching layer (first request MISS, then HIT)")
+	cacheTTL := flag.Duration("cache-ttl", 5*time.Minute, "Simulated cache TTL (0 = never expire)")
+	cacheMissDelay := flag.Duration("cache-miss-delay", 200*time.Millisecond, "Extra delay for cache misses")
+	cacheHitDelay := flag.Duration("cache-hit-delay", 5*time.Millisecond, "Extra delay for cache hits")
+	cacheHeader := flag.String("cache-header", testserver.DefaultCacheHeader, "Header carrying the simulated cache status")
+	rateLimit := flag.Float64("rate-limit", 0, "Answer requests above this rate (per second) with 429 (0 = unlimited)")
+	rateLimitBurst := flag.Int("rate-limit-burst", 0, "Rate limit bucket size (0 = one second of requests)")
+	retryAfter := flag.Duration("retry-after", 0, "Fixed Retry-After for 429 responses (0 = time until the next token)")
+	tlsAddr := flag.String("tls-addr", "", "Also serve HTTPS with a generated certificate on this address (e.g. :8443)")
+	tlsClientAuth := flag.Bool("tls-client-auth", false, "Require a client certificate signed by the generated CA on the HTTPS listener")
+	tlsCertDir := flag.String("tls-cert-dir", "", "Directory to write the generated CA, server, and client PEM files (default: a temp dir)")
+	seed := flag.Uint64("seed", 0, "Seed for probabilistic behavior (0 = random)")
+	scenario := flag.String("scenario", "", "Load pages, faults, latencies, cache, rate limit, and scripted routes from a YAML scenario file")
+
+	var faults []testserver.Fault
+	flag.Func("fault", "Inject a fault, e.g. 'status=503,probability=0.1' or 'reset,every=10,burst=2,path=/pages/' (repeatable)", func(spec string) error {
+		fault, err := testserver.ParseFault(spec)
+		if err != nil {
+			return err
+		}
+		faults = append(faults, fault)
+		return nil
+	})
+	var latencies []testserver.Latency
+	flag.Func("latency", "Delay responses, e.g. 'base=50ms,jitter=20ms' or 'path=/pages/,ramp=10ms,ramp-every=5,max=3s' (repeatable)", func(spec string) error {
+		latency, err := testserver.ParseLatency(spec)
+		if err != nil {
+			return err
+		}
+		latencies = append(latencies, latency)
+		return nil
+	})
+	flag.Parse()
+
+	if _, err := os.Stat(*examplesDir); os.IsNotExist(err) {
 		log.Fatal("Examples directory not found")
 	}
 
-	// Create file server
-	fs := http.FileServer(http.Dir(examplesDir))
-
-	// Handle root to show available files
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
-			// Combine all HTML into a single write to avoid multiple error checks
-			html := `<h1>Sitemap Test Server</h1>
-<p>Available sitemaps:</p>
-<ul>
-<li><a href='/sample-sitemap.xml'>Sample Sitemap</a></li>
-<li><a href='/sitemap-index.xml'>Sitemap Index</a></li>
-<li><a href='/plain-sitemap.txt'>Plain Text Sitemap</a></li>
-</ul>`
-			if _, err := fmt.Fprint(w, html); err != nil {
-				http.Error(w, "Failed to write response", http.StatusInternalServerError)
-				return
+	var config testserver.Config
+	if *scenario != "" {
+		if name := behaviorFlagSet(); name != "" {
+			log.Fatalf("--%s cannot be combined with --scenario; set it in the scenario file instead", name)
+		}
+		loaded, err := testserver.LoadScenario(*scenario)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config = loaded
+	} else {
+		var cacheConfig *testserver.Cache
+		if *cache {
+			cacheConfig = &testserver.Cache{
+				Header:    *cacheHeader,
+				TTL:       *cacheTTL,
+				MissDelay: *cacheMissDelay,
+				HitDelay:  *cacheHitDelay,
 			}
-			return
 		}
-		fs.ServeHTTP(w, r)
-	})
 
-	port := ":8080"
-	fmt.Printf("Starting test server on http://localhost%s\n", port)
+		var rateLimitConfig *testserver.RateLimit
+		if *rateLimit > 0 {
+			rateLimitConfig = &testserver.RateLimit{
+				Rate:       *rateLimit,
+				Burst:      *rateLimitBurst,
+				RetryAfter: *retryAfter,
+			}
+		}
+
+		config = testserver.Config{
+			Pages:     *pages,
+			Faults:    faults,
+			Latencies: latencies,
+			Cache:     cacheConfig,
+			RateLimit: rateLimitConfig,
+			Seed:      *seed,
+		}
+	}
+	config.ExamplesDir = *examplesDir
+
+	server := testserver.New(config)
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	baseURL := listenURL("http", listener.Addr())
+
+	fmt.Printf("Starting test server on %s\n", baseURL)
 	fmt.Printf("Available sitemaps:\n")
-	fmt.Printf("  - http://localhost%s/sample-sitemap.xml\n", port)
-	fmt.Printf("  - http://localhost%s/sitemap-index.xml\n", port)
-	fmt.Printf("  - http://localhost%s/plain-sitemap.txt\n", port)
+	fmt.Printf("  - %s/local-sitemap.xml\n", baseURL)
+	fmt.Printf("  - %s/generate/sitemap?urls=1000&children=10\n", baseURL)
+	fmt.Printf("  - %s/sample-sitemap.xml\n", baseURL)
+	fmt.Printf("  - %s/sitemap-index.xml\n", baseURL)
+	fmt.Printf("  - %s/plain-sitemap.txt\n", baseURL)
+	if *scenario != "" {
+		fmt.Printf("Loaded scenario %s (%d scripted route(s))\n", *scenario, len(config.Routes))
+	}
+	if len(config.Faults) > 0 {
+		fmt.Printf("Injecting %d fault rule(s)\n", len(config.Faults))
+	}
 	fmt.Printf("\nPress Ctrl+C to stop\n")
 
-	// Create server with proper timeouts to address G114
-	server := &http.Server{
-		Addr:         port,
-		Handler:      nil, // Use DefaultServeMux
+	if *tlsAddr != "" {
+		go serveTLS(server.Handler(), *tlsAddr, *tlsCertDir, *tlsClientAuth)
+	}
+
+	log.Fatal(newHTTPServer(*addr, server.Handler()).Serve(listener))
+}
+
+// listenURL returns the URL a listener is reached at, with the port it was
+// given. An unspecified host, as in ":8080", is shown as localhost.
+func listenURL(scheme string, addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return scheme + "://" + addr.String()
+	}
+	host := "localhost"
+	if tcpAddr.IP != nil && !tcpAddr.IP.IsUnspecified() {
+		host = tcpAddr.IP.String()
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(tcpAddr.Port))
+}
+
+// behaviorFlagSet returns the name of an explicitly set flag that a scenario
+// file would otherwise override, or "" if there is none
+func behaviorFlagSet() string {
+	var name string
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr", "examples", "scenario", "tls-addr", "tls-client-auth", "tls-cert-dir":
+		default:
+			if name == "" {
+				name = f.Name
+			}
+		}
+	})
+	return name
+}
+
+// newHTTPServer creates a server with proper timeouts to address G114
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+}
+
+// serveTLS generates certificates, writes them to certDir, and serves HTTPS
+func serveTLS(handler http.Handler, addr, certDir string, clientAuth bool) {
+	certs, err := testserver.GenerateCertificates([]string{"localhost", "127.0.0.1", "::1"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if certDir == "" {
+		if certDir, err = os.MkdirTemp("", "sitemap-test-server-"); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := certs.WriteFiles(certDir); err != nil {
+		log.Fatal(err)
+	}
+
+	tlsConfig, err := certs.TLSConfig(clientAuth)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Serving HTTPS on %s (client certificates required: %t)\n", listenURL("https", listener.Addr()), clientAuth)
+	fmt.Printf("Certificates written to %s (ca.pem, client.pem, client-key.pem)\n", certDir)
 
-	log.Fatal(server.ListenAndServe())
+	httpsServer := newHTTPServer(addr, handler)
+	httpsServer.TLSConfig = tlsConfig
+	log.Fatal(httpsServer.ServeTLS(listener, "", ""))
 }